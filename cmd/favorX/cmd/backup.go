@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/localstore"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/node"
+	"github.com/FavorLabs/favorX/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+const (
+	optionNameBase  = "base"
+	optionNameSince = "since"
+	optionNameRoot  = "root"
+)
+
+func (c *command) initBackupCmd() {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up and restore the chunks and the state of a node",
+	}
+
+	c.backupCreateCmd(cmd)
+	c.backupRestoreCmd(cmd)
+
+	c.root.AddCommand(cmd)
+}
+
+func (c *command) backupCreateCmd(cmd *cobra.Command) {
+	bc := &cobra.Command{
+		Use:   "create <filename>",
+		Short: "Back up the localstore and the statestore to a file. Use \"-\" as filename in order to write to STDOUT",
+		Long: `Back up the localstore and the statestore to a file.
+
+The backup holds the chunks with their pins and the entries of every statestore
+namespace: file views and mirrors, chunk bit vectors, the address book, cheques
+and the other node records. The node must not be running.
+
+An incremental backup holds only the chunks stored after the backup given with
+--base, or after the time given with --since. The statestore is always backed
+up in full. With --root only the chunk trees of the given roots and their
+statestore entries are backed up.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			if len(args) != 1 {
+				return cmd.Help()
+			}
+			if args[0] == "-" {
+				// keep the logs out of the backup
+				cmd.SetOut(cmd.ErrOrStderr())
+			}
+			logger, err := backupLogger(cmd)
+			if err != nil {
+				return err
+			}
+			base, err := cmd.Flags().GetString(optionNameBase)
+			if err != nil {
+				return fmt.Errorf("get base: %v", err)
+			}
+			since, err := cmd.Flags().GetString(optionNameSince)
+			if err != nil {
+				return fmt.Errorf("get since: %v", err)
+			}
+			if base != "" && since != "" {
+				return fmt.Errorf("only one of --%s and --%s can be given", optionNameBase, optionNameSince)
+			}
+			roots, err := cmd.Flags().GetStringSlice(optionNameRoot)
+			if err != nil {
+				return fmt.Errorf("get root: %v", err)
+			}
+
+			var o localstore.BackupOptions
+			for _, r := range roots {
+				root, err := boson.ParseHexAddress(r)
+				if err != nil {
+					return fmt.Errorf("invalid root %s: %w", r, err)
+				}
+				o.Roots = append(o.Roots, root)
+			}
+			if since != "" {
+				t, err := time.Parse(time.RFC3339, since)
+				if err != nil {
+					return fmt.Errorf("invalid since time: %w", err)
+				}
+				o.Since = &localstore.Watermark{Timestamp: t.UnixNano()}
+			}
+			var baseOverlay boson.Address
+			if base != "" {
+				f, err := os.Open(base)
+				if err != nil {
+					return fmt.Errorf("open base backup: %w", err)
+				}
+				m, err := localstore.ReadBackupManifest(f)
+				_ = f.Close()
+				if err != nil {
+					return fmt.Errorf("read base backup: %w", err)
+				}
+				o.Since = &m.Watermark
+				baseOverlay = m.Overlay
+			}
+
+			stateStore, storer, err := c.openBackupStores(cmd, logger, false, boson.ZeroAddress)
+			if err != nil {
+				return err
+			}
+			defer closeBackupStores(logger, stateStore, storer)
+			if !baseOverlay.IsZero() {
+				overlay, err := node.StoredOverlay(stateStore)
+				if err != nil {
+					return fmt.Errorf("statestore overlay: %w", err)
+				}
+				if !baseOverlay.Equal(overlay) {
+					return fmt.Errorf("base backup is of node %s, not of node %s", baseOverlay, overlay)
+				}
+			}
+
+			var out io.Writer
+			if args[0] == "-" {
+				out = os.Stdout
+			} else {
+				f, err := os.Create(args[0])
+				if err != nil {
+					return fmt.Errorf("error opening output file: %s", err)
+				}
+				defer func() {
+					if cerr := f.Close(); err == nil {
+						err = cerr
+					}
+					if err != nil {
+						_ = os.Remove(args[0])
+					}
+				}()
+				out = f
+			}
+
+			m, err := storer.Backup(cmd.Context(), out, stateStore, o)
+			if err != nil {
+				return fmt.Errorf("error backing up node: %w", err)
+			}
+			logger.Infof("backed up %d chunks and %d state entries of node %s", m.Chunks, m.State, m.Overlay)
+			return nil
+		},
+	}
+	bc.Flags().String(optionNameDataDir, "", "data directory")
+	bc.Flags().String(optionDatabasePath, "", "localstore directory if it is not in the data directory")
+	bc.Flags().String(optionNameBase, "", "previous backup to make an incremental backup of")
+	bc.Flags().String(optionNameSince, "", "make an incremental backup of the chunks stored after this RFC3339 time")
+	bc.Flags().StringSlice(optionNameRoot, nil, "back up only the chunk trees of these root addresses")
+	bc.Flags().String(optionNamePassword, "", "password the databases are encrypted with")
+	bc.Flags().String(optionNamePasswordFile, "", "path to a file that contains the password the databases are encrypted with")
+	bc.Flags().String(optionNameVerbosity, "info", "verbosity level")
+	cmd.AddCommand(bc)
+}
+
+func (c *command) backupRestoreCmd(cmd *cobra.Command) {
+	rc := &cobra.Command{
+		Use:   "restore <filename>",
+		Short: "Restore a backup to the localstore and the statestore. Use \"-\" as filename in order to feed from STDIN",
+		Long: `Restore a backup to the localstore and the statestore.
+
+Chunks the localstore already holds are kept. Statestore entries are replaced
+by the ones in the backup. A backup of a whole node can only be restored to a
+new data directory or to the data directory of the same node. Incremental
+backups are restored in order after the backup they are based on. The node must
+not be running.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			if len(args) != 1 {
+				return cmd.Help()
+			}
+			logger, err := backupLogger(cmd)
+			if err != nil {
+				return err
+			}
+			encrypt, err := cmd.Flags().GetBool(optionDatabaseEncryption)
+			if err != nil {
+				return fmt.Errorf("get db-encryption: %v", err)
+			}
+
+			var in io.Reader
+			if args[0] == "-" {
+				in = os.Stdin
+			} else {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return fmt.Errorf("error opening input file: %s", err)
+				}
+				defer f.Close()
+				in = f
+			}
+			r, err := localstore.NewBackupReader(in)
+			if err != nil {
+				return fmt.Errorf("error reading backup: %w", err)
+			}
+			h := r.Header()
+			logger.Infof("restoring backup of node %s created at %s", h.Overlay, h.Created.Format(time.RFC3339))
+
+			stateStore, storer, err := c.openBackupStores(cmd, logger, encrypt, h.Overlay)
+			if err != nil {
+				return err
+			}
+			defer closeBackupStores(logger, stateStore, storer)
+
+			res, err := storer.Restore(cmd.Context(), r, stateStore)
+			if err != nil {
+				return fmt.Errorf("error restoring backup: %w", err)
+			}
+			logger.Infof("restored %d chunks and %d state entries, %d chunks were already stored", res.Chunks, res.State, res.Skipped)
+			return nil
+		},
+	}
+	rc.Flags().String(optionNameDataDir, "", "data directory")
+	rc.Flags().String(optionDatabasePath, "", "localstore directory if it is not in the data directory")
+	rc.Flags().Uint64(optionNameCacheCapacity, 80000, fmt.Sprintf("cache capacity in chunks, multiply by %d to get approximate capacity in bytes", boson.ChunkSize))
+	rc.Flags().Bool(optionDatabaseEncryption, false, "encrypt new databases at rest with keys sealed by the password")
+	rc.Flags().String(optionNamePassword, "", "password the databases are encrypted with")
+	rc.Flags().String(optionNamePasswordFile, "", "path to a file that contains the password the databases are encrypted with")
+	rc.Flags().String(optionNameVerbosity, "info", "verbosity level")
+	cmd.AddCommand(rc)
+}
+
+func backupLogger(cmd *cobra.Command) (logging.Logger, error) {
+	v, err := cmd.Flags().GetString(optionNameVerbosity)
+	if err != nil {
+		return nil, fmt.Errorf("get verbosity: %v", err)
+	}
+	logger, err := newLogger(cmd, strings.ToLower(v))
+	if err != nil {
+		return nil, fmt.Errorf("new logger: %v", err)
+	}
+	return logger, nil
+}
+
+// openBackupStores opens the statestore and the localstore of the data
+// directory. The localstore is opened with the overlay kept in the statestore,
+// or with the given one if there is none. New databases are only created if an
+// overlay is given, and encrypted if encrypt is set.
+func (c *command) openBackupStores(cmd *cobra.Command, logger logging.Logger, encrypt bool, overlay boson.Address) (storage.StateStorer, *localstore.DB, error) {
+	dataDir, err := cmd.Flags().GetString(optionNameDataDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get data-dir: %v", err)
+	}
+	if dataDir == "" {
+		return nil, nil, errors.New("no data-dir provided")
+	}
+	dbPath, err := cmd.Flags().GetString(optionDatabasePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get db-path: %v", err)
+	}
+	capacity := uint64(0)
+	if cmd.Flags().Lookup(optionNameCacheCapacity) != nil {
+		if capacity, err = cmd.Flags().GetUint64(optionNameCacheCapacity); err != nil {
+			return nil, nil, fmt.Errorf("get cache-capacity: %v", err)
+		}
+	}
+
+	statePath := filepath.Join(dataDir, "statestore")
+	path := filepath.Join(dataDir, "localstore")
+	if dbPath != "" {
+		path = dbPath
+	}
+	if overlay.IsZero() {
+		if _, err := os.Stat(statePath); err != nil {
+			return nil, nil, fmt.Errorf("statestore: %w", err)
+		}
+	}
+
+	var password string
+	if encrypt || checkKeyring(statePath) == nil || checkKeyring(path) == nil {
+		if password, err = c.dbPasswordFlag(cmd); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	stateStore, err := node.InitStateStore(logger, dataDir, password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("statestore: %w", err)
+	}
+
+	stored, err := node.StoredOverlay(stateStore)
+	switch {
+	case err == nil:
+		overlay = stored
+	case !errors.Is(err, storage.ErrNotFound) || overlay.IsZero():
+		_ = stateStore.Close()
+		return nil, nil, fmt.Errorf("statestore overlay: %w", err)
+	}
+
+	storer, err := localstore.New(path, overlay.Bytes(), stateStore, &localstore.Options{
+		Driver:   driver,
+		Password: password,
+		Capacity: capacity,
+	}, logger)
+	if err != nil {
+		_ = stateStore.Close()
+		return nil, nil, fmt.Errorf("localstore: %w", err)
+	}
+	return stateStore, storer, nil
+}
+
+func closeBackupStores(logger logging.Logger, stateStore storage.StateStorer, storer *localstore.DB) {
+	if err := storer.Close(); err != nil {
+		logger.Errorf("close localstore: %v", err)
+	}
+	if err := stateStore.Close(); err != nil {
+		logger.Errorf("close statestore: %v", err)
+	}
+}
@@ -3,13 +3,14 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/FavorLabs/favorX/pkg/address"
 	"github.com/FavorLabs/favorX/pkg/boson"
 	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/provider"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -20,6 +21,7 @@ const (
 	optionNameCacheCapacity         = "cache-capacity"
 	optionDatabaseDriver            = "db-driver"
 	optionDatabasePath              = "db-path"
+	optionDatabaseEncryption        = "db-encryption"
 	optionNamePassword              = "password"
 	optionNamePasswordFile          = "password-file"
 	optionNameHTTPAddr              = "http-addr"
@@ -33,6 +35,7 @@ const (
 	optionNameDebugAPIAddr          = "debug-api-addr"
 	optionNameBootnodes             = "bootnode"
 	optionNameChainEndpoint         = "chain-endpoint"
+	optionNameDevChain              = "dev-chain"
 	optionNameOracleContractAddr    = "oracle-contract-addr"
 	optionNameNetworkID             = "network-id"
 	optionWelcomeMessage            = "welcome-message"
@@ -51,6 +54,20 @@ const (
 	optionNameGatewayMode           = "gateway-mode"
 	optionNameTrafficContractAddr   = "traffic-contract-addr"
 	optionNameTrafficEnable         = "traffic-enable"
+	optionNameSettlementChains      = "settlement-chains"
+	optionNameOracleIndexInterval   = "oracle-index-interval"
+	optionNameOracleIndexStart      = "oracle-index-start-block"
+	optionNameOracleIndexConfirm    = "oracle-index-confirmations"
+	optionNameProviderTTL           = "provider-ttl"
+	optionNameChallengeInterval     = "storage-challenge-interval"
+	optionNameChallengeBlocklist    = "storage-challenge-blocklist-after"
+	optionNameCashoutInterval       = "cashout-interval"
+	optionNameCashoutMinProfit      = "cashout-min-profit"
+	optionNameCashoutGasRate        = "cashout-gas-rate"
+	optionNameCashoutGasBudget      = "cashout-daily-gas-budget"
+	optionNamePriceChunk            = "price-chunk"
+	optionNamePriceByte             = "price-byte"
+	optionNameRetrievalPriceWeight  = "retrieval-price-weight"
 	optionNameBinMaxPeers           = "bin-max-peers"
 	optionNameLightMaxPeers         = "light-max-peers"
 	optionNameAllowPrivateCIDRs     = "allow-private-cidrs"
@@ -137,6 +154,7 @@ func newCommand(opts ...option) (c *command, err error) {
 
 	c.initVersionCmd()
 	c.initDBCmd()
+	c.initBackupCmd()
 
 	if err := c.initConfigurateOptionsCmd(); err != nil {
 		return nil, err
@@ -209,8 +227,9 @@ func (c *command) setHomeDir() (err error) {
 func (c *command) setAllFlags(cmd *cobra.Command) {
 	cmd.Flags().String(optionNameDataDir, filepath.Join(c.homeDir, ".favorX"), "data directory")
 	cmd.Flags().Uint64(optionNameCacheCapacity, 80000, fmt.Sprintf("cache capacity in chunks, multiply by %d to get approximate capacity in bytes", boson.ChunkSize))
-	cmd.Flags().String(optionDatabaseDriver, driver, "database storage driver, only support leveldb/wiredtiger")
+	cmd.Flags().String(optionDatabaseDriver, driver, "database storage driver, only support leveldb/wiredtiger/bbolt")
 	cmd.Flags().String(optionDatabasePath, "", "if the path not empty, all chunks will be stored at this directory")
+	cmd.Flags().Bool(optionDatabaseEncryption, false, "encrypt the localstore and statestore at rest with keys sealed by the password")
 	cmd.Flags().String(optionNamePassword, "", "password for decrypting keys")
 	cmd.Flags().String(optionNamePasswordFile, "", "path to a file that contains password for decrypting keys")
 	// cmd.Flags().String(optionNameHTTPAddr, ":1636", "HTTP json-rpc listen address")
@@ -222,6 +241,7 @@ func (c *command) setAllFlags(cmd *cobra.Command) {
 	cmd.Flags().Bool(optionNameP2PQUICEnable, false, "enable P2P QUIC transport")
 	cmd.Flags().StringSlice(optionNameBootnodes, []string{}, "initial nodes to connect to")
 	cmd.Flags().String(optionNameChainEndpoint, "", "link to chain endpoint")
+	cmd.Flags().Bool(optionNameDevChain, false, "use an in-process simulated chain with the oracle and traffic contracts deployed instead of the chain endpoint")
 	cmd.Flags().String(optionNameOracleContractAddr, "", "link to oracle contract")
 	cmd.Flags().Bool(optionNameDebugAPIEnable, true, "enable debug HTTP API")
 	cmd.Flags().String(optionNameDebugAPIAddr, ":1635", "debug HTTP API listen address")
@@ -240,7 +260,21 @@ func (c *command) setAllFlags(cmd *cobra.Command) {
 	cmd.Flags().Bool(optionNameGatewayMode, false, "disable a set of sensitive features in the api")
 	cmd.Flags().Bool(optionNameBootnodeMode, false, "cause the node to always accept incoming connections")
 	cmd.Flags().String(optionNameTrafficContractAddr, "", "link to traffic contract")
+	cmd.Flags().StringSlice(optionNameSettlementChains, []string{}, "chains to settle on besides the one of chain-endpoint, which is preferred, can be repeated, format traffic-contract-addr[,oracle-contract-addr[:start-block]]@url")
 	cmd.Flags().Bool(optionNameTrafficEnable, false, "enable traffic")
+	cmd.Flags().Duration(optionNameOracleIndexInterval, 0, "poll the calls to the oracle contracts at this interval to answer the lookups from a local index, never if 0")
+	cmd.Flags().Uint64(optionNameOracleIndexStart, 0, "block the oracle contract of chain-endpoint is indexed from")
+	cmd.Flags().Uint64(optionNameOracleIndexConfirm, 0, "blocks an oracle contract call has to be under the head of the chain to be indexed")
+	cmd.Flags().Duration(optionNameProviderTTL, provider.DefaultTTL, "how long the records announcing the files the node stores live in the closest nodes, published again at half of it")
+	cmd.Flags().Duration(optionNameChallengeInterval, 0, "challenge a random node registered for a file the node stores to prove it stores the file at this interval, never if 0")
+	cmd.Flags().Int(optionNameChallengeBlocklist, 0, "blocklist the nodes failing this many storage challenges in a row, never if 0")
+	cmd.Flags().Duration(optionNameCashoutInterval, 0, "cash the received cheques worth cashing at this interval, never if 0")
+	cmd.Flags().String(optionNameCashoutMinProfit, "0", "least amount of token units a cheque has to pay, over the estimated gas cost if --cashout-gas-rate is set, to be cashed automatically")
+	cmd.Flags().String(optionNameCashoutGasRate, "", "token units one wei of gas cost is worth, such as 1/1000000, to count the gas cost against the cheques cashed automatically; not counted if empty")
+	cmd.Flags().String(optionNameCashoutGasBudget, "", "most estimated gas cost in wei spent on cashing cheques automatically per day, unlimited if empty")
+	cmd.Flags().Uint64(optionNamePriceChunk, address.DefaultPrice.Chunk, "price charged to the peers per chunk served")
+	cmd.Flags().Uint64(optionNamePriceByte, address.DefaultPrice.Byte, "price charged to the peers per byte relayed")
+	cmd.Flags().Float64(optionNameRetrievalPriceWeight, 0.5, "how much the price of a route weighs against its speed when retrieving, 0 to ignore prices")
 	cmd.Flags().Bool(optionNameFullNode, true, "full node")
 	cmd.Flags().Int(optionNameLightMaxPeers, 100, "connected light node max limit")
 	cmd.Flags().Int(optionNameBinMaxPeers, 20, "kademlia every k bucket connected peers max limit")
@@ -269,22 +303,31 @@ func (c *command) setAllFlags(cmd *cobra.Command) {
 }
 
 func newLogger(cmd *cobra.Command, verbosity string) (logging.Logger, error) {
-	var logger logging.Logger
+	level, err := verbosityLevel(verbosity)
+	if err != nil {
+		return nil, err
+	}
+	// silent loggers keep the output, so that a reload can raise their level
+	return logging.New(cmd.OutOrStdout(), level), nil
+}
+
+// verbosityLevel returns the log level of the verbosity, which is the panic
+// level for silent loggers.
+func verbosityLevel(verbosity string) (logrus.Level, error) {
 	switch verbosity {
 	case "0", "silent":
-		logger = logging.New(io.Discard, 0)
+		return logrus.PanicLevel, nil
 	case "1", "error":
-		logger = logging.New(cmd.OutOrStdout(), logrus.ErrorLevel)
+		return logrus.ErrorLevel, nil
 	case "2", "warn":
-		logger = logging.New(cmd.OutOrStdout(), logrus.WarnLevel)
+		return logrus.WarnLevel, nil
 	case "3", "info":
-		logger = logging.New(cmd.OutOrStdout(), logrus.InfoLevel)
+		return logrus.InfoLevel, nil
 	case "4", "debug":
-		logger = logging.New(cmd.OutOrStdout(), logrus.DebugLevel)
+		return logrus.DebugLevel, nil
 	case "5", "trace":
-		logger = logging.New(cmd.OutOrStdout(), logrus.TraceLevel)
+		return logrus.TraceLevel, nil
 	default:
-		return nil, fmt.Errorf("unknown verbosity level %q", verbosity)
+		return 0, fmt.Errorf("unknown verbosity level %q", verbosity)
 	}
-	return logger, nil
 }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -9,9 +10,19 @@ import (
 	"strings"
 
 	"github.com/FavorLabs/favorX/pkg/localstore"
+	"github.com/FavorLabs/favorX/pkg/node"
+	"github.com/FavorLabs/favorX/pkg/shed"
+	"github.com/FavorLabs/favorX/pkg/shed/encrypted"
 	"github.com/spf13/cobra"
 )
 
+const (
+	optionNameNewPassword = "new-password"
+	optionNameFrom        = "from"
+	optionNameTo          = "to"
+	optionNameBatchSize   = "batch-size"
+)
+
 var driver string
 
 func (c *command) initDBCmd() {
@@ -22,6 +33,8 @@ func (c *command) initDBCmd() {
 
 	dbExportCmd(cmd)
 	dbImportCmd(cmd)
+	c.dbRekeyCmd(cmd)
+	c.dbMigrateCmd(cmd)
 
 	c.root.AddCommand(cmd)
 }
@@ -146,3 +159,367 @@ func dbImportCmd(cmd *cobra.Command) {
 	c.Flags().String(optionNameVerbosity, "info", "verbosity level")
 	cmd.AddCommand(c)
 }
+
+func (c *command) dbRekeyCmd(cmd *cobra.Command) {
+	rc := &cobra.Command{
+		Use:   "rekey",
+		Short: "Rotate the keys of the encrypted localstore and statestore and re-encrypt their data",
+		Long: `Rotate the keys of the encrypted localstore and statestore and re-encrypt their data.
+The node must be stopped. The keys of a running node are rotated with the /db/rekey debug API endpoint.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			if len(args) > 0 {
+				return cmd.Help()
+			}
+			v, err := cmd.Flags().GetString(optionNameVerbosity)
+			if err != nil {
+				return fmt.Errorf("get verbosity: %v", err)
+			}
+			v = strings.ToLower(v)
+			logger, err := newLogger(cmd, v)
+			if err != nil {
+				return fmt.Errorf("new logger: %v", err)
+			}
+			dataDir, err := cmd.Flags().GetString(optionNameDataDir)
+			if err != nil {
+				return fmt.Errorf("get data-dir: %v", err)
+			}
+			if dataDir == "" {
+				return errors.New("no data-dir provided")
+			}
+			dbPath, err := cmd.Flags().GetString(optionDatabasePath)
+			if err != nil {
+				return fmt.Errorf("get db-path: %v", err)
+			}
+			newPassword, err := cmd.Flags().GetString(optionNameNewPassword)
+			if err != nil {
+				return fmt.Errorf("get new-password: %v", err)
+			}
+
+			password, err := c.dbPasswordFlag(cmd)
+			if err != nil {
+				return err
+			}
+
+			rekey := func(name string, edb *encrypted.DB) error {
+				logger.Infof("re-encrypting %s", name)
+				if err := edb.Rotate(); err != nil {
+					return fmt.Errorf("rotate %s key: %w", name, err)
+				}
+				if newPassword != "" {
+					if err := edb.ChangePassword(newPassword); err != nil {
+						return fmt.Errorf("change %s password: %w", name, err)
+					}
+				}
+				logger.Infof("%s keys rotated", name)
+				return nil
+			}
+
+			path := filepath.Join(dataDir, "localstore")
+			if dbPath != "" {
+				path = dbPath
+			}
+			if err := checkKeyring(path); err != nil {
+				return fmt.Errorf("localstore: %w", err)
+			}
+			db, err := shed.Open(path, &shed.Options{Driver: driver, Password: password})
+			if err != nil {
+				return fmt.Errorf("localstore: %w", err)
+			}
+			err = rekey("localstore", db.(*encrypted.DB))
+			if cerr := db.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+
+			path = filepath.Join(dataDir, "statestore")
+			if err := checkKeyring(path); err != nil {
+				return fmt.Errorf("statestore: %w", err)
+			}
+			stateStore, err := node.InitStateStore(logger, dataDir, password)
+			if err != nil {
+				return fmt.Errorf("statestore: %w", err)
+			}
+			err = rekey("statestore", stateStore.DB().(*encrypted.DB))
+			if cerr := stateStore.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+
+			if newPassword != "" {
+				logger.Warning("database password changed, the node keys are still sealed with the old password")
+			}
+			return nil
+		},
+	}
+	rc.Flags().String(optionNameDataDir, "", "data directory")
+	rc.Flags().String(optionDatabasePath, "", "localstore directory if it is not in the data directory")
+	rc.Flags().String(optionNamePassword, "", "password the databases are encrypted with")
+	rc.Flags().String(optionNamePasswordFile, "", "path to a file that contains the password the databases are encrypted with")
+	rc.Flags().String(optionNameNewPassword, "", "new password to seal the database keys with")
+	rc.Flags().String(optionNameVerbosity, "info", "verbosity level")
+	cmd.AddCommand(rc)
+}
+
+// dbPasswordFlag returns the database password given with the password
+// flags, or prompts for it if there is none.
+func (c *command) dbPasswordFlag(cmd *cobra.Command) (string, error) {
+	password, err := cmd.Flags().GetString(optionNamePassword)
+	if err != nil {
+		return "", fmt.Errorf("get password: %v", err)
+	}
+	if password != "" {
+		return password, nil
+	}
+	pf, err := cmd.Flags().GetString(optionNamePasswordFile)
+	if err != nil {
+		return "", fmt.Errorf("get password-file: %v", err)
+	}
+	if pf != "" {
+		b, err := os.ReadFile(pf)
+		if err != nil {
+			return "", err
+		}
+		return string(bytes.Trim(b, "\n")), nil
+	}
+	return terminalPromptPassword(cmd, c.passwordReader, "Password")
+}
+
+func (c *command) dbMigrateCmd(cmd *cobra.Command) {
+	mc := &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate the localstore and the statestore to another database driver",
+		Long: `Migrate the localstore and the statestore to another database driver.
+
+The fields, indexes and state entries of the databases are copied into new
+databases of the target driver, which are verified and then swapped with the
+source ones. The source databases are kept next to them with the driver name
+as suffix. The statestore is migrated from the driver it is kept by, leveldb
+unless it was migrated before.
+
+The swap is recorded in a journal in the data directory first. If it is
+interrupted, it is finished by the next migration or when the node starts.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			if len(args) > 0 {
+				return cmd.Help()
+			}
+			v, err := cmd.Flags().GetString(optionNameVerbosity)
+			if err != nil {
+				return fmt.Errorf("get verbosity: %v", err)
+			}
+			v = strings.ToLower(v)
+			logger, err := newLogger(cmd, v)
+			if err != nil {
+				return fmt.Errorf("new logger: %v", err)
+			}
+			dataDir, err := cmd.Flags().GetString(optionNameDataDir)
+			if err != nil {
+				return fmt.Errorf("get data-dir: %v", err)
+			}
+			if dataDir == "" {
+				return errors.New("no data-dir provided")
+			}
+			dbPath, err := cmd.Flags().GetString(optionDatabasePath)
+			if err != nil {
+				return fmt.Errorf("get db-path: %v", err)
+			}
+			from, err := cmd.Flags().GetString(optionNameFrom)
+			if err != nil {
+				return fmt.Errorf("get from: %v", err)
+			}
+			to, err := cmd.Flags().GetString(optionNameTo)
+			if err != nil {
+				return fmt.Errorf("get to: %v", err)
+			}
+			batchSize, err := cmd.Flags().GetInt(optionNameBatchSize)
+			if err != nil {
+				return fmt.Errorf("get batch-size: %v", err)
+			}
+			fromName, toName := driverName(from), driverName(to)
+			if fromName == "" || toName == "" {
+				return errors.New("source and target drivers are required")
+			}
+			if fromName == toName {
+				return errors.New("source and target drivers are the same")
+			}
+
+			journal := node.SwapJournalPath(dataDir)
+			if err := shed.CompleteSwap(journal); err != nil {
+				return fmt.Errorf("complete interrupted migration: %w", err)
+			}
+
+			path := filepath.Join(dataDir, "localstore")
+			if dbPath != "" {
+				path = dbPath
+			}
+			if _, err := os.Stat(path); err != nil {
+				return fmt.Errorf("localstore: %w", err)
+			}
+			migrations := []*dbMigration{{name: "localstore", path: path, from: from, to: to, fromName: fromName}}
+
+			statePath := filepath.Join(dataDir, "statestore")
+			stateFrom, err := node.StateStoreDriver(dataDir)
+			if err != nil {
+				return err
+			}
+			if stateFrom != toName {
+				if _, err := os.Stat(statePath); err != nil {
+					return fmt.Errorf("statestore: %w", err)
+				}
+				migrations = append(migrations, &dbMigration{name: "statestore", path: statePath, from: stateFrom, to: to, fromName: stateFrom})
+			} else {
+				logger.Infof("statestore is kept by %s already", toName)
+			}
+
+			var password string
+			for _, m := range migrations {
+				if _, err := os.Stat(m.backupPath()); err == nil {
+					return fmt.Errorf("backup path %s already exists", m.backupPath())
+				}
+				m.encrypted = checkKeyring(m.path) == nil
+				if m.encrypted && password == "" {
+					password, err = c.dbPasswordFlag(cmd)
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			removeTmp := func() {
+				for _, m := range migrations {
+					m.removeTmp()
+				}
+			}
+			removeTmp()
+
+			for _, m := range migrations {
+				logger.Infof("migrating %s at %s from %s to %s", m.name, m.path, m.fromName, toName)
+				r, err := m.migrate(password, batchSize)
+				if err != nil {
+					removeTmp()
+					return err
+				}
+				logger.Infof("copied and verified %d fields, %d indexes and %d entries, %d of them state entries", r.Fields, r.Indexes, r.Entries, r.State)
+			}
+
+			var renames []shed.Rename
+			for _, m := range migrations {
+				renames = append(renames, m.renames()...)
+			}
+			if len(migrations) > 1 {
+				// the statestore is opened with the driver named in this file
+				driverPath := node.StateStoreDriverPath(dataDir)
+				if err := os.WriteFile(driverPath+".migrate", []byte(toName), 0600); err != nil {
+					removeTmp()
+					return fmt.Errorf("write statestore driver: %w", err)
+				}
+				if _, err := os.Stat(driverPath); err == nil {
+					renames = append(renames, shed.Rename{From: driverPath, To: statePath + "." + stateFrom + ".driver"})
+				}
+				renames = append(renames, shed.Rename{From: driverPath + ".migrate", To: driverPath})
+			}
+			if err := shed.Swap(journal, renames); err != nil {
+				return fmt.Errorf("swap databases, the swap is finished by the next migration or when the node starts: %w", err)
+			}
+
+			for _, m := range migrations {
+				logger.Infof("%s migrated to %s, the previous database is kept at %s", m.name, toName, m.backupPath())
+			}
+			logger.Infof("start the node with --%s %s", optionDatabaseDriver, to)
+			return nil
+		},
+	}
+	mc.Flags().String(optionNameDataDir, "", "data directory")
+	mc.Flags().String(optionDatabasePath, "", "localstore directory if it is not in the data directory")
+	mc.Flags().String(optionNameFrom, driver, "database driver the localstore is stored with")
+	mc.Flags().String(optionNameTo, "", "database driver to migrate the databases to")
+	mc.Flags().Int(optionNameBatchSize, shed.DefaultMigrateBatchSize, "number of entries written in a single batch")
+	mc.Flags().String(optionNamePassword, "", "password the databases are encrypted with")
+	mc.Flags().String(optionNamePasswordFile, "", "path to a file that contains the password the databases are encrypted with")
+	mc.Flags().String(optionNameVerbosity, "info", "verbosity level")
+	cmd.AddCommand(mc)
+}
+
+// dbMigration is a database migrated to a new database of another driver,
+// which is created next to it and swapped with it once it is verified.
+type dbMigration struct {
+	name      string
+	path      string
+	from, to  string
+	fromName  string
+	encrypted bool
+}
+
+func (m *dbMigration) tmpPath() string {
+	return m.path + ".migrate"
+}
+
+func (m *dbMigration) backupPath() string {
+	return m.path + "." + m.fromName
+}
+
+func (m *dbMigration) removeTmp() {
+	_ = os.RemoveAll(m.tmpPath())
+	_ = os.Remove(encrypted.KeyringPath(m.tmpPath()))
+}
+
+// migrate copies the database into the temporary path.
+func (m *dbMigration) migrate(password string, batchSize int) (r shed.MigrateResult, err error) {
+	if !m.encrypted {
+		password = ""
+	}
+	src, err := shed.Open(m.path, &shed.Options{Driver: m.from, Password: password})
+	if err != nil {
+		return r, fmt.Errorf("open source %s: %w", m.name, err)
+	}
+	dst, err := shed.Open(m.tmpPath(), &shed.Options{Driver: m.to, Password: password})
+	if err != nil {
+		_ = src.Close()
+		return r, fmt.Errorf("open target %s: %w", m.name, err)
+	}
+
+	r, err = shed.Migrate(src, dst, batchSize)
+	if cerr := src.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("close source %s: %w", m.name, cerr)
+	}
+	if cerr := dst.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("close target %s: %w", m.name, cerr)
+	}
+	if err != nil {
+		return r, fmt.Errorf("migrate %s: %w", m.name, err)
+	}
+	return r, nil
+}
+
+// renames returns the renames that swap the migrated database and its keyring
+// with the source ones.
+func (m *dbMigration) renames() []shed.Rename {
+	renames := []shed.Rename{{From: m.path, To: m.backupPath()}}
+	if m.encrypted {
+		renames = append(renames, shed.Rename{From: encrypted.KeyringPath(m.path), To: encrypted.KeyringPath(m.backupPath())})
+	}
+	renames = append(renames, shed.Rename{From: m.tmpPath(), To: m.path})
+	if m.encrypted {
+		renames = append(renames, shed.Rename{From: encrypted.KeyringPath(m.tmpPath()), To: encrypted.KeyringPath(m.path)})
+	}
+	return renames
+}
+
+// driverName returns the name of the driver without its options.
+func driverName(d string) string {
+	return strings.SplitN(d, ":", 2)[0]
+}
+
+// checkKeyring returns an error if the database at path is not encrypted.
+func checkKeyring(path string) error {
+	if _, err := os.Stat(encrypted.KeyringPath(path)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return errors.New("database is not encrypted")
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build bbolt
+// +build bbolt
+
+package cmd
+
+func init() {
+	driver = "bbolt"
+}
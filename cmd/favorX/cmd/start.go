@@ -5,10 +5,13 @@ import (
 	"context"
 	"crypto/ecdsa"
 	_ "embed"
+	"errors"
 	"fmt"
+	"math/big"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"syscall"
 	"time"
@@ -25,6 +28,7 @@ import (
 	"github.com/FavorLabs/favorX/pkg/multicast/model"
 	"github.com/FavorLabs/favorX/pkg/node"
 	"github.com/FavorLabs/favorX/pkg/resolver/multiresolver"
+	"github.com/FavorLabs/favorX/pkg/settlement/multichain"
 	"github.com/gogf/gf/v2/util/gconv"
 	"github.com/kardianos/service"
 	crypto2 "github.com/libp2p/go-libp2p/core/crypto"
@@ -69,24 +73,8 @@ func (c *command) initStartCmd() (err error) {
 				}
 			}
 
-			// If the resolver is specified, resolve all connection strings
-			// and fail on any errors.
-			var resolverCfgs []multiresolver.ConnectionConfig
-			resolverEndpoints := c.config.GetStringSlice(optionNameResolverEndpoints)
-			if len(resolverEndpoints) > 0 {
-				resolverCfgs, err = multiresolver.ParseConnectionStrings(resolverEndpoints)
-				if err != nil {
-					return err
-				}
-			}
-
 			fmt.Print(welcomeMessage)
 
-			debugAPIAddr := c.config.GetString(optionNameDebugAPIAddr)
-			if !c.config.GetBool(optionNameDebugAPIEnable) {
-				debugAPIAddr = ""
-			}
-
 			signerCfg, err := c.configureSigner(cmd, logger)
 			if err != nil {
 				return err
@@ -108,73 +96,36 @@ func (c *command) initStartCmd() (err error) {
 				logger.Info("Start node mode light.")
 			}
 
-			var configGroups []model.ConfigNodeGroup
-			if obj := c.config.Get(optionNameGroups); obj != nil {
-				err = gconv.Struct(obj, &configGroups)
-				if err != nil {
-					logger.Errorf("Group configuration acquisition failed: %v", err)
-					return err
-				}
+			o, err := c.nodeOptions(logger, signerCfg)
+			if err != nil {
+				return err
 			}
+			o.ConfigLoader = c.configLoader(cmd, logger, signerCfg)
 
-			b, err := node.NewNode(mode, c.config.GetString(optionNameP2PAddr), signerCfg.address, *signerCfg.publicKey, signerCfg.signer, c.config.GetUint64(optionNameNetworkID), logger, signerCfg.libp2pPrivateKey, node.Options{
-				DataDir:                c.config.GetString(optionNameDataDir),
-				CacheCapacity:          c.config.GetUint64(optionNameCacheCapacity),
-				DBDriver:               c.config.GetString(optionDatabaseDriver),
-				DBPath:                 c.config.GetString(optionDatabasePath),
-				HTTPAddr:               c.config.GetString(optionNameHTTPAddr),
-				WSAddr:                 c.config.GetString(optionNameWebsocketAddr),
-				APIAddr:                c.config.GetString(optionNameAPIAddr),
-				DebugAPIAddr:           debugAPIAddr,
-				ApiBufferSizeMul:       c.config.GetInt(optionNameApiFileBufferMultiple),
-				NATAddr:                c.config.GetString(optionNameNATAddr),
-				EnableWS:               c.config.GetBool(optionNameP2PWSEnable),
-				EnableQUIC:             c.config.GetBool(optionNameP2PQUICEnable),
-				WelcomeMessage:         c.config.GetString(optionWelcomeMessage),
-				Bootnodes:              c.config.GetStringSlice(optionNameBootnodes),
-				ChainEndpoint:          c.config.GetString(optionNameChainEndpoint),
-				OracleContractAddress:  c.config.GetString(optionNameOracleContractAddr),
-				CORSAllowedOrigins:     c.config.GetStringSlice(optionCORSAllowedOrigins),
-				Standalone:             c.config.GetBool(optionNameStandalone),
-				IsDev:                  c.config.GetBool(optionNameDevMode),
-				TracingEnabled:         c.config.GetBool(optionNameTracingEnabled),
-				TracingEndpoint:        c.config.GetString(optionNameTracingEndpoint),
-				TracingServiceName:     c.config.GetString(optionNameTracingServiceName),
-				Logger:                 logger,
-				ResolverConnectionCfgs: resolverCfgs,
-				GatewayMode:            c.config.GetBool(optionNameGatewayMode),
-				TrafficEnable:          c.config.GetBool(optionNameTrafficEnable),
-				TrafficContractAddr:    c.config.GetString(optionNameTrafficContractAddr),
-				KadBinMaxPeers:         c.config.GetInt(optionNameBinMaxPeers),
-				LightNodeMaxPeers:      c.config.GetInt(optionNameLightMaxPeers),
-				AllowPrivateCIDRs:      c.config.GetBool(optionNameAllowPrivateCIDRs),
-				Restricted:             c.config.GetBool(optionNameRestrictedAPI),
-				TokenEncryptionKey:     c.config.GetString(optionNameTokenEncryptionKey),
-				AdminPasswordHash:      c.config.GetString(optionNameAdminPasswordHash),
-				RouteAlpha:             c.config.GetInt32(optionNameRouteAlpha),
-				Groups:                 configGroups,
-				EnableApiTLS:           c.config.GetBool(optionNameEnableApiTls),
-				TlsCrtFile:             c.config.GetString(optionNameTlsCRT),
-				TlsKeyFile:             c.config.GetString(optionNameTlsKey),
-				ProxyEnable:            c.config.GetBool(optionNameProxyEnable),
-				ProxyAddr:              c.config.GetString(optionNameProxyAddr),
-				ProxyNATAddr:           c.config.GetString(optionNameProxyNATAddr),
-				ProxyGroup:             c.config.GetString(optionNameProxyGroup),
-				TunEnable:              c.config.GetBool(optionNameTunEnable),
-				TunCidr4:               c.config.GetString(optionNameTunCidr4),
-				TunCidr6:               c.config.GetString(optionNameTunCidr6),
-				TunMTU:                 c.config.GetInt(optionNameTunMTU),
-				TunServiceIPv4:         c.config.GetString(optionNameTunServiceIP4),
-				TunServiceIPv6:         c.config.GetString(optionNameTunServiceIP6),
-				TunGroup:               c.config.GetString(optionNameTunGroup),
-				VpnEnable:              c.config.GetBool(optionNameVpnEnable),
-				VpnAddr:                c.config.GetString(optionNameVpnAddr),
-				Relay:                  c.config.GetBool(optionRelay),
-			})
+			b, err := node.NewNode(mode, c.config.GetString(optionNameP2PAddr), signerCfg.address, *signerCfg.publicKey, signerCfg.signer, c.config.GetUint64(optionNameNetworkID), logger, signerCfg.libp2pPrivateKey, o)
 			if err != nil {
 				return err
 			}
 
+			if len(reloadSignals) > 0 {
+				reloadChannel := make(chan os.Signal, 1)
+				signal.Notify(reloadChannel, reloadSignals...)
+				go func() {
+					for sig := range reloadChannel {
+						logger.Debugf("received signal: %v", sig)
+						r, err := b.ReloadConfig()
+						if err != nil {
+							logger.Errorf("config reload: %v", err)
+							continue
+						}
+						logger.Infof("config reloaded, applied: %v", r.Applied)
+						if len(r.RestartRequired) > 0 {
+							logger.Warningf("config reloaded, restart required to apply: %v", r.RestartRequired)
+						}
+					}
+				}()
+			}
+
 			// Wait for termination or interrupt signals.
 			// We want to clean up things at the end.
 			interruptChannel := make(chan os.Signal, 1)
@@ -259,11 +210,187 @@ func (p *program) Stop(s service.Service) error {
 	return nil
 }
 
+// nodeOptions returns the options of the node from the configuration.
+func (c *command) nodeOptions(logger logging.Logger, signerCfg *signerConfig) (o node.Options, err error) {
+	// If the resolver is specified, resolve all connection strings
+	// and fail on any errors.
+	var resolverCfgs []multiresolver.ConnectionConfig
+	resolverEndpoints := c.config.GetStringSlice(optionNameResolverEndpoints)
+	if len(resolverEndpoints) > 0 {
+		resolverCfgs, err = multiresolver.ParseConnectionStrings(resolverEndpoints)
+		if err != nil {
+			return o, err
+		}
+	}
+
+	chains, err := multichain.ParseChainConfigs(c.config.GetStringSlice(optionNameSettlementChains))
+	if err != nil {
+		return o, err
+	}
+
+	debugAPIAddr := c.config.GetString(optionNameDebugAPIAddr)
+	if !c.config.GetBool(optionNameDebugAPIEnable) {
+		debugAPIAddr = ""
+	}
+
+	var configGroups []model.ConfigNodeGroup
+	if obj := c.config.Get(optionNameGroups); obj != nil {
+		err = gconv.Struct(obj, &configGroups)
+		if err != nil {
+			logger.Errorf("Group configuration acquisition failed: %v", err)
+			return o, err
+		}
+	}
+
+	logLevel, err := verbosityLevel(strings.ToLower(c.config.GetString(optionNameVerbosity)))
+	if err != nil {
+		return o, err
+	}
+
+	cashoutMinProfit, ok := new(big.Int).SetString(c.config.GetString(optionNameCashoutMinProfit), 10)
+	if !ok {
+		return o, fmt.Errorf("invalid %s", optionNameCashoutMinProfit)
+	}
+	var cashoutGasRate *big.Rat
+	if rate := c.config.GetString(optionNameCashoutGasRate); rate != "" {
+		if cashoutGasRate, ok = new(big.Rat).SetString(rate); !ok || cashoutGasRate.Sign() < 0 {
+			return o, fmt.Errorf("invalid %s", optionNameCashoutGasRate)
+		}
+	}
+	var cashoutGasBudget *big.Int
+	if budget := c.config.GetString(optionNameCashoutGasBudget); budget != "" {
+		if cashoutGasBudget, ok = new(big.Int).SetString(budget, 10); !ok {
+			return o, fmt.Errorf("invalid %s", optionNameCashoutGasBudget)
+		}
+	}
+
+	return node.Options{
+		DataDir:                  c.config.GetString(optionNameDataDir),
+		CacheCapacity:            c.config.GetUint64(optionNameCacheCapacity),
+		DBDriver:                 c.config.GetString(optionDatabaseDriver),
+		DBPath:                   c.config.GetString(optionDatabasePath),
+		DBPassword:               c.dbPassword(signerCfg),
+		HTTPAddr:                 c.config.GetString(optionNameHTTPAddr),
+		WSAddr:                   c.config.GetString(optionNameWebsocketAddr),
+		APIAddr:                  c.config.GetString(optionNameAPIAddr),
+		DebugAPIAddr:             debugAPIAddr,
+		ApiBufferSizeMul:         c.config.GetInt(optionNameApiFileBufferMultiple),
+		NATAddr:                  c.config.GetString(optionNameNATAddr),
+		EnableWS:                 c.config.GetBool(optionNameP2PWSEnable),
+		EnableQUIC:               c.config.GetBool(optionNameP2PQUICEnable),
+		WelcomeMessage:           c.config.GetString(optionWelcomeMessage),
+		Bootnodes:                c.config.GetStringSlice(optionNameBootnodes),
+		ChainEndpoint:            c.config.GetString(optionNameChainEndpoint),
+		DevChain:                 c.config.GetBool(optionNameDevChain),
+		OracleContractAddress:    c.config.GetString(optionNameOracleContractAddr),
+		CORSAllowedOrigins:       c.config.GetStringSlice(optionCORSAllowedOrigins),
+		Standalone:               c.config.GetBool(optionNameStandalone),
+		IsDev:                    c.config.GetBool(optionNameDevMode),
+		TracingEnabled:           c.config.GetBool(optionNameTracingEnabled),
+		TracingEndpoint:          c.config.GetString(optionNameTracingEndpoint),
+		TracingServiceName:       c.config.GetString(optionNameTracingServiceName),
+		Logger:                   logger,
+		ResolverConnectionCfgs:   resolverCfgs,
+		GatewayMode:              c.config.GetBool(optionNameGatewayMode),
+		TrafficEnable:            c.config.GetBool(optionNameTrafficEnable),
+		TrafficContractAddr:      c.config.GetString(optionNameTrafficContractAddr),
+		Chains:                   chains,
+		OracleIndexInterval:      c.config.GetDuration(optionNameOracleIndexInterval),
+		OracleIndexStartBlock:    c.config.GetUint64(optionNameOracleIndexStart),
+		OracleIndexConfirmations: c.config.GetUint64(optionNameOracleIndexConfirm),
+		ProviderTTL:              c.config.GetDuration(optionNameProviderTTL),
+		ChallengeInterval:        c.config.GetDuration(optionNameChallengeInterval),
+		ChallengeBlocklistAfter:  c.config.GetInt(optionNameChallengeBlocklist),
+		CashoutInterval:          c.config.GetDuration(optionNameCashoutInterval),
+		CashoutMinProfit:         cashoutMinProfit,
+		CashoutDailyGasBudget:    cashoutGasBudget,
+		CashoutGasRate:           cashoutGasRate,
+		Price: address.Price{
+			Chunk: c.config.GetUint64(optionNamePriceChunk),
+			Byte:  c.config.GetUint64(optionNamePriceByte),
+		},
+		RetrievalPriceWeight: c.config.GetFloat64(optionNameRetrievalPriceWeight),
+		KadBinMaxPeers:       c.config.GetInt(optionNameBinMaxPeers),
+		LightNodeMaxPeers:    c.config.GetInt(optionNameLightMaxPeers),
+		AllowPrivateCIDRs:    c.config.GetBool(optionNameAllowPrivateCIDRs),
+		Restricted:           c.config.GetBool(optionNameRestrictedAPI),
+		TokenEncryptionKey:   c.config.GetString(optionNameTokenEncryptionKey),
+		AdminPasswordHash:    c.config.GetString(optionNameAdminPasswordHash),
+		RouteAlpha:           c.config.GetInt32(optionNameRouteAlpha),
+		Groups:               configGroups,
+		EnableApiTLS:         c.config.GetBool(optionNameEnableApiTls),
+		TlsCrtFile:           c.config.GetString(optionNameTlsCRT),
+		TlsKeyFile:           c.config.GetString(optionNameTlsKey),
+		ProxyEnable:          c.config.GetBool(optionNameProxyEnable),
+		ProxyAddr:            c.config.GetString(optionNameProxyAddr),
+		ProxyNATAddr:         c.config.GetString(optionNameProxyNATAddr),
+		ProxyGroup:           c.config.GetString(optionNameProxyGroup),
+		TunEnable:            c.config.GetBool(optionNameTunEnable),
+		TunCidr4:             c.config.GetString(optionNameTunCidr4),
+		TunCidr6:             c.config.GetString(optionNameTunCidr6),
+		TunMTU:               c.config.GetInt(optionNameTunMTU),
+		TunServiceIPv4:       c.config.GetString(optionNameTunServiceIP4),
+		TunServiceIPv6:       c.config.GetString(optionNameTunServiceIP6),
+		TunGroup:             c.config.GetString(optionNameTunGroup),
+		VpnEnable:            c.config.GetBool(optionNameVpnEnable),
+		VpnAddr:              c.config.GetString(optionNameVpnAddr),
+		Relay:                c.config.GetBool(optionRelay),
+		LogLevel:             logLevel,
+	}, nil
+}
+
+// configLoader returns the loader the node reads its configuration file
+// again with. The settings the node options do not hold are compared with
+// the ones the node started with, as they can only change with a restart.
+func (c *command) configLoader(cmd *cobra.Command, logger logging.Logger, signerCfg *signerConfig) node.ConfigLoader {
+	restartOptions := []struct{ name, option string }{
+		{"P2PAddr", optionNameP2PAddr},
+		{"NetworkID", optionNameNetworkID},
+		{"NodeMode", optionNameBootnodeMode},
+		{"NodeMode", optionNameFullNode},
+		{"Password", optionNamePassword},
+		{"Password", optionNamePasswordFile},
+	}
+	return func() (o node.Options, restart []string, err error) {
+		file := c.config.ConfigFileUsed()
+		if file == "" {
+			return o, nil, errors.New("no config file to reload")
+		}
+		rc := &command{cfgFile: file, homeDir: c.homeDir}
+		if err = rc.initConfig(); err != nil {
+			return o, nil, err
+		}
+		if err = rc.config.BindPFlags(cmd.Flags()); err != nil {
+			return o, nil, err
+		}
+		for _, v := range restartOptions {
+			if reflect.DeepEqual(c.config.Get(v.option), rc.config.Get(v.option)) {
+				continue
+			}
+			if len(restart) == 0 || restart[len(restart)-1] != v.name {
+				restart = append(restart, v.name)
+			}
+		}
+		o, err = rc.nodeOptions(logger, signerCfg)
+		return o, restart, err
+	}
+}
+
 type signerConfig struct {
 	signer           crypto.Signer
 	address          boson.Address
 	publicKey        *ecdsa.PublicKey
 	libp2pPrivateKey crypto2.PrivKey
+	password         string
+}
+
+// dbPassword returns the password the databases are encrypted with, which is
+// empty unless the at-rest encryption is enabled.
+func (c *command) dbPassword(cfg *signerConfig) string {
+	if !c.config.GetBool(optionDatabaseEncryption) {
+		return ""
+	}
+	return cfg.password
 }
 
 func (c *command) configureSigner(cmd *cobra.Command, logger logging.Logger) (config *signerConfig, err error) {
@@ -346,5 +473,6 @@ func (c *command) configureSigner(cmd *cobra.Command, logger logging.Logger) (co
 		address:          addr,
 		publicKey:        publicKey,
 		libp2pPrivateKey: libp2pPrivateKey,
+		password:         password,
 	}, nil
 }
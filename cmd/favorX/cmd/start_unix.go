@@ -5,10 +5,15 @@ package cmd
 
 import (
 	"errors"
+	"os"
+	"syscall"
 
 	"github.com/FavorLabs/favorX/pkg/logging"
 )
 
+// reloadSignals make a running node reload its configuration.
+var reloadSignals = []os.Signal{syscall.SIGHUP}
+
 func isWindowsService() (bool, error) {
 	return false, nil
 }
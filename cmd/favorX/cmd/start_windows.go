@@ -6,6 +6,7 @@ package cmd
 import (
 	"fmt"
 	"io"
+	"os"
 
 	"github.com/FavorLabs/favorX/pkg/logging"
 	"github.com/sirupsen/logrus"
@@ -14,6 +15,10 @@ import (
 	"golang.org/x/sys/windows/svc/eventlog"
 )
 
+// reloadSignals make a running node reload its configuration. Windows has
+// none, the debug API reloads it there.
+var reloadSignals []os.Signal
+
 func isWindowsService() (bool, error) {
 	return svc.IsWindowsService()
 }
@@ -92,3 +97,7 @@ func (l *windowsEventLogger) WriterLevel(level logrus.Level) *io.PipeWriter {
 func (l *windowsEventLogger) NewEntry() *logrus.Entry {
 	return l.logger.NewEntry()
 }
+
+func (l *windowsEventLogger) SetLevel(level logrus.Level) {
+	logging.SetLevel(l.logger, level)
+}
@@ -5,6 +5,7 @@ package accounting
 import (
 	"context"
 	"errors"
+	"github.com/FavorLabs/favorX/pkg/address"
 	"github.com/FavorLabs/favorX/pkg/boson"
 	"github.com/FavorLabs/favorX/pkg/logging"
 	"github.com/FavorLabs/favorX/pkg/p2p"
@@ -23,13 +24,26 @@ var (
 	ErrLowAvailableExceeded        = errors.New("low available balance")
 )
 
-// Interface is the Accounting interface.
+// Interface is the Accounting interface. The chunks served and the bytes
+// relayed are charged at the price agreed with the peer.
 type Interface interface {
-	Reserve(peer boson.Address, traffic uint64) error
+	Reserve(peer boson.Address, chunks uint64) error
 	// Credit increases the balance the peer has with us (we "pay" the peer).
-	Credit(ctx context.Context, peer boson.Address, traffic uint64) error
+	Credit(ctx context.Context, peer boson.Address, chunks uint64) error
 	// Debit increases the balance we have with the peer (we get "paid" back).
-	Debit(peer boson.Address, traffic uint64) error
+	Debit(peer boson.Address, chunks uint64) error
+	// CreditRelay credits the peer the bytes it relayed for us.
+	CreditRelay(ctx context.Context, peer boson.Address, bytes uint64) error
+	// DebitRelay debits the peer the bytes we relayed for it.
+	DebitRelay(peer boson.Address, bytes uint64) error
+}
+
+// Pricer returns the prices the traffic is charged at.
+type Pricer interface {
+	// ChargedPrice returns the price of ours the peer acknowledged.
+	ChargedPrice(peer boson.Address) address.Price
+	// PeerPrice returns the price the peer charges us.
+	PeerPrice(peer boson.Address) address.Price
 }
 
 // Accounting is the main implementation of the accounting interface.
@@ -42,6 +56,7 @@ type Accounting struct {
 	paymentTolerance  *big.Int
 	paymentThreshold  *big.Int
 	settlement        settlement.Interface
+	pricer            Pricer
 	metrics           metrics
 	payChan           chan payChan
 }
@@ -64,6 +79,7 @@ func NewAccounting(
 	logger logging.Logger,
 	store storage.StateStorer,
 	settlement settlement.Interface,
+	pricer Pricer,
 ) *Accounting {
 	acc := &Accounting{
 		accountingPeers:  make(map[string]*accountingPeer),
@@ -72,6 +88,7 @@ func NewAccounting(
 		logger:           logger,
 		store:            store,
 		settlement:       settlement,
+		pricer:           pricer,
 		payChan:          make(chan payChan, 1000),
 		metrics:          newMetrics(),
 	}
@@ -80,14 +97,14 @@ func NewAccounting(
 }
 
 // Reserve reserves a portion of the balance for peer and attempts settlements if necessary.
-func (a *Accounting) Reserve(peer boson.Address, traffic uint64) (err error) {
+func (a *Accounting) Reserve(peer boson.Address, chunks uint64) (err error) {
 	accountingPeer, err := a.getAccountingPeer(peer)
 	if err != nil {
 		return err
 	}
 	retrieve := accountingPeer.unPaidTraffic
-	ret := big.NewInt(0).Add(retrieve, new(big.Int).SetUint64(traffic))
-	available, err := a.settlement.AvailableBalance()
+	ret := big.NewInt(0).Add(retrieve, a.peerPrice(peer).ChunkCost(chunks))
+	available, err := a.availableBalance(peer)
 	if err != nil {
 		return err
 	}
@@ -98,20 +115,38 @@ func (a *Accounting) Reserve(peer boson.Address, traffic uint64) (err error) {
 	return nil
 }
 
+// availableBalance returns the balance the peer is paid from.
+func (a *Accounting) availableBalance(peer boson.Address) (*big.Int, error) {
+	if balancer, ok := a.settlement.(settlement.PeerBalancer); ok {
+		return balancer.PeerAvailableBalance(peer)
+	}
+	return a.settlement.AvailableBalance()
+}
+
 // Credit increases the amount of credit we have with the given peer
 // (and decreases existing debt).
-func (a *Accounting) Credit(ctx context.Context, peer boson.Address, traffic uint64) error {
+func (a *Accounting) Credit(ctx context.Context, peer boson.Address, chunks uint64) error {
+	return a.credit(peer, a.peerPrice(peer).ChunkCost(chunks))
+}
+
+// CreditRelay credits the peer the bytes it relayed at its price.
+func (a *Accounting) CreditRelay(ctx context.Context, peer boson.Address, bytes uint64) error {
+	return a.credit(peer, a.peerPrice(peer).RelayCost(bytes))
+}
+
+func (a *Accounting) credit(peer boson.Address, cost *big.Int) error {
 	accountingPeer, err := a.getAccountingPeer(peer)
 	if err != nil {
 		return err
 	}
 	accountingPeer.lock.Lock()
 	defer accountingPeer.lock.Unlock()
-	accountingPeer.unPaidTraffic = big.NewInt(0).Add(accountingPeer.unPaidTraffic, new(big.Int).SetUint64(traffic))
-	if err := a.settlement.PutRetrieveTraffic(peer, new(big.Int).SetUint64(traffic)); err != nil {
+	accountingPeer.unPaidTraffic = big.NewInt(0).Add(accountingPeer.unPaidTraffic, cost)
+	if err := a.settlement.PutRetrieveTraffic(peer, cost); err != nil {
 		a.logger.Errorf("failed to modify retrieve traffic")
 		return err
 	}
+	a.recordTraffic(peer, cost, nil)
 	unPaid := accountingPeer.unPaidTraffic
 	if unPaid.Cmp(accountingPeer.paymentThreshold) >= 0 {
 		a.payChan <- payChan{
@@ -136,8 +171,17 @@ func (a *Accounting) settle() {
 
 // Debit increases the amount of debt we have with the given peer (and decreases
 // existing credit).
-func (a *Accounting) Debit(peer boson.Address, traffic uint64) error {
+func (a *Accounting) Debit(peer boson.Address, chunks uint64) error {
+	return a.debit(peer, a.chargedPrice(peer).ChunkCost(chunks))
+}
 
+// DebitRelay debits the peer the bytes we relayed at the price it
+// acknowledged.
+func (a *Accounting) DebitRelay(peer boson.Address, bytes uint64) error {
+	return a.debit(peer, a.chargedPrice(peer).RelayCost(bytes))
+}
+
+func (a *Accounting) debit(peer boson.Address, cost *big.Int) error {
 	accountingPeer, err := a.getAccountingPeer(peer)
 	if err != nil {
 		return err
@@ -155,12 +199,38 @@ func (a *Accounting) Debit(peer boson.Address, traffic uint64) error {
 		return p2p.NewBlockPeerError(24*time.Hour, ErrDisconnectThresholdExceeded)
 	}
 
-	if err := a.settlement.PutTransferTraffic(peer, new(big.Int).SetUint64(traffic)); err != nil {
+	if err := a.settlement.PutTransferTraffic(peer, cost); err != nil {
 		return err
 	}
+	a.recordTraffic(peer, nil, cost)
 	return nil
 }
 
+// recordTraffic records the traffic in the ledger of the settlement, if it
+// keeps one.
+func (a *Accounting) recordTraffic(peer boson.Address, credit, debit *big.Int) {
+	if recorder, ok := a.settlement.(settlement.TrafficRecorder); ok {
+		recorder.RecordTraffic(peer, credit, debit)
+	}
+}
+
+// peerPrice returns the price the peer charges us.
+func (a *Accounting) peerPrice(peer boson.Address) address.Price {
+	if a.pricer == nil {
+		return address.DefaultPrice
+	}
+	return a.pricer.PeerPrice(peer)
+}
+
+// chargedPrice returns the price we charge the peer, the one it
+// acknowledged.
+func (a *Accounting) chargedPrice(peer boson.Address) address.Price {
+	if a.pricer == nil {
+		return address.DefaultPrice
+	}
+	return a.pricer.ChargedPrice(peer)
+}
+
 // getAccountingPeer returns the accountingPeer for a given boson address.
 // If not found in memory it will initialize it.
 func (a *Accounting) getAccountingPeer(peer boson.Address) (*accountingPeer, error) {
@@ -38,7 +38,7 @@ func TestAccountingReserve(t *testing.T) {
 
 	defer store.Close()
 
-	acc := NewAccounting(testPaymentThreshold, testPaymentTolerance, logger, store, settlement)
+	acc := NewAccounting(testPaymentThreshold, testPaymentTolerance, logger, store, settlement, nil)
 
 	if err != nil {
 		t.Fatal(err)
@@ -90,7 +90,7 @@ func TestAccountingCredit(t *testing.T) {
 
 	defer store.Close()
 
-	acc := NewAccounting(testPaymentTolerance, testPaymentThreshold, logger, store, settlement)
+	acc := NewAccounting(testPaymentTolerance, testPaymentThreshold, logger, store, settlement, nil)
 
 	if err != nil {
 		t.Fatal(err)
@@ -146,7 +146,7 @@ func TestAccountingDebit(t *testing.T) {
 
 	defer store.Close()
 
-	acc := NewAccounting(testPaymentTolerance, testPaymentThreshold, logger, store, settlement)
+	acc := NewAccounting(testPaymentTolerance, testPaymentThreshold, logger, store, settlement, nil)
 
 	if err != nil {
 		t.Fatal(err)
@@ -180,7 +180,7 @@ func TestAccountingNotifyPayment(t *testing.T) {
 			return big.NewInt(0), nil
 		}),
 	)
-	acc := NewAccounting(testPaymentTolerance, testPaymentThreshold, logger, store, settlement)
+	acc := NewAccounting(testPaymentTolerance, testPaymentThreshold, logger, store, settlement, nil)
 
 	if err != nil {
 		t.Fatal(err)
@@ -91,6 +91,32 @@ func (s *Service) Debit(peer boson.Address, traffic uint64) error {
 	return nil
 }
 
+// CreditRelay is the mock function crediting the relayed bytes
+func (s *Service) CreditRelay(_ context.Context, peer boson.Address, bytes uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if bal, ok := s.balances[peer.String()]; ok {
+		s.balances[peer.String()] = new(big.Int).Sub(bal, new(big.Int).SetUint64(bytes))
+	} else {
+		s.balances[peer.String()] = new(big.Int).Neg(new(big.Int).SetUint64(bytes))
+	}
+	return nil
+}
+
+// DebitRelay is the mock function debiting the relayed bytes
+func (s *Service) DebitRelay(peer boson.Address, bytes uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if bal, ok := s.balances[peer.String()]; ok {
+		s.balances[peer.String()] = new(big.Int).Add(bal, new(big.Int).SetUint64(bytes))
+	} else {
+		s.balances[peer.String()] = new(big.Int).SetUint64(bytes)
+	}
+	return nil
+}
+
 // Option is the option passed to the mock accounting service
 type Option interface {
 	apply(*Service)
@@ -61,6 +61,8 @@ func (m Model) String() string {
 type AddressInfo struct {
 	Address  *Address
 	NodeMode Model
+	Price    *Price // nil if the peer announced no price
+	OwnPrice *Price // the price announced to the peer
 }
 
 func (i *AddressInfo) LightString() string {
@@ -0,0 +1,26 @@
+package address
+
+import "math/big"
+
+// Price is what a node charges for the traffic it serves, announced to its
+// peers in the handshake.
+type Price struct {
+	Chunk uint64 `json:"chunk"` // charged per chunk served
+	Byte  uint64 `json:"byte"`  // charged per byte relayed
+}
+
+// DefaultPrice charges the traffic as it was charged before the nodes
+// announced prices, and is assumed for the peers announcing none.
+var DefaultPrice = Price{Chunk: 256, Byte: 0}
+
+// ChunkCost returns the cost of serving the chunks.
+func (p Price) ChunkCost(chunks uint64) *big.Int {
+	cost := new(big.Int).SetUint64(p.Chunk)
+	return cost.Mul(cost, new(big.Int).SetUint64(chunks))
+}
+
+// RelayCost returns the cost of relaying the bytes.
+func (p Price) RelayCost(bytes uint64) *big.Int {
+	cost := new(big.Int).SetUint64(p.Byte)
+	return cost.Mul(cost, new(big.Int).SetUint64(bytes))
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/FavorLabs/favorX/pkg/chunkinfo"
 	"github.com/FavorLabs/favorX/pkg/file/pipeline"
 	"github.com/FavorLabs/favorX/pkg/file/pipeline/builder"
+	"github.com/FavorLabs/favorX/pkg/file/pipeline/compress"
 	"github.com/FavorLabs/favorX/pkg/fileinfo"
 	"github.com/FavorLabs/favorX/pkg/jsonhttp"
 	"github.com/FavorLabs/favorX/pkg/logging"
@@ -44,6 +45,9 @@ const (
 	CollectionHeader     = "Collection"
 	CollectionNameHeader = "Collection-Name"
 	ReferenceLinkHeader  = "Reference-Link"
+	CompressionHeader    = "Compression"
+	ChunkingHeader       = "Chunking"
+	TransferIDHeader     = "Transfer-Id"
 	// TargetsRecoveryHeader defines the Header for Recovery targets in Global Pinning
 	TargetsRecoveryHeader = "recovery-targets"
 )
@@ -83,6 +87,9 @@ type Service interface {
 	http.Handler
 	m.Collector
 	io.Closer
+	// SetCORSAllowedOrigins replaces the origins allowed to make
+	// cross-origin requests.
+	SetCORSAllowedOrigins(origins []string)
 }
 
 type authenticator interface {
@@ -90,6 +97,13 @@ type authenticator interface {
 	GenerateKey(string, int) (string, error)
 	RefreshKey(string, int) (string, error)
 	Enforce(string, string, string) (bool, error)
+	CreateKey(auth.KeyOptions) (auth.APIKey, string, error)
+	Keys() ([]auth.APIKey, error)
+	RevokeKey(string) error
+	KeyAudit(string) ([]auth.AuditEntry, error)
+	Roles() []auth.Role
+	AddRole(auth.Role) error
+	RemoveRole(string) error
 }
 
 type server struct {
@@ -107,6 +121,7 @@ type server struct {
 	commonChain chain.Common
 	oracleChain chain.Resolver
 	Options
+	corsMu sync.RWMutex // guards Options.CORSAllowedOrigins
 	http.Handler
 	metrics metrics
 
@@ -230,6 +245,25 @@ func requestEncrypt(r *http.Request) bool {
 	return strings.ToLower(r.Header.Get(EncryptHeader)) == StringTrue
 }
 
+// requestCompression returns the codec the file contents of this request
+// should be compressed with.
+func requestCompression(r *http.Request) (compress.Codec, error) {
+	return compress.ParseCodec(r.Header.Get(CompressionHeader))
+}
+
+// requestChunking returns the chunking mode the file contents of this
+// request should be cut with.
+func requestChunking(r *http.Request) (builder.Chunking, error) {
+	chunking, err := builder.ParseChunking(r.Header.Get(ChunkingHeader))
+	if err != nil {
+		return chunking, err
+	}
+	if chunking != builder.FixedChunking && requestEncrypt(r) {
+		return chunking, builder.ErrChunkingEncryption
+	}
+	return chunking, nil
+}
+
 type securityTokenRsp struct {
 	Key string `json:"key"`
 }
@@ -405,6 +439,14 @@ func lookaheadBufferSize(size int64) int {
 	return largeFileBufferSize * BufferSizeMul
 }
 
+// SetCORSAllowedOrigins replaces the origins allowed to make cross-origin
+// requests.
+func (s *server) SetCORSAllowedOrigins(origins []string) {
+	s.corsMu.Lock()
+	s.CORSAllowedOrigins = origins
+	s.corsMu.Unlock()
+}
+
 // checkOrigin returns true if the origin is not set or is equal to the request host.
 func (s *server) checkOrigin(r *http.Request) bool {
 	origin := r.Header["Origin"]
@@ -415,7 +457,9 @@ func (s *server) checkOrigin(r *http.Request) bool {
 	if r.TLS != nil {
 		scheme = "https"
 	}
-	hosts := append(s.CORSAllowedOrigins, scheme+"://"+r.Host)
+	s.corsMu.RLock()
+	hosts := append([]string{scheme + "://" + r.Host}, s.CORSAllowedOrigins...)
+	s.corsMu.RUnlock()
 	for _, v := range hosts {
 		if equalASCIIFold(origin[0], v) || v == "*" {
 			return true
@@ -451,12 +495,26 @@ func equalASCIIFold(s, t string) bool {
 
 type pipelineFunc func(context.Context, io.Reader) (boson.Address, error)
 
-func requestPipelineFn(s storage.Storer, r *http.Request) pipelineFunc {
+// requestPipelineFn returns the pipeline the file contents of this request
+// are stored with, or an error if the request asks for an unsupported
+// compression or chunking.
+func requestPipelineFn(s storage.Storer, r *http.Request) (pipelineFunc, error) {
 	mode, encrypt := requestModePut(r), requestEncrypt(r)
+	codec, err := requestCompression(r)
+	if err != nil {
+		return nil, err
+	}
+	chunking, err := requestChunking(r)
+	if err != nil {
+		return nil, err
+	}
 	return func(ctx context.Context, r io.Reader) (boson.Address, error) {
-		pipe := builder.NewPipelineBuilder(ctx, s, mode, encrypt)
+		pipe, err := builder.NewCompressionPipelineBuilder(ctx, s, mode, encrypt, codec, chunking)
+		if err != nil {
+			return boson.ZeroAddress, err
+		}
 		return builder.FeedPipeline(ctx, pipe, r)
-	}
+	}, nil
 }
 
 func requestPipelineFactory(ctx context.Context, s storage.Putter, r *http.Request) func() pipeline.Interface {
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/auth"
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/jsonhttp"
+	"github.com/gorilla/mux"
+)
+
+type apiKeyReq struct {
+	Name   string          `json:"name"`
+	Role   string          `json:"role"`
+	Expiry int             `json:"expiry"`
+	Groups []string        `json:"groups"`
+	Roots  []boson.Address `json:"roots"`
+}
+
+type apiKeyRsp struct {
+	auth.APIKey
+	Key string `json:"key"`
+}
+
+type apiKeysRsp struct {
+	Keys []auth.APIKey `json:"keys"`
+}
+
+type apiKeyAuditRsp struct {
+	Entries []auth.AuditEntry `json:"entries"`
+}
+
+type rolesRsp struct {
+	Roles []auth.Role `json:"roles"`
+}
+
+// adminAuthHandler lets through only the requests authorized with the
+// admin password.
+func (s *server) adminAuthHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pass, ok := r.BasicAuth()
+		if !ok || !s.auth.Authorize(pass) {
+			s.logger.Error("api: admin auth: unauthorized")
+			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+			jsonhttp.Unauthorized(w, "Unauthorized")
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (s *server) apiKeyCreateHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.logger.Debugf("api: api key create: read request body: %v", err)
+		s.logger.Error("api: api key create: read request body")
+		jsonhttp.BadRequest(w, "Read request body")
+		return
+	}
+	var payload apiKeyReq
+	if err = json.Unmarshal(body, &payload); err != nil {
+		s.logger.Debugf("api: api key create: unmarshal request body: %v", err)
+		s.logger.Error("api: api key create: unmarshal request body")
+		jsonhttp.BadRequest(w, "Unmarshal json body")
+		return
+	}
+
+	k, key, err := s.auth.CreateKey(auth.KeyOptions{
+		Name:   payload.Name,
+		Role:   payload.Role,
+		Expiry: time.Duration(payload.Expiry) * time.Second,
+		Groups: payload.Groups,
+		Roots:  payload.Roots,
+	})
+	if err != nil {
+		if errors.Is(err, auth.ErrExpiry) || errors.Is(err, auth.ErrUnknownRole) {
+			jsonhttp.BadRequest(w, err.Error())
+			return
+		}
+		s.logger.Debugf("api: api key create: %v", err)
+		s.logger.Error("api: api key create")
+		jsonhttp.InternalServerError(w, "Error creating api key")
+		return
+	}
+
+	jsonhttp.Created(w, apiKeyRsp{
+		APIKey: k,
+		Key:    key,
+	})
+}
+
+func (s *server) apiKeyListHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.auth.Keys()
+	if err != nil {
+		s.logger.Debugf("api: api key list: %v", err)
+		s.logger.Error("api: api key list")
+		jsonhttp.InternalServerError(w, "Error listing api keys")
+		return
+	}
+	jsonhttp.OK(w, apiKeysRsp{Keys: keys})
+}
+
+func (s *server) apiKeyRevokeHandler(w http.ResponseWriter, r *http.Request) {
+	err := s.auth.RevokeKey(mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, auth.ErrKeyNotFound) {
+			jsonhttp.NotFound(w, nil)
+			return
+		}
+		s.logger.Debugf("api: api key revoke: %v", err)
+		s.logger.Error("api: api key revoke")
+		jsonhttp.InternalServerError(w, "Error revoking api key")
+		return
+	}
+	jsonhttp.OK(w, nil)
+}
+
+func (s *server) apiKeyAuditHandler(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.auth.KeyAudit(mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, auth.ErrKeyNotFound) {
+			jsonhttp.NotFound(w, nil)
+			return
+		}
+		s.logger.Debugf("api: api key audit: %v", err)
+		s.logger.Error("api: api key audit")
+		jsonhttp.InternalServerError(w, "Error reading api key audit log")
+		return
+	}
+	jsonhttp.OK(w, apiKeyAuditRsp{Entries: entries})
+}
+
+func (s *server) roleListHandler(w http.ResponseWriter, r *http.Request) {
+	jsonhttp.OK(w, rolesRsp{Roles: s.auth.Roles()})
+}
+
+func (s *server) roleAddHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.logger.Debugf("api: role add: read request body: %v", err)
+		s.logger.Error("api: role add: read request body")
+		jsonhttp.BadRequest(w, "Read request body")
+		return
+	}
+	var payload auth.Role
+	if err = json.Unmarshal(body, &payload); err != nil {
+		s.logger.Debugf("api: role add: unmarshal request body: %v", err)
+		s.logger.Error("api: role add: unmarshal request body")
+		jsonhttp.BadRequest(w, "Unmarshal json body")
+		return
+	}
+
+	if err := s.auth.AddRole(payload); err != nil {
+		if errors.Is(err, auth.ErrInvalidRole) || errors.Is(err, auth.ErrUnknownRole) {
+			jsonhttp.BadRequest(w, err.Error())
+			return
+		}
+		s.logger.Debugf("api: role add: %v", err)
+		s.logger.Error("api: role add")
+		jsonhttp.InternalServerError(w, "Error adding role")
+		return
+	}
+	jsonhttp.Created(w, nil)
+}
+
+func (s *server) roleRemoveHandler(w http.ResponseWriter, r *http.Request) {
+	err := s.auth.RemoveRole(mux.Vars(r)["name"])
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrUnknownRole):
+			jsonhttp.NotFound(w, nil)
+		case errors.Is(err, auth.ErrRoleInUse):
+			jsonhttp.Conflict(w, err.Error())
+		default:
+			s.logger.Debugf("api: role remove: %v", err)
+			s.logger.Error("api: role remove")
+			jsonhttp.InternalServerError(w, "Error removing role")
+		}
+		return
+	}
+	jsonhttp.OK(w, nil)
+}
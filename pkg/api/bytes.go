@@ -62,5 +62,5 @@ func (s *server) bytesGetHandler(w http.ResponseWriter, r *http.Request) {
 		"Content-Type": {"application/octet-stream"},
 	}
 
-	s.downloadHandler(w, r, address, address, 0, additionalHeaders, true)
+	s.downloadHandler(w, r, address, address, 0, additionalHeaders, true, noEncoding)
 }
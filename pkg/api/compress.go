@@ -0,0 +1,119 @@
+package api
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/FavorLabs/favorX/pkg/file/pipeline/compress"
+)
+
+// compressHandler gzip or deflate compresses responses for clients that
+// accept it. Unlike handlers.CompressHandler the decision is taken when the
+// header is written, so responses that already carry a Content-Encoding, such
+// as file entries stored compressed, are passed through untouched.
+func compressHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var encoding string
+		switch acceptEncoding := r.Header.Get("Accept-Encoding"); {
+		case compress.Accepted(compress.Gzip, acceptEncoding):
+			encoding = string(compress.Gzip)
+		case compress.Accepted(deflate, acceptEncoding):
+			encoding = string(deflate)
+		default:
+			h.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressResponseWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.close()
+		h.ServeHTTP(cw, r)
+	})
+}
+
+// deflate is the content-coding of the responses compressed with flate.
+const deflate compress.Codec = "deflate"
+
+// flushWriteCloser is a compressing writer, gzip or flate.
+type flushWriteCloser interface {
+	io.WriteCloser
+	Flush() error
+}
+
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding    string
+	enc         flushWriteCloser
+	wroteHeader bool
+}
+
+func (w *compressResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	h := w.Header()
+	if h.Get("Content-Encoding") == "" && code != http.StatusNoContent && code != http.StatusNotModified {
+		h.Set("Content-Encoding", w.encoding)
+		h.Add("Vary", "Accept-Encoding")
+		h.Del("Content-Length")
+		if w.encoding == string(deflate) {
+			w.enc, _ = flate.NewWriter(w.ResponseWriter, flate.DefaultCompression)
+		} else {
+			w.enc = gzip.NewWriter(w.ResponseWriter)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *compressResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressResponseWriter) Flush() {
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not implement http.Hijacker")
+	}
+	w.wroteHeader = true
+	return h.Hijack()
+}
+
+func (w *compressResponseWriter) close() {
+	if w.enc != nil {
+		_ = w.enc.Close()
+	}
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCompressHandler(t *testing.T) {
+	const body = "compressed response body"
+	handler := compressHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/encoded" {
+			w.Header().Set("Content-Encoding", "zstd")
+		}
+		_, _ = io.WriteString(w, body)
+	}))
+
+	for _, tc := range []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		wantEncoding   string
+	}{
+		{name: "gzip", path: "/", acceptEncoding: "gzip, deflate", wantEncoding: "gzip"},
+		{name: "deflate", path: "/", acceptEncoding: "deflate", wantEncoding: "deflate"},
+		{name: "gzip refused", path: "/", acceptEncoding: "gzip;q=0, deflate", wantEncoding: "deflate"},
+		{name: "identity", path: "/", acceptEncoding: "", wantEncoding: ""},
+		{name: "already encoded", path: "/encoded", acceptEncoding: "gzip", wantEncoding: "zstd"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			r.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Header().Get("Content-Encoding"); got != tc.wantEncoding {
+				t.Fatalf("got Content-Encoding %q, want %q", got, tc.wantEncoding)
+			}
+			var rd io.Reader = w.Body
+			switch tc.wantEncoding {
+			case "gzip":
+				gr, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rd = gr
+			case "deflate":
+				rd = flate.NewReader(w.Body)
+			}
+			got, err := io.ReadAll(rd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != body {
+				t.Fatalf("got body %q, want %q", got, body)
+			}
+		})
+	}
+}
+
+func TestRequestPipelineFn(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set(CompressionHeader, "brotli")
+	if _, err := requestPipelineFn(nil, r); err == nil {
+		t.Fatal("got no error for an unsupported compression")
+	}
+}
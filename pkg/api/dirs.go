@@ -9,6 +9,7 @@ import (
 	"github.com/FavorLabs/favorX/pkg/chunkinfo"
 	"github.com/FavorLabs/favorX/pkg/file"
 	"github.com/FavorLabs/favorX/pkg/file/loadsave"
+	"github.com/FavorLabs/favorX/pkg/file/pipeline/compress"
 	"github.com/FavorLabs/favorX/pkg/fileinfo"
 	"github.com/FavorLabs/favorX/pkg/jsonhttp"
 	"github.com/FavorLabs/favorX/pkg/logging"
@@ -60,13 +61,28 @@ func (s *server) dirUploadHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
+	codec, err := requestCompression(r)
+	if err != nil {
+		logger.Debugf("dir upload dir: invalid compression: %v", err)
+		logger.Error("dir upload dir: invalid compression")
+		jsonhttp.BadRequest(w, err.Error())
+		return
+	}
+	p, err := requestPipelineFn(s.storer, r)
+	if err != nil {
+		logger.Debugf("dir upload dir: invalid chunking: %v", err)
+		logger.Error("dir upload dir: invalid chunking")
+		jsonhttp.BadRequest(w, err.Error())
+		return
+	}
+
 	ctx := r.Context()
 
-	p := requestPipelineFn(s.storer, r)
 	factory := requestPipelineFactory(ctx, s.storer, r)
 	reference, err := storeDir(
 		ctx,
 		requestEncrypt(r),
+		codec,
 		dReader,
 		s.logger,
 		p,
@@ -121,6 +137,7 @@ func UnescapeUnicode(raw string) (string, error) {
 func storeDir(
 	ctx context.Context,
 	encrypt bool,
+	codec compress.Codec,
 	reader dirReader,
 	log logging.Logger,
 	p pipelineFunc,
@@ -158,7 +175,8 @@ func storeDir(
 			return boson.ZeroAddress, fmt.Errorf("read tar stream: %w", err)
 		}
 
-		fileReference, err := p(ctx, fileInfo.Reader)
+		cr := &countingReader{r: fileInfo.Reader}
+		fileReference, err := p(ctx, cr)
 		if err != nil {
 			return boson.ZeroAddress, fmt.Errorf("store dir file: %w", err)
 		}
@@ -168,6 +186,10 @@ func storeDir(
 			manifest.EntryMetadataContentTypeKey: fileInfo.ContentType,
 			manifest.EntryMetadataFilenameKey:    fileInfo.Name,
 		}
+		if codec != compress.None {
+			fileMetadata[manifest.EntryMetadataContentEncodingKey] = string(codec)
+			fileMetadata[manifest.EntryMetadataDecompressedKey] = strconv.FormatInt(cr.n, 10)
+		}
 		// add file entry to dir manifest
 		err = dirManifest.Add(ctx, fileInfo.Path, manifest.NewEntry(fileReference, fileMetadata, 0))
 		if err != nil {
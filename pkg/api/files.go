@@ -1,6 +1,7 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -21,6 +22,7 @@ import (
 	"github.com/FavorLabs/favorX/pkg/boson"
 	"github.com/FavorLabs/favorX/pkg/file/joiner"
 	"github.com/FavorLabs/favorX/pkg/file/loadsave"
+	"github.com/FavorLabs/favorX/pkg/file/pipeline/compress"
 	"github.com/FavorLabs/favorX/pkg/fileinfo"
 	"github.com/FavorLabs/favorX/pkg/jsonhttp"
 	"github.com/FavorLabs/favorX/pkg/localstore/filestore"
@@ -85,10 +87,24 @@ func (s *server) fileUploadHandler(w http.ResponseWriter, r *http.Request) {
 	referenceLink := r.Header.Get(ReferenceLinkHeader)
 	reader = r.Body
 
-	p := requestPipelineFn(s.storer, r)
+	codec, err := requestCompression(r)
+	if err != nil {
+		logger.Debugf("upload file: invalid compression, file %q: %v", fileName, err)
+		logger.Errorf("upload file: invalid compression, file %q", fileName)
+		jsonhttp.BadRequest(w, err.Error())
+		return
+	}
+	p, err := requestPipelineFn(s.storer, r)
+	if err != nil {
+		logger.Debugf("upload file: invalid chunking, file %q: %v", fileName, err)
+		logger.Errorf("upload file: invalid chunking, file %q", fileName)
+		jsonhttp.BadRequest(w, err.Error())
+		return
+	}
 
 	// first store the file and get its reference
-	fr, err := p(ctx, reader)
+	cr := &countingReader{r: reader}
+	fr, err := p(ctx, cr)
 	if err != nil {
 		logger.Debugf("upload file: file len, file %q: %v", fileName, err)
 		logger.Errorf("upload file: file len, file %q", fileName)
@@ -154,6 +170,10 @@ func (s *server) fileUploadHandler(w http.ResponseWriter, r *http.Request) {
 		manifest.EntryMetadataContentTypeKey: contentType,
 		manifest.EntryMetadataFilenameKey:    realIndexFilename,
 	}
+	if codec != compress.None {
+		fileMtdt[manifest.EntryMetadataContentEncodingKey] = string(codec)
+		fileMtdt[manifest.EntryMetadataDecompressedKey] = strconv.FormatInt(cr.n, 10)
+	}
 
 	err = m.Add(ctx, fileName, manifest.NewEntry(fr, fileMtdt, 0))
 	if err != nil {
@@ -378,11 +398,31 @@ func (s *server) serveManifestEntry(
 		additionalHeaders["Content-Type"] = []string{mimeType}
 	}
 
-	s.downloadHandler(w, r, rootCid, manifestEntry.Reference(), manifestEntry.Index(), additionalHeaders, etag)
+	s.downloadHandler(w, r, rootCid, manifestEntry.Reference(), manifestEntry.Index(), additionalHeaders, etag, entryEncodingOf(metadata))
+}
+
+// entryEncoding tells how the content of a manifest entry is compressed.
+type entryEncoding struct {
+	codec        compress.Codec
+	decompressed int64 // length of the content decompressed, unknown if negative
+}
+
+// noEncoding is the encoding of the content stored as is.
+var noEncoding = entryEncoding{codec: compress.None, decompressed: -1}
+
+func entryEncodingOf(metadata map[string]string) entryEncoding {
+	e := entryEncoding{codec: compress.Codec(metadata[manifest.EntryMetadataContentEncodingKey]), decompressed: -1}
+	if n, err := strconv.ParseInt(metadata[manifest.EntryMetadataDecompressedKey], 10, 64); err == nil && n >= 0 {
+		e.decompressed = n
+	}
+	return e
 }
 
-// downloadHandler contains common logic for downloading file from API
-func (s *server) downloadHandler(w http.ResponseWriter, r *http.Request, rootCid, reference boson.Address, index int64, additionalHeaders http.Header, etag bool) {
+// downloadHandler contains common logic for downloading file from API.
+// Content stored with a codec is passed through with a Content-Encoding
+// header when the client accepts it, and decompressed otherwise.
+func (s *server) downloadHandler(w http.ResponseWriter, r *http.Request, rootCid, reference boson.Address, index int64, additionalHeaders http.Header, etag bool, encoding entryEncoding) {
+	codec := encoding.codec
 	logger := tracing.NewLoggerWithTraceID(r.Context(), s.logger)
 	targets := r.URL.Query().Get("targets")
 	if targets != "" {
@@ -435,12 +475,46 @@ func (s *server) downloadHandler(w http.ResponseWriter, r *http.Request, rootCid
 	// http cache policy
 	w.Header().Set("Cache-Control", "no-store")
 
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", l))
-	w.Header().Set("Decompressed-Content-Length", fmt.Sprintf("%d", l))
 	w.Header().Set("Access-Control-Expose-Headers", "Content-Disposition")
 	if targets != "" {
 		w.Header().Set(TargetsRecoveryHeader, targets)
 	}
+
+	if codec != compress.None {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !compress.Accepted(codec, r.Header.Get("Accept-Encoding")) {
+			dr, err := compress.NewReader(codec, langos.NewBufferedLangos(reader, lookaheadBufferSize(l)))
+			if err != nil {
+				logger.Debugf("api download: decompress %s with %s: %v", reference, codec, err)
+				logger.Error("api download: decompress")
+				jsonhttp.InternalServerError(w, err)
+				return
+			}
+			defer dr.Close()
+			if w.Header().Get("Content-Type") == "" {
+				w.Header().Set("Content-Type", "application/octet-stream")
+			}
+			if encoding.decompressed >= 0 {
+				w.Header().Set("Content-Length", fmt.Sprintf("%d", encoding.decompressed))
+				w.Header().Set("Decompressed-Content-Length", fmt.Sprintf("%d", encoding.decompressed))
+			}
+			w.WriteHeader(http.StatusOK)
+			if r.Method != http.MethodHead {
+				if _, err = io.Copy(w, dr); err != nil {
+					logger.Debugf("api download: decompress %s with %s: %v", reference, codec, err)
+				}
+			}
+			return
+		}
+		w.Header().Set("Content-Encoding", string(codec))
+		if encoding.decompressed >= 0 {
+			w.Header().Set("Decompressed-Content-Length", fmt.Sprintf("%d", encoding.decompressed))
+		}
+	} else {
+		w.Header().Set("Decompressed-Content-Length", fmt.Sprintf("%d", l))
+	}
+
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", l))
 	http.ServeContent(w, r, "", time.Now(), langos.NewBufferedLangos(reader, lookaheadBufferSize(l)))
 }
 
@@ -785,13 +859,26 @@ func (s *server) fileRegisterRemove(w http.ResponseWriter, r *http.Request) {
 		})
 }
 
+// maxManifestActions caps the number of operations in a batch manifest request.
+const maxManifestActions = 1024
+
 type ManifestAction struct {
-	Target    string              `json:"target"`
-	Source    string              `json:"source"`
-	Reference string              `json:"ref"`
-	Operation filestore.Operation `json:"op"`
+	Target      string              `json:"target"`
+	Source      string              `json:"source"`
+	Reference   string              `json:"ref"`
+	ContentType string              `json:"contentType,omitempty"`
+	Operation   filestore.Operation `json:"op"`
+}
+
+// manifestStep is a validated ManifestAction with its reference resolved.
+type manifestStep struct {
+	ManifestAction
+	source boson.Address
 }
 
+// manifestInteractionHandler applies one ManifestAction, or an ordered list of
+// them, to the manifest and stores the result once. When any operation fails
+// no new manifest reference is stored.
 func (s *server) manifestInteractionHandler(w http.ResponseWriter, r *http.Request) {
 	logger := tracing.NewLoggerWithTraceID(r.Context(), s.logger)
 
@@ -814,31 +901,29 @@ func (s *server) manifestInteractionHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	var action ManifestAction
-	if err = json.Unmarshal(body, &action); err != nil {
+	var actions []ManifestAction
+	if body = bytes.TrimSpace(body); len(body) > 0 && body[0] == '[' {
+		err = json.Unmarshal(body, &actions)
+	} else {
+		var action ManifestAction
+		err = json.Unmarshal(body, &action)
+		actions = append(actions, action)
+	}
+	if err != nil {
 		logger.Debugf("manifest interaction: parse request action error: %v", err)
 		logger.Error("manifest interaction: parse request action error")
 		jsonhttp.BadRequest(w, "unable to parse manifest action")
 		return
 	}
 
-	var source boson.Address
-	if action.Reference != "" {
-		source, err = s.resolveNameOrAddress(action.Reference)
-		if err != nil {
-			jsonhttp.BadRequest(w, err)
-			return
-		}
-	}
-
-	if len(strings.Split(action.Target, "/")) > 256 {
-		jsonhttp.BadRequest(w, "file directories too long")
+	steps, err := s.prepareManifestActions(actions)
+	if err != nil {
+		logger.Debugf("manifest interaction: invalid action: %v", err)
+		logger.Error("manifest interaction: invalid action")
+		jsonhttp.BadRequest(w, err.Error())
 		return
 	}
 
-	action.Target = strings.TrimLeft(action.Target, "/")
-	action.Source = strings.TrimLeft(action.Source, "/")
-
 	factory := requestPipelineFactory(ctx, s.storer, r)
 	ls := loadsave.New(s.storer, factory)
 	m, err := manifest.NewDefaultManifestReference(target, ls)
@@ -849,42 +934,38 @@ func (s *server) manifestInteractionHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	switch action.Operation {
-	case filestore.MOVE:
-		err = m.Move(r.Context(), source, action.Source, action.Target, true)
-		if err != nil {
-			logger.Debugf("manifest interaction: moving manifest error: %v", err)
-			logger.Error("manifest interaction: moving manifest error")
+	removed := false
+	var sources []boson.Address
+	for i, step := range steps {
+		if err = s.checkManifestAction(ctx, m, target, step); err != nil {
+			logger.Debugf("manifest interaction: %s operation %d invalid: %v", step.Operation, i, err)
+			logger.Errorf("manifest interaction: %s operation %d invalid", step.Operation, i)
+			if errors.Is(err, manifest.ErrNotFound) || errors.Is(err, storage.ErrNotFound) {
+				jsonhttp.BadRequest(w, fmt.Sprintf("operation %d (%s): %v", i, step.Operation, err))
+				return
+			}
 			jsonhttp.InternalServerError(w, err)
 			return
 		}
-
-	case filestore.COPY:
-		err = m.Copy(r.Context(), source, action.Source, action.Target, true)
+		entries, err := s.manifestSources(ctx, target, step)
 		if err != nil {
-			logger.Debugf("manifest interaction: copying manifest error: %v", err)
-			logger.Error("manifest interaction: copying manifest error")
+			logger.Debugf("manifest interaction: sources of %s operation %d error: %v", step.Operation, i, err)
+			logger.Errorf("manifest interaction: sources of %s operation %d error", step.Operation, i)
 			jsonhttp.InternalServerError(w, err)
 			return
 		}
-
-	case filestore.REMOVE:
-		err = m.Remove(ctx, action.Target)
-		if err != nil {
-			logger.Debugf("manifest interaction: removing manifest error : %v", err)
-			logger.Error("manifest interaction: removing manifest error")
+		sources = append(sources, entries...)
+		if err = applyManifestAction(ctx, m, step); err != nil {
+			logger.Debugf("manifest interaction: %s operation %d error: %v", step.Operation, i, err)
+			logger.Errorf("manifest interaction: %s operation %d error", step.Operation, i)
+			if len(steps) > 1 {
+				err = fmt.Errorf("operation %d (%s): %w", i, step.Operation, err)
+			}
 			jsonhttp.InternalServerError(w, err)
 			return
 		}
-	case filestore.MKDIR:
-		metadata := map[string]string{}
-		mkdirManifestEntry := manifest.NewEntry(boson.ZeroAddress, metadata, 0)
-		err = m.Add(ctx, action.Target, mkdirManifestEntry)
-		if err != nil {
-			logger.Debugf("manifest interaction: add to manifest: %v", err)
-			logger.Error("manifest interaction: add to manifest")
-			jsonhttp.InternalServerError(w, err)
-			return
+		if step.Operation == filestore.REMOVE {
+			removed = true
 		}
 	}
 
@@ -897,7 +978,17 @@ func (s *server) manifestInteractionHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if action.Operation == filestore.REMOVE {
+	// the files brought in are announced once the manifest is stored, as the
+	// counters cannot be taken back; the stored manifest is not recorded as a
+	// version of the target until they are
+	if err = s.announceManifestSources(ctx, sources); err != nil {
+		logger.Debugf("manifest interaction: announce sources error: %v", err)
+		logger.Error("manifest interaction: announce sources error")
+		jsonhttp.InternalServerError(w, err)
+		return
+	}
+
+	if removed {
 		var fileCount = 0
 		_ = m.IterateDirectories(ctx, []byte(""), 0,
 			func(nodeType int, path, prefix, hash []byte, metadata map[string]string) error {
@@ -930,52 +1021,6 @@ func (s *server) manifestInteractionHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if !source.IsZero() && !source.Equal(target) && action.Operation != filestore.REMOVE {
-		ls = loadsave.NewReadonly(s.storer, storage.ModeGetRequest)
-		m, err = manifest.NewDefaultManifestReference(source, ls)
-		if err != nil {
-			logger.Debugf("download: not manifest %s: %v", source, err)
-			logger.Errorf("download: not manifest %s", source)
-			jsonhttp.NotFound(w, err)
-			return
-		}
-		entries := make([]manifest.Entry, 0)
-		_ = m.IterateDirectories(ctx, []byte(""), 0,
-			func(nodeType int, path, prefix, hash []byte, metadata map[string]string) error {
-				p := make([]byte, len(path)+len(prefix))
-				copy(p, path)
-				p = append(p, prefix...)
-				fullPath := string(p)
-				if nodeType == 0 && strings.Contains(fullPath, action.Source) {
-					entry := manifest.NewEntry(boson.NewAddress(hash), metadata, 0)
-					entries = append(entries, entry)
-				}
-				return nil
-			})
-
-		for _, entry := range entries {
-			err = s.fileInfo.FileCounter(entry.Reference())
-			if err != nil {
-				logger.Debugf("manifest interaction: file counter error: %v", err)
-				logger.Error("manifest interaction: file counter error")
-				jsonhttp.InternalServerError(w, err)
-				return
-			}
-			bitLen, err := s.fileInfo.GetFileSize(entry.Reference())
-			if err != nil {
-				return
-			}
-			if bitLen > 1 {
-				bitLen++
-			}
-			err = s.chunkInfo.OnFileUpload(ctx, entry.Reference(), bitLen)
-			if err != nil {
-				return
-			}
-		}
-
-	}
-
 	err = s.chunkInfo.OnFileUpload(ctx, manifestReference, int64(bitLen))
 	if err != nil {
 		logger.Debugf("upload file: chunk transfer data err: %v", err)
@@ -983,7 +1028,12 @@ func (s *server) manifestInteractionHandler(w http.ResponseWriter, r *http.Reque
 		jsonhttp.InternalServerError(w, "chunk transfer data error")
 		return
 	}
-	if err = s.fileInfo.AddFileMirror(manifestReference, target, action.Operation); err != nil {
+
+	operation := steps[0].Operation
+	if len(steps) > 1 {
+		operation = filestore.BATCH
+	}
+	if err = s.fileInfo.AddFileMirror(manifestReference, target, operation); err != nil {
 		logger.Debugf("manifest interaction: adding file mirror error : %v", err)
 		logger.Error("manifest interaction:  adding file mirror error")
 		jsonhttp.InternalServerError(w, "file mirror storage error")
@@ -994,3 +1044,176 @@ func (s *server) manifestInteractionHandler(w http.ResponseWriter, r *http.Reque
 		Reference: manifestReference,
 	})
 }
+
+// prepareManifestActions validates every action and resolves its reference
+// before anything is applied to the manifest.
+func (s *server) prepareManifestActions(actions []ManifestAction) ([]manifestStep, error) {
+	if len(actions) == 0 {
+		return nil, errors.New("no manifest action")
+	}
+	if len(actions) > maxManifestActions {
+		return nil, fmt.Errorf("too many manifest actions, limit is %d", maxManifestActions)
+	}
+	steps := make([]manifestStep, 0, len(actions))
+	for i, action := range actions {
+		step := manifestStep{ManifestAction: action}
+		if len(strings.Split(action.Target, "/")) > 256 {
+			return nil, fmt.Errorf("operation %d: file directories too long", i)
+		}
+		step.Target = strings.TrimLeft(action.Target, "/")
+		step.Source = strings.TrimLeft(action.Source, "/")
+
+		switch action.Operation {
+		case filestore.MOVE, filestore.COPY:
+			if step.Source == "" || step.Target == "" {
+				return nil, fmt.Errorf("operation %d: %s needs a source and a target path", i, action.Operation)
+			}
+		case filestore.REMOVE, filestore.MKDIR:
+			if step.Target == "" {
+				return nil, fmt.Errorf("operation %d: %s needs a target path", i, action.Operation)
+			}
+		case filestore.ADD:
+			if step.Target == "" || strings.HasSuffix(step.Target, "/") {
+				return nil, fmt.Errorf("operation %d: ADD needs a target file path", i)
+			}
+			if action.Reference == "" {
+				return nil, fmt.Errorf("operation %d: ADD needs a reference", i)
+			}
+		default:
+			return nil, fmt.Errorf("operation %d: unsupported operation %d", i, action.Operation)
+		}
+
+		if action.Reference != "" {
+			source, err := s.resolveNameOrAddress(action.Reference)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+			step.source = source
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// applyManifestAction applies a single step to the in-memory manifest.
+func applyManifestAction(ctx context.Context, m manifest.Interface, step manifestStep) error {
+	switch step.Operation {
+	case filestore.MOVE:
+		return m.Move(ctx, step.source, step.Source, step.Target, true)
+	case filestore.COPY:
+		return m.Copy(ctx, step.source, step.Source, step.Target, true)
+	case filestore.REMOVE:
+		return m.Remove(ctx, step.Target)
+	case filestore.MKDIR:
+		metadata := map[string]string{}
+		return m.Add(ctx, step.Target, manifest.NewEntry(boson.ZeroAddress, metadata, 0))
+	case filestore.ADD:
+		contentType := step.ContentType
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(step.Target))
+		}
+		metadata := map[string]string{
+			manifest.EntryMetadataContentTypeKey: contentType,
+			manifest.EntryMetadataFilenameKey:    path.Base(step.Target),
+		}
+		return m.Add(ctx, step.Target, manifest.NewEntry(step.source, metadata, 0))
+	}
+	return fmt.Errorf("unsupported operation %d", step.Operation)
+}
+
+// checkManifestAction checks the paths and the reference a step works on
+// exist, against the manifest as the previous steps left it.
+func (s *server) checkManifestAction(ctx context.Context, m manifest.Interface, target boson.Address, step manifestStep) error {
+	switch step.Operation {
+	case filestore.REMOVE:
+		return checkManifestPath(ctx, m, step.Target)
+	case filestore.MOVE, filestore.COPY:
+		if step.source.IsZero() || step.source.Equal(target) {
+			return checkManifestPath(ctx, m, step.Source)
+		}
+		ls := loadsave.NewReadonly(s.storer, storage.ModeGetRequest)
+		source, err := manifest.NewDefaultManifestReference(step.source, ls)
+		if err != nil {
+			return err
+		}
+		return checkManifestPath(ctx, source, step.Source)
+	case filestore.ADD:
+		has, err := s.storer.Has(ctx, storage.ModeHasChunk, step.source)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return fmt.Errorf("reference %s: %w", step.source, storage.ErrNotFound)
+		}
+	}
+	return nil
+}
+
+// checkManifestPath checks a file or a directory exists at the path.
+func checkManifestPath(ctx context.Context, m manifest.Interface, path string) error {
+	has, err := m.HasPrefix(ctx, path)
+	if err != nil {
+		return err
+	}
+	if !has {
+		return fmt.Errorf("path %q: %w", path, manifest.ErrNotFound)
+	}
+	return nil
+}
+
+// manifestSources returns the files that a step brings in from outside the
+// target manifest.
+func (s *server) manifestSources(ctx context.Context, target boson.Address, step manifestStep) ([]boson.Address, error) {
+	if step.source.IsZero() || step.source.Equal(target) || step.Operation == filestore.REMOVE {
+		return nil, nil
+	}
+
+	var entries []boson.Address
+	if step.Operation == filestore.ADD {
+		entries = append(entries, step.source)
+	} else {
+		ls := loadsave.NewReadonly(s.storer, storage.ModeGetRequest)
+		m, err := manifest.NewDefaultManifestReference(step.source, ls)
+		if err != nil {
+			return nil, err
+		}
+		_ = m.IterateDirectories(ctx, []byte(""), 0,
+			func(nodeType int, path, prefix, hash []byte, metadata map[string]string) error {
+				p := make([]byte, 0, len(path)+len(prefix))
+				p = append(p, path...)
+				p = append(p, prefix...)
+				fullPath := string(p)
+				if nodeType == 0 && strings.Contains(fullPath, step.Source) {
+					entries = append(entries, boson.NewAddress(hash))
+				}
+				return nil
+			})
+	}
+	return entries, nil
+}
+
+// announceManifestSources registers the chunk info of the files brought in
+// from outside the target manifest. The sizes of all the files are read
+// first, so a missing file fails the announcement before any file is counted.
+func (s *server) announceManifestSources(ctx context.Context, entries []boson.Address) error {
+	sizes := make([]int64, len(entries))
+	for i, entry := range entries {
+		bitLen, err := s.fileInfo.GetFileSize(entry)
+		if err != nil {
+			return err
+		}
+		if bitLen > 1 {
+			bitLen++
+		}
+		sizes[i] = bitLen
+	}
+	for i, entry := range entries {
+		if err := s.fileInfo.FileCounter(entry); err != nil {
+			return err
+		}
+		if err := s.chunkInfo.OnFileUpload(ctx, entry, sizes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/chunkinfo"
+	"github.com/FavorLabs/favorX/pkg/file/loadsave"
+	"github.com/FavorLabs/favorX/pkg/file/pipeline"
+	"github.com/FavorLabs/favorX/pkg/file/pipeline/builder"
+	"github.com/FavorLabs/favorX/pkg/fileinfo"
+	"github.com/FavorLabs/favorX/pkg/jsonhttp/jsonhttptest"
+	"github.com/FavorLabs/favorX/pkg/localstore/filestore"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/manifest"
+	"github.com/FavorLabs/favorX/pkg/storage"
+	"github.com/FavorLabs/favorX/pkg/storage/mock"
+	"github.com/gorilla/mux"
+)
+
+type fileInfoMock struct {
+	fileinfo.Interface
+	mu         sync.Mutex
+	counterErr error
+	counted    int
+	missing    boson.Address
+	mirrors    []filestore.Operation
+}
+
+func (m *fileInfoMock) FileCounter(boson.Address) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.counterErr != nil {
+		return m.counterErr
+	}
+	m.counted++
+	return nil
+}
+
+func (m *fileInfoMock) GetFileSize(rootCid boson.Address) (int64, error) {
+	if rootCid.Equal(m.missing) {
+		return 0, storage.ErrNotFound
+	}
+	return 1, nil
+}
+
+func (m *fileInfoMock) AddFileMirror(_, _ boson.Address, ope filestore.Operation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mirrors = append(m.mirrors, ope)
+	return nil
+}
+
+type chunkInfoMock struct {
+	chunkinfo.Interface
+}
+
+func (chunkInfoMock) OnFileUpload(context.Context, boson.Address, int64) error {
+	return nil
+}
+
+func newManifestTestServer(t *testing.T) (*server, *fileInfoMock, *http.Client, string) {
+	t.Helper()
+	fileInfo := new(fileInfoMock)
+	s := &server{
+		storer:    mock.NewStorer(),
+		fileInfo:  fileInfo,
+		chunkInfo: chunkInfoMock{},
+		logger:    logging.New(io.Discard, 0),
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/manifest/{address}", s.manifestInteractionHandler).Methods(http.MethodPost)
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+	return s, fileInfo, ts.Client(), ts.URL
+}
+
+// storeFile stores the data and returns its reference.
+func storeFile(t *testing.T, s storage.Storer, data string) boson.Address {
+	t.Helper()
+	ctx := context.Background()
+	ref, err := builder.FeedPipeline(ctx, builder.NewPipelineBuilder(ctx, s, storage.ModePutUpload, false), bytes.NewReader([]byte(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ref
+}
+
+// storeManifest stores a manifest of the files at the paths.
+func storeManifest(t *testing.T, s storage.Storer, files map[string]boson.Address) boson.Address {
+	t.Helper()
+	ctx := context.Background()
+	ls := loadsave.New(s, func() pipeline.Interface {
+		return builder.NewPipelineBuilder(ctx, s, storage.ModePutUpload, false)
+	})
+	m, err := manifest.NewDefaultManifest(ls, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, ref := range files {
+		if err := m.Add(ctx, path, manifest.NewEntry(ref, map[string]string{}, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ref, err := m.Store(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ref
+}
+
+func lookupManifest(t *testing.T, s storage.Storer, ref boson.Address, path string) error {
+	t.Helper()
+	m, err := manifest.NewDefaultManifestReference(ref, loadsave.NewReadonly(s, storage.ModeGetRequest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = m.Lookup(context.Background(), path)
+	return err
+}
+
+func TestManifestInteraction(t *testing.T) {
+	s, fileInfo, client, url := newManifestTestServer(t)
+	a, b := storeFile(t, s.storer, "a"), storeFile(t, s.storer, "b")
+	target := storeManifest(t, s.storer, map[string]boson.Address{"dir/a.txt": a})
+
+	t.Run("add", func(t *testing.T) {
+		var resp UploadResponse
+		jsonhttptest.Request(t, client, http.MethodPost, url+"/manifest/"+target.String(), http.StatusCreated,
+			jsonhttptest.WithJSONRequestBody(ManifestAction{Target: "b.txt", Reference: b.String(), Operation: filestore.ADD}),
+			jsonhttptest.WithUnmarshalJSONResponse(&resp),
+		)
+		if err := lookupManifest(t, s.storer, resp.Reference, "b.txt"); err != nil {
+			t.Fatalf("lookup added file: %v", err)
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		var resp UploadResponse
+		jsonhttptest.Request(t, client, http.MethodPost, url+"/manifest/"+target.String(), http.StatusCreated,
+			jsonhttptest.WithJSONRequestBody([]ManifestAction{
+				{Target: "b.txt", Reference: b.String(), Operation: filestore.ADD},
+				{Source: "b.txt", Target: "dir/b.txt", Operation: filestore.MOVE},
+				{Target: "dir/a.txt", Operation: filestore.REMOVE},
+			}),
+			jsonhttptest.WithUnmarshalJSONResponse(&resp),
+		)
+		if err := lookupManifest(t, s.storer, resp.Reference, "dir/b.txt"); err != nil {
+			t.Fatalf("lookup moved file: %v", err)
+		}
+		for _, path := range []string{"b.txt", "dir/a.txt"} {
+			if err := lookupManifest(t, s.storer, resp.Reference, path); !errors.Is(err, manifest.ErrNotFound) {
+				t.Fatalf("lookup %s: got error %v, want %v", path, err, manifest.ErrNotFound)
+			}
+		}
+		if n := len(fileInfo.mirrors); n == 0 || fileInfo.mirrors[n-1] != filestore.BATCH {
+			t.Fatalf("got mirrors %v, want a batch last", fileInfo.mirrors)
+		}
+	})
+}
+
+func TestManifestInteractionInvalid(t *testing.T) {
+	s, fileInfo, client, url := newManifestTestServer(t)
+	a := storeFile(t, s.storer, "a")
+	target := storeManifest(t, s.storer, map[string]boson.Address{"a.txt": a})
+	missing := boson.MustParseHexAddress("ca1e9f3938cc1425c6061b96ad9eb93e134dfe8734ad490164ef20af9d1cf59c")
+
+	for _, tc := range []struct {
+		name    string
+		actions []ManifestAction
+	}{
+		{
+			name:    "remove missing path",
+			actions: []ManifestAction{{Target: "b.txt", Operation: filestore.REMOVE}},
+		},
+		{
+			name:    "move missing path",
+			actions: []ManifestAction{{Source: "b.txt", Target: "c.txt", Operation: filestore.MOVE}},
+		},
+		{
+			name:    "add missing reference",
+			actions: []ManifestAction{{Target: "b.txt", Reference: missing.String(), Operation: filestore.ADD}},
+		},
+		{
+			name: "batch with a missing path",
+			actions: []ManifestAction{
+				{Source: "a.txt", Target: "b.txt", Operation: filestore.MOVE},
+				{Target: "a.txt", Operation: filestore.REMOVE},
+			},
+		},
+		{
+			name:    "no target",
+			actions: []ManifestAction{{Reference: a.String(), Operation: filestore.ADD}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			jsonhttptest.Request(t, client, http.MethodPost, url+"/manifest/"+target.String(), http.StatusBadRequest,
+				jsonhttptest.WithJSONRequestBody(tc.actions),
+			)
+		})
+	}
+	if len(fileInfo.mirrors) != 0 {
+		t.Fatalf("got mirrors %v of invalid actions", fileInfo.mirrors)
+	}
+}
+
+func TestManifestInteractionAnnounceError(t *testing.T) {
+	s, fileInfo, client, url := newManifestTestServer(t)
+	a, b := storeFile(t, s.storer, "a"), storeFile(t, s.storer, "b")
+	target := storeManifest(t, s.storer, map[string]boson.Address{"a.txt": a})
+	fileInfo.counterErr = errors.New("count chunks")
+
+	jsonhttptest.Request(t, client, http.MethodPost, url+"/manifest/"+target.String(), http.StatusInternalServerError,
+		jsonhttptest.WithJSONRequestBody(ManifestAction{Target: "b.txt", Reference: b.String(), Operation: filestore.ADD}),
+	)
+	if len(fileInfo.mirrors) != 0 {
+		t.Fatalf("got mirrors %v after the announcement failed", fileInfo.mirrors)
+	}
+}
+
+func TestManifestInteractionMissingSource(t *testing.T) {
+	s, fileInfo, client, url := newManifestTestServer(t)
+	a, b, c := storeFile(t, s.storer, "a"), storeFile(t, s.storer, "b"), storeFile(t, s.storer, "c")
+	target := storeManifest(t, s.storer, map[string]boson.Address{"a.txt": a})
+	fileInfo.missing = c
+
+	jsonhttptest.Request(t, client, http.MethodPost, url+"/manifest/"+target.String(), http.StatusInternalServerError,
+		jsonhttptest.WithJSONRequestBody([]ManifestAction{
+			{Target: "b.txt", Reference: b.String(), Operation: filestore.ADD},
+			{Target: "c.txt", Reference: c.String(), Operation: filestore.ADD},
+		}),
+	)
+	if fileInfo.counted != 0 {
+		t.Fatalf("got %d files counted before the announcement failed, want none", fileInfo.counted)
+	}
+	if len(fileInfo.mirrors) != 0 {
+		t.Fatalf("got mirrors %v after the announcement failed", fileInfo.mirrors)
+	}
+}
@@ -2,9 +2,11 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/FavorLabs/favorX/pkg/boson"
 	"github.com/FavorLabs/favorX/pkg/jsonhttp"
@@ -37,8 +39,8 @@ func (s *server) addGroup(w http.ResponseWriter, r *http.Request, gType model.GT
 	}
 	req.Name = gid
 	req.GType = gType
-	err = s.multicast.AddGroup([]model.ConfigNodeGroup{req})
-	if err != nil {
+	err = s.multicast.CreateGroup(req)
+	if err != nil && !errors.Is(err, multicast.ErrGroupExists) {
 		jsonhttp.InternalServerError(w, err)
 		return
 	}
@@ -72,14 +74,27 @@ func (s *server) multicastMsg(w http.ResponseWriter, r *http.Request) {
 		jsonhttp.InternalServerError(w, err)
 		return
 	}
-	err = s.multicast.Multicast(&pb.MulticastMsg{
-		Gid:  gid.Bytes(),
-		Data: body,
-	})
+	topic := r.URL.Query().Get("topic")
+	if err = multicast.ValidateTopic(topic); err != nil {
+		jsonhttp.BadRequest(w, err)
+		return
+	}
+	reliable, _ := strconv.ParseBool(r.URL.Query().Get("reliable"))
+	msg := &pb.MulticastMsg{
+		Gid:      gid.Bytes(),
+		Data:     body,
+		Reliable: reliable,
+		Topic:    topic,
+	}
+	err = s.multicast.Multicast(msg)
 	if err != nil {
 		jsonhttp.InternalServerError(w, err)
 		return
 	}
+	if reliable {
+		jsonhttp.Accepted(w, deliveryIDResponse{ID: msg.Id})
+		return
+	}
 	jsonhttp.OK(w, nil)
 }
 
@@ -110,6 +125,15 @@ func (s *server) sendReceive(w http.ResponseWriter, r *http.Request) {
 		jsonhttp.InternalServerError(w, err)
 		return
 	}
+	topic := r.URL.Query().Get("topic")
+	if err = multicast.ValidateTopic(topic); err != nil {
+		jsonhttp.BadRequest(w, err)
+		return
+	}
+	if stream, _ := strconv.ParseBool(r.URL.Query().Get("stream")); stream {
+		s.sendStream(w, r, gid, target, topic)
+		return
+	}
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -120,7 +144,7 @@ func (s *server) sendReceive(w http.ResponseWriter, r *http.Request) {
 		jsonhttp.BadRequest(w, fmt.Errorf("missing body"))
 		return
 	}
-	out, err := s.multicast.SendReceive(r.Context(), body, gid, target)
+	out, err := s.multicast.SendReceive(r.Context(), body, gid, target, topic)
 	if err != nil {
 		jsonhttp.InternalServerError(w, err)
 		return
@@ -130,6 +154,61 @@ func (s *server) sendReceive(w http.ResponseWriter, r *http.Request) {
 	}{Data: out})
 }
 
+// sendStream streams the request body to the target and the response back,
+// the request is sent in full before the response is read. Large requests
+// are stored as content chunks with only their reference sent.
+func (s *server) sendStream(w http.ResponseWriter, r *http.Request, gid, target boson.Address, topic string) {
+	var content io.Reader
+	if r.ContentLength > multicast.OffloadThreshold {
+		content = r.Body
+	}
+	t, err := s.multicast.OpenTransfer(r.Context(), gid, target, topic, r.URL.Query().Get("id"), content)
+	if err != nil {
+		if errors.Is(err, multicast.ErrInvalidTransferID) {
+			jsonhttp.BadRequest(w, err)
+			return
+		}
+		jsonhttp.InternalServerError(w, err)
+		return
+	}
+	defer t.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-r.Context().Done():
+			_ = t.Close()
+		case <-done:
+		}
+	}()
+
+	if content == nil {
+		if _, err = io.Copy(t, r.Body); err == nil {
+			err = t.CloseWrite()
+		}
+		if err != nil {
+			jsonhttp.InternalServerError(w, err)
+			return
+		}
+	}
+	// the first read tells the failures of the request apart
+	buf := make([]byte, 32*1024)
+	n, err := t.Read(buf)
+	if err != nil && !errors.Is(err, io.EOF) {
+		jsonhttp.InternalServerError(w, err)
+		return
+	}
+	w.Header().Set(contentTypeHeader, "application/octet-stream")
+	w.Header().Set(TransferIDHeader, t.SessionID())
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(buf[:n]); err == nil && n > 0 {
+		_, err = io.Copy(w, t)
+	}
+	if err != nil {
+		s.logger.Debugf("multicast send stream: %v", err)
+	}
+}
+
 func (s *server) notify(w http.ResponseWriter, r *http.Request) {
 	str := mux.Vars(r)["gid"]
 	gid, err := boson.ParseHexAddress(str)
@@ -152,7 +231,16 @@ func (s *server) notify(w http.ResponseWriter, r *http.Request) {
 		jsonhttp.BadRequest(w, fmt.Errorf("missing body"))
 		return
 	}
-	err = s.multicast.Send(r.Context(), body, gid, target)
+	topic := r.URL.Query().Get("topic")
+	if err = multicast.ValidateTopic(topic); err != nil {
+		jsonhttp.BadRequest(w, err)
+		return
+	}
+	if reliable, _ := strconv.ParseBool(r.URL.Query().Get("reliable")); reliable {
+		jsonhttp.Accepted(w, deliveryIDResponse{ID: s.multicast.SendReliable(body, gid, target, topic)})
+		return
+	}
+	err = s.multicast.Send(r.Context(), body, gid, target, topic)
 	if err != nil {
 		jsonhttp.InternalServerError(w, err)
 		return
@@ -160,6 +248,29 @@ func (s *server) notify(w http.ResponseWriter, r *http.Request) {
 	jsonhttp.OK(w, nil)
 }
 
+type deliveryIDResponse struct {
+	ID uint64 `json:"id"`
+}
+
+func (s *server) deliveryHandler(w http.ResponseWriter, r *http.Request) {
+	gid := multicast.GroupID(mux.Vars(r)["gid"])
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		jsonhttp.BadRequest(w, "invalid message id")
+		return
+	}
+	status, err := s.multicast.DeliveryStatus(gid, id)
+	if err != nil {
+		if errors.Is(err, multicast.ErrDeliveryNotFound) {
+			jsonhttp.NotFound(w, err)
+			return
+		}
+		jsonhttp.InternalServerError(w, err)
+		return
+	}
+	jsonhttp.OK(w, status)
+}
+
 func (s *server) peers(w http.ResponseWriter, r *http.Request) {
 	groupName := mux.Vars(r)["gid"]
 	peers, err := s.multicast.GetGroupPeers(groupName)
@@ -169,3 +280,113 @@ func (s *server) peers(w http.ResponseWriter, r *http.Request) {
 	}
 	jsonhttp.OK(w, peers)
 }
+
+type groupResponse struct {
+	GID boson.Address `json:"gid"`
+	model.ConfigNodeGroup
+}
+
+type groupsResponse struct {
+	Groups []groupResponse `json:"groups"`
+}
+
+func newGroupResponse(o model.ConfigNodeGroup) groupResponse {
+	return groupResponse{
+		GID:             multicast.GroupID(o.Name),
+		ConfigNodeGroup: o,
+	}
+}
+
+func (s *server) groupsListHandler(w http.ResponseWriter, r *http.Request) {
+	groups := s.multicast.GroupConfigs()
+	resp := groupsResponse{Groups: make([]groupResponse, 0, len(groups))}
+	for _, o := range groups {
+		resp.Groups = append(resp.Groups, newGroupResponse(o))
+	}
+	jsonhttp.OK(w, resp)
+}
+
+func (s *server) groupCreateHandler(w http.ResponseWriter, r *http.Request) {
+	var req model.ConfigNodeGroup
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Debugf("multicast create group: decode request: %v", err)
+		jsonhttp.BadRequest(w, invalidRequest)
+		return
+	}
+	err := s.multicast.CreateGroup(req)
+	switch {
+	case errors.Is(err, multicast.ErrInvalidGroup):
+		jsonhttp.BadRequest(w, err)
+		return
+	case errors.Is(err, multicast.ErrGroupExists):
+		jsonhttp.Conflict(w, err)
+		return
+	case err != nil:
+		s.logger.Errorf("multicast create group: %v", err)
+		jsonhttp.InternalServerError(w, err)
+		return
+	}
+	o, err := s.multicast.GroupConfig(req.Name)
+	if err != nil {
+		jsonhttp.InternalServerError(w, err)
+		return
+	}
+	jsonhttp.Created(w, newGroupResponse(o))
+}
+
+func (s *server) groupGetHandler(w http.ResponseWriter, r *http.Request) {
+	o, err := s.multicast.GroupConfig(mux.Vars(r)["gid"])
+	if err != nil {
+		jsonhttp.NotFound(w, err)
+		return
+	}
+	jsonhttp.OK(w, newGroupResponse(o))
+}
+
+func (s *server) groupUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	current, err := s.multicast.GroupConfig(mux.Vars(r)["gid"])
+	if err != nil {
+		jsonhttp.NotFound(w, err)
+		return
+	}
+	var req model.ConfigNodeGroup
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Debugf("multicast update group: decode request: %v", err)
+		jsonhttp.BadRequest(w, invalidRequest)
+		return
+	}
+	req.Name = current.Name
+	err = s.multicast.UpdateGroup(req)
+	switch {
+	case errors.Is(err, multicast.ErrInvalidGroup):
+		jsonhttp.BadRequest(w, err)
+		return
+	case errors.Is(err, multicast.ErrGroupNotFound):
+		jsonhttp.NotFound(w, err)
+		return
+	case err != nil:
+		s.logger.Errorf("multicast update group: %v", err)
+		jsonhttp.InternalServerError(w, err)
+		return
+	}
+	o, err := s.multicast.GroupConfig(req.Name)
+	if err != nil {
+		jsonhttp.InternalServerError(w, err)
+		return
+	}
+	jsonhttp.OK(w, newGroupResponse(o))
+}
+
+func (s *server) groupDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	o, err := s.multicast.GroupConfig(mux.Vars(r)["gid"])
+	if err != nil {
+		jsonhttp.NotFound(w, err)
+		return
+	}
+	if err := s.multicast.RemoveGroup(o.Name, o.GType); err != nil {
+		s.logger.Errorf("multicast delete group: %v", err)
+		jsonhttp.InternalServerError(w, err)
+		return
+	}
+	jsonhttp.OK(w, nil)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/FavorLabs/favorX/pkg/file/proof"
+	"github.com/FavorLabs/favorX/pkg/jsonhttp"
+	"github.com/FavorLabs/favorX/pkg/storage"
+	"github.com/FavorLabs/favorX/pkg/tracing"
+	"github.com/gorilla/mux"
+)
+
+type proofResponse struct {
+	Reference string `json:"reference"`
+	Length    int64  `json:"length"`
+	proof.Proof
+}
+
+// bytesProofHandler returns the proof of the segment of the data at the offset
+// of the query, or of the whole leaf chunk holding it if asked for, for light
+// clients to check the data they read against the reference. Only the chunks
+// stored by the node are proven.
+func (s *server) bytesProofHandler(w http.ResponseWriter, r *http.Request) {
+	logger := tracing.NewLoggerWithTraceID(r.Context(), s.logger).Logger
+	nameOrHex := mux.Vars(r)["address"]
+
+	address, err := s.resolveNameOrAddress(nameOrHex)
+	if err != nil {
+		logger.Debugf("bytes proof: parse address %s: %v", nameOrHex, err)
+		logger.Error("bytes proof: parse address error")
+		jsonhttp.NotFound(w, nil)
+		return
+	}
+	offset, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		logger.Debugf("bytes proof: parse offset: %v", err)
+		jsonhttp.BadRequest(w, "bad offset")
+		return
+	}
+	prove := proof.ProveSegment
+	if chunk, _ := strconv.ParseBool(r.URL.Query().Get("chunk")); chunk {
+		prove = proof.Prove
+	}
+
+	p, err := prove(r.Context(), s.storer, storage.ModeGetLookup, address, offset)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			logger.Debugf("bytes proof: not found %s: %v", address, err)
+			jsonhttp.NotFound(w, "data not found")
+		case errors.Is(err, proof.ErrOffset), errors.Is(err, proof.ErrEncrypted):
+			jsonhttp.BadRequest(w, err)
+		default:
+			logger.Debugf("bytes proof: prove %s at %d: %v", address, offset, err)
+			logger.Error("bytes proof: prove error")
+			jsonhttp.InternalServerError(w, "prove error")
+		}
+		return
+	}
+	jsonhttp.OK(w, proofResponse{
+		Reference: address.String(),
+		Length:    p.Length(),
+		Proof:     p,
+	})
+}
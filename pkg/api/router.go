@@ -10,7 +10,6 @@ import (
 	"github.com/FavorLabs/favorX/pkg/boson"
 	"github.com/FavorLabs/favorX/pkg/jsonhttp"
 	"github.com/FavorLabs/favorX/pkg/logging/httpaccess"
-	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	"resenje.org/web"
@@ -59,6 +58,29 @@ func (s *server) setupRouting() {
 				web.FinalHandlerFunc(s.refreshHandler),
 			),
 		})
+		router.Handle("/auth/keys", web.ChainHandlers(s.adminAuthHandler, web.FinalHandler(jsonhttp.MethodHandler{
+			"GET": http.HandlerFunc(s.apiKeyListHandler),
+			"POST": web.ChainHandlers(
+				jsonhttp.NewMaxBodyBytesHandler(64*1024),
+				web.FinalHandlerFunc(s.apiKeyCreateHandler),
+			),
+		})))
+		router.Handle("/auth/keys/{id}", web.ChainHandlers(s.adminAuthHandler, web.FinalHandler(jsonhttp.MethodHandler{
+			"DELETE": http.HandlerFunc(s.apiKeyRevokeHandler),
+		})))
+		router.Handle("/auth/keys/{id}/audit", web.ChainHandlers(s.adminAuthHandler, web.FinalHandler(jsonhttp.MethodHandler{
+			"GET": http.HandlerFunc(s.apiKeyAuditHandler),
+		})))
+		router.Handle("/auth/roles", web.ChainHandlers(s.adminAuthHandler, web.FinalHandler(jsonhttp.MethodHandler{
+			"GET": http.HandlerFunc(s.roleListHandler),
+			"POST": web.ChainHandlers(
+				jsonhttp.NewMaxBodyBytesHandler(64*1024),
+				web.FinalHandlerFunc(s.roleAddHandler),
+			),
+		})))
+		router.Handle("/auth/roles/{name}", web.ChainHandlers(s.adminAuthHandler, web.FinalHandler(jsonhttp.MethodHandler{
+			"DELETE": http.HandlerFunc(s.roleRemoveHandler),
+		})))
 	}
 
 	handle("/apiPort", jsonhttp.MethodHandler{
@@ -83,6 +105,13 @@ func (s *server) setupRouting() {
 		),
 	})
 
+	handle("/bytes/{address}/proof", jsonhttp.MethodHandler{
+		"GET": web.ChainHandlers(
+			s.newTracingHandler("bytes-proof"),
+			web.FinalHandlerFunc(s.bytesProofHandler),
+		),
+	})
+
 	handle("/chunks", jsonhttp.MethodHandler{
 		"POST": web.ChainHandlers(
 			jsonhttp.NewMaxBodyBytesHandler(boson.ChunkWithSpanSize),
@@ -149,6 +178,27 @@ func (s *server) setupRouting() {
 		),
 	})
 
+	handle("/versions/{address}", jsonhttp.MethodHandler{
+		"GET": web.ChainHandlers(
+			s.newTracingHandler("file-versions"),
+			web.FinalHandlerFunc(s.fileVersionsHandler),
+		),
+	})
+
+	handle("/versions/{address}/diff", jsonhttp.MethodHandler{
+		"GET": web.ChainHandlers(
+			s.newTracingHandler("file-version-diff"),
+			web.FinalHandlerFunc(s.fileVersionDiffHandler),
+		),
+	})
+
+	handle("/versions/{address}/rollback/{target}", jsonhttp.MethodHandler{
+		"POST": web.ChainHandlers(
+			s.newTracingHandler("file-rollback"),
+			web.FinalHandlerFunc(s.fileRollbackHandler),
+		),
+	})
+
 	handle("/pins", web.ChainHandlers(
 		s.gatewayModeForbidEndpointHandler,
 		web.FinalHandler(jsonhttp.MethodHandler{
@@ -186,6 +236,20 @@ func (s *server) setupRouting() {
 		})),
 	)
 
+	handle("/traffic/ledger", web.ChainHandlers(
+		s.gatewayModeForbidEndpointHandler,
+		web.FinalHandler(jsonhttp.MethodHandler{
+			"GET": http.HandlerFunc(s.trafficLedger),
+		})),
+	)
+
+	handle("/traffic/ledger/totals", web.ChainHandlers(
+		s.gatewayModeForbidEndpointHandler,
+		web.FinalHandler(jsonhttp.MethodHandler{
+			"GET": http.HandlerFunc(s.trafficLedgerTotals),
+		})),
+	)
+
 	handle("/traffic/cash/{address}", web.ChainHandlers(
 		s.gatewayModeForbidEndpointHandler,
 		web.FinalHandler(jsonhttp.MethodHandler{
@@ -204,6 +268,15 @@ func (s *server) setupRouting() {
 		),
 	})
 
+	handle("/groups", jsonhttp.MethodHandler{
+		"GET":  http.HandlerFunc(s.groupsListHandler),
+		"POST": http.HandlerFunc(s.groupCreateHandler),
+	})
+	handle("/groups/{gid}", jsonhttp.MethodHandler{
+		"GET":    http.HandlerFunc(s.groupGetHandler),
+		"PUT":    http.HandlerFunc(s.groupUpdateHandler),
+		"DELETE": http.HandlerFunc(s.groupDeleteHandler),
+	})
 	handle("/group/join/{gid}", jsonhttp.MethodHandler{
 		"POST":   http.HandlerFunc(s.groupJoinHandler),
 		"DELETE": http.HandlerFunc(s.groupLeaveHandler),
@@ -224,6 +297,9 @@ func (s *server) setupRouting() {
 	handle("/group/peers/{gid}", jsonhttp.MethodHandler{
 		"GET": http.HandlerFunc(s.peers),
 	})
+	handle("/group/delivery/{gid}/{id}", jsonhttp.MethodHandler{
+		"GET": http.HandlerFunc(s.deliveryHandler),
+	})
 
 	handle("/buffer", jsonhttp.MethodHandler{
 		"POST": http.HandlerFunc(s.bufferUploadHandler),
@@ -236,7 +312,7 @@ func (s *server) setupRouting() {
 
 	s.Handler = web.ChainHandlers(
 		httpaccess.NewHTTPAccessLogHandler(s.logger, logrus.InfoLevel, s.tracer, "api access"),
-		handlers.CompressHandler,
+		compressHandler,
 		s.responseCodeMetricsHandler,
 		s.pageviewMetricsHandler,
 		func(h http.Handler) http.Handler {
@@ -244,7 +320,7 @@ func (s *server) setupRouting() {
 				if o := r.Header.Get("Origin"); o != "" && s.checkOrigin(r) {
 					w.Header().Set("Access-Control-Allow-Credentials", "true")
 					w.Header().Set("Access-Control-Allow-Origin", o)
-					w.Header().Set("Access-Control-Allow-Headers", "X-Session-Token, User-Agent, Origin, Accept, Authorization, Content-Type, X-Requested-With, Access-Control-Request-Headers, Access-Control-Request-Method, Tag, Pin, Encrypt, Index-Document, Error-Document, Collection, Collection-Name, Reference-Link")
+					w.Header().Set("Access-Control-Allow-Headers", "X-Session-Token, User-Agent, Origin, Accept, Authorization, Content-Type, X-Requested-With, Access-Control-Request-Headers, Access-Control-Request-Method, Tag, Pin, Encrypt, Index-Document, Error-Document, Collection, Collection-Name, Reference-Link, Compression, Chunking")
 					w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS, POST, PUT, DELETE")
 					w.Header().Set("Access-Control-Max-Age", "3600")
 				}
@@ -2,21 +2,28 @@ package api
 
 import (
 	"context"
+	"errors"
 	"math/big"
 	"net/http"
 	"sort"
+	"strconv"
+	"time"
 
 	"github.com/FavorLabs/favorX/pkg/boson"
 	"github.com/FavorLabs/favorX/pkg/jsonhttp"
+	"github.com/FavorLabs/favorX/pkg/settlement/traffic"
+	chequePkg "github.com/FavorLabs/favorX/pkg/settlement/traffic/cheque"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gorilla/mux"
 )
 
 type trafficInfo struct {
-	Balance          *big.Int `json:"balance"`
-	AvailableBalance *big.Int `json:"availableBalance"`
-	TotalSendTraffic *big.Int `json:"totalSendTraffic"`
-	ReceivedTraffic  *big.Int `json:"receivedTraffic"`
+	Balance          *big.Int       `json:"balance"`
+	AvailableBalance *big.Int       `json:"availableBalance"`
+	TotalSendTraffic *big.Int       `json:"totalSendTraffic"`
+	ReceivedTraffic  *big.Int       `json:"receivedTraffic"`
+	ChainID          int64          `json:"chainID,omitempty"`
+	Chains           []*trafficInfo `json:"chains,omitempty"`
 }
 
 type trafficCheque struct {
@@ -27,6 +34,7 @@ type trafficCheque struct {
 	Total               *big.Int      `json:"total"`
 	UnCashed            *big.Int      `json:"unCashed"`
 	Status              int           `json:"status"`
+	ChainID             int64         `json:"chainID"`
 }
 
 func (s *server) trafficInfo(w http.ResponseWriter, r *http.Request) {
@@ -37,12 +45,21 @@ func (s *server) trafficInfo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var traffic trafficInfo
-	traffic.Balance = tra.Balance
-	traffic.AvailableBalance = tra.AvailableBalance
-	traffic.TotalSendTraffic = tra.TotalSendTraffic
-	traffic.ReceivedTraffic = tra.ReceivedTraffic
-	jsonhttp.OK(w, traffic)
+	jsonhttp.OK(w, newTrafficInfo(tra))
+}
+
+func newTrafficInfo(tra *traffic.TrafficInfo) *trafficInfo {
+	info := &trafficInfo{
+		Balance:          tra.Balance,
+		AvailableBalance: tra.AvailableBalance,
+		TotalSendTraffic: tra.TotalSendTraffic,
+		ReceivedTraffic:  tra.ReceivedTraffic,
+		ChainID:          tra.ChainID,
+	}
+	for _, chain := range tra.Chains {
+		info.Chains = append(info.Chains, newTrafficInfo(chain))
+	}
+	return info
 }
 
 func (s *server) address(w http.ResponseWriter, r *http.Request) {
@@ -72,6 +89,7 @@ func (s *server) trafficCheques(w http.ResponseWriter, r *http.Request) {
 			Total:               v.Total,
 			UnCashed:            v.Uncashed,
 			Status:              v.Status,
+			ChainID:             v.ChainID,
 		}
 		chequeList = append(chequeList, cheque)
 	}
@@ -102,3 +120,92 @@ func (s *server) cashCheque(w http.ResponseWriter, r *http.Request) {
 	}
 	jsonhttp.OK(w, out{Hash: hash})
 }
+
+// ledgerFilter parses the peer and the from and to unix times selecting the
+// ledger entries.
+func (s *server) ledgerFilter(r *http.Request) (filter chequePkg.LedgerFilter, err error) {
+	query := r.URL.Query()
+	if peer := query.Get("peer"); peer != "" {
+		if filter.Peer, err = s.resolveNameOrAddress(peer); err != nil {
+			return filter, errors.New("invalid peer")
+		}
+	}
+	parseTime := func(name string) (time.Time, error) {
+		value := query.Get(name)
+		if value == "" {
+			return time.Time{}, nil
+		}
+		sec, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || sec < 0 {
+			return time.Time{}, errors.New("invalid " + name)
+		}
+		return time.Unix(sec, 0), nil
+	}
+	if filter.From, err = parseTime("from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTime("to"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func (s *server) trafficLedger(w http.ResponseWriter, r *http.Request) {
+	filter, err := s.ledgerFilter(r)
+	if err != nil {
+		jsonhttp.BadRequest(w, err)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		jsonhttp.BadRequest(w, "invalid format")
+		return
+	}
+	entries, err := s.traffic.Ledger(filter)
+	if err != nil {
+		s.logger.Errorf("api trafficLedger: query failed: %v", err)
+		jsonhttp.InternalServerError(w, err)
+		return
+	}
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="ledger.csv"`)
+		if err := chequePkg.WriteLedgerCSV(w, entries); err != nil {
+			s.logger.Errorf("api trafficLedger: write csv: %v", err)
+		}
+		return
+	}
+	jsonhttp.OK(w, struct {
+		Entries []*chequePkg.LedgerEntry `json:"entries"`
+	}{
+		Entries: entries,
+	})
+}
+
+func (s *server) trafficLedgerTotals(w http.ResponseWriter, r *http.Request) {
+	filter, err := s.ledgerFilter(r)
+	if err != nil {
+		jsonhttp.BadRequest(w, err)
+		return
+	}
+	period := chequePkg.LedgerDay
+	if p := r.URL.Query().Get("period"); p != "" {
+		period = chequePkg.LedgerPeriod(p)
+	}
+	totals, err := s.traffic.LedgerTotals(filter, period)
+	if err != nil {
+		if errors.Is(err, chequePkg.ErrInvalidPeriod) {
+			jsonhttp.BadRequest(w, err)
+			return
+		}
+		s.logger.Errorf("api trafficLedgerTotals: query failed: %v", err)
+		jsonhttp.InternalServerError(w, err)
+		return
+	}
+	jsonhttp.OK(w, struct {
+		Totals []*chequePkg.LedgerTotal `json:"totals"`
+	}{
+		Totals: totals,
+	})
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/file/loadsave"
+	"github.com/FavorLabs/favorX/pkg/fileinfo"
+	"github.com/FavorLabs/favorX/pkg/jsonhttp"
+	"github.com/FavorLabs/favorX/pkg/localstore/filestore"
+	"github.com/FavorLabs/favorX/pkg/manifest"
+	"github.com/FavorLabs/favorX/pkg/storage"
+	"github.com/FavorLabs/favorX/pkg/tracing"
+	"github.com/gorilla/mux"
+)
+
+type RollbackResponse struct {
+	Reference boson.Address   `json:"reference"`
+	Dropped   []boson.Address `json:"dropped"`
+}
+
+// fileVersionsHandler lists the version chain of a file or collection, newest first.
+func (s *server) fileVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	logger := tracing.NewLoggerWithTraceID(r.Context(), s.logger)
+	nameOrHex := mux.Vars(r)["address"]
+	reference, err := s.resolveNameOrAddress(nameOrHex)
+	if err != nil {
+		logger.Debugf("file versions: parse address %s: %v", nameOrHex, err)
+		logger.Error("file versions: parse address")
+		jsonhttp.BadRequest(w, "invalid address")
+		return
+	}
+
+	versions, err := s.fileInfo.GetFileVersions(reference)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			jsonhttp.NotFound(w, nil)
+			return
+		}
+		logger.Debugf("file versions: get versions %s: %v", reference, err)
+		logger.Error("file versions: get versions")
+		jsonhttp.InternalServerError(w, err)
+		return
+	}
+
+	jsonhttp.OK(w, versions)
+}
+
+// fileVersionDiffHandler compares two versions of a collection at the manifest
+// path level. Both versions must be part of the version chain of the collection.
+func (s *server) fileVersionDiffHandler(w http.ResponseWriter, r *http.Request) {
+	logger := tracing.NewLoggerWithTraceID(r.Context(), s.logger)
+	nameOrHex := mux.Vars(r)["address"]
+	reference, err := s.resolveNameOrAddress(nameOrHex)
+	if err != nil {
+		logger.Debugf("file version diff: parse address %s: %v", nameOrHex, err)
+		logger.Error("file version diff: parse address")
+		jsonhttp.BadRequest(w, "invalid address")
+		return
+	}
+	query := r.URL.Query()
+	from, err := s.resolveNameOrAddress(query.Get("from"))
+	if err != nil {
+		logger.Debugf("file version diff: parse from address %s: %v", query.Get("from"), err)
+		logger.Error("file version diff: parse from address")
+		jsonhttp.BadRequest(w, "invalid from address")
+		return
+	}
+	toAddress := reference
+	if to := query.Get("to"); to != "" {
+		toAddress, err = s.resolveNameOrAddress(to)
+		if err != nil {
+			logger.Debugf("file version diff: parse to address %s: %v", to, err)
+			logger.Error("file version diff: parse to address")
+			jsonhttp.BadRequest(w, "invalid to address")
+			return
+		}
+	}
+
+	versions, err := s.fileInfo.GetFileVersions(reference)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			jsonhttp.NotFound(w, nil)
+			return
+		}
+		logger.Debugf("file version diff: get versions %s: %v", reference, err)
+		logger.Error("file version diff: get versions")
+		jsonhttp.InternalServerError(w, nil)
+		return
+	}
+	if !isVersion(versions, from) {
+		jsonhttp.BadRequest(w, "from is not a version of this file")
+		return
+	}
+	if !isVersion(versions, toAddress) {
+		jsonhttp.BadRequest(w, "to is not a version of this file")
+		return
+	}
+
+	diffs, err := s.fileInfo.DiffFileVersions(r.Context(), from, toAddress)
+	if err != nil {
+		logger.Debugf("file version diff: %s..%s: %v", from, toAddress, err)
+		logger.Error("file version diff: compare manifests")
+		if errors.Is(err, storage.ErrNotFound) {
+			jsonhttp.NotFound(w, nil)
+			return
+		}
+		jsonhttp.InternalServerError(w, nil)
+		return
+	}
+
+	jsonhttp.OK(w, diffs)
+}
+
+// isVersion reports whether the root cid is one of the versions.
+func isVersion(versions []fileinfo.FileVersion, rootCid boson.Address) bool {
+	for _, v := range versions {
+		if v.RootCid.Equal(rootCid) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileRollbackHandler rolls a collection back to an earlier root cid of its version chain.
+func (s *server) fileRollbackHandler(w http.ResponseWriter, r *http.Request) {
+	logger := tracing.NewLoggerWithTraceID(r.Context(), s.logger)
+	ctx := r.Context()
+	nameOrHex := mux.Vars(r)["address"]
+	reference, err := s.resolveNameOrAddress(nameOrHex)
+	if err != nil {
+		logger.Debugf("file rollback: parse address %s: %v", nameOrHex, err)
+		logger.Error("file rollback: parse address")
+		jsonhttp.BadRequest(w, "invalid address")
+		return
+	}
+	target, err := s.resolveNameOrAddress(mux.Vars(r)["target"])
+	if err != nil {
+		logger.Debugf("file rollback: parse target %s: %v", mux.Vars(r)["target"], err)
+		logger.Error("file rollback: parse target")
+		jsonhttp.BadRequest(w, "invalid target address")
+		return
+	}
+
+	ls := loadsave.NewReadonly(s.storer, storage.ModeGetRequest)
+	m, err := manifest.NewDefaultManifestReference(target, ls)
+	if err != nil {
+		logger.Debugf("file rollback: not manifest %s: %v", target, err)
+		logger.Errorf("file rollback: not manifest %s", target)
+		jsonhttp.NotFound(w, err)
+		return
+	}
+	bitLen := 0
+	if err = m.IterateAddresses(ctx, func(reference boson.Address) error {
+		bitLen++
+		return nil
+	}); err != nil {
+		logger.Debugf("file rollback: iterate address error: %v", err)
+		logger.Error("file rollback: iterate address error")
+		jsonhttp.NotFound(w, err)
+		return
+	}
+
+	dropped, err := s.fileInfo.RollbackFile(reference, target)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			jsonhttp.NotFound(w, nil)
+		case errors.Is(err, filestore.ErrVersionNotFound):
+			jsonhttp.BadRequest(w, "target is not a version of this file")
+		default:
+			logger.Debugf("file rollback: %s to %s: %v", reference, target, err)
+			logger.Error("file rollback: rollback file")
+			jsonhttp.InternalServerError(w, err)
+		}
+		return
+	}
+
+	pinned := false
+	for _, d := range dropped {
+		s.chunkInfo.CancelFindChunkInfo(d)
+		has, err := s.pinning.HasPin(d)
+		if err != nil {
+			logger.Debugf("file rollback: checking pin for %s: %v", d, err)
+			continue
+		}
+		if has {
+			pinned = true
+			if err = s.pinning.DeletePin(ctx, d); err != nil {
+				logger.Errorf("file rollback: delete pin for %s: %v", d, err)
+			}
+		}
+	}
+	if pinned {
+		if err = s.pinning.CreatePin(ctx, target, false); err != nil {
+			logger.Debugf("file rollback: creation of pin for %s failed: %v", target, err)
+			logger.Error("file rollback: creation of pin failed")
+		}
+	}
+	if err = s.fileInfo.PinFile(target, pinned); err != nil {
+		logger.Errorf("file rollback: update fileinfo pin failed: %v", err)
+	}
+
+	if err = s.chunkInfo.OnFileUpload(ctx, target, int64(bitLen)); err != nil {
+		logger.Debugf("file rollback: chunk transfer data err: %v", err)
+		logger.Errorf("file rollback: chunk transfer data err")
+		jsonhttp.InternalServerError(w, "chunk transfer data error")
+		return
+	}
+
+	jsonhttp.OK(w, RollbackResponse{
+		Reference: target,
+		Dropped:   dropped,
+	})
+}
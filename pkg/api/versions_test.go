@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/boson/test"
+	"github.com/FavorLabs/favorX/pkg/fileinfo"
+	"github.com/FavorLabs/favorX/pkg/jsonhttp/jsonhttptest"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/storage"
+	"github.com/gorilla/mux"
+)
+
+type versionsFileInfoMock struct {
+	fileinfo.Interface
+	current  boson.Address
+	versions []fileinfo.FileVersion
+}
+
+func (m *versionsFileInfoMock) GetFileVersions(rootCid boson.Address) ([]fileinfo.FileVersion, error) {
+	if !rootCid.Equal(m.current) {
+		return nil, storage.ErrNotFound
+	}
+	return m.versions, nil
+}
+
+func (m *versionsFileInfoMock) DiffFileVersions(_ context.Context, from, to boson.Address) ([]fileinfo.ManifestDiff, error) {
+	return []fileinfo.ManifestDiff{{Path: "a.txt", Change: fileinfo.DiffModified, From: from, To: to}}, nil
+}
+
+func TestFileVersionDiff(t *testing.T) {
+	v0, v1, other := test.RandomAddress(), test.RandomAddress(), test.RandomAddress()
+	s := &server{
+		fileInfo: &versionsFileInfoMock{
+			current:  v1,
+			versions: []fileinfo.FileVersion{{RootCid: v1}, {RootCid: v0}},
+		},
+		logger: logging.New(io.Discard, 0),
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/versions/{address}/diff", s.fileVersionDiffHandler).Methods(http.MethodGet)
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+	client := ts.Client()
+
+	var diffs []fileinfo.ManifestDiff
+	jsonhttptest.Request(t, client, http.MethodGet, ts.URL+"/versions/"+v1.String()+"/diff?from="+v0.String(), http.StatusOK,
+		jsonhttptest.WithUnmarshalJSONResponse(&diffs),
+	)
+	if len(diffs) != 1 || !diffs[0].From.Equal(v0) || !diffs[0].To.Equal(v1) {
+		t.Fatalf("got diffs %+v, want %s..%s", diffs, v0, v1)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"from outside the chain", "/versions/" + v1.String() + "/diff?from=" + other.String(), http.StatusBadRequest},
+		{"to outside the chain", "/versions/" + v1.String() + "/diff?from=" + v0.String() + "&to=" + other.String(), http.StatusBadRequest},
+		{"unknown file", "/versions/" + other.String() + "/diff?from=" + v0.String(), http.StatusNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			jsonhttptest.Request(t, client, http.MethodGet, ts.URL+tc.path, tc.status)
+		})
+	}
+}
@@ -10,9 +10,12 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/storage"
 	"github.com/casbin/casbin/v2"
 	"github.com/casbin/casbin/v2/model"
 	"golang.org/x/crypto/bcrypt"
@@ -28,6 +31,18 @@ type Authenticator struct {
 	ciph         *encrypter
 	enforcer     *casbin.Enforcer
 	log          logging.Logger
+
+	mu      sync.Mutex // guards the api keys, the custom roles and the pending usage
+	store   storage.StateStorer
+	keys    map[string]*keyRecord
+	roles   map[string]Role
+	pending []pendingAudit  // audit entries not written to the store yet
+	dirty   map[string]bool // keys with usage not written to the store yet
+
+	flushMu sync.Mutex // serializes the writes of the usage of the api keys
+	flushC  chan struct{}
+	quit    chan struct{}
+	wg      sync.WaitGroup
 }
 
 func New(encryptionKey, passwordHash string, logger logging.Logger) (*Authenticator, error) {
@@ -38,11 +53,14 @@ func New(encryptionKey, passwordHash string, logger logging.Logger) (*Authentica
 	[policy_definition]
 	p = sub, obj, act
 
+	[role_definition]
+	g = _, _
+
 	[policy_effect]
 	e = some(where (p.eft == allow))
 
 	[matchers]
-	m = (r.sub == p.sub || r.sub == "master") && (keyMatch(r.obj, p.obj) || keyMatch(r.obj, '/v1'+p.obj)) && regexMatch(r.act, p.act)`)
+	m = (g(r.sub, p.sub) || g(r.sub, "master")) && (keyMatch(r.obj, p.obj) || keyMatch(r.obj, '/v1'+p.obj)) && regexMatch(r.act, p.act)`)
 
 	if err != nil {
 		return nil, err
@@ -146,22 +164,17 @@ func (a *Authenticator) RefreshKey(apiKey string, expiryDuration int) (string, e
 	return apiKey, nil
 }
 
+// Enforce reports whether the token or the API key is allowed the action on
+// the object, a path followed by the query of the request if it has one. The
+// query is only checked against the restrictions of API keys.
 func (a *Authenticator) Enforce(apiKey, obj, act string) (bool, error) {
-	decoded, err := base64.StdEncoding.DecodeString(apiKey)
-	if err != nil {
-		a.log.Error("decode token", err)
-		return false, err
+	if strings.HasPrefix(apiKey, apiKeyPrefix) {
+		return a.enforceKey(apiKey, obj, act)
 	}
+	obj, _, _ = strings.Cut(obj, "?")
 
-	decryptedBytes, err := a.ciph.decrypt(decoded)
+	ar, err := a.decodeToken(apiKey)
 	if err != nil {
-		a.log.Error("decrypt token", err)
-		return false, err
-	}
-
-	var ar authRecord
-	if err := json.Unmarshal(decryptedBytes, &ar); err != nil {
-		a.log.Error("unmarshal token", err)
 		return false, err
 	}
 
@@ -179,6 +192,42 @@ func (a *Authenticator) Enforce(apiKey, obj, act string) (bool, error) {
 	return allow, nil
 }
 
+// Expiry returns the time the token or the API key expires at. The zero time
+// is returned for API keys that never expire.
+func (a *Authenticator) Expiry(apiKey string) (time.Time, error) {
+	if strings.HasPrefix(apiKey, apiKeyPrefix) {
+		return a.keyExpiry(apiKey)
+	}
+
+	ar, err := a.decodeToken(apiKey)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ar.Expiry, nil
+}
+
+func (a *Authenticator) decodeToken(apiKey string) (authRecord, error) {
+	var ar authRecord
+
+	decoded, err := base64.StdEncoding.DecodeString(apiKey)
+	if err != nil {
+		a.log.Error("decode token", err)
+		return ar, err
+	}
+
+	decryptedBytes, err := a.ciph.decrypt(decoded)
+	if err != nil {
+		a.log.Error("decrypt token", err)
+		return ar, err
+	}
+
+	if err := json.Unmarshal(decryptedBytes, &ar); err != nil {
+		a.log.Error("unmarshal token", err)
+		return ar, err
+	}
+	return ar, nil
+}
+
 type encrypter struct {
 	gcm cipher.AEAD
 }
@@ -238,13 +287,19 @@ func applyPolicies(e *casbin.Enforcer) error {
 		{"consumer", "/file/*/*", "GET"},
 		{"consumer", "/manifest/*", "GET"},
 		{"consumer", "/manifest/*/*", "GET"},
+		{"consumer", "/versions/*", "GET"},
+		{"consumer", "/versions/*/diff", "GET"},
+		{"creator", "/versions/*/rollback/*", "POST"},
 		{"creator", "/pins/*", "(GET)|(DELETE)|(POST)"},
 		{"consumer", "/group/peers/*", "GET"},
 		{"consumer", "/group/multicast/*", "POST"},
 		{"consumer", "/group/send/*/*", "POST"},
 		{"consumer", "/group/notify/*/*", "POST"},
+		{"consumer", "/group/delivery/*/*", "GET"},
 		{"consumer", "/group/join/*", "(DELETE)|(POST)"},
 		{"consumer", "/group/observe/*", "(DELETE)|(POST)"},
+		{"consumer", "/groups", "(GET)|(POST)"},
+		{"consumer", "/groups/*", "(GET)|(PUT)|(DELETE)"},
 		{"maintainer", "/pins", "GET"},
 
 		// debug api
@@ -268,9 +323,20 @@ func applyPolicies(e *casbin.Enforcer) error {
 		{"maintainer", "/keystore", "(GET)|(POST)"},
 		{"maintainer", "/privatekey", "GET"},
 		{"maintainer", "/transaction", "POST"},
+		{"maintainer", "/config/reload", "POST"},
 
 		// multicast
 		{"maintainer", "/topology/group", "GET"},
+		{"maintainer", "/topology/group/discovery", "GET"},
+
+		// json-rpc
+		{"consumer", "/rpc/rpc/*", "CALL"},
+		{"consumer", "/rpc/group/*", "(CALL)|(SUBSCRIBE)"},
+		{"consumer", "/rpc/chunkInfo/*", "SUBSCRIBE"},
+		{"maintainer", "/rpc/p2p/*", "SUBSCRIBE"},
+		{"maintainer", "/rpc/traffic/*", "SUBSCRIBE"},
+		{"maintainer", "/rpc/retrieval/*", "SUBSCRIBE"},
+		{"maintainer", "/rpc/oracle/*", "SUBSCRIBE"},
 	})
 
 	return err
@@ -31,12 +31,21 @@ func PermissionCheckHandler(auth auth) func(h http.Handler) http.Handler {
 
 			apiKey := keys[1]
 
-			allowed, err := auth.Enforce(apiKey, r.URL.Path, r.Method)
+			obj := r.URL.Path
+			if r.URL.RawQuery != "" {
+				obj += "?" + r.URL.RawQuery
+			}
+			allowed, err := auth.Enforce(apiKey, obj, r.Method)
 			if errors.Is(err, ErrTokenExpired) {
 				jsonhttp.Unauthorized(w, "Token expired")
 				return
 			}
 
+			if errors.Is(err, ErrInvalidKey) {
+				jsonhttp.Unauthorized(w, "Invalid security token")
+				return
+			}
+
 			if err != nil {
 				jsonhttp.InternalServerError(w, "Error occurred while validating the security token")
 				return
@@ -0,0 +1,692 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/storage"
+)
+
+const (
+	// apiKeyPrefix starts every API key, which tells them apart from the
+	// tokens issued with the admin password.
+	apiKeyPrefix = "fxk_"
+
+	keyIDLength     = 8
+	keySecretLength = 32
+
+	keyStorePrefix   = "auth-key-"
+	roleStorePrefix  = "auth-role-"
+	auditStorePrefix = "auth-audit-"
+
+	// auditLimit is the number of audit entries kept for every key.
+	auditLimit = 1000
+	// auditFlushInterval is the interval the audit entries and the usage of
+	// the keys are written to the state store at.
+	auditFlushInterval = time.Second
+	// auditFlushSize is the number of pending audit entries that are written
+	// before the interval elapses.
+	auditFlushSize = 256
+)
+
+var (
+	// ErrNoStateStore is returned by the API key operations before a state
+	// store is set.
+	ErrNoStateStore = errors.New("api keys need a state store")
+	// ErrInvalidKey is returned for unknown and revoked API keys.
+	ErrInvalidKey = errors.New("invalid api key")
+	// ErrKeyNotFound is returned when there is no API key with the identifier.
+	ErrKeyNotFound = errors.New("api key not found")
+	// ErrUnknownRole is returned when a role is not defined.
+	ErrUnknownRole = errors.New("unknown role")
+	// ErrInvalidRole is returned when a custom role cannot be defined.
+	ErrInvalidRole = errors.New("invalid role")
+	// ErrRoleInUse is returned when a role used by an API key is removed.
+	ErrRoleInUse = errors.New("role is used by api keys")
+)
+
+// builtinRoles are defined by applyPolicies and cannot be changed.
+var builtinRoles = []string{"consumer", "creator", "maintainer", "master"}
+
+// rootResources are the first path segments of the endpoints that take root
+// addresses, with the positions of the path segments holding them.
+var rootResources = map[string][]int{
+	"bytes":        {1},
+	"chunks":       {1},
+	"file":         {1},
+	"fileRegister": {1},
+	"manifest":     {1},
+	"versions":     {1, 3}, // the target of /versions/{address}/rollback/{target}
+	"pins":         {1},
+}
+
+// rootQueries are the query parameters that take root addresses, like the
+// versions compared by /versions/{address}/diff.
+var rootQueries = []string{"from", "to"}
+
+// scopedRPCNamespaces are the json-rpc namespaces that take groups or root
+// addresses as parameters, which the restrictions of a key cannot be checked
+// against.
+var scopedRPCNamespaces = map[string]bool{
+	"group":     true,
+	"chunkInfo": true,
+	"oracle":    true,
+}
+
+var roleNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// KeyOptions configure a new API key.
+type KeyOptions struct {
+	Name string
+	Role string
+	// Expiry is the lifetime of the key, it never expires if it is zero.
+	Expiry time.Duration
+	// Groups restricts the multicast endpoints to these groups.
+	Groups []string
+	// Roots restricts the content endpoints to these root addresses.
+	Roots []boson.Address
+}
+
+// APIKey describes an API key. The key itself is only returned when it is
+// created, the state store keeps its hash.
+type APIKey struct {
+	ID       string          `json:"id"`
+	Name     string          `json:"name"`
+	Role     string          `json:"role"`
+	Groups   []string        `json:"groups,omitempty"`
+	Roots    []boson.Address `json:"roots,omitempty"`
+	Created  time.Time       `json:"created"`
+	Expiry   *time.Time      `json:"expiry,omitempty"`
+	Revoked  *time.Time      `json:"revoked,omitempty"`
+	LastUsed *time.Time      `json:"lastUsed,omitempty"`
+	Uses     uint64          `json:"uses"`
+}
+
+// keyRecord is an API key as kept in the state store.
+type keyRecord struct {
+	APIKey
+	Hash     []byte `json:"hash"`
+	AuditSeq uint64 `json:"auditSeq"`
+}
+
+// AuditEntry records a request made with an API key.
+type AuditEntry struct {
+	Time    time.Time `json:"time"`
+	Method  string    `json:"method"`
+	Path    string    `json:"path"`
+	Allowed bool      `json:"allowed"`
+}
+
+// pendingAudit is an audit entry of a key that is not written yet.
+type pendingAudit struct {
+	id    string
+	seq   uint64
+	entry AuditEntry
+}
+
+// Policy allows the action, a regular expression of HTTP methods, on the
+// object, a path pattern matched with keyMatch.
+type Policy struct {
+	Object string `json:"object"`
+	Action string `json:"action"`
+}
+
+// Role is a named set of policies. A role is granted the policies of the
+// roles it inherits too.
+type Role struct {
+	Name     string   `json:"name"`
+	Inherits []string `json:"inherits,omitempty"`
+	Policies []Policy `json:"policies,omitempty"`
+	BuiltIn  bool     `json:"builtIn"`
+}
+
+// SetStateStore loads the API keys and the custom roles from the state store
+// and keeps the new ones there. The usage of the keys is written in batches
+// in the background until Close is called.
+func (a *Authenticator) SetStateStore(store storage.StateStorer) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	roles := make(map[string]Role)
+	err := store.Iterate(roleStorePrefix, func(k, _ []byte) (bool, error) {
+		var r Role
+		if err := store.Get(string(k), &r); err != nil {
+			return true, err
+		}
+		roles[r.Name] = r
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("load roles: %w", err)
+	}
+	for _, r := range roles {
+		if err := a.applyRole(r); err != nil {
+			return fmt.Errorf("load role %s: %w", r.Name, err)
+		}
+	}
+
+	keys := make(map[string]*keyRecord)
+	err = store.Iterate(keyStorePrefix, func(k, _ []byte) (bool, error) {
+		var r keyRecord
+		if err := store.Get(string(k), &r); err != nil {
+			return true, err
+		}
+		keys[r.ID] = &r
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("load api keys: %w", err)
+	}
+
+	a.store = store
+	a.roles = roles
+	a.keys = keys
+	a.dirty = make(map[string]bool)
+	if a.quit == nil {
+		a.flushC = make(chan struct{}, 1)
+		a.quit = make(chan struct{})
+		a.wg.Add(1)
+		go a.flushLoop(a.quit)
+	}
+	return nil
+}
+
+// Close writes the pending usage of the API keys and stops writing it in
+// the background.
+func (a *Authenticator) Close() error {
+	a.mu.Lock()
+	quit := a.quit
+	a.quit = nil
+	a.mu.Unlock()
+	if quit == nil {
+		return nil
+	}
+	close(quit)
+	a.wg.Wait()
+	return a.flush()
+}
+
+// flushLoop writes the usage of the API keys every auditFlushInterval or once
+// auditFlushSize audit entries are pending.
+func (a *Authenticator) flushLoop(quit chan struct{}) {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(auditFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+		case <-a.flushC:
+		}
+		if err := a.flush(); err != nil {
+			a.log.Debugf("auth: write api key usage: %v", err)
+			a.log.Error("auth: write api key usage")
+		}
+	}
+}
+
+// flush writes the pending audit entries and the records of the keys used
+// since the last flush. The store is written without holding a.mu, so that
+// the requests are not blocked by it.
+func (a *Authenticator) flush() error {
+	a.flushMu.Lock()
+	defer a.flushMu.Unlock()
+
+	a.mu.Lock()
+	store := a.store
+	pending := a.pending
+	a.pending = nil
+	records := make([]keyRecord, 0, len(a.dirty))
+	for id := range a.dirty {
+		records = append(records, *a.keys[id])
+	}
+	a.dirty = make(map[string]bool)
+	a.mu.Unlock()
+
+	if store == nil {
+		return nil
+	}
+	for _, p := range pending {
+		if err := store.Put(auditKeyPrefix(p.id)+auditSeq(p.seq), p.entry); err != nil {
+			return err
+		}
+		if p.seq > auditLimit {
+			if err := store.Delete(auditKeyPrefix(p.id) + auditSeq(p.seq-auditLimit)); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return err
+			}
+		}
+	}
+	for _, r := range records {
+		if err := store.Put(keyStorePrefix+r.ID, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateKey creates an API key and returns it together with its description.
+func (a *Authenticator) CreateKey(o KeyOptions) (APIKey, string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.store == nil {
+		return APIKey{}, "", ErrNoStateStore
+	}
+	if o.Expiry < 0 {
+		return APIKey{}, "", ErrExpiry
+	}
+	if !a.hasRole(o.Role) {
+		return APIKey{}, "", fmt.Errorf("%w: %s", ErrUnknownRole, o.Role)
+	}
+
+	b := make([]byte, keyIDLength+keySecretLength)
+	if _, err := rand.Read(b); err != nil {
+		return APIKey{}, "", err
+	}
+	id := hex.EncodeToString(b[:keyIDLength])
+	secret := hex.EncodeToString(b[keyIDLength:])
+	hash := sha256.Sum256([]byte(secret))
+
+	now := time.Now().UTC()
+	r := &keyRecord{
+		APIKey: APIKey{
+			ID:      id,
+			Name:    o.Name,
+			Role:    o.Role,
+			Groups:  o.Groups,
+			Roots:   o.Roots,
+			Created: now,
+		},
+		Hash: hash[:],
+	}
+	if o.Expiry > 0 {
+		expiry := now.Add(o.Expiry)
+		r.Expiry = &expiry
+	}
+	if err := a.store.Put(keyStorePrefix+id, r); err != nil {
+		return APIKey{}, "", err
+	}
+	a.keys[id] = r
+	return r.APIKey, apiKeyPrefix + id + "_" + secret, nil
+}
+
+// Keys returns the API keys ordered by their creation time.
+func (a *Authenticator) Keys() ([]APIKey, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.store == nil {
+		return nil, ErrNoStateStore
+	}
+	keys := make([]APIKey, 0, len(a.keys))
+	for _, r := range a.keys {
+		keys = append(keys, r.APIKey)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Created.Before(keys[j].Created)
+	})
+	return keys, nil
+}
+
+// RevokeKey revokes the API key. Its description and audit log are kept.
+func (a *Authenticator) RevokeKey(id string) error {
+	a.mu.Lock()
+	if a.store == nil {
+		a.mu.Unlock()
+		return ErrNoStateStore
+	}
+	r, ok := a.keys[id]
+	if !ok {
+		a.mu.Unlock()
+		return ErrKeyNotFound
+	}
+	if r.Revoked != nil {
+		a.mu.Unlock()
+		return nil
+	}
+	now := time.Now().UTC()
+	r.Revoked = &now
+	a.dirty[id] = true
+	a.mu.Unlock()
+
+	return a.flush()
+}
+
+// KeyAudit returns the audit log of the API key, oldest entries first.
+func (a *Authenticator) KeyAudit(id string) ([]AuditEntry, error) {
+	a.mu.Lock()
+	store := a.store
+	_, ok := a.keys[id]
+	a.mu.Unlock()
+
+	if store == nil {
+		return nil, ErrNoStateStore
+	}
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	if err := a.flush(); err != nil {
+		return nil, err
+	}
+	var keys []string
+	err := store.Iterate(auditKeyPrefix(id), func(k, _ []byte) (bool, error) {
+		keys = append(keys, string(k))
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the keys end with the zero padded sequence of the entries, and not
+	// every state store iterates in order
+	sort.Strings(keys)
+	entries := make([]AuditEntry, 0, len(keys))
+	for _, k := range keys {
+		var e AuditEntry
+		if err := store.Get(k, &e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Roles returns the built-in and the custom roles.
+func (a *Authenticator) Roles() []Role {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	roles := make([]Role, 0, len(builtinRoles)+len(a.roles))
+	for _, name := range builtinRoles {
+		r := Role{Name: name, BuiltIn: true}
+		for _, p := range a.enforcer.GetFilteredPolicy(0, name) {
+			r.Policies = append(r.Policies, Policy{Object: p[1], Action: p[2]})
+		}
+		roles = append(roles, r)
+	}
+	custom := make([]Role, 0, len(a.roles))
+	for _, r := range a.roles {
+		custom = append(custom, r)
+	}
+	sort.Slice(custom, func(i, j int) bool {
+		return custom[i].Name < custom[j].Name
+	})
+	return append(roles, custom...)
+}
+
+// AddRole defines a custom role or replaces the policies of an existing one.
+func (a *Authenticator) AddRole(r Role) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.store == nil {
+		return ErrNoStateStore
+	}
+	if !roleNameRegexp.MatchString(r.Name) || isBuiltinRole(r.Name) {
+		return fmt.Errorf("%w: name %q", ErrInvalidRole, r.Name)
+	}
+	for _, name := range r.Inherits {
+		if name == r.Name || !a.hasRole(name) {
+			return fmt.Errorf("%w: %s", ErrUnknownRole, name)
+		}
+	}
+	for _, p := range r.Policies {
+		if !strings.HasPrefix(p.Object, "/") {
+			return fmt.Errorf("%w: object %q", ErrInvalidRole, p.Object)
+		}
+		if _, err := regexp.Compile(p.Action); err != nil {
+			return fmt.Errorf("%w: action %q: %v", ErrInvalidRole, p.Action, err)
+		}
+	}
+	r.BuiltIn = false
+
+	if err := a.removeRolePolicies(r.Name); err != nil {
+		return err
+	}
+	if err := a.applyRole(r); err != nil {
+		return err
+	}
+	if err := a.store.Put(roleStorePrefix+r.Name, r); err != nil {
+		return err
+	}
+	a.roles[r.Name] = r
+	return nil
+}
+
+// RemoveRole removes a custom role that no active API key or role uses.
+func (a *Authenticator) RemoveRole(name string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.store == nil {
+		return ErrNoStateStore
+	}
+	if _, ok := a.roles[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownRole, name)
+	}
+	for _, k := range a.keys {
+		if k.Role == name && k.Revoked == nil {
+			return ErrRoleInUse
+		}
+	}
+	for _, r := range a.roles {
+		for _, i := range r.Inherits {
+			if i == name {
+				return ErrRoleInUse
+			}
+		}
+	}
+	if err := a.removeRolePolicies(name); err != nil {
+		return err
+	}
+	if err := a.store.Delete(roleStorePrefix + name); err != nil {
+		return err
+	}
+	delete(a.roles, name)
+	return nil
+}
+
+// applyRole adds the policies and the inherited roles of the role to the enforcer.
+func (a *Authenticator) applyRole(r Role) error {
+	for _, p := range r.Policies {
+		if _, err := a.enforcer.AddPolicy(r.Name, p.Object, p.Action); err != nil {
+			return err
+		}
+	}
+	for _, i := range r.Inherits {
+		if _, err := a.enforcer.AddGroupingPolicy(r.Name, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Authenticator) removeRolePolicies(name string) error {
+	if _, err := a.enforcer.RemoveFilteredPolicy(0, name); err != nil {
+		return err
+	}
+	_, err := a.enforcer.RemoveFilteredGroupingPolicy(0, name)
+	return err
+}
+
+func (a *Authenticator) hasRole(name string) bool {
+	if isBuiltinRole(name) {
+		return true
+	}
+	_, ok := a.roles[name]
+	return ok
+}
+
+func isBuiltinRole(name string) bool {
+	for _, r := range builtinRoles {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+// keyExpiry returns the expiry of the API key.
+func (a *Authenticator) keyExpiry(apiKey string) (time.Time, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	r, err := a.lookupKey(apiKey)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if r.Expiry == nil {
+		return time.Time{}, nil
+	}
+	return *r.Expiry, nil
+}
+
+// lookupKey returns the record of an active API key. The caller must hold a.mu.
+func (a *Authenticator) lookupKey(apiKey string) (*keyRecord, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(apiKey, apiKeyPrefix), "_")
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	r, ok := a.keys[id]
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	hash := sha256.Sum256([]byte(secret))
+	if subtle.ConstantTimeCompare(hash[:], r.Hash) != 1 || r.Revoked != nil {
+		return nil, ErrInvalidKey
+	}
+	return r, nil
+}
+
+// enforceKey checks the API key and records the request in its audit log.
+// The audit entry and the usage of the key are written later by flush.
+func (a *Authenticator) enforceKey(apiKey, obj, act string) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	r, err := a.lookupKey(apiKey)
+	if err != nil {
+		return false, err
+	}
+	id := r.ID
+	now := time.Now().UTC()
+	if r.Expiry != nil && now.After(*r.Expiry) {
+		return false, ErrTokenExpired
+	}
+
+	path, query, _ := strings.Cut(obj, "?")
+	allow := r.permits(path, query)
+	if allow {
+		allow, err = a.enforcer.Enforce(r.Role, path, act)
+		if err != nil {
+			a.log.Error("enforce", err)
+			return false, err
+		}
+	}
+
+	r.LastUsed = &now
+	r.Uses++
+	r.AuditSeq++
+	a.pending = append(a.pending, pendingAudit{
+		id:  id,
+		seq: r.AuditSeq,
+		entry: AuditEntry{
+			Time:    now,
+			Method:  act,
+			Path:    path,
+			Allowed: allow,
+		},
+	})
+	a.dirty[id] = true
+	if len(a.pending) >= auditFlushSize {
+		select {
+		case a.flushC <- struct{}{}:
+		default:
+		}
+	}
+	return allow, nil
+}
+
+// permits reports whether the group and root restrictions of the key allow
+// access to the object of the path and the query.
+func (r *keyRecord) permits(path, query string) bool {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(path, "/v1"), "/"), "/")
+	switch {
+	case (len(r.Groups) > 0 || len(r.Roots) > 0) && segments[0] == "rpc":
+		return len(segments) > 1 && !scopedRPCNamespaces[segments[1]]
+	case len(r.Groups) > 0 && segments[0] == "group":
+		// group endpoints take the group after the action, like /group/join/{gid}
+		if len(segments) < 3 {
+			return false
+		}
+		for _, g := range r.Groups {
+			if segments[2] == g {
+				return true
+			}
+		}
+		return false
+	case len(r.Groups) > 0 && segments[0] == "groups":
+		// the groups a key is restricted to can be managed, but not listed
+		// or created
+		if len(segments) < 2 {
+			return false
+		}
+		for _, g := range r.Groups {
+			if segments[1] == g {
+				return true
+			}
+		}
+		return false
+	case len(r.Roots) > 0 && rootResources[segments[0]] != nil:
+		if len(segments) < 2 {
+			return false
+		}
+		for _, i := range rootResources[segments[0]] {
+			if i < len(segments) && !r.hasRoot(segments[i]) {
+				return false
+			}
+		}
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return false
+		}
+		for _, name := range rootQueries {
+			for _, v := range values[name] {
+				if v != "" && !r.hasRoot(v) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return true
+}
+
+// hasRoot reports whether the key is restricted to the root address.
+func (r *keyRecord) hasRoot(address string) bool {
+	for _, root := range r.Roots {
+		if strings.EqualFold(address, root.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+func auditKeyPrefix(id string) string {
+	return auditStorePrefix + id + "-"
+}
+
+func auditSeq(seq uint64) string {
+	return fmt.Sprintf("%020d", seq)
+}
@@ -0,0 +1,294 @@
+package auth_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/auth"
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	mockstate "github.com/FavorLabs/favorX/pkg/statestore/mock"
+	"github.com/FavorLabs/favorX/pkg/storage"
+)
+
+func newKeyAuthenticator(t *testing.T, store storage.StateStorer) *auth.Authenticator {
+	t.Helper()
+
+	a, err := auth.New(encryptionKey, passwordHash, logging.New(io.Discard, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetStateStore(store); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := a.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	return a
+}
+
+func enforce(t *testing.T, a *auth.Authenticator, key, obj, act string, want bool) {
+	t.Helper()
+
+	got, err := a.Enforce(key, obj, act)
+	if err != nil {
+		t.Fatalf("%s %s: %v", act, obj, err)
+	}
+	if got != want {
+		t.Fatalf("%s %s: got %v, want %v", act, obj, got, want)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	store := mockstate.NewStateStore()
+	a := newKeyAuthenticator(t, store)
+
+	if _, _, err := a.CreateKey(auth.KeyOptions{Role: "nobody"}); !errors.Is(err, auth.ErrUnknownRole) {
+		t.Fatalf("got error %v, want %v", err, auth.ErrUnknownRole)
+	}
+
+	k, key, err := a.CreateKey(auth.KeyOptions{Name: "app", Role: "consumer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enforce(t, a, key, "/bytes/1", "GET", true)
+	enforce(t, a, key, "/bytes", "POST", false)
+
+	if _, err := a.Enforce(key+"0", "/bytes/1", "GET"); !errors.Is(err, auth.ErrInvalidKey) {
+		t.Fatalf("got error %v, want %v", err, auth.ErrInvalidKey)
+	}
+
+	keys, err := a.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].ID != k.ID || keys[0].Uses != 2 || keys[0].LastUsed == nil {
+		t.Fatalf("got keys %+v", keys)
+	}
+	audit, err := a.KeyAudit(k.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(audit) != 2 || !audit[0].Allowed || audit[1].Allowed || audit[1].Path != "/bytes" {
+		t.Fatalf("got audit log %+v", audit)
+	}
+
+	if err := a.RevokeKey(k.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Enforce(key, "/bytes/1", "GET"); !errors.Is(err, auth.ErrInvalidKey) {
+		t.Fatalf("got error %v, want %v", err, auth.ErrInvalidKey)
+	}
+	if err := a.RevokeKey("unknown"); !errors.Is(err, auth.ErrKeyNotFound) {
+		t.Fatalf("got error %v, want %v", err, auth.ErrKeyNotFound)
+	}
+
+	// keys are kept hashed in the state store
+	reloaded := newKeyAuthenticator(t, store)
+	keys, err = reloaded.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].Revoked == nil {
+		t.Fatalf("got reloaded keys %+v", keys)
+	}
+	_, active, err := reloaded.CreateKey(auth.KeyOptions{Role: "maintainer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enforce(t, reloaded, active, "/peers", "GET", true)
+}
+
+// TestAPIKeyUsage validates that the usage of a key is kept in the state store
+// once it is written in the background or on close.
+func TestAPIKeyUsage(t *testing.T) {
+	store := mockstate.NewStateStore()
+	a := newKeyAuthenticator(t, store)
+
+	k, key, err := a.CreateKey(auth.KeyOptions{Role: "consumer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const uses = 300
+	for i := 0; i < uses; i++ {
+		enforce(t, a, key, "/bytes/1", "GET", true)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := newKeyAuthenticator(t, store)
+	keys, err := reloaded.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].Uses != uses || keys[0].LastUsed == nil {
+		t.Fatalf("got reloaded keys %+v", keys)
+	}
+	audit, err := reloaded.KeyAudit(k.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(audit) != uses {
+		t.Fatalf("got %d audit entries, want %d", len(audit), uses)
+	}
+}
+
+func TestAPIKeyExpiry(t *testing.T) {
+	a := newKeyAuthenticator(t, mockstate.NewStateStore())
+
+	_, key, err := a.CreateKey(auth.KeyOptions{Role: "consumer", Expiry: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enforce(t, a, key, "/bytes/1", "GET", true)
+	time.Sleep(20 * time.Millisecond)
+	if _, err := a.Enforce(key, "/bytes/1", "GET"); !errors.Is(err, auth.ErrTokenExpired) {
+		t.Fatalf("got error %v, want %v", err, auth.ErrTokenExpired)
+	}
+}
+
+func TestCustomRoles(t *testing.T) {
+	store := mockstate.NewStateStore()
+	a := newKeyAuthenticator(t, store)
+
+	if err := a.AddRole(auth.Role{Name: "consumer"}); !errors.Is(err, auth.ErrInvalidRole) {
+		t.Fatalf("got error %v, want %v", err, auth.ErrInvalidRole)
+	}
+	if err := a.AddRole(auth.Role{Name: "uploader", Inherits: []string{"unknown"}}); !errors.Is(err, auth.ErrUnknownRole) {
+		t.Fatalf("got error %v, want %v", err, auth.ErrUnknownRole)
+	}
+	if err := a.AddRole(auth.Role{
+		Name:     "uploader",
+		Inherits: []string{"consumer"},
+		Policies: []auth.Policy{{Object: "/bytes", Action: "POST"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	_, key, err := a.CreateKey(auth.KeyOptions{Role: "uploader"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enforce(t, a, key, "/bytes", "POST", true)
+	enforce(t, a, key, "/v1/bytes/1", "GET", true)
+	enforce(t, a, key, "/pins/1", "DELETE", false)
+
+	// the role is loaded from the state store
+	reloaded := newKeyAuthenticator(t, store)
+	enforce(t, reloaded, key, "/bytes", "POST", true)
+	roles := reloaded.Roles()
+	if last := roles[len(roles)-1]; last.Name != "uploader" || last.BuiltIn || len(last.Policies) != 1 {
+		t.Fatalf("got role %+v", last)
+	}
+
+	if err := a.RemoveRole("uploader"); !errors.Is(err, auth.ErrRoleInUse) {
+		t.Fatalf("got error %v, want %v", err, auth.ErrRoleInUse)
+	}
+}
+
+func TestAPIKeyRestrictions(t *testing.T) {
+	a := newKeyAuthenticator(t, mockstate.NewStateStore())
+
+	root := boson.MustParseHexAddress("ca1e9f3938cc1425c6061b96ad9eb93e134dfe8734ad490164ef20af9d1cf59c")
+	_, key, err := a.CreateKey(auth.KeyOptions{
+		Role:   "master",
+		Groups: []string{"team"},
+		Roots:  []boson.Address{root},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enforce(t, a, key, "/group/join/team", "POST", true)
+	enforce(t, a, key, "/group/send/team/peer", "POST", true)
+	enforce(t, a, key, "/group/join/other", "POST", false)
+	enforce(t, a, key, "/groups/team", "PUT", true)
+	enforce(t, a, key, "/groups/other", "DELETE", false)
+	enforce(t, a, key, "/groups", "GET", false)
+	enforce(t, a, key, "/file/"+root.String(), "GET", true)
+	enforce(t, a, key, "/v1/pins/"+root.String(), "POST", true)
+	enforce(t, a, key, "/file/"+boson.ZeroAddress.String(), "GET", false)
+	enforce(t, a, key, "/file", "GET", false)
+
+	// a custom role lets the master keys register files
+	if err := a.AddRole(auth.Role{
+		Name:     "registrar",
+		Policies: []auth.Policy{{Object: "/fileRegister/*", Action: "(POST)|(DELETE)"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	other := boson.MustParseHexAddress("ca1e9f3938cc1425c6061b96ad9eb93e134dfe8734ad490164ef20af9d1cf59d").String()
+	enforce(t, a, key, "/fileRegister/"+root.String(), "POST", true)
+	enforce(t, a, key, "/fileRegister/"+other, "POST", false)
+	enforce(t, a, key, "/fileRegister/"+other, "DELETE", false)
+	enforce(t, a, key, "/versions/"+root.String()+"/rollback/"+root.String(), "POST", true)
+	enforce(t, a, key, "/versions/"+root.String()+"/rollback/"+other, "POST", false)
+	enforce(t, a, key, "/versions/"+root.String()+"/diff?from="+root.String(), "GET", true)
+	enforce(t, a, key, "/versions/"+root.String()+"/diff?from="+other, "GET", false)
+	enforce(t, a, key, "/versions/"+root.String()+"/diff?from="+root.String()+"&to="+other, "GET", false)
+}
+
+func TestAPIKeyRestrictionsQuery(t *testing.T) {
+	a := newKeyAuthenticator(t, mockstate.NewStateStore())
+
+	root := boson.MustParseHexAddress("ca1e9f3938cc1425c6061b96ad9eb93e134dfe8734ad490164ef20af9d1cf59c")
+	other := boson.MustParseHexAddress("ca1e9f3938cc1425c6061b96ad9eb93e134dfe8734ad490164ef20af9d1cf59d")
+	_, key, err := a.CreateKey(auth.KeyOptions{Role: "consumer", Roots: []boson.Address{root}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := auth.PermissionCheckHandler(a)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, tc := range []struct {
+		from   boson.Address
+		status int
+	}{
+		{root, http.StatusOK},
+		{other, http.StatusForbidden},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/versions/"+root.String()+"/diff?from="+tc.from.String(), nil)
+		r.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != tc.status {
+			t.Fatalf("diff from %s: got status %d, want %d", tc.from, w.Code, tc.status)
+		}
+	}
+}
+
+func TestAPIKeyRPC(t *testing.T) {
+	a := newKeyAuthenticator(t, mockstate.NewStateStore())
+
+	k, key, err := a.CreateKey(auth.KeyOptions{Role: "consumer", Expiry: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiry, err := a.Expiry(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expiry.Equal(*k.Expiry) {
+		t.Fatalf("got expiry %v, want %v", expiry, *k.Expiry)
+	}
+	enforce(t, a, key, "/rpc/group/message", "SUBSCRIBE", true)
+	enforce(t, a, key, "/rpc/p2p/kadInfo", "SUBSCRIBE", false)
+
+	_, scoped, err := a.CreateKey(auth.KeyOptions{Role: "master", Groups: []string{"team"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expiry, err := a.Expiry(scoped); err != nil || !expiry.IsZero() {
+		t.Fatalf("got expiry %v, error %v", expiry, err)
+	}
+	enforce(t, a, scoped, "/rpc/group/message", "SUBSCRIBE", false)
+	enforce(t, a, scoped, "/rpc/p2p/kadInfo", "SUBSCRIBE", true)
+
+	if _, err := a.Expiry(key + "0"); !errors.Is(err, auth.ErrInvalidKey) {
+		t.Fatalf("got error %v, want %v", err, auth.ErrInvalidKey)
+	}
+}
@@ -1,5 +1,11 @@
 package mock
 
+import (
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/auth"
+)
+
 type Auth struct {
 	AuthorizeFunc   func(string) bool
 	GenerateKeyFunc func(string) (string, error)
@@ -26,3 +32,27 @@ func (ma *Auth) RefreshKey(k string, _ int) (string, error) {
 func (*Auth) Enforce(string, string, string) (bool, error) {
 	return false, nil
 }
+func (*Auth) Expiry(string) (time.Time, error) {
+	return time.Time{}, nil
+}
+func (*Auth) CreateKey(o auth.KeyOptions) (auth.APIKey, string, error) {
+	return auth.APIKey{Name: o.Name, Role: o.Role}, "", nil
+}
+func (*Auth) Keys() ([]auth.APIKey, error) {
+	return nil, nil
+}
+func (*Auth) RevokeKey(string) error {
+	return nil
+}
+func (*Auth) KeyAudit(string) ([]auth.AuditEntry, error) {
+	return nil, nil
+}
+func (*Auth) Roles() []auth.Role {
+	return nil
+}
+func (*Auth) AddRole(auth.Role) error {
+	return nil
+}
+func (*Auth) RemoveRole(string) error {
+	return nil
+}
@@ -7,6 +7,7 @@ package bmt_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
@@ -284,3 +285,102 @@ func randomBytes(t testing.TB, seed int64) []byte {
 	}
 	return data
 }
+
+// tests the proofs of the segments lead to the hash of the chunk
+func TestProof(t *testing.T) {
+	pool := bmt.NewPool(bmt.NewConf(boson.NewHasher, testSegmentCount, 1))
+	for _, n := range []int{0, 1, 31, 32, 33, 100, 4095, 4096} {
+		data := make([]byte, n)
+		if _, err := rand.New(rand.NewSource(seed)).Read(data); err != nil {
+			t.Fatal(err)
+		}
+		h := pool.Get()
+		want, err := syncHash(h, data)
+		pool.Put(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		span := bmt.LengthToSpan(int64(n))
+		for _, i := range []int{0, 1, n / hashSize % testSegmentCount, testSegmentCount - 1} {
+			proof, err := pool.Prove(span, data, i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := proof.Hash(boson.NewHasher, pool.Depth())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("length %d segment %d: got hash %x, want %x", n, i, got, want)
+			}
+
+			if err := proof.Verify(boson.NewHasher, pool.Depth(), want); err != nil {
+				t.Fatalf("length %d segment %d: %v", n, i, err)
+			}
+
+			proof.Segment = append([]byte{1}, proof.Segment[1:]...)
+			if err := proof.Verify(boson.NewHasher, pool.Depth(), want); !errors.Is(err, bmt.ErrInvalidProof) {
+				t.Fatalf("length %d segment %d: got error %v verifying the proof of another segment, want %v", n, i, err, bmt.ErrInvalidProof)
+			}
+		}
+	}
+
+	if _, err := pool.Prove(nil, nil, testSegmentCount); err == nil {
+		t.Fatal("proved a segment beyond the capacity")
+	}
+	proof, err := pool.Prove(bmt.LengthToSpan(0), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof.Index = testSegmentCount
+	if _, err := proof.Hash(boson.NewHasher, pool.Depth()); err == nil {
+		t.Fatal("got the hash of a proof of a segment beyond the tree")
+	}
+}
+
+// tests a proof passing an inner node of the tree off as a segment is rejected
+func TestProofInnerNode(t *testing.T) {
+	pool := bmt.NewPool(bmt.NewConf(boson.NewHasher, testSegmentCount, 1))
+	data := randomBytes(t, seed)
+	h := pool.Get()
+	want, err := syncHash(h, data)
+	pool.Put(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := pool.Prove(bmt.LengthToSpan(int64(len(data))), data, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proof.Verify(boson.NewHasher, pool.Depth(), want); err != nil {
+		t.Fatal(err)
+	}
+
+	// the parent of the segments 2 and 3 is the segment 1 of a tree a level lower
+	inner, err := bmt.Sha3hash(data[2*hashSize:3*hashSize], data[3*hashSize:4*hashSize])
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := bmt.Proof{
+		Index:   1,
+		Segment: inner,
+		Sisters: proof.Sisters[1:],
+		Span:    proof.Span,
+	}
+	if err := forged.Verify(boson.NewHasher, pool.Depth(), want); !errors.Is(err, bmt.ErrInvalidProof) {
+		t.Fatalf("got error %v verifying the proof of an inner node, want %v", err, bmt.ErrInvalidProof)
+	}
+	// the forged proof only leads to the address in a tree of fewer levels
+	got, err := forged.Hash(boson.NewHasher, pool.Depth()-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got hash %x of the forged proof, want %x", got, want)
+	}
+
+	proof.Index = 1 << len(proof.Sisters)
+	if _, err := proof.Hash(boson.NewHasher, pool.Depth()); !errors.Is(err, bmt.ErrInvalidProof) {
+		t.Fatalf("got error %v hashing the proof of a segment beyond the tree, want %v", err, bmt.ErrInvalidProof)
+	}
+}
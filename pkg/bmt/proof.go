@@ -0,0 +1,108 @@
+package bmt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidProof = errors.New("invalid bmt proof")
+
+// Proof proves a segment is part of the data of a chunk at an index: it holds
+// the sister segment and the sister hashes on the path to the root of the BMT,
+// and the span the root is hashed with into the address of the chunk.
+type Proof struct {
+	Index   int      `json:"index"`   // of the segment in the data
+	Segment []byte   `json:"segment"` // proven
+	Sisters [][]byte `json:"sisters"` // the sister segment first, then the sister hashes up the tree
+	Span    []byte   `json:"span"`
+}
+
+// Prove returns the proof of the segment at the index of the data hashed with
+// the span. The data is padded with zeros as the Hasher does.
+func (c *Conf) Prove(span, data []byte, index int) (Proof, error) {
+	if len(data) > c.maxSize {
+		return Proof{}, fmt.Errorf("data of %d bytes over the capacity of %d", len(data), c.maxSize)
+	}
+	count := c.maxSize / c.segmentSize
+	if index < 0 || index >= count {
+		return Proof{}, fmt.Errorf("segment index %d out of %d", index, count)
+	}
+	buf := make([]byte, c.maxSize)
+	copy(buf, data)
+	level := make([][]byte, count)
+	for i := range level {
+		level[i] = buf[i*c.segmentSize : (i+1)*c.segmentSize]
+	}
+
+	proof := Proof{
+		Index:   index,
+		Segment: level[index],
+		Span:    append([]byte(nil), span...),
+	}
+	h := c.hasher()
+	for i := index; len(level) > 1; i /= 2 {
+		proof.Sisters = append(proof.Sisters, append([]byte(nil), level[i^1]...))
+		next := make([][]byte, len(level)/2)
+		for j := range next {
+			s, err := doHash(h, level[2*j], level[2*j+1])
+			if err != nil {
+				return Proof{}, err
+			}
+			next[j] = s
+		}
+		level = next
+	}
+	return proof, nil
+}
+
+// Depth returns the number of sisters the proofs of the segments have, the
+// levels of the BMT below the root.
+func (c *Conf) Depth() int {
+	return c.depth
+}
+
+// Hash returns the address of the chunk the segment is proven part of: the
+// hash of the span and the root of the BMT of the depth the sisters lead to.
+// The depth is checked so an inner node of the tree is never taken for a
+// segment of the data.
+func (p Proof) Hash(hasher BaseHasherFunc, depth int) ([]byte, error) {
+	h := hasher()
+	if len(p.Segment) != h.Size() || len(p.Span) != SpanSize || len(p.Sisters) != depth {
+		return nil, ErrInvalidProof
+	}
+	// the index is beyond the segments of the tree
+	if p.Index < 0 || p.Index >= 1<<len(p.Sisters) {
+		return nil, ErrInvalidProof
+	}
+	s, i := p.Segment, p.Index
+	for _, sister := range p.Sisters {
+		if len(sister) != h.Size() {
+			return nil, ErrInvalidProof
+		}
+		var err error
+		if i%2 == 0 {
+			s, err = doHash(h, s, sister)
+		} else {
+			s, err = doHash(h, sister, s)
+		}
+		if err != nil {
+			return nil, err
+		}
+		i /= 2
+	}
+	return doHash(h, p.Span, s)
+}
+
+// Verify checks the proof proves the segment is part of the chunk of the
+// address, hashed into a BMT of the depth.
+func (p Proof) Verify(hasher BaseHasherFunc, depth int, address []byte) error {
+	hash, err := p.Hash(hasher, depth)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(hash, address) {
+		return ErrInvalidProof
+	}
+	return nil
+}
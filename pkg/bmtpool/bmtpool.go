@@ -25,3 +25,15 @@ func Get() *bmt.Hasher {
 func Put(h *bmt.Hasher) {
 	instance.Put(h)
 }
+
+// Prove returns the inclusion proof of the segment at the index of the chunk
+// data hashed with the span.
+func Prove(span, data []byte, index int) (bmt.Proof, error) {
+	return instance.Prove(span, data, index)
+}
+
+// Depth returns the number of sisters the inclusion proofs of the segments of
+// the chunks have.
+func Depth() int {
+	return instance.Depth()
+}
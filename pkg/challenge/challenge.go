@@ -0,0 +1,480 @@
+// Package challenge checks the nodes registered on chain to store a file do
+// store it. A verifier asks a provider for the chunks of the file at random
+// offsets, which the provider returns with the inclusion proofs of them in the
+// trie of the root cid within a deadline. The results are kept as the
+// reputation of the peers, the peers failing repeatedly may be blocklisted and
+// the failures reported on chain.
+//
+// Encrypted files are not challenged, their references are longer than the
+// segments of the BMT.
+package challenge
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/bmt"
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/challenge/pb"
+	"github.com/FavorLabs/favorX/pkg/file"
+	"github.com/FavorLabs/favorX/pkg/file/proof"
+	"github.com/FavorLabs/favorX/pkg/localstore/filestore"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/p2p"
+	"github.com/FavorLabs/favorX/pkg/p2p/protobuf"
+	"github.com/FavorLabs/favorX/pkg/storage"
+)
+
+const (
+	protocolName    = "challenge"
+	protocolVersion = "1.0.0"
+	streamName      = "challenge"
+
+	// DefaultDeadline is how long a provider has to answer a challenge by
+	// default.
+	DefaultDeadline = 30 * time.Second
+	// DefaultSamples is the number of random offsets of the file a challenge
+	// asks the chunks of by default.
+	DefaultSamples = 4
+
+	maxSamples    = 32 // offsets a provider answers the chunks of in a challenge
+	reportTimeout = time.Minute
+
+	reputationPrefix = "challenge_peer_"
+)
+
+var (
+	ErrNoProviders   = errors.New("no providers registered for the file")
+	errTooManyNonces = errors.New("too many offsets challenged")
+)
+
+// Storer holds the files the node challenges the providers of, and answers
+// the challenges for.
+type Storer interface {
+	storage.Getter
+	GetListFile(page filestore.Page, filter []filestore.Filter, sort filestore.Sort) ([]filestore.FileView, int)
+}
+
+// Registry tells the nodes registered on chain to store a file.
+type Registry interface {
+	GetNodesFromCid([]byte) []boson.Address
+}
+
+// Reporter reports the failed challenges on chain. The oracle contract has
+// no method for it, so none is set unless a chain supporting it is.
+type Reporter interface {
+	ReportFailure(ctx context.Context, result Result) error
+}
+
+// Options tune how often and how hard the providers are challenged.
+type Options struct {
+	Interval          time.Duration // between the challenges sent, none if 0
+	Deadline          time.Duration // a provider has to answer within, DefaultDeadline if 0
+	Samples           int           // offsets challenged at once, DefaultSamples if 0
+	BlocklistAfter    int           // consecutive failures a peer is blocklisted after, never if 0
+	BlocklistDuration time.Duration // a peer failing is blocklisted for, for ever if 0
+}
+
+// Result is the outcome of a challenge.
+type Result struct {
+	Peer     boson.Address `json:"peer"`
+	RootCid  boson.Address `json:"rootCid"`
+	Passed   bool          `json:"passed"`
+	Error    string        `json:"error,omitempty"`
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Reputation sums up the challenges a peer answered.
+type Reputation struct {
+	Peer                boson.Address `json:"peer"`
+	Passed              uint64        `json:"passed"`
+	Failed              uint64        `json:"failed"`
+	ConsecutiveFailures uint64        `json:"consecutiveFailures"`
+	LastChallenge       time.Time     `json:"lastChallenge"`
+	LastFailure         time.Time     `json:"lastFailure,omitempty"`
+	LastError           string        `json:"lastError,omitempty"`
+}
+
+// Trust returns the share of the challenges the peer passed, counting one
+// passed challenge in advance so the peers never challenged are trusted fully.
+func (r Reputation) Trust() float64 {
+	return float64(r.Passed+1) / float64(r.Passed+r.Failed+1)
+}
+
+type Service struct {
+	base        boson.Address
+	streamer    p2p.Streamer
+	storer      Storer
+	registry    Registry
+	blocklister p2p.Blocklister
+	store       storage.StateStorer
+	logger      logging.Logger
+	opts        Options
+	metrics     metrics
+
+	mu       sync.Mutex
+	reporter Reporter
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New(base boson.Address, streamer p2p.Streamer, storer Storer, registry Registry, blocklister p2p.Blocklister,
+	store storage.StateStorer, logger logging.Logger, opts Options) *Service {
+	if opts.Deadline <= 0 {
+		opts.Deadline = DefaultDeadline
+	}
+	if opts.Samples <= 0 {
+		opts.Samples = DefaultSamples
+	}
+	if opts.Samples > maxSamples {
+		opts.Samples = maxSamples
+	}
+	return &Service{
+		base:        base,
+		streamer:    streamer,
+		storer:      storer,
+		registry:    registry,
+		blocklister: blocklister,
+		store:       store,
+		logger:      logger,
+		opts:        opts,
+		metrics:     newMetrics(),
+		quit:        make(chan struct{}),
+	}
+}
+
+func (s *Service) Protocol() p2p.ProtocolSpec {
+	return p2p.ProtocolSpec{
+		Name:    protocolName,
+		Version: protocolVersion,
+		StreamSpecs: []p2p.StreamSpec{
+			{
+				Name:    streamName,
+				Handler: s.handler,
+			},
+		},
+	}
+}
+
+// SetReporter sets where the failed challenges are reported on chain.
+func (s *Service) SetReporter(reporter Reporter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reporter = reporter
+}
+
+// Start challenges a random provider of a random file the node stores at
+// every interval.
+func (s *Service) Start() {
+	if s.opts.Interval <= 0 || s.registry == nil {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-s.quit
+			cancel()
+		}()
+
+		ticker := time.NewTicker(s.opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.quit:
+				return
+			case <-ticker.C:
+			}
+			if err := s.challengeRandom(ctx); err != nil {
+				s.logger.Debugf("challenge: %v", err)
+			}
+		}
+	}()
+}
+
+func (s *Service) Close() error {
+	close(s.quit)
+	s.wg.Wait()
+	return nil
+}
+
+func (s *Service) challengeRandom(ctx context.Context) error {
+	files, _ := s.storer.GetListFile(filestore.Page{}, nil, filestore.Sort{})
+	if len(files) == 0 {
+		return nil
+	}
+	rootCid := files[randomInt(len(files))].RootCid
+	result, err := s.ChallengeRegistered(ctx, rootCid)
+	if errors.Is(err, ErrNoProviders) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("challenge a provider of %s: %w", rootCid, err)
+	}
+	if !result.Passed {
+		s.logger.Infof("challenge: peer %s failed the challenge for %s: %s", result.Peer, rootCid, result.Error)
+	}
+	return nil
+}
+
+// ChallengeRegistered challenges a random node registered on chain for the
+// file.
+func (s *Service) ChallengeRegistered(ctx context.Context, rootCid boson.Address) (Result, error) {
+	var peers []boson.Address
+	if s.registry != nil {
+		for _, overlay := range s.registry.GetNodesFromCid(rootCid.Bytes()) {
+			if !overlay.Equal(s.base) {
+				peers = append(peers, overlay)
+			}
+		}
+	}
+	if len(peers) == 0 {
+		return Result{}, ErrNoProviders
+	}
+	return s.Challenge(ctx, peers[randomInt(len(peers))], rootCid)
+}
+
+// Challenge asks the peer for the chunks of the file at random offsets with
+// their proofs, and records the result. An error is returned only if the
+// peer could not be challenged.
+func (s *Service) Challenge(ctx context.Context, peer, rootCid boson.Address) (Result, error) {
+	nonces := make([]int64, s.opts.Samples)
+	for i := range nonces {
+		nonces[i] = randomInt64()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.opts.Deadline)
+	defer cancel()
+	stream, err := s.streamer.NewStream(ctx, peer, nil, protocolName, protocolVersion, streamName)
+	if err != nil {
+		return Result{}, err
+	}
+	start := time.Now()
+	err = s.challenge(ctx, stream, rootCid, nonces)
+	if err != nil {
+		_ = stream.Reset()
+	} else {
+		go stream.FullClose()
+	}
+
+	result := Result{
+		Peer:     peer,
+		RootCid:  rootCid,
+		Passed:   err == nil,
+		Time:     start,
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if err := s.record(result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func (s *Service) challenge(ctx context.Context, stream p2p.Stream, rootCid boson.Address, nonces []int64) error {
+	w, r := protobuf.NewWriterAndReader(stream)
+	if err := w.WriteMsgWithContext(ctx, &pb.Challenge{RootCid: rootCid.Bytes(), Nonces: nonces}); err != nil {
+		return err
+	}
+	for _, nonce := range nonces {
+		var msg pb.Proof
+		if err := r.ReadMsgWithContext(ctx, &msg); err != nil {
+			return err
+		}
+		p := fromProto(&msg)
+		if length := p.Length(); length <= 0 || p.Offset != nonce%length {
+			return proof.ErrInvalidProof
+		}
+		if err := p.Verify(rootCid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handler answers a challenge with the proofs of the chunks of the file at
+// the offsets, one message each.
+func (s *Service) handler(ctx context.Context, p p2p.Peer, stream p2p.Stream) (err error) {
+	w, r := protobuf.NewWriterAndReader(stream)
+	defer func() {
+		if err != nil {
+			_ = stream.Reset()
+		} else {
+			go stream.FullClose()
+		}
+	}()
+	var req pb.Challenge
+	if err := r.ReadMsgWithContext(ctx, &req); err != nil {
+		return fmt.Errorf("read challenge from peer %v: %w", p.Address, err)
+	}
+	if len(req.Nonces) > maxSamples {
+		return fmt.Errorf("challenge from peer %v: %w", p.Address, errTooManyNonces)
+	}
+	rootCid := boson.NewAddress(req.RootCid)
+	ch, err := s.storer.Get(ctx, storage.ModeGetLookup, rootCid, 0)
+	if err != nil {
+		return fmt.Errorf("challenge for %s from peer %v: %w", rootCid, p.Address, err)
+	}
+	if len(ch.Data()) < boson.SpanSize {
+		return fmt.Errorf("challenge for %s from peer %v: %w", rootCid, p.Address, proof.ErrInvalidProof)
+	}
+	length := int64(file.SpanLength(binary.LittleEndian.Uint64(ch.Data()[:boson.SpanSize])))
+	if length <= 0 {
+		return fmt.Errorf("challenge for %s from peer %v: %w", rootCid, p.Address, proof.ErrOffset)
+	}
+
+	for _, nonce := range req.Nonces {
+		if nonce < 0 {
+			return fmt.Errorf("challenge for %s from peer %v: %w", rootCid, p.Address, proof.ErrOffset)
+		}
+		pr, err := proof.Prove(ctx, s.storer, storage.ModeGetLookup, rootCid, nonce%length)
+		if err != nil {
+			return fmt.Errorf("prove %s for peer %v: %w", rootCid, p.Address, err)
+		}
+		if err := w.WriteMsgWithContext(ctx, toProto(pr)); err != nil {
+			return fmt.Errorf("write proof to peer %v: %w", p.Address, err)
+		}
+	}
+	s.metrics.Answered.Inc()
+	return nil
+}
+
+// record adds the result to the reputation of the peer, blocklisting the peer
+// and reporting the failure if asked to.
+func (s *Service) record(result Result) error {
+	s.mu.Lock()
+	rep, err := s.Reputation(result.Peer)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	rep.LastChallenge = result.Time
+	if result.Passed {
+		rep.Passed++
+		rep.ConsecutiveFailures = 0
+	} else {
+		rep.Failed++
+		rep.ConsecutiveFailures++
+		rep.LastFailure = result.Time
+		rep.LastError = result.Error
+	}
+	err = s.store.Put(reputationPrefix+result.Peer.String(), rep)
+	reporter := s.reporter
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if result.Passed {
+		s.metrics.Passed.Inc()
+		return nil
+	}
+	s.metrics.Failed.Inc()
+	if s.opts.BlocklistAfter > 0 && rep.ConsecutiveFailures >= uint64(s.opts.BlocklistAfter) && s.blocklister != nil {
+		if err := s.blocklister.Blocklist(result.Peer, s.opts.BlocklistDuration, "failed storage challenges"); err != nil {
+			s.logger.Errorf("challenge: blocklist peer %s: %v", result.Peer, err)
+		} else {
+			s.metrics.Blocklisted.Inc()
+			s.logger.Infof("challenge: blocklisted peer %s after %d failed challenges", result.Peer, rep.ConsecutiveFailures)
+		}
+	}
+	if reporter != nil {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+			defer cancel()
+			if err := reporter.ReportFailure(ctx, result); err != nil {
+				s.logger.Errorf("challenge: report failure of peer %s: %v", result.Peer, err)
+			}
+		}()
+	}
+	return nil
+}
+
+// Reputation returns the reputation of the peer, empty if it was never
+// challenged.
+func (s *Service) Reputation(peer boson.Address) (Reputation, error) {
+	var rep Reputation
+	err := s.store.Get(reputationPrefix+peer.String(), &rep)
+	if errors.Is(err, storage.ErrNotFound) {
+		return Reputation{Peer: peer}, nil
+	}
+	return rep, err
+}
+
+// Reputations returns the reputations of the peers challenged.
+func (s *Service) Reputations() ([]Reputation, error) {
+	reps := make([]Reputation, 0)
+	err := s.store.Iterate(reputationPrefix, func(key, value []byte) (bool, error) {
+		if !strings.HasPrefix(string(key), reputationPrefix) {
+			return true, nil
+		}
+		var rep Reputation
+		if err := json.Unmarshal(value, &rep); err != nil {
+			return true, err
+		}
+		reps = append(reps, rep)
+		return false, nil
+	})
+	return reps, err
+}
+
+func toProto(p proof.Proof) *pb.Proof {
+	msg := &pb.Proof{Offset: p.Offset}
+	for _, step := range p.Steps {
+		st := &pb.Step{Chunk: step.Chunk}
+		if step.Segment != nil {
+			st.Segment = &pb.Segment{
+				Index:   int64(step.Segment.Index),
+				Segment: step.Segment.Segment,
+				Sisters: step.Segment.Sisters,
+				Span:    step.Segment.Span,
+			}
+		}
+		msg.Steps = append(msg.Steps, st)
+	}
+	return msg
+}
+
+func fromProto(msg *pb.Proof) proof.Proof {
+	p := proof.Proof{Offset: msg.Offset}
+	for _, st := range msg.Steps {
+		step := proof.Step{Chunk: st.Chunk}
+		if st.Segment != nil {
+			step.Segment = &bmt.Proof{
+				Index:   int(st.Segment.Index),
+				Segment: st.Segment.Segment,
+				Sisters: st.Segment.Sisters,
+				Span:    st.Segment.Span,
+			}
+		}
+		p.Steps = append(p.Steps, step)
+	}
+	return p
+}
+
+// randomInt64 returns a random positive int64 the peers cannot guess.
+func randomInt64() int64 {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return int64(binary.BigEndian.Uint64(b[:]) >> 1)
+}
+
+// randomInt returns a random int in [0, n).
+func randomInt(n int) int {
+	return int(randomInt64() % int64(n))
+}
@@ -0,0 +1,168 @@
+package challenge_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/boson/test"
+	"github.com/FavorLabs/favorX/pkg/challenge"
+	"github.com/FavorLabs/favorX/pkg/file/pipeline/builder"
+	"github.com/FavorLabs/favorX/pkg/localstore/filestore"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/p2p"
+	"github.com/FavorLabs/favorX/pkg/p2p/streamtest"
+	statestore "github.com/FavorLabs/favorX/pkg/statestore/mock"
+	"github.com/FavorLabs/favorX/pkg/storage"
+	"github.com/FavorLabs/favorX/pkg/storage/mock"
+)
+
+var logger = logging.New(io.Discard, 0)
+
+type storerMock struct {
+	*mock.MockStorer
+	files []boson.Address
+}
+
+func (m *storerMock) GetListFile(filestore.Page, []filestore.Filter, filestore.Sort) ([]filestore.FileView, int) {
+	views := make([]filestore.FileView, 0, len(m.files))
+	for _, rootCid := range m.files {
+		views = append(views, filestore.FileView{RootCid: rootCid})
+	}
+	return views, len(views)
+}
+
+type registryMock map[string][]boson.Address
+
+func (m registryMock) GetNodesFromCid(cid []byte) []boson.Address {
+	return m[string(cid)]
+}
+
+type blocklisterMock struct {
+	mu      sync.Mutex
+	peers   []boson.Address
+	reports []challenge.Result
+}
+
+func (m *blocklisterMock) NetworkStatus() p2p.NetworkStatus {
+	return p2p.NetworkStatusAvailable
+}
+
+func (m *blocklisterMock) Blocklist(overlay boson.Address, _ time.Duration, _ string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.peers = append(m.peers, overlay)
+	return nil
+}
+
+func (m *blocklisterMock) ReportFailure(_ context.Context, result challenge.Result) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reports = append(m.reports, result)
+	return nil
+}
+
+// newStorer returns a storer holding a file of the size.
+func newStorer(t *testing.T, size int) (*storerMock, boson.Address) {
+	t.Helper()
+	ctx := context.Background()
+	store := mock.NewStorer()
+	data := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(data)
+	rootCid, err := builder.FeedPipeline(ctx, builder.NewPipelineBuilder(ctx, store, storage.ModePutUpload, false), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &storerMock{MockStorer: store, files: []boson.Address{rootCid}}, rootCid
+}
+
+func TestChallenge(t *testing.T) {
+	storer, rootCid := newStorer(t, 2*boson.ChunkSize+100)
+	prover := challenge.New(test.RandomAddress(), nil, storer, nil, nil, statestore.NewStateStore(), logger, challenge.Options{})
+
+	proverAddr := test.RandomAddress()
+	recorder := streamtest.New(
+		streamtest.WithProtocols(prover.Protocol()),
+		streamtest.WithBaseAddr(test.RandomAddress()),
+	)
+	registry := registryMock{string(rootCid.Bytes()): {proverAddr}}
+	verifier := challenge.New(test.RandomAddress(), recorder, storer, registry, nil, statestore.NewStateStore(), logger, challenge.Options{})
+
+	result, err := verifier.ChallengeRegistered(context.Background(), rootCid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Passed || !result.Peer.Equal(proverAddr) {
+		t.Fatalf("got result %+v, want passed by %s", result, proverAddr)
+	}
+	rep, err := verifier.Reputation(proverAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Passed != 1 || rep.Failed != 0 {
+		t.Fatalf("got reputation %+v, want one passed challenge", rep)
+	}
+	if trust := rep.Trust(); trust != 1 {
+		t.Fatalf("got trust %v, want 1", trust)
+	}
+
+	if _, err := verifier.ChallengeRegistered(context.Background(), test.RandomAddress()); !errors.Is(err, challenge.ErrNoProviders) {
+		t.Fatalf("got error %v challenging the providers of an unregistered file, want %v", err, challenge.ErrNoProviders)
+	}
+}
+
+func TestChallengeFailure(t *testing.T) {
+	// the prover does not store the file it is challenged for
+	storer, rootCid := newStorer(t, 100)
+	prover := challenge.New(test.RandomAddress(), nil, &storerMock{MockStorer: mock.NewStorer()}, nil, nil, statestore.NewStateStore(), logger, challenge.Options{})
+
+	proverAddr := test.RandomAddress()
+	recorder := streamtest.New(
+		streamtest.WithProtocols(prover.Protocol()),
+		streamtest.WithBaseAddr(test.RandomAddress()),
+	)
+	blocklister := new(blocklisterMock)
+	verifier := challenge.New(test.RandomAddress(), recorder, storer, nil, blocklister, statestore.NewStateStore(), logger, challenge.Options{
+		BlocklistAfter: 2,
+	})
+	verifier.SetReporter(blocklister)
+
+	for i := 0; i < 2; i++ {
+		result, err := verifier.Challenge(context.Background(), proverAddr, rootCid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Passed || result.Error == "" {
+			t.Fatalf("got result %+v, want failed", result)
+		}
+		blocklister.mu.Lock()
+		blocklisted := len(blocklister.peers)
+		blocklister.mu.Unlock()
+		if want := i; blocklisted != want {
+			t.Fatalf("got %d peers blocklisted after %d failures, want %d", blocklisted, i+1, want)
+		}
+	}
+	if err := verifier.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(blocklister.reports) != 2 {
+		t.Fatalf("got %d failures reported, want 2", len(blocklister.reports))
+	}
+
+	reps, err := verifier.Reputations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reps) != 1 || !reps[0].Peer.Equal(proverAddr) || reps[0].Failed != 2 || reps[0].ConsecutiveFailures != 2 || reps[0].LastError == "" {
+		t.Fatalf("got reputations %+v, want two failures of %s", reps, proverAddr)
+	}
+	if trust := reps[0].Trust(); trust != 1.0/3 {
+		t.Fatalf("got trust %v, want %v", trust, 1.0/3)
+	}
+}
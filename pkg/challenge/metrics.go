@@ -0,0 +1,48 @@
+package challenge
+
+import (
+	m "github.com/FavorLabs/favorX/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	Passed      prometheus.Counter
+	Failed      prometheus.Counter
+	Answered    prometheus.Counter
+	Blocklisted prometheus.Counter
+}
+
+func newMetrics() metrics {
+	subsystem := "challenge"
+
+	return metrics{
+		Passed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: m.Namespace,
+			Subsystem: subsystem,
+			Name:      "passed",
+			Help:      "Number of storage challenges the peers passed.",
+		}),
+		Failed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: m.Namespace,
+			Subsystem: subsystem,
+			Name:      "failed",
+			Help:      "Number of storage challenges the peers failed.",
+		}),
+		Answered: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: m.Namespace,
+			Subsystem: subsystem,
+			Name:      "answered",
+			Help:      "Number of storage challenges from the peers answered.",
+		}),
+		Blocklisted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: m.Namespace,
+			Subsystem: subsystem,
+			Name:      "blocklisted",
+			Help:      "Number of peers blocklisted for failing storage challenges.",
+		}),
+	}
+}
+
+func (s *Service) Metrics() []prometheus.Collector {
+	return m.PrometheusCollectorsFromFields(s.metrics)
+}
@@ -33,6 +33,13 @@ type Interface interface {
 	CancelFindChunkInfo(rootCid boson.Address)
 }
 
+// Providers finds the nodes storing a file besides the oracle, and announces
+// the files the node stores.
+type Providers interface {
+	Provide(ctx context.Context, rootCid boson.Address) error
+	FindProviders(ctx context.Context, rootCid boson.Address) []boson.Address
+}
+
 type ChunkInfo struct {
 	addr           boson.Address
 	route          routetab.RouteTab
@@ -41,6 +48,7 @@ type ChunkInfo struct {
 	metrics        metrics
 	singleflight   singleflight.Group
 	oracleChain    chain.Resolver
+	providers      Providers
 	subPub         subscribe.SubPub
 	fileInfo       fileinfo.Interface
 	discover       sync.Map
@@ -71,6 +79,12 @@ func New(addr boson.Address, streamer p2p.Streamer, logger logging.Logger,
 	return chunkInfo
 }
 
+// SetProviders makes the node look the providers of the files up besides
+// the oracle, and announce the files uploaded right away.
+func (ci *ChunkInfo) SetProviders(providers Providers) {
+	ci.providers = providers
+}
+
 type BitVector struct {
 	Len int    `json:"len"`
 	B   []byte `json:"b"`
@@ -96,12 +110,10 @@ func (ci *ChunkInfo) Discover(ctx context.Context, authInfo []byte, rootCid boso
 		overlays, _ := sctx.GetTargets(topCtx)
 		if overlays == nil {
 			rootCid := sctx.GetRootHash(topCtx)
-			value, ok := ci.discover.Load(rootCid.String())
-			if !ok && chain {
-				overlays = ci.oracleChain.GetNodesFromCid(rootCid.Bytes())
-			}
-			if ok {
+			if value, ok := ci.discover.Load(rootCid.String()); ok {
 				overlays = value.([]boson.Address)
+			} else {
+				overlays = ci.findSources(ctx, rootCid, chain)
 			}
 			oracles, _ := sctx.GetOracle(topCtx)
 			if oracles != nil {
@@ -121,6 +133,26 @@ func (ci *ChunkInfo) Discover(ctx context.Context, authInfo []byte, rootCid boso
 	return v.(bool)
 }
 
+// findSources asks the providers, and the oracle of the chain if chain is
+// set, in parallel for the nodes storing the file.
+func (ci *ChunkInfo) findSources(ctx context.Context, rootCid boson.Address, chain bool) []boson.Address {
+	var found chan []boson.Address
+	if ci.providers != nil {
+		found = make(chan []boson.Address, 1)
+		go func() {
+			found <- ci.providers.FindProviders(ctx, rootCid)
+		}()
+	}
+	var overlays []boson.Address
+	if chain {
+		overlays = ci.oracleChain.GetNodesFromCid(rootCid.Bytes())
+	}
+	if found != nil {
+		overlays = removeRepeatElement(overlays, <-found...)
+	}
+	return overlays
+}
+
 func removeRepeatElement(list []boson.Address, address ...boson.Address) []boson.Address {
 	addresses := make(map[string]struct{}, len(list)+len(address))
 	for _, over := range list {
@@ -177,6 +209,9 @@ func (ci *ChunkInfo) OnFileUpload(ctx context.Context, rootCid boson.Address, le
 			return err
 		}
 	}
+	if ci.providers != nil {
+		return ci.providers.Provide(ctx, rootCid)
+	}
 	return nil
 }
 
@@ -0,0 +1,76 @@
+package debugapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/challenge"
+	"github.com/FavorLabs/favorX/pkg/jsonhttp"
+	"github.com/gorilla/mux"
+)
+
+var errNoChallenge = errors.New("no storage challenge service")
+
+type challengeReputationsResponse struct {
+	Reputations []challenge.Reputation `json:"reputations"`
+}
+
+// MustRegisterChallenge sets the service the /challenges endpoints report on
+// and challenge the providers with.
+func (s *Service) MustRegisterChallenge(c *challenge.Service) {
+	s.challenge = c
+}
+
+func (s *Service) challengeReputationsHandler(w http.ResponseWriter, r *http.Request) {
+	if s.challenge == nil {
+		jsonhttp.NotImplemented(w, errNoChallenge)
+		return
+	}
+	reps, err := s.challenge.Reputations()
+	if err != nil {
+		s.logger.Debugf("debug api: challenge reputations: %v", err)
+		s.logger.Error("debug api: challenge reputations")
+		jsonhttp.InternalServerError(w, "challenge reputations")
+		return
+	}
+	jsonhttp.OK(w, challengeReputationsResponse{Reputations: reps})
+}
+
+// challengeHandler challenges the peer of the query, or a random node
+// registered on chain, for the file.
+func (s *Service) challengeHandler(w http.ResponseWriter, r *http.Request) {
+	if s.challenge == nil {
+		jsonhttp.NotImplemented(w, errNoChallenge)
+		return
+	}
+	rootCid, err := boson.ParseHexAddress(mux.Vars(r)["rootCid"])
+	if err != nil {
+		s.logger.Debugf("debug api: challenge: parse rootCid: %v", err)
+		jsonhttp.BadRequest(w, "bad rootCid")
+		return
+	}
+
+	var result challenge.Result
+	if p := r.URL.Query().Get("peer"); p != "" {
+		peer, err := boson.ParseHexAddress(p)
+		if err != nil {
+			s.logger.Debugf("debug api: challenge: parse peer: %v", err)
+			jsonhttp.BadRequest(w, "bad peer")
+			return
+		}
+		result, err = s.challenge.Challenge(r.Context(), peer, rootCid)
+	} else {
+		result, err = s.challenge.ChallengeRegistered(r.Context(), rootCid)
+	}
+	if errors.Is(err, challenge.ErrNoProviders) {
+		jsonhttp.NotFound(w, err)
+		return
+	}
+	if err != nil {
+		s.logger.Debugf("debug api: challenge %s: %v", rootCid, err)
+		jsonhttp.BadGateway(w, err)
+		return
+	}
+	jsonhttp.OK(w, result)
+}
@@ -0,0 +1,41 @@
+package debugapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/FavorLabs/favorX/pkg/jsonhttp"
+)
+
+// ConfigReloader reloads the configuration of the node. It returns the
+// settings applied live and the ones that need a restart.
+type ConfigReloader func() (applied, restartRequired []string, err error)
+
+type configReloadResponse struct {
+	Applied         []string `json:"applied"`
+	RestartRequired []string `json:"restartRequired"`
+}
+
+// SetConfigReloader sets the function the /config/reload endpoint reloads
+// the configuration with. It must be called before Configure.
+func (s *Service) SetConfigReloader(r ConfigReloader) {
+	s.configReloader = r
+}
+
+func (s *Service) configReloadHandler(w http.ResponseWriter, r *http.Request) {
+	if s.configReloader == nil {
+		jsonhttp.NotImplemented(w, errors.New("config reload not supported"))
+		return
+	}
+	applied, restartRequired, err := s.configReloader()
+	if err != nil {
+		s.logger.Debugf("debugapi: config reload: %v", err)
+		s.logger.Error("debugapi: config reload failed")
+		jsonhttp.InternalServerError(w, err)
+		return
+	}
+	jsonhttp.OK(w, configReloadResponse{
+		Applied:         applied,
+		RestartRequired: restartRequired,
+	})
+}
@@ -0,0 +1,54 @@
+package debugapi_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/FavorLabs/favorX/pkg/debugapi"
+	"github.com/FavorLabs/favorX/pkg/jsonhttp"
+	"github.com/FavorLabs/favorX/pkg/jsonhttp/jsonhttptest"
+)
+
+func TestConfigReload(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		srv := newTestServer(t, testServerOptions{
+			ConfigReloader: func() ([]string, []string, error) {
+				return []string{"Groups", "LogLevel"}, []string{"APIAddr"}, nil
+			},
+		})
+
+		jsonhttptest.Request(t, srv.Client, http.MethodPost, "/config/reload", http.StatusOK,
+			jsonhttptest.WithExpectedJSONResponse(debugapi.ConfigReloadResponse{
+				Applied:         []string{"Groups", "LogLevel"},
+				RestartRequired: []string{"APIAddr"},
+			}),
+		)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		srv := newTestServer(t, testServerOptions{
+			ConfigReloader: func() ([]string, []string, error) {
+				return nil, nil, errors.New("bad config")
+			},
+		})
+
+		jsonhttptest.Request(t, srv.Client, http.MethodPost, "/config/reload", http.StatusInternalServerError,
+			jsonhttptest.WithExpectedJSONResponse(jsonhttp.StatusResponse{
+				Message: "bad config",
+				Code:    http.StatusInternalServerError,
+			}),
+		)
+	})
+
+	t.Run("not supported", func(t *testing.T) {
+		srv := newTestServer(t, testServerOptions{})
+
+		jsonhttptest.Request(t, srv.Client, http.MethodPost, "/config/reload", http.StatusNotImplemented,
+			jsonhttptest.WithExpectedJSONResponse(jsonhttp.StatusResponse{
+				Message: "config reload not supported",
+				Code:    http.StatusNotImplemented,
+			}),
+		)
+	})
+}
@@ -8,7 +8,7 @@ import (
 // corsHandler sets CORS headers to HTTP response if allowed origins are configured.
 func (s *Service) corsHandler(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if o := r.Header.Get("Origin"); o != "" && checkOrigin(r, s.corsAllowedOrigins) {
+		if o := r.Header.Get("Origin"); o != "" && checkOrigin(r, s.getCORSAllowedOrigins()) {
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 			w.Header().Set("Access-Control-Allow-Origin", o)
 			w.Header().Set("Access-Control-Allow-Headers", "User-Agent, Origin, Accept, Authorization, Content-Type, X-Requested-With, Access-Control-Request-Headers, Access-Control-Request-Method")
@@ -23,6 +23,20 @@ func (s *Service) corsHandler(h http.Handler) http.Handler {
 	})
 }
 
+// SetCORSAllowedOrigins replaces the origins allowed to make cross-origin
+// requests.
+func (s *Service) SetCORSAllowedOrigins(origins []string) {
+	s.corsMu.Lock()
+	s.corsAllowedOrigins = origins
+	s.corsMu.Unlock()
+}
+
+func (s *Service) getCORSAllowedOrigins() []string {
+	s.corsMu.RLock()
+	defer s.corsMu.RUnlock()
+	return s.corsAllowedOrigins
+}
+
 // checkOrigin returns true if the origin header is not set or is equal to the request host.
 func checkOrigin(r *http.Request, allowed []string) bool {
 	origin := r.Header["Origin"]
@@ -33,7 +47,7 @@ func checkOrigin(r *http.Request, allowed []string) bool {
 	if r.TLS != nil {
 		scheme = "https"
 	}
-	hosts := append(allowed, scheme+"://"+r.Host)
+	hosts := append([]string{scheme + "://" + r.Host}, allowed...)
 	for _, v := range hosts {
 		if equalASCIIFold(origin[0], v) || v == "*" {
 			return true
@@ -0,0 +1,38 @@
+package debugapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/FavorLabs/favorX/pkg/jsonhttp"
+)
+
+// DBRekeyer rotates the data keys of the encrypted databases of the node and
+// re-encrypts their data while the node is running.
+type DBRekeyer func() error
+
+// SetDBRekeyer sets the function the /db/rekey endpoint rotates the database
+// keys with. It must be called before Configure.
+func (s *Service) SetDBRekeyer(r DBRekeyer) {
+	s.dbRekeyer = r
+}
+
+func (s *Service) dbRekeyHandler(w http.ResponseWriter, r *http.Request) {
+	if s.dbRekeyer == nil {
+		jsonhttp.NotImplemented(w, errors.New("database encryption not enabled"))
+		return
+	}
+	if !s.dbRekeyMu.TryLock() {
+		jsonhttp.Conflict(w, errors.New("database rekey in progress"))
+		return
+	}
+	defer s.dbRekeyMu.Unlock()
+
+	if err := s.dbRekeyer(); err != nil {
+		s.logger.Debugf("debugapi: db rekey: %v", err)
+		s.logger.Error("debugapi: db rekey failed")
+		jsonhttp.InternalServerError(w, err)
+		return
+	}
+	jsonhttp.OK(w, nil)
+}
@@ -0,0 +1,53 @@
+package debugapi_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/FavorLabs/favorX/pkg/jsonhttp"
+	"github.com/FavorLabs/favorX/pkg/jsonhttp/jsonhttptest"
+)
+
+func TestDBRekey(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		var called bool
+		srv := newTestServer(t, testServerOptions{
+			DBRekeyer: func() error {
+				called = true
+				return nil
+			},
+		})
+
+		jsonhttptest.Request(t, srv.Client, http.MethodPost, "/db/rekey", http.StatusOK)
+		if !called {
+			t.Fatal("keys not rotated")
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		srv := newTestServer(t, testServerOptions{
+			DBRekeyer: func() error {
+				return errors.New("rotate failed")
+			},
+		})
+
+		jsonhttptest.Request(t, srv.Client, http.MethodPost, "/db/rekey", http.StatusInternalServerError,
+			jsonhttptest.WithExpectedJSONResponse(jsonhttp.StatusResponse{
+				Message: "rotate failed",
+				Code:    http.StatusInternalServerError,
+			}),
+		)
+	})
+
+	t.Run("not encrypted", func(t *testing.T) {
+		srv := newTestServer(t, testServerOptions{})
+
+		jsonhttptest.Request(t, srv.Client, http.MethodPost, "/db/rekey", http.StatusNotImplemented,
+			jsonhttptest.WithExpectedJSONResponse(jsonhttp.StatusResponse{
+				Message: "database encryption not enabled",
+				Code:    http.StatusNotImplemented,
+			}),
+		)
+	})
+}
@@ -9,14 +9,18 @@ import (
 	"github.com/FavorLabs/favorX/pkg/address"
 	"github.com/FavorLabs/favorX/pkg/addressbook"
 	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/challenge"
 	"github.com/FavorLabs/favorX/pkg/chunkinfo"
 	"github.com/FavorLabs/favorX/pkg/fileinfo"
 	"github.com/FavorLabs/favorX/pkg/logging"
 	"github.com/FavorLabs/favorX/pkg/multicast"
 	"github.com/FavorLabs/favorX/pkg/p2p"
 	"github.com/FavorLabs/favorX/pkg/pingpong"
+	"github.com/FavorLabs/favorX/pkg/pricing"
 	"github.com/FavorLabs/favorX/pkg/retrieval"
 	"github.com/FavorLabs/favorX/pkg/routetab"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/oracle"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/transaction"
 	"github.com/FavorLabs/favorX/pkg/settlement/traffic"
 	"github.com/FavorLabs/favorX/pkg/storage"
 	"github.com/FavorLabs/favorX/pkg/topology"
@@ -54,7 +58,12 @@ type Service struct {
 	fileInfo           fileinfo.Interface
 	retrieval          retrieval.Interface
 	traffic            traffic.ApiInterface
+	transaction        transaction.Service
+	pricing            pricing.Interface
+	oracleIndexes      []*oracle.Index
+	challenge          *challenge.Service
 	corsAllowedOrigins []string
+	corsMu             sync.RWMutex
 	metricsRegistry    *prometheus.Registry
 	handler            http.Handler
 	handlerMu          sync.RWMutex
@@ -62,6 +71,9 @@ type Service struct {
 	cache              *gcache.Cache
 	cacheCtx           context.Context
 	addressBook        addressbook.Interface
+	configReloader     ConfigReloader
+	dbRekeyer          DBRekeyer
+	dbRekeyMu          sync.Mutex
 }
 
 type Options struct {
@@ -39,6 +39,8 @@ type testServerOptions struct {
 	Resolver           resolver.Interface
 	TopologyOpts       []topologymock.Option
 	AccountingOpts     []accountingmock.Option
+	ConfigReloader     debugapi.ConfigReloader
+	DBRekeyer          debugapi.DBRekeyer
 }
 
 type testServer struct {
@@ -57,6 +59,8 @@ func newTestServer(t *testing.T, o testServerOptions) *testServer {
 	ln := lightnode.NewContainer(o.Overlay)
 	bn := bootnode.NewContainer(o.Overlay)
 	s := debugapi.New(o.Overlay, o.PublicKey, logging.New(io.Discard, 0), nil, o.CORSAllowedOrigins, false, nil, debugapi.Options{NodeMode: address.NewModel()})
+	s.SetConfigReloader(o.ConfigReloader)
+	s.SetDBRekeyer(o.DBRekeyer)
 	s.Configure(o.P2P, o.Pingpong, nil, topologyDriver, ln, bn, o.Storer, nil, nil, nil, nil, nil)
 	ts := httptest.NewServer(s)
 	t.Cleanup(ts.Close)
@@ -8,6 +8,7 @@ type (
 	AddressesResponse      = addressesResponse
 	WelcomeMessageRequest  = welcomeMessageRequest
 	WelcomeMessageResponse = welcomeMessageResponse
+	ConfigReloadResponse   = configReloadResponse
 	//BalancesResponse       = balancesResponse
 	//BalanceResponse        = balanceResponse
 	//SettlementResponse     = settlementResponse
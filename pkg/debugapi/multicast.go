@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"github.com/FavorLabs/favorX/pkg/jsonhttp"
+	"github.com/FavorLabs/favorX/pkg/multicast/model"
 )
 
 func (s *Service) groupsTopologyHandler(w http.ResponseWriter, r *http.Request) {
@@ -20,3 +21,15 @@ func (s *Service) groupsTopologyHandler(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("Content-Type", jsonhttp.DefaultContentTypeHeader)
 	_, _ = io.Copy(w, bytes.NewBuffer(b))
 }
+
+type groupsDiscoveryResponse struct {
+	Groups []model.GroupDiscovery `json:"groups"`
+}
+
+func (s *Service) groupsDiscoveryHandler(w http.ResponseWriter, r *http.Request) {
+	groups := s.group.DiscoveryStatus()
+	if groups == nil {
+		groups = []model.GroupDiscovery{}
+	}
+	jsonhttp.OK(w, groupsDiscoveryResponse{Groups: groups})
+}
@@ -0,0 +1,45 @@
+package debugapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/FavorLabs/favorX/pkg/jsonhttp"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/oracle"
+)
+
+var errNoOracleIndex = errors.New("no oracle index")
+
+type oracleIndexesResponse struct {
+	Indexes []oracle.IndexStatus `json:"indexes"`
+}
+
+// MustRegisterOracleIndexes sets the indexes of the oracle contracts the
+// /oracle/index endpoints report on and resync.
+func (s *Service) MustRegisterOracleIndexes(indexes ...*oracle.Index) {
+	s.oracleIndexes = indexes
+}
+
+func (s *Service) oracleIndexHandler(w http.ResponseWriter, r *http.Request) {
+	if len(s.oracleIndexes) == 0 {
+		jsonhttp.NotImplemented(w, errNoOracleIndex)
+		return
+	}
+	resp := oracleIndexesResponse{Indexes: make([]oracle.IndexStatus, 0, len(s.oracleIndexes))}
+	for _, index := range s.oracleIndexes {
+		resp.Indexes = append(resp.Indexes, index.Status())
+	}
+	jsonhttp.OK(w, resp)
+}
+
+func (s *Service) oracleIndexResyncHandler(w http.ResponseWriter, r *http.Request) {
+	if len(s.oracleIndexes) == 0 {
+		jsonhttp.NotImplemented(w, errNoOracleIndex)
+		return
+	}
+	for _, index := range s.oracleIndexes {
+		index.Resync()
+	}
+	s.logger.Infof("debugapi: oracle index: resyncing %d indexes", len(s.oracleIndexes))
+	jsonhttp.Accepted(w, nil)
+}
@@ -0,0 +1,61 @@
+package debugapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/FavorLabs/favorX/pkg/address"
+	"github.com/FavorLabs/favorX/pkg/jsonhttp"
+	"github.com/FavorLabs/favorX/pkg/pricing"
+)
+
+const priceMaxRequestSize = 512
+
+var errNoPricingService = errors.New("no pricing service")
+
+type peerPricesResponse struct {
+	Prices map[string]address.Price `json:"prices"`
+}
+
+// MustRegisterPricing sets the pricing service the /price endpoints read and
+// change the prices of.
+func (s *Service) MustRegisterPricing(pricingService pricing.Interface) {
+	s.pricing = pricingService
+}
+
+func (s *Service) getPriceHandler(w http.ResponseWriter, r *http.Request) {
+	if s.pricing == nil {
+		jsonhttp.NotImplemented(w, errNoPricingService)
+		return
+	}
+	jsonhttp.OK(w, s.pricing.Price())
+}
+
+func (s *Service) setPriceHandler(w http.ResponseWriter, r *http.Request) {
+	if s.pricing == nil {
+		jsonhttp.NotImplemented(w, errNoPricingService)
+		return
+	}
+	var price address.Price
+	if err := json.NewDecoder(r.Body).Decode(&price); err != nil {
+		s.logger.Debugf("debugapi: price: failed to read request: %v", err)
+		jsonhttp.BadRequest(w, err)
+		return
+	}
+	if err := s.pricing.SetPrice(r.Context(), price); err != nil {
+		s.logger.Debugf("debugapi: price: failed to set: %v", err)
+		s.logger.Errorf("Failed to set price")
+		jsonhttp.InternalServerError(w, err)
+		return
+	}
+	jsonhttp.OK(w, nil)
+}
+
+func (s *Service) peerPricesHandler(w http.ResponseWriter, r *http.Request) {
+	if s.pricing == nil {
+		jsonhttp.NotImplemented(w, errNoPricingService)
+		return
+	}
+	jsonhttp.OK(w, peerPricesResponse{Prices: s.pricing.PeerPrices()})
+}
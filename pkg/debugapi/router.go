@@ -116,6 +116,9 @@ func (s *Service) newRouter() *mux.Router {
 		handle("/topology/group", jsonhttp.MethodHandler{
 			"GET": http.HandlerFunc(s.groupsTopologyHandler),
 		})
+		handle("/topology/group/discovery", jsonhttp.MethodHandler{
+			"GET": http.HandlerFunc(s.groupsDiscoveryHandler),
+		})
 	}
 	handle("/route/{peer-id}", jsonhttp.MethodHandler{
 		"GET":    http.HandlerFunc(s.getRouteHandel),
@@ -156,6 +159,42 @@ func (s *Service) newRouter() *mux.Router {
 	handle("/tun/stats", jsonhttp.MethodHandler{
 		"GET": http.HandlerFunc(s.tunStats),
 	})
+	handle("/config/reload", jsonhttp.MethodHandler{
+		"POST": http.HandlerFunc(s.configReloadHandler),
+	})
+	handle("/db/rekey", jsonhttp.MethodHandler{
+		"POST": http.HandlerFunc(s.dbRekeyHandler),
+	})
+	handle("/transactions", jsonhttp.MethodHandler{
+		"GET": http.HandlerFunc(s.transactionListHandler),
+	})
+	handle("/transactions/{hash}", jsonhttp.MethodHandler{
+		"GET":    http.HandlerFunc(s.transactionDetailHandler),
+		"POST":   http.HandlerFunc(s.transactionSpeedUpHandler),
+		"DELETE": http.HandlerFunc(s.transactionCancelHandler),
+	})
+	handle("/price", jsonhttp.MethodHandler{
+		"GET": http.HandlerFunc(s.getPriceHandler),
+		"POST": web.ChainHandlers(
+			jsonhttp.NewMaxBodyBytesHandler(priceMaxRequestSize),
+			web.FinalHandlerFunc(s.setPriceHandler),
+		),
+	})
+	handle("/prices", jsonhttp.MethodHandler{
+		"GET": http.HandlerFunc(s.peerPricesHandler),
+	})
+	handle("/oracle/index", jsonhttp.MethodHandler{
+		"GET": http.HandlerFunc(s.oracleIndexHandler),
+	})
+	handle("/oracle/index/resync", jsonhttp.MethodHandler{
+		"POST": http.HandlerFunc(s.oracleIndexResyncHandler),
+	})
+	handle("/challenges", jsonhttp.MethodHandler{
+		"GET": http.HandlerFunc(s.challengeReputationsHandler),
+	})
+	handle("/challenges/{rootCid}", jsonhttp.MethodHandler{
+		"POST": http.HandlerFunc(s.challengeHandler),
+	})
 
 	s.newLoopbackRouter(router)
 
@@ -0,0 +1,171 @@
+package debugapi
+
+import (
+	"errors"
+	"math/big"
+	"net/http"
+
+	"github.com/FavorLabs/favorX/pkg/jsonhttp"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/transaction"
+	"github.com/FavorLabs/favorX/pkg/storage"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gorilla/mux"
+)
+
+var errNoTransactionService = errors.New("no transaction service")
+
+type transactionInfo struct {
+	Hash        common.Hash          `json:"hash"`
+	Replaced    []common.Hash        `json:"replaced"`
+	Status      transaction.TxStatus `json:"status"`
+	Description string               `json:"description"`
+	Nonce       uint64               `json:"nonce"`
+	To          *common.Address      `json:"to"`
+	Data        hexutil.Bytes        `json:"data"`
+	Value       *big.Int             `json:"value"`
+	GasLimit    uint64               `json:"gasLimit"`
+	GasPrice    *big.Int             `json:"gasPrice,omitempty"`
+	GasFeeCap   *big.Int             `json:"gasFeeCap,omitempty"`
+	GasTipCap   *big.Int             `json:"gasTipCap,omitempty"`
+	Cancelled   bool                 `json:"cancelled"`
+	Created     int64                `json:"created"`
+	Sent        int64                `json:"sent"`
+	Bumps       int                  `json:"bumps"`
+}
+
+type transactionsResponse struct {
+	Transactions []transactionInfo `json:"transactions"`
+}
+
+type transactionHashResponse struct {
+	Hash common.Hash `json:"hash"`
+}
+
+// MustRegisterTransaction sets the transaction service the /transactions
+// endpoints list and replace the pending transactions of.
+func (s *Service) MustRegisterTransaction(transactionService transaction.Service) {
+	s.transaction = transactionService
+}
+
+func newTransactionInfo(stx *transaction.StoredTransaction) transactionInfo {
+	replaced := stx.Replaced
+	if replaced == nil {
+		replaced = []common.Hash{}
+	}
+	return transactionInfo{
+		Hash:        stx.Hash,
+		Replaced:    replaced,
+		Status:      stx.Status,
+		Description: stx.Description,
+		Nonce:       stx.Nonce,
+		To:          stx.To,
+		Data:        stx.Data,
+		Value:       stx.Value,
+		GasLimit:    stx.GasLimit,
+		GasPrice:    stx.GasPrice,
+		GasFeeCap:   stx.GasFeeCap,
+		GasTipCap:   stx.GasTipCap,
+		Cancelled:   stx.Cancelled,
+		Created:     stx.Created,
+		Sent:        stx.Sent,
+		Bumps:       stx.Bumps,
+	}
+}
+
+func (s *Service) transactionListHandler(w http.ResponseWriter, r *http.Request) {
+	if s.transaction == nil {
+		jsonhttp.NotImplemented(w, errNoTransactionService)
+		return
+	}
+	txs, err := s.transaction.PendingTransactions()
+	if err != nil {
+		s.logger.Debugf("debugapi: pending transactions: %v", err)
+		s.logger.Error("debugapi: pending transactions failed")
+		jsonhttp.InternalServerError(w, err)
+		return
+	}
+	resp := transactionsResponse{Transactions: make([]transactionInfo, 0, len(txs))}
+	for _, stx := range txs {
+		resp.Transactions = append(resp.Transactions, newTransactionInfo(stx))
+	}
+	jsonhttp.OK(w, resp)
+}
+
+// transactionHash returns the hash in the path, writing the response when it
+// cannot be handled.
+func (s *Service) transactionHash(w http.ResponseWriter, r *http.Request) (common.Hash, bool) {
+	if s.transaction == nil {
+		jsonhttp.NotImplemented(w, errNoTransactionService)
+		return common.Hash{}, false
+	}
+	hash, err := hexutil.Decode(mux.Vars(r)["hash"])
+	if err != nil || len(hash) != common.HashLength {
+		jsonhttp.BadRequest(w, "invalid transaction hash")
+		return common.Hash{}, false
+	}
+	return common.BytesToHash(hash), true
+}
+
+func (s *Service) transactionError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, transaction.ErrTransactionNotFound):
+		jsonhttp.NotFound(w, err)
+	case errors.Is(err, transaction.ErrTransactionNotPending):
+		jsonhttp.BadRequest(w, err)
+	default:
+		s.logger.Debugf("debugapi: %s transaction: %v", action, err)
+		s.logger.Errorf("debugapi: %s transaction failed", action)
+		jsonhttp.InternalServerError(w, err)
+	}
+}
+
+func (s *Service) transactionDetailHandler(w http.ResponseWriter, r *http.Request) {
+	hash, ok := s.transactionHash(w, r)
+	if !ok {
+		return
+	}
+	stx, err := s.transaction.StoredTransaction(hash)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			err = transaction.ErrTransactionNotFound
+		}
+		s.transactionError(w, "get", err)
+		return
+	}
+	jsonhttp.OK(w, newTransactionInfo(stx))
+}
+
+func (s *Service) transactionSpeedUpHandler(w http.ResponseWriter, r *http.Request) {
+	hash, ok := s.transactionHash(w, r)
+	if !ok {
+		return
+	}
+	var gasPrice *big.Int
+	if price := r.URL.Query().Get("gasPrice"); price != "" {
+		var valid bool
+		if gasPrice, valid = new(big.Int).SetString(price, 10); !valid || gasPrice.Sign() <= 0 {
+			jsonhttp.BadRequest(w, "invalid gas price")
+			return
+		}
+	}
+	replacement, err := s.transaction.SpeedUpTransaction(r.Context(), hash, gasPrice)
+	if err != nil {
+		s.transactionError(w, "speed up", err)
+		return
+	}
+	jsonhttp.OK(w, transactionHashResponse{Hash: replacement})
+}
+
+func (s *Service) transactionCancelHandler(w http.ResponseWriter, r *http.Request) {
+	hash, ok := s.transactionHash(w, r)
+	if !ok {
+		return
+	}
+	replacement, err := s.transaction.CancelTransaction(r.Context(), hash)
+	if err != nil {
+		s.transactionError(w, "cancel", err)
+		return
+	}
+	jsonhttp.OK(w, transactionHashResponse{Hash: replacement})
+}
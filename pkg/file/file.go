@@ -39,3 +39,21 @@ type Joiner interface {
 type Splitter interface {
 	Split(ctx context.Context, dataIn io.ReadCloser, dataLength int64, toEncrypt bool) (addr boson.Address, err error)
 }
+
+// IndexedSpanFlag marks the span of an intermediate chunk of a content-defined
+// chunked trie. The leaves of such a trie have variable sizes, so its
+// intermediate chunks hold span|reference pairs instead of bare references
+// and the subtrie sizes cannot be derived from the position of a reference.
+const IndexedSpanFlag uint64 = 1 << 63
+
+// IsIndexedSpan reports whether the span belongs to an intermediate chunk
+// holding span|reference pairs.
+func IsIndexedSpan(span uint64) bool {
+	return span&IndexedSpanFlag != 0
+}
+
+// SpanLength returns the length of the data represented by the span with
+// the IndexedSpanFlag cleared.
+func SpanLength(span uint64) uint64 {
+	return span &^ IndexedSpanFlag
+}
@@ -19,10 +19,12 @@ import (
 type joiner struct {
 	addr      boson.Address
 	rootData  []byte
+	rootLeaf  bool
 	span      int64
 	off       int64
 	index     int64
 	refLength int
+	indexed   bool // intermediate chunks hold span|reference pairs, see file.IndexedSpanFlag
 
 	dataChunks    [][]byte
 	allowSaveData bool
@@ -46,16 +48,19 @@ func New(ctx context.Context, getter storage.Getter, getMode storage.ModeGet, ad
 
 	var chunkData = rootChunk.Data()
 
-	span := int64(binary.LittleEndian.Uint64(chunkData[:boson.SpanSize]))
+	rawSpan := binary.LittleEndian.Uint64(chunkData[:boson.SpanSize])
+	span := int64(file.SpanLength(rawSpan))
 	j := &joiner{
 		addr:      rootChunk.Address(),
 		refLength: len(address.Bytes()),
+		indexed:   file.IsIndexedSpan(rawSpan),
 		ctx:       ctx,
 		index:     index,
 		getter:    getter,
 		getMode:   getMode,
 		span:      span,
 		rootData:  chunkData[boson.SpanSize:],
+		rootLeaf:  isLeaf(rawSpan, chunkData[boson.SpanSize:]),
 	}
 
 	return j, span, nil
@@ -97,13 +102,13 @@ func (j *joiner) ReadAt(buffer []byte, off int64) (read int, err error) {
 		return 0, io.EOF
 	}
 
-	readLen := int64(cap(buffer))
+	readLen := int64(len(buffer))
 	if readLen > j.span-off {
 		readLen = j.span - off
 	}
 	var bytesRead int64
 	var eg errgroup.Group
-	j.readAtOffset(buffer, j.rootData, 0, 0, 0, j.span, off, 0, readLen, &bytesRead, &eg)
+	j.readAtOffset(buffer, j.rootData, j.rootLeaf, 0, 0, 0, j.span, off, 0, readLen, &bytesRead, &eg)
 
 	err = eg.Wait()
 	if err != nil {
@@ -115,9 +120,9 @@ func (j *joiner) ReadAt(buffer []byte, off int64) (read int, err error) {
 
 var ErrMalformedTrie = errors.New("malformed tree")
 
-func (j *joiner) readAtOffset(b, data []byte, index, lastIndex, cur, subTrieSize, off, bufferOffset, bytesToRead int64, bytesRead *int64, eg *errgroup.Group) {
+func (j *joiner) readAtOffset(b, data []byte, leaf bool, index, lastIndex, cur, subTrieSize, off, bufferOffset, bytesToRead int64, bytesRead *int64, eg *errgroup.Group) {
 	// we are at a leaf data chunk
-	if subTrieSize <= int64(len(data)) {
+	if leaf {
 		dataOffsetStart := off - cur
 		dataOffsetEnd := dataOffsetStart + bytesToRead
 
@@ -131,20 +136,19 @@ func (j *joiner) readAtOffset(b, data []byte, index, lastIndex, cur, subTrieSize
 		return
 	}
 
-	for cursor := 0; cursor < len(data); cursor += j.refLength {
+	for cursor := 0; cursor < len(data); cursor += j.entryLength() {
 		if bytesToRead == 0 {
 			break
 		}
 
 		// fast-forward the cursor
-		sec := subtrieSection(data, cursor, j.refLength, subTrieSize)
+		address, sec, _ := j.reference(data, cursor, subTrieSize)
 		if cur+sec < off {
 			cur += sec
 			continue
 		}
 
 		// if we are here it means that we are within the bounds of the data we need to read
-		address := boson.NewAddress(data[cursor : cursor+j.refLength])
 
 		subtrieSpan := sec
 		subtrieSpanLimit := sec
@@ -160,10 +164,10 @@ func (j *joiner) readAtOffset(b, data []byte, index, lastIndex, cur, subTrieSize
 
 		func(address boson.Address, b []byte, index, lastIndex, cur, subTrieSize, off, bufferOffset, bytesToRead, subtrieSpanLimit int64, cursor int) {
 			eg.Go(func() error {
-				chunkIndex := int64(cursor / j.refLength)
-				subtree := int64(boson.Branches)
+				chunkIndex := int64(cursor / j.entryLength())
+				subtree := j.branches()
 				index = lastIndex + 1 + (index * subtree) + chunkIndex
-				lastIndex = int64(len(data) / j.refLength)
+				lastIndex = int64(len(data) / j.entryLength())
 				ch, err := j.getter.Get(j.ctx, j.getMode, address, index+j.index)
 				if err != nil {
 					return err
@@ -176,7 +180,8 @@ func (j *joiner) readAtOffset(b, data []byte, index, lastIndex, cur, subTrieSize
 					return ErrMalformedTrie
 				}
 
-				j.readAtOffset(b, chunkData, index, lastIndex, cur, subtrieSpan, off, bufferOffset, bytesToRead, bytesRead, eg)
+				leaf := isLeaf(binary.LittleEndian.Uint64(ch.Data()[:8]), chunkData)
+				j.readAtOffset(b, chunkData, leaf, index, lastIndex, cur, subtrieSpan, off, bufferOffset, bytesToRead, bytesRead, eg)
 				return nil
 			})
 		}(address, b, index, lastIndex, cur, subtrieSpan, off, bufferOffset, currentReadSize, subtrieSpanLimit, cursor)
@@ -188,6 +193,36 @@ func (j *joiner) readAtOffset(b, data []byte, index, lastIndex, cur, subTrieSize
 	}
 }
 
+// entryLength returns the length of a reference entry in an intermediate chunk.
+func (j *joiner) entryLength() int {
+	if j.indexed {
+		return boson.SpanSize + j.refLength
+	}
+	return j.refLength
+}
+
+// branches returns the number of chunks the chunk index of a file counts
+// below an intermediate chunk: the number of span|reference entries an
+// intermediate chunk of an indexed trie holds, boson.Branches otherwise.
+func (j *joiner) branches() int64 {
+	if j.indexed {
+		return int64(boson.ChunkSize / j.entryLength())
+	}
+	return boson.Branches
+}
+
+// reference returns the address of the reference entry at the cursor, the size
+// of the subtrie it points to and whether it points to a leaf data chunk.
+func (j *joiner) reference(data []byte, cursor int, subTrieSize int64) (boson.Address, int64, bool) {
+	if j.indexed {
+		span := binary.LittleEndian.Uint64(data[cursor : cursor+boson.SpanSize])
+		address := boson.NewAddress(data[cursor+boson.SpanSize : cursor+boson.SpanSize+j.refLength])
+		return address, int64(file.SpanLength(span)), !file.IsIndexedSpan(span)
+	}
+	sec := subtrieSection(data, cursor, j.refLength, subTrieSize)
+	return boson.NewAddress(data[cursor : cursor+j.refLength]), sec, sec <= boson.ChunkSize
+}
+
 // brute-forces the subtrie size for each of the sections in this intermediate chunk
 func subtrieSection(data []byte, startIdx, refLen int, subtrieSize int64) int64 {
 	// assume we have a trie of size `y` then we can assume that all
@@ -253,12 +288,12 @@ func (j *joiner) IterateChunkAddresses(fn boson.AddressIterFunc) error {
 	if err != nil {
 		return err
 	}
-	return j.processChunkAddresses(j.ctx, fn, j.rootData, 0, 0, j.span)
+	return j.processChunkAddresses(j.ctx, fn, j.rootData, j.rootLeaf, 0, 0, j.span)
 }
 
-func (j *joiner) processChunkAddresses(ctx context.Context, fn boson.AddressIterFunc, data []byte, index, lastIndex, subTrieSize int64) error {
+func (j *joiner) processChunkAddresses(ctx context.Context, fn boson.AddressIterFunc, data []byte, leaf bool, index, lastIndex, subTrieSize int64) error {
 	// we are at a leaf data chunk
-	if subTrieSize <= int64(len(data)) {
+	if leaf {
 		if j.allowSaveData {
 			j.dataChunks = append(j.dataChunks, j.addr.Bytes())
 		}
@@ -275,16 +310,15 @@ func (j *joiner) processChunkAddresses(ctx context.Context, fn boson.AddressIter
 
 	var wg sync.WaitGroup
 
-	for cursor := 0; cursor < len(data); cursor += j.refLength {
+	for cursor := 0; cursor < len(data); cursor += j.entryLength() {
 
-		address := boson.NewAddress(data[cursor : cursor+j.refLength])
+		address, _, leaf := j.reference(data, cursor, subTrieSize)
 
 		if err := fn(address); err != nil {
 			return err
 		}
 
-		sec := subtrieSection(data, cursor, j.refLength, subTrieSize)
-		if sec <= boson.ChunkSize {
+		if leaf {
 			if j.allowSaveData {
 				j.dataChunks = append(j.dataChunks, address.Bytes())
 			}
@@ -296,13 +330,13 @@ func (j *joiner) processChunkAddresses(ctx context.Context, fn boson.AddressIter
 
 			eg.Go(func() error {
 				defer wg.Done()
-				chunkIndex := int64(cursor / j.refLength)
-				subtree := int64(boson.Branches)
+				chunkIndex := int64(cursor / j.entryLength())
+				subtree := j.branches()
 				index = lastIndex + 1 + (index * subtree) + chunkIndex
 				if index == 2 {
 					lastIndex = 0
 				} else {
-					lastIndex = int64(len(data) / j.refLength)
+					lastIndex = int64(len(data) / j.entryLength())
 				}
 				ch, err := j.getter.Get(ectx, j.getMode, address, index)
 				if err != nil {
@@ -316,7 +350,8 @@ func (j *joiner) processChunkAddresses(ctx context.Context, fn boson.AddressIter
 					j.edgeChunks[address.String()] = ch.Data()
 				}
 
-				return j.processChunkAddresses(ectx, fn, chunkData, index, lastIndex, subtrieSpan)
+				leaf := isLeaf(binary.LittleEndian.Uint64(ch.Data()[:8]), chunkData)
+				return j.processChunkAddresses(ectx, fn, chunkData, leaf, index, lastIndex, subtrieSpan)
 			})
 		}(address, index, lastIndex, cursor, eg)
 
@@ -330,6 +365,16 @@ func (j *joiner) Size() int64 {
 	return j.span
 }
 
+// isLeaf reports whether a chunk with the given span and data is a leaf data chunk.
+// Intermediate chunks of indexed tries are flagged, any other chunk is a leaf if
+// its data covers its whole span.
+func isLeaf(span uint64, data []byte) bool {
+	if file.IsIndexedSpan(span) {
+		return false
+	}
+	return span <= uint64(len(data))
+}
+
 func chunkToSpan(data []byte) uint64 {
-	return binary.LittleEndian.Uint64(data[:8])
+	return file.SpanLength(binary.LittleEndian.Uint64(data[:8]))
 }
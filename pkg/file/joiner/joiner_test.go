@@ -16,7 +16,12 @@ import (
 	"github.com/FavorLabs/favorX/pkg/cac"
 	"github.com/FavorLabs/favorX/pkg/encryption/store"
 	"github.com/FavorLabs/favorX/pkg/file/joiner"
+	"github.com/FavorLabs/favorX/pkg/file/pipeline"
+	"github.com/FavorLabs/favorX/pkg/file/pipeline/bmt"
 	"github.com/FavorLabs/favorX/pkg/file/pipeline/builder"
+	"github.com/FavorLabs/favorX/pkg/file/pipeline/feeder"
+	"github.com/FavorLabs/favorX/pkg/file/pipeline/hashtrie"
+	pstore "github.com/FavorLabs/favorX/pkg/file/pipeline/store"
 	"github.com/FavorLabs/favorX/pkg/file/splitter"
 	filetest "github.com/FavorLabs/favorX/pkg/file/testing"
 	"github.com/FavorLabs/favorX/pkg/storage"
@@ -873,3 +878,71 @@ func TestJoinerIterateChunkAddresses(t *testing.T) {
 		checkAddressFound(t, foundAddresses, createdAddress)
 	}
 }
+
+// TestJoinerIndexed tests reading at arbitrary offsets and iterating the chunks of
+// a multi-level trie of variable-size leaf chunks.
+func TestJoinerIndexed(t *testing.T) {
+	ctx := context.Background()
+	s := mock.NewStorer()
+	mode := storage.ModePutUpload
+
+	// small chunks give a branching factor of 6 and several trie levels
+	chunkSize := 256
+	tw := hashtrie.NewIndexedHashTrieWriter(chunkSize, boson.HashSize, func() pipeline.ChainWriter {
+		return bmt.NewBmtWriter(pstore.NewStoreWriter(ctx, s, mode, nil))
+	})
+	p := feeder.NewContentDefinedFeederWriter(16, 64, chunkSize, bmt.NewBmtWriter(pstore.NewStoreWriter(ctx, s, mode, tw)))
+
+	data := make([]byte, 50000)
+	_, _ = mrand.New(mrand.NewSource(1)).Read(data)
+	addr, err := builder.FeedPipeline(ctx, p, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	j, l, err := joiner.New(ctx, s, storage.ModeGetLookup, addr, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l != int64(len(data)) {
+		t.Fatalf("got span %d, want %d", l, len(data))
+	}
+
+	for _, off := range []int64{0, 1, 255, 256, 4097, 33333, 49999} {
+		b := make([]byte, 1000)
+		n, err := j.ReadAt(b, off)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := data[off:]
+		if len(want) > len(b) {
+			want = want[:len(b)]
+		}
+		if !bytes.Equal(b[:n], want) {
+			t.Fatalf("offset %d: read data differs", off)
+		}
+	}
+
+	var leaves, chunks int
+	j.SetSaveDataChunks()
+	err = j.IterateChunkAddresses(func(boson.Address) error {
+		chunks++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range j.GetDataChunks() {
+		ch, err := s.Get(ctx, storage.ModeGetLookup, boson.NewAddress(c), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaves += len(ch.Data()) - boson.SpanSize
+	}
+	if leaves != len(data) {
+		t.Fatalf("got %d bytes in leaf chunks, want %d", leaves, len(data))
+	}
+	if chunks <= len(j.GetDataChunks())+1 {
+		t.Fatalf("got %d chunks for %d leaves, want several levels", chunks, len(j.GetDataChunks()))
+	}
+}
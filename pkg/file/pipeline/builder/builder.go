@@ -2,13 +2,16 @@ package builder
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/FavorLabs/favorX/pkg/boson"
 	"github.com/FavorLabs/favorX/pkg/encryption"
 	"github.com/FavorLabs/favorX/pkg/file/pipeline"
 	"github.com/FavorLabs/favorX/pkg/file/pipeline/bmt"
+	"github.com/FavorLabs/favorX/pkg/file/pipeline/compress"
 	enc "github.com/FavorLabs/favorX/pkg/file/pipeline/encryption"
 	"github.com/FavorLabs/favorX/pkg/file/pipeline/feeder"
 	"github.com/FavorLabs/favorX/pkg/file/pipeline/hashtrie"
@@ -16,6 +19,45 @@ import (
 	"github.com/FavorLabs/favorX/pkg/storage"
 )
 
+// Chunking is the way the data of a pipeline is cut into leaf chunks.
+type Chunking string
+
+const (
+	// FixedChunking cuts the data at boson.ChunkSize boundaries.
+	FixedChunking Chunking = ""
+	// ContentDefinedChunking cuts the data at boundaries derived from the content,
+	// so that similar data shares most of its chunks.
+	ContentDefinedChunking Chunking = "cdc"
+)
+
+// Content-defined chunk size limits. Leaf chunks never exceed boson.ChunkSize.
+const (
+	cdcMinSize = boson.ChunkSize / 4
+	cdcAvgSize = boson.ChunkSize / 2
+	cdcMaxSize = boson.ChunkSize
+)
+
+var (
+	// ErrUnknownChunking is returned when the chunking mode is not supported.
+	ErrUnknownChunking = errors.New("unknown chunking")
+	// ErrChunkingEncryption is returned when content-defined chunking is requested
+	// for an encrypted pipeline.
+	ErrChunkingEncryption = errors.New("content-defined chunking does not support encryption")
+)
+
+// ParseChunking returns the chunking mode named by s. An empty
+// string and "fixed" both select fixed size chunking.
+func ParseChunking(s string) (Chunking, error) {
+	switch c := Chunking(strings.ToLower(strings.TrimSpace(s))); c {
+	case FixedChunking, "fixed":
+		return FixedChunking, nil
+	case ContentDefinedChunking:
+		return c, nil
+	default:
+		return FixedChunking, fmt.Errorf("%w: %s", ErrUnknownChunking, s)
+	}
+}
+
 // NewPipelineBuilder returns the appropriate pipeline according to the specified parameters
 func NewPipelineBuilder(ctx context.Context, s storage.Putter, mode storage.ModePut, encrypt bool) pipeline.Interface {
 	if encrypt {
@@ -24,6 +66,33 @@ func NewPipelineBuilder(ctx context.Context, s storage.Putter, mode storage.Mode
 	return newPipeline(ctx, s, mode)
 }
 
+// NewChunkingPipelineBuilder returns the pipeline for the specified parameters cutting the
+// data according to the chunking mode. Fixed chunking returns the same pipeline as NewPipelineBuilder.
+func NewChunkingPipelineBuilder(ctx context.Context, s storage.Putter, mode storage.ModePut, encrypt bool, chunking Chunking) (pipeline.Interface, error) {
+	switch chunking {
+	case FixedChunking:
+		return NewPipelineBuilder(ctx, s, mode, encrypt), nil
+	case ContentDefinedChunking:
+		if encrypt {
+			return nil, ErrChunkingEncryption
+		}
+		return newContentDefinedPipeline(ctx, s, mode), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChunking, chunking)
+	}
+}
+
+// NewCompressionPipelineBuilder returns the pipeline for the specified parameters with
+// a compression stage in front of it. The pipeline flow is: Data -> Compression -> Feeder -> ...
+// An empty codec returns the same pipeline as NewChunkingPipelineBuilder.
+func NewCompressionPipelineBuilder(ctx context.Context, s storage.Putter, mode storage.ModePut, encrypt bool, codec compress.Codec, chunking Chunking) (pipeline.Interface, error) {
+	p, err := NewChunkingPipelineBuilder(ctx, s, mode, encrypt, chunking)
+	if err != nil {
+		return nil, err
+	}
+	return compress.NewCompressionWriter(codec, p)
+}
+
 // newPipeline creates a standard pipeline that only hashes content with BMT to create
 // a merkle-tree of hashes that represent the given arbitrary size byte stream. Partial
 // writes are supported. The pipeline flow is: Data -> Feeder -> BMT -> Storage -> HashTrie.
@@ -34,6 +103,17 @@ func newPipeline(ctx context.Context, s storage.Putter, mode storage.ModePut) pi
 	return feeder.NewChunkFeederWriter(boson.ChunkSize, b)
 }
 
+// newContentDefinedPipeline creates a pipeline that cuts the content into variable-size chunks
+// at content-defined boundaries and hashes them with BMT into a merkle-tree whose intermediate
+// chunks record the span of every reference. The pipeline flow is:
+// Data -> Content-defined Feeder -> BMT -> Storage -> Indexed HashTrie.
+func newContentDefinedPipeline(ctx context.Context, s storage.Putter, mode storage.ModePut) pipeline.Interface {
+	tw := hashtrie.NewIndexedHashTrieWriter(boson.ChunkSize, boson.HashSize, newShortPipelineFunc(ctx, s, mode))
+	lsw := store.NewStoreWriter(ctx, s, mode, tw)
+	b := bmt.NewBmtWriter(lsw)
+	return feeder.NewContentDefinedFeederWriter(cdcMinSize, cdcAvgSize, cdcMaxSize, b)
+}
+
 // newShortPipelineFunc returns a constructor function for an ephemeral hashing pipeline
 // needed by the hashTrieWriter.
 func newShortPipelineFunc(ctx context.Context, s storage.Putter, mode storage.ModePut) func() pipeline.ChainWriter {
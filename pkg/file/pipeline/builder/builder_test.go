@@ -5,11 +5,15 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	mrand "math/rand"
 	"strconv"
 	"testing"
 
 	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/file/joiner"
 	"github.com/FavorLabs/favorX/pkg/file/pipeline/builder"
 	test "github.com/FavorLabs/favorX/pkg/file/testing"
 	"github.com/FavorLabs/favorX/pkg/storage"
@@ -156,3 +160,71 @@ func benchmarkPipeline(b *testing.B, count int) {
 		b.Fatal(err)
 	}
 }
+
+// TestContentDefinedChunking tests that content-defined chunked data is joined back
+// unchanged and that inserting a byte near its start leaves most chunks intact.
+func TestContentDefinedChunking(t *testing.T) {
+	ctx := context.Background()
+	m := mock.NewStorer()
+
+	data := make([]byte, 64*boson.ChunkSize)
+	_, _ = mrand.New(mrand.NewSource(1)).Read(data)
+	edited := append([]byte{data[0], 0xff}, data[1:]...)
+
+	upload := func(t *testing.T, data []byte) map[string]struct{} {
+		t.Helper()
+
+		p, err := builder.NewChunkingPipelineBuilder(ctx, m, storage.ModePutUpload, false, builder.ContentDefinedChunking)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr, err := builder.FeedPipeline(ctx, p, bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		j, l, err := joiner.New(ctx, m, storage.ModeGetRequest, addr, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l != int64(len(data)) {
+			t.Fatalf("got span %d, want %d", l, len(data))
+		}
+		got, err := io.ReadAll(io.NewSectionReader(j, 0, l))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatal("joined data differs from the uploaded data")
+		}
+
+		chunks := make(map[string]struct{})
+		err = j.IterateChunkAddresses(func(a boson.Address) error {
+			chunks[a.String()] = struct{}{}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return chunks
+	}
+
+	original := upload(t, data)
+	changed := upload(t, edited)
+
+	var shared int
+	for c := range changed {
+		if _, ok := original[c]; ok {
+			shared++
+		}
+	}
+	// only the chunk holding the insertion and the root are expected to change
+	if shared < len(changed)-3 {
+		t.Fatalf("got %d of %d chunks shared", shared, len(changed))
+	}
+
+	_, err := builder.NewChunkingPipelineBuilder(ctx, m, storage.ModePutUpload, true, builder.ContentDefinedChunking)
+	if !errors.Is(err, builder.ErrChunkingEncryption) {
+		t.Fatalf("got error %v, want %v", err, builder.ErrChunkingEncryption)
+	}
+}
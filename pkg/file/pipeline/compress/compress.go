@@ -0,0 +1,122 @@
+// Package compress provides an optional compression stage that sits in front
+// of the chunk feeder of a pipeline, together with the matching readers used
+// when the content is served.
+package compress
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/FavorLabs/favorX/pkg/file/pipeline"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec names a compression format. The values are the HTTP content-coding
+// tokens so they can be used in Content-Encoding headers verbatim.
+type Codec string
+
+const (
+	None Codec = ""
+	Gzip Codec = "gzip"
+	Zstd Codec = "zstd"
+)
+
+// ErrUnknownCodec is returned for codec names that are not supported.
+var ErrUnknownCodec = errors.New("compress: unknown codec")
+
+// ParseCodec returns the codec for the given name. An empty name means no
+// compression.
+func ParseCodec(name string) (Codec, error) {
+	switch c := Codec(strings.ToLower(strings.TrimSpace(name))); c {
+	case None, Gzip, Zstd:
+		return c, nil
+	default:
+		return None, fmt.Errorf("%w: %s", ErrUnknownCodec, name)
+	}
+}
+
+type compressionWriter struct {
+	next pipeline.Interface
+	enc  io.WriteCloser
+}
+
+// NewCompressionWriter returns a pipeline that compresses the written data with
+// codec before passing it on to next. The compressed stream is flushed when Sum
+// is called.
+func NewCompressionWriter(codec Codec, next pipeline.Interface) (pipeline.Interface, error) {
+	var (
+		enc io.WriteCloser
+		err error
+	)
+	switch codec {
+	case None:
+		return next, nil
+	case Gzip:
+		enc = gzip.NewWriter(next)
+	case Zstd:
+		enc, err = zstd.NewWriter(next)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCodec, codec)
+	}
+	return &compressionWriter{
+		next: next,
+		enc:  enc,
+	}, nil
+}
+
+func (c *compressionWriter) Write(b []byte) (int, error) {
+	return c.enc.Write(b)
+}
+
+func (c *compressionWriter) Sum() ([]byte, error) {
+	if err := c.enc.Close(); err != nil {
+		return nil, err
+	}
+	return c.next.Sum()
+}
+
+// NewReader returns a reader that decompresses r with codec.
+func NewReader(codec Codec, r io.Reader) (io.ReadCloser, error) {
+	switch codec {
+	case None:
+		return io.NopCloser(r), nil
+	case Gzip:
+		return gzip.NewReader(r)
+	case Zstd:
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCodec, codec)
+	}
+}
+
+// Accepted reports whether codec is listed in the value of an Accept-Encoding
+// header with a non-zero quality.
+func Accepted(codec Codec, acceptEncoding string) bool {
+	if codec == None {
+		return true
+	}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		token, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(token), string(codec)) {
+			continue
+		}
+		params = strings.ReplaceAll(params, " ", "")
+		if !strings.HasPrefix(params, "q=") {
+			return true
+		}
+		q, err := strconv.ParseFloat(params[2:], 64)
+		return err == nil && q > 0
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package compress_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/FavorLabs/favorX/pkg/file/joiner"
+	"github.com/FavorLabs/favorX/pkg/file/pipeline/builder"
+	"github.com/FavorLabs/favorX/pkg/file/pipeline/compress"
+	"github.com/FavorLabs/favorX/pkg/storage"
+	"github.com/FavorLabs/favorX/pkg/storage/mock"
+)
+
+func TestParseCodec(t *testing.T) {
+	for name, want := range map[string]compress.Codec{
+		"":      compress.None,
+		"gzip":  compress.Gzip,
+		" ZSTD": compress.Zstd,
+	} {
+		got, err := compress.ParseCodec(name)
+		if err != nil {
+			t.Fatalf("parse %q: %v", name, err)
+		}
+		if got != want {
+			t.Fatalf("parse %q: got %q, want %q", name, got, want)
+		}
+	}
+	if _, err := compress.ParseCodec("br"); !errors.Is(err, compress.ErrUnknownCodec) {
+		t.Fatalf("got error %v, want %v", err, compress.ErrUnknownCodec)
+	}
+}
+
+func TestAccepted(t *testing.T) {
+	for _, tc := range []struct {
+		codec  compress.Codec
+		header string
+		want   bool
+	}{
+		{compress.None, "", true},
+		{compress.Gzip, "", false},
+		{compress.Gzip, "gzip, deflate", true},
+		{compress.Zstd, "gzip, deflate", false},
+		{compress.Zstd, "br;q=1.0, zstd;q=0.5", true},
+		{compress.Gzip, "gzip;q=0", false},
+	} {
+		if got := compress.Accepted(tc.codec, tc.header); got != tc.want {
+			t.Errorf("accepted %q in %q: got %v, want %v", tc.codec, tc.header, got, tc.want)
+		}
+	}
+}
+
+// TestCompressionPipeline stores compressible data through the compression
+// stage and checks that joining and decompressing returns the original data.
+func TestCompressionPipeline(t *testing.T) {
+	data := bytes.Repeat([]byte("favorX compression stage "), 4096)
+
+	for _, codec := range []compress.Codec{compress.Gzip, compress.Zstd} {
+		t.Run(string(codec), func(t *testing.T) {
+			ctx := context.Background()
+			m := mock.NewStorer()
+			p, err := builder.NewCompressionPipelineBuilder(ctx, m, storage.ModePutUpload, false, codec, builder.FixedChunking)
+			if err != nil {
+				t.Fatal(err)
+			}
+			addr, err := builder.FeedPipeline(ctx, p, bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			j, l, err := joiner.New(ctx, m, storage.ModeGetRequest, addr, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if l >= int64(len(data)) {
+				t.Fatalf("stored %d bytes, want less than %d", l, len(data))
+			}
+			r, err := compress.NewReader(codec, j)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatal("decompressed data mismatch")
+			}
+		})
+	}
+}
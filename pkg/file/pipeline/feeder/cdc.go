@@ -0,0 +1,147 @@
+package feeder
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/bits"
+
+	"github.com/FavorLabs/favorX/pkg/file/pipeline"
+)
+
+// gear is the table of random values the rolling hash is built from. It is derived
+// from a fixed seed since changing it changes the boundaries of every chunk.
+var gear [256]uint64
+
+func init() {
+	for i := range gear {
+		h := sha256.Sum256([]byte{byte(i)})
+		gear[i] = binary.LittleEndian.Uint64(h[:8])
+	}
+}
+
+type cdcFeeder struct {
+	minSize   int
+	avgSize   int
+	maxSize   int
+	maskS     uint64 // stricter mask used before the average size is reached
+	maskL     uint64 // looser mask used after the average size is reached
+	next      pipeline.ChainWriter
+	buffer    []byte
+	bufferIdx int
+	wrote     int64
+}
+
+// NewContentDefinedFeederWriter creates a chunk feeder that cuts the data at content-defined
+// boundaries using FastCDC with normalized chunking. Chunks are at least minSize and at most
+// maxSize long, and avgSize long on average. Since the boundaries depend on the local content
+// only, an insertion or a deletion changes the chunks around it and leaves the others intact.
+// The chunks are of variable size, so the feeder must be followed by an indexed hash trie.
+func NewContentDefinedFeederWriter(minSize, avgSize, maxSize int, next pipeline.ChainWriter) pipeline.Interface {
+	b := bits.Len(uint(avgSize)) - 1
+	return &cdcFeeder{
+		minSize: minSize,
+		avgSize: avgSize,
+		maxSize: maxSize,
+		maskS:   topBits(b + 2),
+		maskL:   topBits(b - 2),
+		next:    next,
+		buffer:  make([]byte, maxSize),
+	}
+}
+
+// topBits returns a mask of the n most significant bits, the gear hash
+// is the most random there.
+func topBits(n int) uint64 {
+	if n <= 0 {
+		return 0
+	}
+	return ^uint64(0) << (64 - n)
+}
+
+// Write buffers the data and writes a chunk to the next writer each time
+// the buffer holds enough data to find the next boundary.
+func (f *cdcFeeder) Write(b []byte) (int, error) {
+	w := 0
+	for len(b) > 0 {
+		n := copy(f.buffer[f.bufferIdx:], b)
+		f.bufferIdx += n
+		b = b[n:]
+		w += n
+		if f.bufferIdx == f.maxSize {
+			if err := f.writeChunk(f.cut(f.buffer)); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return w, nil
+}
+
+// cut returns the length of the chunk at the start of the data.
+func (f *cdcFeeder) cut(data []byte) int {
+	n := len(data)
+	if n <= f.minSize {
+		return n
+	}
+	if n > f.maxSize {
+		n = f.maxSize
+	}
+	normal := f.avgSize
+	if n < normal {
+		normal = n
+	}
+
+	var fp uint64
+	i := f.minSize
+	for ; i < normal; i++ {
+		fp = (fp << 1) + gear[data[i]]
+		if fp&f.maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		fp = (fp << 1) + gear[data[i]]
+		if fp&f.maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// writeChunk writes the first l bytes of the buffer to the next writer
+// and shifts the rest of the buffer to its start.
+func (f *cdcFeeder) writeChunk(l int) error {
+	d := make([]byte, span+l)
+	copy(d[span:], f.buffer[:l])
+	binary.LittleEndian.PutUint64(d[:span], uint64(l))
+	args := &pipeline.PipeWriteArgs{Data: d, Span: d[:span]}
+	if err := f.next.ChainWrite(args); err != nil {
+		return err
+	}
+	f.bufferIdx = copy(f.buffer, f.buffer[l:f.bufferIdx])
+	f.wrote += int64(l)
+	return nil
+}
+
+// Sum cuts and flushes the data left in the buffer to subsequent writers
+// and returns the cryptographic root-hash respresenting the data written
+// to the feeder.
+func (f *cdcFeeder) Sum() ([]byte, error) {
+	for f.bufferIdx > 0 {
+		if err := f.writeChunk(f.cut(f.buffer[:f.bufferIdx])); err != nil {
+			return nil, err
+		}
+	}
+
+	if f.wrote == 0 {
+		// this is an empty file, we should write the span of
+		// an empty file (0).
+		d := make([]byte, span)
+		args := &pipeline.PipeWriteArgs{Data: d, Span: d}
+		err := f.next.ChainWrite(args)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return f.next.Sum()
+}
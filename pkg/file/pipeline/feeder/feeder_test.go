@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"math/rand"
 	"testing"
 
 	"github.com/FavorLabs/favorX/pkg/file/pipeline"
@@ -233,3 +234,79 @@ func (w *countingResultWriter) ChainWrite(p *pipeline.PipeWriteArgs) error {
 func (w *countingResultWriter) Sum() ([]byte, error) {
 	return nil, errors.New("not implemented")
 }
+
+// TestContentDefinedFeeder tests that the content-defined feeder cuts chunks
+// within the size limits and at the same boundaries regardless of how the
+// data is partitioned into writes.
+func TestContentDefinedFeeder(t *testing.T) {
+	var (
+		minSize = 64
+		avgSize = 256
+		maxSize = 1024
+		data    = make([]byte, 64*1024)
+	)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	cut := func(t *testing.T, writeSize int) [][]byte {
+		t.Helper()
+
+		w := &collectingResultWriter{}
+		tf := feeder.NewContentDefinedFeederWriter(minSize, avgSize, maxSize, w)
+		for i := 0; i < len(data); i += writeSize {
+			end := i + writeSize
+			if end > len(data) {
+				end = len(data)
+			}
+			if _, err := tf.Write(data[i:end]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := tf.Sum(); err != nil {
+			t.Fatal(err)
+		}
+		return w.chunks
+	}
+
+	want := cut(t, len(data))
+	var joined []byte
+	for i, c := range want {
+		if len(c) > maxSize {
+			t.Fatalf("chunk %d: size %d exceeds %d", i, len(c), maxSize)
+		}
+		if len(c) < minSize && i != len(want)-1 {
+			t.Fatalf("chunk %d: size %d below %d", i, len(c), minSize)
+		}
+		joined = append(joined, c...)
+	}
+	if !bytes.Equal(joined, data) {
+		t.Fatal("joined chunks differ from the written data")
+	}
+
+	for _, writeSize := range []int{1, 7, 100, 4096} {
+		got := cut(t, writeSize)
+		if len(got) != len(want) {
+			t.Fatalf("write size %d: got %d chunks, want %d", writeSize, len(got), len(want))
+		}
+		for i := range want {
+			if !bytes.Equal(got[i], want[i]) {
+				t.Fatalf("write size %d: chunk %d differs", writeSize, i)
+			}
+		}
+	}
+}
+
+// collectingResultWriter collects the data of the chunks written to it.
+type collectingResultWriter struct {
+	chunks [][]byte
+}
+
+func (w *collectingResultWriter) ChainWrite(p *pipeline.PipeWriteArgs) error {
+	if l := binary.LittleEndian.Uint64(p.Span); l > 0 {
+		w.chunks = append(w.chunks, append([]byte(nil), p.Data[len(p.Span):]...))
+	}
+	return nil
+}
+
+func (w *collectingResultWriter) Sum() ([]byte, error) {
+	return nil, nil
+}
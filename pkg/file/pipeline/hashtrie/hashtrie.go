@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/file"
 	"github.com/FavorLabs/favorX/pkg/file/pipeline"
 )
 
@@ -23,6 +24,7 @@ type hashTrieWriter struct {
 	cursors    []int  // level cursors, key is level. level 0 is data level and is not represented in this package. writes always start at level 1. higher levels will always have LOWER cursor values.
 	buffer     []byte // keeps all level data
 	full       bool   // indicates whether the trie is full. currently we support (128^7)*4096 = 2305843009213693952 bytes
+	indexed    bool   // intermediate chunks keep the span of every reference, see file.IndexedSpanFlag
 	pipelineFn pipeline.PipelineFunc
 }
 
@@ -38,6 +40,16 @@ func NewHashTrieWriter(chunkSize, branching, refLen int, pipelineFn pipeline.Pip
 	}
 }
 
+// NewIndexedHashTrieWriter returns a hash trie writer for variable-size leaf chunks.
+// Its intermediate chunks hold span|reference pairs, which reduces the branching
+// factor to chunkSize/(refLen+boson.SpanSize), and their spans are flagged with
+// file.IndexedSpanFlag so that joiners can locate offsets without the leaves.
+func NewIndexedHashTrieWriter(chunkSize, refLen int, pipelineFn pipeline.PipelineFunc) pipeline.ChainWriter {
+	h := NewHashTrieWriter(chunkSize, chunkSize/(refLen+boson.SpanSize), refLen, pipelineFn).(*hashTrieWriter)
+	h.indexed = true
+	return h
+}
+
 // accepts writes of hashes from the previous writer in the chain, by definition these writes
 // are on level 1
 func (h *hashTrieWriter) ChainWrite(p *pipeline.PipeWriteArgs) error {
@@ -85,10 +97,17 @@ func (h *hashTrieWriter) wrapFullLevel(level int) error {
 	for i := 0; i < len(data); i += h.refSize + 8 {
 		// sum up the spans of the level, then we need to bmt them and store it as a chunk
 		// then write the chunk address to the next level up
-		sp += binary.LittleEndian.Uint64(data[i : i+8])
+		sp += file.SpanLength(binary.LittleEndian.Uint64(data[i : i+8]))
+		if h.indexed {
+			hashes = append(hashes, data[i:i+h.refSize+8]...)
+			continue
+		}
 		hash := data[i+8 : i+h.refSize+8]
 		hashes = append(hashes, hash...)
 	}
+	if h.indexed {
+		sp |= file.IndexedSpanFlag
+	}
 	spb := make([]byte, 8)
 	binary.LittleEndian.PutUint64(spb, sp)
 	hashes = append(spb, hashes...)
@@ -0,0 +1,274 @@
+// Package proof proves the data at an offset of a file is part of the trie of
+// the file, with the BMT inclusion proofs of the references on the path from
+// the root chunk to the leaf, and of the segment of the leaf holding the data.
+// Light clients check the data they read from untrusted nodes with the proofs
+// against the root cid, without fetching the whole file.
+package proof
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/FavorLabs/favorX/pkg/bmt"
+	"github.com/FavorLabs/favorX/pkg/bmtpool"
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/cac"
+	"github.com/FavorLabs/favorX/pkg/file"
+	"github.com/FavorLabs/favorX/pkg/storage"
+)
+
+// branching is the number of references an intermediate chunk of a trie of
+// plain references holds.
+const branching = boson.ChunkSize / boson.HashSize
+
+var (
+	ErrInvalidProof = errors.New("invalid file proof")
+	ErrOffset       = errors.New("offset beyond the file")
+	ErrEncrypted    = errors.New("encrypted files are not supported")
+	ErrDataMismatch = errors.New("data does not match the proof")
+)
+
+// Step is a chunk on the path from the root to the data at the offset, given
+// whole or as the inclusion proof of the reference on the path.
+type Step struct {
+	Chunk   []byte     `json:"chunk,omitempty"`   // span and data of the chunk given whole
+	Segment *bmt.Proof `json:"segment,omitempty"` // of the reference or the data on the path otherwise
+}
+
+// Proof proves the chunk or the segment holding the data at the offset of a
+// file is part of its trie. The intermediate chunks of content-defined chunked
+// tries are given whole, the intermediate chunks of plain tries as the proofs
+// of their references, and the leaf chunk whole or as the proof of the segment
+// holding the data.
+type Proof struct {
+	Offset int64  `json:"offset"`
+	Steps  []Step `json:"steps"`
+}
+
+// Prove returns the proof of the leaf chunk holding the data at the offset of
+// the file of the root, given whole.
+func Prove(ctx context.Context, getter storage.Getter, mode storage.ModeGet, root boson.Address, offset int64) (Proof, error) {
+	return prove(ctx, getter, mode, root, offset, false)
+}
+
+// ProveSegment returns the proof of the segment of the leaf chunk holding the
+// data at the offset of the file of the root.
+func ProveSegment(ctx context.Context, getter storage.Getter, mode storage.ModeGet, root boson.Address, offset int64) (Proof, error) {
+	return prove(ctx, getter, mode, root, offset, true)
+}
+
+func prove(ctx context.Context, getter storage.Getter, mode storage.ModeGet, root boson.Address, offset int64, segment bool) (Proof, error) {
+	if len(root.Bytes()) != boson.HashSize {
+		return Proof{}, ErrEncrypted
+	}
+	p := Proof{Offset: offset}
+	addr, base := root, int64(0)
+	for {
+		ch, err := getter.Get(ctx, mode, addr, 0)
+		if err != nil {
+			return Proof{}, fmt.Errorf("get chunk %s: %w", addr, err)
+		}
+		data := ch.Data()
+		if len(data) < boson.SpanSize {
+			return Proof{}, fmt.Errorf("chunk %s: %w", addr, ErrInvalidProof)
+		}
+		rawSpan := binary.LittleEndian.Uint64(data[:boson.SpanSize])
+		length, payload := int64(file.SpanLength(rawSpan)), data[boson.SpanSize:]
+		off := offset - base
+		if off < 0 || off >= length {
+			return Proof{}, ErrOffset
+		}
+
+		switch {
+		case file.IsIndexedSpan(rawSpan):
+			p.Steps = append(p.Steps, Step{Chunk: data})
+			ref, cur, _, ok := indexedReference(payload, off)
+			if !ok {
+				return Proof{}, fmt.Errorf("chunk %s: %w", addr, ErrInvalidProof)
+			}
+			addr, base = ref, base+cur
+		case length <= int64(len(payload)):
+			if !segment {
+				p.Steps = append(p.Steps, Step{Chunk: data})
+				return p, nil
+			}
+			proof, err := bmtpool.Prove(data[:boson.SpanSize], payload, int(off/boson.SectionSize))
+			if err != nil {
+				return Proof{}, err
+			}
+			p.Steps = append(p.Steps, Step{Segment: &proof})
+			return p, nil
+		default:
+			size := childSize(length)
+			i := int(off / size)
+			if (i+1)*boson.HashSize > len(payload) {
+				return Proof{}, fmt.Errorf("chunk %s: %w", addr, ErrInvalidProof)
+			}
+			proof, err := bmtpool.Prove(data[:boson.SpanSize], payload, i)
+			if err != nil {
+				return Proof{}, err
+			}
+			p.Steps = append(p.Steps, Step{Segment: &proof})
+			addr, base = boson.NewAddress(proof.Segment), base+int64(i)*size
+		}
+	}
+}
+
+// Verify checks the steps lead from the root to the leaf chunk holding the
+// data at the offset.
+func (p Proof) Verify(root boson.Address) error {
+	_, _, err := p.verify(root)
+	return err
+}
+
+// VerifyData checks the proof and that the data read from the offset is the
+// data of the file the proof covers, and returns how many bytes of it are
+// covered: up to the end of the segment or of the leaf chunk proven. Light
+// clients check the data of a range read this way, asking for the proofs of
+// the offsets the counts returned lead to.
+func (p Proof) VerifyData(root boson.Address, data []byte) (int, error) {
+	proven, start, err := p.verify(root)
+	if err != nil {
+		return 0, err
+	}
+	proven = proven[p.Offset-start:]
+	n := len(data)
+	if n > len(proven) {
+		n = len(proven)
+	}
+	if !bytes.Equal(data[:n], proven[:n]) {
+		return 0, ErrDataMismatch
+	}
+	return n, nil
+}
+
+// verify checks the proof and returns the data of the file proven, the leaf
+// chunk or the segment, with the offset of the file it starts at.
+func (p Proof) verify(root boson.Address) ([]byte, int64, error) {
+	var (
+		addr     = root.Bytes()
+		base     int64
+		wantSpan uint64
+		known    bool // whether the span of the chunk is told by its parent
+	)
+	for n, step := range p.Steps {
+		var (
+			hash    []byte
+			rawSpan uint64
+			err     error
+		)
+		switch {
+		case step.Chunk != nil:
+			ch, err := cac.NewWithDataSpan(step.Chunk)
+			if err != nil {
+				return nil, 0, ErrInvalidProof
+			}
+			hash, rawSpan = ch.Address().Bytes(), binary.LittleEndian.Uint64(step.Chunk[:boson.SpanSize])
+		case step.Segment != nil:
+			if hash, err = step.Segment.Hash(boson.NewHasher, bmtpool.Depth()); err != nil {
+				return nil, 0, ErrInvalidProof
+			}
+			rawSpan = binary.LittleEndian.Uint64(step.Segment.Span)
+		default:
+			return nil, 0, ErrInvalidProof
+		}
+		if !bytes.Equal(hash, addr) || known && rawSpan != wantSpan {
+			return nil, 0, ErrInvalidProof
+		}
+		length := int64(file.SpanLength(rawSpan))
+		off := p.Offset - base
+		if off < 0 || off >= length {
+			return nil, 0, ErrInvalidProof
+		}
+		last := n == len(p.Steps)-1
+
+		switch {
+		case file.IsIndexedSpan(rawSpan):
+			if step.Chunk == nil || last {
+				return nil, 0, ErrInvalidProof
+			}
+			ref, cur, span, ok := indexedReference(step.Chunk[boson.SpanSize:], off)
+			if !ok {
+				return nil, 0, ErrInvalidProof
+			}
+			addr, base, wantSpan, known = ref.Bytes(), base+cur, span, true
+		case step.Chunk != nil && length <= int64(len(step.Chunk)-boson.SpanSize):
+			if !last {
+				return nil, 0, ErrInvalidProof
+			}
+			return step.Chunk[boson.SpanSize : boson.SpanSize+length], base, nil
+		case step.Segment != nil && length <= boson.ChunkSize:
+			// the leaf chunk is given as the proof of the segment
+			if !last || int64(step.Segment.Index) != off/boson.SectionSize {
+				return nil, 0, ErrInvalidProof
+			}
+			start := int64(step.Segment.Index) * boson.SectionSize
+			end := start + boson.SectionSize
+			if end > length {
+				end = length
+			}
+			return step.Segment.Segment[:end-start], base + start, nil
+		default:
+			if step.Chunk != nil || length <= boson.ChunkSize || last {
+				return nil, 0, ErrInvalidProof
+			}
+			size := childSize(length)
+			i := off / size
+			if int64(step.Segment.Index) != i {
+				return nil, 0, ErrInvalidProof
+			}
+			child := length - i*size
+			if child > size {
+				child = size
+			}
+			addr, base, wantSpan, known = step.Segment.Segment, base+i*size, uint64(child), true
+		}
+	}
+	return nil, 0, ErrInvalidProof
+}
+
+// Length returns the length of the file the root chunk tells, to be trusted
+// once the proof is verified.
+func (p Proof) Length() int64 {
+	if len(p.Steps) == 0 {
+		return 0
+	}
+	switch step := p.Steps[0]; {
+	case len(step.Chunk) >= boson.SpanSize:
+		return int64(file.SpanLength(binary.LittleEndian.Uint64(step.Chunk[:boson.SpanSize])))
+	case step.Segment != nil && len(step.Segment.Span) == boson.SpanSize:
+		return int64(file.SpanLength(binary.LittleEndian.Uint64(step.Segment.Span)))
+	}
+	return 0
+}
+
+// childSize returns the size of the subtries the references of an
+// intermediate chunk of a plain trie of the length point to, all of them but
+// the last one full.
+func childSize(length int64) int64 {
+	size := int64(boson.ChunkSize)
+	for length > size*branching {
+		size *= branching
+	}
+	return size
+}
+
+// indexedReference returns the span|reference entry of an intermediate chunk
+// of a content-defined chunked trie covering the offset, with the offset the
+// subtrie it points to starts at.
+func indexedReference(data []byte, off int64) (boson.Address, int64, uint64, bool) {
+	const entryLength = boson.SpanSize + boson.HashSize
+	var cur int64
+	for cursor := 0; cursor+entryLength <= len(data); cursor += entryLength {
+		span := binary.LittleEndian.Uint64(data[cursor : cursor+boson.SpanSize])
+		length := int64(file.SpanLength(span))
+		if off < cur+length {
+			return boson.NewAddress(data[cursor+boson.SpanSize : cursor+entryLength]), cur, span, true
+		}
+		cur += length
+	}
+	return boson.ZeroAddress, 0, 0, false
+}
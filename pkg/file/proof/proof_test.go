@@ -0,0 +1,194 @@
+package proof_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/FavorLabs/favorX/pkg/bmt"
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/boson/test"
+	"github.com/FavorLabs/favorX/pkg/file/pipeline/builder"
+	"github.com/FavorLabs/favorX/pkg/file/proof"
+	"github.com/FavorLabs/favorX/pkg/storage"
+	"github.com/FavorLabs/favorX/pkg/storage/mock"
+)
+
+func TestProof(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		size     int
+		chunking builder.Chunking
+	}{
+		{"leaf", 100, builder.FixedChunking},
+		{"intermediate", 3*boson.ChunkSize + 5, builder.FixedChunking},
+		{"indexed", 3 * boson.ChunkSize, builder.ContentDefinedChunking},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := mock.NewStorer()
+			data := make([]byte, tc.size)
+			rand.New(rand.NewSource(1)).Read(data)
+			p, err := builder.NewChunkingPipelineBuilder(ctx, store, storage.ModePutUpload, false, tc.chunking)
+			if err != nil {
+				t.Fatal(err)
+			}
+			root, err := builder.FeedPipeline(ctx, p, bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, offset := range []int64{0, int64(tc.size) / 2, int64(tc.size) - 1} {
+				pr, err := proof.Prove(ctx, store, storage.ModeGetLookup, root, offset)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := pr.Verify(root); err != nil {
+					t.Fatalf("offset %d: %v", offset, err)
+				}
+				if err := pr.Verify(test.RandomAddress()); !errors.Is(err, proof.ErrInvalidProof) {
+					t.Fatalf("offset %d: got error %v verifying against another root", offset, err)
+				}
+
+				leaf := pr.Steps[len(pr.Steps)-1].Chunk
+				leaf[len(leaf)-1]++
+				if err := pr.Verify(root); !errors.Is(err, proof.ErrInvalidProof) {
+					t.Fatalf("offset %d: got error %v verifying altered data", offset, err)
+				}
+				leaf[len(leaf)-1]--
+
+				pr.Steps = pr.Steps[:len(pr.Steps)-1]
+				if err := pr.Verify(root); !errors.Is(err, proof.ErrInvalidProof) {
+					t.Fatalf("offset %d: got error %v verifying a proof without the leaf", offset, err)
+				}
+			}
+
+			// the proof of a chunk does not prove the data at the offsets of
+			// the other chunks
+			if tc.size > boson.ChunkSize {
+				pr, err := proof.Prove(ctx, store, storage.ModeGetLookup, root, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				pr.Offset = int64(tc.size) - 1
+				if err := pr.Verify(root); !errors.Is(err, proof.ErrInvalidProof) {
+					t.Fatalf("got error %v verifying the proof of another offset", err)
+				}
+			}
+
+			if _, err := proof.Prove(ctx, store, storage.ModeGetLookup, root, int64(tc.size)); !errors.Is(err, proof.ErrOffset) {
+				t.Fatalf("got error %v proving beyond the file, want %v", err, proof.ErrOffset)
+			}
+		})
+	}
+}
+
+// tests the data of a range is checked the way light clients do, proof by
+// proof, the proofs sent as json
+func TestVerifyData(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		chunking builder.Chunking
+	}{
+		{"fixed", builder.FixedChunking},
+		{"indexed", builder.ContentDefinedChunking},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := mock.NewStorer()
+			data := make([]byte, 2*boson.ChunkSize+5)
+			rand.New(rand.NewSource(2)).Read(data)
+			p, err := builder.NewChunkingPipelineBuilder(ctx, store, storage.ModePutUpload, false, tc.chunking)
+			if err != nil {
+				t.Fatal(err)
+			}
+			root, err := builder.FeedPipeline(ctx, p, bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, prove := range []func(context.Context, storage.Getter, storage.ModeGet, boson.Address, int64) (proof.Proof, error){
+				proof.Prove,
+				proof.ProveSegment,
+			} {
+				// a range over the end of a chunk and the end of the file
+				for _, r := range [][2]int{{boson.ChunkSize - 40, boson.ChunkSize + 70}, {len(data) - 50, len(data)}} {
+					for off := r[0]; off < r[1]; {
+						pr, err := prove(ctx, store, storage.ModeGetLookup, root, int64(off))
+						if err != nil {
+							t.Fatal(err)
+						}
+						b, err := json.Marshal(pr)
+						if err != nil {
+							t.Fatal(err)
+						}
+						var got proof.Proof
+						if err := json.Unmarshal(b, &got); err != nil {
+							t.Fatal(err)
+						}
+						if got.Length() != int64(len(data)) {
+							t.Fatalf("got length %d, want %d", got.Length(), len(data))
+						}
+
+						altered := append([]byte(nil), data[off:r[1]]...)
+						altered[0]++
+						if _, err := got.VerifyData(root, altered); !errors.Is(err, proof.ErrDataMismatch) {
+							t.Fatalf("offset %d: got error %v verifying altered data, want %v", off, err, proof.ErrDataMismatch)
+						}
+						n, err := got.VerifyData(root, data[off:r[1]])
+						if err != nil {
+							t.Fatalf("offset %d: %v", off, err)
+						}
+						if n <= 0 {
+							t.Fatalf("offset %d: no data verified", off)
+						}
+						off += n
+					}
+				}
+			}
+		})
+	}
+}
+
+// tests a proof passing an inner node of the BMT of the leaf off as a segment
+// of the data is rejected
+func TestVerifyInnerNode(t *testing.T) {
+	ctx := context.Background()
+	store := mock.NewStorer()
+	data := make([]byte, boson.ChunkSize)
+	rand.New(rand.NewSource(3)).Read(data)
+	p, err := builder.NewChunkingPipelineBuilder(ctx, store, storage.ModePutUpload, false, builder.FixedChunking)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := builder.FeedPipeline(ctx, p, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err := proof.ProveSegment(ctx, store, storage.ModeGetLookup, root, 2*boson.SectionSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	segment := pr.Steps[len(pr.Steps)-1].Segment
+
+	// the parent of the segments 2 and 3 is the segment 1 of a tree a level
+	// lower, told to be the data at the offset of the segment 1
+	h := boson.NewHasher()
+	h.Write(data[2*boson.SectionSize : 4*boson.SectionSize])
+	inner := h.Sum(nil)
+	forged := proof.Proof{
+		Offset: boson.SectionSize,
+		Steps: []proof.Step{{Segment: &bmt.Proof{
+			Index:   1,
+			Segment: inner,
+			Sisters: segment.Sisters[1:],
+			Span:    segment.Span,
+		}}},
+	}
+	if n, err := forged.VerifyData(root, inner); !errors.Is(err, proof.ErrInvalidProof) {
+		t.Fatalf("got %d bytes verified and error %v, want %v", n, err, proof.ErrInvalidProof)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"github.com/FavorLabs/favorX/pkg/address"
 	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/file"
+	"github.com/FavorLabs/favorX/pkg/file/joiner"
 	"github.com/FavorLabs/favorX/pkg/localstore"
 	"github.com/FavorLabs/favorX/pkg/localstore/chunkstore"
 	"github.com/FavorLabs/favorX/pkg/localstore/filestore"
@@ -38,6 +40,9 @@ type Interface interface {
 	GetChunkInfoSource(rootCid boson.Address) ChunkInfoSource
 	AddFileMirror(next, rootCid boson.Address, ope filestore.Operation) error
 	FileCounter(rootCid boson.Address) error
+	GetFileVersions(rootCid boson.Address) ([]FileVersion, error)
+	DiffFileVersions(ctx context.Context, from, to boson.Address) ([]ManifestDiff, error)
+	RollbackFile(rootCid, target boson.Address) ([]boson.Address, error)
 }
 
 type FileInfo struct {
@@ -63,11 +68,34 @@ func (f *FileInfo) GetFileSize(rootCid boson.Address) (int64, error) {
 		return 0, err
 	}
 	var chunkData = chunk.Data()
-	span := int64(binary.LittleEndian.Uint64(chunkData[:boson.SpanSize]))
-	size := chunkLen(span)
+	span := binary.LittleEndian.Uint64(chunkData[:boson.SpanSize])
+	if file.IsIndexedSpan(span) {
+		return f.indexedChunkLen(ctx, rootCid)
+	}
+	size := chunkLen(int64(span))
 	return size, nil
 }
 
+// indexedChunkLen counts the chunks of a content-defined chunked file. Its leaves
+// have variable sizes, so the count is taken from the intermediate chunks, which
+// must be available locally.
+func (f *FileInfo) indexedChunkLen(ctx context.Context, rootCid boson.Address) (int64, error) {
+	j, _, err := joiner.New(ctx, f.localStore, storage.ModeGetRequest, rootCid, 0)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	err = j.IterateChunkAddresses(func(boson.Address) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	// like chunkLen, the root chunk is not counted
+	return count - 1, nil
+}
+
 func (f *FileInfo) GetFileList(page filestore.Page, filter []filestore.Filter, sort filestore.Sort) ([]FileView, int) {
 	list, total := f.localStore.GetListFile(page, filter, sort)
 	fileList := make([]FileView, 0, len(list))
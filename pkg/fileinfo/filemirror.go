@@ -1,10 +1,40 @@
 package fileinfo
 
 import (
+	"context"
+	"sort"
+
 	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/file/loadsave"
 	"github.com/FavorLabs/favorX/pkg/localstore/filestore"
+	"github.com/FavorLabs/favorX/pkg/manifest"
+	"github.com/FavorLabs/favorX/pkg/storage"
 )
 
+// uploadOperation names the version that was not produced by a manifest
+// interaction but by the original upload.
+const uploadOperation = "UPLOAD"
+
+const (
+	DiffAdded    = "added"
+	DiffRemoved  = "removed"
+	DiffModified = "modified"
+)
+
+type FileVersion struct {
+	RootCid   boson.Address `json:"rootCid"`
+	Operation string        `json:"operation"`
+	Timestamp int64         `json:"timestamp"`
+	Size      int           `json:"size"`
+}
+
+type ManifestDiff struct {
+	Path   string        `json:"path"`
+	Change string        `json:"change"`
+	From   boson.Address `json:"from"`
+	To     boson.Address `json:"to"`
+}
+
 func (f *FileInfo) AddFileMirror(next, rootCid boson.Address, ope filestore.Operation) error {
 	err := f.localStore.PutMirrorFile(next, rootCid, ope)
 	if err != nil {
@@ -39,3 +69,96 @@ func (f *FileInfo) Rollback(reference, rollback boson.Address) error {
 
 	return nil
 }
+
+// GetFileVersions returns the version chain of reference, newest first.
+func (f *FileInfo) GetFileVersions(reference boson.Address) ([]FileVersion, error) {
+	file, ok := f.localStore.GetFile(reference)
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	mirrors, err := f.localStore.GetMirrors(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]FileVersion, 0, len(mirrors)+1)
+	current := FileVersion{
+		RootCid:   reference,
+		Operation: uploadOperation,
+		Size:      file.Size,
+	}
+	for _, m := range mirrors {
+		current.Operation = m.Operation.String()
+		current.Timestamp = m.Timestamp
+		versions = append(versions, current)
+		current = FileVersion{
+			RootCid:   m.RootCid,
+			Operation: uploadOperation,
+			Size:      m.Size,
+		}
+	}
+	versions = append(versions, current)
+	return versions, nil
+}
+
+// DiffFileVersions compares the file entries of two manifests by path.
+func (f *FileInfo) DiffFileVersions(ctx context.Context, from, to boson.Address) ([]ManifestDiff, error) {
+	fromEntries, err := f.manifestEntries(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	toEntries, err := f.manifestEntries(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]ManifestDiff, 0)
+	for path, ref := range fromEntries {
+		next, ok := toEntries[path]
+		switch {
+		case !ok:
+			diffs = append(diffs, ManifestDiff{Path: path, Change: DiffRemoved, From: ref, To: boson.ZeroAddress})
+		case !next.Equal(ref):
+			diffs = append(diffs, ManifestDiff{Path: path, Change: DiffModified, From: ref, To: next})
+		}
+	}
+	for path, ref := range toEntries {
+		if _, ok := fromEntries[path]; !ok {
+			diffs = append(diffs, ManifestDiff{Path: path, Change: DiffAdded, From: boson.ZeroAddress, To: ref})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+	return diffs, nil
+}
+
+// RollbackFile makes target the current version of reference. The dropped
+// versions are returned newest first.
+func (f *FileInfo) RollbackFile(reference, target boson.Address) ([]boson.Address, error) {
+	return f.localStore.RollbackFile(reference, target)
+}
+
+func (f *FileInfo) manifestEntries(ctx context.Context, reference boson.Address) (map[string]boson.Address, error) {
+	ls := loadsave.NewReadonly(f.localStore, storage.ModeGetRequest)
+	m, err := manifest.NewDefaultManifestReference(reference, ls)
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string]boson.Address)
+	err = m.IterateDirectories(ctx, []byte(""), 0,
+		func(nodeType int, path, prefix, hash []byte, metadata map[string]string) error {
+			if nodeType != int(manifest.File) {
+				return nil
+			}
+			p := make([]byte, 0, len(path)+len(prefix))
+			p = append(p, path...)
+			p = append(p, prefix...)
+			entries[string(p)] = boson.NewAddress(hash)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
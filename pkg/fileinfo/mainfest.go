@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/file"
 	"github.com/FavorLabs/favorX/pkg/file/loadsave"
 	"github.com/FavorLabs/favorX/pkg/manifest"
 	"github.com/FavorLabs/favorX/pkg/storage"
@@ -117,7 +118,7 @@ func (f *FileInfo) ManifestView(ctx context.Context, nameOrHex string, pathVar s
 				}
 				var size uint64 = 0
 				if refChunk != nil {
-					size = binary.LittleEndian.Uint64(refChunk.Data()[:boson.SpanSize])
+					size = file.SpanLength(binary.LittleEndian.Uint64(refChunk.Data()[:boson.SpanSize]))
 				}
 				node.Nodes[string(prefix)] = &ManifestNode{
 					Type:      manifest.File.String(),
@@ -167,7 +168,7 @@ func (f *FileInfo) ManifestView(ctx context.Context, nameOrHex string, pathVar s
 			filename: {
 				Type:      manifest.File.String(),
 				Hash:      e.Reference().String(),
-				Size:      binary.LittleEndian.Uint64(refChunk.Data()[:boson.SpanSize]),
+				Size:      file.SpanLength(binary.LittleEndian.Uint64(refChunk.Data()[:boson.SpanSize])),
 				Extension: extension,
 				MimeType:  e.Metadata()[manifest.EntryMetadataContentTypeKey],
 			},
@@ -0,0 +1,576 @@
+package localstore
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/shed"
+	"github.com/FavorLabs/favorX/pkg/shed/driver"
+	"github.com/FavorLabs/favorX/pkg/storage"
+	"github.com/FavorLabs/favorX/pkg/traversal"
+)
+
+const (
+	// current backup format version
+	currentBackupVersion = 1
+
+	// names of the entries in the backup tar archive
+	backupHeaderFilename  = "backup.json"
+	backupSummaryFilename = "summary.json"
+	backupChunkDir        = "chunks/"
+	backupStateDir        = "state/"
+
+	// number of chunk records written to the database in a single batch
+	restoreBatchSize = 1000
+)
+
+// flags of a chunk record telling which indexes it holds an entry of
+const (
+	recordData byte = 1 << iota
+	recordAccess
+	recordGC
+	recordPin
+	recordTransfer
+)
+
+// size of the fixed part of a chunk record: flags, BinID, StoreTimestamp,
+// Type, Counter, AccessTimestamp, GCounter, PinCounter and Cids length
+const recordHeaderSize = 1 + 7*8 + 4
+
+var (
+	// ErrInvalidBackup is returned when a backup cannot be read.
+	ErrInvalidBackup = errors.New("invalid backup")
+	// ErrOverlayMismatch is returned when a backup with the node state is
+	// restored to a node with another overlay address.
+	ErrOverlayMismatch = errors.New("backup overlay does not match the node overlay")
+)
+
+// Watermark marks the chunks a backup holds. BinIDs are the last bin IDs of
+// every proximity order bin of the node and Timestamp is the time the backup
+// was created at, in nanoseconds.
+type Watermark struct {
+	Timestamp int64    `json:"timestamp"`
+	BinIDs    []uint64 `json:"binIDs,omitempty"`
+}
+
+// BackupOptions configure the chunks written by Backup.
+type BackupOptions struct {
+	// Since makes the backup incremental. Only the chunks stored after the
+	// watermark are written, compared by bin IDs if it has them and by
+	// store timestamps otherwise. Pins of the other chunks are still kept.
+	Since *Watermark
+	// Roots limits the backup to the chunk trees of the root addresses and to
+	// the state entries of those roots.
+	Roots []boson.Address
+}
+
+// BackupHeader is the first entry of a backup.
+type BackupHeader struct {
+	Version int             `json:"version"`
+	Created time.Time       `json:"created"`
+	Overlay boson.Address   `json:"overlay"`
+	Since   *Watermark      `json:"since,omitempty"`
+	Roots   []boson.Address `json:"roots,omitempty"`
+}
+
+// BackupSummary is the last entry of a backup. Its watermark is used as the
+// Since option of the next incremental backup.
+type BackupSummary struct {
+	Watermark Watermark `json:"watermark"`
+	Chunks    uint64    `json:"chunks"`
+	State     uint64    `json:"state"`
+}
+
+// BackupManifest describes a complete backup.
+type BackupManifest struct {
+	BackupHeader
+	BackupSummary
+}
+
+// RestoreResult holds the number of restored entries.
+type RestoreResult struct {
+	Chunks  uint64
+	Skipped uint64
+	State   uint64
+}
+
+// Backup writes a tar archive with the chunks of the database together with
+// the entries they have in the other indexes and every entry of the state
+// store, which holds the file views, the chunk bit vectors, the address book
+// and the other node records. The state store is written in full even in
+// incremental backups, as it does not keep track of changes.
+func (db *DB) Backup(ctx context.Context, w io.Writer, state storage.StateStorer, o BackupOptions) (m BackupManifest, err error) {
+	m.BackupHeader = BackupHeader{
+		Version: currentBackupVersion,
+		Created: time.Now().UTC(),
+		Overlay: boson.NewAddress(db.baseKey),
+		Since:   o.Since,
+		Roots:   o.Roots,
+	}
+	// the watermark is taken before the chunks are iterated, chunks stored
+	// meanwhile may end up in the next incremental backup too
+	m.Watermark.Timestamp = now()
+	m.Watermark.BinIDs = make([]uint64, boson.MaxBins)
+	for po := range m.Watermark.BinIDs {
+		m.Watermark.BinIDs[po], err = db.binIDs.Get(uint64(po))
+		if err != nil {
+			return m, err
+		}
+	}
+
+	var roots map[string]struct{}
+	if len(o.Roots) > 0 {
+		roots = make(map[string]struct{})
+		t := traversal.New(lookupGetter{db})
+		for _, root := range o.Roots {
+			err := t.Traverse(ctx, root, func(addr boson.Address) error {
+				roots[addr.ByteString()] = struct{}{}
+				return nil
+			})
+			if err != nil {
+				return m, fmt.Errorf("traverse %s: %w", root, err)
+			}
+		}
+	}
+
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+
+	if err := writeBackupJSON(tw, backupHeaderFilename, m.BackupHeader); err != nil {
+		return m, err
+	}
+
+	err = db.retrievalDataIndex.Iterate(func(item shed.Item) (stop bool, err error) {
+		if err := ctx.Err(); err != nil {
+			return true, err
+		}
+		if roots != nil {
+			if _, ok := roots[string(item.Address)]; !ok {
+				return false, nil
+			}
+		}
+		r, err := db.chunkRecord(item, o.Since)
+		if err != nil || r == nil {
+			return false, err
+		}
+		if err := writeBackupEntry(tw, backupChunkDir+hex.EncodeToString(item.Address), r.encode()); err != nil {
+			return true, err
+		}
+		m.Chunks++
+		return false, nil
+	}, nil)
+	if err != nil {
+		return m, err
+	}
+
+	err = state.Iterate("", func(key, value []byte) (stop bool, err error) {
+		if roots != nil && !matchRoots(string(key), o.Roots) {
+			return false, nil
+		}
+		if err := writeBackupEntry(tw, backupStateDir+hex.EncodeToString(key), value); err != nil {
+			return true, err
+		}
+		m.State++
+		return false, nil
+	})
+	if err != nil {
+		return m, err
+	}
+
+	if err := writeBackupJSON(tw, backupSummaryFilename, m.BackupSummary); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+// backupRecord is a chunk together with its entries in the other indexes.
+type backupRecord struct {
+	flags byte
+	item  shed.Item
+	cids  []byte
+}
+
+// chunkRecord returns the backup record of the chunk. Chunks stored before the
+// since watermark are only written if they are pinned, and then without their
+// data. It returns nil if the chunk is skipped.
+func (db *DB) chunkRecord(item shed.Item, since *Watermark) (*backupRecord, error) {
+	r := &backupRecord{item: item}
+	if since == nil || since.includes(item, db.po(boson.NewAddress(item.Address))) {
+		r.flags |= recordData
+	}
+
+	pin, err := db.pinIndex.Get(item)
+	switch {
+	case err == nil:
+		r.flags |= recordPin
+		r.item.PinCounter = pin.PinCounter
+	case !errors.Is(err, driver.ErrNotFound):
+		return nil, err
+	}
+	if r.flags&recordData == 0 {
+		if r.flags&recordPin == 0 {
+			return nil, nil
+		}
+		return r, nil
+	}
+
+	access, err := db.retrievalAccessIndex.Get(item)
+	switch {
+	case err == nil:
+		r.flags |= recordAccess
+		r.item.AccessTimestamp = access.AccessTimestamp
+		gc, err := db.gcIndex.Get(r.item)
+		switch {
+		case err == nil:
+			r.flags |= recordGC
+			r.item.GCounter = gc.GCounter
+		case !errors.Is(err, driver.ErrNotFound):
+			return nil, err
+		}
+	case !errors.Is(err, driver.ErrNotFound):
+		return nil, err
+	}
+
+	transfer, err := db.transferDataIndex.Get(item)
+	switch {
+	case err == nil:
+		r.flags |= recordTransfer
+		r.cids = transfer.Data
+	case !errors.Is(err, driver.ErrNotFound):
+		return nil, err
+	}
+	return r, nil
+}
+
+// includes reports whether the chunk is stored after the watermark.
+func (w *Watermark) includes(item shed.Item, po uint8) bool {
+	if len(w.BinIDs) > int(po) {
+		return item.BinID > w.BinIDs[po]
+	}
+	return item.StoreTimestamp > w.Timestamp
+}
+
+// matchRoots reports whether the state store key belongs to one of the roots.
+// File views, mirrors and chunk bit vectors are all keyed by the root address.
+func matchRoots(key string, roots []boson.Address) bool {
+	for _, root := range roots {
+		if strings.Contains(key, root.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *backupRecord) encode() []byte {
+	item := r.item
+	var data []byte
+	if r.flags&recordData != 0 {
+		data = item.Data
+	}
+	b := make([]byte, recordHeaderSize, recordHeaderSize+len(r.cids)+len(data))
+	b[0] = r.flags
+	binary.BigEndian.PutUint64(b[1:9], item.BinID)
+	binary.BigEndian.PutUint64(b[9:17], uint64(item.StoreTimestamp))
+	binary.BigEndian.PutUint64(b[17:25], item.Type)
+	binary.BigEndian.PutUint64(b[25:33], item.Counter)
+	binary.BigEndian.PutUint64(b[33:41], uint64(item.AccessTimestamp))
+	binary.BigEndian.PutUint64(b[41:49], item.GCounter)
+	binary.BigEndian.PutUint64(b[49:57], item.PinCounter)
+	binary.BigEndian.PutUint32(b[57:61], uint32(len(r.cids)))
+	b = append(b, r.cids...)
+	return append(b, data...)
+}
+
+func decodeBackupRecord(b []byte) (r backupRecord, err error) {
+	if len(b) < recordHeaderSize {
+		return r, ErrInvalidBackup
+	}
+	r.flags = b[0]
+	item := &r.item
+	item.BinID = binary.BigEndian.Uint64(b[1:9])
+	item.StoreTimestamp = int64(binary.BigEndian.Uint64(b[9:17]))
+	item.Type = binary.BigEndian.Uint64(b[17:25])
+	item.Counter = binary.BigEndian.Uint64(b[25:33])
+	item.AccessTimestamp = int64(binary.BigEndian.Uint64(b[33:41]))
+	item.GCounter = binary.BigEndian.Uint64(b[41:49])
+	item.PinCounter = binary.BigEndian.Uint64(b[49:57])
+	l := int(binary.BigEndian.Uint32(b[57:61]))
+	if len(b) < recordHeaderSize+l {
+		return r, ErrInvalidBackup
+	}
+	r.cids = b[recordHeaderSize : recordHeaderSize+l]
+	item.Data = b[recordHeaderSize+l:]
+	return r, nil
+}
+
+func writeBackupEntry(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name: name,
+		Mode: 0644,
+		Size: int64(len(data)),
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func writeBackupJSON(tw *tar.Writer, name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeBackupEntry(tw, name, data)
+}
+
+// lookupGetter gets chunks without updating the gc indexes, so that
+// traversing the roots of a backup does not change the database.
+type lookupGetter struct {
+	*DB
+}
+
+func (g lookupGetter) Get(ctx context.Context, _ storage.ModeGet, addr boson.Address, index int64) (boson.Chunk, error) {
+	return g.DB.Get(ctx, storage.ModeGetLookup, addr, index)
+}
+
+// BackupReader reads a backup written by Backup.
+type BackupReader struct {
+	tr     *tar.Reader
+	header BackupHeader
+}
+
+// NewBackupReader reads the header of the backup from r.
+func NewBackupReader(r io.Reader) (*BackupReader, error) {
+	br := &BackupReader{tr: tar.NewReader(r)}
+	name, data, err := br.next()
+	if err != nil {
+		return nil, err
+	}
+	if name != backupHeaderFilename {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidBackup)
+	}
+	if err := json.Unmarshal(data, &br.header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidBackup, err)
+	}
+	if br.header.Version != currentBackupVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidBackup, br.header.Version)
+	}
+	return br, nil
+}
+
+// Header returns the header of the backup.
+func (r *BackupReader) Header() BackupHeader {
+	return r.header
+}
+
+// next returns the name and the data of the next entry.
+func (r *BackupReader) next() (string, []byte, error) {
+	hdr, err := r.tr.Next()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return "", nil, fmt.Errorf("%w: unexpected end of backup", ErrInvalidBackup)
+		}
+		return "", nil, err
+	}
+	data, err := io.ReadAll(r.tr)
+	if err != nil {
+		return "", nil, err
+	}
+	return hdr.Name, data, nil
+}
+
+// each calls the functions for the chunk and state entries of the backup and
+// returns its summary, which is checked against the read entries.
+func (r *BackupReader) each(chunkFn func(addr, data []byte) error, stateFn func(key, value []byte) error) (s BackupSummary, err error) {
+	var chunks, state uint64
+	for {
+		name, data, err := r.next()
+		if err != nil {
+			return s, err
+		}
+		switch {
+		case name == backupSummaryFilename:
+			if err := json.Unmarshal(data, &s); err != nil {
+				return s, fmt.Errorf("%w: summary: %v", ErrInvalidBackup, err)
+			}
+			if s.Chunks != chunks || s.State != state {
+				return s, fmt.Errorf("%w: got %d chunks and %d state entries, want %d and %d", ErrInvalidBackup, chunks, state, s.Chunks, s.State)
+			}
+			return s, nil
+		case strings.HasPrefix(name, backupChunkDir):
+			addr, err := hex.DecodeString(strings.TrimPrefix(name, backupChunkDir))
+			if err != nil || len(addr) != boson.HashSize {
+				return s, fmt.Errorf("%w: chunk entry %s", ErrInvalidBackup, name)
+			}
+			if err := chunkFn(addr, data); err != nil {
+				return s, err
+			}
+			chunks++
+		case strings.HasPrefix(name, backupStateDir):
+			key, err := hex.DecodeString(strings.TrimPrefix(name, backupStateDir))
+			if err != nil {
+				return s, fmt.Errorf("%w: state entry %s", ErrInvalidBackup, name)
+			}
+			if err := stateFn(key, data); err != nil {
+				return s, err
+			}
+			state++
+		default:
+			return s, fmt.Errorf("%w: unknown entry %s", ErrInvalidBackup, name)
+		}
+	}
+}
+
+// ReadBackupManifest reads the whole backup from r and returns its manifest.
+func ReadBackupManifest(r io.Reader) (m BackupManifest, err error) {
+	br, err := NewBackupReader(r)
+	if err != nil {
+		return m, err
+	}
+	m.BackupHeader = br.Header()
+	nop := func(_, _ []byte) error { return nil }
+	m.BackupSummary, err = br.each(nop, nop)
+	return m, err
+}
+
+// Restore writes the chunks and the state entries of the backup to the
+// database and to the state store. Chunks the database already holds are
+// skipped, only their pin counters are set from the backup. The restored
+// chunks get new bin IDs. Backups with the full state of a node can only be
+// restored to a node with the same overlay address, as the state holds it.
+// Incremental backups are restored after the backup they are based on.
+func (db *DB) Restore(ctx context.Context, r *BackupReader, state storage.StateStorer) (res RestoreResult, err error) {
+	h := r.Header()
+	if len(h.Roots) == 0 && !h.Overlay.Equal(boson.NewAddress(db.baseKey)) {
+		return res, fmt.Errorf("%w: backup of %s, node %s", ErrOverlayMismatch, h.Overlay, boson.NewAddress(db.baseKey))
+	}
+
+	pending := make([]backupRecord, 0, restoreBatchSize)
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		n, err := db.restoreChunks(pending)
+		if err != nil {
+			return err
+		}
+		res.Chunks += n
+		res.Skipped += uint64(len(pending)) - n
+		pending = pending[:0]
+		return nil
+	}
+
+	_, err = r.each(func(addr, data []byte) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r, err := decodeBackupRecord(data)
+		if err != nil {
+			return fmt.Errorf("chunk %x: %w", addr, err)
+		}
+		r.item.Address = addr
+		pending = append(pending, r)
+		if len(pending) == restoreBatchSize {
+			return flush()
+		}
+		return nil
+	}, func(key, value []byte) error {
+		if err := flush(); err != nil {
+			return err
+		}
+		if err := state.Put(string(key), rawValue(value)); err != nil {
+			return fmt.Errorf("state entry %q: %w", key, err)
+		}
+		res.State++
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	return res, flush()
+}
+
+// restoreChunks writes the chunk records in a single batch and returns the
+// number of chunks that were not in the database.
+func (db *DB) restoreChunks(records []backupRecord) (n uint64, err error) {
+	db.batchMu.Lock()
+	defer db.batchMu.Unlock()
+
+	batch := db.shed.NewBatch()
+	binIDs := make(map[uint8]uint64)
+	var gcSizeChange int64
+
+	for _, r := range records {
+		item := r.item
+		exists, err := db.retrievalDataIndex.Has(item)
+		if err != nil {
+			return 0, err
+		}
+		if r.flags&recordPin != 0 && (exists || r.flags&recordData != 0) {
+			if err := db.pinIndex.PutInBatch(batch, item); err != nil {
+				return 0, err
+			}
+		}
+		if exists || r.flags&recordData == 0 {
+			continue
+		}
+
+		item.BinID, err = db.incBinID(binIDs, db.po(boson.NewAddress(item.Address)))
+		if err != nil {
+			return 0, err
+		}
+		if err := db.retrievalDataIndex.PutInBatch(batch, item); err != nil {
+			return 0, err
+		}
+		if r.flags&recordAccess != 0 {
+			hasAccess, err := db.retrievalAccessIndex.Has(item)
+			if err != nil {
+				return 0, err
+			}
+			if !hasAccess {
+				if err := db.retrievalAccessIndex.PutInBatch(batch, item); err != nil {
+					return 0, err
+				}
+				if r.flags&recordGC != 0 {
+					if err := db.gcIndex.PutInBatch(batch, item); err != nil {
+						return 0, err
+					}
+					gcSizeChange += int64(item.GCounter)
+				}
+			}
+		}
+		if r.flags&recordTransfer != 0 {
+			if err := db.transferDataIndex.PutInBatch(batch, shed.Item{Address: item.Address, Data: r.cids}); err != nil {
+				return 0, err
+			}
+		}
+		n++
+	}
+
+	for po, id := range binIDs {
+		_ = db.binIDs.PutInBatch(batch, uint64(po), id)
+	}
+	if err := db.incGCSizeInBatch(batch, gcSizeChange); err != nil {
+		return 0, err
+	}
+	if err := batch.Commit(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// rawValue is stored in the state store as it is.
+type rawValue []byte
+
+func (v rawValue) MarshalBinary() ([]byte, error) {
+	return v, nil
+}
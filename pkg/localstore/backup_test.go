@@ -0,0 +1,193 @@
+package localstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/localstore/filestore"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	mockstate "github.com/FavorLabs/favorX/pkg/statestore/mock"
+	"github.com/FavorLabs/favorX/pkg/storage"
+)
+
+// TestBackupRestore validates that a full and an incremental backup restore
+// the chunks, their pins and the state store entries to another database.
+func TestBackupRestore(t *testing.T) {
+	ctx := context.Background()
+	baseKey := generateTestRandomChunk().Address().Bytes()
+	state1 := mockstate.NewStateStore()
+	db1 := newBackupTestDB(t, baseKey, state1)
+
+	chunks := generateTestRandomChunks(10)
+	if _, err := db1.Put(ctx, storage.ModePutUpload, chunks...); err != nil {
+		t.Fatal(err)
+	}
+	pinned := chunks[:2]
+	if err := db1.Set(ctx, storage.ModeSetPin, chunkAddresses(pinned)...); err != nil {
+		t.Fatal(err)
+	}
+	root := chunks[0].Address()
+	if err := db1.PutFile(filestore.FileView{RootCid: root, Name: "backup", Size: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	m, err := db1.Backup(ctx, &buf, state1, BackupOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Chunks != uint64(len(chunks)) {
+		t.Errorf("got %d backed up chunks, want %d", m.Chunks, len(chunks))
+	}
+	wantState := countState(t, state1)
+	if m.State != wantState {
+		t.Errorf("got %d backed up state entries, want %d", m.State, wantState)
+	}
+
+	state2 := mockstate.NewStateStore()
+	db2 := newBackupTestDB(t, baseKey, state2)
+	res := restoreBackup(t, db2, state2, &buf)
+	if want := (RestoreResult{Chunks: uint64(len(chunks)), State: wantState}); res != want {
+		t.Errorf("got restore result %+v, want %+v", res, want)
+	}
+	checkBackupChunks(t, db2, chunks)
+	for _, ch := range pinned {
+		item, err := db2.pinIndex.Get(addressToItem(ch.Address()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.PinCounter != 1 {
+			t.Errorf("chunk %s: got pin counter %d, want 1", ch.Address(), item.PinCounter)
+		}
+	}
+	var view filestore.FileView
+	if err := state2.Get("file-"+root.String(), &view); err != nil {
+		t.Fatal(err)
+	}
+	if view.Name != "backup" {
+		t.Errorf("got file name %q, want %q", view.Name, "backup")
+	}
+
+	// the incremental backup holds the new chunks and the pins of the old ones
+	newChunks := generateTestRandomChunks(5)
+	if _, err := db1.Put(ctx, storage.ModePutUpload, newChunks...); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	im, err := db1.Backup(ctx, &buf, state1, BackupOptions{Since: &m.Watermark})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64(len(newChunks) + len(pinned)); im.Chunks != want {
+		t.Errorf("got %d backed up chunks, want %d", im.Chunks, want)
+	}
+	manifest, err := ReadBackupManifest(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Chunks != im.Chunks || manifest.Since == nil {
+		t.Errorf("got manifest %+v", manifest)
+	}
+
+	res = restoreBackup(t, db2, state2, &buf)
+	if want := (RestoreResult{Chunks: uint64(len(newChunks)), Skipped: uint64(len(pinned)), State: wantState}); res != want {
+		t.Errorf("got restore result %+v, want %+v", res, want)
+	}
+	checkBackupChunks(t, db2, append(chunks, newChunks...))
+
+	buf.Reset()
+	if _, err := db1.Backup(ctx, &buf, state1, BackupOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	state3 := mockstate.NewStateStore()
+	db3 := newBackupTestDB(t, generateTestRandomChunk().Address().Bytes(), state3)
+	r, err := NewBackupReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db3.Restore(ctx, r, state3); !errors.Is(err, ErrOverlayMismatch) {
+		t.Fatalf("got error %v, want %v", err, ErrOverlayMismatch)
+	}
+}
+
+// TestBackupIncomplete validates that a truncated backup is not restored
+// without an error.
+func TestBackupIncomplete(t *testing.T) {
+	baseKey := generateTestRandomChunk().Address().Bytes()
+	state := mockstate.NewStateStore()
+	db := newBackupTestDB(t, baseKey, state)
+	if _, err := db.Put(context.Background(), storage.ModePutUpload, generateTestRandomChunks(10)...); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := db.Backup(context.Background(), &buf, state, BackupOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadBackupManifest(bytes.NewReader(buf.Bytes()[:buf.Len()/2])); !errors.Is(err, ErrInvalidBackup) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidBackup)
+	}
+}
+
+func newBackupTestDB(t *testing.T, baseKey []byte, state storage.StateStorer) *DB {
+	t.Helper()
+
+	var path string
+	if dbDriver == "wiredtiger" {
+		path = t.TempDir()
+	}
+	db, err := New(path, baseKey, state, nil, logging.New(io.Discard, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	return db
+}
+
+func restoreBackup(t *testing.T, db *DB, state storage.StateStorer, backup io.Reader) RestoreResult {
+	t.Helper()
+
+	r, err := NewBackupReader(backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := db.Restore(context.Background(), r, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func checkBackupChunks(t *testing.T, db *DB, chunks []boson.Chunk) {
+	t.Helper()
+
+	for _, ch := range chunks {
+		got, err := db.Get(context.Background(), storage.ModeGetLookup, ch.Address(), 0)
+		if err != nil {
+			t.Fatalf("chunk %s: %v", ch.Address(), err)
+		}
+		if !bytes.Equal(got.Data(), ch.Data()) {
+			t.Fatalf("chunk %s: got data %x, want %x", ch.Address(), got.Data(), ch.Data())
+		}
+	}
+}
+
+func countState(t *testing.T, state storage.StateStorer) (n uint64) {
+	t.Helper()
+
+	err := state.Iterate("", func(_, _ []byte) (bool, error) {
+		n++
+		return false, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
@@ -0,0 +1,79 @@
+package localstore
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/localstore/filestore"
+	"github.com/FavorLabs/favorX/pkg/storage"
+)
+
+// TestRollbackFile validates that rolling back restores the recorded file
+// view of the target version and drops every newer mirror.
+func TestRollbackFile(t *testing.T) {
+	db := newTestDB(t, nil)
+
+	v0 := generateTestRandomChunk().Address()
+	v1 := generateTestRandomChunk().Address()
+	v2 := generateTestRandomChunk().Address()
+
+	if err := db.PutFile(filestore.FileView{RootCid: v0, Name: "v0", Size: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutMirrorFile(v1, v0, filestore.MOVE); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutFile(filestore.FileView{RootCid: v1, Name: "v1", Size: 20}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutMirrorFile(v2, v1, filestore.REMOVE); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutFile(filestore.FileView{RootCid: v2, Name: "v2", Size: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	mirrors, err := db.GetMirrors(v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mirrors) != 2 {
+		t.Fatalf("got %d mirrors, want 2", len(mirrors))
+	}
+
+	_, err = db.RollbackFile(v2, generateTestRandomChunk().Address())
+	if !errors.Is(err, filestore.ErrVersionNotFound) {
+		t.Fatalf("got error %v, want %v", err, filestore.ErrVersionNotFound)
+	}
+
+	dropped, err := db.RollbackFile(v2, v0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []boson.Address{v2, v1}
+	if len(dropped) != len(want) {
+		t.Fatalf("got %d dropped versions, want %d", len(dropped), len(want))
+	}
+	for i := range want {
+		if !dropped[i].Equal(want[i]) {
+			t.Fatalf("dropped version %d: got %s, want %s", i, dropped[i], want[i])
+		}
+	}
+
+	file, ok := db.GetFile(v0)
+	if !ok {
+		t.Fatal("rolled back file not found")
+	}
+	if file.Name != "v0" || file.Size != 10 {
+		t.Fatalf("got file %+v, want the v0 view", file)
+	}
+	if db.HasFile(v2) {
+		t.Fatal("current version still present after rollback")
+	}
+	for _, d := range dropped {
+		if _, err := db.GetMirror(d); !errors.Is(err, storage.ErrNotFound) {
+			t.Fatalf("mirror of %s: got error %v, want %v", d, err, storage.ErrNotFound)
+		}
+	}
+}
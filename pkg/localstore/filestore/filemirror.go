@@ -1,6 +1,8 @@
 package filestore
 
 import (
+	"time"
+
 	"github.com/FavorLabs/favorX/pkg/boson"
 	"github.com/FavorLabs/favorX/pkg/storage"
 )
@@ -21,6 +23,7 @@ type FileMirror struct {
 	ErrDefault    string
 	MimeType      string
 	ReferenceLink string
+	Timestamp     int64
 }
 
 type Operation int
@@ -30,6 +33,8 @@ const (
 	MOVE
 	COPY
 	MKDIR
+	ADD
+	BATCH
 )
 
 func (o Operation) String() string {
@@ -42,6 +47,10 @@ func (o Operation) String() string {
 		return "COPY"
 	case MKDIR:
 		return "MKDIR"
+	case ADD:
+		return "ADD"
+	case BATCH:
+		return "BATCH"
 	default:
 		return "Unknown"
 	}
@@ -69,6 +78,7 @@ func (fs *fileStore) putMirror(pre, next boson.Address, ope Operation, file File
 		ErrDefault:    file.ErrDefault,
 		MimeType:      file.MimeType,
 		ReferenceLink: file.ReferenceLink,
+		Timestamp:     time.Now().Unix(),
 	}
 	if err := fs.stateStore.Put(mirrorPrefix+"-"+next.String(), fileMirror); err != nil {
 		return err
@@ -122,3 +132,57 @@ func (fs *fileStore) hasMirror(reference boson.Address) (bool, error) {
 	}
 	return true, nil
 }
+
+// rollback walks the mirror chain of reference back to target, restores the
+// file view that was recorded for target and drops every newer version.
+// The dropped root cids, reference included, are returned newest first.
+func (fs *fileStore) rollback(reference, target boson.Address) ([]boson.Address, error) {
+	if !fs.Has(reference) {
+		return nil, storage.ErrNotFound
+	}
+	mirrors, err := fs.getMirrors(reference)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		dropped []boson.Address
+		found   *FileMirror
+	)
+	for _, m := range mirrors {
+		dropped = append(dropped, m.NextRootCid)
+		if m.RootCid.Equal(target) {
+			found = m
+			break
+		}
+	}
+	if found == nil {
+		return nil, ErrVersionNotFound
+	}
+
+	file := FileView{
+		RootCid:       found.RootCid,
+		Hash:          found.Hash,
+		Pinned:        found.Pinned,
+		Registered:    found.Registered,
+		Size:          found.Size,
+		Type:          found.Type,
+		Name:          found.Name,
+		Extension:     found.Extension,
+		Default:       found.Default,
+		ErrDefault:    found.ErrDefault,
+		MimeType:      found.MimeType,
+		ReferenceLink: found.ReferenceLink,
+	}
+	if err = fs.Put(file); err != nil {
+		return nil, err
+	}
+	for _, d := range dropped {
+		if err = fs.delMirror(d); err != nil {
+			return nil, err
+		}
+	}
+	if err = fs.Delete(reference); err != nil {
+		return nil, err
+	}
+	return dropped, nil
+}
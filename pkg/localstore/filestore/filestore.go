@@ -1,11 +1,16 @@
 package filestore
 
 import (
+	"errors"
 	"github.com/FavorLabs/favorX/pkg/boson"
 	"github.com/FavorLabs/favorX/pkg/storage"
 	"strings"
 )
 
+// ErrVersionNotFound is returned when a root cid is not part of the mirror
+// chain of a file.
+var ErrVersionNotFound = errors.New("filestore: version not found")
+
 type Interface interface {
 	Init() error
 	Get(reference boson.Address) (FileView, bool)
@@ -18,6 +23,7 @@ type Interface interface {
 	DeleteMirror(reference boson.Address) error
 	Has(reference boson.Address) bool
 	Update(file FileView) error
+	Rollback(reference, target boson.Address) ([]boson.Address, error)
 }
 type fileStore struct {
 	stateStore storage.StateStorer
@@ -146,6 +152,10 @@ func (fs *fileStore) Delete(reference boson.Address) error {
 func (fs *fileStore) DeleteMirror(reference boson.Address) error {
 	return fs.delMirror(reference)
 }
+
+func (fs *fileStore) Rollback(reference, target boson.Address) ([]boson.Address, error) {
+	return fs.rollback(reference, target)
+}
 func (fs *fileStore) Has(reference boson.Address) bool {
 	_, ok := fs.files[reference.String()]
 	return ok
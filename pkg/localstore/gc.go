@@ -19,6 +19,7 @@ package localstore
 import (
 	"errors"
 	"runtime"
+	"sync/atomic"
 	"time"
 
 	"github.com/FavorLabs/favorX/pkg/shed"
@@ -240,7 +241,19 @@ func getMemory() uint64 {
 // gcTrigger retruns the absolute value for garbage collection
 // target value, calculated from db.capacity and gcTargetRatio.
 func (db *DB) gcTarget() (target uint64) {
-	return uint64(float64(db.capacity) * gcTargetRatio)
+	return uint64(float64(atomic.LoadUint64(&db.capacity)) * gcTargetRatio)
+}
+
+// SetCapacity changes the number of chunks the database keeps before the
+// garbage collection removes the least recently accessed ones.
+func (db *DB) SetCapacity(capacity uint64) {
+	if capacity == 0 {
+		capacity = defaultCapacity
+	}
+	atomic.StoreUint64(&db.capacity, capacity)
+	if gcSize, err := db.gcSize.Get(); err == nil && gcSize >= capacity {
+		db.triggerGarbageCollection()
+	}
 }
 
 // triggerGarbageCollection signals collectGarbageWorker
@@ -290,7 +303,7 @@ func (db *DB) incGCSizeInBatch(batch driver.Batching, change int64) (err error)
 	db.metrics.GCSize.Set(float64(newSize))
 
 	// trigger garbage collection if we reached the capacity
-	if newSize >= db.capacity {
+	if newSize >= atomic.LoadUint64(&db.capacity) {
 		db.triggerGarbageCollection()
 	}
 	if db.fullNode {
@@ -138,6 +138,10 @@ type Options struct {
 	// Driver support: leveldb/wiredtiger
 	Driver string
 
+	// Password enables the at-rest encryption of the database
+	// with data keys sealed by it.
+	Password string
+
 	// Capacity is a limit that triggers garbage collection when
 	// number of items in gcIndex equals or exceeds it.
 	Capacity uint64
@@ -193,7 +197,8 @@ func New(path string, baseKey []byte, stateStore storage.StateStorer, o *Options
 	}
 
 	shedOpts := &shed.Options{
-		Driver: o.Driver,
+		Driver:   o.Driver,
+		Password: o.Password,
 	}
 
 	db.shed, err = shed.NewDB(path, shedOpts)
@@ -675,6 +680,25 @@ func (db *DB) DeleteMirror(reference boson.Address) error {
 	return db.filestore.DeleteMirror(reference)
 }
 
+// RollbackFile restores the file view of target from the mirror chain of
+// reference. The chunk info of every dropped version is removed, the chunks
+// themselves are left to the garbage collector.
+func (db *DB) RollbackFile(reference, target boson.Address) ([]boson.Address, error) {
+	db.fileMu.Lock()
+	defer db.fileMu.Unlock()
+	dropped, err := db.filestore.Rollback(reference, target)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range dropped {
+		db.chunkstore.CancelFinder(d)
+		if err = db.deleteFile(d); err != nil {
+			return nil, err
+		}
+	}
+	return dropped, nil
+}
+
 func (db *DB) ChunkCounter(reference boson.Address) error {
 	db.fileMu.Lock()
 	defer db.fileMu.Unlock()
@@ -697,3 +721,9 @@ func (db *DB) ChunkCounter(reference boson.Address) error {
 	db.batchMu.Unlock()
 	return err
 }
+
+// RotateKeys rotates the data keys of the encrypted database and re-encrypts
+// the stored data while the database stays in use.
+func (db *DB) RotateKeys() error {
+	return db.shed.RotateKeys()
+}
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/file"
 	"github.com/FavorLabs/favorX/pkg/localstore/chunkstore"
 	"github.com/FavorLabs/favorX/pkg/sctx"
 	"github.com/FavorLabs/favorX/pkg/shed"
@@ -258,9 +259,11 @@ func (db *DB) getChunk(ch boson.Address, t int) (chs []boson.Address, err error)
 		return nil, err
 	}
 	data := item.Data[8:]
-	size := int64(binary.LittleEndian.Uint64(item.Data[:8]))
+	span := binary.LittleEndian.Uint64(item.Data[:8])
+	indexed := file.IsIndexedSpan(span)
+	size := int64(file.SpanLength(span))
 	var nodes []*node
-	if size <= int64(len(data)) {
+	if !indexed && size <= int64(len(data)) {
 		if t == 1 {
 			chs = append(chs, ch)
 			return
@@ -275,8 +278,14 @@ func (db *DB) getChunk(ch boson.Address, t int) (chs []boson.Address, err error)
 	if len(nodes) > 0 {
 		return chs, err
 	}
-	for i := 0; i < len(data); i += boson.HashSize {
-		ch = boson.NewAddress(data[i : i+boson.HashSize])
+	// the intermediate chunks of content-defined chunked tries hold
+	// span|reference entries
+	entryLength, refOffset := boson.HashSize, 0
+	if indexed {
+		entryLength, refOffset = boson.SpanSize+boson.HashSize, boson.SpanSize
+	}
+	for i := 0; i+entryLength <= len(data); i += entryLength {
+		ch = boson.NewAddress(data[i+refOffset : i+entryLength])
 		x := [32]byte{}
 		slice := x[:]
 		if ch.Equal(boson.NewAddress(slice)) {
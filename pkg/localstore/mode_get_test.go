@@ -19,11 +19,15 @@ package localstore
 import (
 	"bytes"
 	"context"
+	"math/rand"
+	"testing"
+	"time"
+
 	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/file/joiner"
+	"github.com/FavorLabs/favorX/pkg/file/pipeline/builder"
 	filetest "github.com/FavorLabs/favorX/pkg/file/testing"
 	"github.com/FavorLabs/favorX/pkg/storage"
-	"testing"
-	"time"
 )
 
 // TestModeGetRequest validates ModeGetRequest index values on the provided DB.
@@ -305,6 +309,49 @@ func TestGetChunks(t *testing.T) {
 	}
 }
 
+// TestGetChunksIndexed checks the chunks of content-defined chunked tries are
+// all found, their intermediate chunks holding span|reference entries.
+func TestGetChunksIndexed(t *testing.T) {
+	db := newTestDB(t, nil)
+	ctx := context.Background()
+
+	data := make([]byte, 300*boson.ChunkSize)
+	rand.New(rand.NewSource(1)).Read(data)
+	p, err := builder.NewChunkingPipelineBuilder(ctx, db, storage.ModePutUpload, false, builder.ContentDefinedChunking)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootCid, err := builder.FeedPipeline(ctx, p, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	j, _, err := joiner.New(ctx, db, storage.ModeGetRequest, rootCid, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make(map[string]struct{})
+	if err := j.IterateChunkAddresses(func(addr boson.Address) error {
+		want[addr.String()] = struct{}{}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	chs, err := db.getChunk(rootCid, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chs) != len(want) {
+		t.Fatalf("got %d chunks, want %d", len(chs), len(want))
+	}
+	for _, ch := range chs {
+		if _, ok := want[ch.String()]; !ok {
+			t.Fatalf("got chunk %s not in the trie", ch)
+		}
+	}
+}
+
 func TestChainChunks(t *testing.T) {
 	db := newTestDB(t, nil)
 
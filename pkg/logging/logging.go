@@ -51,3 +51,13 @@ func New(w io.Writer, level logrus.Level) Logger {
 func (l *logger) NewEntry() *logrus.Entry {
 	return logrus.NewEntry(l.Logger)
 }
+
+// SetLevel changes the level of the logger. It reports whether the logger
+// supports changing its level.
+func SetLevel(l Logger, level logrus.Level) bool {
+	s, ok := l.(interface{ SetLevel(logrus.Level) })
+	if ok {
+		s.SetLevel(level)
+	}
+	return ok
+}
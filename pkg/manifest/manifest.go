@@ -14,13 +14,15 @@ import (
 const DefaultManifestType = ManifestMantarayContentType
 
 const (
-	RootPath                      = "/"
-	ReferenceLinkKey              = "reference"
-	WebsiteIndexDocumentSuffixKey = "website-index-document"
-	WebsiteErrorDocumentPathKey   = "website-error-document"
-	EntryMetadataContentTypeKey   = "Content-Type"
-	EntryMetadataDirnameKey       = "Dirname"
-	EntryMetadataFilenameKey      = "Filename"
+	RootPath                        = "/"
+	ReferenceLinkKey                = "reference"
+	WebsiteIndexDocumentSuffixKey   = "website-index-document"
+	WebsiteErrorDocumentPathKey     = "website-error-document"
+	EntryMetadataContentTypeKey     = "Content-Type"
+	EntryMetadataDirnameKey         = "Dirname"
+	EntryMetadataFilenameKey        = "Filename"
+	EntryMetadataContentEncodingKey = "Content-Encoding"
+	EntryMetadataDecompressedKey    = "Decompressed-Content-Length"
 )
 
 var (
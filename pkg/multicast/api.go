@@ -2,6 +2,9 @@ package multicast
 
 import (
 	"context"
+	"errors"
+	"io"
+
 	"github.com/FavorLabs/favorX/pkg/boson"
 	"github.com/FavorLabs/favorX/pkg/rpc"
 )
@@ -57,6 +60,38 @@ func (a *apiService) Multicast(ctx context.Context, name string) (*rpc.Subscript
 	return sub, nil
 }
 
+// MulticastTopics subscribe the group multicast message with a topic matching
+// one of the patterns
+func (a *apiService) MulticastTopics(ctx context.Context, name string, patterns []string) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+
+	err := a.s.SubscribeMulticastTopics(notifier, sub, GroupID(name), patterns)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// MessageTopics subscribe the group message with a topic matching one of the
+// patterns
+func (a *apiService) MessageTopics(ctx context.Context, name string, patterns []string) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+
+	err := a.s.SubscribeGroupMessageTopics(notifier, sub, GroupID(name), patterns)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
 func (a *apiService) Peers(ctx context.Context, name string) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
@@ -79,3 +114,76 @@ func (a *apiService) Peers(ctx context.Context, name string) (*rpc.Subscription,
 func (a *apiService) Reply(sessionID string, data []byte) error {
 	return a.s.replyGroupMessage(sessionID, data)
 }
+
+// StreamData is a piece of the payload read from a stream
+type StreamData struct {
+	Data []byte `json:"data"`
+	EOF  bool   `json:"eof"`
+}
+
+// OpenStream open a streamed request to the target in the group, it is written
+// and its response read with the returned session ID
+func (a *apiService) OpenStream(name, target, topic string) (string, error) {
+	dest, err := boson.ParseHexAddress(target)
+	if err != nil {
+		return "", err
+	}
+	if err = ValidateTopic(topic); err != nil {
+		return "", err
+	}
+	t, err := a.s.OpenTransfer(context.Background(), GroupID(name), dest, topic, "", nil)
+	if err != nil {
+		return "", err
+	}
+	return t.SessionID(), nil
+}
+
+// StreamWrite write to the payload of the stream, blocking while the peer is
+// behind reading it
+func (a *apiService) StreamWrite(ctx context.Context, sessionID string, data []byte) error {
+	t, err := a.s.GetTransfer(sessionID)
+	if err != nil {
+		return err
+	}
+	_, err = t.WriteContext(ctx, data)
+	return err
+}
+
+// StreamCloseWrite end the payload of the stream
+func (a *apiService) StreamCloseWrite(sessionID string) error {
+	t, err := a.s.GetTransfer(sessionID)
+	if err != nil {
+		return err
+	}
+	return t.CloseWrite()
+}
+
+// StreamRead read up to max bytes of the payload of the peer, blocking until
+// some are received
+func (a *apiService) StreamRead(ctx context.Context, sessionID string, max int) (*StreamData, error) {
+	t, err := a.s.GetTransfer(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if max <= 0 || max > transferFrameSize {
+		max = transferFrameSize
+	}
+	buf := make([]byte, max)
+	n, err := t.ReadContext(ctx, buf)
+	if errors.Is(err, io.EOF) {
+		return &StreamData{EOF: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &StreamData{Data: buf[:n]}, nil
+}
+
+// StreamClose abort the stream on both sides
+func (a *apiService) StreamClose(sessionID string) error {
+	t, err := a.s.GetTransfer(sessionID)
+	if err != nil {
+		return err
+	}
+	return t.Abort()
+}
@@ -43,16 +43,17 @@ func (s *Service) StartDiscover() {
 
 func (s *Service) discover(g *Group) {
 	doFunc := func(wg *sync.WaitGroup, v *Group) {
-		if v.connectedPeers.Length() < v.option.KeepConnectedPeers {
+		option := v.getOption()
+		if v.connectedPeers.Length() < option.KeepConnectedPeers {
 			_ = v.keepPeers.EachBin(func(address boson.Address, u uint8) (stop, jumpToNext bool, err error) {
 				_ = s.route.Connect(context.Background(), address)
-				if v.connectedPeers.Length() >= v.option.KeepConnectedPeers {
+				if v.connectedPeers.Length() >= option.KeepConnectedPeers {
 					return true, false, err
 				}
 				return false, false, err
 			})
 		}
-		if v.keepPeers.Length() < v.option.KeepPingPeers || v.connectedPeers.Length() < v.option.KeepConnectedPeers {
+		if v.keepPeers.Length() < option.KeepPingPeers || v.connectedPeers.Length() < option.KeepConnectedPeers {
 			// do find
 			wg.Add(1)
 			go s.doFindGroup(wg, v)
@@ -77,11 +78,12 @@ func (s *Service) doFindGroup(wg *sync.WaitGroup, group *Group) {
 		var a, b, at, bt int
 		at = group.connectedPeers.Length()
 		bt = group.keepPeers.Length()
-		if group.option.KeepConnectedPeers > at {
-			a = group.option.KeepConnectedPeers - at
+		option := group.getOption()
+		if option.KeepConnectedPeers > at {
+			a = option.KeepConnectedPeers - at
 		}
-		if group.option.KeepPingPeers > bt {
-			b = group.option.KeepPingPeers - bt
+		if option.KeepPingPeers > bt {
+			b = option.KeepPingPeers - bt
 		}
 		return a + b
 	}
@@ -96,6 +98,8 @@ func (s *Service) doFindGroup(wg *sync.WaitGroup, group *Group) {
 	default:
 	}
 
+	defer group.discovery.start(group)()
+
 	t := time.Now()
 	s.logger.Tracef("doFindGroup start %s", group.gid)
 	defer s.logger.Tracef("doFindGroup done %s took %v", group.gid, time.Since(t))
@@ -228,8 +232,11 @@ func (s *Service) doFindGroup(wg *sync.WaitGroup, group *Group) {
 
 }
 
-func (s *Service) getForwardNodes(gid boson.Address, skip ...boson.Address) (nodes []boson.Address) {
-	nodes = s.getForwardNodesKnownConnected(gid, skip...)
+// getForwardNodes returns the nodes to forward a message of the group to. The
+// members of the group are preferred when their subscribers are interested in
+// the topic; messages without a topic and lookups are not pruned.
+func (s *Service) getForwardNodes(gid boson.Address, topic string, skip ...boson.Address) (nodes []boson.Address) {
+	nodes = s.getForwardNodesKnownConnected(gid, topic, skip...)
 	if len(nodes) > 0 {
 		s.logger.Tracef("multicast forward get known connected %d", len(nodes))
 		return nodes
@@ -269,7 +276,7 @@ func (s *Service) getCloserKnownGID(gid boson.Address) (p *pslice.PSlice) {
 }
 
 // Get forwarding nodes step 1
-func (s *Service) getForwardNodesKnownConnected(gid boson.Address, skip ...boson.Address) (nodes []boson.Address) {
+func (s *Service) getForwardNodesKnownConnected(gid boson.Address, topic string, skip ...boson.Address) (nodes []boson.Address) {
 	conn := s.getCloserKnownGID(gid)
 	if conn != nil {
 		_ = conn.EachBin(func(address boson.Address, u uint8) (stop, jumpToNext bool, err error) {
@@ -278,6 +285,13 @@ func (s *Service) getForwardNodesKnownConnected(gid boson.Address, skip ...boson
 			}
 			return false, false, nil
 		})
+		// any member floods the message through the group, so the ones
+		// without interested subscribers are left out only when others remain
+		if topic != "" {
+			if interested := s.interestedPeers(gid, topic, nodes); len(interested) > 0 {
+				nodes = interested
+			}
+		}
 		if len(nodes) > forwardLimit {
 			nodes = RandomPeersLimit(nodes, forwardLimit)
 		}
@@ -476,7 +490,7 @@ func (s *Service) onFindGroup(ctx context.Context, peer p2p.Peer, stream p2p.Str
 	}
 
 	// forward
-	nodes := s.getForwardNodes(gid, skip...)
+	nodes := s.getForwardNodes(gid, "", skip...)
 
 	var finds []boson.Address
 	for _, addr := range nodes {
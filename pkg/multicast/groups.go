@@ -0,0 +1,233 @@
+package multicast
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/multicast/model"
+	"github.com/FavorLabs/favorX/pkg/storage"
+)
+
+const groupKeyPrefix = "multicast_group_"
+
+var (
+	ErrGroupNotFound = errors.New("group not found")
+	ErrGroupExists   = errors.New("group already exists")
+	ErrInvalidGroup  = errors.New("invalid group configuration")
+)
+
+// GroupID returns the id of the group, which is the name itself if it is a
+// hex address.
+func GroupID(name string) boson.Address {
+	gid, err := boson.ParseHexAddress(name)
+	if err != nil {
+		return GenerateGID(name)
+	}
+	return gid
+}
+
+func groupKey(gid boson.Address) string {
+	return groupKeyPrefix + gid.String()
+}
+
+func validateGroup(o model.ConfigNodeGroup) error {
+	if o.Name == "" {
+		return fmt.Errorf("%w: missing name", ErrInvalidGroup)
+	}
+	if o.GType != model.GTypeJoin && o.GType != model.GTypeObserve {
+		return fmt.Errorf("%w: unknown type %d", ErrInvalidGroup, o.GType)
+	}
+	return nil
+}
+
+// GroupConfigs returns the configurations of the groups the node joined or
+// observes, ordered by name.
+func (s *Service) GroupConfigs() (out []model.ConfigNodeGroup) {
+	s.groups.Range(func(_, value interface{}) bool {
+		out = append(out, value.(*Group).getOption())
+		return true
+	})
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// GroupConfig returns the configuration of the group.
+func (s *Service) GroupConfig(name string) (model.ConfigNodeGroup, error) {
+	v, ok := s.groups.Load(GroupID(name).String())
+	if !ok {
+		return model.ConfigNodeGroup{}, ErrGroupNotFound
+	}
+	return v.(*Group).getOption(), nil
+}
+
+// CreateGroup joins or observes the group and persists its configuration, so
+// the group is restored when the node restarts. Joining an observed group
+// updates it.
+func (s *Service) CreateGroup(o model.ConfigNodeGroup) error {
+	if err := validateGroup(o); err != nil {
+		return err
+	}
+	gid := GroupID(o.Name)
+	if v, ok := s.groups.Load(gid.String()); ok {
+		if v.(*Group).getOption().GType == model.GTypeObserve && o.GType == model.GTypeJoin {
+			return s.UpdateGroup(o)
+		}
+		return ErrGroupExists
+	}
+	if err := s.AddGroup([]model.ConfigNodeGroup{o}); err != nil {
+		return err
+	}
+	return s.store.Put(groupKey(gid), groupOption(o))
+}
+
+// UpdateGroup applies the configuration to the running group and persists
+// it.
+func (s *Service) UpdateGroup(o model.ConfigNodeGroup) error {
+	if err := validateGroup(o); err != nil {
+		return err
+	}
+	gid := GroupID(o.Name)
+	if err := s.updateGroup(gid, o); err != nil {
+		return err
+	}
+	return s.store.Put(groupKey(gid), groupOption(o))
+}
+
+func (s *Service) updateGroup(gid boson.Address, o model.ConfigNodeGroup) error {
+	v, ok := s.groups.Load(gid.String())
+	if !ok {
+		return ErrGroupNotFound
+	}
+	defer s.refreshProtectPeers()
+
+	g := v.(*Group)
+	switch {
+	case g.getOption().GType == model.GTypeJoin && o.GType == model.GTypeObserve:
+		if err := s.leaveGroup(gid); err != nil {
+			return err
+		}
+		return s.observeGroup(gid, o)
+	case g.getOption().GType == model.GTypeObserve && o.GType == model.GTypeJoin:
+		if err := s.joinGroup(gid, o); err != nil {
+			return err
+		}
+	}
+
+	g.setOption(groupOption(o))
+	s.addNodes(g, o.Nodes)
+	go s.discover(g)
+	return nil
+}
+
+// RestoreGroups joins and observes the groups with persisted configurations.
+// The groups the node started with keep the configuration of the config
+// file.
+func (s *Service) RestoreGroups() error {
+	var groups []model.ConfigNodeGroup
+	err := s.store.Iterate(groupKeyPrefix, func(_, value []byte) (bool, error) {
+		var o model.ConfigNodeGroup
+		if err := json.Unmarshal(value, &o); err != nil {
+			return true, err
+		}
+		groups = append(groups, o)
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, o := range groups {
+		if _, ok := s.groups.Load(GroupID(o.Name).String()); ok {
+			s.logger.Debugf("multicast: group %s is configured, persisted configuration ignored", o.Name)
+			continue
+		}
+		if err = s.AddGroup([]model.ConfigNodeGroup{o}); err != nil {
+			return fmt.Errorf("restore group %s: %w", o.Name, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) forgetGroup(gid boson.Address) error {
+	err := s.store.Delete(groupKey(gid))
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// discoveryStatus records the lookups of the peers of a group.
+type discoveryStatus struct {
+	mu      sync.Mutex
+	running bool
+	rounds  uint64
+	last    time.Time
+	found   int
+}
+
+// start records the start of a lookup and returns the function recording its
+// end.
+func (d *discoveryStatus) start(g *Group) func() {
+	peers := func() int {
+		return g.connectedPeers.Length() + g.keepPeers.Length()
+	}
+	before := peers()
+
+	d.mu.Lock()
+	d.running = true
+	d.mu.Unlock()
+
+	return func() {
+		found := peers() - before
+		if found < 0 {
+			found = 0
+		}
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.running = false
+		d.rounds++
+		d.last = time.Now()
+		d.found = found
+	}
+}
+
+// DiscoveryStatus returns the discovery status of the groups, ordered by
+// name.
+func (s *Service) DiscoveryStatus() (out []model.GroupDiscovery) {
+	s.groups.Range(func(_, value interface{}) bool {
+		g := value.(*Group)
+		option := g.getOption()
+		status := model.GroupDiscovery{
+			GroupID:            g.gid,
+			Name:               option.Name,
+			GType:              option.GType,
+			Connected:          g.connectedPeers.Length(),
+			KeepConnectedPeers: option.KeepConnectedPeers,
+			Keep:               g.keepPeers.Length(),
+			KeepPingPeers:      option.KeepPingPeers,
+			Known:              g.knownPeers.Length(),
+		}
+		status.Satisfied = status.Connected >= status.KeepConnectedPeers && status.Keep >= status.KeepPingPeers
+
+		g.discovery.mu.Lock()
+		status.Discovering = g.discovery.running
+		status.Rounds = g.discovery.rounds
+		status.LastDiscovery = g.discovery.last
+		status.LastFound = g.discovery.found
+		g.discovery.mu.Unlock()
+
+		out = append(out, status)
+		return true
+	})
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
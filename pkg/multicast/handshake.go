@@ -36,7 +36,7 @@ func (s *Service) Handshake(ctx context.Context, addr boson.Address) (err error)
 
 	s.logger.Tracef("group: send handshake syn")
 
-	err = w.WriteMsgWithContext(ctx, &pb.GIDs{Gid: GIDs})
+	err = w.WriteMsgWithContext(ctx, &pb.GIDs{Gid: GIDs, Topics: s.getGroupTopics()})
 	if err != nil {
 		s.logger.Errorf("multicast Handshake write %s", err)
 		return err
@@ -66,6 +66,9 @@ func (s *Service) Handshake(ctx context.Context, addr boson.Address) (err error)
 			s.logger.Tracef("group: handshake keep %s with gid %s", addr, gid)
 		}
 	}
+	for _, t := range resp.Topics {
+		s.setPeerTopics(boson.NewAddress(t.Gid), addr, t.Patterns)
+	}
 	return nil
 }
 
@@ -99,12 +102,15 @@ func (s *Service) HandshakeIncoming(ctx context.Context, peer p2p.Peer, stream p
 			s.logger.Tracef("HandshakeIncoming keep %s with gid %s", peer.Address, gid)
 		}
 	}
+	for _, t := range resp.Topics {
+		s.setPeerTopics(boson.NewAddress(t.Gid), peer.Address, t.Patterns)
+	}
 
 	GIDs := s.getGIDsByte()
 
 	s.logger.Tracef("group: send back handshake ack")
 
-	err = w.WriteMsgWithContext(ctx, &pb.GIDs{Gid: GIDs})
+	err = w.WriteMsgWithContext(ctx, &pb.GIDs{Gid: GIDs, Topics: s.getGroupTopics()})
 	if err != nil {
 		s.logger.Errorf("multicast HandshakeIncoming write %s", err)
 		return err
@@ -19,6 +19,7 @@ import (
 	"github.com/FavorLabs/favorX/pkg/p2p/protobuf"
 	"github.com/FavorLabs/favorX/pkg/routetab"
 	"github.com/FavorLabs/favorX/pkg/rpc"
+	"github.com/FavorLabs/favorX/pkg/storage"
 	"github.com/FavorLabs/favorX/pkg/subscribe"
 	"github.com/FavorLabs/favorX/pkg/topology"
 	topModel "github.com/FavorLabs/favorX/pkg/topology/model"
@@ -33,6 +34,7 @@ const (
 	streamFindGroup = "findGroup"
 	streamMulticast = "multicast"
 	streamMessage   = "message"
+	streamTransfer  = "transfer"
 	streamNotify    = "notify"
 
 	handshakeTimeout = time.Second * 15
@@ -46,6 +48,7 @@ type NotifyStatus int
 const (
 	NotifyJoinGroup NotifyStatus = iota + 1
 	NotifyLeaveGroup
+	NotifyTopics
 )
 
 type Service struct {
@@ -57,12 +60,22 @@ type Service struct {
 	logger         logging.Logger
 	kad            topology.Driver
 	route          routetab.RouteTab
+	store          storage.StateStorer
 	connectedPeers sync.Map // key=gid, slice is peer, all is neighbor
 	groups         sync.Map
 	msgSeq         uint64
 	close          chan struct{}
 	sessionStream  sync.Map // key= sessionID, value= *WsStream
 
+	epoch         int64    // start time, resetting the sequences of the reliable messages
+	outboxes      sync.Map // key= gid+dest, value= *outbox
+	multicastSeqs sync.Map // key= gid, value= *sequence
+	orderers      sync.Map // key= stream+sender+gid, value= *orderer
+	peerTopics    sync.Map // key= gid+peer, value= topic patterns of the peer
+	transfers     sync.Map // key= session id, value= *Transfer
+	peerTransfers sync.Map // key= peer+transfer id, value= *Transfer
+	content       storage.Storer
+
 	// logSig    []chan LogContent
 	// logSigMtx sync.Mutex
 
@@ -89,7 +102,10 @@ type Group struct {
 	keepPeers      *pslice.PSlice // Need to maintain the connection with ping
 	knownPeers     *pslice.PSlice
 	srv            *Service
+	optionMu       sync.RWMutex
 	option         model.ConfigNodeGroup
+	discovery      *discoveryStatus
+	topics         *topicSubs
 
 	multicastSub bool
 	groupMsgSub  bool
@@ -98,19 +114,33 @@ type Group struct {
 	groupPeersSending  chan struct{} // whether a goroutine is sending msg. This chan needs to be declared whit "make(chan struct{}, 1)"
 }
 
+func (g *Group) getOption() model.ConfigNodeGroup {
+	g.optionMu.RLock()
+	defer g.optionMu.RUnlock()
+	return g.option
+}
+
+func (g *Group) setOption(o model.ConfigNodeGroup) {
+	g.optionMu.Lock()
+	defer g.optionMu.Unlock()
+	g.option = o
+}
+
+func (g *Group) setGType(t model.GType) {
+	g.optionMu.Lock()
+	defer g.optionMu.Unlock()
+	g.option.GType = t
+}
+
 func (s *Service) newGroup(gid boson.Address, o model.ConfigNodeGroup) *Group {
-	if o.KeepConnectedPeers < 0 {
-		o.KeepConnectedPeers = 0
-	}
-	if o.KeepPingPeers < 0 {
-		o.KeepPingPeers = 0
-	}
 	g := &Group{
 		gid:        gid,
 		keepPeers:  pslice.New(1, s.self),
 		knownPeers: pslice.New(1, s.self),
 		srv:        s,
-		option:     o,
+		option:     groupOption(o),
+		discovery:  &discoveryStatus{},
+		topics:     newTopicSubs(),
 
 		groupPeersLastSend: time.Now(),
 		groupPeersSending:  make(chan struct{}, 1),
@@ -124,11 +154,37 @@ func (s *Service) newGroup(gid boson.Address, o model.ConfigNodeGroup) *Group {
 	return g
 }
 
+// groupOption returns the option with the peers to keep clamped to zero.
+func groupOption(o model.ConfigNodeGroup) model.ConfigNodeGroup {
+	if o.KeepConnectedPeers < 0 {
+		o.KeepConnectedPeers = 0
+	}
+	if o.KeepPingPeers < 0 {
+		o.KeepPingPeers = 0
+	}
+	return o
+}
+
+// addNodes adds the configured nodes of the group to its connected or known
+// peers.
+func (s *Service) addNodes(g *Group, nodes []boson.Address) {
+	for _, addr := range nodes {
+		if addr.Equal(s.self) {
+			continue
+		}
+		if s.route.IsNeighbor(addr) {
+			g.connectedPeers.Add(addr)
+		} else {
+			g.knownPeers.Add(addr)
+		}
+	}
+}
+
 type Option struct {
 	Dev bool
 }
 
-func NewService(self boson.Address, nodeMode address.Model, service p2p.Service, streamer p2p.Streamer, kad topology.Driver, route routetab.RouteTab, logger logging.Logger, subPub subscribe.SubPub, o Option) *Service {
+func NewService(self boson.Address, nodeMode address.Model, service p2p.Service, streamer p2p.Streamer, kad topology.Driver, route routetab.RouteTab, stateStore storage.StateStorer, logger logging.Logger, subPub subscribe.SubPub, o Option) *Service {
 	srv := &Service{
 		o:        o,
 		nodeMode: nodeMode,
@@ -138,8 +194,10 @@ func NewService(self boson.Address, nodeMode address.Model, service p2p.Service,
 		logger:   logger,
 		kad:      kad,
 		route:    route,
+		store:    stateStore,
 		subPub:   subPub,
 		close:    make(chan struct{}, 1),
+		epoch:    time.Now().UnixNano(),
 	}
 	return srv
 }
@@ -169,6 +227,10 @@ func (s *Service) Protocol() p2p.ProtocolSpec {
 				Name:    streamMessage,
 				Handler: s.onMessage,
 			},
+			{
+				Name:    streamTransfer,
+				Handler: s.onTransfer,
+			},
 		},
 	}
 }
@@ -314,6 +376,9 @@ func (s *Service) connectedRemoveFromGroup(gid boson.Address, peers ...boson.Add
 }
 
 func (s *Service) leaveConnectedAll(peers ...boson.Address) {
+	for _, v := range peers {
+		s.forgetPeerTopics(v)
+	}
 	s.connectedPeers.Range(func(key, value interface{}) bool {
 		conn := value.(*pslice.PSlice)
 		for _, v := range peers {
@@ -339,7 +404,7 @@ func (s *Service) getGIDsByte() [][]byte {
 	s.groups.Range(func(key, value interface{}) bool {
 		gid := boson.MustParseHexAddress(gconv.String(key))
 		g := value.(*Group)
-		if g.option.GType == model.GTypeJoin {
+		if g.getOption().GType == model.GTypeJoin {
 			GIDs = append(GIDs, gid.Bytes())
 		}
 		return true
@@ -347,11 +412,20 @@ func (s *Service) getGIDsByte() [][]byte {
 	return GIDs
 }
 
+// Multicast sends the message to the group. The messages the node originates
+// get an id, and reliable ones a sequence and a delivery status.
 func (s *Service) Multicast(info *pb.MulticastMsg, skip ...boson.Address) error {
+	gid := boson.NewAddress(info.Gid)
+	var d *delivery
 	if len(info.Origin) == 0 {
 		info.CreateTime = time.Now().UnixMilli()
 		info.Origin = s.self.Bytes()
 		info.Id = atomic.AddUint64(&s.msgSeq, 1)
+		if info.Reliable {
+			info.Epoch = s.epoch
+			info.Seq, info.Base = s.multicastSequence(gid).next()
+			d = s.newDelivery(info.Id, gid, boson.ZeroAddress, info.Seq, nil)
+		}
 	}
 	origin := boson.NewAddress(info.Origin)
 
@@ -364,8 +438,6 @@ func (s *Service) Multicast(info *pb.MulticastMsg, skip ...boson.Address) error
 		return nil
 	}
 
-	gid := boson.NewAddress(info.Gid)
-
 	s.logger.Tracef("multicast deliver: %s data=%v", key, info.Data)
 	s.notifyLogContent(LogContent{
 		Event: "multicast_deliver",
@@ -385,27 +457,23 @@ func (s *Service) Multicast(info *pb.MulticastMsg, skip ...boson.Address) error
 		if v.connectedPeers.Length() == 0 && v.keepPeers.Length() == 0 {
 			s.discover(v)
 		}
-		if v.connectedPeers.Length() == 0 && v.keepPeers.Length() == 0 {
-			return nil
-		}
 		// An isolated node within the group
-		send := func(address boson.Address, u uint8) (stop, jumpToNext bool, err error) {
+		var peers []boson.Address
+		add := func(address boson.Address, u uint8) (stop, jumpToNext bool, err error) {
 			if !address.MemberOf(skip) {
-				_ = s.sendData(context.Background(), address, streamMulticast, info)
+				peers = append(peers, address)
 			}
 			return false, false, nil
 		}
-		_ = v.connectedPeers.EachBin(send)
-		_ = v.keepPeers.EachBin(send)
+		_ = v.connectedPeers.EachBin(add)
+		_ = v.keepPeers.EachBin(add)
+		s.sendMulticast(info, peers, d)
 		return nil
 	}
 
-	nodes := s.getForwardNodes(gid, skip...)
+	nodes := s.getForwardNodes(gid, info.Topic, skip...)
 	s.logger.Tracef("multicast got forward %d nodes", len(nodes))
-	for _, v := range nodes {
-		s.logger.Tracef("multicast forward to %s", v)
-		_ = s.sendData(context.Background(), v, streamMulticast, info)
-	}
+	s.sendMulticast(info, nodes, d)
 	return nil
 }
 
@@ -423,6 +491,14 @@ func (s *Service) onMulticast(ctx context.Context, peer p2p.Peer, stream p2p.Str
 	if err != nil {
 		return err
 	}
+	if info.Reliable {
+		// duplicates are acknowledged too, as the sender retransmits until
+		// it gets an acknowledgement
+		err = protobuf.NewWriter(stream).WriteMsgWithContext(ctx, &pb.Ack{Id: info.Id})
+		if err != nil {
+			return err
+		}
+	}
 
 	origin := boson.NewAddress(info.Origin)
 
@@ -446,6 +522,7 @@ func (s *Service) onMulticast(ctx context.Context, peer p2p.Peer, stream p2p.Str
 		Origin:     origin,
 		Data:       info.Data,
 		From:       peer.Address,
+		Topic:      info.Topic,
 	}
 
 	s.logger.Tracef("multicast receive: %s data=%v", key, info.Data)
@@ -453,9 +530,15 @@ func (s *Service) onMulticast(ctx context.Context, peer p2p.Peer, stream p2p.Str
 
 	notifyLog := true
 	g, ok := s.groups.Load(gid.String())
-	if ok && g.(*Group).option.GType == model.GTypeJoin {
+	if ok && g.(*Group).getOption().GType == model.GTypeJoin {
 		notifyLog = false
-		_ = s.notifyMulticast(gid, msg)
+		if info.Reliable {
+			s.orderer(streamMulticast, origin, gid).push(info.Epoch, info.Seq, info.Base, func() {
+				_ = s.notifyMulticast(gid, msg)
+			})
+		} else {
+			_ = s.notifyMulticast(gid, msg)
+		}
 		s.logger.Tracef("%s-multicast receive %s from %s", gid, key, peer.Address)
 	}
 	if notifyLog {
@@ -499,16 +582,7 @@ func (s *Service) observeGroup(gid boson.Address, option model.ConfigNodeGroup)
 		g = s.newGroup(gid, option)
 		s.groups.Store(gid.String(), g)
 	}
-	for _, addr := range option.Nodes {
-		if addr.Equal(s.self) {
-			continue
-		}
-		if s.route.IsNeighbor(addr) {
-			g.connectedPeers.Add(addr)
-		} else {
-			g.knownPeers.Add(addr)
-		}
-	}
+	s.addNodes(g, option.Nodes)
 	go s.discover(g)
 	return nil
 }
@@ -519,7 +593,7 @@ func (s *Service) observeGroupCancel(gid boson.Address) error {
 		return errors.New("group not found")
 	}
 	g := v.(*Group)
-	if g.option.GType == model.GTypeObserve {
+	if g.getOption().GType == model.GTypeObserve {
 		s.groups.Delete(gid.String())
 	}
 	return nil
@@ -531,27 +605,18 @@ func (s *Service) joinGroup(gid boson.Address, option model.ConfigNodeGroup) err
 	value, ok := s.groups.Load(gid.String())
 	if ok {
 		g = value.(*Group)
-		if g.option.GType == model.GTypeJoin {
+		if g.getOption().GType == model.GTypeJoin {
 			return errors.New("it's already in the group")
 		}
 	} else {
 		g = s.newGroup(gid, option)
 		s.groups.Store(gid.String(), g)
 	}
-	if g.option.GType == model.GTypeObserve {
+	if g.getOption().GType == model.GTypeObserve {
 		// observe group join group
-		g.option.GType = model.GTypeJoin
-	}
-	for _, v := range option.Nodes {
-		if v.Equal(s.self) {
-			continue
-		}
-		if s.route.IsNeighbor(v) {
-			g.connectedPeers.Add(v)
-		} else {
-			g.knownPeers.Add(v)
-		}
+		g.setGType(model.GTypeJoin)
 	}
+	s.addNodes(g, option.Nodes)
 
 	go s.notify(&pb.Notify{
 		Status: int32(NotifyJoinGroup),
@@ -568,9 +633,10 @@ func (s *Service) SubscribeMulticastMsg(n *rpc.Notifier, sub *rpc.Subscription,
 	value, ok := s.groups.Load(gid.String())
 	if ok {
 		g = value.(*Group)
-		if g.option.GType == model.GTypeJoin && g.multicastSub == false {
+		if g.getOption().GType == model.GTypeJoin && g.multicastSub == false {
 			g.multicastSub = true
 			_ = s.subPub.Subscribe(notifier, "group", "multicastMsg", gid.String())
+			s.watchTopics(g, sub, []string{allTopics})
 			return nil
 		}
 		if g.multicastSub == true {
@@ -607,7 +673,9 @@ func (s *Service) AddGroup(groups []model.ConfigNodeGroup) error {
 	return nil
 }
 
-func (s *Service) RemoveGroup(group string, gType model.GType) error {
+// RemoveGroup leaves or stops observing the group and forgets its persisted
+// configuration.
+func (s *Service) RemoveGroup(group string, gType model.GType) (err error) {
 	gid, err := boson.ParseHexAddress(group)
 	if err != nil {
 		gid = GenerateGID(group)
@@ -615,12 +683,20 @@ func (s *Service) RemoveGroup(group string, gType model.GType) error {
 	defer s.refreshProtectPeers()
 	switch gType {
 	case model.GTypeObserve:
-		return s.observeGroupCancel(gid)
+		err = s.observeGroupCancel(gid)
 	case model.GTypeJoin:
-		return s.leaveGroup(gid)
+		err = s.leaveGroup(gid)
 	default:
 		return errors.New("gType not support")
 	}
+	if err != nil {
+		return err
+	}
+	if _, ok := s.groups.Load(gid.String()); ok {
+		// a joined group is not cancelled by observing it
+		return nil
+	}
+	return s.forgetGroup(gid)
 }
 
 // LeaveGroup For yourself
@@ -641,6 +717,7 @@ func (s *Service) leaveGroup(gid boson.Address) error {
 		return true
 	})
 	s.groups.Delete(gid.String())
+	s.forgetOrderers(gid)
 
 	go s.notify(&pb.Notify{
 		Status: int32(NotifyLeaveGroup),
@@ -706,6 +783,7 @@ func (s *Service) onNotify(ctx context.Context, peer p2p.Peer, stream p2p.Stream
 		for _, v := range msg.Gids {
 			gid := boson.NewAddress(v)
 			s.connectedRemoveFromGroup(gid, peer.Address)
+			s.forgetPeerTopics(peer.Address, gid)
 			s.logger.Tracef("onNotify remove connected %s with gid %s", peer.Address, gid)
 			value, ok := s.groups.Load(gid.String())
 			if ok {
@@ -714,6 +792,12 @@ func (s *Service) onNotify(ctx context.Context, peer p2p.Peer, stream p2p.Stream
 				g.knownPeers.Remove(peer.Address)
 			}
 		}
+	case NotifyTopics:
+		for _, v := range msg.Gids {
+			gid := boson.NewAddress(v)
+			s.setPeerTopics(gid, peer.Address, msg.Topics)
+			s.logger.Tracef("onNotify %s topics %v with gid %s", peer.Address, msg.Topics, gid)
+		}
 	default:
 		return errors.New("notify status invalid")
 	}
@@ -768,7 +852,7 @@ func (s *Service) getModelGroupInfo() (out []*model.GroupInfo) {
 		v := value.(*Group)
 		out = append(out, &model.GroupInfo{
 			GroupID:   v.gid,
-			Option:    v.option,
+			Option:    v.getOption(),
 			KeepPeers: s.getOptimumPeers(peersFunc(v.keepPeers)),
 			KnowPeers: s.getOptimumPeers(peersFunc(v.knownPeers)),
 		})
@@ -854,7 +938,7 @@ func (s *Service) getStream(ctx context.Context, dest boson.Address, streamName
 	return
 }
 
-func (s *Service) Send(ctx context.Context, data []byte, gid, dest boson.Address) (err error) {
+func (s *Service) Send(ctx context.Context, data []byte, gid, dest boson.Address, topic string) (err error) {
 	var stream p2p.Stream
 	stream, err = s.getStream(ctx, dest, streamMessage)
 	if err != nil {
@@ -868,9 +952,10 @@ func (s *Service) Send(ctx context.Context, data []byte, gid, dest boson.Address
 		}
 	}()
 	req := &pb.GroupMsg{
-		Gid:  gid.Bytes(),
-		Data: data,
-		Type: int32(SendOnly),
+		Gid:   gid.Bytes(),
+		Data:  data,
+		Type:  int32(SendOnly),
+		Topic: topic,
 	}
 	w, r := protobuf.NewWriterAndReader(stream)
 	err = w.WriteMsgWithContext(ctx, req)
@@ -890,7 +975,7 @@ func (s *Service) Send(ctx context.Context, data []byte, gid, dest boson.Address
 	return nil
 }
 
-func (s *Service) SendReceive(ctx context.Context, data []byte, gid, dest boson.Address) (result []byte, err error) {
+func (s *Service) SendReceive(ctx context.Context, data []byte, gid, dest boson.Address, topic string) (result []byte, err error) {
 	var stream p2p.Stream
 	stream, err = s.getStream(ctx, dest, streamMessage)
 	if err != nil {
@@ -904,9 +989,10 @@ func (s *Service) SendReceive(ctx context.Context, data []byte, gid, dest boson.
 		}
 	}()
 	req := &pb.GroupMsg{
-		Gid:  gid.Bytes(),
-		Data: data,
-		Type: int32(SendReceive),
+		Gid:   gid.Bytes(),
+		Data:  data,
+		Type:  int32(SendReceive),
+		Topic: topic,
 	}
 	w, r := protobuf.NewWriterAndReader(stream)
 	err = w.WriteMsgWithContext(ctx, req)
@@ -977,11 +1063,15 @@ func (s *Service) onMessage(ctx context.Context, peer p2p.Peer, stream p2p.Strea
 		_ = stream.Reset()
 		return err
 	}
+	if SendOption(info.Type) == SendReliable {
+		return s.onReliableMessage(ctx, peer, stream, info)
+	}
 
 	msg := GroupMessage{
-		GID:  boson.NewAddress(info.Gid),
-		Data: info.Data,
-		From: peer.Address,
+		GID:   boson.NewAddress(info.Gid),
+		Data:  info.Data,
+		From:  peer.Address,
+		Topic: info.Topic,
 	}
 
 	st := &WsStream{
@@ -998,12 +1088,16 @@ func (s *Service) onMessage(ctx context.Context, peer p2p.Peer, stream p2p.Strea
 	)
 	s.groups.Range(func(_, value interface{}) bool {
 		g := value.(*Group)
-		if g.gid.Equal(msg.GID) && g.option.GType == model.GTypeJoin {
+		if g.gid.Equal(msg.GID) && g.getOption().GType == model.GTypeJoin {
 			haveNotify = true
 			if g.groupMsgSub == false {
 				notifyErr = fmt.Errorf("target not subscribe the group message")
 				return false
 			}
+			if !g.subscribedTopic(msg.Topic) {
+				notifyErr = fmt.Errorf("target not subscribe the topic")
+				return false
+			}
 			_ = s.notifyMessage(g, msg, st)
 			return false
 		}
@@ -1033,9 +1127,10 @@ func (s *Service) SubscribeGroupMessage(n *rpc.Notifier, sub *rpc.Subscription,
 	value, ok := s.groups.Load(gid.String())
 	if ok {
 		g = value.(*Group)
-		if g.option.GType == model.GTypeJoin {
+		if g.getOption().GType == model.GTypeJoin {
 			g.groupMsgSub = true
 			_ = s.subPub.Subscribe(notifier, "group", "groupMessage", gid.String())
+			s.watchTopics(g, sub, []string{allTopics})
 			return nil
 		}
 	}
@@ -1100,6 +1195,12 @@ func (s *Service) notifyMessage(g *Group, msg GroupMessage, st *WsStream) (e err
 }
 
 func (s *Service) replyGroupMessage(sessionID string, data []byte) (err error) {
+	if t, err := s.GetTransfer(sessionID); err == nil {
+		if _, err = t.Write(data); err != nil {
+			return err
+		}
+		return t.CloseWrite()
+	}
 	v, ok := s.sessionStream.Load(rpc.ID(sessionID))
 	if !ok {
 		s.logger.Tracef("group: sessionID %s reply err invalid or has expired", sessionID)
@@ -35,6 +35,24 @@ type KadParams struct {
 	ConnectedInfo []*ConnectedInfo `json:"connectedInfo"` // connected info
 }
 
+// GroupDiscovery is the discovery status of a group: the peers it keeps
+// against the configured ones and the outcome of the last lookup.
+type GroupDiscovery struct {
+	GroupID            boson.Address `json:"gid"`
+	Name               string        `json:"name"`
+	GType              GType         `json:"type"`
+	Connected          int           `json:"connected"`
+	KeepConnectedPeers int           `json:"keepConnectedPeers"`
+	Keep               int           `json:"keep"`
+	KeepPingPeers      int           `json:"keepPingPeers"`
+	Known              int           `json:"known"`
+	Satisfied          bool          `json:"satisfied"`
+	Discovering        bool          `json:"discovering"`
+	Rounds             uint64        `json:"rounds"`
+	LastDiscovery      time.Time     `json:"lastDiscovery"`
+	LastFound          int           `json:"lastFound"`
+}
+
 type ConfigNetDomain struct {
 	Domain string `json:"domain"`
 	Addr   string `json:"addr"`
@@ -55,3 +73,28 @@ const (
 	GTypeJoin GType = iota
 	GTypeObserve
 )
+
+type DeliveryState string
+
+const (
+	DeliveryPending   DeliveryState = "pending"
+	DeliveryDelivered DeliveryState = "delivered"
+	DeliveryFailed    DeliveryState = "failed"
+)
+
+// Delivery is the delivery status of a reliable message. The target of a
+// multicast message is zero and its peers are the ones the message was sent
+// to in the first hop.
+type Delivery struct {
+	ID       uint64        `json:"id"`
+	GroupID  boson.Address `json:"gid"`
+	Target   boson.Address `json:"target"`
+	Seq      uint64        `json:"seq"`
+	State    DeliveryState `json:"state"`
+	Attempts int           `json:"attempts"`
+	Peers    int           `json:"peers"`
+	Acked    int           `json:"acked"`
+	Error    string        `json:"error,omitempty"`
+	Created  time.Time     `json:"created"`
+	Updated  time.Time     `json:"updated"`
+}
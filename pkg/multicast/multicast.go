@@ -2,6 +2,7 @@ package multicast
 
 import (
 	"context"
+	"io"
 
 	"github.com/FavorLabs/favorX/pkg/boson"
 	"github.com/FavorLabs/favorX/pkg/multicast/model"
@@ -13,15 +14,25 @@ type GroupInterface interface {
 	Multicast(info *pb.MulticastMsg, skip ...boson.Address) error
 	AddGroup(groups []model.ConfigNodeGroup) error
 	RemoveGroup(group string, gType model.GType) error
+	GroupConfigs() []model.ConfigNodeGroup
+	GroupConfig(name string) (model.ConfigNodeGroup, error)
+	CreateGroup(o model.ConfigNodeGroup) error
+	UpdateGroup(o model.ConfigNodeGroup) error
 	Snapshot() *model.KadParams
 	StartDiscover()
 	SubscribeLogContent(n *rpc.Notifier, sub *rpc.Subscription)
 	SubscribeMulticastMsg(n *rpc.Notifier, sub *rpc.Subscription, gid boson.Address) (err error)
+	SubscribeMulticastTopics(n *rpc.Notifier, sub *rpc.Subscription, gid boson.Address, patterns []string) error
+	SubscribeGroupMessageTopics(n *rpc.Notifier, sub *rpc.Subscription, gid boson.Address, patterns []string) error
 	GetGroupPeers(groupName string) (out *GroupPeers, err error)
 	GetOptimumPeer(groupName string) (peer boson.Address, err error)
 	GetSendStream(ctx context.Context, gid, dest boson.Address) (out SendStreamCh, err error)
-	SendReceive(ctx context.Context, data []byte, gid, dest boson.Address) (result []byte, err error)
-	Send(ctx context.Context, data []byte, gid, dest boson.Address) (err error)
+	SendReceive(ctx context.Context, data []byte, gid, dest boson.Address, topic string) (result []byte, err error)
+	Send(ctx context.Context, data []byte, gid, dest boson.Address, topic string) (err error)
+	SendReliable(data []byte, gid, dest boson.Address, topic string) (id uint64)
+	DeliveryStatus(gid boson.Address, id uint64) (model.Delivery, error)
+	OpenTransfer(ctx context.Context, gid, dest boson.Address, topic, id string, content io.Reader) (*Transfer, error)
+	GetTransfer(session string) (*Transfer, error)
 }
 
 // Message multicast message
@@ -32,6 +43,7 @@ type Message struct {
 	Origin     boson.Address
 	Data       []byte
 	From       boson.Address
+	Topic      string
 }
 
 type GroupMessage struct {
@@ -39,6 +51,8 @@ type GroupMessage struct {
 	GID       boson.Address `json:"gid"`
 	Data      []byte        `json:"data"`
 	From      boson.Address `json:"from"`
+	Topic     string        `json:"topic,omitempty"`
+	Stream    bool          `json:"stream,omitempty"` // the data is streamed with the session id
 }
 
 type LogContent struct {
@@ -58,6 +72,7 @@ const (
 	SendOnly SendOption = iota
 	SendReceive
 	SendStream
+	SendReliable
 )
 
 func (s SendOption) String() string {
@@ -68,6 +83,8 @@ func (s SendOption) String() string {
 		return "SendReceive"
 	case SendStream:
 		return "SendStream"
+	case SendReliable:
+		return "SendReliable"
 	default:
 		return ""
 	}
@@ -1,6 +1,7 @@
 package multicast
 
 import (
+	"errors"
 	"io"
 	"testing"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/FavorLabs/favorX/pkg/logging"
 	"github.com/FavorLabs/favorX/pkg/multicast/model"
 	mockRoute "github.com/FavorLabs/favorX/pkg/routetab/mock"
+	mockstate "github.com/FavorLabs/favorX/pkg/statestore/mock"
 	"github.com/FavorLabs/favorX/pkg/subscribe"
 	"github.com/FavorLabs/favorX/pkg/topology/kademlia/mock"
 	"github.com/ethereum/go-ethereum/common"
@@ -46,7 +48,7 @@ func TestService_ObserveGroup(t *testing.T) {
 	gid := GenerateGID("gid1")
 	route := mockRoute.NewMockRouteTable()
 	kad := mock.NewMockKademlia()
-	s := NewService(test.RandomAddress(), address.NewModel(), nil, nil, kad, &route, logger, subscribe.NewSubPub(), Option{Dev: true})
+	s := NewService(test.RandomAddress(), address.NewModel(), nil, nil, kad, &route, mockstate.NewStateStore(), logger, subscribe.NewSubPub(), Option{Dev: true})
 	err := s.observeGroup(gid, model.ConfigNodeGroup{})
 	if err != nil {
 		t.Fatal(err)
@@ -56,3 +58,81 @@ func TestService_ObserveGroup(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestService_PersistGroups(t *testing.T) {
+	route := mockRoute.NewMockRouteTable()
+	kad := mock.NewMockKademlia()
+	store := mockstate.NewStateStore()
+	newService := func() *Service {
+		return NewService(test.RandomAddress(), address.NewModel(), nil, nil, kad, &route, store, logger, subscribe.NewSubPub(), Option{Dev: true})
+	}
+
+	s := newService()
+	o := model.ConfigNodeGroup{Name: "team", GType: model.GTypeObserve, KeepConnectedPeers: 1}
+	if err := s.CreateGroup(o); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateGroup(o); !errors.Is(err, ErrGroupExists) {
+		t.Fatalf("got error %v, want %v", err, ErrGroupExists)
+	}
+	if err := s.CreateGroup(model.ConfigNodeGroup{GType: model.GTypeJoin}); !errors.Is(err, ErrInvalidGroup) {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidGroup)
+	}
+
+	// joining an observed group updates it
+	o.GType = model.GTypeJoin
+	o.KeepPingPeers = 2
+	if err := s.CreateGroup(o); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GroupConfig("team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GType != model.GTypeJoin || got.KeepPingPeers != 2 {
+		t.Fatalf("got group %+v, want %+v", got, o)
+	}
+	if err := s.UpdateGroup(model.ConfigNodeGroup{Name: "other", GType: model.GTypeJoin}); !errors.Is(err, ErrGroupNotFound) {
+		t.Fatalf("got error %v, want %v", err, ErrGroupNotFound)
+	}
+
+	status := s.DiscoveryStatus()
+	if len(status) != 1 || status[0].Name != "team" || !status[0].GroupID.Equal(GroupID("team")) {
+		t.Fatalf("got discovery status %+v", status)
+	}
+
+	restored := newService()
+	if err := restored.RestoreGroups(); err != nil {
+		t.Fatal(err)
+	}
+	configs := restored.GroupConfigs()
+	if len(configs) != 1 || configs[0].Name != "team" || configs[0].GType != model.GTypeJoin || configs[0].KeepPingPeers != 2 {
+		t.Fatalf("got restored groups %+v", configs)
+	}
+
+	// the groups of the config file take precedence
+	configured := newService()
+	if err := configured.AddGroup([]model.ConfigNodeGroup{{Name: "team", GType: model.GTypeObserve, KeepConnectedPeers: 3}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := configured.RestoreGroups(); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := configured.GroupConfig("team"); err != nil || got.GType != model.GTypeObserve || got.KeepConnectedPeers != 3 {
+		t.Fatalf("got configured group %+v, %v", got, err)
+	}
+
+	if err := restored.RemoveGroup("team", model.GTypeJoin); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := restored.GroupConfig("team"); !errors.Is(err, ErrGroupNotFound) {
+		t.Fatalf("got error %v, want %v", err, ErrGroupNotFound)
+	}
+	last := newService()
+	if err := last.RestoreGroups(); err != nil {
+		t.Fatal(err)
+	}
+	if configs := last.GroupConfigs(); len(configs) != 0 {
+		t.Fatalf("got groups %+v after removal", configs)
+	}
+}
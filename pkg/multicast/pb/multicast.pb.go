@@ -23,7 +23,8 @@ var _ = math.Inf
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
 type GIDs struct {
-	Gid [][]byte `protobuf:"bytes,1,rep,name=gid,proto3" json:"gid,omitempty"`
+	Gid    [][]byte  `protobuf:"bytes,1,rep,name=gid,proto3" json:"gid,omitempty"`
+	Topics []*Topics `protobuf:"bytes,2,rep,name=topics,proto3" json:"topics,omitempty"`
 }
 
 func (m *GIDs) Reset()         { *m = GIDs{} }
@@ -66,6 +67,13 @@ func (m *GIDs) GetGid() [][]byte {
 	return nil
 }
 
+func (m *GIDs) GetTopics() []*Topics {
+	if m != nil {
+		return m.Topics
+	}
+	return nil
+}
+
 type FindGroupReq struct {
 	Gid   []byte   `protobuf:"bytes,1,opt,name=gid,proto3" json:"gid,omitempty"`
 	Limit int32    `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
@@ -184,6 +192,11 @@ type MulticastMsg struct {
 	Origin     []byte `protobuf:"bytes,3,opt,name=origin,proto3" json:"origin,omitempty"`
 	Gid        []byte `protobuf:"bytes,4,opt,name=gid,proto3" json:"gid,omitempty"`
 	Data       []byte `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+	Seq        uint64 `protobuf:"varint,6,opt,name=seq,proto3" json:"seq,omitempty"`
+	Base       uint64 `protobuf:"varint,7,opt,name=base,proto3" json:"base,omitempty"`
+	Epoch      int64  `protobuf:"varint,8,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Reliable   bool   `protobuf:"varint,9,opt,name=reliable,proto3" json:"reliable,omitempty"`
+	Topic      string `protobuf:"bytes,10,opt,name=topic,proto3" json:"topic,omitempty"`
 }
 
 func (m *MulticastMsg) Reset()         { *m = MulticastMsg{} }
@@ -254,9 +267,45 @@ func (m *MulticastMsg) GetData() []byte {
 	return nil
 }
 
+func (m *MulticastMsg) GetSeq() uint64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *MulticastMsg) GetBase() uint64 {
+	if m != nil {
+		return m.Base
+	}
+	return 0
+}
+
+func (m *MulticastMsg) GetEpoch() int64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *MulticastMsg) GetReliable() bool {
+	if m != nil {
+		return m.Reliable
+	}
+	return false
+}
+
+func (m *MulticastMsg) GetTopic() string {
+	if m != nil {
+		return m.Topic
+	}
+	return ""
+}
+
 type Notify struct {
 	Status int32    `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
 	Gids   [][]byte `protobuf:"bytes,2,rep,name=gids,proto3" json:"gids,omitempty"`
+	Topics []string `protobuf:"bytes,3,rep,name=topics,proto3" json:"topics,omitempty"`
 }
 
 func (m *Notify) Reset()         { *m = Notify{} }
@@ -306,11 +355,22 @@ func (m *Notify) GetGids() [][]byte {
 	return nil
 }
 
+func (m *Notify) GetTopics() []string {
+	if m != nil {
+		return m.Topics
+	}
+	return nil
+}
+
 type GroupMsg struct {
-	Gid  []byte `protobuf:"bytes,1,opt,name=gid,proto3" json:"gid,omitempty"`
-	Data []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
-	Type int32  `protobuf:"varint,3,opt,name=type,proto3" json:"type,omitempty"`
-	Err  string `protobuf:"bytes,4,opt,name=err,proto3" json:"err,omitempty"`
+	Gid   []byte `protobuf:"bytes,1,opt,name=gid,proto3" json:"gid,omitempty"`
+	Data  []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Type  int32  `protobuf:"varint,3,opt,name=type,proto3" json:"type,omitempty"`
+	Err   string `protobuf:"bytes,4,opt,name=err,proto3" json:"err,omitempty"`
+	Seq   uint64 `protobuf:"varint,5,opt,name=seq,proto3" json:"seq,omitempty"`
+	Base  uint64 `protobuf:"varint,6,opt,name=base,proto3" json:"base,omitempty"`
+	Epoch int64  `protobuf:"varint,7,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Topic string `protobuf:"bytes,8,opt,name=topic,proto3" json:"topic,omitempty"`
 }
 
 func (m *GroupMsg) Reset()         { *m = GroupMsg{} }
@@ -374,6 +434,290 @@ func (m *GroupMsg) GetErr() string {
 	return ""
 }
 
+func (m *GroupMsg) GetSeq() uint64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *GroupMsg) GetBase() uint64 {
+	if m != nil {
+		return m.Base
+	}
+	return 0
+}
+
+func (m *GroupMsg) GetEpoch() int64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *GroupMsg) GetTopic() string {
+	if m != nil {
+		return m.Topic
+	}
+	return ""
+}
+
+type Ack struct {
+	Id uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (m *Ack) Reset()         { *m = Ack{} }
+func (m *Ack) String() string { return proto.CompactTextString(m) }
+func (*Ack) ProtoMessage()    {}
+func (*Ack) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eedbde62517e047e, []int{6}
+}
+func (m *Ack) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Ack) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Ack.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Ack) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Ack.Merge(m, src)
+}
+func (m *Ack) XXX_Size() int {
+	return m.Size()
+}
+func (m *Ack) XXX_DiscardUnknown() {
+	xxx_messageInfo_Ack.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Ack proto.InternalMessageInfo
+
+func (m *Ack) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+type Topics struct {
+	Gid      []byte   `protobuf:"bytes,1,opt,name=gid,proto3" json:"gid,omitempty"`
+	Patterns []string `protobuf:"bytes,2,rep,name=patterns,proto3" json:"patterns,omitempty"`
+}
+
+func (m *Topics) Reset()         { *m = Topics{} }
+func (m *Topics) String() string { return proto.CompactTextString(m) }
+func (*Topics) ProtoMessage()    {}
+func (*Topics) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eedbde62517e047e, []int{7}
+}
+func (m *Topics) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Topics) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Topics.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Topics) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Topics.Merge(m, src)
+}
+func (m *Topics) XXX_Size() int {
+	return m.Size()
+}
+func (m *Topics) XXX_DiscardUnknown() {
+	xxx_messageInfo_Topics.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Topics proto.InternalMessageInfo
+
+func (m *Topics) GetGid() []byte {
+	if m != nil {
+		return m.Gid
+	}
+	return nil
+}
+
+func (m *Topics) GetPatterns() []string {
+	if m != nil {
+		return m.Patterns
+	}
+	return nil
+}
+
+type Transfer struct {
+	Gid       []byte `protobuf:"bytes,1,opt,name=gid,proto3" json:"gid,omitempty"`
+	Id        string `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Topic     string `protobuf:"bytes,3,opt,name=topic,proto3" json:"topic,omitempty"`
+	Reference []byte `protobuf:"bytes,4,opt,name=reference,proto3" json:"reference,omitempty"`
+	Offset    uint64 `protobuf:"varint,5,opt,name=offset,proto3" json:"offset,omitempty"`
+	Err       string `protobuf:"bytes,6,opt,name=err,proto3" json:"err,omitempty"`
+}
+
+func (m *Transfer) Reset()         { *m = Transfer{} }
+func (m *Transfer) String() string { return proto.CompactTextString(m) }
+func (*Transfer) ProtoMessage()    {}
+func (*Transfer) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eedbde62517e047e, []int{8}
+}
+func (m *Transfer) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Transfer) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Transfer.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Transfer) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Transfer.Merge(m, src)
+}
+func (m *Transfer) XXX_Size() int {
+	return m.Size()
+}
+func (m *Transfer) XXX_DiscardUnknown() {
+	xxx_messageInfo_Transfer.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Transfer proto.InternalMessageInfo
+
+func (m *Transfer) GetGid() []byte {
+	if m != nil {
+		return m.Gid
+	}
+	return nil
+}
+
+func (m *Transfer) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Transfer) GetTopic() string {
+	if m != nil {
+		return m.Topic
+	}
+	return ""
+}
+
+func (m *Transfer) GetReference() []byte {
+	if m != nil {
+		return m.Reference
+	}
+	return nil
+}
+
+func (m *Transfer) GetOffset() uint64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *Transfer) GetErr() string {
+	if m != nil {
+		return m.Err
+	}
+	return ""
+}
+
+type Frame struct {
+	Offset uint64 `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	Data   []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Eof    bool   `protobuf:"varint,3,opt,name=eof,proto3" json:"eof,omitempty"`
+	Ack    uint64 `protobuf:"varint,4,opt,name=ack,proto3" json:"ack,omitempty"`
+	Err    string `protobuf:"bytes,5,opt,name=err,proto3" json:"err,omitempty"`
+}
+
+func (m *Frame) Reset()         { *m = Frame{} }
+func (m *Frame) String() string { return proto.CompactTextString(m) }
+func (*Frame) ProtoMessage()    {}
+func (*Frame) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eedbde62517e047e, []int{9}
+}
+func (m *Frame) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Frame) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Frame.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Frame) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Frame.Merge(m, src)
+}
+func (m *Frame) XXX_Size() int {
+	return m.Size()
+}
+func (m *Frame) XXX_DiscardUnknown() {
+	xxx_messageInfo_Frame.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Frame proto.InternalMessageInfo
+
+func (m *Frame) GetOffset() uint64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *Frame) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *Frame) GetEof() bool {
+	if m != nil {
+		return m.Eof
+	}
+	return false
+}
+
+func (m *Frame) GetAck() uint64 {
+	if m != nil {
+		return m.Ack
+	}
+	return 0
+}
+
+func (m *Frame) GetErr() string {
+	if m != nil {
+		return m.Err
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*GIDs)(nil), "multicastFavorX.GIDs")
 	proto.RegisterType((*FindGroupReq)(nil), "multicastFavorX.FindGroupReq")
@@ -381,33 +725,51 @@ func init() {
 	proto.RegisterType((*MulticastMsg)(nil), "multicastFavorX.MulticastMsg")
 	proto.RegisterType((*Notify)(nil), "multicastFavorX.Notify")
 	proto.RegisterType((*GroupMsg)(nil), "multicastFavorX.GroupMsg")
+	proto.RegisterType((*Ack)(nil), "multicastFavorX.Ack")
+	proto.RegisterType((*Topics)(nil), "multicastFavorX.Topics")
+	proto.RegisterType((*Transfer)(nil), "multicastFavorX.Transfer")
+	proto.RegisterType((*Frame)(nil), "multicastFavorX.Frame")
 }
 
 func init() { proto.RegisterFile("multicast.proto", fileDescriptor_eedbde62517e047e) }
 
 var fileDescriptor_eedbde62517e047e = []byte{
-	// 332 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x91, 0x4f, 0x4b, 0x33, 0x31,
-	0x10, 0xc6, 0xbb, 0xff, 0xca, 0xdb, 0x61, 0xdf, 0x3f, 0x84, 0x17, 0xc9, 0xa1, 0x84, 0xb2, 0xa7,
-	0x5e, 0xf4, 0xa2, 0x9f, 0x40, 0xa4, 0xc5, 0x43, 0x3d, 0x04, 0x11, 0xf1, 0x20, 0xa4, 0x4d, 0x5c,
-	0x03, 0x6d, 0x77, 0x4d, 0xa6, 0x42, 0xc1, 0x0f, 0xe1, 0xc7, 0xf2, 0xd8, 0xa3, 0x47, 0x69, 0xbf,
-	0x88, 0x64, 0xba, 0x65, 0x0b, 0xde, 0x9e, 0x79, 0x78, 0x32, 0xf3, 0x9b, 0x09, 0xfc, 0x5d, 0xac,
-	0xe6, 0x68, 0x67, 0xca, 0xe3, 0x59, 0xed, 0x2a, 0xac, 0x58, 0x6b, 0x8c, 0xd4, 0x6b, 0xe5, 0xee,
-	0x0b, 0x0e, 0xe9, 0xf8, 0xfa, 0xca, 0xb3, 0x7f, 0x90, 0x94, 0x56, 0xf3, 0x68, 0x90, 0x0c, 0x73,
-	0x19, 0x64, 0xf1, 0x08, 0xf9, 0xc8, 0x2e, 0xf5, 0xd8, 0x55, 0xab, 0x5a, 0x9a, 0x97, 0x36, 0x11,
-	0x35, 0x09, 0xf6, 0x1f, 0xb2, 0xb9, 0x5d, 0x58, 0xe4, 0xf1, 0x20, 0x1a, 0x66, 0x72, 0x5f, 0x84,
-	0x1c, 0xe2, 0x9c, 0x27, 0xe4, 0x05, 0x19, 0x72, 0xb5, 0xc2, 0x67, 0xcf, 0x53, 0xea, 0xbe, 0x2f,
-	0x8a, 0x53, 0xf8, 0x7d, 0xd4, 0xdf, 0xd7, 0xac, 0x0f, 0x3d, 0xa5, 0xb5, 0x33, 0xde, 0x1b, 0xdf,
-	0x80, 0xb4, 0x46, 0xf1, 0x06, 0xf9, 0xe4, 0xc0, 0x3e, 0xf1, 0x25, 0xfb, 0x03, 0x71, 0x43, 0x93,
-	0xca, 0xd8, 0x6a, 0x26, 0x00, 0x66, 0xce, 0x28, 0x34, 0xb7, 0x76, 0x61, 0x88, 0x28, 0x91, 0x47,
-	0x0e, 0x3b, 0x81, 0x6e, 0xe5, 0x6c, 0x69, 0x97, 0x44, 0x96, 0xcb, 0xa6, 0x3a, 0xac, 0x95, 0xb6,
-	0x6b, 0x31, 0x48, 0xb5, 0x42, 0xc5, 0x33, 0xb2, 0x48, 0x17, 0x17, 0xd0, 0xbd, 0xa9, 0xd0, 0x3e,
-	0xad, 0x43, 0x1f, 0x8f, 0x0a, 0x57, 0x9e, 0x66, 0x67, 0xb2, 0xa9, 0xc2, 0xab, 0xd2, 0x6a, 0xcf,
-	0x63, 0x02, 0x27, 0x5d, 0xdc, 0xc1, 0x2f, 0x5a, 0x2f, 0xf0, 0xfe, 0x3c, 0xdf, 0x61, 0x4e, 0xdc,
-	0xce, 0x09, 0x1e, 0xae, 0x6b, 0xd3, 0x5c, 0x8f, 0x74, 0x78, 0x69, 0x9c, 0x23, 0xc2, 0x9e, 0x0c,
-	0xf2, 0xb2, 0xff, 0xb1, 0x15, 0xd1, 0x66, 0x2b, 0xa2, 0xaf, 0xad, 0x88, 0xde, 0x77, 0xa2, 0xb3,
-	0xd9, 0x89, 0xce, 0xe7, 0x4e, 0x74, 0x1e, 0xe2, 0x7a, 0x3a, 0xed, 0xd2, 0x57, 0x9f, 0x7f, 0x07,
-	0x00, 0x00, 0xff, 0xff, 0x7f, 0x0b, 0x05, 0xd0, 0xfd, 0x01, 0x00, 0x00,
+	// 556 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x54, 0xbd, 0x6e, 0xd4, 0x40,
+	0x10, 0xce, 0xfa, 0x2f, 0xf6, 0x70, 0xfc, 0xc8, 0xe2, 0x67, 0x15, 0x45, 0x96, 0xe5, 0xca, 0x0d,
+	0x41, 0x02, 0x89, 0x1e, 0x84, 0x12, 0x45, 0x22, 0x14, 0xab, 0x2b, 0x10, 0x05, 0xd2, 0x9e, 0xbd,
+	0xbe, 0xac, 0x72, 0x77, 0x76, 0x76, 0xf7, 0x90, 0xf2, 0x0a, 0x54, 0x3c, 0x06, 0x8f, 0x42, 0x99,
+	0x92, 0x12, 0xdd, 0x75, 0x3c, 0x05, 0xda, 0xf1, 0xcf, 0x99, 0xe4, 0xba, 0xef, 0x1b, 0xcf, 0xec,
+	0x7c, 0xf3, 0xcd, 0xc8, 0xf0, 0x78, 0xb9, 0x5e, 0x18, 0x59, 0x70, 0x6d, 0x4e, 0x1a, 0x55, 0x9b,
+	0x3a, 0xde, 0x05, 0x4e, 0xf9, 0xb7, 0x5a, 0x7d, 0xce, 0xce, 0xc1, 0x3b, 0x3b, 0xff, 0xa0, 0xe3,
+	0x27, 0xe0, 0xce, 0x65, 0x49, 0x49, 0xea, 0xe6, 0x13, 0x66, 0x61, 0xfc, 0x0a, 0x02, 0x53, 0x37,
+	0xb2, 0xd0, 0xd4, 0x49, 0xdd, 0xfc, 0xc1, 0xeb, 0x17, 0x27, 0x77, 0x6a, 0x4f, 0xa6, 0xf8, 0x99,
+	0x75, 0x69, 0xd9, 0x57, 0x98, 0x9c, 0xca, 0x55, 0x79, 0xa6, 0xea, 0x75, 0xc3, 0xc4, 0xf5, 0xee,
+	0x49, 0xd2, 0x3f, 0xf9, 0x14, 0xfc, 0x85, 0x5c, 0x4a, 0x43, 0x9d, 0x94, 0xe4, 0x3e, 0x6b, 0x89,
+	0xcd, 0x33, 0x66, 0x41, 0x5d, 0x8c, 0x59, 0x68, 0xf3, 0x1a, 0x6e, 0x2e, 0x35, 0xf5, 0x50, 0x4e,
+	0x4b, 0xb2, 0x97, 0xf0, 0x70, 0xf4, 0xbe, 0x6e, 0xe2, 0x63, 0x88, 0x78, 0x59, 0x2a, 0xa1, 0xb5,
+	0xd0, 0x9d, 0xf2, 0x5d, 0x20, 0xfb, 0x4b, 0x60, 0x72, 0xd1, 0x2b, 0xbe, 0xd0, 0xf3, 0xf8, 0x11,
+	0x38, 0x9d, 0x1c, 0x8f, 0x39, 0xb2, 0x8c, 0x13, 0x80, 0x42, 0x09, 0x6e, 0xc4, 0x54, 0x2e, 0x05,
+	0x4a, 0x72, 0xd9, 0x28, 0x12, 0x3f, 0x87, 0xa0, 0x56, 0x72, 0x2e, 0x57, 0x28, 0x6d, 0xc2, 0x3a,
+	0xd6, 0xcf, 0xe5, 0xed, 0xe6, 0x8a, 0xc1, 0x2b, 0xb9, 0xe1, 0xd4, 0xc7, 0x10, 0x62, 0x9b, 0xa5,
+	0xc5, 0x35, 0x0d, 0xb0, 0x9d, 0x85, 0x36, 0x6b, 0xc6, 0xb5, 0xa0, 0x87, 0x18, 0x42, 0x6c, 0x27,
+	0x15, 0x4d, 0x5d, 0x5c, 0xd2, 0x10, 0xdb, 0xb7, 0x24, 0x3e, 0x82, 0x50, 0x89, 0x85, 0xe4, 0xb3,
+	0x85, 0xa0, 0x51, 0x4a, 0xf2, 0x90, 0x0d, 0xdc, 0x56, 0xa0, 0xdf, 0x14, 0x52, 0x92, 0x47, 0xac,
+	0x25, 0xd9, 0x47, 0x08, 0x3e, 0xd5, 0x46, 0x56, 0x37, 0x56, 0xb5, 0x36, 0xdc, 0xac, 0x35, 0x4e,
+	0xea, 0xb3, 0x8e, 0xd9, 0xee, 0x73, 0x59, 0xb6, 0xcb, 0x9c, 0x30, 0xc4, 0x36, 0xb7, 0x5b, 0xb1,
+	0x9b, 0xba, 0x79, 0x34, 0x6c, 0xf2, 0x27, 0x81, 0x10, 0x6d, 0xb6, 0xb6, 0xdd, 0x5f, 0x63, 0x3f,
+	0xae, 0x33, 0x1a, 0x37, 0x06, 0xcf, 0xdc, 0x34, 0xa2, 0xdb, 0x22, 0x62, 0x5b, 0x29, 0x94, 0x42,
+	0xa3, 0x22, 0x66, 0x61, 0x6f, 0x8a, 0x7f, 0xdf, 0x94, 0x60, 0x9f, 0x29, 0x87, 0x63, 0x53, 0x86,
+	0xc1, 0xc3, 0xf1, 0xe0, 0xcf, 0xc0, 0x7d, 0x57, 0x5c, 0xdd, 0xdd, 0x6d, 0xf6, 0x16, 0x82, 0xf6,
+	0x3a, 0xf7, 0xc8, 0x3f, 0x82, 0xb0, 0xe1, 0xc6, 0x08, 0xb5, 0x6a, 0xdd, 0x88, 0xd8, 0xc0, 0xb3,
+	0xef, 0x04, 0xc2, 0xa9, 0xe2, 0x2b, 0x5d, 0x09, 0xb5, 0xa7, 0xb4, 0x6d, 0xe3, 0xa0, 0x00, 0xa7,
+	0x3d, 0xe8, 0x56, 0x93, 0x3b, 0xd2, 0x64, 0xef, 0x52, 0x89, 0x4a, 0x28, 0xb1, 0x2a, 0x44, 0x77,
+	0x26, 0xbb, 0x00, 0x9e, 0x55, 0x55, 0x69, 0x61, 0x3a, 0x1b, 0x3a, 0xd6, 0xbb, 0x15, 0x0c, 0x6e,
+	0x65, 0x12, 0xfc, 0x53, 0xc5, 0x97, 0xe3, 0x12, 0xf2, 0x5f, 0xc9, 0xbe, 0x45, 0xd8, 0x67, 0xea,
+	0x0a, 0x05, 0x85, 0xcc, 0x42, 0x1b, 0xe1, 0xc5, 0x15, 0x0a, 0xf1, 0x98, 0x85, 0x7d, 0x2b, 0x7f,
+	0x68, 0xf5, 0xfe, 0xf8, 0xd7, 0x26, 0x21, 0xb7, 0x9b, 0x84, 0xfc, 0xd9, 0x24, 0xe4, 0xc7, 0x36,
+	0x39, 0xb8, 0xdd, 0x26, 0x07, 0xbf, 0xb7, 0xc9, 0xc1, 0x17, 0xa7, 0x99, 0xcd, 0x02, 0xfc, 0x79,
+	0xbc, 0xf9, 0x37, 0x00, 0x1a, 0xb8, 0xb7, 0xd1, 0x4f, 0x04, 0x00, 0x00,
 }
 
 func (m *GIDs) Marshal() (dAtA []byte, err error) {
@@ -430,6 +792,20 @@ func (m *GIDs) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Topics) > 0 {
+		for iNdEx := len(m.Topics) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Topics[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintMulticast(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
 	if len(m.Gid) > 0 {
 		for iNdEx := len(m.Gid) - 1; iNdEx >= 0; iNdEx-- {
 			i -= len(m.Gid[iNdEx])
@@ -543,6 +919,38 @@ func (m *MulticastMsg) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Topic) > 0 {
+		i -= len(m.Topic)
+		copy(dAtA[i:], m.Topic)
+		i = encodeVarintMulticast(dAtA, i, uint64(len(m.Topic)))
+		i--
+		dAtA[i] = 0x52
+	}
+	if m.Reliable {
+		i--
+		if m.Reliable {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x48
+	}
+	if m.Epoch != 0 {
+		i = encodeVarintMulticast(dAtA, i, uint64(m.Epoch))
+		i--
+		dAtA[i] = 0x40
+	}
+	if m.Base != 0 {
+		i = encodeVarintMulticast(dAtA, i, uint64(m.Base))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.Seq != 0 {
+		i = encodeVarintMulticast(dAtA, i, uint64(m.Seq))
+		i--
+		dAtA[i] = 0x30
+	}
 	if len(m.Data) > 0 {
 		i -= len(m.Data)
 		copy(dAtA[i:], m.Data)
@@ -597,6 +1005,15 @@ func (m *Notify) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Topics) > 0 {
+		for iNdEx := len(m.Topics) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Topics[iNdEx])
+			copy(dAtA[i:], m.Topics[iNdEx])
+			i = encodeVarintMulticast(dAtA, i, uint64(len(m.Topics[iNdEx])))
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
 	if len(m.Gids) > 0 {
 		for iNdEx := len(m.Gids) - 1; iNdEx >= 0; iNdEx-- {
 			i -= len(m.Gids[iNdEx])
@@ -634,6 +1051,28 @@ func (m *GroupMsg) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Topic) > 0 {
+		i -= len(m.Topic)
+		copy(dAtA[i:], m.Topic)
+		i = encodeVarintMulticast(dAtA, i, uint64(len(m.Topic)))
+		i--
+		dAtA[i] = 0x42
+	}
+	if m.Epoch != 0 {
+		i = encodeVarintMulticast(dAtA, i, uint64(m.Epoch))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.Base != 0 {
+		i = encodeVarintMulticast(dAtA, i, uint64(m.Base))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.Seq != 0 {
+		i = encodeVarintMulticast(dAtA, i, uint64(m.Seq))
+		i--
+		dAtA[i] = 0x28
+	}
 	if len(m.Err) > 0 {
 		i -= len(m.Err)
 		copy(dAtA[i:], m.Err)
@@ -663,15 +1102,202 @@ func (m *GroupMsg) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintMulticast(dAtA []byte, offset int, v uint64) int {
-	offset -= sovMulticast(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *Ack) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
+	return dAtA[:n], nil
+}
+
+func (m *Ack) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Ack) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Id != 0 {
+		i = encodeVarintMulticast(dAtA, i, uint64(m.Id))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Topics) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Topics) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Topics) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Patterns) > 0 {
+		for iNdEx := len(m.Patterns) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Patterns[iNdEx])
+			copy(dAtA[i:], m.Patterns[iNdEx])
+			i = encodeVarintMulticast(dAtA, i, uint64(len(m.Patterns[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Gid) > 0 {
+		i -= len(m.Gid)
+		copy(dAtA[i:], m.Gid)
+		i = encodeVarintMulticast(dAtA, i, uint64(len(m.Gid)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Transfer) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Transfer) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Transfer) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Err) > 0 {
+		i -= len(m.Err)
+		copy(dAtA[i:], m.Err)
+		i = encodeVarintMulticast(dAtA, i, uint64(len(m.Err)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if m.Offset != 0 {
+		i = encodeVarintMulticast(dAtA, i, uint64(m.Offset))
+		i--
+		dAtA[i] = 0x28
+	}
+	if len(m.Reference) > 0 {
+		i -= len(m.Reference)
+		copy(dAtA[i:], m.Reference)
+		i = encodeVarintMulticast(dAtA, i, uint64(len(m.Reference)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.Topic) > 0 {
+		i -= len(m.Topic)
+		copy(dAtA[i:], m.Topic)
+		i = encodeVarintMulticast(dAtA, i, uint64(len(m.Topic)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Id) > 0 {
+		i -= len(m.Id)
+		copy(dAtA[i:], m.Id)
+		i = encodeVarintMulticast(dAtA, i, uint64(len(m.Id)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Gid) > 0 {
+		i -= len(m.Gid)
+		copy(dAtA[i:], m.Gid)
+		i = encodeVarintMulticast(dAtA, i, uint64(len(m.Gid)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Frame) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Frame) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Frame) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Err) > 0 {
+		i -= len(m.Err)
+		copy(dAtA[i:], m.Err)
+		i = encodeVarintMulticast(dAtA, i, uint64(len(m.Err)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Ack != 0 {
+		i = encodeVarintMulticast(dAtA, i, uint64(m.Ack))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Eof {
+		i--
+		if m.Eof {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Data) > 0 {
+		i -= len(m.Data)
+		copy(dAtA[i:], m.Data)
+		i = encodeVarintMulticast(dAtA, i, uint64(len(m.Data)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Offset != 0 {
+		i = encodeVarintMulticast(dAtA, i, uint64(m.Offset))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintMulticast(dAtA []byte, offset int, v uint64) int {
+	offset -= sovMulticast(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
 	return base
 }
 func (m *GIDs) Size() (n int) {
@@ -686,6 +1312,12 @@ func (m *GIDs) Size() (n int) {
 			n += 1 + l + sovMulticast(uint64(l))
 		}
 	}
+	if len(m.Topics) > 0 {
+		for _, e := range m.Topics {
+			l = e.Size()
+			n += 1 + l + sovMulticast(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -753,6 +1385,22 @@ func (m *MulticastMsg) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovMulticast(uint64(l))
 	}
+	if m.Seq != 0 {
+		n += 1 + sovMulticast(uint64(m.Seq))
+	}
+	if m.Base != 0 {
+		n += 1 + sovMulticast(uint64(m.Base))
+	}
+	if m.Epoch != 0 {
+		n += 1 + sovMulticast(uint64(m.Epoch))
+	}
+	if m.Reliable {
+		n += 2
+	}
+	l = len(m.Topic)
+	if l > 0 {
+		n += 1 + l + sovMulticast(uint64(l))
+	}
 	return n
 }
 
@@ -771,6 +1419,12 @@ func (m *Notify) Size() (n int) {
 			n += 1 + l + sovMulticast(uint64(l))
 		}
 	}
+	if len(m.Topics) > 0 {
+		for _, s := range m.Topics {
+			l = len(s)
+			n += 1 + l + sovMulticast(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -795,6 +1449,108 @@ func (m *GroupMsg) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovMulticast(uint64(l))
 	}
+	if m.Seq != 0 {
+		n += 1 + sovMulticast(uint64(m.Seq))
+	}
+	if m.Base != 0 {
+		n += 1 + sovMulticast(uint64(m.Base))
+	}
+	if m.Epoch != 0 {
+		n += 1 + sovMulticast(uint64(m.Epoch))
+	}
+	l = len(m.Topic)
+	if l > 0 {
+		n += 1 + l + sovMulticast(uint64(l))
+	}
+	return n
+}
+
+func (m *Ack) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Id != 0 {
+		n += 1 + sovMulticast(uint64(m.Id))
+	}
+	return n
+}
+
+func (m *Topics) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Gid)
+	if l > 0 {
+		n += 1 + l + sovMulticast(uint64(l))
+	}
+	if len(m.Patterns) > 0 {
+		for _, s := range m.Patterns {
+			l = len(s)
+			n += 1 + l + sovMulticast(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *Transfer) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Gid)
+	if l > 0 {
+		n += 1 + l + sovMulticast(uint64(l))
+	}
+	l = len(m.Id)
+	if l > 0 {
+		n += 1 + l + sovMulticast(uint64(l))
+	}
+	l = len(m.Topic)
+	if l > 0 {
+		n += 1 + l + sovMulticast(uint64(l))
+	}
+	l = len(m.Reference)
+	if l > 0 {
+		n += 1 + l + sovMulticast(uint64(l))
+	}
+	if m.Offset != 0 {
+		n += 1 + sovMulticast(uint64(m.Offset))
+	}
+	l = len(m.Err)
+	if l > 0 {
+		n += 1 + l + sovMulticast(uint64(l))
+	}
+	return n
+}
+
+func (m *Frame) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Offset != 0 {
+		n += 1 + sovMulticast(uint64(m.Offset))
+	}
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovMulticast(uint64(l))
+	}
+	if m.Eof {
+		n += 2
+	}
+	if m.Ack != 0 {
+		n += 1 + sovMulticast(uint64(m.Ack))
+	}
+	l = len(m.Err)
+	if l > 0 {
+		n += 1 + l + sovMulticast(uint64(l))
+	}
 	return n
 }
 
@@ -865,24 +1621,58 @@ func (m *GIDs) Unmarshal(dAtA []byte) error {
 			m.Gid = append(m.Gid, make([]byte, postIndex-iNdEx))
 			copy(m.Gid[len(m.Gid)-1], dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMulticast(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if (skippy < 0) || (iNdEx+skippy) < 0 {
-				return ErrInvalidLengthMulticast
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Topics", wireType)
 			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Topics = append(m.Topics, &Topics{})
+			if err := m.Topics[len(m.Topics)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMulticast(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
 	return nil
 }
@@ -991,7 +1781,861 @@ func (m *FindGroupReq) Unmarshal(dAtA []byte) error {
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Paths", wireType)
 			}
-			var byteLen int
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Paths = append(m.Paths, make([]byte, postIndex-iNdEx))
+			copy(m.Paths[len(m.Paths)-1], dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMulticast(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *FindGroupResp) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMulticast
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: FindGroupResp: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: FindGroupResp: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Addresses", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Addresses = append(m.Addresses, make([]byte, postIndex-iNdEx))
+			copy(m.Addresses[len(m.Addresses)-1], dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMulticast(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *MulticastMsg) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMulticast
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MulticastMsg: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MulticastMsg: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			}
+			m.Id = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Id |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CreateTime", wireType)
+			}
+			m.CreateTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CreateTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Origin", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Origin = append(m.Origin[:0], dAtA[iNdEx:postIndex]...)
+			if m.Origin == nil {
+				m.Origin = []byte{}
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Gid", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Gid = append(m.Gid[:0], dAtA[iNdEx:postIndex]...)
+			if m.Gid == nil {
+				m.Gid = []byte{}
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Seq", wireType)
+			}
+			m.Seq = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Seq |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Base", wireType)
+			}
+			m.Base = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Base |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Epoch", wireType)
+			}
+			m.Epoch = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Epoch |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Reliable", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Reliable = bool(v != 0)
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Topic", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Topic = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMulticast(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Notify) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMulticast
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Notify: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Notify: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Status", wireType)
+			}
+			m.Status = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Status |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Gids", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Gids = append(m.Gids, make([]byte, postIndex-iNdEx))
+			copy(m.Gids[len(m.Gids)-1], dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Topics", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Topics = append(m.Topics, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMulticast(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GroupMsg) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMulticast
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GroupMsg: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GroupMsg: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Gid", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Gid = append(m.Gid[:0], dAtA[iNdEx:postIndex]...)
+			if m.Gid == nil {
+				m.Gid = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Type |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Err", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Err = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Seq", wireType)
+			}
+			m.Seq = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Seq |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Base", wireType)
+			}
+			m.Base = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Base |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Epoch", wireType)
+			}
+			m.Epoch = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Epoch |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Topic", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Topic = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMulticast(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Ack) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMulticast
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Ack: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Ack: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			}
+			m.Id = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMulticast
@@ -1001,24 +2645,11 @@ func (m *FindGroupReq) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				m.Id |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
-				return ErrInvalidLengthMulticast
-			}
-			postIndex := iNdEx + byteLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMulticast
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Paths = append(m.Paths, make([]byte, postIndex-iNdEx))
-			copy(m.Paths[len(m.Paths)-1], dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMulticast(dAtA[iNdEx:])
@@ -1040,7 +2671,7 @@ func (m *FindGroupReq) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *FindGroupResp) Unmarshal(dAtA []byte) error {
+func (m *Topics) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1063,15 +2694,15 @@ func (m *FindGroupResp) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: FindGroupResp: wiretype end group for non-group")
+			return fmt.Errorf("proto: Topics: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: FindGroupResp: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Topics: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Addresses", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Gid", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -1098,8 +2729,42 @@ func (m *FindGroupResp) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Addresses = append(m.Addresses, make([]byte, postIndex-iNdEx))
-			copy(m.Addresses[len(m.Addresses)-1], dAtA[iNdEx:postIndex])
+			m.Gid = append(m.Gid[:0], dAtA[iNdEx:postIndex]...)
+			if m.Gid == nil {
+				m.Gid = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Patterns", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMulticast
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Patterns = append(m.Patterns, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -1122,7 +2787,7 @@ func (m *FindGroupResp) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *MulticastMsg) Unmarshal(dAtA []byte) error {
+func (m *Transfer) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1145,17 +2810,17 @@ func (m *MulticastMsg) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: MulticastMsg: wiretype end group for non-group")
+			return fmt.Errorf("proto: Transfer: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MulticastMsg: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Transfer: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Gid", wireType)
 			}
-			m.Id = 0
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMulticast
@@ -1165,35 +2830,31 @@ func (m *MulticastMsg) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Id |= uint64(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field CreateTime", wireType)
+			if byteLen < 0 {
+				return ErrInvalidLengthMulticast
 			}
-			m.CreateTime = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMulticast
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.CreateTime |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMulticast
 			}
-		case 3:
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Gid = append(m.Gid[:0], dAtA[iNdEx:postIndex]...)
+			if m.Gid == nil {
+				m.Gid = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Origin", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMulticast
@@ -1203,31 +2864,29 @@ func (m *MulticastMsg) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthMulticast
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthMulticast
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Origin = append(m.Origin[:0], dAtA[iNdEx:postIndex]...)
-			if m.Origin == nil {
-				m.Origin = []byte{}
-			}
+			m.Id = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 4:
+		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Gid", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Topic", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMulticast
@@ -1237,29 +2896,27 @@ func (m *MulticastMsg) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthMulticast
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthMulticast
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Gid = append(m.Gid[:0], dAtA[iNdEx:postIndex]...)
-			if m.Gid == nil {
-				m.Gid = []byte{}
-			}
+			m.Topic = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 5:
+		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Reference", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -1286,66 +2943,16 @@ func (m *MulticastMsg) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
-			if m.Data == nil {
-				m.Data = []byte{}
+			m.Reference = append(m.Reference[:0], dAtA[iNdEx:postIndex]...)
+			if m.Reference == nil {
+				m.Reference = []byte{}
 			}
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMulticast(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if (skippy < 0) || (iNdEx+skippy) < 0 {
-				return ErrInvalidLengthMulticast
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *Notify) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMulticast
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: Notify: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Notify: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+		case 5:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Status", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
 			}
-			m.Status = 0
+			m.Offset = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMulticast
@@ -1355,16 +2962,16 @@ func (m *Notify) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Status |= int32(b&0x7F) << shift
+				m.Offset |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
+		case 6:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Gids", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Err", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMulticast
@@ -1374,23 +2981,23 @@ func (m *Notify) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthMulticast
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthMulticast
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Gids = append(m.Gids, make([]byte, postIndex-iNdEx))
-			copy(m.Gids[len(m.Gids)-1], dAtA[iNdEx:postIndex])
+			m.Err = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -1413,7 +3020,7 @@ func (m *Notify) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GroupMsg) Unmarshal(dAtA []byte) error {
+func (m *Frame) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1436,17 +3043,17 @@ func (m *GroupMsg) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GroupMsg: wiretype end group for non-group")
+			return fmt.Errorf("proto: Frame: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GroupMsg: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Frame: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Gid", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
 			}
-			var byteLen int
+			m.Offset = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMulticast
@@ -1456,26 +3063,11 @@ func (m *GroupMsg) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				m.Offset |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
-				return ErrInvalidLengthMulticast
-			}
-			postIndex := iNdEx + byteLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMulticast
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Gid = append(m.Gid[:0], dAtA[iNdEx:postIndex]...)
-			if m.Gid == nil {
-				m.Gid = []byte{}
-			}
-			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
@@ -1512,9 +3104,9 @@ func (m *GroupMsg) Unmarshal(dAtA []byte) error {
 			iNdEx = postIndex
 		case 3:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Eof", wireType)
 			}
-			m.Type = 0
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMulticast
@@ -1524,12 +3116,32 @@ func (m *GroupMsg) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Type |= int32(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			m.Eof = bool(v != 0)
 		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ack", wireType)
+			}
+			m.Ack = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMulticast
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Ack |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Err", wireType)
 			}
@@ -0,0 +1,619 @@
+package multicast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/multicast/model"
+	"github.com/FavorLabs/favorX/pkg/multicast/pb"
+	"github.com/FavorLabs/favorX/pkg/p2p"
+	"github.com/FavorLabs/favorX/pkg/p2p/protobuf"
+)
+
+const (
+	reliableAckTimeout  = time.Second * 10
+	reliableRetryDelay  = time.Second // doubled after every attempt
+	reliableMaxAttempts = 5
+
+	reorderTimeout     = time.Second * 5 // how long a message waits for the messages before it
+	reorderBufferLimit = 256
+	reorderWindow      = 1024 // how far behind the sequence late messages are still handed over
+
+	deliveryExpire = time.Minute * 10
+)
+
+var (
+	ErrDeliveryNotFound = errors.New("delivery not found")
+
+	errServiceClosed = errors.New("multicast service closed")
+	errOutOfOrder    = errors.New("waiting for the preceding messages")
+	errNoPeers       = errors.New("no peers to send the message to")
+)
+
+// delivery tracks a reliable message the node sent.
+type delivery struct {
+	mu     sync.Mutex
+	status model.Delivery
+	data   []byte
+	topic  string
+}
+
+func deliveryKey(id uint64) string {
+	return fmt.Sprintf("Delivery_%d", id)
+}
+
+func (s *Service) newDelivery(id uint64, gid, target boson.Address, seq uint64, data []byte) *delivery {
+	now := time.Now()
+	d := &delivery{
+		status: model.Delivery{
+			ID:      id,
+			GroupID: gid,
+			Target:  target,
+			Seq:     seq,
+			State:   model.DeliveryPending,
+			Created: now,
+			Updated: now,
+		},
+		data: data,
+	}
+	_ = cache.Set(cacheCtx, deliveryKey(id), d, deliveryExpire)
+	return d
+}
+
+func (d *delivery) update(f func(status *model.Delivery)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	f(&d.status)
+	d.status.Updated = time.Now()
+}
+
+// DeliveryStatus returns the status of the reliable message the node sent to
+// the group. Statuses are kept for ten minutes.
+func (s *Service) DeliveryStatus(gid boson.Address, id uint64) (model.Delivery, error) {
+	v, err := cache.Get(cacheCtx, deliveryKey(id))
+	if err != nil {
+		return model.Delivery{}, err
+	}
+	if v == nil {
+		return model.Delivery{}, ErrDeliveryNotFound
+	}
+	d := v.Val().(*delivery)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.status.GroupID.Equal(gid) {
+		return model.Delivery{}, ErrDeliveryNotFound
+	}
+	return d.status, nil
+}
+
+// retry calls send until it succeeds, backing off exponentially between the
+// attempts, and returns the error of the last attempt.
+func (s *Service) retry(send func() error) error {
+	delay := reliableRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := send()
+		if err == nil || attempt == reliableMaxAttempts {
+			return err
+		}
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-s.close:
+			return errServiceClosed
+		}
+	}
+}
+
+// outbox sends the reliable messages of the node to a target in a group one
+// at a time, so they leave in the order of their sequence.
+type outbox struct {
+	gid     boson.Address
+	dest    boson.Address
+	mu      sync.Mutex
+	seq     uint64
+	queue   []*delivery
+	running bool
+}
+
+// SendReliable queues the data for the target in the group and returns the id
+// of the message. The message is retransmitted until the target acknowledges
+// it, and the target hands the messages of the node to its subscribers in the
+// order they were sent.
+func (s *Service) SendReliable(data []byte, gid, dest boson.Address, topic string) (id uint64) {
+	v, _ := s.outboxes.LoadOrStore(gid.String()+dest.String(), &outbox{gid: gid, dest: dest})
+	ob := v.(*outbox)
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.seq++
+	id = atomic.AddUint64(&s.msgSeq, 1)
+	d := s.newDelivery(id, gid, dest, ob.seq, data)
+	d.status.Peers = 1
+	d.topic = topic
+	ob.queue = append(ob.queue, d)
+	if !ob.running {
+		ob.running = true
+		go s.runOutbox(ob)
+	}
+	return id
+}
+
+func (s *Service) runOutbox(ob *outbox) {
+	for {
+		ob.mu.Lock()
+		if len(ob.queue) == 0 {
+			ob.running = false
+			ob.mu.Unlock()
+			return
+		}
+		d := ob.queue[0]
+		ob.mu.Unlock()
+
+		err := s.retry(func() error {
+			d.update(func(status *model.Delivery) {
+				status.Attempts++
+			})
+			return s.sendReliable(ob, d)
+		})
+		d.update(func(status *model.Delivery) {
+			if err != nil {
+				status.State = model.DeliveryFailed
+				status.Error = err.Error()
+				return
+			}
+			status.State = model.DeliveryDelivered
+			status.Acked = 1
+			status.Error = ""
+		})
+		if err != nil {
+			s.logger.Debugf("group: reliable message %d to %s failed: %v", d.status.ID, ob.dest, err)
+		}
+
+		ob.mu.Lock()
+		ob.queue = ob.queue[1:]
+		ob.mu.Unlock()
+	}
+}
+
+func (s *Service) sendReliable(ob *outbox, d *delivery) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), reliableAckTimeout)
+	defer cancel()
+
+	var stream p2p.Stream
+	stream, err = s.getStream(ctx, ob.dest, streamMessage)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = stream.Reset()
+		} else {
+			go stream.FullClose()
+		}
+	}()
+
+	// the messages before this one were acknowledged or given up on, so the
+	// target does not wait for them
+	req := &pb.GroupMsg{
+		Gid:   ob.gid.Bytes(),
+		Data:  d.data,
+		Type:  int32(SendReliable),
+		Seq:   d.status.Seq,
+		Base:  d.status.Seq,
+		Epoch: s.epoch,
+		Topic: d.topic,
+	}
+	w, r := protobuf.NewWriterAndReader(stream)
+	err = w.WriteMsgWithContext(ctx, req)
+	if err != nil {
+		return err
+	}
+	res := &pb.GroupMsg{}
+	err = r.ReadMsgWithContext(ctx, res)
+	if err != nil {
+		return err
+	}
+	if res.Err != "" {
+		return errors.New(res.Err)
+	}
+	if res.Seq != req.Seq {
+		return fmt.Errorf("acknowledged sequence %d, want %d", res.Seq, req.Seq)
+	}
+	return nil
+}
+
+// onReliableMessage hands the message to the subscribers of the group in the
+// order of the sequence of the sender and acknowledges it. Duplicates are
+// acknowledged again without being handed over.
+func (s *Service) onReliableMessage(ctx context.Context, peer p2p.Peer, stream p2p.Stream, info *pb.GroupMsg) (err error) {
+	defer func() {
+		if err != nil {
+			_ = stream.Reset()
+		} else {
+			go stream.FullClose()
+		}
+	}()
+
+	gid := boson.NewAddress(info.Gid)
+	reply := &pb.GroupMsg{
+		Gid:  info.Gid,
+		Type: info.Type,
+		Seq:  info.Seq,
+	}
+	v, ok := s.groups.Load(gid.String())
+	switch {
+	case !ok || v.(*Group).getOption().GType != model.GTypeJoin:
+		reply.Err = "target not in the group"
+	case !v.(*Group).groupMsgSub:
+		reply.Err = "target not subscribe the group message"
+	case !v.(*Group).subscribedTopic(info.Topic):
+		reply.Err = "target not subscribe the topic"
+	default:
+		g := v.(*Group)
+		msg := GroupMessage{
+			GID:   gid,
+			Data:  info.Data,
+			From:  peer.Address,
+			Topic: info.Topic,
+		}
+		done := s.orderer(streamMessage, peer.Address, gid).push(info.Epoch, info.Seq, info.Base, func() {
+			s.publishGroupMessage(g, msg)
+		})
+		select {
+		case <-done:
+		case <-time.After(reorderTimeout):
+			reply.Err = errOutOfOrder.Error()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return protobuf.NewWriter(stream).WriteMsgWithContext(ctx, reply)
+}
+
+func (s *Service) publishGroupMessage(g *Group, msg GroupMessage) {
+	defer func() {
+		err := recover()
+		if err != nil {
+			s.logger.Errorf("group %s , notify msg %s", g.gid, err)
+			g.groupMsgSub = false
+		}
+	}()
+	_ = s.subPub.Publish("group", "groupMessage", g.gid.String(), msg)
+}
+
+// sequence numbers the reliable multicast messages of the node to a group and
+// tracks the ones still being delivered.
+type sequence struct {
+	mu      sync.Mutex
+	seq     uint64
+	pending map[uint64]struct{}
+}
+
+func (s *Service) multicastSequence(gid boson.Address) *sequence {
+	v, _ := s.multicastSeqs.LoadOrStore(gid.String(), &sequence{pending: make(map[uint64]struct{})})
+	return v.(*sequence)
+}
+
+// next returns the sequence of a new message and the lowest sequence still
+// being delivered.
+func (q *sequence) next() (seq, base uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seq++
+	q.pending[q.seq] = struct{}{}
+	base = q.seq
+	for p := range q.pending {
+		if p < base {
+			base = p
+		}
+	}
+	return q.seq, base
+}
+
+func (q *sequence) done(seq uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.pending, seq)
+}
+
+// sendMulticast sends the message to the peers. Reliable messages are
+// retransmitted in the background until every peer acknowledges them; the
+// delivery of the messages the node originated is tracked in d.
+func (s *Service) sendMulticast(info *pb.MulticastMsg, peers []boson.Address, d *delivery) {
+	if !info.Reliable {
+		for _, addr := range peers {
+			_ = s.sendData(context.Background(), addr, streamMulticast, info)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, addr := range peers {
+		wg.Add(1)
+		go func(addr boson.Address) {
+			defer wg.Done()
+			err := s.retry(func() error {
+				if d != nil {
+					d.update(func(status *model.Delivery) {
+						status.Attempts++
+					})
+				}
+				return s.sendMulticastAcked(addr, info)
+			})
+			if err != nil {
+				s.logger.Tracef("multicast: reliable message %d to %s failed: %v", info.Id, addr, err)
+			}
+			if d != nil {
+				d.update(func(status *model.Delivery) {
+					if err != nil {
+						status.Error = err.Error()
+						return
+					}
+					status.Acked++
+				})
+			}
+		}(addr)
+	}
+	if d == nil {
+		return
+	}
+
+	d.update(func(status *model.Delivery) {
+		status.Peers = len(peers)
+	})
+	go func() {
+		wg.Wait()
+		d.update(func(status *model.Delivery) {
+			switch {
+			case len(peers) == 0:
+				status.State = model.DeliveryFailed
+				status.Error = errNoPeers.Error()
+			case status.Acked < status.Peers:
+				status.State = model.DeliveryFailed
+			default:
+				status.State = model.DeliveryDelivered
+			}
+		})
+		s.multicastSequence(d.status.GroupID).done(info.Seq)
+	}()
+}
+
+func (s *Service) sendMulticastAcked(address boson.Address, info *pb.MulticastMsg) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), reliableAckTimeout)
+	defer cancel()
+
+	var stream p2p.Stream
+	stream, err = s.getStream(ctx, address, streamMulticast)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = stream.Reset()
+		} else {
+			go stream.FullClose()
+		}
+	}()
+
+	w, r := protobuf.NewWriterAndReader(stream)
+	err = w.WriteMsgWithContext(ctx, info)
+	if err != nil {
+		return err
+	}
+	ack := &pb.Ack{}
+	err = r.ReadMsgWithContext(ctx, ack)
+	if err != nil {
+		return err
+	}
+	if ack.Id != info.Id {
+		return fmt.Errorf("acknowledged message %d, want %d", ack.Id, info.Id)
+	}
+	return nil
+}
+
+// orderer hands the reliable messages of a sender over in the order of their
+// sequence. The sender tells the lowest sequence it still delivers, so a
+// receiver neither waits for the messages the sender gave up on nor for the
+// ones before it started receiving. Gaps left by lost messages are skipped
+// after reorderTimeout.
+type orderer struct {
+	mu      sync.Mutex
+	late    bool // hand over the messages behind the sequence instead of dropping them
+	epoch   int64
+	next    uint64
+	pending map[uint64]*pendingMessage
+	seen    map[uint64]struct{} // the late messages handed over within reorderWindow of next
+	timer   *time.Timer
+}
+
+type pendingMessage struct {
+	deliver func()
+	done    chan struct{}
+}
+
+func newOrderer(late bool) *orderer {
+	return &orderer{
+		late:    late,
+		pending: make(map[uint64]*pendingMessage),
+		seen:    make(map[uint64]struct{}),
+	}
+}
+
+func ordererKey(streamName string, from, gid boson.Address) string {
+	return streamName + "_" + from.String() + "_" + gid.String()
+}
+
+// orderer returns the orderer of the messages of the stream the sender sends
+// to the group. Orderers are kept until the node leaves the group, so the
+// duplicates of the messages the sender retransmits are dropped however late
+// they arrive. Multicast messages behind the sequence are late rather than
+// duplicates, unless they are already handed over.
+func (s *Service) orderer(streamName string, from, gid boson.Address) *orderer {
+	v, _ := s.orderers.LoadOrStore(ordererKey(streamName, from, gid), newOrderer(streamName == streamMulticast))
+	return v.(*orderer)
+}
+
+// forgetOrderers drops the orderers of the messages to the group.
+func (s *Service) forgetOrderers(gid boson.Address) {
+	suffix := "_" + gid.String()
+	s.orderers.Range(func(key, _ interface{}) bool {
+		if strings.HasSuffix(key.(string), suffix) {
+			s.orderers.Delete(key)
+		}
+		return true
+	})
+}
+
+// push queues the message and returns a channel closed once it is handed
+// over. Messages behind the sequence are not queued.
+func (o *orderer) push(epoch int64, seq, base uint64, deliver func()) <-chan struct{} {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	done := make(chan struct{})
+	switch {
+	case epoch < o.epoch:
+		// a message from before the sender restarted
+		close(done)
+		return done
+	case epoch > o.epoch:
+		o.reset(epoch)
+	}
+	if o.next == 0 {
+		o.next = base
+		if o.next == 0 || o.next > seq {
+			o.next = seq
+		}
+	}
+	o.skipTo(base)
+
+	if seq < o.next {
+		// late messages are handed over once, and only within the window
+		if _, ok := o.seen[seq]; o.late && !ok && seq+reorderWindow >= o.next {
+			o.seen[seq] = struct{}{}
+			deliver()
+		}
+		close(done)
+		return done
+	}
+	if p, ok := o.pending[seq]; ok {
+		// a retransmission of a queued message
+		return p.done
+	}
+	o.pending[seq] = &pendingMessage{deliver: deliver, done: done}
+	o.flush()
+	if len(o.pending) > reorderBufferLimit {
+		o.skipTo(o.first())
+	}
+	return done
+}
+
+func (o *orderer) reset(epoch int64) {
+	if o.timer != nil {
+		o.timer.Stop()
+		o.timer = nil
+	}
+	o.epoch = epoch
+	o.next = 0
+	o.pending = make(map[uint64]*pendingMessage)
+	o.seen = make(map[uint64]struct{})
+}
+
+// skipTo hands over the queued messages before seq, which the sender no
+// longer waits for, and continues the sequence from seq.
+func (o *orderer) skipTo(seq uint64) {
+	if seq <= o.next {
+		return
+	}
+	var skipped []uint64
+	for p := range o.pending {
+		if p < seq {
+			skipped = append(skipped, p)
+		}
+	}
+	sort.Slice(skipped, func(i, j int) bool {
+		return skipped[i] < skipped[j]
+	})
+	for _, p := range skipped {
+		o.handOver(p)
+	}
+	o.next = seq
+	o.flush()
+}
+
+// flush hands over the queued messages that continue the sequence.
+func (o *orderer) flush() {
+	for {
+		if _, ok := o.pending[o.next]; !ok {
+			break
+		}
+		o.handOver(o.next)
+		o.next++
+	}
+	if len(o.seen) > 2*reorderWindow {
+		o.forget()
+	}
+	o.schedule()
+}
+
+func (o *orderer) handOver(seq uint64) {
+	p := o.pending[seq]
+	delete(o.pending, seq)
+	p.deliver()
+	close(p.done)
+	if o.late {
+		o.seen[seq] = struct{}{}
+	}
+}
+
+// forget drops the handed over sequences that fell out of the window.
+func (o *orderer) forget() {
+	for p := range o.seen {
+		if p+reorderWindow < o.next {
+			delete(o.seen, p)
+		}
+	}
+}
+
+func (o *orderer) first() (seq uint64) {
+	for p := range o.pending {
+		if seq == 0 || p < seq {
+			seq = p
+		}
+	}
+	return seq
+}
+
+// schedule skips the gap before the queued messages once it is open for
+// reorderTimeout.
+func (o *orderer) schedule() {
+	if len(o.pending) == 0 {
+		if o.timer != nil {
+			o.timer.Stop()
+			o.timer = nil
+		}
+		return
+	}
+	if o.timer != nil {
+		return
+	}
+	var t *time.Timer
+	t = time.AfterFunc(reorderTimeout, func() {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		if o.timer != t {
+			return
+		}
+		o.timer = nil
+		o.skipTo(o.first())
+		o.schedule()
+	})
+	o.timer = t
+}
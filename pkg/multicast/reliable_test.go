@@ -0,0 +1,148 @@
+package multicast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/address"
+	"github.com/FavorLabs/favorX/pkg/boson/test"
+	"github.com/FavorLabs/favorX/pkg/multicast/model"
+	"github.com/FavorLabs/favorX/pkg/p2p/streamtest"
+	mockRoute "github.com/FavorLabs/favorX/pkg/routetab/mock"
+	"github.com/FavorLabs/favorX/pkg/rpc"
+	mockstate "github.com/FavorLabs/favorX/pkg/statestore/mock"
+	"github.com/FavorLabs/favorX/pkg/subscribe"
+	"github.com/FavorLabs/favorX/pkg/topology/kademlia/mock"
+)
+
+func TestOrderer(t *testing.T) {
+	var got []uint64
+	o := newOrderer(false)
+	push := func(epoch int64, seq, base uint64) <-chan struct{} {
+		return o.push(epoch, seq, base, func() {
+			got = append(got, seq)
+		})
+	}
+	closed := func(ch <-chan struct{}) bool {
+		select {
+		case <-ch:
+			return true
+		default:
+			return false
+		}
+	}
+
+	// the receiver joins in the middle of the sequence
+	if !closed(push(1, 5, 5)) {
+		t.Fatal("message continuing the sequence not handed over")
+	}
+	wait := push(1, 7, 6)
+	if closed(wait) {
+		t.Fatal("message after a gap handed over")
+	}
+	if !closed(push(1, 6, 6)) || !closed(wait) {
+		t.Fatal("messages not handed over once the gap is filled")
+	}
+	// duplicates are dropped
+	if !closed(push(1, 6, 6)) {
+		t.Fatal("duplicate not acknowledged")
+	}
+	// the sender gave up on 8
+	wait = push(1, 10, 9)
+	if !closed(push(1, 9, 9)) || !closed(wait) {
+		t.Fatal("messages after the base not handed over")
+	}
+	// the sender restarted
+	if !closed(push(2, 1, 1)) {
+		t.Fatal("message of the restarted sender not handed over")
+	}
+	if !closed(push(1, 11, 11)) {
+		t.Fatal("message from before the restart not dropped")
+	}
+
+	want := []uint64{5, 6, 7, 9, 10, 1}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	// multicast messages behind the sequence are late, not duplicates
+	got = nil
+	o = newOrderer(true)
+	push(1, 3, 3)
+	push(1, 2, 2)
+	if len(got) != 2 || got[0] != 3 || got[1] != 2 {
+		t.Fatalf("got %v, want [3 2]", got)
+	}
+	// but handed over only once, and not when they fall out of the window
+	push(1, 2, 2)
+	push(1, 3, 3)
+	push(1, 4+reorderWindow, 4+reorderWindow)
+	push(1, 1, 1)
+	if len(got) != 3 || got[2] != 4+reorderWindow {
+		t.Fatalf("got %v, want [3 2 %d]", got, 4+reorderWindow)
+	}
+}
+
+func TestService_SendReliable(t *testing.T) {
+	route := mockRoute.NewMockRouteTable()
+	kad := mock.NewMockKademlia()
+	subPub := subscribe.NewSubPub()
+	receiverAddr := test.RandomAddress()
+	receiver := NewService(receiverAddr, address.NewModel(), nil, nil, kad, &route, mockstate.NewStateStore(), logger, subPub, Option{Dev: true})
+
+	gid := GenerateGID("team")
+	if err := receiver.AddGroup([]model.ConfigNodeGroup{{Name: "team", GType: model.GTypeJoin}}); err != nil {
+		t.Fatal(err)
+	}
+	notifier := subscribe.NewNotifierWithMsgChan()
+	_ = subPub.Subscribe(notifier, "group", "groupMessage", gid.String())
+	v, _ := receiver.groups.Load(gid.String())
+	v.(*Group).groupMsgSub = true
+	receiver.watchTopics(v.(*Group), &rpc.Subscription{ID: rpc.NewID()}, []string{allTopics})
+
+	recorder := streamtest.New(streamtest.WithProtocols(receiver.Protocol()))
+	sender := NewService(test.RandomAddress(), address.NewModel(), nil, recorder, kad, &route, mockstate.NewStateStore(), logger, subscribe.NewSubPub(), Option{Dev: true})
+
+	// the subscription is registered asynchronously
+	time.Sleep(100 * time.Millisecond)
+
+	var ids []uint64
+	for _, data := range []string{"a", "b", "c"} {
+		ids = append(ids, sender.SendReliable([]byte(data), gid, receiverAddr, ""))
+	}
+	for _, want := range []string{"a", "b", "c"} {
+		select {
+		case msg := <-notifier.MsgChan:
+			if got := string(msg.(GroupMessage).Data); got != want {
+				t.Fatalf("got message %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("message %q not delivered", want)
+		}
+	}
+
+	for i, id := range ids {
+		var status model.Delivery
+		for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+			var err error
+			status, err = sender.DeliveryStatus(gid, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status.State != model.DeliveryPending {
+				break
+			}
+		}
+		if status.State != model.DeliveryDelivered || status.Seq != uint64(i+1) || status.Attempts != 1 {
+			t.Fatalf("got delivery %+v", status)
+		}
+	}
+	if _, err := sender.DeliveryStatus(GenerateGID("other"), ids[0]); err != ErrDeliveryNotFound {
+		t.Fatalf("got error %v, want %v", err, ErrDeliveryNotFound)
+	}
+}
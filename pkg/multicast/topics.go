@@ -0,0 +1,281 @@
+package multicast
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/multicast/model"
+	"github.com/FavorLabs/favorX/pkg/multicast/pb"
+	"github.com/FavorLabs/favorX/pkg/rpc"
+	"github.com/FavorLabs/favorX/pkg/subscribe"
+)
+
+// allTopics is the pattern matching every topic and the messages without one.
+const allTopics = "#"
+
+var ErrInvalidTopic = errors.New("invalid topic pattern")
+
+// MatchTopic reports whether the topic matches the pattern. Topics are
+// segments separated by '/', in patterns '*' matches one segment and a
+// trailing '#' the remaining ones. Messages without a topic only match "#".
+func MatchTopic(pattern, topic string) bool {
+	if pattern == allTopics {
+		return true
+	}
+	if topic == "" {
+		return false
+	}
+	ps, ts := strings.Split(pattern, "/"), strings.Split(topic, "/")
+	for i, p := range ps {
+		if p == allTopics {
+			return true
+		}
+		if i >= len(ts) || (p != "*" && p != ts[i]) {
+			return false
+		}
+	}
+	return len(ps) == len(ts)
+}
+
+func matchTopics(patterns []string, topic string) bool {
+	for _, p := range patterns {
+		if MatchTopic(p, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTopic checks that the topic a message is published with has no
+// wildcards.
+func ValidateTopic(topic string) error {
+	if strings.ContainsAny(topic, "*"+allTopics) {
+		return fmt.Errorf("invalid topic %q: wildcards are only allowed in patterns", topic)
+	}
+	return nil
+}
+
+func validateTopicPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if p == "" {
+			return fmt.Errorf("%w: empty pattern", ErrInvalidTopic)
+		}
+		segments := strings.Split(p, "/")
+		for i, seg := range segments {
+			if strings.Contains(seg, allTopics) && (seg != allTopics || i != len(segments)-1) {
+				return fmt.Errorf("%w: %q, '#' must be the last segment", ErrInvalidTopic, p)
+			}
+			if strings.Contains(seg, "*") && seg != "*" {
+				return fmt.Errorf("%w: %q, '*' must be a whole segment", ErrInvalidTopic, p)
+			}
+		}
+	}
+	return nil
+}
+
+// topicSubs holds the topic patterns of the subscriptions to a joined group.
+type topicSubs struct {
+	mu         sync.Mutex
+	subs       map[rpc.ID][]string
+	advertised []string
+}
+
+func newTopicSubs() *topicSubs {
+	return &topicSubs{subs: make(map[rpc.ID][]string)}
+}
+
+// patterns returns the union of the patterns of the subscriptions.
+func (t *topicSubs) patterns() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.union()
+}
+
+func (t *topicSubs) union() []string {
+	set := make(map[string]struct{})
+	for _, patterns := range t.subs {
+		for _, p := range patterns {
+			if p == allTopics {
+				return []string{allTopics}
+			}
+			set[p] = struct{}{}
+		}
+	}
+	out := make([]string, 0, len(set))
+	for p := range set {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// update applies the change to the subscriptions and returns the union of
+// their patterns if it changed since it was last advertised.
+func (t *topicSubs) update(f func(subs map[rpc.ID][]string)) (patterns []string, changed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f(t.subs)
+	patterns = t.union()
+	if t.advertised != nil && strings.Join(patterns, "\n") == strings.Join(t.advertised, "\n") {
+		return nil, false
+	}
+	t.advertised = patterns
+	return patterns, true
+}
+
+// topicNotifier passes on the messages with a topic matching its patterns.
+type topicNotifier struct {
+	subscribe.INotifier
+	patterns []string
+}
+
+func (n *topicNotifier) Notify(key string, data interface{}) error {
+	var topic string
+	switch msg := data.(type) {
+	case Message:
+		topic = msg.Topic
+	case GroupMessage:
+		topic = msg.Topic
+	}
+	if !matchTopics(n.patterns, topic) {
+		return nil
+	}
+	return n.INotifier.Notify(key, data)
+}
+
+// SubscribeMulticastTopics subscribes to the multicast messages of the joined
+// group with a topic matching one of the patterns, or to all of them without
+// patterns.
+func (s *Service) SubscribeMulticastTopics(n *rpc.Notifier, sub *rpc.Subscription, gid boson.Address, patterns []string) error {
+	return s.subscribeTopics(n, sub, gid, "multicastMsg", patterns)
+}
+
+// SubscribeGroupMessageTopics subscribes to the messages sent to the node in
+// the joined group with a topic matching one of the patterns, or to all of
+// them without patterns.
+func (s *Service) SubscribeGroupMessageTopics(n *rpc.Notifier, sub *rpc.Subscription, gid boson.Address, patterns []string) error {
+	return s.subscribeTopics(n, sub, gid, "groupMessage", patterns)
+}
+
+func (s *Service) subscribeTopics(n *rpc.Notifier, sub *rpc.Subscription, gid boson.Address, kind string, patterns []string) error {
+	if err := validateTopicPatterns(patterns); err != nil {
+		return err
+	}
+	value, ok := s.groups.Load(gid.String())
+	if !ok || value.(*Group).getOption().GType != model.GTypeJoin {
+		return errors.New("the joined group notfound")
+	}
+	g := value.(*Group)
+	if len(patterns) == 0 {
+		patterns = []string{allTopics}
+	}
+	if kind == "multicastMsg" {
+		g.multicastSub = true
+	} else {
+		g.groupMsgSub = true
+	}
+	notifier := &topicNotifier{
+		INotifier: subscribe.NewNotifier(n, sub),
+		patterns:  patterns,
+	}
+	_ = s.subPub.Subscribe(notifier, "group", kind, gid.String())
+	s.watchTopics(g, sub, patterns)
+	return nil
+}
+
+// watchTopics adds the patterns of the subscription to the ones the node
+// advertises for the group until the subscription ends.
+func (s *Service) watchTopics(g *Group, sub *rpc.Subscription, patterns []string) {
+	s.updateTopics(g, func(subs map[rpc.ID][]string) {
+		subs[sub.ID] = patterns
+	})
+	go func() {
+		<-sub.Err()
+		s.updateTopics(g, func(subs map[rpc.ID][]string) {
+			delete(subs, sub.ID)
+		})
+	}()
+}
+
+func (s *Service) updateTopics(g *Group, f func(subs map[rpc.ID][]string)) {
+	patterns, changed := g.topics.update(f)
+	if !changed {
+		return
+	}
+	s.logger.Tracef("group %s: topics %v", g.gid, patterns)
+	go s.notify(&pb.Notify{
+		Status: int32(NotifyTopics),
+		Gids:   [][]byte{g.gid.Bytes()},
+		Topics: patterns,
+	})
+}
+
+// subscribedTopic reports whether a subscription of the group matches the
+// topic.
+func (g *Group) subscribedTopic(topic string) bool {
+	return matchTopics(g.topics.patterns(), topic)
+}
+
+// getGroupTopics returns the topic patterns of the joined groups, which are
+// exchanged in the handshake.
+func (s *Service) getGroupTopics() (out []*pb.Topics) {
+	if s.nodeMode.IsBootNode() || !s.nodeMode.IsFull() {
+		return nil
+	}
+	s.groups.Range(func(_, value interface{}) bool {
+		g := value.(*Group)
+		if g.getOption().GType == model.GTypeJoin {
+			out = append(out, &pb.Topics{
+				Gid:      g.gid.Bytes(),
+				Patterns: g.topics.patterns(),
+			})
+		}
+		return true
+	})
+	return out
+}
+
+func peerTopicsKey(gid, peer boson.Address) string {
+	return gid.String() + peer.String()
+}
+
+// setPeerTopics records the topic patterns the subscribers of the peer in the
+// group are interested in.
+func (s *Service) setPeerTopics(gid, peer boson.Address, patterns []string) {
+	if patterns == nil {
+		patterns = []string{}
+	}
+	s.peerTopics.Store(peerTopicsKey(gid, peer), patterns)
+}
+
+func (s *Service) forgetPeerTopics(peer boson.Address, gids ...boson.Address) {
+	if len(gids) > 0 {
+		for _, gid := range gids {
+			s.peerTopics.Delete(peerTopicsKey(gid, peer))
+		}
+		return
+	}
+	suffix := peer.String()
+	s.peerTopics.Range(func(key, _ interface{}) bool {
+		if strings.HasSuffix(key.(string), suffix) {
+			s.peerTopics.Delete(key)
+		}
+		return true
+	})
+}
+
+// interestedPeers returns the peers of the group with subscribers to the
+// topic. The peers that did not tell their topics are assumed to have some.
+func (s *Service) interestedPeers(gid boson.Address, topic string, peers []boson.Address) (out []boson.Address) {
+	for _, p := range peers {
+		v, ok := s.peerTopics.Load(peerTopicsKey(gid, p))
+		if !ok || matchTopics(v.([]string), topic) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
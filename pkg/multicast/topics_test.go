@@ -0,0 +1,177 @@
+package multicast
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/address"
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/boson/test"
+	"github.com/FavorLabs/favorX/pkg/multicast/model"
+	"github.com/FavorLabs/favorX/pkg/multicast/pb"
+	"github.com/FavorLabs/favorX/pkg/p2p/protobuf"
+	"github.com/FavorLabs/favorX/pkg/p2p/streamtest"
+	mockRoute "github.com/FavorLabs/favorX/pkg/routetab/mock"
+	"github.com/FavorLabs/favorX/pkg/rpc"
+	mockstate "github.com/FavorLabs/favorX/pkg/statestore/mock"
+	"github.com/FavorLabs/favorX/pkg/subscribe"
+	"github.com/FavorLabs/favorX/pkg/topology/kademlia/mock"
+	"github.com/FavorLabs/favorX/pkg/topology/pslice"
+)
+
+func TestMatchTopic(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		topic   string
+		match   bool
+	}{
+		{pattern: "#", topic: "", match: true},
+		{pattern: "#", topic: "chat/room1", match: true},
+		{pattern: "chat", topic: "chat", match: true},
+		{pattern: "chat", topic: "chat/room1", match: false},
+		{pattern: "chat/*", topic: "chat/room1", match: true},
+		{pattern: "chat/*", topic: "chat", match: false},
+		{pattern: "chat/*", topic: "chat/room1/alice", match: false},
+		{pattern: "chat/#", topic: "chat", match: true},
+		{pattern: "chat/#", topic: "chat/room1/alice", match: true},
+		{pattern: "*/room1", topic: "chat/room1", match: true},
+		{pattern: "*/room1", topic: "chat/room2", match: false},
+		{pattern: "chat/*", topic: "", match: false},
+	} {
+		if got := MatchTopic(tc.pattern, tc.topic); got != tc.match {
+			t.Errorf("MatchTopic(%q, %q) = %v, want %v", tc.pattern, tc.topic, got, tc.match)
+		}
+	}
+
+	for _, p := range []string{"", "chat/#/room", "chat#", "chat/room*"} {
+		if err := validateTopicPatterns([]string{p}); !errors.Is(err, ErrInvalidTopic) {
+			t.Errorf("pattern %q: got error %v, want %v", p, err, ErrInvalidTopic)
+		}
+	}
+	if err := ValidateTopic("chat/*"); err == nil {
+		t.Error("no error for a topic with a wildcard")
+	}
+}
+
+func TestTopicNotifier(t *testing.T) {
+	n := subscribe.NewNotifierWithMsgChan()
+	tn := &topicNotifier{INotifier: n, patterns: []string{"chat/*"}}
+	for _, topic := range []string{"chat/room1", "news", ""} {
+		_ = tn.Notify("", Message{Topic: topic})
+		_ = tn.Notify("", GroupMessage{Topic: topic})
+	}
+	if len(n.MsgChan) != 2 {
+		t.Fatalf("got %d messages, want 2", len(n.MsgChan))
+	}
+	for i := 0; i < 2; i++ {
+		switch msg := (<-n.MsgChan).(type) {
+		case Message:
+			if msg.Topic != "chat/room1" {
+				t.Fatalf("got multicast message of topic %q", msg.Topic)
+			}
+		case GroupMessage:
+			if msg.Topic != "chat/room1" {
+				t.Fatalf("got group message of topic %q", msg.Topic)
+			}
+		}
+	}
+}
+
+func TestTopicsAdvertisement(t *testing.T) {
+	route := mockRoute.NewMockRouteTable()
+	kad := mock.NewMockKademlia()
+	gid := GenerateGID("team")
+	member, other := test.RandomAddress(), test.RandomAddress()
+
+	s := NewService(test.RandomAddress(), address.NewModel(), nil, nil, kad, &route, mockstate.NewStateStore(), logger, subscribe.NewSubPub(), Option{Dev: true})
+	recorder := streamtest.New(streamtest.WithProtocols(s.Protocol()), streamtest.WithBaseAddr(member))
+
+	notify := func(msg *pb.Notify, done func() bool) {
+		t.Helper()
+		stream, err := recorder.NewStream(context.Background(), s.self, nil, protocolName, protocolVersion, streamNotify)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stream.Close()
+		if err := protobuf.NewWriter(stream).WriteMsg(msg); err != nil {
+			t.Fatal(err)
+		}
+		for start := time.Now(); !done(); time.Sleep(10 * time.Millisecond) {
+			if time.Since(start) > 5*time.Second {
+				t.Fatalf("notify %+v not handled", msg)
+			}
+		}
+	}
+	notify(&pb.Notify{Status: int32(NotifyTopics), Gids: [][]byte{gid.Bytes()}, Topics: []string{"chat/*"}}, func() bool {
+		_, ok := s.peerTopics.Load(peerTopicsKey(gid, member))
+		return ok
+	})
+
+	conn := pslice.New(1, s.self)
+	conn.Add(member)
+	conn.Add(other)
+	s.connectedPeers.Store(gid.String(), conn)
+
+	// the peer without advertised topics is assumed to be interested
+	for _, tc := range []struct {
+		topic string
+		want  []boson.Address
+	}{
+		{topic: "chat/room1", want: []boson.Address{member, other}},
+		{topic: "news", want: []boson.Address{other}},
+		{topic: "", want: []boson.Address{member, other}},
+	} {
+		got := s.getForwardNodes(gid, tc.topic)
+		if len(got) != len(tc.want) {
+			t.Fatalf("topic %q: got forward nodes %v, want %v", tc.topic, got, tc.want)
+		}
+		for _, w := range tc.want {
+			if !w.MemberOf(got) {
+				t.Fatalf("topic %q: got forward nodes %v, want %v", tc.topic, got, tc.want)
+			}
+		}
+	}
+
+	// members without interested subscribers are still used as the last resort
+	s.setPeerTopics(gid, other, nil)
+	if got := s.getForwardNodes(gid, "news"); len(got) != 2 {
+		t.Fatalf("got forward nodes %v, want all members", got)
+	}
+
+	// the topics of the peer leaving the group are forgotten
+	notify(&pb.Notify{Status: int32(NotifyLeaveGroup), Gids: [][]byte{gid.Bytes()}}, func() bool {
+		_, ok := s.peerTopics.Load(peerTopicsKey(gid, member))
+		return !ok
+	})
+}
+
+func TestGroupTopics(t *testing.T) {
+	route := mockRoute.NewMockRouteTable()
+	kad := mock.NewMockKademlia()
+	s := NewService(test.RandomAddress(), address.NewModel().SetMode(address.FullNode), nil, nil, kad, &route, mockstate.NewStateStore(), logger, subscribe.NewSubPub(), Option{Dev: true})
+	gid := GenerateGID("team")
+	if err := s.AddGroup([]model.ConfigNodeGroup{{Name: "team", GType: model.GTypeJoin}}); err != nil {
+		t.Fatal(err)
+	}
+	v, _ := s.groups.Load(gid.String())
+	g := v.(*Group)
+
+	if g.subscribedTopic("chat/room1") {
+		t.Fatal("topic subscribed without subscriptions")
+	}
+	s.watchTopics(g, &rpc.Subscription{ID: rpc.NewID()}, []string{"chat/*"})
+	s.watchTopics(g, &rpc.Subscription{ID: rpc.NewID()}, []string{"news", "chat/*"})
+	if !g.subscribedTopic("chat/room1") || !g.subscribedTopic("news") || g.subscribedTopic("") {
+		t.Fatalf("got subscribed topics %v", g.topics.patterns())
+	}
+	topics := s.getGroupTopics()
+	if len(topics) != 1 || !boson.NewAddress(topics[0].Gid).Equal(gid) || len(topics[0].Patterns) != 2 {
+		t.Fatalf("got handshake topics %v", topics)
+	}
+	s.watchTopics(g, &rpc.Subscription{ID: rpc.NewID()}, []string{allTopics})
+	if p := g.topics.patterns(); len(p) != 1 || p[0] != allTopics {
+		t.Fatalf("got patterns %v, want [#]", p)
+	}
+}
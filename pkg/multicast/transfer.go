@@ -0,0 +1,829 @@
+package multicast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/file/joiner"
+	"github.com/FavorLabs/favorX/pkg/file/pipeline/builder"
+	"github.com/FavorLabs/favorX/pkg/multicast/model"
+	"github.com/FavorLabs/favorX/pkg/multicast/pb"
+	"github.com/FavorLabs/favorX/pkg/p2p"
+	"github.com/FavorLabs/favorX/pkg/p2p/protobuf"
+	"github.com/FavorLabs/favorX/pkg/rpc"
+	"github.com/FavorLabs/favorX/pkg/sctx"
+	"github.com/FavorLabs/favorX/pkg/storage"
+)
+
+const (
+	transferFrameSize = 64 * 1024
+	transferWindow    = 16 * transferFrameSize // bytes sent the peer has not read yet
+
+	transferMaxAttempts   = 5 // attempts to resume a transfer without progress
+	transferRetryDelay    = time.Second
+	transferResumeTimeout = time.Minute     // how long a broken transfer waits for the sender to resume it
+	transferCloseTimeout  = time.Second * 5 // how long a closed transfer waits to be finished with the peer
+
+	// OffloadThreshold is the size above which a request is better stored
+	// as content chunks, so that only its reference is sent.
+	OffloadThreshold = 4 * 1024 * 1024
+)
+
+var (
+	ErrTransferNotFound  = errors.New("transfer not found")
+	ErrInvalidTransferID = errors.New("invalid transfer id")
+
+	errTransferClosed  = errors.New("transfer closed")
+	errTransferAborted = errors.New("transfer aborted by the peer")
+	errTransferExpired = errors.New("transfer not resumed in time")
+	errTransferLost    = errors.New("transfer state lost by the peer")
+	errTransferFrame   = errors.New("invalid transfer frame")
+
+	transferIDRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+)
+
+// sendFlow is the payload a side of the transfer writes.
+type sendFlow struct {
+	buf       []byte // written bytes the peer may still need
+	base      uint64 // offset of buf in the payload
+	read      uint64 // bytes the peer read, one more once it read the end
+	discard   uint64 // bytes the peer already has of the next writes
+	eof       bool
+	offloaded bool // the payload is sent as content chunks
+}
+
+func (f *sendFlow) end() uint64 {
+	return f.base + uint64(len(f.buf))
+}
+
+// forget drops the bytes before the offset, which the peer does not need again.
+func (f *sendFlow) forget(offset uint64) {
+	if offset <= f.base {
+		return
+	}
+	if end := f.end(); offset > end {
+		f.discard += offset - end
+		f.buf = nil
+		f.base = offset
+		return
+	}
+	f.buf = f.buf[offset-f.base:]
+	f.base = offset
+}
+
+func (f *sendFlow) completed() bool {
+	return f.offloaded || (f.eof && f.read == f.end()+1)
+}
+
+// recvFlow is the payload of the peer a side of the transfer reads.
+type recvFlow struct {
+	buf   []byte // received bytes not read yet
+	read  uint64 // offset of buf in the payload
+	eof   bool   // the end of the payload is received
+	done  bool   // the end of the payload is read
+	local bool   // the payload is fetched from content chunks
+}
+
+func (f *recvFlow) received() uint64 {
+	return f.read + uint64(len(f.buf))
+}
+
+// ack is the offset acknowledging what is read.
+func (f *recvFlow) ack() uint64 {
+	if f.done {
+		return f.read + 1
+	}
+	return f.read
+}
+
+// Transfer is a request streamed to a member of a group and the response
+// streamed back. Each side writes its payload and reads the one of the peer,
+// in frames acknowledged once read, so a writer is held back while the reader
+// falls behind. A broken stream is replaced by the sender, continuing where
+// the receiver left off.
+type Transfer struct {
+	s       *Service
+	id      string
+	session string
+	gid     boson.Address
+	peer    boson.Address
+	topic   string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{} // closed when the sender stops
+
+	mu      sync.Mutex
+	changed chan struct{}
+	out     sendFlow
+	in      recvFlow
+	ref     boson.Address
+	pinned  []boson.Address // chunks of the offloaded request, pinned while the transfer lasts
+	release sync.Once
+	err     error
+	notify  bool   // err is sent to the peer
+	conn    uint64 // generation of the stream carrying the transfer
+	stream  p2p.Stream
+}
+
+func (s *Service) newTransfer(id, session string, gid, peer boson.Address, topic string) *Transfer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Transfer{
+		s:       s,
+		id:      id,
+		session: session,
+		gid:     gid,
+		peer:    peer,
+		topic:   topic,
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		changed: make(chan struct{}),
+	}
+}
+
+// SessionID is the id the transfer is read and written with over the API.
+func (t *Transfer) SessionID() string {
+	return t.session
+}
+
+// signal wakes up the ones waiting for the transfer to change, t.mu must be held.
+func (t *Transfer) signal() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+func (t *Transfer) fail(err error, notify bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return
+	}
+	t.err = err
+	t.notify = notify
+	t.cancel()
+	t.signal()
+}
+
+// wait blocks until the transfer changes, the changed channel must be taken
+// with t.mu held.
+func wait(ctx context.Context, changed <-chan struct{}) error {
+	select {
+	case <-changed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Read reads the payload of the peer.
+func (t *Transfer) Read(p []byte) (int, error) {
+	return t.ReadContext(context.Background(), p)
+}
+
+// ReadContext reads the payload of the peer, it blocks until some of it is
+// received, returning io.EOF once all of it is read.
+func (t *Transfer) ReadContext(ctx context.Context, p []byte) (int, error) {
+	for {
+		t.mu.Lock()
+		if len(t.in.buf) > 0 {
+			n := copy(p, t.in.buf)
+			t.in.buf = t.in.buf[n:]
+			t.in.read += uint64(n)
+			t.signal()
+			t.mu.Unlock()
+			return n, nil
+		}
+		if t.in.eof {
+			if !t.in.done {
+				t.in.done = true
+				t.signal()
+			}
+			t.mu.Unlock()
+			return 0, io.EOF
+		}
+		if t.err != nil {
+			err := t.err
+			t.mu.Unlock()
+			return 0, err
+		}
+		changed := t.changed
+		t.mu.Unlock()
+		if err := wait(ctx, changed); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Write writes to the payload sent to the peer.
+func (t *Transfer) Write(p []byte) (int, error) {
+	return t.WriteContext(context.Background(), p)
+}
+
+// WriteContext writes to the payload sent to the peer, it blocks while the
+// window of bytes the peer has not read is full.
+func (t *Transfer) WriteContext(ctx context.Context, p []byte) (written int, err error) {
+	for len(p) > 0 {
+		t.mu.Lock()
+		switch {
+		case t.err != nil:
+			err = t.err
+		case t.out.eof:
+			err = errors.New("write after the end of the payload")
+		}
+		if err != nil {
+			t.mu.Unlock()
+			return written, err
+		}
+		n := 0
+		if t.out.discard > 0 {
+			n = len(p)
+			if uint64(n) > t.out.discard {
+				n = int(t.out.discard)
+			}
+			t.out.discard -= uint64(n)
+		} else if room := transferWindow - len(t.out.buf); room > 0 {
+			n = len(p)
+			if n > room {
+				n = room
+			}
+			t.out.buf = append(t.out.buf, p[:n]...)
+			t.signal()
+		}
+		if n > 0 {
+			p = p[n:]
+			written += n
+			t.mu.Unlock()
+			continue
+		}
+		changed := t.changed
+		t.mu.Unlock()
+		if err = wait(ctx, changed); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// CloseWrite ends the payload sent to the peer.
+func (t *Transfer) CloseWrite() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return t.err
+	}
+	t.out.eof = true
+	t.signal()
+	return nil
+}
+
+// Close releases the transfer. A request not finished can be resumed with
+// its id until the receiver gives up on it, a response read to the end is
+// finished with the peer first.
+func (t *Transfer) Close() error {
+	t.mu.Lock()
+	done := t.in.done
+	t.mu.Unlock()
+	if done {
+		select {
+		case <-t.done:
+		case <-time.After(transferCloseTimeout):
+		}
+	}
+	t.fail(errTransferClosed, false)
+	t.s.removeTransfer(t)
+	return nil
+}
+
+// Abort ends the transfer on both sides.
+func (t *Transfer) Abort() error {
+	t.fail(errTransferClosed, true)
+	t.s.removeTransfer(t)
+	return nil
+}
+
+func transferKey(peer boson.Address, id string) string {
+	return peer.String() + id
+}
+
+func (s *Service) removeTransfer(t *Transfer) {
+	if v, ok := s.transfers.Load(t.session); ok && v == t {
+		s.transfers.Delete(t.session)
+	}
+	key := transferKey(t.peer, t.id)
+	if v, ok := s.peerTransfers.Load(key); ok && v == t {
+		s.peerTransfers.Delete(key)
+	}
+	t.release.Do(func() {
+		s.unpin(t.pinned)
+	})
+}
+
+// unpin releases the chunks of an offloaded request to the garbage collector.
+func (s *Service) unpin(chunks []boson.Address) {
+	for _, addr := range chunks {
+		if err := s.content.Set(context.Background(), storage.ModeSetUnpin, addr); err != nil {
+			s.logger.Debugf("group: unpin offloaded chunk %s: %v", addr, err)
+		}
+	}
+}
+
+// pinPutter stores the chunks of an offloaded request pinned, so they are
+// not evicted before the receiver fetches them, and records them to be
+// unpinned once the transfer is over.
+type pinPutter struct {
+	storage.Putter
+	mu     sync.Mutex
+	pinned []boson.Address
+}
+
+func (p *pinPutter) Put(ctx context.Context, _ storage.ModePut, chs ...boson.Chunk) ([]bool, error) {
+	exist, err := p.Putter.Put(ctx, storage.ModePutUploadPin, chs...)
+	if err != nil {
+		return exist, err
+	}
+	p.mu.Lock()
+	for _, ch := range chs {
+		p.pinned = append(p.pinned, ch.Address())
+	}
+	p.mu.Unlock()
+	return exist, nil
+}
+
+// GetTransfer returns the transfer with the session id.
+func (s *Service) GetTransfer(session string) (*Transfer, error) {
+	v, ok := s.transfers.Load(session)
+	if !ok {
+		return nil, ErrTransferNotFound
+	}
+	return v.(*Transfer), nil
+}
+
+// SetContentStore sets the store requests are offloaded to and fetched from.
+func (s *Service) SetContentStore(store storage.Storer) {
+	s.content = store
+}
+
+// OpenTransfer opens a streamed request to the target in the group, which
+// response is read from the transfer. The id resumes the request of an
+// earlier transfer, a new one is generated if it is empty. The request is
+// written to the transfer, unless the content is given: it is the whole
+// request then, stored as content chunks with only the reference sent. The
+// chunks are pinned until the transfer is removed.
+func (s *Service) OpenTransfer(ctx context.Context, gid, dest boson.Address, topic, id string, content io.Reader) (*Transfer, error) {
+	if id == "" {
+		id = string(rpc.NewID())
+	} else if !transferIDRegexp.MatchString(id) {
+		return nil, ErrInvalidTransferID
+	}
+	t := s.newTransfer(id, id, gid, dest, topic)
+	if content != nil && s.content != nil {
+		putter := &pinPutter{Putter: s.content}
+		pipe := builder.NewPipelineBuilder(ctx, putter, storage.ModePutUploadPin, false)
+		ref, err := builder.FeedPipeline(ctx, pipe, content)
+		if err != nil {
+			s.unpin(putter.pinned)
+			return nil, fmt.Errorf("offload request: %w", err)
+		}
+		t.ref = ref
+		t.pinned = putter.pinned
+		t.out.eof = true
+		t.out.offloaded = true
+	}
+	if v, ok := s.transfers.Load(id); ok {
+		v.(*Transfer).fail(errTransferClosed, false)
+	}
+	s.transfers.Store(id, t)
+	go t.send()
+	if content != nil && s.content == nil {
+		go func() {
+			if _, err := io.Copy(t, content); err != nil {
+				t.fail(err, true)
+				return
+			}
+			_ = t.CloseWrite()
+		}()
+	}
+	return t, nil
+}
+
+// send carries the transfer opened by the node, over new streams while the
+// earlier ones break and progress is made.
+func (t *Transfer) send() {
+	defer close(t.done)
+	defer t.s.removeTransfer(t)
+
+	var progress uint64
+	for attempt := 1; ; attempt++ {
+		err := t.connect()
+		t.mu.Lock()
+		if t.err != nil {
+			t.mu.Unlock()
+			return
+		}
+		if moved := t.out.read + t.in.received(); moved > progress {
+			progress = moved
+			attempt = 1
+		}
+		t.mu.Unlock()
+		if err == nil {
+			return
+		}
+		t.s.logger.Debugf("group: transfer %s to %s: %v", t.id, t.peer, err)
+		if attempt >= transferMaxAttempts {
+			t.fail(fmt.Errorf("transfer to %s: %w", t.peer, err), false)
+			return
+		}
+		select {
+		case <-time.After(transferRetryDelay):
+		case <-t.ctx.Done():
+			return
+		}
+	}
+}
+
+// connect opens a stream to the peer and carries the transfer over it.
+func (t *Transfer) connect() (err error) {
+	stream, err := t.s.getStream(t.ctx, t.peer, streamTransfer)
+	if err != nil {
+		return err
+	}
+	gen, err := t.attach(stream)
+	if err != nil {
+		_ = stream.Reset()
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = stream.Reset()
+		} else {
+			go stream.FullClose()
+		}
+	}()
+
+	w, r := protobuf.NewWriterAndReader(stream)
+	t.mu.Lock()
+	req := &pb.Transfer{
+		Gid:    t.gid.Bytes(),
+		Id:     t.id,
+		Topic:  t.topic,
+		Offset: t.in.received(),
+	}
+	if !t.ref.IsZero() {
+		req.Reference = t.ref.Bytes()
+	}
+	t.mu.Unlock()
+	if err = w.WriteMsgWithContext(t.ctx, req); err != nil {
+		return err
+	}
+	var resp pb.Transfer
+	if err = r.ReadMsgWithContext(t.ctx, &resp); err != nil {
+		return err
+	}
+	if resp.Err != "" {
+		err = errors.New(resp.Err)
+		t.fail(err, false)
+		return err
+	}
+	t.mu.Lock()
+	if resp.Offset < t.out.base {
+		t.mu.Unlock()
+		t.fail(errTransferLost, false)
+		return errTransferLost
+	}
+	t.out.forget(resp.Offset)
+	t.mu.Unlock()
+	return t.serve(t.ctx, w, r, gen, resp.Offset)
+}
+
+// attach makes the stream the one carrying the transfer, dropping the one
+// before.
+func (t *Transfer) attach(stream p2p.Stream) (gen uint64, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return 0, t.err
+	}
+	if t.stream != nil {
+		_ = t.stream.Reset()
+	}
+	t.conn++
+	t.stream = stream
+	t.signal()
+	return t.conn, nil
+}
+
+// serve exchanges the frames of the transfer over the stream until both
+// payloads are read to the end, the transfer fails or the stream breaks.
+// Sending starts at the offset the peer received. A failure the peer is told
+// about ends the stream like a finished transfer.
+func (t *Transfer) serve(ctx context.Context, w protobuf.Writer, r protobuf.Reader, gen, sent uint64) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			var f pb.Frame
+			if err := r.ReadMsgWithContext(ctx, &f); err != nil {
+				readErr <- err
+				return
+			}
+			if err := t.onFrame(&f); err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	var (
+		acked   uint64 // ack sent over the stream
+		eofSent bool
+	)
+	for {
+		t.mu.Lock()
+		if t.conn != gen {
+			t.mu.Unlock()
+			return errors.New("transfer moved to another stream")
+		}
+		if t.err != nil {
+			err, notify := t.err, t.notify
+			t.mu.Unlock()
+			if notify && w.WriteMsgWithContext(ctx, &pb.Frame{Err: err.Error()}) == nil {
+				return nil
+			}
+			return err
+		}
+		var (
+			f    pb.Frame
+			send bool
+		)
+		if ack := t.in.ack(); !t.in.local && ack > acked {
+			f.Ack = ack
+			send = true
+		}
+		end := t.out.end()
+		if limit := t.out.read + transferWindow; sent < end && sent < limit {
+			n := end - sent
+			if n > transferFrameSize {
+				n = transferFrameSize
+			}
+			if sent+n > limit {
+				n = limit - sent
+			}
+			f.Offset = sent
+			f.Data = append([]byte(nil), t.out.buf[sent-t.out.base:sent-t.out.base+n]...)
+			send = true
+		}
+		if t.out.eof && !t.out.offloaded && !eofSent && sent+uint64(len(f.Data)) == end {
+			f.Offset = sent
+			f.Eof = true
+			send = true
+		}
+		if !send {
+			if t.out.completed() && t.in.done {
+				t.mu.Unlock()
+				return nil
+			}
+			changed := t.changed
+			t.mu.Unlock()
+			select {
+			case <-changed:
+			case err := <-readErr:
+				return err
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			continue
+		}
+		t.mu.Unlock()
+
+		if err := w.WriteMsgWithContext(ctx, &f); err != nil {
+			return err
+		}
+		if f.Ack > acked {
+			acked = f.Ack
+		}
+		sent += uint64(len(f.Data))
+		eofSent = eofSent || f.Eof
+	}
+}
+
+func (t *Transfer) onFrame(f *pb.Frame) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if f.Err != "" {
+		if t.err == nil {
+			t.err = fmt.Errorf("%w: %s", errTransferAborted, f.Err)
+			t.cancel()
+			t.signal()
+		}
+		return t.err
+	}
+	if f.Ack > t.out.read && !t.out.offloaded {
+		if f.Ack > t.out.end()+1 || (f.Ack == t.out.end()+1 && !t.out.eof) {
+			return errTransferFrame
+		}
+		t.out.read = f.Ack
+		if f.Ack <= t.out.end() {
+			t.out.forget(f.Ack)
+		}
+		t.signal()
+	}
+	if len(f.Data) == 0 && !f.Eof {
+		return nil
+	}
+	received := t.in.received()
+	if t.in.eof && f.Offset+uint64(len(f.Data)) <= received {
+		// sent again over a new stream
+		return nil
+	}
+	if t.in.local || t.in.eof || f.Offset > received {
+		return errTransferFrame
+	}
+	data := f.Data
+	if skip := received - f.Offset; skip < uint64(len(data)) {
+		data = data[skip:]
+	} else {
+		data = nil
+	}
+	if len(t.in.buf)+len(data) > transferWindow {
+		return errTransferFrame
+	}
+	t.in.buf = append(t.in.buf, data...)
+	t.in.eof = f.Eof
+	t.signal()
+	return nil
+}
+
+// onTransfer takes the transfers the peers open, the new ones are announced
+// to the subscribers of the group messages with the session id to read the
+// request and write the response with.
+func (s *Service) onTransfer(ctx context.Context, peer p2p.Peer, stream p2p.Stream) (err error) {
+	w, r := protobuf.NewWriterAndReader(stream)
+	var req pb.Transfer
+	if err = r.ReadMsgWithContext(ctx, &req); err != nil {
+		_ = stream.Reset()
+		return err
+	}
+	t, err := s.acceptTransfer(peer.Address, &req)
+	if err != nil {
+		err = w.WriteMsgWithContext(ctx, &pb.Transfer{Gid: req.Gid, Id: req.Id, Err: err.Error()})
+		if err != nil {
+			_ = stream.Reset()
+		} else {
+			go stream.FullClose()
+		}
+		return nil
+	}
+	gen, err := t.attach(stream)
+	if err != nil {
+		_ = stream.Reset()
+		return nil
+	}
+
+	t.mu.Lock()
+	var sent uint64
+	if req.Offset < t.out.base {
+		err = errTransferLost
+	} else {
+		t.out.forget(req.Offset)
+		sent = req.Offset
+	}
+	resp := &pb.Transfer{Gid: req.Gid, Id: req.Id, Offset: t.in.received()}
+	t.mu.Unlock()
+	if err != nil {
+		resp.Err = err.Error()
+	}
+	if err = w.WriteMsgWithContext(ctx, resp); err != nil || resp.Err != "" {
+		_ = stream.Reset()
+		return nil
+	}
+
+	err = t.serve(ctx, w, r, gen, sent)
+	if err == nil {
+		s.removeTransfer(t)
+		go stream.FullClose()
+		return nil
+	}
+	_ = stream.Reset()
+	s.logger.Debugf("group: transfer %s from %s: %v", t.id, t.peer, err)
+	time.AfterFunc(transferResumeTimeout, func() {
+		t.mu.Lock()
+		resumed := t.conn != gen
+		t.mu.Unlock()
+		if !resumed {
+			t.fail(errTransferExpired, false)
+			s.removeTransfer(t)
+		}
+	})
+	return nil
+}
+
+// acceptTransfer returns the transfer the request resumes or starts.
+func (s *Service) acceptTransfer(peer boson.Address, req *pb.Transfer) (*Transfer, error) {
+	key := transferKey(peer, req.Id)
+	if v, ok := s.peerTransfers.Load(key); ok {
+		return v.(*Transfer), nil
+	}
+	if req.Offset > 0 || !transferIDRegexp.MatchString(req.Id) {
+		return nil, ErrTransferNotFound
+	}
+
+	gid := boson.NewAddress(req.Gid)
+	v, ok := s.groups.Load(gid.String())
+	switch {
+	case !ok || v.(*Group).getOption().GType != model.GTypeJoin:
+		return nil, errors.New("target not in the group")
+	case !v.(*Group).groupMsgSub:
+		return nil, errors.New("target not subscribe the group message")
+	case !v.(*Group).subscribedTopic(req.Topic):
+		return nil, errors.New("target not subscribe the topic")
+	}
+	t := s.newTransfer(req.Id, string(rpc.NewID()), gid, peer, req.Topic)
+	close(t.done)
+	if v, loaded := s.peerTransfers.LoadOrStore(key, t); loaded {
+		return v.(*Transfer), nil
+	}
+	s.transfers.Store(t.session, t)
+	if len(req.Reference) > 0 {
+		t.in.local = true
+		go t.fetch(boson.NewAddress(req.Reference))
+	}
+	s.publishGroupMessage(v.(*Group), GroupMessage{
+		SessionID: rpc.ID(t.session),
+		GID:       gid,
+		From:      peer,
+		Topic:     req.Topic,
+		Stream:    true,
+	})
+	return t, nil
+}
+
+// fetch reads the request stored as content chunks from the sender.
+func (t *Transfer) fetch(ref boson.Address) {
+	err := t.s.fetchContent(t, ref)
+	if err != nil {
+		t.fail(fmt.Errorf("fetch request %s: %w", ref, err), true)
+	}
+}
+
+func (s *Service) fetchContent(t *Transfer, ref boson.Address) error {
+	if s.content == nil {
+		return errors.New("content store not available")
+	}
+	ctx := sctx.SetTargets(t.ctx, t.peer.String())
+	ctx = sctx.SetRootHash(ctx, ref)
+	j, _, err := joiner.New(ctx, s.content, storage.ModeGetRequest, ref, 0)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, transferFrameSize)
+	for {
+		n, err := j.Read(buf)
+		if n > 0 {
+			if err := t.receive(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			t.mu.Lock()
+			t.in.eof = true
+			t.signal()
+			t.mu.Unlock()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// receive adds the data to the payload read from the transfer, once the
+// window has room for it.
+func (t *Transfer) receive(data []byte) error {
+	for {
+		t.mu.Lock()
+		if t.err != nil {
+			err := t.err
+			t.mu.Unlock()
+			return err
+		}
+		if len(t.in.buf)+len(data) <= transferWindow {
+			t.in.buf = append(t.in.buf, data...)
+			t.signal()
+			t.mu.Unlock()
+			return nil
+		}
+		changed := t.changed
+		t.mu.Unlock()
+		if err := wait(t.ctx, changed); err != nil {
+			return err
+		}
+	}
+}
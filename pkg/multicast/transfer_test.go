@@ -0,0 +1,253 @@
+package multicast
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/address"
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/boson/test"
+	"github.com/FavorLabs/favorX/pkg/multicast/model"
+	"github.com/FavorLabs/favorX/pkg/p2p/streamtest"
+	mockRoute "github.com/FavorLabs/favorX/pkg/routetab/mock"
+	"github.com/FavorLabs/favorX/pkg/rpc"
+	mockstate "github.com/FavorLabs/favorX/pkg/statestore/mock"
+	"github.com/FavorLabs/favorX/pkg/storage"
+	mockstorer "github.com/FavorLabs/favorX/pkg/storage/mock"
+	"github.com/FavorLabs/favorX/pkg/subscribe"
+	"github.com/FavorLabs/favorX/pkg/topology/kademlia/mock"
+)
+
+// newTransferPair returns a sender and a receiver subscribed to the group
+// messages of the group.
+func newTransferPair(t *testing.T) (sender, receiver *Service, gid boson.Address, messages <-chan interface{}) {
+	t.Helper()
+	route := mockRoute.NewMockRouteTable()
+	kad := mock.NewMockKademlia()
+	subPub := subscribe.NewSubPub()
+	receiver = NewService(test.RandomAddress(), address.NewModel(), nil, nil, kad, &route, mockstate.NewStateStore(), logger, subPub, Option{Dev: true})
+
+	gid = GenerateGID("team")
+	if err := receiver.AddGroup([]model.ConfigNodeGroup{{Name: "team", GType: model.GTypeJoin}}); err != nil {
+		t.Fatal(err)
+	}
+	notifier := subscribe.NewNotifierWithMsgChan()
+	_ = subPub.Subscribe(notifier, "group", "groupMessage", gid.String())
+	v, _ := receiver.groups.Load(gid.String())
+	v.(*Group).groupMsgSub = true
+	receiver.watchTopics(v.(*Group), &rpc.Subscription{ID: rpc.NewID()}, []string{allTopics})
+
+	recorder := streamtest.New(streamtest.WithProtocols(receiver.Protocol()))
+	sender = NewService(test.RandomAddress(), address.NewModel(), nil, recorder, kad, &route, mockstate.NewStateStore(), logger, subscribe.NewSubPub(), Option{Dev: true})
+
+	// the subscription is registered asynchronously
+	time.Sleep(100 * time.Millisecond)
+	return sender, receiver, gid, notifier.MsgChan
+}
+
+func randomPayload(t *testing.T, size int) []byte {
+	t.Helper()
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// accept returns the transfer announced to the subscribers.
+func accept(t *testing.T, receiver *Service, messages <-chan interface{}) *Transfer {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		m := msg.(GroupMessage)
+		if !m.Stream {
+			t.Fatalf("got message %+v, want a stream", m)
+		}
+		tr, err := receiver.GetTransfer(string(m.SessionID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tr
+	case <-time.After(5 * time.Second):
+		t.Fatal("transfer not announced")
+	}
+	return nil
+}
+
+func readAll(t *testing.T, tr *Transfer) []byte {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var out bytes.Buffer
+	buf := make([]byte, 10000)
+	for {
+		n, err := tr.ReadContext(ctx, buf)
+		out.Write(buf[:n])
+		if err == io.EOF {
+			return out.Bytes()
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// respond writes the whole payload in the background, the peer reading it
+// concurrently.
+func respond(t *testing.T, tr *Transfer, payload []byte) {
+	go func() {
+		_, err := tr.Write(payload)
+		if err == nil {
+			err = tr.CloseWrite()
+		}
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+}
+
+func TestTransfer(t *testing.T) {
+	sender, receiver, gid, messages := newTransferPair(t)
+	req := randomPayload(t, 3*transferWindow+100)
+	resp := randomPayload(t, 2*transferWindow)
+
+	tr, err := sender.OpenTransfer(context.Background(), gid, receiver.self, "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written int64
+	go func() {
+		for i := 0; i < len(req); i += 1000 {
+			end := i + 1000
+			if end > len(req) {
+				end = len(req)
+			}
+			n, _ := tr.Write(req[i:end])
+			atomic.AddInt64(&written, int64(n))
+		}
+		_ = tr.CloseWrite()
+	}()
+
+	in := accept(t, receiver, messages)
+	// the receiver does not read yet, so the sender is held back
+	time.Sleep(200 * time.Millisecond)
+	if w := atomic.LoadInt64(&written); w > 2*transferWindow {
+		t.Fatalf("written %d bytes the receiver has not read", w)
+	}
+
+	if got := readAll(t, in); !bytes.Equal(got, req) {
+		t.Fatalf("got request of %d bytes, want %d", len(got), len(req))
+	}
+	respond(t, in, resp)
+	if got := readAll(t, tr); !bytes.Equal(got, resp) {
+		t.Fatalf("got response of %d bytes, want %d", len(got), len(resp))
+	}
+
+	// both sides let go of the finished transfer
+	_ = tr.Close()
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		_, err1 := sender.GetTransfer(tr.SessionID())
+		_, err2 := receiver.GetTransfer(in.SessionID())
+		if err1 == ErrTransferNotFound && err2 == ErrTransferNotFound {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("finished transfer not removed")
+		}
+	}
+}
+
+func TestTransfer_Resume(t *testing.T) {
+	sender, receiver, gid, messages := newTransferPair(t)
+	req := randomPayload(t, 2*transferWindow)
+	resp := randomPayload(t, transferWindow/2)
+
+	tr, err := sender.OpenTransfer(context.Background(), gid, receiver.self, "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	respond(t, tr, req)
+	in := accept(t, receiver, messages)
+
+	// break the stream once the receiver read some of the request
+	buf := make([]byte, transferWindow/2)
+	if _, err := io.ReadFull(in, buf); err != nil {
+		t.Fatal(err)
+	}
+	tr.mu.Lock()
+	_ = tr.stream.Reset()
+	tr.mu.Unlock()
+
+	rest := readAll(t, in)
+	if got := append(buf, rest...); !bytes.Equal(got, req) {
+		t.Fatalf("got request of %d bytes, want %d", len(got), len(req))
+	}
+	respond(t, in, resp)
+	if got := readAll(t, tr); !bytes.Equal(got, resp) {
+		t.Fatalf("got response of %d bytes, want %d", len(got), len(resp))
+	}
+	tr.mu.Lock()
+	conn := tr.conn
+	tr.mu.Unlock()
+	if conn < 2 {
+		t.Fatal("transfer not resumed over a new stream")
+	}
+}
+
+func TestTransfer_Offload(t *testing.T) {
+	sender, receiver, gid, messages := newTransferPair(t)
+	store := mockstorer.NewStorer()
+	sender.SetContentStore(store)
+	receiver.SetContentStore(store)
+	req := randomPayload(t, 3*transferWindow)
+
+	tr, err := sender.OpenTransfer(context.Background(), gid, receiver.self, "", "", bytes.NewReader(req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.ref.IsZero() {
+		t.Fatal("request not offloaded")
+	}
+	if pinned, err := store.Has(context.Background(), storage.ModeHasPin, tr.ref); err != nil || !pinned {
+		t.Fatalf("offloaded request pinned %v, error %v", pinned, err)
+	}
+	in := accept(t, receiver, messages)
+	if got := readAll(t, in); !bytes.Equal(got, req) {
+		t.Fatalf("got request of %d bytes, want %d", len(got), len(req))
+	}
+	respond(t, in, []byte("ok"))
+	if got := readAll(t, tr); string(got) != "ok" {
+		t.Fatalf("got response %q", got)
+	}
+
+	// the request is released once the transfer is over
+	if err := tr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range tr.pinned {
+		if pinned, err := store.Has(context.Background(), storage.ModeHasPin, addr); err != nil || pinned {
+			t.Fatalf("chunk %s of the request pinned %v, error %v", addr, pinned, err)
+		}
+	}
+}
+
+func TestTransfer_Refused(t *testing.T) {
+	sender, receiver, _, _ := newTransferPair(t)
+	tr, err := sender.OpenTransfer(context.Background(), GenerateGID("other"), receiver.self, "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := tr.ReadContext(ctx, make([]byte, 1)); err == nil || err.Error() != "target not in the group" {
+		t.Fatalf("got error %v, want the target not in the group", err)
+	}
+	if _, err := sender.OpenTransfer(context.Background(), GenerateGID("other"), receiver.self, "", "no spaces", nil); err != ErrInvalidTransferID {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidTransferID)
+	}
+}
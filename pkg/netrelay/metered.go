@@ -0,0 +1,134 @@
+package netrelay
+
+import (
+	"context"
+	"sync"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/p2p"
+)
+
+// relayChargeSize is the traffic relayed over a stream between its charges.
+const relayChargeSize = 64 * 1024
+
+// meteredStream charges the bytes relayed over the stream, every
+// relayChargeSize bytes and when the stream is closed. Once a charge fails
+// the stream fails with its error.
+type meteredStream struct {
+	p2p.Stream
+	charge func(bytes uint64) error
+
+	mu      sync.Mutex
+	pending uint64
+	err     error
+}
+
+func newMeteredStream(stream p2p.Stream, charge func(bytes uint64) error) *meteredStream {
+	return &meteredStream{
+		Stream: stream,
+		charge: charge,
+	}
+}
+
+func (m *meteredStream) Read(b []byte) (int, error) {
+	if err := m.failed(); err != nil {
+		return 0, err
+	}
+	n, err := m.Stream.Read(b)
+	if cerr := m.add(uint64(n), false); cerr != nil && err == nil {
+		err = cerr
+	}
+	return n, err
+}
+
+func (m *meteredStream) Write(b []byte) (int, error) {
+	if err := m.failed(); err != nil {
+		return 0, err
+	}
+	n, err := m.Stream.Write(b)
+	if cerr := m.add(uint64(n), false); cerr != nil && err == nil {
+		err = cerr
+	}
+	return n, err
+}
+
+func (m *meteredStream) Close() error {
+	err := m.flush()
+	if cerr := m.Stream.Close(); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+func (m *meteredStream) FullClose() error {
+	err := m.flush()
+	if cerr := m.Stream.FullClose(); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+func (m *meteredStream) Reset() error {
+	_ = m.flush()
+	return m.Stream.Reset()
+}
+
+// flush charges the bytes not charged yet.
+func (m *meteredStream) flush() error {
+	return m.add(0, true)
+}
+
+func (m *meteredStream) failed() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+func (m *meteredStream) add(bytes uint64, flush bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.pending += bytes
+	if m.pending == 0 || (!flush && m.pending < relayChargeSize) {
+		return nil
+	}
+	pending := m.pending
+	m.pending = 0
+	m.err = m.charge(pending)
+	return m.err
+}
+
+// openStream opens the stream to the peer, directly if it is a neighbor and
+// over a relay chain otherwise. The peer is credited the bytes it relays.
+func (s *Service) openStream(ctx context.Context, addr boson.Address, streamName string) (st p2p.Stream, err error) {
+	if s.route.IsNeighbor(addr) {
+		st, err = s.streamer.NewStream(ctx, addr, nil, protocolName, protocolVersion, streamName)
+	} else {
+		st, err = s.streamer.NewConnChainRelayStream(ctx, addr, nil, protocolName, protocolVersion, streamName)
+	}
+	if err != nil || s.accounting == nil {
+		return st, err
+	}
+	return newMeteredStream(st, func(bytes uint64) error {
+		return s.accounting.CreditRelay(context.Background(), addr, bytes)
+	}), nil
+}
+
+// metered debits the peer the bytes relayed over the streams it opens.
+func (s *Service) metered(handler p2p.HandlerFunc) p2p.HandlerFunc {
+	return func(ctx context.Context, p p2p.Peer, stream p2p.Stream) error {
+		if s.accounting == nil {
+			return handler(ctx, p, stream)
+		}
+		st := newMeteredStream(stream, func(bytes uint64) error {
+			return s.accounting.DebitRelay(p.Address, bytes)
+		})
+		err := handler(ctx, p, st)
+		if ferr := st.flush(); ferr != nil && err == nil {
+			err = ferr
+		}
+		return err
+	}
+}
@@ -0,0 +1,63 @@
+package netrelay
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/FavorLabs/favorX/pkg/p2p"
+)
+
+type bufferStream struct {
+	p2p.Stream
+	bytes.Buffer
+}
+
+func (s *bufferStream) Read(b []byte) (int, error)  { return s.Buffer.Read(b) }
+func (s *bufferStream) Write(b []byte) (int, error) { return s.Buffer.Write(b) }
+func (s *bufferStream) Close() error                { return nil }
+func (s *bufferStream) Reset() error                { return nil }
+
+func TestMeteredStream(t *testing.T) {
+	var charged []uint64
+	st := newMeteredStream(&bufferStream{}, func(bytes uint64) error {
+		charged = append(charged, bytes)
+		return nil
+	})
+
+	if _, err := st.Write(make([]byte, relayChargeSize-1)); err != nil {
+		t.Fatal(err)
+	}
+	if len(charged) != 0 {
+		t.Fatalf("charged %v below the charge size", charged)
+	}
+	if _, err := st.Read(make([]byte, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if len(charged) != 1 || charged[0] != relayChargeSize+9 {
+		t.Fatalf("got charges %v, want %d", charged, relayChargeSize+9)
+	}
+	if _, err := st.Write(make([]byte, 5)); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(charged) != 2 || charged[1] != 5 {
+		t.Fatalf("got charges %v, want the rest charged on close", charged)
+	}
+}
+
+func TestMeteredStreamChargeFailed(t *testing.T) {
+	errCharge := errors.New("charge")
+	st := newMeteredStream(&bufferStream{}, func(uint64) error {
+		return errCharge
+	})
+
+	if _, err := st.Write(make([]byte, relayChargeSize)); !errors.Is(err, errCharge) {
+		t.Fatalf("got error %v, want %v", err, errCharge)
+	}
+	if _, err := st.Write([]byte{1}); !errors.Is(err, errCharge) {
+		t.Fatalf("got error %v after the failed charge, want %v", err, errCharge)
+	}
+}
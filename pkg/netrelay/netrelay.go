@@ -9,7 +9,9 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync"
 
+	"github.com/FavorLabs/favorX/pkg/accounting"
 	"github.com/FavorLabs/favorX/pkg/address"
 	"github.com/FavorLabs/favorX/pkg/boson"
 	"github.com/FavorLabs/favorX/pkg/jsonhttp"
@@ -29,6 +31,7 @@ type Service struct {
 	streamer      p2p.Streamer
 	logger        logging.Logger
 	route         routetab.RouteTab
+	mu            sync.RWMutex // guards groups, proxyGroup and tunGroup
 	groups        []model.ConfigNodeGroup
 	multicast     multicast.GroupInterface
 	socks5UDPConn *net.UDPConn
@@ -37,6 +40,7 @@ type Service struct {
 	iface         *water.Interface
 	tunGroup      string
 	tunConfig     TunConfig
+	accounting    accounting.Interface
 }
 
 func New(streamer p2p.Streamer, logging logging.Logger, groups []model.ConfigNodeGroup, route routetab.RouteTab, multicast multicast.GroupInterface) *Service {
@@ -49,6 +53,11 @@ func New(streamer p2p.Streamer, logging logging.Logger, groups []model.ConfigNod
 	}
 }
 
+// SetAccounting charges the bytes relayed at the price agreed with the peers.
+func (s *Service) SetAccounting(acc accounting.Interface) {
+	s.accounting = acc
+}
+
 func (s *Service) RelayHttpDo(w http.ResponseWriter, r *http.Request, addr boson.Address) {
 	url := strings.ReplaceAll(r.URL.String(), address.RelayPrefixHttp, "")
 	var forward []boson.Address
@@ -85,11 +94,7 @@ func (s *Service) RelayHttpDo(w http.ResponseWriter, r *http.Request, addr boson
 
 func (s *Service) copyStream(w http.ResponseWriter, r *http.Request, addr boson.Address) (err error) {
 	var st p2p.Stream
-	if s.route.IsNeighbor(addr) {
-		st, err = s.streamer.NewStream(r.Context(), addr, nil, protocolName, protocolVersion, streamRelayHttpReqV2)
-	} else {
-		st, err = s.streamer.NewConnChainRelayStream(r.Context(), addr, nil, protocolName, protocolVersion, streamRelayHttpReqV2)
-	}
+	st, err = s.openStream(r.Context(), addr, streamRelayHttpReqV2)
 	if err != nil {
 		return fmt.Errorf("new stream %s", err)
 	}
@@ -183,19 +188,42 @@ func (s *Service) SetProxyGroup(group string) error {
 	if err != nil {
 		return fmt.Errorf("proxy group %s notfound", group)
 	}
+	s.mu.Lock()
 	s.proxyGroup = group
+	s.mu.Unlock()
 	return nil
 }
 
+func (s *Service) getProxyGroup() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.proxyGroup
+}
+
 func (s *Service) SetTunGroup(group string) error {
 	_, err := s.multicast.GetGroupPeers(group)
 	if err != nil {
 		return fmt.Errorf("tun forward group %s notfound", group)
 	}
+	s.mu.Lock()
 	s.tunGroup = group
+	s.mu.Unlock()
 	return nil
 }
 
+func (s *Service) getTunGroup() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tunGroup
+}
+
+// SetGroups replaces the groups the http and websocket agents are looked up in.
+func (s *Service) SetGroups(groups []model.ConfigNodeGroup) {
+	s.mu.Lock()
+	s.groups = groups
+	s.mu.Unlock()
+}
+
 func (s *Service) StartProxyTCP(addr, natAddr string) *net.TCPListener {
 	host, port, err := net.SplitHostPort(addr)
 	if err != nil {
@@ -292,7 +320,7 @@ func (s *Service) parseFirst(conn net.Conn) {
 }
 
 func (s *Service) httpProxyStart(conn net.Conn, first []byte) {
-	forward, err := s.getForward(s.proxyGroup)
+	forward, err := s.getForward(s.getProxyGroup())
 	if err != nil {
 		s.logger.Errorf("proxy http(s) get forward peer err %s", err)
 		return
@@ -307,11 +335,7 @@ func (s *Service) httpProxyStart(conn net.Conn, first []byte) {
 
 func (s *Service) copyStreamHttpProxy(first []byte, conn net.Conn, addr boson.Address) (err error) {
 	var st p2p.Stream
-	if s.route.IsNeighbor(addr) {
-		st, err = s.streamer.NewStream(context.Background(), addr, nil, protocolName, protocolVersion, streamHttpProxy)
-	} else {
-		st, err = s.streamer.NewConnChainRelayStream(context.Background(), addr, nil, protocolName, protocolVersion, streamHttpProxy)
-	}
+	st, err = s.openStream(context.Background(), addr, streamHttpProxy)
 	if err != nil {
 		return fmt.Errorf("new stream %s", err)
 	}
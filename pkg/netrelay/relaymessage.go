@@ -34,46 +34,46 @@ func (s *Service) Protocol() p2p.ProtocolSpec {
 		StreamSpecs: []p2p.StreamSpec{
 			{
 				Name:    streamRelayHttpReqV2,
-				Handler: s.onRelayHttpReqV2,
+				Handler: s.metered(s.onRelayHttpReqV2),
 			},
 			{
 				Name:    streamHttpProxy,
-				Handler: s.onHttpProxy,
+				Handler: s.metered(s.onHttpProxy),
 			},
 			{
 				Name:    streamSocks5TCP,
-				Handler: s.onSocks5TCP,
+				Handler: s.metered(s.onSocks5TCP),
 			},
 			{
 				Name:    streamSocks5UDP,
-				Handler: s.onSocks5UDP,
+				Handler: s.metered(s.onSocks5UDP),
 			},
 			{
 				Name:    streamVpnTun,
-				Handler: s.onVpnTun,
+				Handler: s.metered(s.onVpnTun),
 			},
 			{
 				Name:    streamVpnRequest,
-				Handler: s.onVpnRequest,
+				Handler: s.metered(s.onVpnRequest),
 			},
 		},
 	}
 }
 
 func (s *Service) getDomainAddrWithScheme(scheme, groupName, domainName string) (string, bool) {
-	for _, v := range s.groups {
+	// the groups managed at runtime take precedence over the configured ones
+	if g, err := s.multicast.GroupConfig(groupName); err == nil {
+		if addr, ok := domainAddr(scheme, g, domainName); ok {
+			return addr, true
+		}
+	}
+	s.mu.RLock()
+	groups := s.groups
+	s.mu.RUnlock()
+	for _, v := range groups {
 		if v.Name == groupName {
-			var agents []model.ConfigNetDomain
-			switch scheme {
-			case "ws", "wss":
-				agents = v.AgentWS
-			case "http", "https":
-				agents = v.AgentHttp
-			}
-			for _, domain := range agents {
-				if domain.Domain == domainName {
-					return domain.Addr, true
-				}
+			if addr, ok := domainAddr(scheme, v, domainName); ok {
+				return addr, true
 			}
 		}
 	}
@@ -81,6 +81,22 @@ func (s *Service) getDomainAddrWithScheme(scheme, groupName, domainName string)
 	return "", false
 }
 
+func domainAddr(scheme string, group model.ConfigNodeGroup, domainName string) (string, bool) {
+	var agents []model.ConfigNetDomain
+	switch scheme {
+	case "ws", "wss":
+		agents = group.AgentWS
+	case "http", "https":
+		agents = group.AgentHttp
+	}
+	for _, domain := range agents {
+		if domain.Domain == domainName {
+			return domain.Addr, true
+		}
+	}
+	return "", false
+}
+
 func (s *Service) onRelayHttpReqV2(ctx context.Context, p p2p.Peer, stream p2p.Stream) (err error) {
 	defer func() {
 		if err != nil {
@@ -193,9 +209,9 @@ func (s *Service) onHttpProxy(ctx context.Context, p p2p.Peer, stream p2p.Stream
 		}
 	}()
 
-	if s.proxyGroup != "" {
+	if group := s.getProxyGroup(); group != "" {
 		// forward
-		s.forwardStream(stream, s.proxyGroup, streamHttpProxy)
+		s.forwardStream(stream, group, streamHttpProxy)
 		return nil
 	}
 
@@ -155,7 +155,7 @@ func (s *Service) socks5ProxyTCP(conn net.Conn) {
 	cmd := b2[1]
 	switch cmd {
 	case cmdTCPConnect, cmdTorResolve, cmdTorResolvePTR:
-		forward, err := s.getForward(s.proxyGroup)
+		forward, err := s.getForward(s.getProxyGroup())
 		if err != nil {
 			s.logger.Errorf("socks5 get forward peer err %s", err)
 			return
@@ -222,7 +222,7 @@ func (s *Service) socks5ProxyUDP(src *net.UDPAddr, data []byte) {
 		return
 	}
 
-	forward, err := s.getForward(s.proxyGroup)
+	forward, err := s.getForward(s.getProxyGroup())
 	if err != nil {
 		s.logger.Errorf("socks5 get forward peer err %s", err)
 		return
@@ -240,11 +240,7 @@ func (s *Service) socks5ProxyUDP(src *net.UDPAddr, data []byte) {
 
 func (s *Service) socks5HandleTCP(conn net.Conn, addr boson.Address) (err error) {
 	var st p2p.Stream
-	if s.route.IsNeighbor(addr) {
-		st, err = s.streamer.NewStream(context.Background(), addr, nil, protocolName, protocolVersion, streamSocks5TCP)
-	} else {
-		st, err = s.streamer.NewConnChainRelayStream(context.Background(), addr, nil, protocolName, protocolVersion, streamSocks5TCP)
-	}
+	st, err = s.openStream(context.Background(), addr, streamSocks5TCP)
 	if err != nil {
 		return fmt.Errorf("new stream %s", err)
 	}
@@ -298,9 +294,9 @@ func (s *Service) onSocks5TCP(_ context.Context, p p2p.Peer, stream p2p.Stream)
 		}
 	}()
 
-	if s.proxyGroup != "" {
+	if group := s.getProxyGroup(); group != "" {
 		// forward
-		s.forwardStream(stream, s.proxyGroup, streamSocks5TCP)
+		s.forwardStream(stream, group, streamSocks5TCP)
 		return nil
 	}
 
@@ -364,11 +360,7 @@ func (s *Service) onSocks5TCP(_ context.Context, p p2p.Peer, stream p2p.Stream)
 
 func (s *Service) socks5HandleUDP(src *net.UDPAddr, dst string, data []byte, addr boson.Address) (err error) {
 	var st p2p.Stream
-	if s.route.IsNeighbor(addr) {
-		st, err = s.streamer.NewStream(context.Background(), addr, nil, protocolName, protocolVersion, streamSocks5UDP)
-	} else {
-		st, err = s.streamer.NewConnChainRelayStream(context.Background(), addr, nil, protocolName, protocolVersion, streamSocks5UDP)
-	}
+	st, err = s.openStream(context.Background(), addr, streamSocks5UDP)
 	if err != nil {
 		return fmt.Errorf("new stream %s", err)
 	}
@@ -410,9 +402,9 @@ func (s *Service) onSocks5UDP(_ context.Context, p p2p.Peer, stream p2p.Stream)
 		}
 	}()
 
-	if s.proxyGroup != "" {
+	if group := s.getProxyGroup(); group != "" {
 		// forward
-		s.forwardStream(stream, s.proxyGroup, streamSocks5UDP)
+		s.forwardStream(stream, group, streamSocks5UDP)
 		return nil
 	}
 
@@ -619,11 +611,7 @@ func (s *Service) forwardStream(src p2p.Stream, group, streamName string) error
 	}
 	var st p2p.Stream
 	for _, p := range forward {
-		if s.route.IsNeighbor(p) {
-			st, err = s.streamer.NewStream(context.Background(), p, nil, protocolName, protocolVersion, streamName)
-		} else {
-			st, err = s.streamer.NewConnChainRelayStream(context.Background(), p, nil, protocolName, protocolVersion, streamName)
-		}
+		st, err = s.openStream(context.Background(), p, streamName)
 		if err == nil {
 			break
 		}
@@ -114,8 +114,8 @@ func (s *Service) onVpnTun(_ context.Context, p p2p.Peer, stream p2p.Stream) (er
 			s.logger.Tracef("onVpnTun from %s stream close", p.Address)
 		}
 	}()
-	if s.tunGroup != "" {
-		s.forwardStream(stream, s.tunGroup, streamVpnTun)
+	if group := s.getTunGroup(); group != "" {
+		s.forwardStream(stream, group, streamVpnTun)
 		return nil
 	}
 
@@ -167,8 +167,8 @@ func (s *Service) onVpnRequest(ctx context.Context, p p2p.Peer, stream p2p.Strea
 		}
 	}()
 
-	if s.tunGroup != "" {
-		s.forwardStream(stream, s.tunGroup, streamVpnRequest)
+	if group := s.getTunGroup(); group != "" {
+		s.forwardStream(stream, group, streamVpnRequest)
 		return nil
 	}
 
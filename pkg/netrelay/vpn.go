@@ -225,11 +225,7 @@ func (s *Service) createStream(wsconn net.Conn, group string) (st p2p.Stream, er
 		return
 	}
 	for _, peer := range forward {
-		if s.route.IsNeighbor(peer) {
-			st, err = s.streamer.NewStream(context.Background(), peer, nil, protocolName, protocolVersion, streamVpnTun)
-		} else {
-			st, err = s.streamer.NewConnChainRelayStream(context.Background(), peer, nil, protocolName, protocolVersion, streamVpnTun)
-		}
+		st, err = s.openStream(context.Background(), peer, streamVpnTun)
 		if err == nil {
 			go s.toClient(wsconn, st)
 			break
@@ -245,11 +241,7 @@ func (s *Service) vpnRequest(ctx context.Context, group, path, ip string) (err e
 	}
 	var st p2p.Stream
 	for _, peer := range forward {
-		if s.route.IsNeighbor(peer) {
-			st, err = s.streamer.NewStream(context.Background(), peer, nil, protocolName, protocolVersion, streamVpnRequest)
-		} else {
-			st, err = s.streamer.NewConnChainRelayStream(context.Background(), peer, nil, protocolName, protocolVersion, streamVpnRequest)
-		}
+		st, err = s.openStream(context.Background(), peer, streamVpnRequest)
 		if err == nil {
 			w, r := protobuf.NewWriterAndReader(st)
 			err = w.WriteMsgWithContext(ctx, &pb.VpnRequest{
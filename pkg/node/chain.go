@@ -3,6 +3,7 @@ package node
 import (
 	"context"
 	"fmt"
+	"io"
 	"math/big"
 
 	"github.com/FavorLabs/favorX/pkg/crypto"
@@ -11,11 +12,14 @@ import (
 	"github.com/FavorLabs/favorX/pkg/settlement"
 	"github.com/FavorLabs/favorX/pkg/settlement/chain"
 	chainCommon "github.com/FavorLabs/favorX/pkg/settlement/chain/common"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/devchain"
 	"github.com/FavorLabs/favorX/pkg/settlement/chain/oracle"
 	chainTraffic "github.com/FavorLabs/favorX/pkg/settlement/chain/traffic"
 	"github.com/FavorLabs/favorX/pkg/settlement/chain/transaction"
+	"github.com/FavorLabs/favorX/pkg/settlement/multichain"
 	"github.com/FavorLabs/favorX/pkg/settlement/pseudosettle"
 	"github.com/FavorLabs/favorX/pkg/settlement/traffic"
+	"github.com/FavorLabs/favorX/pkg/settlement/traffic/cashout"
 	chequePkg "github.com/FavorLabs/favorX/pkg/settlement/traffic/cheque"
 	"github.com/FavorLabs/favorX/pkg/settlement/traffic/trafficprotocol"
 	"github.com/FavorLabs/favorX/pkg/storage"
@@ -24,96 +28,308 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
-// InitChain will initialize the Ethereum backend at the given endpoint and
-// set up the Transaction Service to interact with it using the provided signer.
+// InitChain will initialize the Ethereum backend at the given endpoint, or the
+// in-process dev chain, and set up the Transaction Service to interact with
+// it using the provided signer. The node settles on the chains besides, the
+// one of the endpoint preferred. The oracle contracts are indexed if the index
+// interval is set. The returned closer stops the cashout schedulers, stores
+// the traffic accounted in the ledgers and closes the oracle indexes and the
+// services of the chains besides.
 func InitChain(
 	ctx context.Context,
 	logger logging.Logger,
 	endpoint string,
+	devChain bool,
 	oracleContractAddress string,
 	stateStore storage.StateStorer,
 	signer crypto.Signer,
 	trafficEnable bool,
 	trafficContractAddr string,
+	chains []multichain.ChainConfig,
+	indexOptions oracle.IndexOptions,
+	cashoutOptions cashout.Options,
 	p2pService *libp2p.Service,
 	subPub subscribe.SubPub,
-) (chain.Resolver, settlement.Interface, traffic.ApiInterface, chain.Common, error) {
+) (chain.Resolver, settlement.Interface, traffic.ApiInterface, chain.Common, transaction.Service, []*oracle.Index, io.Closer, error) {
 	var (
-		backend = &ethclient.Client{}
+		backend transaction.Backend
 		chainID = &big.Int{}
 		cc      = &chainCommon.Common{}
 	)
 
-	backend, err := ethclient.Dial(endpoint)
-	if err != nil && (trafficEnable || oracleContractAddress != "") {
-		return nil, nil, nil, nil, fmt.Errorf("dial eth client: %w", err)
-	}
-
-	if backend != nil && (trafficEnable || oracleContractAddress != "") {
-		chainID, err = backend.ChainID(ctx)
+	if devChain {
+		dev, err := devchain.Shared()
 		if err != nil {
-			logger.Infof("could not connect to backend at %v. In a swap-enabled network a working blockchain node (for goerli network in production) is required. Check your node or specify another node using --chain-endpoint.", endpoint)
-			return nil, nil, nil, nil, fmt.Errorf("get chain id: %w", err)
+			return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("dev chain: %w", err)
+		}
+		address, err := signer.EthereumAddress()
+		if err != nil {
+			return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("chain address: %w", err)
+		}
+		if err = dev.Fund(ctx, address); err != nil {
+			return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("dev chain: %w", err)
 		}
-		cc, err = chainCommon.New(logger, signer, chainID, endpoint)
+		logger.Infof("using the dev chain, oracle contract %s, traffic contract %s", dev.OracleAddress, dev.TrafficAddress)
+		backend, chainID = dev, dev.ChainID()
+		oracleContractAddress, trafficContractAddr = dev.OracleAddress.String(), dev.TrafficAddress.String()
+		cc, err = chainCommon.NewWithClient(logger, signer, chainID, nil)
 		if err != nil {
-			return nil, nil, nil, nil, fmt.Errorf("new common serveice: %v", err)
+			return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("new common serveice: %v", err)
 		}
-		if oracleContractAddress == "" {
-			return nil, nil, nil, nil, fmt.Errorf("oracle contract address is empty")
+	} else {
+		client, err := ethclient.Dial(endpoint)
+		if err != nil && (trafficEnable || oracleContractAddress != "") {
+			return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("dial eth client: %w", err)
+		}
+
+		if client != nil && (trafficEnable || oracleContractAddress != "") {
+			chainID, err = client.ChainID(ctx)
+			if err != nil {
+				logger.Infof("could not connect to backend at %v. In a swap-enabled network a working blockchain node (for goerli network in production) is required. Check your node or specify another node using --chain-endpoint.", endpoint)
+				return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("get chain id: %w", err)
+			}
+			cc, err = chainCommon.New(logger, signer, chainID, endpoint)
+			if err != nil {
+				return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("new common serveice: %v", err)
+			}
+			if oracleContractAddress == "" {
+				return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("oracle contract address is empty")
+			}
+		}
+		if client != nil {
+			backend = client
 		}
 	}
-	oracleServer, err := oracle.NewServer(logger, backend, chainID, oracleContractAddress, signer, cc, subPub)
+	transactionService, err := transaction.NewService(logger, backend, signer, stateStore, cc, chainID)
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("new oracle service: %w", err)
+		return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("new transaction service: %w", err)
 	}
-	transactionService, err := transaction.NewService(logger, backend, signer, stateStore, cc, chainID)
+	var (
+		closer  closers
+		indexes []*oracle.Index
+		index   *oracle.Index
+	)
+	if indexBackend, ok := backend.(oracle.IndexBackend); ok && oracleContractAddress != "" && indexOptions.Interval > 0 {
+		index, err = oracle.NewIndex(logger, indexBackend, chainID, common.HexToAddress(oracleContractAddress), stateStore, indexOptions)
+		if err != nil {
+			return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("new oracle index: %w", err)
+		}
+		index.Start()
+		indexes = append(indexes, index)
+		closer = append(closer, index)
+	}
+	oracleServer, err := oracle.NewServer(logger, backend, chainID, oracleContractAddress, signer, transactionService, cc, subPub, index)
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("new transaction service: %w", err)
+		_ = closer.Close()
+		return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("new oracle service: %w", err)
 	}
 	address, err := signer.EthereumAddress()
 	logger.Infof("address  %s", address.String())
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("chain address: %w", err)
+		_ = closer.Close()
+		return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("chain address: %w", err)
+	}
+
+	var (
+		others    []*settlementChain
+		resolvers []chain.Resolver
+	)
+	for _, cfg := range chains {
+		c, err := initSettlementChain(ctx, logger, cfg, stateStore, signer, subPub, indexOptions)
+		if err != nil {
+			_ = closer.Close()
+			return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("chain %s: %w", cfg.Endpoint, err)
+		}
+		if c.index != nil {
+			indexes = append(indexes, c.index)
+			closer = append(closer, c.index)
+		}
+		closer = append(closer, c.transactionService)
+		if c.chainID == chainID.Int64() {
+			_ = closer.Close()
+			return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("chain %s: chain %d settled on twice", cfg.Endpoint, c.chainID)
+		}
+		if c.oracle != nil {
+			resolvers = append(resolvers, c.oracle)
+		}
+		others = append(others, c)
 	}
+	resolver := oracle.NewMultiResolver(oracleServer, resolvers...)
 
 	if !trafficEnable {
 		service := pseudosettle.New(p2pService, logger, stateStore, address)
 		if err = service.Init(); err != nil {
-			return nil, nil, nil, nil, fmt.Errorf("InitTraffic:: %w", err)
+			_ = closer.Close()
+			return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("InitTraffic:: %w", err)
 		}
 
-		return oracleServer, service, service, cc, nil
+		return resolver, service, service, cc, transactionService, indexes, closer, nil
 	}
 
+	protocol := trafficprotocol.New(p2pService, logger, address)
 	trafficChainService, err := chainTraffic.NewServer(logger, chainID, backend, signer, transactionService, trafficContractAddr, cc)
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("new traffic service: %w", err)
+		_ = closer.Close()
+		return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("new traffic service: %w", err)
 	}
-
-	trafficService, err := InitTraffic(stateStore, address, trafficChainService, transactionService, logger, p2pService, signer, chainID.Int64(), trafficContractAddr, subPub)
+	trafficService, err := InitTraffic(stateStore, address, trafficChainService, transactionService, logger, p2pService, protocol, signer, chainID.Int64(), trafficContractAddr, subPub)
 	if err != nil {
-		return nil, nil, nil, nil, err
+		_ = closer.Close()
+		return nil, nil, nil, nil, nil, nil, nil, err
 	}
 	err = trafficService.Init()
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("InitChain: %w", err)
+		_ = trafficService.Close()
+		_ = closer.Close()
+		return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("InitChain: %w", err)
+	}
+	// the ledger stores the traffic it accounted before the stores close
+	closer = append(closers{trafficService}, closer...)
+	if cashoutOptions.Interval > 0 {
+		scheduler := cashout.New(logger, trafficService, backend, stateStore, cashoutOptions)
+		scheduler.Start()
+		// the schedulers stop before the transaction services
+		closer = append(closers{scheduler}, closer...)
+	}
+	if len(others) == 0 {
+		if err := p2pService.AddProtocol(protocol.Protocol()); err != nil {
+			_ = closer.Close()
+			return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("traffic server :%v", err)
+		}
+		return resolver, trafficService, trafficService, cc, transactionService, indexes, closer, nil
 	}
 
-	return oracleServer, trafficService, trafficService, cc, nil
+	settlements := multichain.New(protocol)
+	if err := settlements.Add(chainID.Int64(), trafficService); err != nil {
+		_ = closer.Close()
+		return nil, nil, nil, nil, nil, nil, nil, err
+	}
+	for _, c := range others {
+		trafficService, err := c.initTraffic(logger, address, p2pService, protocol, signer, subPub)
+		if err != nil {
+			_ = closer.Close()
+			return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("chain %s: %w", c.endpoint, err)
+		}
+		closer = append(closers{trafficService}, closer...)
+		if err := settlements.Add(c.chainID, trafficService); err != nil {
+			_ = closer.Close()
+			return nil, nil, nil, nil, nil, nil, nil, err
+		}
+		if cashoutOptions.Interval > 0 {
+			scheduler := cashout.New(logger, trafficService, c.backend, c.store, cashoutOptions)
+			scheduler.Start()
+			closer = append(closers{scheduler}, closer...)
+		}
+	}
+	// the chains are negotiated once the protocol knows all of them
+	if err := p2pService.AddProtocol(protocol.Protocol()); err != nil {
+		_ = closer.Close()
+		return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("traffic server :%v", err)
+	}
+	return resolver, settlements, settlements, cc, transactionService, indexes, closer, nil
+}
+
+// settlementChain is a chain settled on besides the one of the chain
+// endpoint. Its state is kept apart in a store of its own.
+type settlementChain struct {
+	endpoint            string
+	trafficContractAddr string
+	chainID             int64
+	backend             transaction.Backend
+	common              chain.Common
+	store               storage.StateStorer
+	transactionService  transaction.Service
+	oracle              chain.Resolver
+	index               *oracle.Index
 }
 
+func initSettlementChain(ctx context.Context, logger logging.Logger, cfg multichain.ChainConfig, stateStore storage.StateStorer, signer crypto.Signer, subPub subscribe.SubPub, indexOptions oracle.IndexOptions) (*settlementChain, error) {
+	client, err := ethclient.Dial(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("dial eth client: %w", err)
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get chain id: %w", err)
+	}
+	cc, err := chainCommon.New(logger, signer, chainID, cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("new common serveice: %v", err)
+	}
+	store := multichain.NewStore(stateStore, chainID.Int64())
+	transactionService, err := transaction.NewService(logger, client, signer, store, cc, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("new transaction service: %w", err)
+	}
+	c := &settlementChain{
+		endpoint:            cfg.Endpoint,
+		trafficContractAddr: cfg.TrafficContractAddr,
+		chainID:             chainID.Int64(),
+		backend:             client,
+		common:              cc,
+		store:               store,
+		transactionService:  transactionService,
+	}
+	if cfg.OracleContractAddr != "" {
+		if indexOptions.Interval > 0 {
+			indexOptions.StartBlock = cfg.OracleStartBlock
+			c.index, err = oracle.NewIndex(logger, client, chainID, common.HexToAddress(cfg.OracleContractAddr), store, indexOptions)
+			if err != nil {
+				_ = transactionService.Close()
+				return nil, fmt.Errorf("new oracle index: %w", err)
+			}
+		}
+		c.oracle, err = oracle.NewServer(logger, client, chainID, cfg.OracleContractAddr, signer, transactionService, cc, subPub, c.index)
+		if err != nil {
+			_ = transactionService.Close()
+			return nil, fmt.Errorf("new oracle service: %w", err)
+		}
+		if c.index != nil {
+			c.index.Start()
+		}
+	}
+	logger.Infof("settling on chain %d at %s besides", c.chainID, cfg.Endpoint)
+	return c, nil
+}
+
+func (c *settlementChain) initTraffic(logger logging.Logger, address common.Address, p2pService *libp2p.Service, protocol *trafficprotocol.Service, signer crypto.Signer, subPub subscribe.SubPub) (*traffic.Service, error) {
+	trafficChainService, err := chainTraffic.NewServer(logger, big.NewInt(c.chainID), c.backend, signer, c.transactionService, c.trafficContractAddr, c.common)
+	if err != nil {
+		return nil, fmt.Errorf("new traffic service: %w", err)
+	}
+	trafficService, err := InitTraffic(c.store, address, trafficChainService, c.transactionService, logger, p2pService, protocol, signer, c.chainID, c.trafficContractAddr, subPub)
+	if err != nil {
+		return nil, err
+	}
+	if err := trafficService.Init(); err != nil {
+		_ = trafficService.Close()
+		return nil, fmt.Errorf("InitChain: %w", err)
+	}
+	return trafficService, nil
+}
+
+// closers closes all of the closers in order, returning the first error.
+type closers []io.Closer
+
+func (c closers) Close() error {
+	var err error
+	for _, closer := range c {
+		if e := closer.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// InitTraffic sets up the traffic service settling on the chain, the cheques
+// of which the protocol exchanges.
 func InitTraffic(store storage.StateStorer, address common.Address, trafficChainService chain.Traffic,
-	transactionService chain.Transaction, logger logging.Logger, p2pService *libp2p.Service, signer crypto.Signer, chainID int64, trafficContractAddr string, subPub subscribe.SubPub) (*traffic.Service, error) {
+	transactionService chain.Transaction, logger logging.Logger, p2pService *libp2p.Service, protocol *trafficprotocol.Service, signer crypto.Signer, chainID int64, trafficContractAddr string, subPub subscribe.SubPub) (*traffic.Service, error) {
 	chequeStore := chequePkg.NewChequeStore(store, address, chequePkg.RecoverCheque, chainID)
 	cashOut := chequePkg.NewCashoutService(store, transactionService, trafficChainService, chequeStore, common.HexToAddress(trafficContractAddr))
 	addressBook := traffic.NewAddressBook(store)
-	protocol := trafficprotocol.New(p2pService, logger, address)
-	if err := p2pService.AddProtocol(protocol.Protocol()); err != nil {
-		return nil, fmt.Errorf("traffic server :%v", err)
-	}
 	chequeSigner := chequePkg.NewChequeSigner(signer, chainID)
-	trafficService := traffic.New(logger, address, store, trafficChainService, chequeStore, cashOut, p2pService, addressBook, chequeSigner, protocol, chainID, subPub)
-	protocol.SetTraffic(trafficService)
+	trafficService := traffic.New(logger, address, store, trafficChainService, chequeStore, cashOut, chequePkg.NewLedger(store), p2pService, addressBook, chequeSigner, protocol.Chain(chainID), chainID, subPub)
+	protocol.AddTraffic(chainID, trafficService)
 	return trafficService, nil
 }
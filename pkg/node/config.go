@@ -31,6 +31,7 @@ type Config struct {
 	WSPathPrefix     string
 	WSOrigins        []string
 	WSModules        []string
+	Authorizer       rpc.Authorizer
 }
 
 // checkModuleAvailability checks that all names given in modules are actually
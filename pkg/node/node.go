@@ -11,6 +11,7 @@ import (
 	"net"
 	"net/http"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/FavorLabs/favorX/pkg/accounting"
@@ -19,6 +20,7 @@ import (
 	"github.com/FavorLabs/favorX/pkg/api"
 	"github.com/FavorLabs/favorX/pkg/auth"
 	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/challenge"
 	"github.com/FavorLabs/favorX/pkg/chunkinfo"
 	"github.com/FavorLabs/favorX/pkg/crypto"
 	"github.com/FavorLabs/favorX/pkg/crypto/cert"
@@ -34,11 +36,18 @@ import (
 	"github.com/FavorLabs/favorX/pkg/p2p/libp2p"
 	"github.com/FavorLabs/favorX/pkg/pingpong"
 	"github.com/FavorLabs/favorX/pkg/pinning"
+	"github.com/FavorLabs/favorX/pkg/pricing"
+	"github.com/FavorLabs/favorX/pkg/provider"
 	"github.com/FavorLabs/favorX/pkg/resolver/multiresolver"
 	"github.com/FavorLabs/favorX/pkg/retrieval"
 	"github.com/FavorLabs/favorX/pkg/routetab"
 	"github.com/FavorLabs/favorX/pkg/rpc"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/oracle"
+	"github.com/FavorLabs/favorX/pkg/settlement/multichain"
+	"github.com/FavorLabs/favorX/pkg/settlement/traffic/cashout"
 	"github.com/FavorLabs/favorX/pkg/shed"
+	"github.com/FavorLabs/favorX/pkg/shed/encrypted"
+	"github.com/FavorLabs/favorX/pkg/storage"
 	"github.com/FavorLabs/favorX/pkg/subscribe"
 	"github.com/FavorLabs/favorX/pkg/topology/bootnode"
 	"github.com/FavorLabs/favorX/pkg/topology/kademlia"
@@ -52,78 +61,105 @@ import (
 )
 
 type Favor struct {
-	p2pService       io.Closer
-	p2pCancel        context.CancelFunc
-	apiCloser        io.Closer
-	apiServer        *http.Server
-	debugAPIServer   *http.Server
-	vpnServer        *http.Server
-	proxyTCPServer   io.Closer
-	proxyUDPServer   io.Closer
-	rpcServer        io.Closer
-	resolverCloser   io.Closer
-	errorLogWriter   *io.PipeWriter
-	tracerCloser     io.Closer
-	groupCloser      io.Closer
-	stateStoreCloser io.Closer
-	localstoreCloser io.Closer
-	topologyCloser   io.Closer
-	ethClientCloser  func()
+	p2pService        io.Closer
+	p2pCancel         context.CancelFunc
+	apiCloser         io.Closer
+	apiServer         *http.Server
+	debugAPIServer    *http.Server
+	vpnServer         *http.Server
+	proxyTCPServer    io.Closer
+	proxyUDPServer    io.Closer
+	rpcServer         io.Closer
+	resolverCloser    io.Closer
+	errorLogWriter    *io.PipeWriter
+	tracerCloser      io.Closer
+	groupCloser       io.Closer
+	stateStoreCloser  io.Closer
+	authCloser        io.Closer
+	localstoreCloser  io.Closer
+	topologyCloser    io.Closer
+	ethClientCloser   func()
+	transactionCloser io.Closer
+	chainCloser       io.Closer
+	providerCloser    io.Closer
+	challengeCloser   io.Closer
+	pricingCloser     io.Closer
+
+	reloadMu    sync.Mutex
+	options     Options
+	reloadHooks map[string]reloadHook
 }
 
 type Options struct {
-	DataDir                string
-	CacheCapacity          uint64
-	DBDriver               string
-	DBPath                 string
-	HTTPAddr               string
-	WSAddr                 string
-	APIAddr                string
-	DebugAPIAddr           string
-	ApiBufferSizeMul       int
-	NATAddr                string
-	EnableWS               bool
-	EnableQUIC             bool
-	WelcomeMessage         string
-	Bootnodes              []string
-	ChainEndpoint          string
-	OracleContractAddress  string
-	CORSAllowedOrigins     []string
-	Logger                 logging.Logger
-	Standalone             bool
-	IsDev                  bool
-	TracingEnabled         bool
-	TracingEndpoint        string
-	TracingServiceName     string
-	ResolverConnectionCfgs []multiresolver.ConnectionConfig
-	GatewayMode            bool
-	TrafficEnable          bool
-	TrafficContractAddr    string
-	KadBinMaxPeers         int
-	LightNodeMaxPeers      int
-	AllowPrivateCIDRs      bool
-	Restricted             bool
-	TokenEncryptionKey     string
-	AdminPasswordHash      string
-	RouteAlpha             int32
-	Groups                 []model.ConfigNodeGroup
-	EnableApiTLS           bool
-	TlsCrtFile             string
-	TlsKeyFile             string
-	ProxyEnable            bool
-	ProxyAddr              string
-	ProxyNATAddr           string
-	ProxyGroup             string
-	TunEnable              bool
-	TunCidr4               string
-	TunCidr6               string
-	TunMTU                 int
-	TunServiceIPv4         string
-	TunServiceIPv6         string
-	TunGroup               string
-	VpnEnable              bool
-	VpnAddr                string
-	Relay                  bool
+	DataDir                  string
+	CacheCapacity            uint64
+	DBDriver                 string
+	DBPath                   string
+	DBPassword               string
+	HTTPAddr                 string
+	WSAddr                   string
+	APIAddr                  string
+	DebugAPIAddr             string
+	ApiBufferSizeMul         int
+	NATAddr                  string
+	EnableWS                 bool
+	EnableQUIC               bool
+	WelcomeMessage           string
+	Bootnodes                []string
+	ChainEndpoint            string
+	DevChain                 bool
+	OracleContractAddress    string
+	CORSAllowedOrigins       []string
+	Logger                   logging.Logger
+	Standalone               bool
+	IsDev                    bool
+	TracingEnabled           bool
+	TracingEndpoint          string
+	TracingServiceName       string
+	ResolverConnectionCfgs   []multiresolver.ConnectionConfig
+	GatewayMode              bool
+	TrafficEnable            bool
+	TrafficContractAddr      string
+	Chains                   []multichain.ChainConfig
+	OracleIndexInterval      time.Duration
+	OracleIndexStartBlock    uint64
+	OracleIndexConfirmations uint64
+	ProviderTTL              time.Duration
+	ChallengeInterval        time.Duration
+	ChallengeBlocklistAfter  int
+	CashoutInterval          time.Duration
+	CashoutMinProfit         *big.Int
+	CashoutDailyGasBudget    *big.Int
+	CashoutGasRate           *big.Rat
+	Price                    address.Price
+	RetrievalPriceWeight     float64
+	KadBinMaxPeers           int
+	LightNodeMaxPeers        int
+	AllowPrivateCIDRs        bool
+	Restricted               bool
+	TokenEncryptionKey       string
+	AdminPasswordHash        string
+	RouteAlpha               int32
+	Groups                   []model.ConfigNodeGroup
+	EnableApiTLS             bool
+	TlsCrtFile               string
+	TlsKeyFile               string
+	ProxyEnable              bool
+	ProxyAddr                string
+	ProxyNATAddr             string
+	ProxyGroup               string
+	TunEnable                bool
+	TunCidr4                 string
+	TunCidr6                 string
+	TunMTU                   int
+	TunServiceIPv4           string
+	TunServiceIPv6           string
+	TunGroup                 string
+	VpnEnable                bool
+	VpnAddr                  string
+	Relay                    bool
+	LogLevel                 logrus.Level
+	ConfigLoader             ConfigLoader
 }
 
 func NewNode(nodeMode address.Model, addr string, bosonAddress boson.Address, publicKey ecdsa.PublicKey, signer crypto.Signer, networkID uint64, logger logging.Logger, libp2pPrivateKey crypto2.PrivKey, o Options) (b *Favor, err error) {
@@ -150,7 +186,11 @@ func NewNode(nodeMode address.Model, addr string, bosonAddress boson.Address, pu
 		p2pCancel:      p2pCancel,
 		errorLogWriter: logger.WriterLevel(logrus.ErrorLevel),
 		tracerCloser:   tracerCloser,
+		options:        o,
 	}
+	b.onReload("LogLevel", func(_, o Options) (bool, error) {
+		return logging.SetLevel(logger, o.LogLevel), nil
+	})
 
 	// a struct warped publish-subscribe function
 	subPub := subscribe.NewSubPub()
@@ -217,7 +257,14 @@ func NewNode(nodeMode address.Model, addr string, bosonAddress boson.Address, pu
 		b.debugAPIServer = debugAPIServer
 	}
 
-	stateStore, err := InitStateStore(logger, o.DataDir)
+	if o.DataDir != "" {
+		// finish the database swap of a migration that was interrupted
+		if err := shed.CompleteSwap(SwapJournalPath(o.DataDir)); err != nil {
+			return nil, fmt.Errorf("complete database migration: %w", err)
+		}
+	}
+
+	stateStore, err := InitStateStore(logger, o.DataDir, o.DBPassword)
 	if err != nil {
 		return nil, err
 	}
@@ -228,6 +275,13 @@ func NewNode(nodeMode address.Model, addr string, bosonAddress boson.Address, pu
 		return nil, err
 	}
 
+	if authenticator != nil {
+		if err = authenticator.SetStateStore(stateStore); err != nil {
+			return nil, fmt.Errorf("authenticator: %w", err)
+		}
+		b.authCloser = authenticator
+	}
+
 	addressBook := addressbook.New(stateStore)
 	lightNodes := lightnode.NewContainer(bosonAddress)
 	bootNodes := bootnode.NewContainer(bosonAddress)
@@ -246,21 +300,52 @@ func NewNode(nodeMode address.Model, addr string, bosonAddress boson.Address, pu
 		return nil, fmt.Errorf("p2p service: %w", err)
 	}
 
-	oracleChain, settlement, apiInterface, commonChain, err := InitChain(
+	oracleChain, settlement, apiInterface, commonChain, transactionService, oracleIndexes, chainCloser, err := InitChain(
 		p2pCtx,
 		logger,
 		o.ChainEndpoint,
+		o.DevChain,
 		o.OracleContractAddress,
 		stateStore,
 		signer,
 		o.TrafficEnable,
 		o.TrafficContractAddr,
+		o.Chains,
+		oracle.IndexOptions{
+			Interval:      o.OracleIndexInterval,
+			StartBlock:    o.OracleIndexStartBlock,
+			Confirmations: o.OracleIndexConfirmations,
+		},
+		cashout.Options{
+			Interval:       o.CashoutInterval,
+			MinProfit:      o.CashoutMinProfit,
+			GasRate:        o.CashoutGasRate,
+			DailyGasBudget: o.CashoutDailyGasBudget,
+		},
 		p2ps,
 		subPub)
 	if err != nil {
 		return nil, err
 	}
+	b.transactionCloser = transactionService
+	b.chainCloser = chainCloser
 	b.p2pService = p2ps
+	b.onReload("WelcomeMessage", func(_, o Options) (bool, error) {
+		return true, p2ps.SetWelcomeMessage(o.WelcomeMessage)
+	})
+	// the connection limits of libp2p are fixed on start, so the peer limits
+	// can only be lowered live
+	lightNodeLimit := o.LightNodeMaxPeers
+	if lightNodeLimit <= 0 {
+		lightNodeLimit = lightnode.DefaultLightNodeLimit
+	}
+	b.onReload("LightNodeMaxPeers", func(_, o Options) (bool, error) {
+		if o.LightNodeMaxPeers > lightNodeLimit {
+			return false, nil
+		}
+		p2ps.SetLightNodeLimit(o.LightNodeMaxPeers)
+		return true, nil
+	})
 
 	if !o.Standalone {
 		if natManager := p2ps.NATManager(); natManager != nil {
@@ -285,6 +370,15 @@ func NewNode(nodeMode address.Model, addr string, bosonAddress boson.Address, pu
 		return nil, fmt.Errorf("pingpong service: %w", err)
 	}
 
+	pricer := pricing.New(p2ps, p2ps, o.Price, logger)
+	if err = p2ps.AddProtocol(pricer.Protocol()); err != nil {
+		return nil, fmt.Errorf("pricing service: %w", err)
+	}
+	b.pricingCloser = pricer
+	b.onReload("Price", func(_, o Options) (bool, error) {
+		return true, pricer.SetPrice(context.Background(), o.Price)
+	})
+
 	var bootnodes []ma.Multiaddr
 	if o.Standalone {
 		logger.Info("Starting node in standalone mode, no p2p connections will be made or accepted")
@@ -310,6 +404,7 @@ func NewNode(nodeMode address.Model, addr string, bosonAddress boson.Address, pu
 		logger,
 		stateStore,
 		settlement,
+		pricer,
 	)
 	settlement.SetNotifyPaymentFunc(acc.AsyncNotifyPayment)
 
@@ -332,6 +427,17 @@ func NewNode(nodeMode address.Model, addr string, bosonAddress boson.Address, pu
 		return nil, fmt.Errorf("unable to create kademlia: %w", err)
 	}
 	b.topologyCloser = kad
+	binMaxPeers := o.KadBinMaxPeers
+	if binMaxPeers <= 0 {
+		binMaxPeers = kademlia.DefaultBinMaxPeers
+	}
+	b.onReload("KadBinMaxPeers", func(_, o Options) (bool, error) {
+		if o.KadBinMaxPeers > binMaxPeers {
+			return false, nil
+		}
+		kad.SetBinMaxPeers(o.KadBinMaxPeers)
+		return true, nil
+	})
 	hiveObj.SetAddPeersHandler(kad.AddPeers)
 	hiveObj.SetConfig(hive2.Config{Kad: kad, Base: bosonAddress, AllowPrivateCIDRs: o.AllowPrivateCIDRs}) // hive2
 
@@ -362,6 +468,7 @@ func NewNode(nodeMode address.Model, addr string, bosonAddress boson.Address, pu
 	lo := &localstore.Options{
 		Capacity: o.CacheCapacity,
 		Driver:   o.DBDriver,
+		Password: o.DBPassword,
 		FullNode: nodeMode.IsFull(),
 	}
 	storer, err := localstore.New(path, bosonAddress.Bytes(), stateStore, lo, logger)
@@ -373,8 +480,17 @@ func NewNode(nodeMode address.Model, addr string, bosonAddress boson.Address, pu
 		return nil, err
 	}
 	b.localstoreCloser = storer
+	b.onReload("CacheCapacity", func(_, o Options) (bool, error) {
+		storer.SetCapacity(o.CacheCapacity)
+		return true, nil
+	})
 
 	retrieve := retrieval.New(bosonAddress, p2ps, route, storer, o.Relay, nodeMode.IsFull(), logger, tracer, acc, subPub)
+	retrieve.SetPricing(pricer, o.RetrievalPriceWeight)
+	b.onReload("RetrievalPriceWeight", func(_, o Options) (bool, error) {
+		retrieve.SetPricing(pricer, o.RetrievalPriceWeight)
+		return true, nil
+	})
 	if err = p2ps.AddProtocol(retrieve.Protocol()); err != nil {
 		return nil, fmt.Errorf("retrieval service: %w", err)
 	}
@@ -396,10 +512,29 @@ func NewNode(nodeMode address.Model, addr string, bosonAddress boson.Address, pu
 	if err = p2ps.AddProtocol(chunkInfo.Protocol()); err != nil {
 		return nil, fmt.Errorf("chunkInfo service: %w", err)
 	}
+	providers := provider.New(bosonAddress, signer, networkID, p2ps, kad, storer, stateStore, logger, provider.Options{TTL: o.ProviderTTL})
+	if err = p2ps.AddProtocol(providers.Protocol()); err != nil {
+		return nil, fmt.Errorf("provider service: %w", err)
+	}
+	providers.Start()
+	b.providerCloser = providers
+	chunkInfo.SetProviders(providers)
+
+	challenges := challenge.New(bosonAddress, p2ps, storer, oracleChain, p2ps, stateStore, logger, challenge.Options{
+		Interval:       o.ChallengeInterval,
+		BlocklistAfter: o.ChallengeBlocklistAfter,
+	})
+	if err = p2ps.AddProtocol(challenges.Protocol()); err != nil {
+		return nil, fmt.Errorf("challenge service: %w", err)
+	}
+	challenges.Start()
+	b.challengeCloser = challenges
+	retrieve.SetReputation(challenges)
 	ns.SetChunkInfo(chunkInfo)
 	retrieve.Config(chunkInfo)
 
-	group := multicast.NewService(bosonAddress, nodeMode, p2ps, p2ps, kad, route, logger, subPub, multicast.Option{Dev: o.IsDev})
+	group := multicast.NewService(bosonAddress, nodeMode, p2ps, p2ps, kad, route, stateStore, logger, subPub, multicast.Option{Dev: o.IsDev})
+	group.SetContentStore(ns)
 	group.Start()
 	b.groupCloser = group
 	err = p2ps.AddProtocol(group.Protocol())
@@ -412,12 +547,35 @@ func NewNode(nodeMode address.Model, addr string, bosonAddress boson.Address, pu
 			return nil, err
 		}
 	}
+	if err = group.RestoreGroups(); err != nil {
+		return nil, fmt.Errorf("multicast: %w", err)
+	}
 
 	relay := netrelay.New(p2ps, logger, o.Groups, route, group)
+	relay.SetAccounting(acc)
 	err = p2ps.AddProtocol(relay.Protocol())
 	if err != nil {
 		return nil, err
 	}
+	b.onReload("Groups", func(old, o Options) (bool, error) {
+		if err := reloadGroups(group, old.Groups, o.Groups); err != nil {
+			return false, err
+		}
+		relay.SetGroups(o.Groups)
+		return true, nil
+	})
+	b.onReload("ProxyGroup", func(_, o Options) (bool, error) {
+		if o.ProxyGroup == "" {
+			return false, nil
+		}
+		return true, relay.SetProxyGroup(o.ProxyGroup)
+	})
+	b.onReload("TunGroup", func(_, o Options) (bool, error) {
+		if o.TunGroup == "" {
+			return false, nil
+		}
+		return true, relay.SetTunGroup(o.TunGroup)
+	})
 	if o.ProxyGroup != "" {
 		err = relay.SetProxyGroup(o.ProxyGroup)
 		if err != nil {
@@ -523,6 +681,17 @@ func NewNode(nodeMode address.Model, addr string, bosonAddress boson.Address, pu
 		b.apiCloser = apiService
 	}
 
+	b.onReload("CORSAllowedOrigins", func(_, o Options) (bool, error) {
+		if apiService != nil {
+			apiService.SetCORSAllowedOrigins(o.CORSAllowedOrigins)
+		}
+		if debugAPIService != nil {
+			debugAPIService.SetCORSAllowedOrigins(o.CORSAllowedOrigins)
+		}
+		// the origins of the rpc websocket are fixed on start
+		return o.WSAddr == "", nil
+	})
+
 	if debugAPIService != nil {
 		// register metrics from components
 		debugAPIService.MustRegisterMetrics(p2ps.Metrics()...)
@@ -532,16 +701,33 @@ func NewNode(nodeMode address.Model, addr string, bosonAddress boson.Address, pu
 		debugAPIService.MustRegisterMetrics(chunkInfo.Metrics()...)
 		debugAPIService.MustRegisterMetrics(route.Metrics()...)
 		debugAPIService.MustRegisterMetrics(retrieve.Metrics()...)
+		debugAPIService.MustRegisterMetrics(challenges.Metrics()...)
+		for _, index := range oracleIndexes {
+			debugAPIService.MustRegisterMetrics(index.Metrics()...)
+		}
 
 		if apiService != nil {
 			debugAPIService.MustRegisterMetrics(apiService.Metrics()...)
 		}
 
+		debugAPIService.SetConfigReloader(func() ([]string, []string, error) {
+			r, err := b.ReloadConfig()
+			return r.Applied, r.RestartRequired, err
+		})
+		if o.DBPassword != "" {
+			debugAPIService.SetDBRekeyer(func() error {
+				return rotateDBKeys(storer, stateStore)
+			})
+		}
 		// inject dependencies and configure full debug api http path routes
 		debugAPIService.Configure(p2ps, pingPong, group, kad, lightNodes, bootNodes, storer, route, chunkInfo, fileInfo, retrieve, addressBook)
 		if apiInterface != nil {
 			debugAPIService.MustRegisterTraffic(apiInterface)
 		}
+		debugAPIService.MustRegisterTransaction(transactionService)
+		debugAPIService.MustRegisterPricing(pricer)
+		debugAPIService.MustRegisterOracleIndexes(oracleIndexes...)
+		debugAPIService.MustRegisterChallenge(challenges)
 	}
 
 	if err = kad.Start(p2pCtx); err != nil {
@@ -551,6 +737,10 @@ func NewNode(nodeMode address.Model, addr string, bosonAddress boson.Address, pu
 		hiveObj.Start()
 	}
 
+	var rpcAuth rpc.Authorizer
+	if authenticator != nil {
+		rpcAuth = authenticator
+	}
 	stack, err := NewRPC(logger, Config{
 		EnableApiTLS: o.EnableApiTLS,
 		TlsCrtFile:   o.TlsCrtFile,
@@ -562,9 +752,10 @@ func NewNode(nodeMode address.Model, addr string, bosonAddress boson.Address, pu
 		// HTTPAddr:    o.HTTPAddr,
 		// HTTPCors:    o.CORSAllowedOrigins,
 		// HTTPModules: []string{"debug", "api"},
-		WSAddr:    o.WSAddr,
-		WSOrigins: o.CORSAllowedOrigins,
-		WSModules: []string{"group", "p2p", "chunkInfo", "traffic", "retrieval", "oracle"},
+		WSAddr:     o.WSAddr,
+		WSOrigins:  o.CORSAllowedOrigins,
+		WSModules:  []string{"group", "p2p", "chunkInfo", "traffic", "retrieval", "oracle"},
+		Authorizer: rpcAuth,
 	})
 	if err != nil {
 		return nil, err
@@ -666,6 +857,36 @@ func (b *Favor) Shutdown(ctx context.Context) error {
 		errs.add(fmt.Errorf("p2p server: %w", err))
 	}
 
+	if b.pricingCloser != nil {
+		if err := b.pricingCloser.Close(); err != nil {
+			errs.add(fmt.Errorf("pricing service: %w", err))
+		}
+	}
+
+	if b.chainCloser != nil {
+		if err := b.chainCloser.Close(); err != nil {
+			errs.add(fmt.Errorf("settlement chains: %w", err))
+		}
+	}
+
+	if b.transactionCloser != nil {
+		if err := b.transactionCloser.Close(); err != nil {
+			errs.add(fmt.Errorf("transaction service: %w", err))
+		}
+	}
+
+	if b.challengeCloser != nil {
+		if err := b.challengeCloser.Close(); err != nil {
+			errs.add(fmt.Errorf("challenge service: %w", err))
+		}
+	}
+
+	if b.providerCloser != nil {
+		if err := b.providerCloser.Close(); err != nil {
+			errs.add(fmt.Errorf("provider service: %w", err))
+		}
+	}
+
 	if c := b.ethClientCloser; c != nil {
 		c()
 	}
@@ -674,6 +895,12 @@ func (b *Favor) Shutdown(ctx context.Context) error {
 		errs.add(fmt.Errorf("tracer: %w", err))
 	}
 
+	if b.authCloser != nil {
+		if err := b.authCloser.Close(); err != nil {
+			errs.add(fmt.Errorf("authenticator: %w", err))
+		}
+	}
+
 	if err := b.stateStoreCloser.Close(); err != nil {
 		errs.add(fmt.Errorf("statestore: %w", err))
 	}
@@ -732,3 +959,19 @@ func (e *multiError) add(err error) {
 func (e *multiError) hasErrors() bool {
 	return len(e.errors) > 0
 }
+
+// rotateDBKeys rotates the data keys of the encrypted localstore and statestore
+// and re-encrypts their data while the node keeps using them.
+func rotateDBKeys(storer *localstore.DB, stateStore storage.StateStorer) error {
+	if err := storer.RotateKeys(); err != nil {
+		return fmt.Errorf("localstore: %w", err)
+	}
+	edb, ok := stateStore.DB().(*encrypted.DB)
+	if !ok {
+		return fmt.Errorf("statestore: %w", shed.ErrNotEncrypted)
+	}
+	if err := edb.Rotate(); err != nil {
+		return fmt.Errorf("statestore: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/FavorLabs/favorX/pkg/multicast/model"
+)
+
+// ConfigLoader reads the configuration of the node again. It returns the
+// options the node would be started with now and the names of the changed
+// settings Options does not hold, which always need a restart.
+type ConfigLoader func() (o Options, restart []string, err error)
+
+var errNoConfigLoader = errors.New("no config loader")
+
+// ReloadReport lists the settings a reload changed, by the names of their
+// Options fields.
+type ReloadReport struct {
+	Applied         []string `json:"applied"`
+	RestartRequired []string `json:"restartRequired"`
+}
+
+// reloadHook applies the change of a setting to the running node. It reports
+// false if the change can only take effect with a restart.
+type reloadHook func(old, o Options) (bool, error)
+
+// onReload registers the hook that applies the changes of the named setting.
+// Changes of settings without a hook need a restart.
+func (b *Favor) onReload(name string, hook reloadHook) {
+	if b.reloadHooks == nil {
+		b.reloadHooks = make(map[string]reloadHook)
+	}
+	b.reloadHooks[name] = hook
+}
+
+// Reload diffs the options against the ones the node runs with and applies
+// the changed settings that can change live. The settings that need a
+// restart are reported and keep being reported by the following reloads
+// until the node restarts.
+func (b *Favor) Reload(o Options) (r ReloadReport, err error) {
+	b.reloadMu.Lock()
+	defer b.reloadMu.Unlock()
+
+	old := b.options
+	ov, nv := reflect.ValueOf(old), reflect.ValueOf(o)
+	running := reflect.ValueOf(&b.options).Elem()
+	for i := 0; i < ov.NumField(); i++ {
+		name := ov.Type().Field(i).Name
+		if name == "Logger" || name == "ConfigLoader" {
+			continue
+		}
+		if reflect.DeepEqual(ov.Field(i).Interface(), nv.Field(i).Interface()) {
+			continue
+		}
+		hook, ok := b.reloadHooks[name]
+		if !ok {
+			r.RestartRequired = append(r.RestartRequired, name)
+			continue
+		}
+		live, err := hook(old, o)
+		if err != nil {
+			return r, fmt.Errorf("reload %s: %w", name, err)
+		}
+		if !live {
+			r.RestartRequired = append(r.RestartRequired, name)
+			continue
+		}
+		running.Field(i).Set(nv.Field(i))
+		r.Applied = append(r.Applied, name)
+	}
+	return r, nil
+}
+
+// ReloadConfig reads the configuration again with the ConfigLoader of the
+// options and reloads the node with it.
+func (b *Favor) ReloadConfig() (ReloadReport, error) {
+	if b.options.ConfigLoader == nil {
+		return ReloadReport{}, errNoConfigLoader
+	}
+	o, restart, err := b.options.ConfigLoader()
+	if err != nil {
+		return ReloadReport{}, fmt.Errorf("load config: %w", err)
+	}
+	r, err := b.Reload(o)
+	r.RestartRequired = append(restart, r.RestartRequired...)
+	return r, err
+}
+
+// reloadGroups leaves the groups removed from the configuration and joins or
+// observes the added ones. Changed groups are left and joined again.
+func reloadGroups(group groupReloader, old, groups []model.ConfigNodeGroup) error {
+	current := make(map[string]model.ConfigNodeGroup, len(groups))
+	for _, g := range groups {
+		current[g.Name] = g
+	}
+	var added []model.ConfigNodeGroup
+	previous := make(map[string]model.ConfigNodeGroup, len(old))
+	for _, g := range old {
+		previous[g.Name] = g
+		if g.Name == "" {
+			continue
+		}
+		if c, ok := current[g.Name]; ok && reflect.DeepEqual(c, g) {
+			continue
+		}
+		if err := group.RemoveGroup(g.Name, g.GType); err != nil {
+			return fmt.Errorf("remove group %s: %w", g.Name, err)
+		}
+	}
+	for _, g := range groups {
+		if p, ok := previous[g.Name]; ok && reflect.DeepEqual(p, g) {
+			continue
+		}
+		added = append(added, g)
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	return group.AddGroup(added)
+}
+
+type groupReloader interface {
+	AddGroup([]model.ConfigNodeGroup) error
+	RemoveGroup(string, model.GType) error
+}
@@ -0,0 +1,141 @@
+package node
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/FavorLabs/favorX/pkg/multicast/model"
+)
+
+func TestReload(t *testing.T) {
+	b := &Favor{options: Options{CacheCapacity: 1, KadBinMaxPeers: 1, APIAddr: ":1633"}}
+	var capacity uint64
+	b.onReload("CacheCapacity", func(_, o Options) (bool, error) {
+		capacity = o.CacheCapacity
+		return true, nil
+	})
+	b.onReload("KadBinMaxPeers", func(_, o Options) (bool, error) {
+		return false, nil
+	})
+
+	r, err := b.Reload(Options{CacheCapacity: 2, KadBinMaxPeers: 2, APIAddr: ":1733"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ReloadReport{
+		Applied:         []string{"CacheCapacity"},
+		RestartRequired: []string{"APIAddr", "KadBinMaxPeers"},
+	}
+	sortReport(&r)
+	if !reflect.DeepEqual(r, want) {
+		t.Fatalf("got report %+v, want %+v", r, want)
+	}
+	if capacity != 2 || b.options.CacheCapacity != 2 {
+		t.Fatalf("got capacity %d, running %d, want 2", capacity, b.options.CacheCapacity)
+	}
+	// settings that need a restart keep the running value and are reported again
+	if b.options.APIAddr != ":1633" || b.options.KadBinMaxPeers != 1 {
+		t.Fatalf("got running options %+v", b.options)
+	}
+	r, err = b.Reload(Options{CacheCapacity: 2, KadBinMaxPeers: 2, APIAddr: ":1733"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sortReport(&r)
+	if len(r.Applied) != 0 || !reflect.DeepEqual(r.RestartRequired, want.RestartRequired) {
+		t.Fatalf("got report %+v", r)
+	}
+}
+
+func TestReloadError(t *testing.T) {
+	b := &Favor{options: Options{CacheCapacity: 1}}
+	hookErr := errors.New("hook failed")
+	b.onReload("CacheCapacity", func(_, o Options) (bool, error) {
+		return false, hookErr
+	})
+
+	if _, err := b.Reload(Options{CacheCapacity: 2}); !errors.Is(err, hookErr) {
+		t.Fatalf("got error %v, want %v", err, hookErr)
+	}
+	if b.options.CacheCapacity != 1 {
+		t.Fatalf("got capacity %d after a failed reload, want 1", b.options.CacheCapacity)
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	b := &Favor{}
+	if _, err := b.ReloadConfig(); !errors.Is(err, errNoConfigLoader) {
+		t.Fatalf("got error %v, want %v", err, errNoConfigLoader)
+	}
+
+	b.options.ConfigLoader = func() (Options, []string, error) {
+		return Options{CacheCapacity: 2}, []string{"p2p-addr"}, nil
+	}
+	b.onReload("CacheCapacity", func(_, o Options) (bool, error) {
+		return true, nil
+	})
+	r, err := b.ReloadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ReloadReport{Applied: []string{"CacheCapacity"}, RestartRequired: []string{"p2p-addr"}}
+	if !reflect.DeepEqual(r, want) {
+		t.Fatalf("got report %+v, want %+v", r, want)
+	}
+}
+
+type groupReloaderMock struct {
+	added   []string
+	removed []string
+}
+
+func (m *groupReloaderMock) AddGroup(groups []model.ConfigNodeGroup) error {
+	for _, g := range groups {
+		m.added = append(m.added, g.Name)
+	}
+	return nil
+}
+
+func (m *groupReloaderMock) RemoveGroup(name string, _ model.GType) error {
+	m.removed = append(m.removed, name)
+	return nil
+}
+
+func TestReloadGroups(t *testing.T) {
+	old := []model.ConfigNodeGroup{
+		{Name: "kept", KeepConnectedPeers: 1},
+		{Name: "changed", KeepConnectedPeers: 1},
+		{Name: "removed", GType: model.GTypeObserve},
+	}
+	groups := []model.ConfigNodeGroup{
+		{Name: "kept", KeepConnectedPeers: 1},
+		{Name: "changed", KeepConnectedPeers: 2},
+		{Name: "added"},
+	}
+
+	m := new(groupReloaderMock)
+	if err := reloadGroups(m, old, groups); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"changed", "removed"}; !reflect.DeepEqual(m.removed, want) {
+		t.Fatalf("got removed groups %v, want %v", m.removed, want)
+	}
+	if want := []string{"changed", "added"}; !reflect.DeepEqual(m.added, want) {
+		t.Fatalf("got added groups %v, want %v", m.added, want)
+	}
+
+	m = new(groupReloaderMock)
+	if err := reloadGroups(m, groups, groups); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.added) != 0 || len(m.removed) != 0 {
+		t.Fatalf("got added %v and removed %v groups for an unchanged configuration", m.added, m.removed)
+	}
+}
+
+func sortReport(r *ReloadReport) {
+	sort.Strings(r.Applied)
+	sort.Strings(r.RestartRequired)
+}
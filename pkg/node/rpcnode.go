@@ -167,7 +167,7 @@ func (n *Node) startRPC() error {
 
 	// Configure IPC.
 	if n.ipc.endpoint != "" {
-		if err := n.ipc.start(n.rpcAPIs); err != nil {
+		if err := n.ipc.start(n.rpcAPIs, n.config.Authorizer); err != nil {
 			return err
 		}
 	}
@@ -184,6 +184,7 @@ func (n *Node) startRPC() error {
 			CorsAllowedOrigins: n.config.HTTPCors,
 			Vhosts:             n.config.HTTPVirtualHosts,
 			Modules:            n.config.HTTPModules,
+			Authorizer:         n.config.Authorizer,
 			prefix:             n.config.HTTPPathPrefix,
 		}
 		if err := n.http.setListenAddr(n.config.HTTPAddr, tls); err != nil {
@@ -198,9 +199,10 @@ func (n *Node) startRPC() error {
 	if n.config.WSAddr != "" {
 		server := n.wsServerForAddr(n.config.WSAddr)
 		config := wsConfig{
-			Modules: n.config.WSModules,
-			Origins: n.config.WSOrigins,
-			prefix:  n.config.WSPathPrefix,
+			Modules:    n.config.WSModules,
+			Origins:    n.config.WSOrigins,
+			Authorizer: n.config.Authorizer,
+			prefix:     n.config.WSPathPrefix,
 		}
 		if err := server.setListenAddr(n.config.WSAddr, tls); err != nil {
 			return err
@@ -23,6 +23,7 @@ type httpConfig struct {
 	Modules            []string
 	CorsAllowedOrigins []string
 	Vhosts             []string
+	Authorizer         rpc.Authorizer
 	prefix             string // path prefix on which to mount http handler
 }
 
@@ -34,9 +35,10 @@ type serverTLS struct {
 
 // wsConfig is the JSON-RPC/Websocket configuration
 type wsConfig struct {
-	Origins []string
-	Modules []string
-	prefix  string // path prefix on which to mount ws handler
+	Origins    []string
+	Modules    []string
+	Authorizer rpc.Authorizer
+	prefix     string // path prefix on which to mount ws handler
 }
 
 type rpcHandler struct {
@@ -294,6 +296,9 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 
 	// Create RPC server and handler.
 	srv := rpc.NewServer()
+	if config.Authorizer != nil {
+		srv.SetAuthorizer(config.Authorizer)
+	}
 	if err := h.registerApis(apis, config.Modules, srv, false); err != nil {
 		return err
 	}
@@ -326,6 +331,9 @@ func (h *httpServer) enableWS(apis []rpc.API, config wsConfig) error {
 
 	// Create RPC server and handler.
 	srv := rpc.NewServer()
+	if config.Authorizer != nil {
+		srv.SetAuthorizer(config.Authorizer)
+	}
 	if err := h.registerApis(apis, config.Modules, srv, false); err != nil {
 		return err
 	}
@@ -487,14 +495,14 @@ func newIPCServer(log logging.Logger, endpoint string) *ipcServer {
 }
 
 // Start starts the httpServer's http.Server
-func (is *ipcServer) start(apis []rpc.API) error {
+func (is *ipcServer) start(apis []rpc.API, auth rpc.Authorizer) error {
 	is.mu.Lock()
 	defer is.mu.Unlock()
 
 	if is.listener != nil {
 		return nil // already running
 	}
-	listener, srv, err := rpc.StartIPCEndpoint(is.endpoint, apis)
+	listener, srv, err := rpc.StartIPCEndpoint(is.endpoint, apis, auth)
 	if err != nil {
 		is.log.Warningf("IPC opening failed url %s error %s", is.endpoint, err)
 		return err
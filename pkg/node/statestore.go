@@ -3,10 +3,13 @@ package node
 import (
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/FavorLabs/favorX/pkg/boson"
 	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/shed"
 	"github.com/FavorLabs/favorX/pkg/statestore/leveldb"
 	"github.com/FavorLabs/favorX/pkg/statestore/mock"
 	"github.com/FavorLabs/favorX/pkg/storage"
@@ -15,13 +18,55 @@ import (
 // InitStateStore will initialize the stateStore with the given path to the
 // data directory. When given an empty directory path, the function will instead
 // initialize an in-memory state store that will not be persisted.
-func InitStateStore(log logging.Logger, dataDir string) (ret storage.StateStorer, err error) {
+// A non-empty password encrypts the persisted state store at rest.
+// The state store is opened with the driver it was migrated to, if any.
+func InitStateStore(log logging.Logger, dataDir, password string) (ret storage.StateStorer, err error) {
 	if dataDir == "" {
 		ret = mock.NewStateStore()
 		log.Warning("using in-mem state store, no node state will be persisted")
 		return ret, nil
 	}
-	return leveldb.NewStateStore(filepath.Join(dataDir, "statestore"), log)
+	path := filepath.Join(dataDir, "statestore")
+	drv, err := StateStoreDriver(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	if drv != shed.LEVELDB {
+		db, err := shed.Open(path, &shed.Options{Driver: drv, Password: password})
+		if err != nil {
+			return nil, err
+		}
+		return leveldb.NewStateStoreWithDB(db, log)
+	}
+	if password != "" {
+		return leveldb.NewEncryptedStateStore(path, password, log)
+	}
+	return leveldb.NewStateStore(path, log)
+}
+
+// StateStoreDriverPath returns the path of the file that names the driver the
+// state store in the data directory was migrated to.
+func StateStoreDriverPath(dataDir string) string {
+	return filepath.Join(dataDir, "statestore.driver")
+}
+
+// StateStoreDriver returns the driver the state store in the data directory
+// is kept by. It is leveldb unless the state store was migrated.
+func StateStoreDriver(dataDir string) (string, error) {
+	b, err := os.ReadFile(StateStoreDriverPath(dataDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return shed.LEVELDB, nil
+		}
+		return "", fmt.Errorf("statestore driver: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// SwapJournalPath returns the path of the journal of the database swap done
+// at the end of a migration, see shed.Swap.
+func SwapJournalPath(dataDir string) string {
+	return filepath.Join(dataDir, "migrate.journal")
 }
 
 const overlayKey = "overlay"
@@ -51,3 +96,9 @@ func CheckOverlayWithStore(overlay boson.Address, storer storage.StateStorer) er
 
 	return nil
 }
+
+// StoredOverlay returns the overlay address kept in the statestore.
+func StoredOverlay(storer storage.StateStorer) (overlay boson.Address, err error) {
+	err = storer.Get(secureOverlayKey, &overlay)
+	return overlay, err
+}
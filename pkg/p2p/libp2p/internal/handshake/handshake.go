@@ -63,12 +63,13 @@ type Service struct {
 	nodeMode              address.Model
 	networkID             uint64
 	welcomeMessage        atomic.Value
+	price                 atomic.Value
 	logger                logging.Logger
 	libp2pID              libp2ppeer.ID
 	metrics               metrics
 	picker                p2p.Picker
 	lightNodes            lightnode.LightNodes
-	lightNodeLimit        int
+	lightNodeLimit        int64
 }
 
 // New creates a new handshake Service.
@@ -87,9 +88,10 @@ func New(signer crypto.Signer, advertisableAddresser AdvertisableAddressResolver
 		libp2pID:              ownPeerID,
 		metrics:               newMetrics(),
 		lightNodes:            lightNodes,
-		lightNodeLimit:        lightLimit,
+		lightNodeLimit:        int64(lightLimit),
 	}
 	svc.welcomeMessage.Store(welcomeMessage)
+	svc.price.Store(address.DefaultPrice)
 
 	return svc, nil
 }
@@ -166,6 +168,7 @@ func (s *Service) Handshake(ctx context.Context, stream p2p.Stream, peerMultiadd
 
 	// Synced read:
 	welcomeMessage := s.GetWelcomeMessage()
+	price := s.Price()
 	if err := w.WriteMsgWithContext(ctx, &pb.Ack{
 		Address: &pb.BzzAddress{
 			Underlay:  advertisableUnderlayBytes,
@@ -174,6 +177,7 @@ func (s *Service) Handshake(ctx context.Context, stream p2p.Stream, peerMultiadd
 		},
 		NetworkID:      s.networkID,
 		NodeMode:       s.nodeMode.Bv.Bytes(),
+		Price:          pbPrice(price),
 		WelcomeMessage: welcomeMessage,
 	}); err != nil {
 		return nil, fmt.Errorf("write ack message: %w", err)
@@ -191,6 +195,8 @@ func (s *Service) Handshake(ctx context.Context, stream p2p.Stream, peerMultiadd
 	return &address.AddressInfo{
 		Address:  remoteBzzAddress,
 		NodeMode: md,
+		Price:    parsePrice(resp.Ack.Price),
+		OwnPrice: &price,
 	}, nil
 }
 
@@ -238,6 +244,7 @@ func (s *Service) Handle(ctx context.Context, stream p2p.Stream, remoteMultiaddr
 	}
 
 	welcomeMessage := s.GetWelcomeMessage()
+	price := s.Price()
 
 	if err := w.WriteMsgWithContext(ctx, &pb.SynAck{
 		Syn: &pb.Syn{
@@ -251,6 +258,7 @@ func (s *Service) Handle(ctx context.Context, stream p2p.Stream, remoteMultiaddr
 			},
 			NetworkID:      s.networkID,
 			NodeMode:       s.nodeMode.Bv.Bytes(),
+			Price:          pbPrice(price),
 			WelcomeMessage: welcomeMessage,
 		},
 	}); err != nil {
@@ -282,8 +290,8 @@ func (s *Service) Handle(ctx context.Context, stream p2p.Stream, remoteMultiaddr
 				return nil, ErrPicker
 			}
 		} else {
-			if s.lightNodes.Count() >= s.lightNodeLimit {
-				s.logger.Warningf("%s %s with limit %d", ErrPickerLight.Error(), overlay.String(), s.lightNodeLimit)
+			if limit := s.LightNodeLimit(); s.lightNodes.Count() >= limit {
+				s.logger.Warningf("%s %s with limit %d", ErrPickerLight.Error(), overlay.String(), limit)
 				return nil, ErrPickerLight
 			}
 		}
@@ -302,6 +310,8 @@ func (s *Service) Handle(ctx context.Context, stream p2p.Stream, remoteMultiaddr
 	return &address.AddressInfo{
 		Address:  remoteBzzAddress,
 		NodeMode: mode,
+		Price:    parsePrice(ack.Price),
+		OwnPrice: &price,
 	}, nil
 }
 
@@ -319,6 +329,38 @@ func (s *Service) GetWelcomeMessage() string {
 	return s.welcomeMessage.Load().(string)
 }
 
+// SetPrice sets the price announced in the handshake.
+func (s *Service) SetPrice(price address.Price) {
+	s.price.Store(price)
+}
+
+// Price returns the price announced in the handshake.
+func (s *Service) Price() address.Price {
+	return s.price.Load().(address.Price)
+}
+
+func pbPrice(price address.Price) *pb.Price {
+	return &pb.Price{Chunk: price.Chunk, Byte: price.Byte}
+}
+
+// parsePrice returns the price the peer announced, nil if it announced none.
+func parsePrice(price *pb.Price) *address.Price {
+	if price == nil {
+		return nil
+	}
+	return &address.Price{Chunk: price.Chunk, Byte: price.Byte}
+}
+
+// SetLightNodeLimit sets the maximum number of connected light nodes.
+func (s *Service) SetLightNodeLimit(n int) {
+	atomic.StoreInt64(&s.lightNodeLimit, int64(n))
+}
+
+// LightNodeLimit returns the maximum number of connected light nodes.
+func (s *Service) LightNodeLimit() int {
+	return int(atomic.LoadInt64(&s.lightNodeLimit))
+}
+
 func buildFullMA(addr ma.Multiaddr, peerID libp2ppeer.ID) (ma.Multiaddr, error) {
 	return ma.NewMultiaddr(fmt.Sprintf("%s/p2p/%s", addr.String(), peerID.Pretty()))
 }
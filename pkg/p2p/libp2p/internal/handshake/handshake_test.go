@@ -169,6 +169,56 @@ func TestHandshake(t *testing.T) {
 		}
 	})
 
+	t.Run("Handshake - price", func(t *testing.T) {
+		var buffer1 bytes.Buffer
+		var buffer2 bytes.Buffer
+		stream1 := mock.NewStream(&buffer1, &buffer2)
+		stream2 := mock.NewStream(&buffer2, &buffer1)
+
+		price := address.Price{Chunk: 5, Byte: 2}
+		handshakeService.SetPrice(price)
+		defer handshakeService.SetPrice(address.DefaultPrice)
+
+		w, r := protobuf.NewWriterAndReader(stream2)
+		if err := w.WriteMsg(&pb.SynAck{
+			Syn: &pb.Syn{
+				ObservedUnderlay: node1maBinary,
+			},
+			Ack: &pb.Ack{
+				Address: &pb.BzzAddress{
+					Underlay:  node2maBinary,
+					Overlay:   node2BzzAddress.Overlay.Bytes(),
+					Signature: node2BzzAddress.Signature,
+				},
+				NetworkID: networkID,
+				NodeMode:  node2Info.NodeMode.Bv.Bytes(),
+				Price:     &pb.Price{Chunk: 7, Byte: 3},
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := handshakeService.Handshake(context.Background(), stream1, node2AddrInfo.Addrs[0], node2AddrInfo.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Price == nil || *res.Price != (address.Price{Chunk: 7, Byte: 3}) {
+			t.Fatalf("got peer price %+v", res.Price)
+		}
+
+		var syn pb.Syn
+		if err := r.ReadMsg(&syn); err != nil {
+			t.Fatal(err)
+		}
+		var ack pb.Ack
+		if err := r.ReadMsg(&ack); err != nil {
+			t.Fatal(err)
+		}
+		if ack.Price == nil || ack.Price.Chunk != price.Chunk || ack.Price.Byte != price.Byte {
+			t.Fatalf("got announced price %+v, want %+v", ack.Price, price)
+		}
+	})
+
 	t.Run("Handshake - picker error", func(t *testing.T) {
 
 		handshakeService, err = handshake.New(signer1, aaddresser, node1Info.Address.Overlay, networkID, address.NewModel().SetMode(address.FullNode), "", node1AddrInfo.ID, logger, light, lightnode.DefaultLightNodeLimit)
@@ -70,6 +70,7 @@ type Ack struct {
 	Address        *BzzAddress `protobuf:"bytes,1,opt,name=Address,proto3" json:"Address,omitempty"`
 	NetworkID      uint64      `protobuf:"varint,2,opt,name=NetworkID,proto3" json:"NetworkID,omitempty"`
 	NodeMode       []byte      `protobuf:"bytes,3,opt,name=NodeMode,proto3" json:"NodeMode,omitempty"`
+	Price          *Price      `protobuf:"bytes,4,opt,name=Price,proto3" json:"Price,omitempty"`
 	WelcomeMessage string      `protobuf:"bytes,99,opt,name=WelcomeMessage,proto3" json:"WelcomeMessage,omitempty"`
 }
 
@@ -127,6 +128,13 @@ func (m *Ack) GetNodeMode() []byte {
 	return nil
 }
 
+func (m *Ack) GetPrice() *Price {
+	if m != nil {
+		return m.Price
+	}
+	return nil
+}
+
 func (m *Ack) GetWelcomeMessage() string {
 	if m != nil {
 		return m.WelcomeMessage
@@ -246,37 +254,92 @@ func (m *BzzAddress) GetOverlay() []byte {
 	return nil
 }
 
+type Price struct {
+	Chunk uint64 `protobuf:"varint,1,opt,name=Chunk,proto3" json:"Chunk,omitempty"`
+	Byte  uint64 `protobuf:"varint,2,opt,name=Byte,proto3" json:"Byte,omitempty"`
+}
+
+func (m *Price) Reset()         { *m = Price{} }
+func (m *Price) String() string { return proto.CompactTextString(m) }
+func (*Price) ProtoMessage()    {}
+func (*Price) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a77305914d5d202f, []int{4}
+}
+func (m *Price) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Price) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Price.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Price) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Price.Merge(m, src)
+}
+func (m *Price) XXX_Size() int {
+	return m.Size()
+}
+func (m *Price) XXX_DiscardUnknown() {
+	xxx_messageInfo_Price.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Price proto.InternalMessageInfo
+
+func (m *Price) GetChunk() uint64 {
+	if m != nil {
+		return m.Chunk
+	}
+	return 0
+}
+
+func (m *Price) GetByte() uint64 {
+	if m != nil {
+		return m.Byte
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Syn)(nil), "handshakeFavorX.Syn")
 	proto.RegisterType((*Ack)(nil), "handshakeFavorX.Ack")
 	proto.RegisterType((*SynAck)(nil), "handshakeFavorX.SynAck")
 	proto.RegisterType((*BzzAddress)(nil), "handshakeFavorX.BzzAddress")
+	proto.RegisterType((*Price)(nil), "handshakeFavorX.Price")
 }
 
 func init() { proto.RegisterFile("handshake.proto", fileDescriptor_a77305914d5d202f) }
 
 var fileDescriptor_a77305914d5d202f = []byte{
-	// 306 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0xcf, 0x48, 0xcc, 0x4b,
-	0x29, 0xce, 0x48, 0xcc, 0x4e, 0xd5, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x42, 0x08, 0xb8, 0x25,
-	0x96, 0xe5, 0x17, 0x45, 0x28, 0x19, 0x72, 0x31, 0x07, 0x57, 0xe6, 0x09, 0x69, 0x71, 0x09, 0xf8,
-	0x27, 0x15, 0xa7, 0x16, 0x95, 0xa5, 0xa6, 0x84, 0xe6, 0xa5, 0xa4, 0x16, 0xe5, 0x24, 0x56, 0x4a,
-	0x30, 0x2a, 0x30, 0x6a, 0xf0, 0x04, 0x61, 0x88, 0x2b, 0xcd, 0x63, 0xe4, 0x62, 0x76, 0x4c, 0xce,
-	0x16, 0x32, 0xe5, 0x62, 0x77, 0x4c, 0x49, 0x29, 0x4a, 0x2d, 0x2e, 0x06, 0x2b, 0xe5, 0x36, 0x92,
-	0xd6, 0x43, 0x33, 0x5d, 0xcf, 0xa9, 0xaa, 0x0a, 0xaa, 0x24, 0x08, 0xa6, 0x56, 0x48, 0x86, 0x8b,
-	0xd3, 0x2f, 0xb5, 0xa4, 0x3c, 0xbf, 0x28, 0xdb, 0xd3, 0x45, 0x82, 0x49, 0x81, 0x51, 0x83, 0x25,
-	0x08, 0x21, 0x20, 0x24, 0xc5, 0xc5, 0xe1, 0x97, 0x9f, 0x92, 0xea, 0x9b, 0x9f, 0x92, 0x2a, 0xc1,
-	0x0c, 0x76, 0x00, 0x9c, 0x2f, 0xa4, 0xc6, 0xc5, 0x17, 0x9e, 0x9a, 0x93, 0x9c, 0x9f, 0x9b, 0xea,
-	0x9b, 0x5a, 0x5c, 0x9c, 0x98, 0x9e, 0x2a, 0x91, 0xac, 0xc0, 0xa8, 0xc1, 0x19, 0x84, 0x26, 0xaa,
-	0x14, 0xc1, 0xc5, 0x16, 0x5c, 0x99, 0x07, 0x72, 0xa2, 0x1a, 0xd8, 0x77, 0x50, 0xe7, 0x89, 0x60,
-	0x38, 0x2f, 0xb8, 0x32, 0x2f, 0x08, 0xec, 0x7d, 0x35, 0xb0, 0x8f, 0xc0, 0xae, 0xc1, 0xa6, 0xce,
-	0x31, 0x39, 0x3b, 0x08, 0xa4, 0x40, 0x29, 0x81, 0x8b, 0x0b, 0xe1, 0x25, 0x90, 0x5b, 0xd1, 0x02,
-	0x0b, 0xce, 0x07, 0xf9, 0x32, 0x38, 0x33, 0x3d, 0x2f, 0xb1, 0xa4, 0xb4, 0x28, 0x15, 0x6c, 0x2e,
-	0x4f, 0x10, 0x42, 0x40, 0x48, 0x82, 0x8b, 0xdd, 0xbf, 0x0c, 0xa2, 0x11, 0xe2, 0x49, 0x18, 0xd7,
-	0x49, 0xe6, 0xc4, 0x23, 0x39, 0xc6, 0x0b, 0x8f, 0xe4, 0x18, 0x1f, 0x3c, 0x92, 0x63, 0x9c, 0xf0,
-	0x58, 0x8e, 0xe1, 0xc2, 0x63, 0x39, 0x86, 0x1b, 0x8f, 0xe5, 0x18, 0xa2, 0x98, 0x0a, 0x92, 0x92,
-	0xd8, 0xc0, 0xb1, 0x68, 0x0c, 0x08, 0x00, 0x00, 0xff, 0xff, 0x5d, 0x8e, 0xa1, 0xbe, 0xd8, 0x01,
-	0x00, 0x00,
+	// 350 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x92, 0xc1, 0x6a, 0xf2, 0x40,
+	0x1c, 0xc4, 0x5d, 0x8d, 0xfa, 0xf9, 0xff, 0xa4, 0x2d, 0x8b, 0x94, 0xd0, 0x4a, 0x90, 0x1c, 0x44,
+	0x4a, 0x11, 0x6c, 0xe9, 0x03, 0x68, 0x4b, 0xa1, 0x07, 0xb5, 0x6c, 0x28, 0x95, 0x9e, 0x1a, 0x93,
+	0x3f, 0x2a, 0xb1, 0xbb, 0xb2, 0x89, 0x96, 0xf8, 0x14, 0x7d, 0xac, 0x1e, 0x7a, 0xf0, 0xd8, 0x63,
+	0xd1, 0x17, 0x29, 0xd9, 0x44, 0x03, 0x4a, 0x6f, 0x99, 0xc9, 0x64, 0x36, 0xf3, 0x63, 0xe1, 0x78,
+	0x6c, 0x73, 0xd7, 0x1f, 0xdb, 0x1e, 0x36, 0x67, 0x52, 0x04, 0x82, 0xa6, 0xc6, 0xbd, 0xbd, 0x10,
+	0x72, 0x60, 0xb6, 0x20, 0x67, 0x85, 0x9c, 0x5e, 0xc0, 0x49, 0x7f, 0xe8, 0xa3, 0x5c, 0xa0, 0xfb,
+	0xc4, 0x5d, 0x94, 0x53, 0x3b, 0xd4, 0x49, 0x8d, 0x34, 0xca, 0xec, 0xc0, 0x37, 0xbf, 0x08, 0xe4,
+	0xda, 0x8e, 0x47, 0x6f, 0xa0, 0xd8, 0x76, 0x5d, 0x89, 0xbe, 0xaf, 0xa2, 0xff, 0xaf, 0xce, 0x9b,
+	0x7b, 0xed, 0xcd, 0xce, 0x72, 0x99, 0x44, 0xd8, 0x36, 0x4b, 0xab, 0x50, 0xea, 0x61, 0xf0, 0x2e,
+	0xa4, 0xf7, 0x70, 0xa7, 0x67, 0x6b, 0xa4, 0xa1, 0xb1, 0xd4, 0xa0, 0x67, 0xf0, 0xaf, 0x27, 0x5c,
+	0xec, 0x0a, 0x17, 0xf5, 0x9c, 0xfa, 0x81, 0x9d, 0xa6, 0x97, 0x90, 0x7f, 0x94, 0x13, 0x07, 0x75,
+	0x4d, 0x1d, 0x77, 0x7a, 0x70, 0x9c, 0x7a, 0xcb, 0xe2, 0x10, 0xad, 0xc3, 0xd1, 0x33, 0x4e, 0x1d,
+	0xf1, 0x86, 0x5d, 0xf4, 0x7d, 0x7b, 0x84, 0xba, 0x53, 0x23, 0x8d, 0x12, 0xdb, 0x73, 0xcd, 0x01,
+	0x14, 0xac, 0x90, 0x47, 0x83, 0xea, 0x8a, 0x45, 0x32, 0xa6, 0x72, 0xd0, 0x6e, 0x85, 0x9c, 0x29,
+	0x58, 0x75, 0xb5, 0x5f, 0xcf, 0xfe, 0x91, 0x6b, 0x3b, 0x1e, 0x8b, 0x02, 0xe6, 0x2b, 0x40, 0x0a,
+	0x20, 0x5a, 0xb6, 0x87, 0x76, 0xa7, 0x23, 0x26, 0xd6, 0x64, 0xc4, 0xed, 0x60, 0x2e, 0x51, 0xf5,
+	0x96, 0x59, 0x6a, 0x50, 0x1d, 0x8a, 0xfd, 0x45, 0xfc, 0x61, 0x8c, 0x64, 0x2b, 0xcd, 0x56, 0x42,
+	0x84, 0x56, 0x20, 0x7f, 0x3b, 0x9e, 0x73, 0x4f, 0x35, 0x6b, 0x2c, 0x16, 0x94, 0x82, 0xd6, 0x09,
+	0x03, 0x4c, 0x28, 0xab, 0xe7, 0x4e, 0xf5, 0x73, 0x6d, 0x90, 0xd5, 0xda, 0x20, 0x3f, 0x6b, 0x83,
+	0x7c, 0x6c, 0x8c, 0xcc, 0x6a, 0x63, 0x64, 0xbe, 0x37, 0x46, 0xe6, 0x25, 0x3b, 0x1b, 0x0e, 0x0b,
+	0xea, 0x9a, 0x5c, 0xff, 0x0e, 0x00, 0x26, 0xdc, 0x80, 0xea, 0x39, 0x02, 0x00, 0x00,
 }
 
 func (m *Syn) Marshal() (dAtA []byte, err error) {
@@ -338,6 +401,18 @@ func (m *Ack) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i--
 		dAtA[i] = 0x9a
 	}
+	if m.Price != nil {
+		{
+			size, err := m.Price.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintHandshake(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
 	if len(m.NodeMode) > 0 {
 		i -= len(m.NodeMode)
 		copy(dAtA[i:], m.NodeMode)
@@ -456,6 +531,39 @@ func (m *BzzAddress) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *Price) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Price) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Price) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Byte != 0 {
+		i = encodeVarintHandshake(dAtA, i, uint64(m.Byte))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Chunk != 0 {
+		i = encodeVarintHandshake(dAtA, i, uint64(m.Chunk))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintHandshake(dAtA []byte, offset int, v uint64) int {
 	offset -= sovHandshake(v)
 	base := offset
@@ -497,6 +605,10 @@ func (m *Ack) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovHandshake(uint64(l))
 	}
+	if m.Price != nil {
+		l = m.Price.Size()
+		n += 1 + l + sovHandshake(uint64(l))
+	}
 	l = len(m.WelcomeMessage)
 	if l > 0 {
 		n += 2 + l + sovHandshake(uint64(l))
@@ -542,6 +654,21 @@ func (m *BzzAddress) Size() (n int) {
 	return n
 }
 
+func (m *Price) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Chunk != 0 {
+		n += 1 + sovHandshake(uint64(m.Chunk))
+	}
+	if m.Byte != 0 {
+		n += 1 + sovHandshake(uint64(m.Byte))
+	}
+	return n
+}
+
 func sovHandshake(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -750,6 +877,42 @@ func (m *Ack) Unmarshal(dAtA []byte) error {
 				m.NodeMode = []byte{}
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Price", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHandshake
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHandshake
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthHandshake
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Price == nil {
+				m.Price = &Price{}
+			}
+			if err := m.Price.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 99:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field WelcomeMessage", wireType)
@@ -1077,6 +1240,94 @@ func (m *BzzAddress) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *Price) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowHandshake
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Price: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Price: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Chunk", wireType)
+			}
+			m.Chunk = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHandshake
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Chunk |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Byte", wireType)
+			}
+			m.Byte = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHandshake
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Byte |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipHandshake(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthHandshake
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipHandshake(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -85,7 +85,6 @@ type Service struct {
 	halt              chan struct{}
 	lightNodes        lightnode.LightNodes
 	bootNodes         bootnode.BootNodes
-	lightNodeLimit    int
 	protocolsmu       sync.RWMutex
 	route             routetab.RelayStream
 	self              boson.Address
@@ -275,7 +274,6 @@ func New(ctx context.Context, signer beecrypto.Signer, networkID uint64, overlay
 		halt:              make(chan struct{}),
 		bootNodes:         bootNodes,
 		lightNodes:        lightNodes,
-		lightNodeLimit:    o.LightNodeLimit,
 	}
 
 	peerRegistry.setDisconnecter(s)
@@ -381,7 +379,7 @@ func (s *Service) handleIncoming(stream network.Stream) {
 		}
 	}
 
-	peer := p2p.Peer{Address: overlay, Mode: i.NodeMode}
+	peer := p2p.Peer{Address: overlay, Mode: i.NodeMode, Price: i.Price, OwnPrice: i.OwnPrice}
 
 	s.protocolsmu.RLock()
 	for _, tn := range s.protocols {
@@ -410,7 +408,7 @@ func (s *Service) handleIncoming(stream network.Stream) {
 				s.logger.Debugf("stream handler: notifier.Announce: %s: %v", peer.Address.String(), err)
 			}
 
-			if s.lightNodes.Count() > s.lightNodeLimit {
+			if s.lightNodes.Count() > s.handshakeService.LightNodeLimit() {
 				// kick another node to fit this one in
 				p, err := s.lightNodes.RandomPeer(peer.Address)
 				if err != nil {
@@ -800,7 +798,7 @@ func (s *Service) Connect(ctx context.Context, addr ma.Multiaddr) (peer *p2p.Pee
 	s.protocolsmu.RLock()
 	for _, tn := range s.protocols {
 		if tn.ConnectOut != nil {
-			if err := tn.ConnectOut(ctx, p2p.Peer{Address: overlay, Mode: i.NodeMode}); err != nil {
+			if err := tn.ConnectOut(ctx, p2p.Peer{Address: overlay, Mode: i.NodeMode, Price: i.Price, OwnPrice: i.OwnPrice}); err != nil {
 				s.logger.Debugf("connectOut: protocol: %s, version:%s, peer: %s: %v", tn.Name, tn.Version, overlay, err)
 				_ = s.Disconnect(overlay, fmt.Sprintf("failed to process outbound connection notifier %s", err))
 				s.protocolsmu.RUnlock()
@@ -1180,6 +1178,20 @@ func (s *Service) GetWelcomeMessage() string {
 	return s.handshakeService.GetWelcomeMessage()
 }
 
+// SetPrice sets the price announced to the peers in the handshake.
+func (s *Service) SetPrice(price address.Price) {
+	s.handshakeService.SetPrice(price)
+}
+
+// SetLightNodeLimit sets the maximum number of connected light nodes. A
+// non-positive n restores the default.
+func (s *Service) SetLightNodeLimit(n int) {
+	if n <= 0 {
+		n = lightnode.DefaultLightNodeLimit
+	}
+	s.handshakeService.SetLightNodeLimit(n)
+}
+
 func (s *Service) Ready() error {
 	if err := s.reachabilityWorker(); err != nil {
 		return fmt.Errorf("reachability worker: %w", err)
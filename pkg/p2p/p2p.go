@@ -209,8 +209,11 @@ type BlockPeers struct {
 
 // Peer holds information about a Peer.
 type Peer struct {
-	Address boson.Address `json:"address"`
-	Mode    address.Model `json:"mode"`
+	Address boson.Address  `json:"address"`
+	Mode    address.Model  `json:"mode"`
+	Price   *address.Price `json:"price,omitempty"` // announced in the handshake, set on connect
+	// OwnPrice is the price announced to the peer in the handshake, set on connect.
+	OwnPrice *address.Price `json:"-"`
 }
 
 type PeerInfo struct {
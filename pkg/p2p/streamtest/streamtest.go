@@ -39,9 +39,9 @@ type Recorder struct {
 	protocolsWithPeers map[string]p2p.ProtocolSpec
 }
 
+// NewConnChainRelayStream records the stream as if the target was connected.
 func (r *Recorder) NewConnChainRelayStream(ctx context.Context, target boson.Address, h p2p.Headers, protocolName, protocolVersion, streamName string) (p2p.Stream, error) {
-	// TODO implement me
-	panic("implement me")
+	return r.NewStream(ctx, target, h, protocolName, protocolVersion, streamName)
 }
 
 func WithProtocols(protocols ...p2p.ProtocolSpec) Option {
@@ -335,7 +335,8 @@ func (r *record) Read(p []byte) (n int, err error) {
 	}
 	n = copy(p, r.b[r.c:end])
 	r.c += n
-	if r.Closed() {
+	// what was written before the close is read first
+	if r.c == len(r.b) && r.Closed() {
 		err = io.EOF
 	}
 
@@ -340,6 +340,46 @@ func TestRecorder_closeAfterPartialWrite(t *testing.T) {
 	}, nil)
 }
 
+func TestRecorder_readAfterClose(t *testing.T) {
+	closed := make(chan struct{})
+	recorder := streamtest.New(
+		streamtest.WithProtocols(
+			newTestProtocol(func(_ context.Context, peer p2p.Peer, stream p2p.Stream) error {
+				defer close(closed)
+				if _, err := stream.Write([]byte("first\nsecond\n")); err != nil {
+					return fmt.Errorf("write: %w", err)
+				}
+				return stream.Close()
+			}),
+		),
+	)
+
+	stream, err := recorder.NewStream(context.Background(), boson.ZeroAddress, nil, testProtocolName, testProtocolVersion, testStreamName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	<-closed
+
+	// the data written before the close is read in full, as buffered readers
+	// drop what is left once they get io.EOF
+	var got []byte
+	b := make([]byte, 4)
+	for {
+		n, err := stream.Read(b)
+		got = append(got, b[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if string(got) != "first\nsecond\n" {
+		t.Errorf("got %q, want %q", got, "first\nsecond\n")
+	}
+}
+
 func TestRecorder_resetAfterPartialWrite(t *testing.T) {
 	recorder := streamtest.New(
 		streamtest.WithProtocols(
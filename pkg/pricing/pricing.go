@@ -0,0 +1,333 @@
+// Package pricing keeps the prices the node and its peers charge for the
+// traffic they serve. The prices are announced in the handshake, and the
+// changes to them over the pricing protocol. A peer is charged the price it
+// last acknowledged, the announcements are retried until it does.
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/address"
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/p2p"
+	"github.com/FavorLabs/favorX/pkg/p2p/protobuf"
+	"github.com/FavorLabs/favorX/pkg/pricing/pb"
+)
+
+const (
+	protocolName    = "pricing"
+	protocolVersion = "1.0.0"
+	streamName      = "pricing"
+
+	announceTimeout = 5 * time.Second
+)
+
+var (
+	// announceRetryMin and announceRetryMax bound the backoff between the
+	// announcements not acknowledged.
+	announceRetryMin = time.Second
+	announceRetryMax = time.Minute
+
+	errAckMismatch = errors.New("acknowledged price mismatch")
+)
+
+type Interface interface {
+	// Price returns the price the node charges.
+	Price() address.Price
+	// SetPrice changes the price the node charges, announcing it to the
+	// connected peers.
+	SetPrice(ctx context.Context, price address.Price) error
+	// PeerPrice returns the price the peer charges, DefaultPrice if it
+	// announced none.
+	PeerPrice(peer boson.Address) address.Price
+	// ChargedPrice returns the price of the node the peer last
+	// acknowledged, DefaultPrice if unknown.
+	ChargedPrice(peer boson.Address) address.Price
+	// PeerPrices returns the prices of the connected peers.
+	PeerPrices() map[string]address.Price
+}
+
+// HandshakePricer announces the price to the peers connecting.
+type HandshakePricer interface {
+	SetPrice(price address.Price)
+}
+
+type peerPrice struct {
+	peer    boson.Address
+	price   address.Price // charged by the peer
+	charged address.Price // charged to the peer, as it acknowledged
+	version uint64        // of the price charged to the peer
+}
+
+type Service struct {
+	streamer  p2p.Streamer
+	handshake HandshakePricer
+	logger    logging.Logger
+
+	mu      sync.RWMutex
+	price   address.Price
+	version uint64 // bumped on every price change
+	peers   map[string]*peerPrice
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+func New(streamer p2p.Streamer, handshake HandshakePricer, price address.Price, logger logging.Logger) *Service {
+	if handshake != nil {
+		handshake.SetPrice(price)
+	}
+	return &Service{
+		streamer:  streamer,
+		handshake: handshake,
+		logger:    logger,
+		price:     price,
+		peers:     make(map[string]*peerPrice),
+		quit:      make(chan struct{}),
+	}
+}
+
+func (s *Service) Protocol() p2p.ProtocolSpec {
+	return p2p.ProtocolSpec{
+		Name:    protocolName,
+		Version: protocolVersion,
+		StreamSpecs: []p2p.StreamSpec{
+			{
+				Name:    streamName,
+				Handler: s.handler,
+			},
+		},
+		ConnectIn:     s.connect,
+		ConnectOut:    s.connect,
+		DisconnectIn:  s.disconnect,
+		DisconnectOut: s.disconnect,
+	}
+}
+
+// connect records the prices the node and the peer announced to each other
+// in the handshake. The peer is announced the price in case it changed
+// during the handshake.
+func (s *Service) connect(_ context.Context, p p2p.Peer) error {
+	price := address.DefaultPrice
+	if p.Price != nil {
+		price = *p.Price
+	}
+	s.mu.Lock()
+	charged := s.price
+	if p.OwnPrice != nil {
+		charged = *p.OwnPrice
+	}
+	s.peers[p.Address.ByteString()] = &peerPrice{
+		peer:    p.Address,
+		price:   price,
+		charged: charged,
+		version: s.version,
+	}
+	if charged != s.price {
+		s.wg.Add(1)
+		go s.announceLoop(p.Address, s.price, s.version)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Service) disconnect(p p2p.Peer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.peers, p.Address.ByteString())
+	return nil
+}
+
+func (s *Service) setPeerPrice(peer boson.Address, price address.Price) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.peers[peer.ByteString()]; ok {
+		p.price = price
+		return
+	}
+	s.peers[peer.ByteString()] = &peerPrice{
+		peer:    peer,
+		price:   price,
+		charged: s.price,
+		version: s.version,
+	}
+}
+
+func (s *Service) handler(ctx context.Context, p p2p.Peer, stream p2p.Stream) (err error) {
+	r := protobuf.NewReader(stream)
+	defer func() {
+		if err != nil {
+			_ = stream.Reset()
+		} else {
+			go stream.FullClose()
+		}
+	}()
+	var req pb.AnnouncePrice
+	if err := r.ReadMsgWithContext(ctx, &req); err != nil {
+		return fmt.Errorf("read price announcement from peer %v: %w", p.Address, err)
+	}
+	s.logger.Tracef("pricing: peer %s charges %d per chunk and %d per byte", p.Address, req.Chunk, req.Byte)
+	s.setPeerPrice(p.Address, address.Price{Chunk: req.Chunk, Byte: req.Byte})
+
+	// acknowledge the price by echoing it
+	w := protobuf.NewWriter(stream)
+	if err := w.WriteMsgWithContext(ctx, &req); err != nil {
+		return fmt.Errorf("acknowledge price to peer %v: %w", p.Address, err)
+	}
+	return nil
+}
+
+func (s *Service) Price() address.Price {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.price
+}
+
+// SetPrice changes the price and announces it to the connected peers in the
+// background. The peers are charged the previous price until they
+// acknowledge the new one.
+func (s *Service) SetPrice(_ context.Context, price address.Price) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.price = price
+	s.version++
+	// the peers connecting from now on learn the price in the handshake
+	if s.handshake != nil {
+		s.handshake.SetPrice(price)
+	}
+	for _, p := range s.peers {
+		s.wg.Add(1)
+		go s.announceLoop(p.peer, price, s.version)
+	}
+	return nil
+}
+
+// announceLoop announces the price to the peer until it acknowledges it, it
+// disconnects or the price changes again.
+func (s *Service) announceLoop(peer boson.Address, price address.Price, version uint64) {
+	defer s.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for retry := announceRetryMin; ; {
+		if !s.announcing(peer, version) {
+			return
+		}
+		err := s.announce(ctx, peer, price)
+		if err == nil {
+			s.acknowledged(peer, price, version)
+			return
+		}
+		s.logger.Debugf("pricing: announce price to peer %s: %v", peer, err)
+
+		select {
+		case <-s.quit:
+			return
+		case <-time.After(retry):
+		}
+		if retry *= 2; retry > announceRetryMax {
+			retry = announceRetryMax
+		}
+	}
+}
+
+// announcing reports whether the announcement of the price version to the
+// peer is still due.
+func (s *Service) announcing(peer boson.Address, version uint64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.peers[peer.ByteString()]
+	return ok && s.version == version
+}
+
+// acknowledged records the price the peer acknowledged, unless it has
+// acknowledged a later one.
+func (s *Service) acknowledged(peer boson.Address, price address.Price, version uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.peers[peer.ByteString()]; ok && p.version <= version {
+		p.charged = price
+		p.version = version
+	}
+}
+
+func (s *Service) announce(ctx context.Context, peer boson.Address, price address.Price) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, announceTimeout)
+	defer cancel()
+
+	stream, err := s.streamer.NewStream(ctx, peer, nil, protocolName, protocolVersion, streamName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = stream.Reset()
+		} else {
+			go stream.FullClose()
+		}
+	}()
+	w, r := protobuf.NewWriterAndReader(stream)
+	req := &pb.AnnouncePrice{
+		Chunk: price.Chunk,
+		Byte:  price.Byte,
+	}
+	if err := w.WriteMsgWithContext(ctx, req); err != nil {
+		return err
+	}
+	var ack pb.AnnouncePrice
+	if err := r.ReadMsgWithContext(ctx, &ack); err != nil {
+		return fmt.Errorf("read price acknowledgement: %w", err)
+	}
+	if ack.Chunk != req.Chunk || ack.Byte != req.Byte {
+		return errAckMismatch
+	}
+	return nil
+}
+
+func (s *Service) PeerPrice(peer boson.Address) address.Price {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if p, ok := s.peers[peer.ByteString()]; ok {
+		return p.price
+	}
+	return address.DefaultPrice
+}
+
+func (s *Service) PeerPrices() map[string]address.Price {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	prices := make(map[string]address.Price, len(s.peers))
+	for _, p := range s.peers {
+		prices[p.peer.String()] = p.price
+	}
+	return prices
+}
+
+func (s *Service) ChargedPrice(peer boson.Address) address.Price {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if p, ok := s.peers[peer.ByteString()]; ok {
+		return p.charged
+	}
+	return address.DefaultPrice
+}
+
+// Close stops the announcements and waits for them to return.
+func (s *Service) Close() error {
+	close(s.quit)
+	s.wg.Wait()
+	return nil
+}
@@ -0,0 +1,146 @@
+package pricing_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/address"
+	"github.com/FavorLabs/favorX/pkg/boson/test"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/p2p"
+	"github.com/FavorLabs/favorX/pkg/p2p/streamtest"
+	"github.com/FavorLabs/favorX/pkg/pricing"
+)
+
+type handshakeMock struct {
+	price address.Price
+}
+
+func (m *handshakeMock) SetPrice(price address.Price) {
+	m.price = price
+}
+
+func TestPeerPrice(t *testing.T) {
+	logger := logging.New(io.Discard, 0)
+	s := pricing.New(nil, nil, address.DefaultPrice, logger)
+	spec := s.Protocol()
+	announced, silent := test.RandomAddress(), test.RandomAddress()
+	price := address.Price{Chunk: 3, Byte: 2}
+	own := address.DefaultPrice
+
+	if err := spec.ConnectIn(context.Background(), p2p.Peer{Address: announced, Price: &price, OwnPrice: &own}); err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.ConnectOut(context.Background(), p2p.Peer{Address: silent}); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.PeerPrice(announced); got != price {
+		t.Fatalf("got price %+v, want %+v", got, price)
+	}
+	if got := s.PeerPrice(silent); got != address.DefaultPrice {
+		t.Fatalf("got price %+v of the peer announcing none, want the default", got)
+	}
+	if got := s.ChargedPrice(announced); got != own {
+		t.Fatalf("got charged price %+v, want %+v", got, own)
+	}
+	if prices := s.PeerPrices(); len(prices) != 2 {
+		t.Fatalf("got %d peer prices, want 2", len(prices))
+	}
+
+	if err := spec.DisconnectIn(p2p.Peer{Address: announced}); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.PeerPrice(announced); got != address.DefaultPrice {
+		t.Fatalf("got price %+v of the disconnected peer, want the default", got)
+	}
+}
+
+func TestSetPrice(t *testing.T) {
+	logger := logging.New(io.Discard, 0)
+	client := test.RandomAddress()
+	server := pricing.New(nil, nil, address.DefaultPrice, logger)
+	recorder := streamtest.New(
+		streamtest.WithProtocols(server.Protocol()),
+		streamtest.WithBaseAddr(client),
+	)
+	handshake := &handshakeMock{}
+	s := pricing.New(recorder, handshake, address.Price{Chunk: 1, Byte: 1}, logger)
+	if handshake.price != (address.Price{Chunk: 1, Byte: 1}) {
+		t.Fatalf("got handshake price %+v", handshake.price)
+	}
+
+	defer s.Close()
+
+	peer := test.RandomAddress()
+	if err := s.Protocol().ConnectOut(context.Background(), p2p.Peer{Address: peer}); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.ChargedPrice(peer); got != (address.Price{Chunk: 1, Byte: 1}) {
+		t.Fatalf("got charged price %+v before the change", got)
+	}
+	price := address.Price{Chunk: 10, Byte: 4}
+	if err := s.SetPrice(context.Background(), price); err != nil {
+		t.Fatal(err)
+	}
+	if s.Price() != price || handshake.price != price {
+		t.Fatalf("got price %+v and handshake price %+v, want %+v", s.Price(), handshake.price, price)
+	}
+
+	// the connected peer learns the new price
+	for start := time.Now(); server.PeerPrice(client) != price; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("got announced price %+v, want %+v", server.PeerPrice(client), price)
+		}
+	}
+	// and is charged it once acknowledged
+	for start := time.Now(); s.ChargedPrice(peer) != price; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("got charged price %+v, want %+v", s.ChargedPrice(peer), price)
+		}
+	}
+}
+
+func TestSetPriceUnacknowledged(t *testing.T) {
+	logger := logging.New(io.Discard, 0)
+	// a peer reading the announcement without acknowledging it
+	announced := make(chan struct{}, 1)
+	recorder := streamtest.New(
+		streamtest.WithProtocols(p2p.ProtocolSpec{
+			Name:    "pricing",
+			Version: "1.0.0",
+			StreamSpecs: []p2p.StreamSpec{{
+				Name: "pricing",
+				Handler: func(_ context.Context, _ p2p.Peer, stream p2p.Stream) error {
+					select {
+					case announced <- struct{}{}:
+					default:
+					}
+					return stream.Close()
+				},
+			}},
+		}),
+	)
+	old := address.Price{Chunk: 1, Byte: 1}
+	s := pricing.New(recorder, nil, old, logger)
+	defer s.Close()
+
+	peer := test.RandomAddress()
+	if err := s.Protocol().ConnectOut(context.Background(), p2p.Peer{Address: peer, OwnPrice: &old}); err != nil {
+		t.Fatal(err)
+	}
+	price := address.Price{Chunk: 10, Byte: 4}
+	if err := s.SetPrice(context.Background(), price); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-announced:
+	case <-time.After(5 * time.Second):
+		t.Fatal("price not announced")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := s.ChargedPrice(peer); got != old {
+		t.Fatalf("got charged price %+v, want the acknowledged %+v", got, old)
+	}
+}
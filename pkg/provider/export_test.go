@@ -0,0 +1,3 @@
+package provider
+
+var MaxRecordsPerPeer = &maxRecordsPerPeer
@@ -0,0 +1,721 @@
+// Package provider publishes signed records of the files the node stores to
+// the nodes closest to their root cids in kademlia, and looks the records up,
+// so that the nodes find who stores a file without the oracle of a chain.
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/crypto"
+	"github.com/FavorLabs/favorX/pkg/localstore/filestore"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/p2p"
+	"github.com/FavorLabs/favorX/pkg/p2p/protobuf"
+	"github.com/FavorLabs/favorX/pkg/provider/pb"
+	"github.com/FavorLabs/favorX/pkg/storage"
+	"github.com/FavorLabs/favorX/pkg/topology"
+)
+
+const (
+	protocolName      = "provider"
+	protocolVersion   = "1.0.0"
+	provideStreamName = "provide"
+	findStreamName    = "find"
+
+	requestTimeout = 10 * time.Second
+	// scanInterval is how often the files stored since are looked for, to
+	// publish their records.
+	scanInterval = time.Minute
+
+	// DefaultTTL is how long a record is kept by default, unless published
+	// again.
+	DefaultTTL = 6 * time.Hour
+	// DefaultReplication is the number of the closest nodes a record is
+	// published to by default.
+	DefaultReplication = 4
+
+	maxTTL           = 7 * 24 * time.Hour // longest a record from a peer lives
+	maxRecordsPerCid = 32                 // providers kept for a cid, the latest expiring ones
+	maxRecords       = 100000             // records kept for the other nodes
+
+	providedPrefix = "provider_cid_"
+)
+
+// maxRecordsPerPeer is the number of records kept which a single peer sent.
+var maxRecordsPerPeer = 2000
+
+var ErrInvalidRecord = errors.New("invalid provider record")
+
+type Interface interface {
+	// Provide announces the node stores the file, until Unprovide is called
+	// or the file is gone.
+	Provide(ctx context.Context, rootCid boson.Address) error
+	// Unprovide stops announcing the file, the records published expire.
+	Unprovide(rootCid boson.Address) error
+	// FindProviders returns the nodes announcing the file, asking the nodes
+	// closest to the root cid.
+	FindProviders(ctx context.Context, rootCid boson.Address) []boson.Address
+}
+
+// FileStore tells which files the node stores, all of which are announced.
+type FileStore interface {
+	HasFile(rootCid boson.Address) bool
+	GetListFile(page filestore.Page, filter []filestore.Filter, sort filestore.Sort) ([]filestore.FileView, int)
+}
+
+// Options tune how long the records live and where they are published.
+type Options struct {
+	TTL         time.Duration // records expire after, DefaultTTL if 0
+	Refresh     time.Duration // records published again at, half the TTL if 0
+	Replication int           // closest nodes a record is published to, DefaultReplication if 0
+}
+
+// Record tells that the overlay stores the file of the root cid, until it
+// expires. It is signed by the overlay.
+type Record struct {
+	RootCid   boson.Address
+	Overlay   boson.Address
+	Expires   time.Time
+	Signature []byte
+}
+
+// heldRecord is a record kept for the other nodes, along with the peer which
+// sent it.
+type heldRecord struct {
+	Record
+	from string
+}
+
+type Service struct {
+	base      boson.Address
+	signer    crypto.Signer
+	networkID uint64
+	streamer  p2p.Streamer
+	topology  topology.ClosestPeerer
+	files     FileStore
+	store     storage.StateStorer
+	logger    logging.Logger
+	opts      Options
+
+	mu      sync.Mutex
+	records map[string]map[string]heldRecord // held for the other nodes, by root cid and overlay
+	count   int
+	senders map[string]int // records held per sending peer
+	soonest time.Time      // no record held expires before
+
+	announcedMu sync.Mutex
+	announced   map[string]struct{} // root cids published since the last refresh
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New(base boson.Address, signer crypto.Signer, networkID uint64, streamer p2p.Streamer, topology topology.ClosestPeerer,
+	files FileStore, store storage.StateStorer, logger logging.Logger, opts Options) *Service {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultTTL
+	}
+	if opts.Refresh <= 0 {
+		opts.Refresh = opts.TTL / 2
+	}
+	if opts.Replication <= 0 {
+		opts.Replication = DefaultReplication
+	}
+	return &Service{
+		base:      base,
+		signer:    signer,
+		networkID: networkID,
+		streamer:  streamer,
+		topology:  topology,
+		files:     files,
+		store:     store,
+		logger:    logger,
+		opts:      opts,
+		records:   make(map[string]map[string]heldRecord),
+		senders:   make(map[string]int),
+		announced: make(map[string]struct{}),
+		quit:      make(chan struct{}),
+	}
+}
+
+func (s *Service) Protocol() p2p.ProtocolSpec {
+	return p2p.ProtocolSpec{
+		Name:    protocolName,
+		Version: protocolVersion,
+		StreamSpecs: []p2p.StreamSpec{
+			{
+				Name:    provideStreamName,
+				Handler: s.provideHandler,
+			},
+			{
+				Name:    findStreamName,
+				Handler: s.findHandler,
+			},
+		},
+	}
+}
+
+// Start publishes the records of the files provided and stored, again before
+// they expire, and of the files stored since as they are. It drops the
+// expired records held.
+func (s *Service) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-s.quit
+			cancel()
+		}()
+
+		ticker := time.NewTicker(s.opts.Refresh)
+		defer ticker.Stop()
+		scan := time.NewTicker(scanInterval)
+		defer scan.Stop()
+		for {
+			s.refresh(ctx)
+		scanning:
+			for {
+				select {
+				case <-s.quit:
+					return
+				case <-ticker.C:
+					break scanning
+				case <-scan.C:
+					s.scan(ctx)
+				}
+			}
+		}
+	}()
+}
+
+func (s *Service) Close() error {
+	close(s.quit)
+	s.wg.Wait()
+	return nil
+}
+
+func (s *Service) refresh(ctx context.Context) {
+	s.prune()
+	cids, err := s.provided()
+	if err != nil {
+		s.logger.Errorf("provider: provided files: %v", err)
+		return
+	}
+	live := cids[:0]
+	for _, rootCid := range cids {
+		if s.files != nil && !s.files.HasFile(rootCid) {
+			s.logger.Debugf("provider: file %s gone, no longer provided", rootCid)
+			if err := s.Unprovide(rootCid); err != nil {
+				s.logger.Errorf("provider: unprovide %s: %v", rootCid, err)
+			}
+			continue
+		}
+		live = append(live, rootCid)
+	}
+	live = append(live, s.storedBesides(live)...)
+
+	s.announcedMu.Lock()
+	s.announced = make(map[string]struct{}, len(live))
+	for _, rootCid := range live {
+		s.announced[rootCid.ByteString()] = struct{}{}
+	}
+	s.announcedMu.Unlock()
+	s.publish(ctx, live...)
+}
+
+// scan publishes the records of the files stored since the last refresh.
+func (s *Service) scan(ctx context.Context) {
+	s.announcedMu.Lock()
+	var announced []boson.Address
+	for k := range s.announced {
+		announced = append(announced, boson.NewAddress([]byte(k)))
+	}
+	s.announcedMu.Unlock()
+
+	cids := s.storedBesides(announced)
+	if len(cids) == 0 {
+		return
+	}
+	s.announcedMu.Lock()
+	for _, rootCid := range cids {
+		s.announced[rootCid.ByteString()] = struct{}{}
+	}
+	s.announcedMu.Unlock()
+	s.publish(ctx, cids...)
+}
+
+// storedBesides returns the files the node stores besides the ones given, as
+// the files downloaded.
+func (s *Service) storedBesides(cids []boson.Address) []boson.Address {
+	if s.files == nil {
+		return nil
+	}
+	known := make(map[string]struct{}, len(cids))
+	for _, rootCid := range cids {
+		known[rootCid.ByteString()] = struct{}{}
+	}
+	files, _ := s.files.GetListFile(filestore.Page{}, nil, filestore.Sort{Key: "rootCid", Order: filestore.ASC})
+	var stored []boson.Address
+	for _, f := range files {
+		if _, ok := known[f.RootCid.ByteString()]; !ok {
+			stored = append(stored, f.RootCid)
+		}
+	}
+	return stored
+}
+
+func (s *Service) provided() ([]boson.Address, error) {
+	var cids []boson.Address
+	err := s.store.Iterate(providedPrefix, func(key, _ []byte) (bool, error) {
+		if !strings.HasPrefix(string(key), providedPrefix) {
+			return true, nil
+		}
+		rootCid, err := boson.ParseHexAddress(strings.TrimPrefix(string(key), providedPrefix))
+		if err != nil {
+			return true, err
+		}
+		cids = append(cids, rootCid)
+		return false, nil
+	})
+	return cids, err
+}
+
+// Provide announces the node stores the file, publishing the record in the
+// background.
+func (s *Service) Provide(_ context.Context, rootCid boson.Address) error {
+	if err := s.store.Put(providedPrefix+rootCid.String(), time.Now().Unix()); err != nil {
+		return err
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		defer cancel()
+		go func() {
+			select {
+			case <-s.quit:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		s.publish(ctx, rootCid)
+	}()
+	s.announcedMu.Lock()
+	s.announced[rootCid.ByteString()] = struct{}{}
+	s.announcedMu.Unlock()
+	return nil
+}
+
+func (s *Service) Unprovide(rootCid boson.Address) error {
+	err := s.store.Delete(providedPrefix + rootCid.String())
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *Service) isProvided(rootCid boson.Address) bool {
+	var since int64
+	return s.store.Get(providedPrefix+rootCid.String(), &since) == nil
+}
+
+// publish sends the records of the files to the nodes closest to each, one
+// message per node.
+func (s *Service) publish(ctx context.Context, cids ...boson.Address) {
+	expires := time.Now().Add(s.opts.TTL)
+	peers := make(map[string]boson.Address)
+	records := make(map[string][]*pb.Record)
+	for _, rootCid := range cids {
+		record, err := s.sign(rootCid, expires)
+		if err != nil {
+			s.logger.Errorf("provider: sign record of %s: %v", rootCid, err)
+			continue
+		}
+		closest, err := s.topology.ClosestPeers(rootCid, s.opts.Replication, topology.Filter{})
+		if err != nil {
+			s.logger.Debugf("provider: closest peers to %s: %v", rootCid, err)
+			continue
+		}
+		for _, peer := range closest {
+			peers[peer.ByteString()] = peer
+			records[peer.ByteString()] = append(records[peer.ByteString()], record)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for k, peer := range peers {
+		wg.Add(1)
+		go func(peer boson.Address, records []*pb.Record) {
+			defer wg.Done()
+			if err := s.provide(ctx, peer, records); err != nil {
+				s.logger.Debugf("provider: publish %d records to peer %s: %v", len(records), peer, err)
+			}
+		}(peer, records[k])
+	}
+	wg.Wait()
+}
+
+func (s *Service) provide(ctx context.Context, peer boson.Address, records []*pb.Record) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	stream, err := s.streamer.NewStream(ctx, peer, nil, protocolName, protocolVersion, provideStreamName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = stream.Reset()
+		} else {
+			go stream.FullClose()
+		}
+	}()
+	w := protobuf.NewWriter(stream)
+	return w.WriteMsgWithContext(ctx, &pb.Provide{Records: records})
+}
+
+func (s *Service) provideHandler(ctx context.Context, p p2p.Peer, stream p2p.Stream) (err error) {
+	r := protobuf.NewReader(stream)
+	defer func() {
+		if err != nil {
+			_ = stream.Reset()
+		} else {
+			go stream.FullClose()
+		}
+	}()
+	var req pb.Provide
+	if err := r.ReadMsgWithContext(ctx, &req); err != nil {
+		return fmt.Errorf("read provider records from peer %v: %w", p.Address, err)
+	}
+	closest := make(map[string]bool)
+	for _, rec := range req.Records {
+		rootCid := boson.NewAddress(rec.RootCid)
+		near, ok := closest[rootCid.ByteString()]
+		if !ok {
+			near = s.isClosest(rootCid, p.Address)
+			closest[rootCid.ByteString()] = near
+		}
+		if !near {
+			s.logger.Debugf("provider: record of %s from peer %s: not closest to the cid", rootCid, p.Address)
+			continue
+		}
+		record, err := s.verify(rec)
+		if err != nil {
+			s.logger.Debugf("provider: record from peer %s: %v", p.Address, err)
+			continue
+		}
+		s.add(record, p.Address)
+	}
+	return nil
+}
+
+// isClosest tells if the node is one of the nodes closest to the root cid,
+// which the records of the file are published to. The sending peer is left
+// out, as it publishes to the closest nodes but itself.
+func (s *Service) isClosest(rootCid, sender boson.Address) bool {
+	if len(rootCid.Bytes()) != boson.HashSize {
+		return false
+	}
+	peers, err := s.topology.ClosestPeers(rootCid, s.opts.Replication, topology.Filter{}, sender)
+	if err != nil && !errors.Is(err, topology.ErrNotFound) {
+		s.logger.Debugf("provider: closest peers to %s: %v", rootCid, err)
+		return false
+	}
+	var closer int
+	for _, peer := range peers {
+		if c, err := boson.DistanceCmp(rootCid.Bytes(), peer.Bytes(), s.base.Bytes()); err == nil && c > 0 {
+			closer++
+		}
+	}
+	return closer < s.opts.Replication
+}
+
+func (s *Service) findHandler(ctx context.Context, p p2p.Peer, stream p2p.Stream) (err error) {
+	w, r := protobuf.NewWriterAndReader(stream)
+	defer func() {
+		if err != nil {
+			_ = stream.Reset()
+		} else {
+			go stream.FullClose()
+		}
+	}()
+	var req pb.Find
+	if err := r.ReadMsgWithContext(ctx, &req); err != nil {
+		return fmt.Errorf("read find providers from peer %v: %w", p.Address, err)
+	}
+	rootCid := boson.NewAddress(req.RootCid)
+
+	var resp pb.Providers
+	for _, record := range s.held(rootCid) {
+		resp.Records = append(resp.Records, toProto(record))
+	}
+	// the node answers for the files it stores too
+	if s.isProvided(rootCid) {
+		if rec, err := s.sign(rootCid, time.Now().Add(s.opts.TTL)); err == nil {
+			resp.Records = append(resp.Records, rec)
+		}
+	}
+	if err := w.WriteMsgWithContext(ctx, &resp); err != nil {
+		return fmt.Errorf("write providers to peer %v: %w", p.Address, err)
+	}
+	return nil
+}
+
+// FindProviders returns the nodes the valid records held and those of the
+// nodes closest to the root cid tell store the file, the node itself left
+// out.
+func (s *Service) FindProviders(ctx context.Context, rootCid boson.Address) []boson.Address {
+	seen := map[string]struct{}{s.base.ByteString(): {}}
+	overlays := make([]boson.Address, 0)
+	add := func(records []Record) {
+		for _, record := range records {
+			if _, ok := seen[record.Overlay.ByteString()]; ok {
+				continue
+			}
+			seen[record.Overlay.ByteString()] = struct{}{}
+			overlays = append(overlays, record.Overlay)
+		}
+	}
+	add(s.held(rootCid))
+
+	peers, err := s.topology.ClosestPeers(rootCid, s.opts.Replication, topology.Filter{})
+	if err != nil {
+		s.logger.Debugf("provider: closest peers to %s: %v", rootCid, err)
+		return overlays
+	}
+	results := make([][]Record, len(peers))
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(i int, peer boson.Address) {
+			defer wg.Done()
+			records, err := s.find(ctx, peer, rootCid)
+			if err != nil {
+				s.logger.Debugf("provider: find providers of %s from peer %s: %v", rootCid, peer, err)
+				return
+			}
+			results[i] = records
+		}(i, peer)
+	}
+	wg.Wait()
+	for _, records := range results {
+		add(records)
+	}
+	return overlays
+}
+
+func (s *Service) find(ctx context.Context, peer, rootCid boson.Address) (records []Record, err error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	stream, err := s.streamer.NewStream(ctx, peer, nil, protocolName, protocolVersion, findStreamName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = stream.Reset()
+		} else {
+			go stream.FullClose()
+		}
+	}()
+	w, r := protobuf.NewWriterAndReader(stream)
+	if err := w.WriteMsgWithContext(ctx, &pb.Find{RootCid: rootCid.Bytes()}); err != nil {
+		return nil, err
+	}
+	var resp pb.Providers
+	if err := r.ReadMsgWithContext(ctx, &resp); err != nil {
+		return nil, err
+	}
+	for _, rec := range resp.Records {
+		record, err := s.verify(rec)
+		if err != nil || !record.RootCid.Equal(rootCid) {
+			s.logger.Debugf("provider: record from peer %s: %v", peer, err)
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// add keeps the record the peer sent for the other nodes, in place of the
+// one of the overlay expiring sooner. The expired records are dropped before
+// refusing it, if the peer or the node holds too many.
+func (s *Service) add(record Record, sender boson.Address) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	from := sender.ByteString()
+	key := record.RootCid.ByteString()
+	overlay := record.Overlay.ByteString()
+	if old, ok := s.records[key][overlay]; ok {
+		if !old.Expires.Before(record.Expires) {
+			return
+		}
+		// a peer sending the records again is not held to its quota
+		if old.from != from && !s.admits(from) {
+			return
+		}
+		// the record replaced, unless dropped as expired meanwhile
+		if old, ok := s.records[key][overlay]; ok {
+			s.drop(s.records[key], old)
+		}
+	} else if !s.admits(from) {
+		return
+	}
+
+	providers, ok := s.records[key]
+	if !ok {
+		providers = make(map[string]heldRecord)
+		s.records[key] = providers
+	}
+	if len(providers) >= maxRecordsPerCid {
+		var soonest heldRecord
+		for _, r := range providers {
+			if soonest.Expires.IsZero() || r.Expires.Before(soonest.Expires) {
+				soonest = r
+			}
+		}
+		if !soonest.Expires.Before(record.Expires) {
+			return
+		}
+		s.drop(providers, soonest)
+	}
+	if s.count >= maxRecords {
+		return
+	}
+	providers[overlay] = heldRecord{Record: record, from: from}
+	s.count++
+	s.senders[from]++
+	if s.soonest.IsZero() || record.Expires.Before(s.soonest) {
+		s.soonest = record.Expires
+	}
+}
+
+// admits tells if one more record of the peer can be held, dropping the
+// expired records if it holds too many.
+func (s *Service) admits(from string) bool {
+	if s.count < maxRecords && s.senders[from] < maxRecordsPerPeer {
+		return true
+	}
+	s.dropExpired()
+	return s.count < maxRecords && s.senders[from] < maxRecordsPerPeer
+}
+
+func (s *Service) drop(providers map[string]heldRecord, record heldRecord) {
+	delete(providers, record.Overlay.ByteString())
+	s.count--
+	if s.senders[record.from]--; s.senders[record.from] <= 0 {
+		delete(s.senders, record.from)
+	}
+}
+
+// held returns the records held of the file which have not expired.
+func (s *Service) held(rootCid boson.Address) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	records := make([]Record, 0, len(s.records[rootCid.ByteString()]))
+	for _, record := range s.records[rootCid.ByteString()] {
+		if record.Expires.After(now) {
+			records = append(records, record.Record)
+		}
+	}
+	return records
+}
+
+func (s *Service) prune() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropExpired()
+}
+
+// dropExpired drops the expired records held, unless none has expired yet.
+func (s *Service) dropExpired() {
+	now := time.Now()
+	if now.Before(s.soonest) {
+		return
+	}
+	s.soonest = time.Time{}
+	for key, providers := range s.records {
+		for _, record := range providers {
+			if !record.Expires.After(now) {
+				s.drop(providers, record)
+			} else if s.soonest.IsZero() || record.Expires.Before(s.soonest) {
+				s.soonest = record.Expires
+			}
+		}
+		if len(providers) == 0 {
+			delete(s.records, key)
+		}
+	}
+}
+
+func (s *Service) sign(rootCid boson.Address, expires time.Time) (*pb.Record, error) {
+	signature, err := s.signer.Sign(signData(rootCid.Bytes(), s.base.Bytes(), expires.Unix(), s.networkID))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Record{
+		RootCid:   rootCid.Bytes(),
+		Overlay:   s.base.Bytes(),
+		Expires:   expires.Unix(),
+		Signature: signature,
+	}, nil
+}
+
+// verify checks the record is signed by the overlay and has not expired.
+func (s *Service) verify(rec *pb.Record) (Record, error) {
+	if len(rec.RootCid) != boson.HashSize || len(rec.Overlay) != boson.HashSize {
+		return Record{}, ErrInvalidRecord
+	}
+	expires := time.Unix(rec.Expires, 0)
+	now := time.Now()
+	if !expires.After(now) {
+		return Record{}, fmt.Errorf("expired: %w", ErrInvalidRecord)
+	}
+	if expires.After(now.Add(maxTTL)) {
+		return Record{}, fmt.Errorf("expires too late: %w", ErrInvalidRecord)
+	}
+	pubKey, err := crypto.Recover(rec.Signature, signData(rec.RootCid, rec.Overlay, rec.Expires, s.networkID))
+	if err != nil {
+		return Record{}, fmt.Errorf("signature: %w", ErrInvalidRecord)
+	}
+	overlay, err := crypto.NewOverlayAddress(*pubKey, s.networkID)
+	if err != nil || !bytes.Equal(overlay.Bytes(), rec.Overlay) {
+		return Record{}, fmt.Errorf("not signed by the overlay: %w", ErrInvalidRecord)
+	}
+	return Record{
+		RootCid:   boson.NewAddress(rec.RootCid),
+		Overlay:   overlay,
+		Expires:   expires,
+		Signature: rec.Signature,
+	}, nil
+}
+
+func toProto(record Record) *pb.Record {
+	return &pb.Record{
+		RootCid:   record.RootCid.Bytes(),
+		Overlay:   record.Overlay.Bytes(),
+		Expires:   record.Expires.Unix(),
+		Signature: record.Signature,
+	}
+}
+
+func signData(rootCid, overlay []byte, expires int64, networkID uint64) []byte {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b, uint64(expires))
+	binary.BigEndian.PutUint64(b[8:], networkID)
+	data := append([]byte("favorx-provider-"), rootCid...)
+	data = append(data, overlay...)
+	return append(data, b...)
+}
@@ -0,0 +1,288 @@
+package provider_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/boson/test"
+	"github.com/FavorLabs/favorX/pkg/crypto"
+	"github.com/FavorLabs/favorX/pkg/localstore/filestore"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/p2p/streamtest"
+	"github.com/FavorLabs/favorX/pkg/provider"
+	statestore "github.com/FavorLabs/favorX/pkg/statestore/mock"
+	"github.com/FavorLabs/favorX/pkg/topology"
+)
+
+const networkID = 7
+
+var logger = logging.New(io.Discard, 0)
+
+type closestMock []boson.Address
+
+func (m closestMock) ClosestPeer(boson.Address, bool, topology.Filter, ...boson.Address) (boson.Address, error) {
+	if len(m) == 0 {
+		return boson.ZeroAddress, topology.ErrNotFound
+	}
+	return m[0], nil
+}
+
+func (m closestMock) ClosestPeers(boson.Address, int, topology.Filter, ...boson.Address) ([]boson.Address, error) {
+	return m, nil
+}
+
+type filesMock []boson.Address
+
+func (m filesMock) HasFile(rootCid boson.Address) bool {
+	for _, f := range m {
+		if f.Equal(rootCid) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m filesMock) GetListFile(filestore.Page, []filestore.Filter, filestore.Sort) ([]filestore.FileView, int) {
+	files := make([]filestore.FileView, 0, len(m))
+	for _, f := range m {
+		files = append(files, filestore.FileView{RootCid: f})
+	}
+	return files, len(files)
+}
+
+func newSigner(t *testing.T) (crypto.Signer, boson.Address) {
+	t.Helper()
+	key, err := crypto.GenerateSecp256k1Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	overlay, err := crypto.NewOverlayAddress(key.PublicKey, networkID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return crypto.NewDefaultSigner(key), overlay
+}
+
+// newService returns the service of a node the holder node is the closest
+// to all cids for.
+func newService(t *testing.T, signer crypto.Signer, base boson.Address, holder *provider.Service, holderAddr boson.Address) *provider.Service {
+	t.Helper()
+	recorder := streamtest.New(
+		streamtest.WithProtocols(holder.Protocol()),
+		streamtest.WithBaseAddr(base),
+	)
+	return provider.New(base, signer, networkID, recorder, closestMock{holderAddr}, nil, statestore.NewStateStore(), logger, provider.Options{})
+}
+
+func findProviders(t *testing.T, s *provider.Service, rootCid boson.Address, want int) []boson.Address {
+	t.Helper()
+	var got []boson.Address
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if got = s.FindProviders(context.Background(), rootCid); len(got) >= want {
+			break
+		}
+	}
+	return got
+}
+
+func TestProvide(t *testing.T) {
+	holderSigner, holderAddr := newSigner(t)
+	holder := provider.New(holderAddr, holderSigner, networkID, nil, closestMock{}, nil, statestore.NewStateStore(), logger, provider.Options{})
+
+	signer, overlay := newSigner(t)
+	publisher := newService(t, signer, overlay, holder, holderAddr)
+	finderSigner, finderAddr := newSigner(t)
+	finder := newService(t, finderSigner, finderAddr, holder, holderAddr)
+
+	rootCid := test.RandomAddress()
+	if err := publisher.Provide(context.Background(), rootCid); err != nil {
+		t.Fatal(err)
+	}
+	got := findProviders(t, finder, rootCid, 1)
+	if len(got) != 1 || !got[0].Equal(overlay) {
+		t.Fatalf("got providers %v, want %s", got, overlay)
+	}
+	// the holder answers from the records it holds too
+	if got := holder.FindProviders(context.Background(), rootCid); len(got) != 1 || !got[0].Equal(overlay) {
+		t.Fatalf("got providers %v held, want %s", got, overlay)
+	}
+	if got := finder.FindProviders(context.Background(), test.RandomAddress()); len(got) != 0 {
+		t.Fatalf("got providers %v of a file not provided", got)
+	}
+
+	// the closest node answers for the files it stores
+	if err := holder.Provide(context.Background(), rootCid); err != nil {
+		t.Fatal(err)
+	}
+	if got := findProviders(t, finder, rootCid, 2); len(got) != 2 {
+		t.Fatalf("got providers %v, want the publisher and the holder", got)
+	}
+
+	if err := publisher.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := holder.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestForgedRecord(t *testing.T) {
+	holderSigner, holderAddr := newSigner(t)
+	holder := provider.New(holderAddr, holderSigner, networkID, nil, closestMock{}, nil, statestore.NewStateStore(), logger, provider.Options{})
+
+	// the records are signed by another key than the one of the overlay
+	signer, _ := newSigner(t)
+	forger := newService(t, signer, test.RandomAddress(), holder, holderAddr)
+	rootCid := test.RandomAddress()
+	if err := forger.Provide(context.Background(), rootCid); err != nil {
+		t.Fatal(err)
+	}
+	if err := forger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := holder.FindProviders(context.Background(), rootCid); len(got) != 0 {
+		t.Fatalf("got providers %v from forged records", got)
+	}
+}
+
+func TestProvideStored(t *testing.T) {
+	holderSigner, holderAddr := newSigner(t)
+	holder := provider.New(holderAddr, holderSigner, networkID, nil, closestMock{}, nil, statestore.NewStateStore(), logger, provider.Options{})
+
+	// the file is stored, as when downloaded, without being provided
+	rootCid := test.RandomAddress()
+	signer, overlay := newSigner(t)
+	recorder := streamtest.New(
+		streamtest.WithProtocols(holder.Protocol()),
+		streamtest.WithBaseAddr(overlay),
+	)
+	publisher := provider.New(overlay, signer, networkID, recorder, closestMock{holderAddr}, filesMock{rootCid}, statestore.NewStateStore(), logger, provider.Options{})
+	publisher.Start()
+	defer publisher.Close()
+
+	finderSigner, finderAddr := newSigner(t)
+	finder := newService(t, finderSigner, finderAddr, holder, holderAddr)
+	if got := findProviders(t, finder, rootCid, 1); len(got) != 1 || !got[0].Equal(overlay) {
+		t.Fatalf("got providers %v of the stored file, want %s", got, overlay)
+	}
+}
+
+func TestRecordNotClosest(t *testing.T) {
+	holderSigner, holderAddr := newSigner(t)
+	// the holder knows of nodes closer to the cid than itself
+	rootCid := test.RandomAddress()
+	var closer closestMock
+	for i := 1; i <= provider.DefaultReplication; i++ {
+		peer := append([]byte(nil), rootCid.Bytes()...)
+		peer[boson.HashSize-1] ^= byte(i)
+		closer = append(closer, boson.NewAddress(peer))
+	}
+	holder := provider.New(holderAddr, holderSigner, networkID, nil, closer, nil, statestore.NewStateStore(), logger, provider.Options{})
+
+	signer, overlay := newSigner(t)
+	publisher := newService(t, signer, overlay, holder, holderAddr)
+	for _, cid := range []boson.Address{rootCid, holderAddr} {
+		if err := publisher.Provide(context.Background(), cid); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := publisher.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	finderSigner, finderAddr := newSigner(t)
+	finder := newService(t, finderSigner, finderAddr, holder, holderAddr)
+	if got := finder.FindProviders(context.Background(), rootCid); len(got) != 0 {
+		t.Fatalf("got providers %v of a cid the holder is not closest to", got)
+	}
+	if got := finder.FindProviders(context.Background(), holderAddr); len(got) != 1 || !got[0].Equal(overlay) {
+		t.Fatalf("got providers %v of a cid the holder is closest to, want %s", got, overlay)
+	}
+}
+
+func TestRecordsPerPeer(t *testing.T) {
+	defer func(max int) {
+		*provider.MaxRecordsPerPeer = max
+	}(*provider.MaxRecordsPerPeer)
+	*provider.MaxRecordsPerPeer = 2
+
+	holderSigner, holderAddr := newSigner(t)
+	holder := provider.New(holderAddr, holderSigner, networkID, nil, closestMock{}, nil, statestore.NewStateStore(), logger, provider.Options{})
+
+	signer, overlay := newSigner(t)
+	publisher := newService(t, signer, overlay, holder, holderAddr)
+	cids := []boson.Address{test.RandomAddress(), test.RandomAddress(), test.RandomAddress()}
+	for _, rootCid := range cids {
+		if err := publisher.Provide(context.Background(), rootCid); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := publisher.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// another peer is held to a quota of its own
+	otherSigner, other := newSigner(t)
+	otherPublisher := newService(t, otherSigner, other, holder, holderAddr)
+	if err := otherPublisher.Provide(context.Background(), cids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := otherPublisher.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	finderSigner, finderAddr := newSigner(t)
+	finder := newService(t, finderSigner, finderAddr, holder, holderAddr)
+	var held int
+	for _, rootCid := range cids {
+		for _, p := range finder.FindProviders(context.Background(), rootCid) {
+			if p.Equal(overlay) {
+				held++
+			}
+		}
+	}
+	if held != 2 {
+		t.Fatalf("got %d records of the peer held, want 2", held)
+	}
+	if got := finder.FindProviders(context.Background(), cids[0]); len(got) != 2 {
+		t.Fatalf("got providers %v, want the record of the other peer held too", got)
+	}
+}
+
+func TestRecordsExpiredDropped(t *testing.T) {
+	defer func(max int) {
+		*provider.MaxRecordsPerPeer = max
+	}(*provider.MaxRecordsPerPeer)
+	*provider.MaxRecordsPerPeer = 1
+
+	holderSigner, holderAddr := newSigner(t)
+	holder := provider.New(holderAddr, holderSigner, networkID, nil, closestMock{}, nil, statestore.NewStateStore(), logger, provider.Options{})
+
+	signer, overlay := newSigner(t)
+	recorder := streamtest.New(
+		streamtest.WithProtocols(holder.Protocol()),
+		streamtest.WithBaseAddr(overlay),
+	)
+	publisher := provider.New(overlay, signer, networkID, recorder, closestMock{holderAddr}, nil, statestore.NewStateStore(), logger, provider.Options{TTL: 2 * time.Second})
+	expiring := test.RandomAddress()
+	if err := publisher.Provide(context.Background(), expiring); err != nil {
+		t.Fatal(err)
+	}
+	// the record held expires, making room for the next one of the peer
+	time.Sleep(2 * time.Second)
+	rootCid := test.RandomAddress()
+	if err := publisher.Provide(context.Background(), rootCid); err != nil {
+		t.Fatal(err)
+	}
+	if err := publisher.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	finderSigner, finderAddr := newSigner(t)
+	finder := newService(t, finderSigner, finderAddr, holder, holderAddr)
+	if got := finder.FindProviders(context.Background(), rootCid); len(got) != 1 || !got[0].Equal(overlay) {
+		t.Fatalf("got providers %v, want %s", got, overlay)
+	}
+}
@@ -3,6 +3,8 @@ package aco
 import (
 	"fmt"
 	"github.com/FavorLabs/favorX/pkg/retrieval/weight"
+	"math"
+	"math/big"
 	"math/rand"
 	"sync"
 	"time"
@@ -42,9 +44,18 @@ type routeMetric struct {
 	downloadDetail *DownloadDetail
 }
 
+// CostFunc returns the cost of retrieving a chunk over the link node.
+type CostFunc func(link boson.Address) *big.Int
+
+// TrustFunc returns how far the link node is trusted, from 0 to 1.
+type TrustFunc func(link boson.Address) float64
+
 type AcoServer struct {
 	routeMetric   map[string]*routeMetric
 	toZeroElapsed int64
+	cost          CostFunc
+	costWeight    float64
+	trust         TrustFunc
 	mutex         sync.Mutex
 }
 
@@ -57,6 +68,24 @@ func NewAcoServer() *AcoServer {
 	return aco
 }
 
+// SetCost weighs the cost of the routes against their speed. The score of a
+// route is scaled by the ratio of the mean cost of the routes to its cost,
+// raised to the weight, so the weight 0 ignores the costs.
+func (s *AcoServer) SetCost(cost CostFunc, weight float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cost = cost
+	s.costWeight = weight
+}
+
+// SetTrust scales the score of the routes by the trust in their link nodes,
+// so the routes over the nodes distrusted are selected less often.
+func (s *AcoServer) SetTrust(trust TrustFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.trust = trust
+}
+
 func (s *AcoServer) OnDownloadStart(route Route) {
 	routeKey := route.ToString()
 
@@ -195,9 +224,31 @@ func (s *AcoServer) getSelectRouteListScore(routeList []Route) []int64 {
 		routeIndex := k
 		routeScoreList[routeIndex] = curRouteScore
 	}
+	if s.cost != nil && s.costWeight > 0 {
+		s.weighCost(routeList, routeScoreList)
+	}
+	if s.trust != nil {
+		for i, route := range routeList {
+			trust := math.Min(math.Max(s.trust(route.LinkNode), 0), 1)
+			routeScoreList[i] = int64(float64(routeScoreList[i]) * trust)
+		}
+	}
 	return routeScoreList
 }
 
+func (s *AcoServer) weighCost(routeList []Route, routeScoreList []int64) {
+	costs := make([]float64, len(routeList))
+	var mean float64
+	for i, route := range routeList {
+		cost, _ := new(big.Float).SetInt(s.cost(route.LinkNode)).Float64()
+		costs[i] = math.Max(cost, 1)
+		mean += costs[i] / float64(len(routeList))
+	}
+	for i := range routeScoreList {
+		routeScoreList[i] = int64(float64(routeScoreList[i]) * math.Pow(mean/costs[i], s.costWeight))
+	}
+}
+
 func (s *AcoServer) getCurRouteScore(route Route) int64 {
 	routeKey := route.ToString()
 
@@ -3,12 +3,14 @@ package aco
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"math/rand"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/boson/test"
 	// "github.com/FavorLabs/favorX/pkg/retrieval/aco"
 )
 
@@ -684,3 +686,57 @@ func (c *mockClient) getFilledChunkCount() int {
 
 	return filledCount
 }
+
+func TestRouteCost(t *testing.T) {
+	acoServer := NewAcoServer()
+	cheap := NewRoute(test.RandomAddress(), test.RandomAddress())
+	dear := NewRoute(test.RandomAddress(), test.RandomAddress())
+	routes := []Route{cheap, dear}
+
+	scores := acoServer.getSelectRouteListScore(routes)
+	if scores[0] != defaultRate || scores[1] != defaultRate {
+		t.Fatalf("got scores %v, want both %d", scores, defaultRate)
+	}
+
+	acoServer.SetCost(func(link boson.Address) *big.Int {
+		if link.Equal(cheap.LinkNode) {
+			return big.NewInt(1)
+		}
+		return big.NewInt(4)
+	}, 1)
+	scores = acoServer.getSelectRouteListScore(routes)
+	if scores[0] != defaultRate*5/2 || scores[1] != defaultRate*5/8 {
+		t.Fatalf("got scores %v, want %d and %d", scores, defaultRate*5/2, defaultRate*5/8)
+	}
+
+	// the costs are ignored with the weight 0
+	acoServer.SetCost(nil, 0)
+	scores = acoServer.getSelectRouteListScore(routes)
+	if scores[0] != defaultRate || scores[1] != defaultRate {
+		t.Fatalf("got scores %v, want both %d", scores, defaultRate)
+	}
+}
+
+func TestRouteTrust(t *testing.T) {
+	acoServer := NewAcoServer()
+	trusted := NewRoute(test.RandomAddress(), test.RandomAddress())
+	distrusted := NewRoute(test.RandomAddress(), test.RandomAddress())
+	routes := []Route{trusted, distrusted}
+
+	acoServer.SetTrust(func(link boson.Address) float64 {
+		if link.Equal(distrusted.LinkNode) {
+			return 0.25
+		}
+		return 1
+	})
+	scores := acoServer.getSelectRouteListScore(routes)
+	if scores[0] != defaultRate || scores[1] != defaultRate/4 {
+		t.Fatalf("got scores %v, want %d and %d", scores, defaultRate, defaultRate/4)
+	}
+
+	acoServer.SetTrust(nil)
+	scores = acoServer.getSelectRouteListScore(routes)
+	if scores[0] != defaultRate || scores[1] != defaultRate {
+		t.Fatalf("got scores %v, want both %d", scores, defaultRate)
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/FavorLabs/favorX/pkg/accounting"
 	"github.com/FavorLabs/favorX/pkg/boson"
 	"github.com/FavorLabs/favorX/pkg/cac"
+	"github.com/FavorLabs/favorX/pkg/challenge"
 	"github.com/FavorLabs/favorX/pkg/chunkinfo"
 	"github.com/FavorLabs/favorX/pkg/logging"
 	"github.com/FavorLabs/favorX/pkg/p2p"
@@ -91,6 +93,32 @@ func (s *Service) Config(chunkInfo chunkinfo.Interface) {
 	s.chunkinfo = chunkInfo
 }
 
+// SetPricing weighs the prices the link nodes charge against the speed of
+// the routes when selecting them, ignoring the prices if the weight is 0.
+func (s *Service) SetPricing(pricer accounting.Pricer, weight float64) {
+	s.acoServer.SetCost(func(link boson.Address) *big.Int {
+		return pricer.PeerPrice(link).ChunkCost(1)
+	}, weight)
+}
+
+// Reputer looks up the reputation the peers earned answering the storage
+// challenges.
+type Reputer interface {
+	Reputation(peer boson.Address) (challenge.Reputation, error)
+}
+
+// SetReputation scales the score of the routes by the trust in their link
+// nodes, so the routes over the nodes failing the challenges are avoided.
+func (s *Service) SetReputation(reputer Reputer) {
+	s.acoServer.SetTrust(func(link boson.Address) float64 {
+		rep, err := reputer.Reputation(link)
+		if err != nil {
+			return 1
+		}
+		return rep.Trust()
+	})
+}
+
 func (s *Service) Protocol() p2p.ProtocolSpec {
 	return p2p.ProtocolSpec{
 		Name:    protocolName,
@@ -274,7 +302,7 @@ func (s *Service) retrieveChunk(ctx context.Context, route aco.Route, rootAddr,
 		return nil, fmt.Errorf("connect failed, peer: %v", route.LinkNode.String())
 	}
 
-	if err := s.accounting.Reserve(route.LinkNode, 256); err != nil {
+	if err := s.accounting.Reserve(route.LinkNode, 1); err != nil {
 		return nil, err
 	}
 
@@ -323,7 +351,7 @@ func (s *Service) retrieveChunk(ctx context.Context, route aco.Route, rootAddr,
 			return nil, boson.ErrInvalidChunk
 		}
 	}
-	if err := s.accounting.Credit(context.Background(), route.LinkNode, 256); err != nil {
+	if err := s.accounting.Credit(context.Background(), route.LinkNode, 1); err != nil {
 		return nil, err
 	}
 
@@ -390,7 +418,7 @@ func (s *Service) handler(ctx context.Context, p p2p.Peer, stream p2p.Stream) (e
 	}); err != nil {
 		return fmt.Errorf("write delivery: %w peer %s", err, p.Address.String())
 	}
-	if err := s.accounting.Debit(p.Address, 256); err != nil {
+	if err := s.accounting.Debit(p.Address, 1); err != nil {
 		return err
 	}
 	if s.chunkinfo != nil && p.Mode.IsFull() {
@@ -0,0 +1,213 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Authorizer checks the tokens clients present. The authenticator of the
+// auth package satisfies it.
+type Authorizer interface {
+	// Enforce reports whether the token grants the action on the object.
+	Enforce(token, obj, act string) (bool, error)
+	// Expiry returns the time the token expires at, or the zero time for
+	// tokens that never expire.
+	Expiry(token string) (time.Time, error)
+}
+
+const (
+	// authenticateMethod authenticates a connection that could not present
+	// a token in the handshake, like an IPC connection, or replaces the
+	// token of a connection before it expires.
+	authenticateMethod = MetadataApi + serviceMethodSeparator + "authenticate"
+
+	// ActionCall and ActionSubscribe are the actions authorized for method
+	// calls and subscriptions.
+	ActionCall      = "CALL"
+	ActionSubscribe = "SUBSCRIBE"
+)
+
+var (
+	errMissingToken = &authError{"missing security token"}
+	errTokenExpired = &authError{"token expired"}
+	errForbidden    = &authError{"provided security token does not grant access to the method"}
+)
+
+type authTokenContextKey struct{}
+
+// SetAuthorizer makes the server require a token on every connection and
+// check every method call and subscription with the authorizer. It must be
+// called before the server serves any connection.
+func (s *Server) SetAuthorizer(a Authorizer) {
+	s.auth = a
+}
+
+// checkToken validates the token presented in a handshake.
+func (s *Server) checkToken(token string) error {
+	if token == "" {
+		return errMissingToken
+	}
+	expiry, err := s.auth.Expiry(token)
+	if err != nil {
+		return &authError{err.Error()}
+	}
+	if !expiry.IsZero() && !time.Now().Before(expiry) {
+		return errTokenExpired
+	}
+	return nil
+}
+
+// ResourcePath returns the object a method or a subscription of the
+// namespace is authorized as, like /rpc/group/message.
+func ResourcePath(namespace, method string) string {
+	return "/rpc/" + namespace + "/" + method
+}
+
+// requestToken returns the bearer token of the request. Browsers cannot set
+// headers on websocket handshakes, so the token query parameter is accepted
+// too.
+func requestToken(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(h, "Bearer "))
+	}
+	return r.URL.Query().Get("token")
+}
+
+// tokenCodec is a server codec of a connection that presented a token in
+// its handshake.
+type tokenCodec struct {
+	ServerCodec
+	token string
+}
+
+func codecToken(codec ServerCodec) string {
+	if tc, ok := codec.(*tokenCodec); ok {
+		return tc.token
+	}
+	return ""
+}
+
+func tokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(authTokenContextKey{}).(string)
+	return token
+}
+
+// connAuth holds the token of a connection. When the token expires the
+// subscriptions of the connection are cancelled.
+type connAuth struct {
+	auth     Authorizer
+	onExpiry func()
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+	timer  *time.Timer
+}
+
+// setToken replaces the token of the connection and schedules its expiry.
+func (ca *connAuth) setToken(token string) error {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+
+	if ca.timer != nil {
+		ca.timer.Stop()
+		ca.timer = nil
+	}
+	ca.token, ca.expiry = token, time.Time{}
+	if token == "" {
+		return errMissingToken
+	}
+	expiry, err := ca.auth.Expiry(token)
+	if err != nil {
+		return &authError{err.Error()}
+	}
+	ca.expiry = expiry
+	if expiry.IsZero() {
+		return nil
+	}
+	d := time.Until(expiry)
+	if d <= 0 {
+		return errTokenExpired
+	}
+	ca.timer = time.AfterFunc(d, func() {
+		ca.mu.Lock()
+		current := ca.token == token && ca.expiry.Equal(expiry)
+		ca.mu.Unlock()
+		if current {
+			ca.onExpiry()
+		}
+	})
+	return nil
+}
+
+// authorize checks that the token of the connection grants the action on
+// the method of the namespace.
+func (ca *connAuth) authorize(namespace, method, act string) error {
+	ca.mu.Lock()
+	token := ca.token
+	ca.mu.Unlock()
+
+	if token == "" {
+		return errMissingToken
+	}
+	allowed, err := ca.auth.Enforce(token, ResourcePath(namespace, method), act)
+	if err != nil {
+		return &authError{err.Error()}
+	}
+	if !allowed {
+		return errForbidden
+	}
+	return nil
+}
+
+// expired reports whether the token of the connection has expired.
+func (ca *connAuth) expired() bool {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+
+	return !ca.expiry.IsZero() && !time.Now().Before(ca.expiry)
+}
+
+func (ca *connAuth) stop() {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+
+	if ca.timer != nil {
+		ca.timer.Stop()
+		ca.timer = nil
+	}
+}
+
+// setAuth makes the handler authorize the calls with the token the
+// connection presented in its handshake.
+func (h *handler) setAuth(a Authorizer, token string) {
+	if a == nil {
+		return
+	}
+	h.auth = &connAuth{auth: a, onExpiry: h.expireToken}
+	// an invalid handshake token is reported by the calls
+	_ = h.auth.setToken(token)
+}
+
+// handleAuthenticate processes rpc_authenticate calls.
+func (h *handler) handleAuthenticate(msg *jsonrpcMessage) *jsonrpcMessage {
+	args, err := parsePositionalArguments(msg.Params, []reflect.Type{stringType})
+	if err != nil {
+		return msg.errorResponse(&invalidParamsError{err.Error()})
+	}
+	if err := h.auth.setToken(args[0].String()); err != nil {
+		return msg.errorResponse(err)
+	}
+	return msg.response(true)
+}
+
+// expireToken cancels the subscriptions of the connection once its token
+// has expired.
+func (h *handler) expireToken() {
+	h.log.Debug("RPC token expired, cancelling subscriptions")
+	h.cancelServerSubscriptions(errTokenExpired)
+}
@@ -0,0 +1,194 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type testAuthorizer struct {
+	expiry map[string]time.Time
+	grants map[string][]string // token -> allowed "act obj"
+}
+
+func (a *testAuthorizer) Enforce(token, obj, act string) (bool, error) {
+	if _, err := a.Expiry(token); err != nil {
+		return false, err
+	}
+	for _, g := range a.grants[token] {
+		if g == act+" "+obj {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (a *testAuthorizer) Expiry(token string) (time.Time, error) {
+	expiry, ok := a.expiry[token]
+	if !ok {
+		return time.Time{}, errors.New("invalid security token")
+	}
+	if !expiry.IsZero() && time.Now().After(expiry) {
+		return time.Time{}, errors.New("token expired")
+	}
+	return expiry, nil
+}
+
+func newAuthTestServer(a Authorizer, service *notificationTestService) *Server {
+	server := NewServer()
+	server.SetAuthorizer(a)
+	server.idgen = sequentialIDGenerator()
+	if err := server.RegisterName("test", new(testService)); err != nil {
+		panic(err)
+	}
+	if err := server.RegisterName("nftest", service); err != nil {
+		panic(err)
+	}
+	return server
+}
+
+func wantAuthError(t *testing.T, err error, msg string) {
+	t.Helper()
+
+	var rpcErr Error
+	if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32001 || rpcErr.Error() != msg {
+		t.Fatalf("got error %v, want %q", err, msg)
+	}
+}
+
+// This test checks that websocket connections present a token in the
+// handshake and that the token is checked for every method.
+func TestAuthWebsocket(t *testing.T) {
+	t.Parallel()
+
+	var (
+		a = &testAuthorizer{
+			expiry: map[string]time.Time{"token": {}},
+			grants: map[string][]string{"token": {ActionCall + " /rpc/test/echo"}},
+		}
+		srv     = newAuthTestServer(a, new(notificationTestService))
+		httpsrv = httptest.NewServer(srv.WebsocketHandler([]string{"*"}))
+		wsURL   = "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	)
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	for _, url := range []string{wsURL, wsURL + "?token=invalid"} {
+		client, err := DialWebsocket(context.Background(), url, "")
+		if err == nil {
+			client.Close()
+			t.Fatalf("%s: no error for missing token", url)
+		}
+		wantErr := wsHandshakeError{websocket.ErrBadHandshake, "401 Unauthorized"}
+		if !errors.Is(err, wantErr) {
+			t.Fatalf("%s: wrong error: %q", url, err)
+		}
+	}
+
+	client, err := DialWebsocket(context.Background(), wsURL+"?token=token", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var result echoResult
+	if err := client.Call(&result, "test_echo", "hello", 10, &echoArgs{"world"}); err != nil {
+		t.Fatal(err)
+	}
+	if result.String != "hello" {
+		t.Fatalf("got result %+v", result)
+	}
+	var n int
+	err = client.Call(&n, "nftest_echo", 1)
+	wantAuthError(t, err, errForbidden.Error())
+}
+
+// This test checks that connections without a handshake authenticate with
+// rpc_authenticate.
+func TestAuthAuthenticateMethod(t *testing.T) {
+	t.Parallel()
+
+	a := &testAuthorizer{
+		expiry: map[string]time.Time{"token": time.Now().Add(time.Hour)},
+		grants: map[string][]string{"token": {ActionCall + " /rpc/nftest/echo"}},
+	}
+	srv := newAuthTestServer(a, new(notificationTestService))
+	defer srv.Stop()
+	p1, p2 := net.Pipe()
+	go srv.ServeCodec(NewCodec(p1))
+	client := initClient(NewCodec(p2), randomIDGenerator(), new(serviceRegistry), nil)
+	defer client.Close()
+
+	var n int
+	err := client.Call(&n, "nftest_echo", 1)
+	wantAuthError(t, err, errMissingToken.Error())
+
+	var ok bool
+	err = client.Call(&ok, authenticateMethod, "invalid")
+	wantAuthError(t, err, "invalid security token")
+
+	if err := client.Call(&ok, authenticateMethod, "token"); err != nil || !ok {
+		t.Fatalf("authenticate: %v %v", ok, err)
+	}
+	if err := client.Call(&n, "nftest_echo", 1); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("got %d, want 1", n)
+	}
+}
+
+// This test checks that the subscriptions of a connection are cancelled when
+// its token expires.
+func TestAuthSubscriptionExpiry(t *testing.T) {
+	t.Parallel()
+
+	var (
+		a = &testAuthorizer{
+			expiry: map[string]time.Time{"token": time.Now().Add(500 * time.Millisecond)},
+			grants: map[string][]string{"token": {ActionSubscribe + " /rpc/nftest/someSubscription"}},
+		}
+		service = &notificationTestService{unsubscribed: make(chan string, 1)}
+		srv     = newAuthTestServer(a, service)
+		httpsrv = httptest.NewServer(srv.WebsocketHandler([]string{"*"}))
+		wsURL   = "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	)
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	client, err := DialWebsocket(context.Background(), wsURL+"?token=token", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ch := make(chan int, 1)
+	sub, err := client.Subscribe(context.Background(), "nftest", ch, "someSubscription", 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+	if v := <-ch; v != 1 {
+		t.Fatalf("got notification %d, want 1", v)
+	}
+	if _, err := client.Subscribe(context.Background(), "nftest", ch, "hangSubscription", 1); err == nil {
+		t.Fatal("no error for subscription not granted")
+	}
+
+	select {
+	case id := <-service.unsubscribed:
+		if id != string(sub.subid) {
+			t.Fatalf("got cancelled subscription %s, want %s", id, sub.subid)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not cancelled after the token expired")
+	}
+	_, err = client.Subscribe(context.Background(), "nftest", ch, "someSubscription", 1, 1)
+	wantAuthError(t, err, "token expired")
+}
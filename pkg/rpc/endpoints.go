@@ -23,14 +23,18 @@ import (
 	"github.com/FavorLabs/favorX/pkg/logging"
 )
 
-// StartIPCEndpoint starts an IPC endpoint.
-func StartIPCEndpoint(ipcEndpoint string, apis []API) (net.Listener, *Server, error) {
+// StartIPCEndpoint starts an IPC endpoint. If auth is not nil, the IPC clients
+// have to authenticate with the rpc_authenticate method.
+func StartIPCEndpoint(ipcEndpoint string, apis []API, auth Authorizer) (net.Listener, *Server, error) {
 	// Register all the APIs exposed by the services.
 	var (
 		handler    = NewServer()
 		regMap     = make(map[string]struct{})
 		registered []string
 	)
+	if auth != nil {
+		handler.SetAuthorizer(auth)
+	}
 	for _, api := range apis {
 		if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
 			logging.Infof("IPC registration failed namespace %s error %s", api.Namespace, err)
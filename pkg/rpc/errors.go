@@ -54,6 +54,7 @@ var (
 	_ Error = new(invalidRequestError)
 	_ Error = new(invalidMessageError)
 	_ Error = new(invalidParamsError)
+	_ Error = new(authError)
 	_ Error = new(CustomError)
 )
 
@@ -103,6 +104,13 @@ func (e *invalidParamsError) ErrorCode() int { return -32602 }
 
 func (e *invalidParamsError) Error() string { return e.message }
 
+// the connection token is missing, invalid or does not grant access
+type authError struct{ message string }
+
+func (e *authError) ErrorCode() int { return -32001 }
+
+func (e *authError) Error() string { return e.message }
+
 type CustomError struct {
 	Code            int
 	ValidationError string
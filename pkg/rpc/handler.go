@@ -61,6 +61,7 @@ type handler struct {
 	conn           jsonWriter                     // where responses will be sent
 	log            *logrus.Entry
 	allowSubscribe bool
+	auth           *connAuth // nil if the calls are not authorized
 
 	subLock    sync.Mutex
 	serverSubs map[ID]*Subscription
@@ -153,6 +154,9 @@ func (h *handler) close(err error, inflightReq *requestOp) {
 	h.cancelAllRequests(err, inflightReq)
 	h.callWG.Wait()
 	h.cancelRoot()
+	if h.auth != nil {
+		h.auth.stop()
+	}
 	h.cancelServerSubscriptions(err)
 }
 
@@ -318,6 +322,9 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
+	if h.auth != nil && msg.Method == authenticateMethod {
+		return h.handleAuthenticate(msg)
+	}
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg)
 	}
@@ -330,6 +337,12 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 	if callb == nil {
 		return msg.errorResponse(&methodNotFoundError{method: msg.Method})
 	}
+	if h.auth != nil && callb != h.unsubscribeCb {
+		method := strings.TrimPrefix(msg.Method, msg.namespace()+serviceMethodSeparator)
+		if err := h.auth.authorize(msg.namespace(), method, ActionCall); err != nil {
+			return msg.errorResponse(err)
+		}
+	}
 	args, err := parsePositionalArguments(msg.Params, callb.argTypes)
 	if err != nil {
 		return msg.errorResponse(&invalidParamsError{err.Error()})
@@ -368,6 +381,11 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 	if callb == nil {
 		return msg.errorResponse(&subscriptionNotFoundError{namespace, name})
 	}
+	if h.auth != nil {
+		if err := h.auth.authorize(namespace, name, ActionSubscribe); err != nil {
+			return msg.errorResponse(err)
+		}
+	}
 
 	// Parse subscription name arg too, but remove it before calling the callback.
 	argTypes := append([]reflect.Type{stringType}, callb.argTypes...)
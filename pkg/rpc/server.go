@@ -33,6 +33,7 @@ type Server struct {
 	idgen    func() ID
 	run      int32
 	codecs   mapset.Set
+	auth     Authorizer
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -68,7 +69,7 @@ func (s *Server) ServeCodec(codec ServerCodec) {
 	s.codecs.Add(codec)
 	defer s.codecs.Remove(codec)
 
-	c := initClient(codec, s.idgen, &s.services)
+	c := initClient(codec, s.idgen, &s.services, s.auth)
 	<-codec.closed()
 	c.Close()
 }
@@ -84,6 +85,7 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec) {
 
 	h := newHandler(ctx, codec, s.idgen, &s.services)
 	h.allowSubscribe = false
+	h.setAuth(s.auth, tokenFromContext(ctx))
 	defer h.close(io.EOF, nil)
 
 	reqs, batch, err := codec.readBatch()
@@ -134,6 +134,9 @@ func (n *Notifier) Notify(id ID, data interface{}) error {
 	} else if n.sub.ID != id {
 		panic("Notify with wrong ID")
 	}
+	if n.h.auth != nil && n.h.auth.expired() {
+		return errTokenExpired
+	}
 	if n.activated {
 		return n.send(n.sub, enc)
 	}
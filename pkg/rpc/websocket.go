@@ -46,6 +46,9 @@ var wsBufferPool = new(sync.Pool)
 //
 // allowedOrigins should be a comma-separated list of allowed origin URLs.
 // To allow connections with any origin, pass "*".
+//
+// If the server has an authorizer, the handshake must present a token as a bearer
+// token or as the token query parameter.
 func (s *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 	var upgrader = websocket.Upgrader{
 		ReadBufferSize:  wsReadBuffer,
@@ -54,13 +57,20 @@ func (s *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 		CheckOrigin:     wsHandshakeValidator(allowedOrigins),
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := requestToken(r)
+		if s.auth != nil {
+			if err := s.checkToken(token); err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
 			logging.Debugf("WebSocket upgrade failed err %s", err)
 			return
 		}
 		codec := newWebsocketCodec(conn, r.Host, r.Header)
-		s.ServeCodec(codec)
+		s.ServeCodec(&tokenCodec{ServerCodec: codec, token: token})
 	})
 }
 
@@ -3,6 +3,7 @@ package common
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
@@ -17,6 +18,8 @@ import (
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
+var errNoEndpoint = errors.New("no chain endpoint")
+
 type Common struct {
 	sync.Mutex
 	tx      chain.TxInfo
@@ -42,6 +45,12 @@ func New(logger logging.Logger, signer crypto.Signer, chainId *big.Int, chainEnd
 	if err != nil {
 		return nil, err
 	}
+	return NewWithClient(logger, signer, chainId, client)
+}
+
+// NewWithClient creates the service with the given rpc client, without one the
+// requests to the chain endpoint fail.
+func NewWithClient(logger logging.Logger, signer crypto.Signer, chainId *big.Int, client *rpc.Client) (*Common, error) {
 	address, err := signer.EthereumAddress()
 	if err != nil {
 		return nil, err
@@ -50,6 +59,9 @@ func New(logger logging.Logger, signer crypto.Signer, chainId *big.Int, chainEnd
 }
 
 func (c *Common) All(ctx context.Context, req *chain.AllRequest) (*chain.AllResponse, error) {
+	if c.client == nil {
+		return nil, errNoEndpoint
+	}
 	var result interface{}
 	if req.Method == "eth_sendTransaction" {
 		txs := make([]interface{}, len(req.Params))
@@ -0,0 +1,538 @@
+package devchain
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+	"text/template"
+
+	"github.com/FavorLabs/favorX/pkg/crypto/eip712"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/oracle"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/traffic"
+	chequePkg "github.com/FavorLabs/favorX/pkg/settlement/traffic/cheque"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/asm"
+)
+
+// The dev contracts implement the part of the oracle and traffic contract
+// ABIs the node uses. They are written in EVM assembly so that the chain
+// needs no solidity compiler and no prebuilt bytecode.
+//
+// Mappings are stored at keccak256(key . slot), the list of a key has its
+// length there and its items in the following slots.
+
+// oracleCode stores the overlays registered for a hash:
+// slot 0: hash => overlay list, slot 1: hash . overlay => list index + 1.
+// Like the deployed contract it emits no events.
+const oracleCode = `
+{{dispatch "get" "set" "remove" "oracleIMap"}}
+
+get:
+{{arg 0}}
+{{slot 0}}
+{{list "get"}}
+
+oracleIMap:
+{{arg 1}}
+{{arg 0}}
+{{slot2 1}}
+SLOAD
+{{ret}}
+
+set:
+{{arg 1}}
+{{arg 0}}
+{{slot2 1}}
+DUP1
+SLOAD
+JUMPI @done
+{{arg 0}}
+{{slot 0}}
+DUP1
+SLOAD
+{{arg 1}}
+DUP3
+DUP3
+ADD
+PUSH 1
+ADD
+SSTORE
+PUSH 1
+ADD
+DUP1
+DUP3
+SSTORE
+DUP3
+SSTORE
+STOP
+
+remove:
+{{arg 1}}
+{{arg 0}}
+{{slot2 1}}
+DUP1
+SLOAD
+DUP1
+ISZERO
+JUMPI @done
+{{arg 0}}
+{{slot 0}}
+DUP1
+SLOAD
+DUP2
+DUP2
+ADD
+DUP1
+SLOAD
+;; move the last overlay to the removed one
+DUP1
+DUP5
+DUP7
+ADD
+SSTORE
+{{arg 0}}
+{{slot2 1}}
+DUP5
+SWAP1
+SSTORE
+PUSH 0
+SWAP1
+SSTORE
+PUSH 1
+SWAP1
+SUB
+SWAP1
+SSTORE
+POP
+PUSH 0
+SWAP1
+SSTORE
+STOP
+
+done:
+STOP
+`
+
+// trafficCode is the traffic token: slot 0: balances, slot 1: payer . payee
+// => cashed cumulative payout, slot 2: retrieved total, slot 3: transferred
+// total, slot 4: payees of a payer, slot 5: payers of a payee, slot 6: total
+// supply.
+const trafficCode = `
+{{dispatch "balanceOf" "totalSupply" "decimals" "transfer" "transTraffic" "retrievedTotal" "transferredTotal" "getRetrievedAddress" "getTransferredAddress" "cashChequeBeneficiary"}}
+
+balanceOf:
+{{arg 0}}
+{{slot 0}}
+SLOAD
+{{ret}}
+
+totalSupply:
+PUSH 6
+SLOAD
+{{ret}}
+
+decimals:
+PUSH 18
+{{ret}}
+
+transTraffic:
+{{arg 1}}
+{{arg 0}}
+{{slot2 1}}
+SLOAD
+{{ret}}
+
+retrievedTotal:
+{{arg 0}}
+{{slot 2}}
+SLOAD
+{{ret}}
+
+transferredTotal:
+{{arg 0}}
+{{slot 3}}
+SLOAD
+{{ret}}
+
+getRetrievedAddress:
+{{arg 0}}
+{{slot 4}}
+{{list "retrieved"}}
+
+getTransferredAddress:
+{{arg 0}}
+{{slot 5}}
+{{list "transferred"}}
+
+transfer:
+CALLER
+{{slot 0}}
+DUP1
+SLOAD
+{{arg 1}}
+DUP1
+DUP3
+LT
+JUMPI @revert
+DUP1
+DUP3
+SUB
+DUP4
+SSTORE
+{{arg 0}}
+{{slot 0}}
+DUP1
+SLOAD
+DUP3
+ADD
+SWAP1
+SSTORE
+PUSH 1
+{{ret}}
+
+cashChequeBeneficiary:
+;; the EIP712 digest of the cheque
+PUSH {{.ChequeTypeHash}}
+PUSH 0
+MSTORE
+{{arg 1}}
+PUSH 0x20
+MSTORE
+{{arg 0}}
+PUSH 0x40
+MSTORE
+{{arg 2}}
+PUSH 0x60
+MSTORE
+PUSH 0x80
+PUSH 0
+KECCAK256
+PUSH {{.EIP712Prefix}}
+PUSH 0
+MSTORE
+PUSH {{.DomainSeparator}}
+PUSH 2
+MSTORE
+PUSH 0x22
+MSTORE
+PUSH 0x42
+PUSH 0
+KECCAK256
+PUSH 0
+MSTORE
+;; the signature is r . s . v
+PUSH 100
+CALLDATALOAD
+PUSH 4
+ADD
+DUP1
+CALLDATALOAD
+PUSH 65
+EQ
+ISZERO
+JUMPI @revert
+DUP1
+PUSH 0x20
+ADD
+CALLDATALOAD
+PUSH 0x40
+MSTORE
+DUP1
+PUSH 0x40
+ADD
+CALLDATALOAD
+PUSH 0x60
+MSTORE
+PUSH 0x60
+ADD
+CALLDATALOAD
+PUSH 248
+SHR
+DUP1
+PUSH 27
+GT
+ISZERO
+JUMPI @vok
+PUSH 27
+ADD
+vok:
+PUSH 0x20
+MSTORE
+PUSH 0
+PUSH 0x80
+MSTORE
+;; ecrecover must return the issuer
+PUSH 0x20
+PUSH 0x80
+PUSH 0x80
+PUSH 0
+PUSH 1
+GAS
+STATICCALL
+ISZERO
+JUMPI @revert
+PUSH 0x80
+MLOAD
+DUP1
+ISZERO
+JUMPI @revert
+{{arg 0}}
+EQ
+ISZERO
+JUMPI @revert
+;; only the payout above the cashed one is paid
+{{arg 1}}
+{{arg 0}}
+{{slot2 1}}
+DUP1
+SLOAD
+{{arg 2}}
+DUP2
+DUP2
+GT
+ISZERO
+JUMPI @revert
+DUP1
+DUP4
+SSTORE
+DUP2
+SWAP1
+SUB
+{{arg 0}}
+{{slot 0}}
+DUP1
+SLOAD
+DUP3
+DUP2
+LT
+JUMPI @revert
+DUP3
+SWAP1
+SUB
+SWAP1
+SSTORE
+{{arg 1}}
+{{slot 0}}
+{{add}}
+{{arg 0}}
+{{slot 2}}
+{{add}}
+{{arg 1}}
+{{slot 3}}
+{{add}}
+POP
+JUMPI @stop
+;; the first cheque between the two links them
+{{arg 1}}
+{{arg 0}}
+{{slot 4}}
+{{push}}
+{{arg 0}}
+{{arg 1}}
+{{slot 5}}
+{{push}}
+STOP
+
+revert:
+PUSH 0
+DUP1
+REVERT
+
+stop:
+STOP
+`
+
+// trafficInit mints the total supply to the deployer.
+const trafficInit = `
+PUSH {{.Supply}}
+DUP1
+PUSH 6
+SSTORE
+CALLER
+{{slot 0}}
+SSTORE
+`
+
+// deployCode returns the code that runs init and deploys the runtime code.
+const deployCode = `
+{{.Init}}
+PUSH {{.Size}}
+DUP1
+PUSH @runtime
+PUSH 1
+ADD
+PUSH 0
+CODECOPY
+PUSH 0
+RETURN
+runtime:
+`
+
+var snippets = template.FuncMap{
+	// arg loads the argument i of the call.
+	"arg": func(i int) string {
+		return fmt.Sprintf("PUSH %d\nCALLDATALOAD", 4+32*i)
+	},
+	// slot replaces the key on the stack with its slot in the mapping.
+	"slot": func(base int) string {
+		return fmt.Sprintf("PUSH 0\nMSTORE\nPUSH %d\nPUSH 0x20\nMSTORE\nPUSH 0x40\nPUSH 0\nKECCAK256", base)
+	},
+	// slot2 replaces the two keys on the stack, the first one on top, with
+	// their slot in the mapping.
+	"slot2": func(base int) string {
+		return fmt.Sprintf("PUSH 0\nMSTORE\nPUSH 0x20\nMSTORE\nPUSH %d\nPUSH 0x40\nMSTORE\nPUSH 0x60\nPUSH 0\nKECCAK256", base)
+	},
+	// add adds the value below the slot on the stack to the slot, keeping
+	// the value.
+	"add": func() string {
+		return "DUP2\nDUP2\nSLOAD\nADD\nSWAP1\nSSTORE"
+	},
+	// push appends the value below the list slot on the stack to the list.
+	"push": func() string {
+		return "DUP1\nSLOAD\nPUSH 1\nADD\nDUP1\nDUP3\nSSTORE\nADD\nSSTORE"
+	},
+	// list returns the items of the list slot on the stack as a bytes32[].
+	"list": func(name string) string {
+		return strings.ReplaceAll(`DUP1
+SLOAD
+PUSH 0x20
+PUSH 0
+MSTORE
+DUP1
+PUSH 0x20
+MSTORE
+PUSH 0
+NAME_loop:
+DUP2
+DUP2
+LT
+ISZERO
+JUMPI @NAME_end
+DUP1
+DUP4
+ADD
+PUSH 1
+ADD
+SLOAD
+DUP2
+PUSH 0x20
+MUL
+PUSH 0x40
+ADD
+MSTORE
+PUSH 1
+ADD
+JUMP @NAME_loop
+NAME_end:
+PUSH 0x20
+MUL
+PUSH 0x40
+ADD
+PUSH 0
+RETURN`, "NAME", name)
+	},
+	// ret returns the word on the stack.
+	"ret": func() string {
+		return "PUSH 0\nMSTORE\nPUSH 0x20\nPUSH 0\nRETURN"
+	},
+}
+
+// assemble compiles the code template, dispatching the calls of the methods
+// of the contract ABI to the labels named after them.
+func assemble(code string, contract *abi.ABI, data interface{}) ([]byte, error) {
+	funcs := template.FuncMap{
+		"dispatch": func(methods ...string) (string, error) {
+			var b strings.Builder
+			b.WriteString("PUSH 0\nCALLDATALOAD\nPUSH 0xe0\nSHR\n")
+			for _, name := range methods {
+				m, ok := contract.Methods[name]
+				if !ok {
+					return "", fmt.Errorf("method %s not in the abi", name)
+				}
+				fmt.Fprintf(&b, "DUP1\nPUSH %s\nEQ\nJUMPI @%s\n", hexutil.Encode(m.ID), name)
+			}
+			b.WriteString("PUSH 0\nDUP1\nREVERT")
+			return b.String(), nil
+		},
+	}
+	for k, v := range snippets {
+		funcs[k] = v
+	}
+	tmpl, err := template.New("code").Funcs(funcs).Parse(code)
+	if err != nil {
+		return nil, err
+	}
+	var src bytes.Buffer
+	if err := tmpl.Execute(&src, data); err != nil {
+		return nil, err
+	}
+	c := asm.NewCompiler(false)
+	c.Feed(asm.Lex(src.Bytes(), false))
+	out, errs := c.Compile()
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("assemble: %v", errs[0])
+	}
+	return common.FromHex(out), nil
+}
+
+// codeParams are the constants of the code templates.
+type codeParams struct {
+	ChequeTypeHash  string
+	EIP712Prefix    string
+	DomainSeparator string
+	Supply          string
+	Size            int
+}
+
+// deployment returns the code deploying the runtime code, running init first.
+func deployment(init string, runtime []byte, params codeParams) ([]byte, error) {
+	params.Size = len(runtime)
+	code, err := assemble(strings.Replace(deployCode, "{{.Init}}", init, 1), nil, params)
+	if err != nil {
+		return nil, err
+	}
+	return append(code, runtime...), nil
+}
+
+// oracleContract returns the deployment code of the dev oracle contract.
+func oracleContract() (*abi.ABI, []byte, error) {
+	contract, err := oracle.OracleMetaData.GetAbi()
+	if err != nil {
+		return nil, nil, err
+	}
+	runtime, err := assemble(oracleCode, contract, codeParams{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("oracle: %w", err)
+	}
+	code, err := deployment("", runtime, codeParams{})
+	return contract, code, err
+}
+
+// trafficContract returns the deployment code of the dev traffic contract
+// verifying the cheques of the chain and minting the supply to the deployer.
+func trafficContract(chainID, supply *big.Int) (*abi.ABI, []byte, error) {
+	contract, err := traffic.TrafficMetaData.GetAbi()
+	if err != nil {
+		return nil, nil, err
+	}
+	domain, err := chequePkg.DomainSeparator(chainID.Int64())
+	if err != nil {
+		return nil, nil, err
+	}
+	typeHash := (&eip712.TypedData{Types: chequePkg.ChequeTypes}).TypeHash("Cheque")
+	params := codeParams{
+		ChequeTypeHash:  hexutil.Encode(typeHash),
+		EIP712Prefix:    hexutil.Encode(append([]byte{0x19, 0x01}, make([]byte, 30)...)),
+		DomainSeparator: hexutil.Encode(domain),
+		Supply:          hexutil.EncodeBig(supply),
+	}
+	runtime, err := assemble(trafficCode, contract, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("traffic: %w", err)
+	}
+	code, err := deployment(trafficInit, runtime, params)
+	return contract, code, err
+}
@@ -0,0 +1,192 @@
+// Package devchain provides an in-process simulated chain with the oracle and
+// traffic contracts deployed, so that the settlement can run without a chain
+// endpoint.
+package devchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/traffic"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/transaction"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const gasLimit = 30_000_000
+
+var (
+	// FundEther is the ether Fund gives an account to pay for gas.
+	FundEther = new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))
+	// FundTokens is the traffic token balance Fund gives an account.
+	FundTokens = new(big.Int).Mul(big.NewInt(1_000_000), big.NewInt(params.Ether))
+
+	faucetEther  = new(big.Int).Lsh(big.NewInt(1), 128)
+	faucetTokens = new(big.Int).Lsh(big.NewInt(1), 128)
+
+	errReverted = errors.New("transaction reverted")
+)
+
+var _ transaction.Backend = (*Backend)(nil)
+
+// Backend is a simulated chain mining every transaction as soon as it is
+// sent, with the oracle and traffic contracts deployed.
+type Backend struct {
+	*backends.SimulatedBackend
+
+	// OracleAddress and TrafficAddress are the addresses of the contracts.
+	OracleAddress  common.Address
+	TrafficAddress common.Address
+
+	mu      sync.Mutex // serialises the faucet transactions
+	faucet  *bind.TransactOpts
+	traffic *traffic.Traffic
+}
+
+// New starts a chain, deploying the contracts from a faucet account holding
+// the ether and traffic tokens the accounts are funded with.
+func New() (*Backend, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	faucet := crypto.PubkeyToAddress(key.PublicKey)
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{faucet: {Balance: faucetEther}}, gasLimit)
+	b := &Backend{SimulatedBackend: sim}
+
+	b.faucet, err = bind.NewKeyedTransactorWithChainID(key, b.ChainID())
+	if err != nil {
+		_ = sim.Close()
+		return nil, err
+	}
+
+	oracleABI, code, err := oracleContract()
+	if err != nil {
+		_ = sim.Close()
+		return nil, err
+	}
+	if b.OracleAddress, err = b.deploy(oracleABI, code); err != nil {
+		_ = sim.Close()
+		return nil, fmt.Errorf("deploy oracle: %w", err)
+	}
+	trafficABI, code, err := trafficContract(b.ChainID(), faucetTokens)
+	if err != nil {
+		_ = sim.Close()
+		return nil, err
+	}
+	if b.TrafficAddress, err = b.deploy(trafficABI, code); err != nil {
+		_ = sim.Close()
+		return nil, fmt.Errorf("deploy traffic: %w", err)
+	}
+	b.traffic, err = traffic.NewTraffic(b.TrafficAddress, b)
+	if err != nil {
+		_ = sim.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+var (
+	sharedOnce sync.Once
+	shared     *Backend
+	sharedErr  error
+)
+
+// Shared returns the chain of the process, starting it on first use. The
+// nodes running in the same process share it, so that they can cash the
+// cheques they issue to each other.
+func Shared() (*Backend, error) {
+	sharedOnce.Do(func() {
+		shared, sharedErr = New()
+	})
+	return shared, sharedErr
+}
+
+// ChainID returns the id of the chain transactions are signed for.
+func (b *Backend) ChainID() *big.Int {
+	return b.Blockchain().Config().ChainID
+}
+
+// BlockNumber returns the number of the last mined block.
+func (b *Backend) BlockNumber(_ context.Context) (uint64, error) {
+	return b.Blockchain().CurrentBlock().NumberU64(), nil
+}
+
+// SendTransaction sends the transaction and mines it.
+func (b *Backend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := b.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	b.Commit()
+	return nil
+}
+
+// Fund sends the account FundEther and FundTokens from the faucet.
+func (b *Backend) Fund(ctx context.Context, account common.Address) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	nonce, err := b.PendingNonceAt(ctx, b.faucet.From)
+	if err != nil {
+		return err
+	}
+	gasPrice, err := b.SuggestGasPrice(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := b.faucet.Signer(b.faucet.From, types.NewTransaction(nonce, account, FundEther, params.TxGas, gasPrice, nil))
+	if err != nil {
+		return err
+	}
+	if err := b.SendTransaction(ctx, tx); err != nil {
+		return fmt.Errorf("fund ether: %w", err)
+	}
+	if err := b.mined(ctx, tx); err != nil {
+		return fmt.Errorf("fund ether: %w", err)
+	}
+
+	tx, err = b.traffic.Transfer(b.opts(ctx), account, FundTokens)
+	if err != nil {
+		return fmt.Errorf("fund tokens: %w", err)
+	}
+	if err := b.mined(ctx, tx); err != nil {
+		return fmt.Errorf("fund tokens: %w", err)
+	}
+	return nil
+}
+
+func (b *Backend) opts(ctx context.Context) *bind.TransactOpts {
+	opts := *b.faucet
+	opts.Context = ctx
+	return &opts
+}
+
+func (b *Backend) deploy(contract *abi.ABI, code []byte) (common.Address, error) {
+	ctx := context.Background()
+	address, tx, _, err := bind.DeployContract(b.opts(ctx), *contract, code, b)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return address, b.mined(ctx, tx)
+}
+
+// mined checks that the mined transaction succeeded.
+func (b *Backend) mined(ctx context.Context, tx *types.Transaction) error {
+	receipt, err := b.TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		return err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return errReverted
+	}
+	return nil
+}
@@ -0,0 +1,342 @@
+package devchain_test
+
+import (
+	"context"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/boson/test"
+	"github.com/FavorLabs/favorX/pkg/crypto"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain"
+	chainCommon "github.com/FavorLabs/favorX/pkg/settlement/chain/common"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/devchain"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/oracle"
+	chainTraffic "github.com/FavorLabs/favorX/pkg/settlement/chain/traffic"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/transaction"
+	chequePkg "github.com/FavorLabs/favorX/pkg/settlement/traffic/cheque"
+	statestore "github.com/FavorLabs/favorX/pkg/statestore/mock"
+	"github.com/FavorLabs/favorX/pkg/subscribe"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var logger = logging.New(io.Discard, 0)
+
+func newChain(t *testing.T) *devchain.Backend {
+	t.Helper()
+	b, err := devchain.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = b.Close() })
+	return b
+}
+
+// newAccount returns the signer of a funded account.
+func newAccount(t *testing.T, b *devchain.Backend) (crypto.Signer, common.Address) {
+	t.Helper()
+	key, err := crypto.GenerateSecp256k1Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := crypto.NewDefaultSigner(key)
+	address, err := signer.EthereumAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Fund(context.Background(), address); err != nil {
+		t.Fatal(err)
+	}
+	return signer, address
+}
+
+func newTransactionService(t *testing.T, b *devchain.Backend, signer crypto.Signer, cc chain.Common) transaction.Service {
+	t.Helper()
+	s, err := transaction.NewService(logger, b, signer, statestore.NewStateStore(), cc, b.ChainID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestOracle(t *testing.T) {
+	b := newChain(t)
+	ctx := context.Background()
+	signer, _ := newAccount(t, b)
+	cc, err := chainCommon.NewWithClient(logger, signer, b.ChainID(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactionService := newTransactionService(t, b, signer, cc)
+	ora, err := oracle.NewServer(logger, b, b.ChainID(), b.OracleAddress.String(), signer, transactionService, cc, subscribe.NewSubPub(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := test.RandomAddress()
+	overlays := []boson.Address{test.RandomAddress(), test.RandomAddress(), test.RandomAddress()}
+	register := func(overlay boson.Address, remove bool) {
+		t.Helper()
+		f := ora.RegisterCidAndNode
+		if remove {
+			f = ora.RemoveCidAndNode
+		}
+		hash, err := f(ctx, root, overlay, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		receipt, err := ora.WaitForReceipt(ctx, root, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			t.Fatal("transaction failed")
+		}
+	}
+	expect := func(want ...boson.Address) {
+		t.Helper()
+		got := ora.GetNodesFromCid(root.Bytes())
+		if len(got) != len(want) {
+			t.Fatalf("got overlays %v, want %v", got, want)
+		}
+		for i := range want {
+			if !got[i].Equal(want[i]) {
+				t.Fatalf("got overlays %v, want %v", got, want)
+			}
+		}
+		for _, o := range overlays {
+			registered, err := ora.GetRegisterState(ctx, root, o)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if registered != o.MemberOf(want) {
+				t.Fatalf("overlay %s registered %v", o, registered)
+			}
+		}
+	}
+
+	expect()
+	for _, o := range overlays {
+		register(o, false)
+	}
+	register(overlays[1], false)
+	expect(overlays...)
+
+	register(overlays[0], true)
+	expect(overlays[2], overlays[1])
+	register(overlays[1], true)
+	register(overlays[1], true)
+	expect(overlays[2])
+	register(overlays[2], true)
+	expect()
+}
+
+func TestOracleIndex(t *testing.T) {
+	b := newChain(t)
+	ctx := context.Background()
+	signer, _ := newAccount(t, b)
+	cc, err := chainCommon.NewWithClient(logger, signer, b.ChainID(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactionService := newTransactionService(t, b, signer, cc)
+	store := statestore.NewStateStore()
+	newIndex := func() *oracle.Index {
+		t.Helper()
+		index, err := oracle.NewIndex(logger, b, b.ChainID(), b.OracleAddress, store, oracle.IndexOptions{BlockRange: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return index
+	}
+	index := newIndex()
+	ora, err := oracle.NewServer(logger, b, b.ChainID(), b.OracleAddress.String(), signer, transactionService, cc, subscribe.NewSubPub(), index)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := test.RandomAddress()
+	overlays := []boson.Address{test.RandomAddress(), test.RandomAddress(), test.RandomAddress()}
+	register := func(overlay boson.Address, remove bool) {
+		t.Helper()
+		f := ora.RegisterCidAndNode
+		if remove {
+			f = ora.RemoveCidAndNode
+		}
+		hash, err := f(ctx, root, overlay, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ora.WaitForReceipt(ctx, root, hash); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect := func(index *oracle.Index, want ...boson.Address) {
+		t.Helper()
+		got, ok := index.Nodes(root.Bytes())
+		if !ok {
+			t.Fatal("index not synced")
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got overlays %v, want %v", got, want)
+		}
+		for i := range want {
+			if !got[i].Equal(want[i]) {
+				t.Fatalf("got overlays %v, want %v", got, want)
+			}
+		}
+	}
+
+	if _, ok := index.Nodes(root.Bytes()); ok {
+		t.Fatal("lookup answered before the index synced")
+	}
+	for _, o := range overlays {
+		register(o, false)
+	}
+	register(overlays[0], true)
+	if err := index.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// the index keeps the overlays in the order of the contract
+	expect(index, overlays[2], overlays[1])
+	if got := ora.GetNodesFromCid(root.Bytes()); len(got) != 2 || !got[0].Equal(overlays[2]) {
+		t.Fatalf("got overlays %v from the oracle, want the indexed ones", got)
+	}
+	// the contract answers the lookups the index knows no overlays for
+	other := test.RandomAddress()
+	hash, err := ora.RegisterCidAndNode(ctx, other, overlays[0], nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ora.WaitForReceipt(ctx, other, hash); err != nil {
+		t.Fatal(err)
+	}
+	if got := ora.GetNodesFromCid(other.Bytes()); len(got) != 1 || !got[0].Equal(overlays[0]) {
+		t.Fatalf("got overlays %v from the oracle, want the registered ones", got)
+	}
+	if err := index.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	head, err := b.BlockNumber(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := index.Status(); !status.Synced || status.Block != head || status.Lag != 0 || status.Cids != 2 {
+		t.Fatalf("got status %+v", status)
+	}
+
+	// the index is restored from the store and follows on from the checkpoint
+	reloaded := newIndex()
+	register(overlays[1], true)
+	if err := reloaded.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	expect(reloaded, overlays[2])
+
+	reloaded.Resync()
+	if _, ok := reloaded.Nodes(root.Bytes()); ok {
+		t.Fatal("lookup answered while resyncing")
+	}
+	if err := reloaded.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	expect(reloaded, overlays[2])
+}
+
+func TestCashCheque(t *testing.T) {
+	b := newChain(t)
+	ctx := context.Background()
+	chainID := b.ChainID()
+	payer, payerAddress := newAccount(t, b)
+	payee, payeeAddress := newAccount(t, b)
+
+	cc, err := chainCommon.NewWithClient(logger, payee, chainID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactionService := newTransactionService(t, b, payee, cc)
+	trafficService, err := chainTraffic.NewServer(logger, chainID, b, payee, transactionService, b.TrafficAddress.String(), cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cash := func(signer crypto.Signer, payout int64) uint64 {
+		t.Helper()
+		c := &chequePkg.Cheque{
+			Recipient:        payeeAddress,
+			Beneficiary:      payerAddress,
+			CumulativePayout: big.NewInt(payout),
+		}
+		signature, err := chequePkg.NewChequeSigner(signer, chainID.Int64()).Sign(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash, err := trafficService.CashChequeBeneficiary(ctx, test.RandomAddress(), payerAddress, payeeAddress, c.CumulativePayout, signature)
+		if err != nil {
+			t.Fatal(err)
+		}
+		receipt, err := transactionService.WaitForReceipt(ctx, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return receipt.Status
+	}
+	expectBalance := func(address common.Address, want *big.Int) {
+		t.Helper()
+		got, err := trafficService.BalanceOf(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Cmp(want) != 0 {
+			t.Fatalf("got balance %v, want %v", got, want)
+		}
+	}
+	expectAddresses := func(got []common.Address, err error, want common.Address) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0] != want {
+			t.Fatalf("got addresses %v, want %v", got, want)
+		}
+	}
+
+	if status := cash(payer, 100); status != types.ReceiptStatusSuccessful {
+		t.Fatal("cheque not cashed")
+	}
+	if status := cash(payer, 250); status != types.ReceiptStatusSuccessful {
+		t.Fatal("cheque not cashed")
+	}
+	// a cheque is only cashed once and only when the payer signed it
+	if status := cash(payer, 250); status != types.ReceiptStatusFailed {
+		t.Fatal("cheque cashed twice")
+	}
+	if status := cash(payee, 300); status != types.ReceiptStatusFailed {
+		t.Fatal("cheque not signed by the payer cashed")
+	}
+
+	paid := big.NewInt(250)
+	expectBalance(payerAddress, new(big.Int).Sub(devchain.FundTokens, paid))
+	expectBalance(payeeAddress, new(big.Int).Add(devchain.FundTokens, paid))
+	for _, f := range []func() (*big.Int, error){
+		func() (*big.Int, error) { return trafficService.TransAmount(payerAddress, payeeAddress) },
+		func() (*big.Int, error) { return trafficService.RetrievedTotal(payerAddress) },
+		func() (*big.Int, error) { return trafficService.TransferredTotal(payeeAddress) },
+	} {
+		got, err := f()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Cmp(paid) != 0 {
+			t.Fatalf("got total %v, want %v", got, paid)
+		}
+	}
+	got, err := trafficService.RetrievedAddress(payerAddress)
+	expectAddresses(got, err, payeeAddress)
+	got, err = trafficService.TransferredAddress(payeeAddress)
+	expectAddresses(got, err, payerAddress)
+}
@@ -2,7 +2,7 @@ package oracle
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"math/big"
 	"sync"
 	"time"
@@ -12,26 +12,32 @@ import (
 	"github.com/FavorLabs/favorX/pkg/logging"
 	"github.com/FavorLabs/favorX/pkg/rpc"
 	"github.com/FavorLabs/favorX/pkg/settlement/chain"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/transaction"
 	"github.com/FavorLabs/favorX/pkg/subscribe"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 type ChainOracle struct {
 	sync.Mutex
 	logger        logging.Logger
 	oracle        *Oracle
-	chain         *ethclient.Client
+	chain         transaction.Backend
 	signer        crypto.Signer
 	senderAddress common.Address
+	address       common.Address
 	chainID       *big.Int
 	commonService chain.Common
 	subPub        subscribe.SubPub
+	index         *Index // answers the lookups once caught up with the chain, if set
+
+	transactionService chain.Transaction
 }
 
-func NewServer(logger logging.Logger, backend *ethclient.Client, chainID *big.Int, address string, signer crypto.Signer, commonService chain.Common, subPub subscribe.SubPub) (chain.Resolver, error) {
+var oracleABI = transaction.ParseABIUnchecked(OracleABI)
+
+func NewServer(logger logging.Logger, backend transaction.Backend, chainID *big.Int, address string, signer crypto.Signer, transactionService chain.Transaction, commonService chain.Common, subPub subscribe.SubPub, index *Index) (chain.Resolver, error) {
 	senderAddress, err := signer.EthereumAddress()
 	if err != nil {
 		return nil, err
@@ -48,9 +54,13 @@ func NewServer(logger logging.Logger, backend *ethclient.Client, chainID *big.In
 		chain:         backend,
 		signer:        signer,
 		senderAddress: senderAddress,
+		address:       common.HexToAddress(address),
 		chainID:       chainID,
 		commonService: commonService,
 		subPub:        subPub,
+		index:         index,
+
+		transactionService: transactionService,
 	}, nil
 }
 
@@ -63,6 +73,13 @@ func (ora *ChainOracle) GetNodesFromCid(cid []byte) []boson.Address {
 	if ora.chain == nil {
 		return make([]boson.Address, 0)
 	}
+	// the contract answers when the index knows no overlays, as the calls
+	// other contracts make to it are not indexed
+	if ora.index != nil {
+		if overs, ok := ora.index.Nodes(cid); ok && len(overs) > 0 {
+			return overs
+		}
+	}
 
 	ctx, cancel := context.WithTimeout(context.TODO(), 30*time.Second)
 	defer cancel()
@@ -93,35 +110,16 @@ func (ora *ChainOracle) DataStoreFinished(cid boson.Address, dataLen uint64, sal
 }
 
 func (ora *ChainOracle) RegisterCidAndNode(ctx context.Context, rootCid boson.Address, address boson.Address, gasPrice, minGasPrice *big.Int) (hash common.Hash, err error) {
-	ora.Lock()
-	defer ora.Unlock()
-	if ora.chain == nil {
-		return common.Hash{}, nil
-	}
-
-	defer func() {
-		if err == nil {
-			ora.commonService.SyncTransaction(chain.ORACLE, rootCid.String(), hash.String())
-		}
-	}()
-
-	if ora.commonService.IsTransaction() {
-		return common.Hash{}, errors.New("existing chain transaction")
-	}
-
-	opts, err := ora.getTransactOpts(ctx, gasPrice, minGasPrice)
-	if err != nil {
-		return common.Hash{}, err
-	}
-	tract, err := ora.oracle.Set(opts, common.BytesToHash(rootCid.Bytes()), common.BytesToHash(address.Bytes()))
-	if err != nil {
-		return common.Hash{}, err
-	}
-	return tract.Hash(), nil
+	return ora.send(ctx, "set", rootCid, address, gasPrice, minGasPrice)
 }
+
 func (ora *ChainOracle) RemoveCidAndNode(ctx context.Context, rootCid boson.Address, address boson.Address, gasPrice, minGasPrice *big.Int) (hash common.Hash, err error) {
-	ora.Lock()
-	defer ora.Unlock()
+	return ora.send(ctx, "remove", rootCid, address, gasPrice, minGasPrice)
+}
+
+// send queues a transaction calling the method of the oracle contract with
+// the root cid and the overlay.
+func (ora *ChainOracle) send(ctx context.Context, method string, rootCid boson.Address, address boson.Address, gasPrice, minGasPrice *big.Int) (hash common.Hash, err error) {
 	if ora.chain == nil {
 		return common.Hash{}, nil
 	}
@@ -130,19 +128,23 @@ func (ora *ChainOracle) RemoveCidAndNode(ctx context.Context, rootCid boson.Addr
 			ora.commonService.SyncTransaction(chain.ORACLE, rootCid.String(), hash.String())
 		}
 	}()
-	if ora.commonService.IsTransaction() {
-		return common.Hash{}, errors.New("existing chain transaction")
-	}
-	opts, err := ora.getTransactOpts(ctx, gasPrice, minGasPrice)
+
+	data, err := oracleABI.Pack(method, common.BytesToHash(rootCid.Bytes()), common.BytesToHash(address.Bytes()))
 	if err != nil {
 		return common.Hash{}, err
 	}
-
-	tract, err := ora.oracle.Remove(opts, common.BytesToHash(rootCid.Bytes()), common.BytesToHash(address.Bytes()))
+	gasPrice, err = ora.gasPrice(ctx, gasPrice, minGasPrice)
 	if err != nil {
 		return common.Hash{}, err
 	}
-	return tract.Hash(), nil
+	return ora.transactionService.Send(ctx, &chain.TxRequest{
+		To:          &ora.address,
+		Data:        data,
+		GasPrice:    gasPrice,
+		GasLimit:    1000000,
+		Value:       big.NewInt(0),
+		Description: fmt.Sprintf("oracle %s %s", method, rootCid),
+	})
 }
 
 func (ora *ChainOracle) WaitForReceipt(ctx context.Context, rootCid boson.Address, txHash common.Hash) (receipt *types.Receipt, err error) {
@@ -150,29 +152,13 @@ func (ora *ChainOracle) WaitForReceipt(ctx context.Context, rootCid boson.Addres
 		ora.PublishRegisterStatus(rootCid, 0)
 		return nil, nil
 	}
-	defer func() {
-		ora.commonService.UpdateStatus(false)
-	}()
-	for {
-		receipt, err := ora.chain.TransactionReceipt(ctx, txHash)
-		if receipt != nil {
-			ora.PublishRegisterStatus(rootCid, receipt.Status)
-			return receipt, nil
-		}
-		if err != nil {
-			// some node implementations return an error if the transaction is not yet mined
-			ora.logger.Tracef("waiting for transaction %x to be mined: %v", txHash, err)
-		} else {
-			ora.logger.Tracef("waiting for transaction %x to be mined", txHash)
-		}
-
-		select {
-		case <-ctx.Done():
-			ora.PublishRegisterStatus(rootCid, 0)
-			return nil, ctx.Err()
-		case <-time.After(3 * time.Second):
-		}
+	receipt, err = ora.transactionService.WaitForReceipt(ctx, txHash)
+	if err != nil {
+		ora.PublishRegisterStatus(rootCid, 0)
+		return nil, err
 	}
+	ora.PublishRegisterStatus(rootCid, receipt.Status)
+	return receipt, nil
 }
 
 func (ora *ChainOracle) GetRegisterState(ctx context.Context, rootCid boson.Address, address boson.Address) (bool, error) {
@@ -190,38 +176,24 @@ func (ora *ChainOracle) GetRegisterState(ctx context.Context, rootCid boson.Addr
 	return state.Cmp(big.NewInt(0)) != 0, nil
 }
 
-func (ora *ChainOracle) getTransactOpts(ctx context.Context, gasPrice, minGasPrice *big.Int) (*bind.TransactOpts, error) {
-	if ora.chain == nil {
+// gasPrice returns the gas price requested, or the suggested one but at least
+// minGasPrice if that is set. A nil gas price leaves the fees to the
+// transaction service.
+func (ora *ChainOracle) gasPrice(ctx context.Context, gasPrice, minGasPrice *big.Int) (*big.Int, error) {
+	if gasPrice != nil && gasPrice.Sign() > 0 {
+		return gasPrice, nil
+	}
+	if minGasPrice == nil || minGasPrice.Sign() <= 0 {
 		return nil, nil
 	}
-	chainNonce, err := ora.chain.PendingNonceAt(ctx, ora.senderAddress)
+	suggested, err := ora.chain.SuggestGasPrice(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	if gasPrice == nil || gasPrice.Cmp(big.NewInt(0)) <= 0 {
-		gasPrice, err = ora.chain.SuggestGasPrice(ctx)
-		if err != nil {
-			return nil, err
-		}
-		if minGasPrice != nil && gasPrice.Cmp(minGasPrice) < 0 {
-			gasPrice = minGasPrice
-		}
+	if suggested.Cmp(minGasPrice) < 0 {
+		return minGasPrice, nil
 	}
-	opts := &bind.TransactOpts{
-		From: ora.senderAddress,
-		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
-			if address != ora.senderAddress {
-				return nil, bind.ErrNotAuthorized
-			}
-			return ora.signer.SignTx(tx, ora.chainID)
-		},
-		GasLimit: 1000000,
-		GasPrice: gasPrice,
-		Context:  ctx,
-		Nonce:    new(big.Int).SetUint64(chainNonce),
-	}
-	return opts, nil
+	return suggested, nil
 }
 
 func (ora *ChainOracle) SubscribeRegisterStatus(notifier *rpc.Notifier, sub *rpc.Subscription, rootCids []boson.Address) {
@@ -0,0 +1,395 @@
+package oracle
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/storage"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	indexPrefix        = "oracle_index_cid_"
+	indexCheckpointKey = "oracle_index_checkpoint" // next block to index
+
+	// DefaultIndexBlockRange is the most blocks indexed between two
+	// checkpoints by default.
+	DefaultIndexBlockRange = 2000
+)
+
+// IndexBackend is the part of the chain backend the index polls.
+type IndexBackend interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// IndexOptions tune how the index follows the oracle contract.
+type IndexOptions struct {
+	Interval      time.Duration // between the polls of the chain, no index if 0
+	StartBlock    uint64        // the contract was deployed at, indexed from
+	BlockRange    uint64        // most blocks indexed between two checkpoints
+	Confirmations uint64        // blocks a call has to be under the head to be indexed
+}
+
+// IndexStatus tells how fresh the index is.
+type IndexStatus struct {
+	ChainID  int64          `json:"chainID"`
+	Contract common.Address `json:"contract"`
+	Synced   bool           `json:"synced"` // caught up with the chain, answering the lookups
+	Block    uint64         `json:"block"`  // last block indexed
+	Head     uint64         `json:"head"`   // last block of the chain seen
+	Lag      uint64         `json:"lag"`    // blocks the index is behind the head
+	LastSync time.Time      `json:"lastSync"`
+	Cids     int            `json:"cids"`
+	Error    string         `json:"error,omitempty"` // of the last poll
+}
+
+// Index keeps the overlays registered for the cids on the oracle contract,
+// following the successful set, remove and clear transactions sent to the
+// contract from a checkpointed block height, so that the lookups are answered
+// without calling the contract. The calls other contracts make to it are not
+// seen. The index and the checkpoint are persisted in the state store.
+type Index struct {
+	logger  logging.Logger
+	backend IndexBackend
+	address common.Address
+	chainID int64
+	store   storage.StateStorer
+	opts    IndexOptions
+	metrics indexMetrics
+
+	mu       sync.RWMutex
+	cids     map[string][]boson.Address
+	next     uint64 // next block to index
+	synced   bool
+	head     uint64
+	lastSync time.Time
+	lastErr  error
+
+	resync  int32 // set to reset the index on the next poll
+	trigger chan struct{}
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewIndex returns the index of the oracle contract at the address, loading
+// what was indexed before.
+func NewIndex(logger logging.Logger, backend IndexBackend, chainID *big.Int, address common.Address, store storage.StateStorer, opts IndexOptions) (*Index, error) {
+	if opts.BlockRange == 0 {
+		opts.BlockRange = DefaultIndexBlockRange
+	}
+	i := &Index{
+		logger:  logger,
+		backend: backend,
+		address: address,
+		chainID: chainID.Int64(),
+		store:   store,
+		opts:    opts,
+		metrics: newIndexMetrics(chainID.Int64()),
+		cids:    make(map[string][]boson.Address),
+		next:    opts.StartBlock,
+		trigger: make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+	}
+	err := store.Get(indexCheckpointKey, &i.next)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, fmt.Errorf("oracle index checkpoint: %w", err)
+	}
+	err = store.Iterate(indexPrefix, func(key, value []byte) (bool, error) {
+		if !strings.HasPrefix(string(key), indexPrefix) {
+			return true, nil
+		}
+		var overlays []boson.Address
+		if err := json.Unmarshal(value, &overlays); err != nil {
+			return true, err
+		}
+		cid, err := hex.DecodeString(strings.TrimPrefix(string(key), indexPrefix))
+		if err != nil {
+			return true, err
+		}
+		i.cids[string(cid)] = overlays
+		return false, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("oracle index: %w", err)
+	}
+	i.metrics.Cids.Set(float64(len(i.cids)))
+	return i, nil
+}
+
+// Start polls the chain in the background.
+func (i *Index) Start() {
+	i.wg.Add(1)
+	go i.run()
+}
+
+func (i *Index) run() {
+	defer i.wg.Done()
+	ticker := time.NewTicker(i.opts.Interval)
+	defer ticker.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-i.quit
+		cancel()
+	}()
+
+	for {
+		if err := i.Sync(ctx); err != nil && ctx.Err() == nil {
+			i.logger.Debugf("oracle index: chain %d: %v", i.chainID, err)
+		}
+		select {
+		case <-i.quit:
+			return
+		case <-ticker.C:
+		case <-i.trigger:
+		}
+	}
+}
+
+// Sync indexes the calls up to the confirmed head of the chain.
+func (i *Index) Sync(ctx context.Context) (err error) {
+	defer func() {
+		i.mu.Lock()
+		i.lastErr = err
+		i.mu.Unlock()
+		if err != nil {
+			i.metrics.SyncErrors.Inc()
+		}
+	}()
+	if atomic.CompareAndSwapInt32(&i.resync, 1, 0) {
+		if err := i.reset(); err != nil {
+			return fmt.Errorf("reset: %w", err)
+		}
+	}
+
+	head, err := i.backend.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("block number: %w", err)
+	}
+	i.mu.Lock()
+	i.head = head
+	next := i.next
+	i.mu.Unlock()
+	i.metrics.HeadBlock.Set(float64(head))
+
+	for head >= i.opts.Confirmations && next <= head-i.opts.Confirmations {
+		if atomic.LoadInt32(&i.resync) == 1 {
+			return nil
+		}
+		to := next + i.opts.BlockRange - 1
+		if last := head - i.opts.Confirmations; to > last {
+			to = last
+		}
+		for n := next; n <= to; n++ {
+			block, err := i.backend.BlockByNumber(ctx, new(big.Int).SetUint64(n))
+			if err != nil {
+				return fmt.Errorf("block %d: %w", n, err)
+			}
+			for _, tx := range block.Transactions() {
+				if err := i.applyTransaction(ctx, tx); err != nil {
+					return fmt.Errorf("apply transaction %s: %w", tx.Hash(), err)
+				}
+			}
+		}
+		next = to + 1
+		if err := i.store.Put(indexCheckpointKey, next); err != nil {
+			return fmt.Errorf("checkpoint: %w", err)
+		}
+		i.mu.Lock()
+		i.next = next
+		i.mu.Unlock()
+		i.metrics.SyncedBlock.Set(float64(to))
+	}
+
+	i.mu.Lock()
+	i.synced = true
+	i.lastSync = time.Now()
+	i.mu.Unlock()
+	i.metrics.LastSyncTime.SetToCurrentTime()
+	if head >= next {
+		i.metrics.LagBlocks.Set(float64(head + 1 - next))
+	} else {
+		i.metrics.LagBlocks.Set(0)
+	}
+	return nil
+}
+
+// applyTransaction applies the call of the transaction to the contract, if
+// it succeeded. The contract emits no events to follow instead.
+func (i *Index) applyTransaction(ctx context.Context, tx *types.Transaction) error {
+	data := tx.Data()
+	if tx.To() == nil || *tx.To() != i.address || len(data) < 4 {
+		return nil
+	}
+	method, err := oracleABI.MethodById(data[:4])
+	if err != nil || (method.Name != "set" && method.Name != "remove" && method.Name != "clear") {
+		return nil
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil // the contract rejects the call
+	}
+	receipt, err := i.backend.TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		return fmt.Errorf("receipt: %w", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil
+	}
+
+	cid := args[0].([32]byte)
+	var overlay boson.Address
+	if len(args) > 1 {
+		addr := args[1].([32]byte)
+		overlay = boson.NewAddress(addr[:])
+	}
+	return i.apply(method.Name, cid[:], overlay)
+}
+
+// apply changes the overlays registered for the cid as the contract method
+// does: set registers the overlay, remove removes it and clear removes them
+// all.
+func (i *Index) apply(method string, cid []byte, overlay boson.Address) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	overlays := i.cids[string(cid)]
+	index := -1
+	for k, o := range overlays {
+		if o.Equal(overlay) {
+			index = k
+			break
+		}
+	}
+	switch method {
+	case "set":
+		if index >= 0 {
+			return nil
+		}
+		overlays = append(overlays, overlay)
+	case "remove":
+		if index < 0 {
+			return nil
+		}
+		// the contract moves the last overlay to the removed one
+		overlays[index] = overlays[len(overlays)-1]
+		overlays = overlays[:len(overlays)-1]
+	case "clear":
+		if len(overlays) == 0 {
+			return nil
+		}
+		overlays = nil
+	default:
+		return nil
+	}
+	i.metrics.Calls.Inc()
+
+	key := indexPrefix + hex.EncodeToString(cid)
+	if len(overlays) == 0 {
+		delete(i.cids, string(cid))
+		i.metrics.Cids.Set(float64(len(i.cids)))
+		return i.store.Delete(key)
+	}
+	i.cids[string(cid)] = overlays
+	i.metrics.Cids.Set(float64(len(i.cids)))
+	return i.store.Put(key, overlays)
+}
+
+// reset forgets everything indexed, to index the calls again from the start
+// block.
+func (i *Index) reset() error {
+	var keys []string
+	err := i.store.Iterate(indexPrefix, func(key, _ []byte) (bool, error) {
+		if !strings.HasPrefix(string(key), indexPrefix) {
+			return true, nil
+		}
+		keys = append(keys, string(key))
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := i.store.Delete(key); err != nil {
+			return err
+		}
+	}
+	if err := i.store.Delete(indexCheckpointKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.cids = make(map[string][]boson.Address)
+	i.next = i.opts.StartBlock
+	i.synced = false
+	i.metrics.Cids.Set(0)
+	return nil
+}
+
+// Resync makes the index forget everything indexed and index the calls again
+// from the start block in the background. The contract answers the lookups
+// until the index catches up.
+func (i *Index) Resync() {
+	atomic.StoreInt32(&i.resync, 1)
+	select {
+	case i.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Nodes returns the overlays registered for the cid, and whether the index
+// caught up with the chain to answer the lookup.
+func (i *Index) Nodes(cid []byte) ([]boson.Address, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if !i.synced || atomic.LoadInt32(&i.resync) == 1 {
+		return nil, false
+	}
+	i.metrics.Lookups.Inc()
+	overlays := i.cids[string(cid)]
+	return append(make([]boson.Address, 0, len(overlays)), overlays...), true
+}
+
+// Status tells how fresh the index is.
+func (i *Index) Status() IndexStatus {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	status := IndexStatus{
+		ChainID:  i.chainID,
+		Contract: i.address,
+		Synced:   i.synced,
+		Head:     i.head,
+		LastSync: i.lastSync,
+		Cids:     len(i.cids),
+	}
+	if i.next > 0 {
+		status.Block = i.next - 1
+	}
+	if i.head >= i.next {
+		status.Lag = i.head + 1 - i.next
+	}
+	if i.lastErr != nil {
+		status.Error = i.lastErr.Error()
+	}
+	return status
+}
+
+func (i *Index) Close() error {
+	close(i.quit)
+	i.wg.Wait()
+	return nil
+}
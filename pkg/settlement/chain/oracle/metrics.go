@@ -0,0 +1,88 @@
+package oracle
+
+import (
+	"strconv"
+
+	m "github.com/FavorLabs/favorX/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type indexMetrics struct {
+	SyncedBlock  prometheus.Gauge
+	HeadBlock    prometheus.Gauge
+	LagBlocks    prometheus.Gauge
+	LastSyncTime prometheus.Gauge
+	Cids         prometheus.Gauge
+	Calls        prometheus.Counter
+	Lookups      prometheus.Counter
+	SyncErrors   prometheus.Counter
+}
+
+func newIndexMetrics(chainID int64) indexMetrics {
+	subsystem := "oracle_index"
+	// the nodes settling on several chains index the oracle of each
+	labels := prometheus.Labels{"chain_id": strconv.FormatInt(chainID, 10)}
+
+	return indexMetrics{
+		SyncedBlock: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   m.Namespace,
+			Subsystem:   subsystem,
+			Name:        "synced_block",
+			Help:        "Last block the oracle contract calls are indexed up to.",
+			ConstLabels: labels,
+		}),
+		HeadBlock: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   m.Namespace,
+			Subsystem:   subsystem,
+			Name:        "head_block",
+			Help:        "Last block of the chain seen by the oracle index.",
+			ConstLabels: labels,
+		}),
+		LagBlocks: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   m.Namespace,
+			Subsystem:   subsystem,
+			Name:        "lag_blocks",
+			Help:        "Blocks the oracle index is behind the head of the chain.",
+			ConstLabels: labels,
+		}),
+		LastSyncTime: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   m.Namespace,
+			Subsystem:   subsystem,
+			Name:        "last_sync_timestamp",
+			Help:        "Unix time the oracle index last caught up with the chain.",
+			ConstLabels: labels,
+		}),
+		Cids: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   m.Namespace,
+			Subsystem:   subsystem,
+			Name:        "cids",
+			Help:        "Number of cids with overlays registered in the oracle index.",
+			ConstLabels: labels,
+		}),
+		Calls: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   m.Namespace,
+			Subsystem:   subsystem,
+			Name:        "calls",
+			Help:        "Number of oracle contract calls changing the index.",
+			ConstLabels: labels,
+		}),
+		Lookups: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   m.Namespace,
+			Subsystem:   subsystem,
+			Name:        "lookups",
+			Help:        "Number of lookups answered by the oracle index.",
+			ConstLabels: labels,
+		}),
+		SyncErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   m.Namespace,
+			Subsystem:   subsystem,
+			Name:        "sync_errors",
+			Help:        "Number of failed polls of the oracle contract calls.",
+			ConstLabels: labels,
+		}),
+	}
+}
+
+func (i *Index) Metrics() []prometheus.Collector {
+	return m.PrometheusCollectorsFromFields(i.metrics)
+}
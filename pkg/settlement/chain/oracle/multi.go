@@ -0,0 +1,54 @@
+package oracle
+
+import (
+	"sync"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain"
+)
+
+// MultiResolver looks the source nodes of the cids up on the oracles of
+// several chains and merges them. The cids are registered on the oracle of
+// the preferred chain, the first one.
+type MultiResolver struct {
+	chain.Resolver
+	others []chain.Resolver
+}
+
+// NewMultiResolver returns the resolver looking up on the oracles, the
+// oracle itself if there is just one.
+func NewMultiResolver(preferred chain.Resolver, others ...chain.Resolver) chain.Resolver {
+	if len(others) == 0 {
+		return preferred
+	}
+	return &MultiResolver{Resolver: preferred, others: others}
+}
+
+// GetNodesFromCid returns the source nodes registered on any of the oracles,
+// the ones of the preferred chain first.
+func (m *MultiResolver) GetNodesFromCid(cid []byte) []boson.Address {
+	resolvers := append([]chain.Resolver{m.Resolver}, m.others...)
+	results := make([][]boson.Address, len(resolvers))
+	var wg sync.WaitGroup
+	for i, resolver := range resolvers {
+		wg.Add(1)
+		go func(i int, resolver chain.Resolver) {
+			defer wg.Done()
+			results[i] = resolver.GetNodesFromCid(cid)
+		}(i, resolver)
+	}
+	wg.Wait()
+
+	seen := make(map[string]struct{})
+	overs := make([]boson.Address, 0)
+	for _, result := range results {
+		for _, overlay := range result {
+			if _, ok := seen[overlay.ByteString()]; ok {
+				continue
+			}
+			seen[overlay.ByteString()] = struct{}{}
+			overs = append(overs, overlay)
+		}
+	}
+	return overs
+}
@@ -0,0 +1,40 @@
+package oracle_test
+
+import (
+	"testing"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/boson/test"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/oracle"
+)
+
+type resolverMock struct {
+	chain.Resolver
+	nodes []boson.Address
+}
+
+func (m *resolverMock) GetNodesFromCid([]byte) []boson.Address {
+	return m.nodes
+}
+
+func TestMultiResolver(t *testing.T) {
+	a, b, c := test.RandomAddress(), test.RandomAddress(), test.RandomAddress()
+	preferred := &resolverMock{nodes: []boson.Address{a, b}}
+
+	if r := oracle.NewMultiResolver(preferred); r != preferred {
+		t.Fatal("got a multi resolver for a single oracle")
+	}
+
+	r := oracle.NewMultiResolver(preferred, &resolverMock{nodes: []boson.Address{b, c}}, &resolverMock{})
+	got := r.GetNodesFromCid(nil)
+	want := []boson.Address{a, b, c}
+	if len(got) != len(want) {
+		t.Fatalf("got nodes %v, want %v", got, want)
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Fatalf("got nodes %v, want %v", got, want)
+		}
+	}
+}
@@ -23,6 +23,8 @@ type TxRequest struct {
 	GasPrice *big.Int        // gas price or nil if suggested gas price should be used
 	GasLimit uint64          // gas limit or 0 if it should be estimated
 	Value    *big.Int        // amount of wei to send
+
+	Description string // shown when listing the pending transactions
 }
 
 type AllRequest struct {
@@ -76,7 +78,7 @@ type Traffic interface {
 
 	TransAmount(beneficiary, recipient common.Address) (*big.Int, error)
 
-	CashChequeBeneficiary(ctx context.Context, peer boson.Address, beneficiary, recipient common.Address, cumulativePayout *big.Int, signature []byte) (*types.Transaction, error)
+	CashChequeBeneficiary(ctx context.Context, peer boson.Address, beneficiary, recipient common.Address, cumulativePayout *big.Int, signature []byte) (common.Hash, error)
 }
 
 // Service is the service to send transactions. It takes care of gas price, gas
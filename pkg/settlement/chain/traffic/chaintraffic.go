@@ -2,7 +2,6 @@ package traffic
 
 import (
 	"context"
-	"errors"
 	"math/big"
 	"sync"
 	"time"
@@ -11,24 +10,26 @@ import (
 	"github.com/FavorLabs/favorX/pkg/crypto"
 	"github.com/FavorLabs/favorX/pkg/logging"
 	"github.com/FavorLabs/favorX/pkg/settlement/chain"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/transaction"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
+var trafficABI = transaction.ParseABIUnchecked(TrafficABI)
+
 type ChainTraffic struct {
 	sync.Mutex
 	logger             logging.Logger
 	signer             crypto.Signer
 	chainID            *big.Int
-	backend            *ethclient.Client
+	backend            transaction.Backend
 	traffic            *Traffic
+	address            common.Address
 	transactionService chain.Transaction
 	commonService      chain.Common
 }
 
-func NewServer(logger logging.Logger, chainID *big.Int, backend *ethclient.Client, signer crypto.Signer,
+func NewServer(logger logging.Logger, chainID *big.Int, backend transaction.Backend, signer crypto.Signer,
 	transactionService chain.Transaction, address string, commonService chain.Common) (chain.Traffic, error) {
 
 	traffic, err := NewTraffic(common.HexToAddress(address), backend)
@@ -41,6 +42,7 @@ func NewServer(logger logging.Logger, chainID *big.Int, backend *ethclient.Clien
 		signer:             signer,
 		chainID:            chainID,
 		traffic:            traffic,
+		address:            common.HexToAddress(address),
 		backend:            backend,
 		transactionService: transactionService,
 		commonService:      commonService,
@@ -94,42 +96,21 @@ func (chainTraffic *ChainTraffic) TransAmount(beneficiary, recipient common.Addr
 	return chainTraffic.traffic.TransTraffic(opts, beneficiary, recipient)
 }
 
-func (chainTraffic *ChainTraffic) CashChequeBeneficiary(ctx context.Context, peer boson.Address, beneficiary, recipient common.Address, cumulativePayout *big.Int, signature []byte) (tx *types.Transaction, err error) {
-	chainTraffic.Lock()
-	defer chainTraffic.Unlock()
+func (chainTraffic *ChainTraffic) CashChequeBeneficiary(ctx context.Context, peer boson.Address, beneficiary, recipient common.Address, cumulativePayout *big.Int, signature []byte) (hash common.Hash, err error) {
 	defer func() {
 		if err == nil {
-			chainTraffic.commonService.SyncTransaction(chain.TRAFFIC, peer.String(), tx.Hash().String())
+			chainTraffic.commonService.SyncTransaction(chain.TRAFFIC, peer.String(), hash.String())
 		}
 	}()
-	if chainTraffic.commonService.IsTransaction() {
-		return nil, errors.New("existing chain transaction")
-	}
-	nonce, err := chainTraffic.transactionService.NextNonce(ctx)
-	if err != nil {
-		return nil, err
-	}
-	ctx1, cancel1 := context.WithTimeout(ctx, 2*time.Second)
-	defer cancel1()
-	gasPrice, err := chainTraffic.backend.SuggestGasPrice(ctx1)
+	data, err := trafficABI.Pack("cashChequeBeneficiary", beneficiary, recipient, cumulativePayout, signature)
 	if err != nil {
-		return nil, err
-	}
-
-	ctx2, cancel2 := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel2()
-	opts := &bind.TransactOpts{
-		From: beneficiary,
-		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
-			if address != beneficiary {
-				return nil, bind.ErrNotAuthorized
-			}
-			return chainTraffic.signer.SignTx(tx, chainTraffic.chainID)
-		},
-		GasLimit: 1000000,
-		GasPrice: gasPrice,
-		Context:  ctx2,
-		Nonce:    new(big.Int).SetUint64(nonce),
+		return common.Hash{}, err
 	}
-	return chainTraffic.traffic.CashChequeBeneficiary(opts, beneficiary, recipient, cumulativePayout, signature)
+	return chainTraffic.transactionService.Send(ctx, &chain.TxRequest{
+		To:          &chainTraffic.address,
+		Data:        data,
+		GasLimit:    1000000,
+		Value:       big.NewInt(0),
+		Description: "cash cheque of " + beneficiary.String(),
+	})
 }
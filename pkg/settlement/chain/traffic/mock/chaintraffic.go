@@ -8,7 +8,6 @@ import (
 	"github.com/FavorLabs/favorX/pkg/boson"
 	"github.com/FavorLabs/favorX/pkg/settlement/chain"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
 )
 
 type ChainTrafficMock struct {
@@ -24,7 +23,7 @@ type ChainTrafficMock struct {
 
 	transAmount func(beneficiary, recipient common.Address) (*big.Int, error)
 
-	cashChequeBeneficiary func(ctx context.Context, peer boson.Address, beneficiary common.Address, recipient common.Address, cumulativePayout *big.Int, signature []byte) (*types.Transaction, error)
+	cashChequeBeneficiary func(ctx context.Context, peer boson.Address, beneficiary common.Address, recipient common.Address, cumulativePayout *big.Int, signature []byte) (common.Hash, error)
 }
 
 func (m *ChainTrafficMock) TransferredAddress(address common.Address) ([]common.Address, error) {
@@ -69,11 +68,11 @@ func (m *ChainTrafficMock) TransAmount(beneficiary, recipient common.Address) (*
 	return big.NewInt(0), errors.New("not implemented")
 }
 
-func (m *ChainTrafficMock) CashChequeBeneficiary(ctx context.Context, peer boson.Address, beneficiary, recipient common.Address, cumulativePayout *big.Int, signature []byte) (*types.Transaction, error) {
+func (m *ChainTrafficMock) CashChequeBeneficiary(ctx context.Context, peer boson.Address, beneficiary, recipient common.Address, cumulativePayout *big.Int, signature []byte) (common.Hash, error) {
 	if m.cashChequeBeneficiary != nil {
 		return m.cashChequeBeneficiary(ctx, peer, beneficiary, recipient, cumulativePayout, signature)
 	}
-	return nil, errors.New("not implemented")
+	return common.Hash{}, errors.New("not implemented")
 }
 
 func New(opts ...Option) chain.Traffic {
@@ -125,7 +124,7 @@ func WithTransAmount(f func(beneficiary, recipient common.Address) (*big.Int, er
 	})
 }
 
-func WithCashChequeBeneficiary(f func(ctx context.Context, peer boson.Address, beneficiary common.Address, recipient common.Address, cumulativePayout *big.Int, signature []byte) (*types.Transaction, error)) Option {
+func WithCashChequeBeneficiary(f func(ctx context.Context, peer boson.Address, beneficiary common.Address, recipient common.Address, cumulativePayout *big.Int, signature []byte) (common.Hash, error)) Option {
 	return optionFunc(func(s *ChainTrafficMock) {
 		s.cashChequeBeneficiary = f
 	})
@@ -26,10 +26,15 @@ type backendMock struct {
 	blockNumber        func(ctx context.Context) (uint64, error)
 	headerByNumber     func(ctx context.Context, number *big.Int) (*types.Header, error)
 	balanceAt          func(ctx context.Context, address common.Address, block *big.Int) (*big.Int, error)
+	suggestGasTipCap   func(ctx context.Context) (*big.Int, error)
+	nonceAt            func(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
 }
 
 func (m *backendMock) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
-	panic("implement me")
+	if m.suggestGasTipCap != nil {
+		return m.suggestGasTipCap(ctx)
+	}
+	return nil, errors.New("not implemented")
 }
 
 func (m *backendMock) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
@@ -118,6 +123,13 @@ func (m *backendMock) BalanceAt(ctx context.Context, address common.Address, blo
 	return nil, errors.New("not implemented")
 }
 
+func (m *backendMock) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	if m.nonceAt != nil {
+		return m.nonceAt(ctx, account, blockNumber)
+	}
+	return 0, errors.New("not implemented")
+}
+
 func New(opts ...Option) transaction.Backend {
 	mock := new(backendMock)
 	for _, o := range opts {
@@ -188,3 +200,15 @@ func WithHeaderbyNumberFunc(f func(ctx context.Context, number *big.Int) (*types
 		s.headerByNumber = f
 	})
 }
+
+func WithSuggestGasTipCapFunc(f func(ctx context.Context) (*big.Int, error)) Option {
+	return optionFunc(func(s *backendMock) {
+		s.suggestGasTipCap = f
+	})
+}
+
+func WithNonceAtFunc(f func(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)) Option {
+	return optionFunc(func(s *backendMock) {
+		s.nonceAt = f
+	})
+}
@@ -0,0 +1,13 @@
+package transaction
+
+import "time"
+
+// SetMonitorTiming sets how often the pending transactions are checked and
+// when they are stuck, returning a function restoring the defaults.
+func SetMonitorTiming(interval, stuck time.Duration) (reset func()) {
+	prevInterval, prevStuck := monitorInterval, stuckTimeout
+	monitorInterval, stuckTimeout = interval, stuck
+	return func() {
+		monitorInterval, stuckTimeout = prevInterval, prevStuck
+	}
+}
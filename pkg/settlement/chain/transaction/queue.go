@@ -0,0 +1,380 @@
+package transaction
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/storage"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const storedPrefix = "transaction_stored_"
+
+var (
+	// monitorInterval is how often the pending transactions are checked.
+	monitorInterval = 15 * time.Second
+	// stuckTimeout is how long a transaction is pending before it is
+	// replaced with one paying higher fees.
+	stuckTimeout = 5 * time.Minute
+	// maxBumps is how many times a transaction is replaced automatically
+	// before it is cancelled, freeing its nonce for the transactions after
+	// it. Cancellations are cheap transfers, so they are replaced until one
+	// is mined.
+	maxBumps = 5
+	// bumpPercent is how much the fees of a replacement are raised, the
+	// nodes requiring at least 10% to accept it.
+	bumpPercent int64 = 20
+	// keepFinished is how long the finished transactions are kept.
+	keepFinished = 24 * time.Hour
+)
+
+var (
+	// ErrTransactionNotFound denotes that the transaction is not in the queue.
+	ErrTransactionNotFound = errors.New("transaction not found")
+	// ErrTransactionNotPending denotes that the transaction is no longer
+	// pending, so it cannot be replaced.
+	ErrTransactionNotPending = errors.New("transaction not pending")
+)
+
+// TxStatus is the state of a queued transaction.
+type TxStatus string
+
+const (
+	TxPending   TxStatus = "pending"
+	TxMined     TxStatus = "mined"
+	TxCancelled TxStatus = "cancelled"
+	TxDropped   TxStatus = "dropped"
+)
+
+// StoredTransaction is a transaction sent by the node, with every version
+// replacing it at the same nonce.
+type StoredTransaction struct {
+	Hash          common.Hash     `json:"hash"`                    // hash of the current version
+	Replaced      []common.Hash   `json:"replaced,omitempty"`      // hashes of the replaced versions
+	Cancellations []common.Hash   `json:"cancellations,omitempty"` // hashes of the versions cancelling it
+	Status        TxStatus        `json:"status"`
+	Description   string          `json:"description"`
+	Nonce         uint64          `json:"nonce"`
+	To            *common.Address `json:"to"`
+	Data          []byte          `json:"data"`
+	Value         *big.Int        `json:"value"`
+	GasLimit      uint64          `json:"gasLimit"`
+	GasPrice      *big.Int        `json:"gasPrice,omitempty"`  // set for legacy transactions
+	GasFeeCap     *big.Int        `json:"gasFeeCap,omitempty"` // set for dynamic fee transactions
+	GasTipCap     *big.Int        `json:"gasTipCap,omitempty"`
+	Cancelled     bool            `json:"cancelled"` // the current version cancels it
+	Created       int64           `json:"created"`
+	Sent          int64           `json:"sent"` // when the current version was sent
+	Finished      int64           `json:"finished,omitempty"`
+	Bumps         int             `json:"bumps"`
+}
+
+// hashes returns the hashes of all versions, the current one first.
+func (stx *StoredTransaction) hashes() []common.Hash {
+	hashes := []common.Hash{stx.Hash}
+	for i := len(stx.Replaced) - 1; i >= 0; i-- {
+		hashes = append(hashes, stx.Replaced[i])
+	}
+	return hashes
+}
+
+func (stx *StoredTransaction) isCancellation(hash common.Hash) bool {
+	for _, h := range stx.Cancellations {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *transactionService) storedKey(nonce uint64) string {
+	return fmt.Sprintf("%s%x_%020d", storedPrefix, t.sender, nonce)
+}
+
+func (t *transactionService) getStored(nonce uint64) (*StoredTransaction, error) {
+	stx := new(StoredTransaction)
+	if err := t.store.Get(t.storedKey(nonce), stx); err != nil {
+		return nil, err
+	}
+	return stx, nil
+}
+
+func (t *transactionService) putStored(stx *StoredTransaction) error {
+	return t.store.Put(t.storedKey(stx.Nonce), stx)
+}
+
+// storedTransactions returns the queued transactions ordered by nonce.
+func (t *transactionService) storedTransactions() ([]*StoredTransaction, error) {
+	var txs []*StoredTransaction
+	err := t.store.Iterate(fmt.Sprintf("%s%x_", storedPrefix, t.sender), func(key, value []byte) (bool, error) {
+		stx := new(StoredTransaction)
+		if err := json.Unmarshal(value, stx); err != nil {
+			return true, fmt.Errorf("invalid stored transaction %s: %w", key, err)
+		}
+		txs = append(txs, stx)
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(txs, func(i, j int) bool {
+		return txs[i].Nonce < txs[j].Nonce
+	})
+	return txs, nil
+}
+
+func (t *transactionService) PendingTransactions() ([]*StoredTransaction, error) {
+	txs, err := t.storedTransactions()
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]*StoredTransaction, 0, len(txs))
+	for _, stx := range txs {
+		if stx.Status == TxPending {
+			pending = append(pending, stx)
+		}
+	}
+	return pending, nil
+}
+
+func (t *transactionService) StoredTransaction(txHash common.Hash) (*StoredTransaction, error) {
+	txs, err := t.storedTransactions()
+	if err != nil {
+		return nil, err
+	}
+	for _, stx := range txs {
+		for _, hash := range stx.hashes() {
+			if hash == txHash {
+				return stx, nil
+			}
+		}
+	}
+	return nil, storage.ErrNotFound
+}
+
+func (t *transactionService) SpeedUpTransaction(ctx context.Context, txHash common.Hash, gasPrice *big.Int) (common.Hash, error) {
+	return t.replaceByHash(ctx, txHash, gasPrice, false)
+}
+
+func (t *transactionService) CancelTransaction(ctx context.Context, txHash common.Hash) (common.Hash, error) {
+	return t.replaceByHash(ctx, txHash, nil, true)
+}
+
+func (t *transactionService) replaceByHash(ctx context.Context, txHash common.Hash, gasPrice *big.Int, cancel bool) (common.Hash, error) {
+	t.replaceLock.Lock()
+	defer t.replaceLock.Unlock()
+
+	stx, err := t.StoredTransaction(txHash)
+	if errors.Is(err, storage.ErrNotFound) {
+		return common.Hash{}, ErrTransactionNotFound
+	}
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if stx.Status != TxPending {
+		return common.Hash{}, ErrTransactionNotPending
+	}
+	return t.replace(ctx, stx, gasPrice, cancel)
+}
+
+// replace sends a version of the transaction paying higher fees, at least
+// gasPrice if it is not nil. Once cancelled, every later version is an empty
+// transfer to the node itself.
+func (t *transactionService) replace(ctx context.Context, stx *StoredTransaction, gasPrice *big.Int, cancel bool) (common.Hash, error) {
+	next := *stx
+	next.Cancelled = stx.Cancelled || cancel
+	if next.Cancelled {
+		next.To = &t.sender
+		next.Data = nil
+		next.Value = big.NewInt(0)
+		next.GasLimit = params.TxGas
+	}
+
+	if stx.GasPrice != nil {
+		suggested, err := t.backend.SuggestGasPrice(ctx)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		next.GasPrice = maxBig(bump(stx.GasPrice), suggested, gasPrice)
+	} else {
+		header, err := t.backend.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		suggested, err := t.backend.SuggestGasTipCap(ctx)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		next.GasTipCap = maxBig(bump(stx.GasTipCap), suggested)
+		var feeCap *big.Int
+		if header.BaseFee != nil {
+			feeCap = new(big.Int).Add(new(big.Int).Mul(header.BaseFee, big.NewInt(2)), next.GasTipCap)
+		}
+		next.GasFeeCap = maxBig(bump(stx.GasFeeCap), feeCap, next.GasTipCap, gasPrice)
+	}
+
+	signedTx, err := t.sign(&next)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	t.logger.Tracef("replacing transaction %x with %x at nonce %d", stx.Hash, signedTx.Hash(), stx.Nonce)
+	if err = t.backend.SendTransaction(ctx, signedTx); err != nil {
+		return common.Hash{}, err
+	}
+
+	// the stored transaction keeps describing the original request
+	stx.Replaced = append(stx.Replaced, stx.Hash)
+	stx.Hash = signedTx.Hash()
+	if next.Cancelled {
+		stx.Cancellations = append(stx.Cancellations, stx.Hash)
+	}
+	stx.Cancelled = next.Cancelled
+	stx.GasPrice, stx.GasFeeCap, stx.GasTipCap = next.GasPrice, next.GasFeeCap, next.GasTipCap
+	stx.Sent = time.Now().Unix()
+	stx.Bumps++
+	if err = t.putStored(stx); err != nil {
+		return common.Hash{}, err
+	}
+	return stx.Hash, nil
+}
+
+// monitor periodically checks the pending transactions until the service is
+// closed.
+func (t *transactionService) monitor() {
+	defer t.wg.Done()
+	ticker := time.NewTicker(monitorInterval)
+	defer ticker.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-t.quit
+		cancel()
+	}()
+
+	for {
+		select {
+		case <-t.quit:
+			return
+		case <-ticker.C:
+		}
+		if err := t.checkPending(ctx); err != nil {
+			t.logger.Debugf("transaction: check pending transactions: %v", err)
+		}
+	}
+}
+
+// checkPending updates the state of the pending transactions, replacing the
+// ones that are stuck, and prunes the finished ones. The receipts are looked
+// up without holding any lock, so sending transactions is not held up.
+func (t *transactionService) checkPending(ctx context.Context) error {
+	txs, err := t.storedTransactions()
+	if err != nil {
+		return err
+	}
+	if len(txs) == 0 {
+		return nil
+	}
+	onchainNonce, err := t.backend.NonceAt(ctx, t.sender, nil)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, stx := range txs {
+		if stx.Status != TxPending {
+			if now.Sub(time.Unix(stx.Finished, 0)) > keepFinished {
+				if err := t.store.Delete(t.storedKey(stx.Nonce)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		status := TxPending
+		for _, hash := range stx.hashes() {
+			receipt, err := t.backend.TransactionReceipt(ctx, hash)
+			if err != nil || receipt == nil {
+				continue
+			}
+			status = TxMined
+			if stx.isCancellation(hash) {
+				status = TxCancelled
+			}
+			break
+		}
+		if status == TxPending && onchainNonce > stx.Nonce {
+			// another transaction took the nonce
+			status = TxDropped
+		}
+
+		if err := t.updatePending(ctx, stx, status, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updatePending records the state of the pending transaction, or replaces it
+// if it is stuck, unless it was replaced since it was checked.
+func (t *transactionService) updatePending(ctx context.Context, checked *StoredTransaction, status TxStatus, now time.Time) error {
+	t.replaceLock.Lock()
+	defer t.replaceLock.Unlock()
+
+	stx, err := t.getStored(checked.Nonce)
+	if err != nil {
+		return err
+	}
+	if stx.Status != TxPending || stx.Hash != checked.Hash {
+		return nil
+	}
+
+	if status != TxPending {
+		t.logger.Tracef("transaction %x with nonce %d %s", stx.Hash, stx.Nonce, status)
+		stx.Status = status
+		stx.Finished = now.Unix()
+		return t.putStored(stx)
+	}
+
+	if now.Sub(time.Unix(stx.Sent, 0)) <= stuckTimeout {
+		return nil
+	}
+	cancel := stx.Bumps >= maxBumps
+	if cancel && !stx.Cancelled {
+		t.logger.Debugf("transaction: cancelling transaction %x with nonce %d stuck after %d replacements", stx.Hash, stx.Nonce, stx.Bumps)
+	}
+	if _, err := t.replace(ctx, stx, nil, cancel); err != nil {
+		t.logger.Debugf("transaction: replace stuck transaction %x: %v", stx.Hash, err)
+	}
+	return nil
+}
+
+// bump raises the fee by bumpPercent.
+func bump(fee *big.Int) *big.Int {
+	if fee == nil {
+		return nil
+	}
+	bumped := new(big.Int).Mul(fee, big.NewInt(100+bumpPercent))
+	bumped.Div(bumped, big.NewInt(100))
+	return bumped.Add(bumped, big.NewInt(1))
+}
+
+// maxBig returns the largest of the values that are not nil.
+func maxBig(values ...*big.Int) *big.Int {
+	var max *big.Int
+	for _, v := range values {
+		if v != nil && (max == nil || v.Cmp(max) > 0) {
+			max = v
+		}
+	}
+	if max == nil {
+		return nil
+	}
+	return new(big.Int).Set(max)
+}
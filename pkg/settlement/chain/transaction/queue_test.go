@@ -0,0 +1,274 @@
+package transaction_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/crypto"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/transaction"
+	"github.com/FavorLabs/favorX/pkg/settlement/chain/transaction/backendmock"
+	storemock "github.com/FavorLabs/favorX/pkg/statestore/mock"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// chainMock records the transactions sent and mines the ones it is told to.
+type chainMock struct {
+	mu      sync.Mutex
+	sent    []*types.Transaction
+	mined   map[common.Hash]bool
+	nonce   uint64
+	baseFee *big.Int
+}
+
+func (c *chainMock) backend() transaction.Backend {
+	return backendmock.New(
+		backendmock.WithSendTransactionFunc(func(ctx context.Context, tx *types.Transaction) error {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.sent = append(c.sent, tx)
+			return nil
+		}),
+		backendmock.WithPendingNonceAtFunc(func(ctx context.Context, account common.Address) (uint64, error) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return c.nonce, nil
+		}),
+		backendmock.WithNonceAtFunc(func(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return c.nonce, nil
+		}),
+		backendmock.WithHeaderbyNumberFunc(func(ctx context.Context, number *big.Int) (*types.Header, error) {
+			return &types.Header{BaseFee: c.baseFee}, nil
+		}),
+		backendmock.WithSuggestGasPriceFunc(func(ctx context.Context) (*big.Int, error) {
+			return big.NewInt(10), nil
+		}),
+		backendmock.WithSuggestGasTipCapFunc(func(ctx context.Context) (*big.Int, error) {
+			return big.NewInt(2), nil
+		}),
+		backendmock.WithTransactionReceiptFunc(func(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.mined[txHash] {
+				return &types.Receipt{TxHash: txHash, Status: types.ReceiptStatusSuccessful}, nil
+			}
+			return nil, errors.New("not found")
+		}),
+	)
+}
+
+// mine mines the transaction, taking its nonce.
+func (c *chainMock) mine(hash common.Hash) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mined[hash] = true
+	c.nonce++
+}
+
+func (c *chainMock) last() *types.Transaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sent[len(c.sent)-1]
+}
+
+func newQueueService(t *testing.T, c *chainMock) (transaction.Service, common.Address) {
+	t.Helper()
+	key, err := crypto.GenerateSecp256k1Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := crypto.NewDefaultSigner(key)
+	sender, err := signer.EthereumAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := transaction.NewService(logging.New(ioutil.Discard, 0), c.backend(), signer, storemock.NewStateStore(), nil, big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s, sender
+}
+
+func sendRequest(t *testing.T, s transaction.Service) common.Hash {
+	t.Helper()
+	to := common.HexToAddress("0xabcd")
+	hash, err := s.Send(context.Background(), &chain.TxRequest{
+		To:          &to,
+		Data:        []byte{1, 2, 3},
+		GasLimit:    100000,
+		Value:       big.NewInt(0),
+		Description: "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestTransactionDynamicFee(t *testing.T) {
+	c := &chainMock{mined: make(map[common.Hash]bool), nonce: 3, baseFee: big.NewInt(10)}
+	s, _ := newQueueService(t, c)
+
+	hash := sendRequest(t, s)
+	tx := c.last()
+	if tx.Type() != types.DynamicFeeTxType {
+		t.Fatalf("got transaction type %d, want dynamic fee", tx.Type())
+	}
+	if tx.GasTipCap().Cmp(big.NewInt(2)) != 0 || tx.GasFeeCap().Cmp(big.NewInt(22)) != 0 {
+		t.Fatalf("got tip %d and fee cap %d, want 2 and 22", tx.GasTipCap(), tx.GasFeeCap())
+	}
+
+	// the next transaction takes the next nonce before the chain sees the first
+	sendRequest(t, s)
+	if nonce := c.last().Nonce(); nonce != 4 {
+		t.Fatalf("got nonce %d, want 4", nonce)
+	}
+
+	pending, err := s.PendingTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].Hash != hash || pending[0].Description != "test" || pending[0].Status != transaction.TxPending {
+		t.Fatalf("got pending transactions %+v", pending)
+	}
+
+	// replacements raise both the tip and the fee cap
+	replacement, err := s.SpeedUpTransaction(context.Background(), hash, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx = c.last()
+	if tx.Hash() != replacement || tx.Nonce() != 3 {
+		t.Fatalf("replacement %x with nonce %d not sent", replacement, tx.Nonce())
+	}
+	if tx.GasTipCap().Cmp(big.NewInt(2)) <= 0 || tx.GasFeeCap().Cmp(big.NewInt(22)) <= 0 {
+		t.Fatalf("got tip %d and fee cap %d, want them raised", tx.GasTipCap(), tx.GasFeeCap())
+	}
+}
+
+func TestTransactionSpeedUpAndCancel(t *testing.T) {
+	c := &chainMock{mined: make(map[common.Hash]bool)}
+	s, sender := newQueueService(t, c)
+	ctx := context.Background()
+
+	hash := sendRequest(t, s)
+	if price := c.last().GasPrice(); price.Cmp(big.NewInt(10)) != 0 {
+		t.Fatalf("got gas price %d, want the suggested 10", price)
+	}
+
+	spedUp, err := s.SpeedUpTransaction(ctx, hash, big.NewInt(50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price := c.last().GasPrice(); price.Cmp(big.NewInt(50)) != 0 {
+		t.Fatalf("got gas price %d, want 50", price)
+	}
+
+	cancelled, err := s.CancelTransaction(ctx, spedUp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := c.last()
+	if *tx.To() != sender || tx.Value().Sign() != 0 || len(tx.Data()) != 0 || tx.Nonce() != 0 {
+		t.Fatalf("got cancellation to %x with value %d and data %x", tx.To(), tx.Value(), tx.Data())
+	}
+	if tx.GasPrice().Cmp(big.NewInt(50)) <= 0 {
+		t.Fatalf("got gas price %d, want it raised", tx.GasPrice())
+	}
+
+	// every version leads to the same transaction
+	for _, h := range []common.Hash{hash, spedUp, cancelled} {
+		stx, err := s.StoredTransaction(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stx.Hash != cancelled || len(stx.Replaced) != 2 || !stx.Cancelled {
+			t.Fatalf("got stored transaction %+v", stx)
+		}
+	}
+
+	c.mine(cancelled)
+	if _, err := s.WaitForReceipt(ctx, hash); !errors.Is(err, transaction.ErrTransactionCancelled) {
+		t.Fatalf("got error %v, want %v", err, transaction.ErrTransactionCancelled)
+	}
+	if _, err := s.SpeedUpTransaction(ctx, common.HexToHash("0x01"), nil); !errors.Is(err, transaction.ErrTransactionNotFound) {
+		t.Fatalf("got error %v, want %v", err, transaction.ErrTransactionNotFound)
+	}
+}
+
+func TestTransactionMonitor(t *testing.T) {
+	t.Cleanup(transaction.SetMonitorTiming(10*time.Millisecond, 0))
+	c := &chainMock{mined: make(map[common.Hash]bool)}
+	s, _ := newQueueService(t, c)
+
+	hash := sendRequest(t, s)
+	waitFor := func(cond func() bool, what string) {
+		t.Helper()
+		for start := time.Now(); !cond(); time.Sleep(10 * time.Millisecond) {
+			if time.Since(start) > 5*time.Second {
+				t.Fatal(what)
+			}
+		}
+	}
+
+	// the stuck transaction is replaced
+	waitFor(func() bool { return c.last().Hash() != hash }, "stuck transaction not replaced")
+	replacement := c.last()
+	if replacement.Nonce() != 0 || replacement.GasPrice().Cmp(big.NewInt(10)) <= 0 {
+		t.Fatalf("got replacement with nonce %d and gas price %d", replacement.Nonce(), replacement.GasPrice())
+	}
+
+	// a receipt of any version finishes it
+	c.mine(hash)
+	waitFor(func() bool {
+		pending, err := s.PendingTransactions()
+		return err == nil && len(pending) == 0
+	}, "mined transaction still pending")
+	stx, err := s.StoredTransaction(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stx.Status != transaction.TxMined {
+		t.Fatalf("got status %s, want %s", stx.Status, transaction.TxMined)
+	}
+	receipt, err := s.WaitForReceipt(context.Background(), replacement.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.TxHash != hash {
+		t.Fatalf("got receipt of %x, want %x", receipt.TxHash, hash)
+	}
+}
+
+func TestTransactionMonitorCancel(t *testing.T) {
+	t.Cleanup(transaction.SetMonitorTiming(10*time.Millisecond, 0))
+	c := &chainMock{mined: make(map[common.Hash]bool)}
+	s, sender := newQueueService(t, c)
+
+	hash := sendRequest(t, s)
+	// the transaction stuck after every replacement is cancelled
+	for start := time.Now(); c.last().To() == nil || *c.last().To() != sender; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("stuck transaction not cancelled")
+		}
+	}
+	cancellation := c.last()
+	if cancellation.Nonce() != 0 || len(cancellation.Data()) != 0 {
+		t.Fatalf("got cancellation with nonce %d and data %x", cancellation.Nonce(), cancellation.Data())
+	}
+
+	c.mine(cancellation.Hash())
+	if _, err := s.WaitForReceipt(context.Background(), hash); !errors.Is(err, transaction.ErrTransactionCancelled) {
+		t.Fatalf("got error %v, want %v", err, transaction.ErrTransactionCancelled)
+	}
+}
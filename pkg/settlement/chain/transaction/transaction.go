@@ -5,8 +5,10 @@
 package transaction
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"sync"
 	"time"
@@ -18,7 +20,6 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"golang.org/x/net/context"
 )
 
 const (
@@ -29,10 +30,37 @@ var (
 	// ErrTransactionReverted denotes that the sent transaction has been
 	// reverted.
 	ErrTransactionReverted = errors.New("transaction reverted")
+	// ErrTransactionCancelled denotes that the transaction was replaced by
+	// its cancellation.
+	ErrTransactionCancelled = errors.New("transaction cancelled")
+	// ErrTransactionDropped denotes that the nonce of the transaction was
+	// used by another one.
+	ErrTransactionDropped = errors.New("transaction dropped")
 )
 
+// Service is the transaction service of the node. It keeps the transactions
+// it sends in a queue until they are mined, replacing the stuck ones with
+// ones paying higher fees.
+type Service interface {
+	chain.Transaction
+	io.Closer
+	// PendingTransactions returns the transactions that are not mined yet,
+	// ordered by nonce.
+	PendingTransactions() ([]*StoredTransaction, error)
+	// StoredTransaction returns the stored transaction with the hash of one
+	// of its versions.
+	StoredTransaction(txHash common.Hash) (*StoredTransaction, error)
+	// SpeedUpTransaction replaces the pending transaction with one paying
+	// higher fees, at least gasPrice if it is not nil.
+	SpeedUpTransaction(ctx context.Context, txHash common.Hash, gasPrice *big.Int) (common.Hash, error)
+	// CancelTransaction replaces the pending transaction with an empty
+	// transfer to the node itself.
+	CancelTransaction(ctx context.Context, txHash common.Hash) (common.Hash, error)
+}
+
 type transactionService struct {
-	lock sync.Mutex
+	lock        sync.Mutex // held while sending, so the nonces are taken in order
+	replaceLock sync.Mutex // held while changing the sent transactions
 
 	logger        logging.Logger
 	backend       Backend
@@ -41,16 +69,20 @@ type transactionService struct {
 	store         storage.StateStorer
 	chainID       *big.Int
 	commonService chain.Common
+
+	quit chan struct{}
+	wg   sync.WaitGroup
 }
 
-// NewService creates a new transaction service.
-func NewService(logger logging.Logger, backend Backend, signer crypto.Signer, store storage.StateStorer, commonService chain.Common, chainID *big.Int) (chain.Transaction, error) {
+// NewService creates a new transaction service. It monitors the pending
+// transactions until it is closed.
+func NewService(logger logging.Logger, backend Backend, signer crypto.Signer, store storage.StateStorer, commonService chain.Common, chainID *big.Int) (Service, error) {
 	senderAddress, err := signer.EthereumAddress()
 	if err != nil {
 		return nil, err
 	}
 
-	return &transactionService{
+	t := &transactionService{
 		logger:        logger,
 		backend:       backend,
 		signer:        signer,
@@ -58,7 +90,13 @@ func NewService(logger logging.Logger, backend Backend, signer crypto.Signer, st
 		store:         store,
 		chainID:       chainID,
 		commonService: commonService,
-	}, nil
+		quit:          make(chan struct{}),
+	}
+	if backend != nil {
+		t.wg.Add(1)
+		go t.monitor()
+	}
+	return t, nil
 }
 
 // Send creates and signs a transaction based on the request and sends it.
@@ -70,11 +108,11 @@ func (t *transactionService) Send(ctx context.Context, request *chain.TxRequest)
 	if err != nil {
 		return common.Hash{}, err
 	}
-	tx, err := t.prepareTransaction(ctx, request, nonce)
+	stx, err := t.prepareTransaction(ctx, request, nonce)
 	if err != nil {
 		return common.Hash{}, err
 	}
-	signedTx, err := t.signer.SignTx(tx, t.chainID)
+	signedTx, err := t.sign(stx)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -91,6 +129,12 @@ func (t *transactionService) Send(ctx context.Context, request *chain.TxRequest)
 		return common.Hash{}, err
 	}
 
+	stx.Hash = signedTx.Hash()
+	stx.Sent = time.Now().Unix()
+	if err = t.putStored(stx); err != nil {
+		return common.Hash{}, err
+	}
+
 	return signedTx.Hash(), nil
 }
 
@@ -112,22 +156,40 @@ func (t *transactionService) Call(ctx context.Context, request *chain.TxRequest)
 	return data, nil
 }
 
-// WaitForReceipt waits until either the transaction with the given hash has
-// been mined or the context is cancelled.
+// WaitForReceipt waits until either the transaction with the given hash, or
+// one replacing it, has been mined or the context is cancelled.
 func (t *transactionService) WaitForReceipt(ctx context.Context, txHash common.Hash) (receipt *types.Receipt, err error) {
 	defer func() {
-		t.commonService.UpdateStatus(false)
+		if t.commonService != nil {
+			t.commonService.UpdateStatus(false)
+		}
 	}()
 	for {
-		receipt, err := t.backend.TransactionReceipt(ctx, txHash)
-		if receipt != nil {
-			return receipt, nil
+		stx, err := t.StoredTransaction(txHash)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return nil, err
 		}
-		if err != nil {
-			// some node implementations return an error if the transaction is not yet mined
-			t.logger.Tracef("waiting for transaction %x to be mined: %v", txHash, err)
-		} else {
-			t.logger.Tracef("waiting for transaction %x to be mined", txHash)
+		hashes := []common.Hash{txHash}
+		if stx != nil {
+			if stx.Status == TxDropped {
+				return nil, ErrTransactionDropped
+			}
+			hashes = stx.hashes()
+		}
+		for _, hash := range hashes {
+			receipt, err := t.backend.TransactionReceipt(ctx, hash)
+			if receipt != nil {
+				if stx != nil && stx.isCancellation(hash) {
+					return nil, ErrTransactionCancelled
+				}
+				return receipt, nil
+			}
+			if err != nil {
+				// some node implementations return an error if the transaction is not yet mined
+				t.logger.Tracef("waiting for transaction %x to be mined: %v", hash, err)
+			} else {
+				t.logger.Tracef("waiting for transaction %x to be mined", hash)
+			}
 		}
 
 		select {
@@ -138,11 +200,20 @@ func (t *transactionService) WaitForReceipt(ctx context.Context, txHash common.H
 	}
 }
 
-// prepareTransaction creates a signable transaction based on a request.
-func (t *transactionService) prepareTransaction(ctx context.Context, request *chain.TxRequest, nonce uint64) (tx *types.Transaction, err error) {
-	var gasLimit uint64
-	if request.GasLimit == 0 {
-		gasLimit, err = t.backend.EstimateGas(ctx, ethereum.CallMsg{
+// prepareTransaction creates a transaction based on a request.
+func (t *transactionService) prepareTransaction(ctx context.Context, request *chain.TxRequest, nonce uint64) (stx *StoredTransaction, err error) {
+	stx = &StoredTransaction{
+		Status:      TxPending,
+		Description: request.Description,
+		Nonce:       nonce,
+		To:          request.To,
+		Data:        request.Data,
+		Value:       request.Value,
+		GasLimit:    request.GasLimit,
+		Created:     time.Now().Unix(),
+	}
+	if stx.GasLimit == 0 {
+		stx.GasLimit, err = t.backend.EstimateGas(ctx, ethereum.CallMsg{
 			From: t.sender,
 			To:   request.To,
 			Data: request.Data,
@@ -150,34 +221,72 @@ func (t *transactionService) prepareTransaction(ctx context.Context, request *ch
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		gasLimit = request.GasLimit
 	}
 
-	var gasPrice *big.Int
-	if request.GasPrice == nil {
-		gasPrice, err = t.backend.SuggestGasPrice(ctx)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		gasPrice = request.GasPrice
+	if request.GasPrice != nil {
+		stx.GasPrice = request.GasPrice
+		return stx, nil
+	}
+	if err = t.suggestFees(ctx, stx); err != nil {
+		return nil, err
+	}
+	return stx, nil
+}
+
+// suggestFees sets the suggested fees of the transaction, dynamic ones where
+// the chain supports them.
+func (t *transactionService) suggestFees(ctx context.Context, stx *StoredTransaction) error {
+	header, err := t.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if header.BaseFee == nil {
+		stx.GasPrice, err = t.backend.SuggestGasPrice(ctx)
+		return err
+	}
+	tip, err := t.backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return err
 	}
+	stx.GasTipCap = tip
+	// leave room for the base fee to rise over the next blocks
+	stx.GasFeeCap = new(big.Int).Add(new(big.Int).Mul(header.BaseFee, big.NewInt(2)), tip)
+	return nil
+}
 
-	return types.NewTx(&types.LegacyTx{
-		Nonce:    nonce,
-		To:       request.To,
-		Value:    request.Value,
-		Gas:      gasLimit,
-		GasPrice: gasPrice,
-		Data:     request.Data,
-	}), nil
+// sign signs the current version of the transaction.
+func (t *transactionService) sign(stx *StoredTransaction) (*types.Transaction, error) {
+	var tx *types.Transaction
+	if stx.GasPrice != nil {
+		tx = types.NewTx(&types.LegacyTx{
+			Nonce:    stx.Nonce,
+			To:       stx.To,
+			Value:    stx.Value,
+			Gas:      stx.GasLimit,
+			GasPrice: stx.GasPrice,
+			Data:     stx.Data,
+		})
+	} else {
+		tx = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   t.chainID,
+			Nonce:     stx.Nonce,
+			To:        stx.To,
+			Value:     stx.Value,
+			Gas:       stx.GasLimit,
+			GasFeeCap: stx.GasFeeCap,
+			GasTipCap: stx.GasTipCap,
+			Data:      stx.Data,
+		})
+	}
+	return t.signer.SignTx(tx, t.chainID)
 }
 
 func (t *transactionService) nonceKey() string {
 	return fmt.Sprintf("%s%x", noncePrefix, t.sender)
 }
 
+// NextNonce returns the nonce of the next transaction, after the ones sent
+// that the chain may not know about yet.
 func (t *transactionService) NextNonce(ctx context.Context) (uint64, error) {
 	ctx, cance := context.WithTimeout(ctx, 2*time.Second)
 	defer cance()
@@ -185,9 +294,29 @@ func (t *transactionService) NextNonce(ctx context.Context) (uint64, error) {
 	if err != nil {
 		return 0, err
 	}
-	return onchainNonce, nil
+
+	var nonce uint64
+	err = t.store.Get(t.nonceKey(), &nonce)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return 0, err
+	}
+	if onchainNonce > nonce {
+		return onchainNonce, nil
+	}
+	return nonce, nil
 }
 
 func (t *transactionService) putNonce(nonce uint64) error {
 	return t.store.Put(t.nonceKey(), nonce)
 }
+
+// Close stops monitoring the pending transactions.
+func (t *transactionService) Close() error {
+	select {
+	case <-t.quit:
+	default:
+		close(t.quit)
+	}
+	t.wg.Wait()
+	return nil
+}
@@ -28,6 +28,11 @@ func nonceKey(sender common.Address) string {
 	return fmt.Sprintf("transaction_nonce_%x", sender)
 }
 
+// legacyHeader returns the header of a chain without dynamic fees.
+func legacyHeader(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{}, nil
+}
+
 func signerMockForTransaction(signedTx *types.Transaction, sender common.Address, signerChainID *big.Int, t *testing.T) crypto.Signer {
 	return signermock.New(
 		signermock.WithSignTxFunc(func(transaction *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
@@ -119,6 +124,7 @@ func TestTransactionSend(t *testing.T) {
 				backendmock.WithSuggestGasPriceFunc(func(ctx context.Context) (*big.Int, error) {
 					return suggestedGasPrice, nil
 				}),
+				backendmock.WithHeaderbyNumberFunc(legacyHeader),
 				backendmock.WithPendingNonceAtFunc(func(ctx context.Context, account common.Address) (uint64, error) {
 					return nonce - 1, nil
 				}),
@@ -187,6 +193,7 @@ func TestTransactionSend(t *testing.T) {
 				backendmock.WithSuggestGasPriceFunc(func(ctx context.Context) (*big.Int, error) {
 					return suggestedGasPrice, nil
 				}),
+				backendmock.WithHeaderbyNumberFunc(legacyHeader),
 				backendmock.WithPendingNonceAtFunc(func(ctx context.Context, account common.Address) (uint64, error) {
 					return nonce, nil
 				}),
@@ -260,6 +267,7 @@ func TestTransactionSend(t *testing.T) {
 				backendmock.WithSuggestGasPriceFunc(func(ctx context.Context) (*big.Int, error) {
 					return suggestedGasPrice, nil
 				}),
+				backendmock.WithHeaderbyNumberFunc(legacyHeader),
 				backendmock.WithPendingNonceAtFunc(func(ctx context.Context, account common.Address) (uint64, error) {
 					return nextNonce, nil
 				}),
@@ -326,6 +334,7 @@ func TestTransactionSend(t *testing.T) {
 				backendmock.WithSuggestGasPriceFunc(func(ctx context.Context) (*big.Int, error) {
 					return suggestedGasPrice, nil
 				}),
+				backendmock.WithHeaderbyNumberFunc(legacyHeader),
 				backendmock.WithPendingNonceAtFunc(func(ctx context.Context, account common.Address) (uint64, error) {
 					return nonce, nil
 				}),
@@ -364,7 +373,7 @@ func TestTransactionWaitForReceipt(t *testing.T) {
 			}),
 		),
 		signermock.New(),
-		nil,
+		storemock.NewStateStore(),
 		nil,
 		chainID,
 	)
@@ -37,5 +37,20 @@ type Interface interface {
 	GetUnPaidBalance(peer boson.Address) (*big.Int, error)
 }
 
+// PeerBalancer is implemented by the settlements paying the peers from
+// different balances, like the ones settling on several chains.
+type PeerBalancer interface {
+	// PeerAvailableBalance returns the available balance the peer is paid from.
+	PeerAvailableBalance(peer boson.Address) (*big.Int, error)
+}
+
+// TrafficRecorder is implemented by the settlements keeping a ledger of the
+// traffic accounted with the peers.
+type TrafficRecorder interface {
+	// RecordTraffic adds the costs of the traffic the peer served us and of
+	// the traffic we served the peer to the ledger.
+	RecordTraffic(peer boson.Address, credit, debit *big.Int)
+}
+
 // NotifyPaymentFunc is called when a payment from peer was successfully received
 type NotifyPaymentFunc func(peer boson.Address, amount *big.Int) error
@@ -0,0 +1,68 @@
+package multichain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var ErrInvalidChainConfig = errors.New("invalid chain config")
+
+// ChainConfig is a chain settled on besides the one of the chain endpoint.
+type ChainConfig struct {
+	Endpoint            string
+	TrafficContractAddr string
+	OracleContractAddr  string // looked up besides the oracle of the chain endpoint if set
+	OracleStartBlock    uint64 // the oracle contract is indexed from
+}
+
+// parseChainConfig parses a chain config of the format
+// traffic-contract-addr[,oracle-contract-addr[:start-block]]@endpoint.
+func parseChainConfig(cs string) (ChainConfig, error) {
+	i := strings.Index(cs, "@")
+	if i <= 0 || i == len(cs)-1 {
+		return ChainConfig{}, fmt.Errorf("%s: %w", cs, ErrInvalidChainConfig)
+	}
+	cfg := ChainConfig{Endpoint: cs[i+1:]}
+	addrs := strings.Split(cs[:i], ",")
+	if len(addrs) > 2 {
+		return ChainConfig{}, fmt.Errorf("%s: %w", cs, ErrInvalidChainConfig)
+	}
+	if len(addrs) == 2 {
+		if j := strings.Index(addrs[1], ":"); j >= 0 {
+			block, err := strconv.ParseUint(addrs[1][j+1:], 10, 64)
+			if err != nil {
+				return ChainConfig{}, fmt.Errorf("%s: invalid start block: %w", cs, ErrInvalidChainConfig)
+			}
+			cfg.OracleStartBlock = block
+			addrs[1] = addrs[1][:j]
+		}
+	}
+	for _, addr := range addrs {
+		if !common.IsHexAddress(addr) {
+			return ChainConfig{}, fmt.Errorf("%s: invalid contract address %q: %w", cs, addr, ErrInvalidChainConfig)
+		}
+	}
+	cfg.TrafficContractAddr = common.HexToAddress(addrs[0]).String()
+	if len(addrs) == 2 {
+		cfg.OracleContractAddr = common.HexToAddress(addrs[1]).String()
+	}
+	return cfg, nil
+}
+
+// ParseChainConfigs parses the configs of the chains settled on besides the
+// one of the chain endpoint, in the order of preference.
+func ParseChainConfigs(cstrs []string) ([]ChainConfig, error) {
+	var res []ChainConfig
+	for _, cs := range cstrs {
+		cfg, err := parseChainConfig(cs)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, cfg)
+	}
+	return res, nil
+}
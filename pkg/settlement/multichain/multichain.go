@@ -0,0 +1,205 @@
+// Package multichain settles with every peer on the chain negotiated with it,
+// for the nodes settling on several chains at once. The balances, cheques
+// and ledger of every chain are kept by a traffic service of its own.
+package multichain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/rpc"
+	"github.com/FavorLabs/favorX/pkg/settlement"
+	"github.com/FavorLabs/favorX/pkg/settlement/traffic"
+	chequePkg "github.com/FavorLabs/favorX/pkg/settlement/traffic/cheque"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Settlement is the settlement on a chain.
+type Settlement interface {
+	settlement.Interface
+	traffic.ApiInterface
+}
+
+// PeerChainer tells the chain negotiated with a peer, which the traffic
+// protocol does.
+type PeerChainer interface {
+	PeerChain(peer boson.Address) int64
+}
+
+type Service struct {
+	peers       PeerChainer
+	chains      []int64 // in the order of preference
+	settlements map[int64]Settlement
+}
+
+func New(peers PeerChainer) *Service {
+	return &Service{
+		peers:       peers,
+		settlements: make(map[int64]Settlement),
+	}
+}
+
+// Add settles on the chain. The chains are preferred in the order they are
+// added.
+func (s *Service) Add(chainID int64, settlement Settlement) error {
+	if _, ok := s.settlements[chainID]; ok {
+		return fmt.Errorf("chain %d added twice", chainID)
+	}
+	s.chains = append(s.chains, chainID)
+	s.settlements[chainID] = settlement
+	return nil
+}
+
+// peer returns the settlement on the chain negotiated with the peer.
+func (s *Service) peer(peer boson.Address) Settlement {
+	if settlement, ok := s.settlements[s.peers.PeerChain(peer)]; ok {
+		return settlement
+	}
+	return s.settlements[s.chains[0]]
+}
+
+func (s *Service) preferred() Settlement {
+	return s.settlements[s.chains[0]]
+}
+
+func (s *Service) Pay(ctx context.Context, peer boson.Address, paymentThreshold *big.Int) error {
+	return s.peer(peer).Pay(ctx, peer, paymentThreshold)
+}
+
+func (s *Service) TransferTraffic(peer boson.Address) (*big.Int, error) {
+	return s.peer(peer).TransferTraffic(peer)
+}
+
+func (s *Service) RetrieveTraffic(peer boson.Address) (*big.Int, error) {
+	return s.peer(peer).RetrieveTraffic(peer)
+}
+
+func (s *Service) PutRetrieveTraffic(peer boson.Address, traffic *big.Int) error {
+	return s.peer(peer).PutRetrieveTraffic(peer, traffic)
+}
+
+func (s *Service) PutTransferTraffic(peer boson.Address, traffic *big.Int) error {
+	return s.peer(peer).PutTransferTraffic(peer, traffic)
+}
+
+// AvailableBalance returns the available balance on the preferred chain.
+func (s *Service) AvailableBalance() (*big.Int, error) {
+	return s.preferred().AvailableBalance()
+}
+
+// PeerAvailableBalance returns the available balance on the chain negotiated
+// with the peer.
+func (s *Service) PeerAvailableBalance(peer boson.Address) (*big.Int, error) {
+	return s.peer(peer).AvailableBalance()
+}
+
+// RecordTraffic records the traffic in the ledger of the chain negotiated
+// with the peer.
+func (s *Service) RecordTraffic(peer boson.Address, credit, debit *big.Int) {
+	if recorder, ok := s.peer(peer).(settlement.TrafficRecorder); ok {
+		recorder.RecordTraffic(peer, credit, debit)
+	}
+}
+
+func (s *Service) SetNotifyPaymentFunc(notifyPaymentFunc settlement.NotifyPaymentFunc) {
+	for _, settlement := range s.settlements {
+		settlement.SetNotifyPaymentFunc(notifyPaymentFunc)
+	}
+}
+
+func (s *Service) GetPeerBalance(peer boson.Address) (*big.Int, error) {
+	return s.peer(peer).GetPeerBalance(peer)
+}
+
+func (s *Service) GetUnPaidBalance(peer boson.Address) (*big.Int, error) {
+	return s.peer(peer).GetUnPaidBalance(peer)
+}
+
+func (s *Service) LastSentCheque(peer boson.Address) (*chequePkg.Cheque, error) {
+	return s.peer(peer).LastSentCheque(peer)
+}
+
+func (s *Service) LastReceivedCheque(peer boson.Address) (*chequePkg.SignedCheque, error) {
+	return s.peer(peer).LastReceivedCheque(peer)
+}
+
+func (s *Service) CashCheque(ctx context.Context, peer boson.Address) (common.Hash, error) {
+	return s.peer(peer).CashCheque(ctx, peer)
+}
+
+// TrafficCheques lists the cheques of every chain.
+func (s *Service) TrafficCheques() ([]*traffic.TrafficCheque, error) {
+	var cheques []*traffic.TrafficCheque
+	for _, chainID := range s.chains {
+		list, err := s.settlements[chainID].TrafficCheques()
+		if err != nil {
+			return nil, fmt.Errorf("chain %d: %w", chainID, err)
+		}
+		cheques = append(cheques, list...)
+	}
+	return cheques, nil
+}
+
+// Ledger lists the ledger entries of every chain by time.
+func (s *Service) Ledger(filter chequePkg.LedgerFilter) ([]*chequePkg.LedgerEntry, error) {
+	var entries []*chequePkg.LedgerEntry
+	for _, chainID := range s.chains {
+		list, err := s.settlements[chainID].Ledger(filter)
+		if err != nil {
+			return nil, fmt.Errorf("chain %d: %w", chainID, err)
+		}
+		entries = append(entries, list...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries, nil
+}
+
+func (s *Service) LedgerTotals(filter chequePkg.LedgerFilter, period chequePkg.LedgerPeriod) ([]*chequePkg.LedgerTotal, error) {
+	entries, err := s.Ledger(filter)
+	if err != nil {
+		return nil, err
+	}
+	return chequePkg.SumLedger(entries, period)
+}
+
+// Address returns the chain address, the same on every chain as the cheques
+// are signed with the same key.
+func (s *Service) Address() common.Address {
+	return s.preferred().Address()
+}
+
+// TrafficInfo sums the information of every chain, listing it in Chains.
+func (s *Service) TrafficInfo() (*traffic.TrafficInfo, error) {
+	info := traffic.NewTrafficInfo()
+	for _, chainID := range s.chains {
+		chainInfo, err := s.settlements[chainID].TrafficInfo()
+		if err != nil {
+			return nil, fmt.Errorf("chain %d: %w", chainID, err)
+		}
+		info.Balance = new(big.Int).Add(info.Balance, chainInfo.Balance)
+		info.AvailableBalance = new(big.Int).Add(info.AvailableBalance, chainInfo.AvailableBalance)
+		info.TotalSendTraffic = new(big.Int).Add(info.TotalSendTraffic, chainInfo.TotalSendTraffic)
+		info.ReceivedTraffic = new(big.Int).Add(info.ReceivedTraffic, chainInfo.ReceivedTraffic)
+		info.Chains = append(info.Chains, chainInfo)
+	}
+	return info, nil
+}
+
+func (s *Service) TrafficInit() error {
+	for _, chainID := range s.chains {
+		if err := s.settlements[chainID].TrafficInit(); err != nil {
+			return fmt.Errorf("chain %d: %w", chainID, err)
+		}
+	}
+	return nil
+}
+
+// API returns the API of the settlement on the preferred chain.
+func (s *Service) API() rpc.API {
+	return s.preferred().API()
+}
@@ -0,0 +1,177 @@
+package multichain_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/boson/test"
+	"github.com/FavorLabs/favorX/pkg/settlement/multichain"
+	"github.com/FavorLabs/favorX/pkg/settlement/traffic/cheque"
+	"github.com/FavorLabs/favorX/pkg/settlement/traffic/mock"
+	statestore "github.com/FavorLabs/favorX/pkg/statestore/mock"
+)
+
+type peerChains map[string]int64
+
+func (p peerChains) PeerChain(peer boson.Address) int64 {
+	return p[peer.ByteString()]
+}
+
+func newSettlement(chainID int64, paid map[int64]int, opts ...mock.Option) multichain.Settlement {
+	opts = append(opts,
+		mock.WithPay(func(context.Context, boson.Address, *big.Int) error {
+			paid[chainID]++
+			return nil
+		}),
+		mock.WithAvailableBalance(func() (*big.Int, error) {
+			return big.NewInt(chainID * 100), nil
+		}),
+	)
+	return mock.NewSettlement(opts...).(multichain.Settlement)
+}
+
+func TestService(t *testing.T) {
+	peer1, peer2, peer3 := test.RandomAddress(), test.RandomAddress(), test.RandomAddress()
+	peers := peerChains{peer1.ByteString(): 1, peer2.ByteString(): 2, peer3.ByteString(): 3}
+	paid := make(map[int64]int)
+	day := time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	s := multichain.New(peers)
+	if err := s.Add(1, newSettlement(1, paid, mock.WithLedger(func(cheque.LedgerFilter) ([]*cheque.LedgerEntry, error) {
+		return []*cheque.LedgerEntry{
+			{Time: day.Add(2 * time.Hour), Type: cheque.LedgerChequeReceived, Amount: big.NewInt(3), ChainID: 1},
+		}, nil
+	}))); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(2, newSettlement(2, paid, mock.WithLedger(func(cheque.LedgerFilter) ([]*cheque.LedgerEntry, error) {
+		return []*cheque.LedgerEntry{
+			{Time: day.Add(time.Hour), Type: cheque.LedgerChequeReceived, Amount: big.NewInt(4), ChainID: 2},
+		}, nil
+	}))); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(2, newSettlement(2, paid)); err == nil {
+		t.Fatal("added a chain twice")
+	}
+
+	for _, peer := range []boson.Address{peer1, peer2, peer2, peer3} {
+		if err := s.Pay(context.Background(), peer, big.NewInt(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the peer negotiated an unknown chain settles on the preferred one
+	if paid[1] != 2 || paid[2] != 2 {
+		t.Fatalf("got payments per chain %v", paid)
+	}
+
+	balance, err := s.PeerAvailableBalance(peer2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Int64() != 200 {
+		t.Fatalf("got available balance %d of the peer on chain 2, want 200", balance)
+	}
+	if balance, _ = s.AvailableBalance(); balance.Int64() != 100 {
+		t.Fatalf("got available balance %d, want that of the preferred chain", balance)
+	}
+
+	entries, err := s.Ledger(cheque.LedgerFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].ChainID != 2 || entries[1].ChainID != 1 {
+		t.Fatalf("got ledger entries %+v, want the ones of both chains by time", entries)
+	}
+	totals, err := s.LedgerTotals(cheque.LedgerFilter{}, cheque.LedgerDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(totals) != 1 || totals[0].Credit.Int64() != 7 {
+		t.Fatalf("got ledger totals %+v", totals)
+	}
+	if _, err := s.LedgerTotals(cheque.LedgerFilter{}, "week"); !errors.Is(err, cheque.ErrInvalidPeriod) {
+		t.Fatalf("got error %v, want %v", err, cheque.ErrInvalidPeriod)
+	}
+}
+
+func TestParseChainConfigs(t *testing.T) {
+	const (
+		traffic = "0x0000000000000000000000000000000000000001"
+		oracle  = "0x0000000000000000000000000000000000000002"
+	)
+	cfgs, err := multichain.ParseChainConfigs([]string{
+		traffic + "@https://polygon.example.com",
+		traffic + "," + oracle + "@ws://user@okc.example.com",
+		traffic + "," + oracle + ":1200@https://bsc.example.com:8545",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []multichain.ChainConfig{
+		{Endpoint: "https://polygon.example.com", TrafficContractAddr: traffic},
+		{Endpoint: "ws://user@okc.example.com", TrafficContractAddr: traffic, OracleContractAddr: oracle},
+		{Endpoint: "https://bsc.example.com:8545", TrafficContractAddr: traffic, OracleContractAddr: oracle, OracleStartBlock: 1200},
+	}
+	if len(cfgs) != len(want) {
+		t.Fatalf("got configs %+v, want %+v", cfgs, want)
+	}
+	for i := range want {
+		if cfgs[i] != want[i] {
+			t.Fatalf("got config %+v, want %+v", cfgs[i], want[i])
+		}
+	}
+
+	for _, cs := range []string{
+		"https://polygon.example.com",
+		traffic + "@",
+		"0x12@https://polygon.example.com",
+		traffic + "," + oracle + "," + oracle + "@https://polygon.example.com",
+		traffic + "," + oracle + ":latest@https://polygon.example.com",
+	} {
+		if _, err := multichain.ParseChainConfigs([]string{cs}); !errors.Is(err, multichain.ErrInvalidChainConfig) {
+			t.Fatalf("parse %q: got error %v, want %v", cs, err, multichain.ErrInvalidChainConfig)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := statestore.NewStateStore()
+	chain1, chain2 := multichain.NewStore(store, 1), multichain.NewStore(store, 2)
+
+	if err := chain1.Put("traffic_cheque_a", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := chain2.Put("traffic_cheque_a", "2"); err != nil {
+		t.Fatal(err)
+	}
+	var v string
+	if err := chain1.Get("traffic_cheque_a", &v); err != nil || v != "1" {
+		t.Fatalf("got %q, %v from chain 1, want 1", v, err)
+	}
+	if err := store.Get("traffic_cheque_a", &v); err == nil {
+		t.Fatal("the state of a chain is in the unprefixed store")
+	}
+
+	var keys []string
+	if err := chain2.Iterate("traffic_cheque_", func(key, _ []byte) (bool, error) {
+		keys = append(keys, string(key))
+		return false, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "traffic_cheque_a" {
+		t.Fatalf("got keys %v iterating chain 2, want the unprefixed key", keys)
+	}
+
+	if err := chain1.Delete("traffic_cheque_a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := chain2.Get("traffic_cheque_a", &v); err != nil || v != "2" {
+		t.Fatalf("got %q, %v from chain 2 after deleting from chain 1, want 2", v, err)
+	}
+}
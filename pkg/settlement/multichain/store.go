@@ -0,0 +1,45 @@
+package multichain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FavorLabs/favorX/pkg/storage"
+)
+
+// chainStore keeps the state of the settlement on a chain apart from the
+// ones on the other chains by prefixing its keys with the chain.
+type chainStore struct {
+	storage.StateStorer
+	prefix string
+}
+
+// NewStore returns the store of the settlement on the chain. The settlement on
+// the chain endpoint keeps using the store unprefixed, so the state of the
+// nodes settling on one chain stays where it was.
+func NewStore(store storage.StateStorer, chainID int64) storage.StateStorer {
+	return &chainStore{StateStorer: store, prefix: fmt.Sprintf("chain_%d_", chainID)}
+}
+
+func (s *chainStore) Get(key string, i interface{}) error {
+	return s.StateStorer.Get(s.prefix+key, i)
+}
+
+func (s *chainStore) Put(key string, i interface{}) error {
+	return s.StateStorer.Put(s.prefix+key, i)
+}
+
+func (s *chainStore) Delete(key string) error {
+	return s.StateStorer.Delete(s.prefix + key)
+}
+
+func (s *chainStore) Iterate(prefix string, iterFunc storage.StateIterFunc) error {
+	return s.StateStorer.Iterate(s.prefix+prefix, func(key, value []byte) (bool, error) {
+		return iterFunc([]byte(strings.TrimPrefix(string(key), s.prefix)), value)
+	})
+}
+
+// Close leaves the store open, it is shared with the other chains.
+func (s *chainStore) Close() error {
+	return nil
+}
@@ -314,6 +314,14 @@ func (s *Service) TrafficCheques() ([]*traffic.TrafficCheque, error) {
 	return trafficList, nil
 }
 
+func (s *Service) Ledger(_ chequePkg.LedgerFilter) ([]*chequePkg.LedgerEntry, error) {
+	return []*chequePkg.LedgerEntry{}, nil
+}
+
+func (s *Service) LedgerTotals(_ chequePkg.LedgerFilter, _ chequePkg.LedgerPeriod) ([]*chequePkg.LedgerTotal, error) {
+	return []*chequePkg.LedgerTotal{}, nil
+}
+
 func (s *Service) Address() common.Address {
 	return s.address
 }
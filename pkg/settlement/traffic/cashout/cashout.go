@@ -0,0 +1,303 @@
+// Package cashout cashes the received traffic cheques on a schedule, the
+// ones paying the most first, as long as cashing them is worth the gas.
+//
+// The cheques pay in token units, while the gas is paid in wei. The gas cost
+// is counted against the cheques only at the rate the node is configured
+// with; otherwise the cheques are weighed on their own and the gas cost is
+// only capped by the daily budget.
+package cashout
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/settlement/traffic"
+	"github.com/FavorLabs/favorX/pkg/storage"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	budgetKey = "cashout_gas_spent"
+
+	// DefaultGasLimit is the gas a cashing transaction is estimated to use.
+	DefaultGasLimit = 150000
+
+	maxBackoff = 24 * time.Hour
+)
+
+// Cashier cashes the cheques, which the traffic service does.
+type Cashier interface {
+	UncashedCheques() ([]traffic.UncashedCheque, error)
+	CashCheque(ctx context.Context, peer boson.Address) (common.Hash, error)
+	PublishCashOut(msg traffic.CashOutStatus)
+}
+
+// GasPricer suggests the gas price of the cashing transactions.
+type GasPricer interface {
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+}
+
+// Options configure the scheduler.
+type Options struct {
+	// Interval is how often the cheques are evaluated.
+	Interval time.Duration
+	// MinProfit is the least amount of token units a cheque has to pay, over
+	// the estimated gas cost if GasRate is set, to be cashed.
+	MinProfit *big.Int
+	// GasRate is how many token units one wei of gas cost is worth. The gas
+	// cost is not counted against the cheques if nil.
+	GasRate *big.Rat
+	// DailyGasBudget caps the estimated gas cost spent per day, none if nil.
+	DailyGasBudget *big.Int
+	// GasLimit is the gas a cashing transaction is estimated to use,
+	// DefaultGasLimit if zero.
+	GasLimit uint64
+}
+
+// Scheduler periodically cashes the cheques worth cashing.
+type Scheduler struct {
+	logger    logging.Logger
+	cashier   Cashier
+	gasPricer GasPricer
+	store     storage.StateStorer
+	opts      Options
+	now       func() time.Time
+
+	mu        sync.Mutex
+	attempts  map[common.Address]*attempt
+	decisions map[common.Address]string // last skip published per peer
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// attempt is the last cashing of the cheque of a peer.
+type attempt struct {
+	cashed   *big.Int // cashed payout when the cheque was cashed
+	failures int
+	next     time.Time // when cashing it may be retried
+}
+
+type budget struct {
+	Day   string   `json:"day"`
+	Spent *big.Int `json:"spent"`
+}
+
+// New creates a scheduler cashing the cheques of the cashier.
+func New(logger logging.Logger, cashier Cashier, gasPricer GasPricer, store storage.StateStorer, opts Options) *Scheduler {
+	if opts.MinProfit == nil {
+		opts.MinProfit = big.NewInt(0)
+	}
+	if opts.GasLimit == 0 {
+		opts.GasLimit = DefaultGasLimit
+	}
+	return &Scheduler{
+		logger:    logger,
+		cashier:   cashier,
+		gasPricer: gasPricer,
+		store:     store,
+		opts:      opts,
+		now:       time.Now,
+		attempts:  make(map[common.Address]*attempt),
+		decisions: make(map[common.Address]string),
+		quit:      make(chan struct{}),
+	}
+}
+
+// Start evaluates the cheques every interval until the scheduler is closed.
+func (s *Scheduler) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.opts.Interval)
+		defer ticker.Stop()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-s.quit
+			cancel()
+		}()
+
+		for {
+			select {
+			case <-s.quit:
+				return
+			case <-ticker.C:
+			}
+			if err := s.round(ctx); err != nil {
+				s.logger.Errorf("cashout: %v", err)
+			}
+		}
+	}()
+}
+
+// Close stops the scheduler.
+func (s *Scheduler) Close() error {
+	close(s.quit)
+	s.wg.Wait()
+	return nil
+}
+
+type candidate struct {
+	traffic.UncashedCheque
+	profit *big.Int
+}
+
+// round cashes the cheques worth cashing, the most profitable first, within
+// the gas budget of the day.
+func (s *Scheduler) round(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cheques, err := s.cashier.UncashedCheques()
+	if err != nil {
+		return err
+	}
+	cost := big.NewInt(0)
+	if s.gasPricer != nil {
+		gasPrice, err := s.gasPricer.SuggestGasPrice(ctx)
+		if err != nil {
+			return err
+		}
+		cost.Mul(gasPrice, new(big.Int).SetUint64(s.opts.GasLimit))
+	}
+	tokenCost := s.tokenCost(cost)
+
+	now := s.now()
+	seen := make(map[common.Address]bool, len(cheques))
+	candidates := make([]candidate, 0, len(cheques))
+	for _, c := range cheques {
+		seen[c.ChainAddress] = true
+		if c.InProgress {
+			continue
+		}
+		if a, ok := s.attempts[c.ChainAddress]; ok {
+			if c.Cashed.Cmp(a.cashed) > 0 {
+				// the last cashing went through
+				delete(s.attempts, c.ChainAddress)
+			} else if a.next.IsZero() {
+				s.failed(c, a, errors.New("cashing transaction failed"))
+				continue
+			} else if now.Before(a.next) {
+				continue
+			}
+		}
+		uncashed := new(big.Int).Sub(c.Payout, c.Cashed)
+		profit := uncashed.Sub(uncashed, tokenCost)
+		if profit.Cmp(s.opts.MinProfit) < 0 {
+			s.skipped(c, "below minimum profit")
+			continue
+		}
+		candidates = append(candidates, candidate{UncashedCheque: c, profit: profit})
+	}
+	for address := range s.attempts {
+		if !seen[address] {
+			delete(s.attempts, address)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].profit.Cmp(candidates[j].profit) > 0
+	})
+
+	spent, err := s.spent(now)
+	if err != nil {
+		return err
+	}
+	for _, c := range candidates {
+		if s.opts.DailyGasBudget != nil && new(big.Int).Add(spent.Spent, cost).Cmp(s.opts.DailyGasBudget) > 0 {
+			s.skipped(c.UncashedCheque, "daily gas budget spent")
+			continue
+		}
+		a := s.attempts[c.ChainAddress]
+		if a == nil {
+			a = &attempt{}
+			s.attempts[c.ChainAddress] = a
+		}
+		a.cashed = c.Cashed
+		a.next = time.Time{}
+
+		hash, err := s.cashier.CashCheque(ctx, c.Peer)
+		if err != nil {
+			s.failed(c.UncashedCheque, a, err)
+			continue
+		}
+		spent.Spent.Add(spent.Spent, cost)
+		if err := s.store.Put(budgetKey, spent); err != nil {
+			return err
+		}
+		delete(s.decisions, c.ChainAddress)
+		s.logger.Infof("cashout: cashing cheque of peer %s paying %d, transaction %s", c.Peer, c.profit, hash)
+		go s.cashier.PublishCashOut(traffic.CashOutStatus{
+			Overlay:  c.Peer,
+			Decision: traffic.CashOutCashing,
+			TxHash:   &hash,
+		})
+	}
+	return nil
+}
+
+// tokenCost converts the gas cost in wei to token units at the configured
+// rate, rounding up. It is zero without a rate.
+func (s *Scheduler) tokenCost(cost *big.Int) *big.Int {
+	if s.opts.GasRate == nil {
+		return big.NewInt(0)
+	}
+	r := new(big.Rat).Mul(new(big.Rat).SetInt(cost), s.opts.GasRate)
+	q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if m.Sign() > 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
+
+// failed schedules a retry of the cheque, backing off on every failure.
+func (s *Scheduler) failed(c traffic.UncashedCheque, a *attempt, err error) {
+	a.failures++
+	backoff := s.opts.Interval << (a.failures - 1)
+	if backoff <= 0 || backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	a.next = s.now().Add(backoff)
+	delete(s.decisions, c.ChainAddress)
+	s.logger.Debugf("cashout: cash cheque of peer %s: %v, retrying in %s", c.Peer, err, backoff)
+	go s.cashier.PublishCashOut(traffic.CashOutStatus{
+		Overlay:  c.Peer,
+		Decision: traffic.CashOutFailed,
+		Reason:   err.Error(),
+	})
+}
+
+// skipped publishes the cheque being skipped, once for the same reason.
+func (s *Scheduler) skipped(c traffic.UncashedCheque, reason string) {
+	if s.decisions[c.ChainAddress] == reason {
+		return
+	}
+	s.decisions[c.ChainAddress] = reason
+	go s.cashier.PublishCashOut(traffic.CashOutStatus{
+		Overlay:  c.Peer,
+		Decision: traffic.CashOutSkipped,
+		Reason:   reason,
+	})
+}
+
+// spent returns the gas cost spent on the day.
+func (s *Scheduler) spent(now time.Time) (*budget, error) {
+	day := now.UTC().Format("2006-01-02")
+	b := new(budget)
+	err := s.store.Get(budgetKey, b)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+	if b.Day != day || b.Spent == nil {
+		b = &budget{Day: day, Spent: big.NewInt(0)}
+	}
+	return b, nil
+}
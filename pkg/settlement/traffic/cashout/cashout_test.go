@@ -0,0 +1,241 @@
+package cashout
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/boson/test"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/settlement/traffic"
+	statestore "github.com/FavorLabs/favorX/pkg/statestore/mock"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type cashierMock struct {
+	mu        sync.Mutex
+	cheques   map[string]*traffic.UncashedCheque
+	cashed    []boson.Address
+	fail      map[string]bool
+	published []traffic.CashOutStatus
+}
+
+func newCashierMock(payouts ...int64) (*cashierMock, []boson.Address) {
+	m := &cashierMock{
+		cheques: make(map[string]*traffic.UncashedCheque),
+		fail:    make(map[string]bool),
+	}
+	peers := make([]boson.Address, 0, len(payouts))
+	for i, payout := range payouts {
+		peer := test.RandomAddress()
+		peers = append(peers, peer)
+		m.cheques[peer.String()] = &traffic.UncashedCheque{
+			Peer:         peer,
+			ChainAddress: common.BigToAddress(big.NewInt(int64(i + 1))),
+			Payout:       big.NewInt(payout),
+			Cashed:       big.NewInt(0),
+		}
+	}
+	return m, peers
+}
+
+func (m *cashierMock) UncashedCheques() ([]traffic.UncashedCheque, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var cheques []traffic.UncashedCheque
+	for _, c := range m.cheques {
+		if c.Payout.Cmp(c.Cashed) > 0 {
+			cheques = append(cheques, *c)
+		}
+	}
+	return cheques, nil
+}
+
+func (m *cashierMock) CashCheque(_ context.Context, peer boson.Address) (common.Hash, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cashed = append(m.cashed, peer)
+	if m.fail[peer.String()] {
+		return common.Hash{}, errors.New("send failed")
+	}
+	return common.BytesToHash(peer.Bytes()), nil
+}
+
+func (m *cashierMock) PublishCashOut(msg traffic.CashOutStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.published = append(m.published, msg)
+}
+
+// mine cashes the cheques of the peers.
+func (m *cashierMock) mine(peers ...boson.Address) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, peer := range peers {
+		c := m.cheques[peer.String()]
+		c.Cashed = c.Payout
+	}
+}
+
+func (m *cashierMock) takeCashed() []boson.Address {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cashed := m.cashed
+	m.cashed = nil
+	return cashed
+}
+
+type gasPrice int64
+
+func (p gasPrice) SuggestGasPrice(context.Context) (*big.Int, error) {
+	return big.NewInt(int64(p)), nil
+}
+
+func expectCashed(t *testing.T, got []boson.Address, want ...boson.Address) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got cashed %v, want %v", got, want)
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Fatalf("got cashed %v, want %v", got, want)
+		}
+	}
+}
+
+func newScheduler(cashier Cashier, opts Options) (*Scheduler, *time.Time) {
+	opts.Interval = time.Minute
+	opts.GasLimit = 10
+	s := New(logging.New(io.Discard, 0), cashier, gasPrice(1), statestore.NewStateStore(), opts)
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestRoundPriority(t *testing.T) {
+	m, peers := newCashierMock(100, 300, 55, 200)
+	s, _ := newScheduler(m, Options{MinProfit: big.NewInt(50), GasRate: big.NewRat(1, 1)})
+
+	if err := s.round(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// the gas costs 10 token units, so the third cheque is not worth cashing
+	expectCashed(t, m.takeCashed(), peers[1], peers[3], peers[0])
+
+	m.mine(peers[1], peers[3], peers[0])
+	if err := s.round(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expectCashed(t, m.takeCashed())
+}
+
+func TestRoundGasRate(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		rate *big.Rat
+		want bool
+	}{
+		{name: "gas not counted", want: true},
+		{name: "gas worth half a token unit per wei", rate: big.NewRat(1, 2), want: true},
+		{name: "gas worth a token unit per wei", rate: big.NewRat(1, 1)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, peers := newCashierMock(55)
+			s, _ := newScheduler(m, Options{MinProfit: big.NewInt(50), GasRate: tc.rate})
+
+			if err := s.round(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if tc.want {
+				expectCashed(t, m.takeCashed(), peers[0])
+			} else {
+				expectCashed(t, m.takeCashed())
+			}
+		})
+	}
+}
+
+func TestRoundBudget(t *testing.T) {
+	m, peers := newCashierMock(100, 300, 200)
+	s, now := newScheduler(m, Options{DailyGasBudget: big.NewInt(25)})
+
+	if err := s.round(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expectCashed(t, m.takeCashed(), peers[1], peers[2])
+	m.mine(peers[1], peers[2])
+
+	// the budget is spent for the day
+	if err := s.round(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expectCashed(t, m.takeCashed())
+
+	*now = now.Add(24 * time.Hour)
+	if err := s.round(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expectCashed(t, m.takeCashed(), peers[0])
+}
+
+func TestRoundRetry(t *testing.T) {
+	m, peers := newCashierMock(100)
+	m.fail[peers[0].String()] = true
+	s, now := newScheduler(m, Options{})
+
+	if err := s.round(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expectCashed(t, m.takeCashed(), peers[0])
+
+	// retried once the backoff passed, backing off longer each time
+	if err := s.round(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expectCashed(t, m.takeCashed())
+	*now = now.Add(time.Minute)
+	if err := s.round(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expectCashed(t, m.takeCashed(), peers[0])
+	*now = now.Add(time.Minute)
+	if err := s.round(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expectCashed(t, m.takeCashed())
+
+	// a transaction that did not cash the cheque is retried as well
+	m.fail[peers[0].String()] = false
+	*now = now.Add(time.Minute)
+	if err := s.round(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expectCashed(t, m.takeCashed(), peers[0])
+	if err := s.round(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if a := s.attempts[m.cheques[peers[0].String()].ChainAddress]; a == nil || a.failures != 3 {
+		t.Fatalf("got attempt %+v, want 3 failures", a)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var failed, cashing int
+	for _, msg := range m.published {
+		switch msg.Decision {
+		case traffic.CashOutFailed:
+			failed++
+		case traffic.CashOutCashing:
+			cashing++
+		}
+	}
+	if failed != 3 || cashing != 1 {
+		t.Fatalf("got %d failed and %d cashing decisions published, want 3 and 1", failed, cashing)
+	}
+}
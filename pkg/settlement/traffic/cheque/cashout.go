@@ -49,21 +49,14 @@ func (s *cashoutService) CashCheque(ctx context.Context, peer boson.Address, ben
 		return common.Hash{}, errors.New("exchange failed")
 	}
 
-	tx, err := s.trafficService.CashChequeBeneficiary(ctx, peer, beneficiary, recipient, cheque.CumulativePayout, cheque.Signature)
-	if err != nil {
-		return common.Hash{}, err
-	}
-
-	if err != nil {
-		return common.Hash{}, err
-	}
-
-	return tx.Hash(), nil
+	return s.trafficService.CashChequeBeneficiary(ctx, peer, beneficiary, recipient, cheque.CumulativePayout, cheque.Signature)
 }
 
 func (s *cashoutService) WaitForReceipt(ctx context.Context, ctxHash common.Hash) (uint64, error) {
 	receipt, err := s.transactionService.WaitForReceipt(ctx, ctxHash)
-
-	return receipt.Status, err
+	if err != nil {
+		return 0, err
+	}
+	return receipt.Status, nil
 
 }
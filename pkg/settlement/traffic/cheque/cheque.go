@@ -85,6 +85,13 @@ func eip712DataForCheque(cheque *Cheque, chainID int64) *eip712.TypedData {
 	}
 }
 
+// DomainSeparator returns the EIP712 domain separator the cheques of the
+// chain are signed with.
+func DomainSeparator(chainID int64) ([]byte, error) {
+	data := eip712DataForCheque(&Cheque{CumulativePayout: new(big.Int)}, chainID)
+	return data.HashStruct("EIP712Domain", data.Domain.Map())
+}
+
 // Sign signs a cheque.
 func (s *chequeSigner) Sign(cheque *Cheque) ([]byte, error) {
 	return s.signer.SignTypedData(eip712DataForCheque(cheque, s.chainID))
@@ -0,0 +1,435 @@
+package cheque
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/shed/driver"
+	"github.com/FavorLabs/favorX/pkg/storage"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const ledgerPrefix = "traffic_ledger_"
+
+// ledgerFlushInterval is how often the traffic accounted in memory is added
+// to the stored accounting entries.
+const ledgerFlushInterval = 30 * time.Second
+
+// ErrInvalidPeriod is the error returned if the totals are asked for an unknown period.
+var ErrInvalidPeriod = errors.New("invalid ledger period")
+
+// LedgerEntryType is the kind of payment a ledger entry records.
+type LedgerEntryType string
+
+const (
+	// LedgerChequeSent is a cheque issued to a peer, a debit.
+	LedgerChequeSent LedgerEntryType = "chequeSent"
+	// LedgerChequeReceived is a cheque received from a peer, a credit.
+	LedgerChequeReceived LedgerEntryType = "chequeReceived"
+	// LedgerCashout is a transaction cashing the cheques of a peer.
+	LedgerCashout LedgerEntryType = "cashout"
+	// LedgerAccountingCredit is the cost of the traffic a peer served us
+	// during a day, the sum of the accounting credits.
+	LedgerAccountingCredit LedgerEntryType = "accountingCredit"
+	// LedgerAccountingDebit is the cost of the traffic we served a peer
+	// during a day, the sum of the accounting debits.
+	LedgerAccountingDebit LedgerEntryType = "accountingDebit"
+)
+
+// LedgerPeriod is the length of the periods the totals are summed over.
+type LedgerPeriod string
+
+const (
+	LedgerDay   LedgerPeriod = "day"
+	LedgerMonth LedgerPeriod = "month"
+)
+
+// LedgerEntry is a cheque issued or received, a cashing transaction, or the
+// accounting of the traffic with a peer during a day, timed at the start of
+// the day.
+type LedgerEntry struct {
+	Time             time.Time       `json:"time"`
+	Type             LedgerEntryType `json:"type"`
+	Peer             boson.Address   `json:"peer"`
+	ChainAddress     common.Address  `json:"chainAddress"`
+	Amount           *big.Int        `json:"amount"`           // paid by the cheque, cashed by the transaction or accounted
+	CumulativePayout *big.Int        `json:"cumulativePayout"` // of the cheque
+	TxHash           *common.Hash    `json:"txHash,omitempty"`
+	Status           uint64          `json:"status,omitempty"`  // receipt status of the cashing transaction
+	Error            string          `json:"error,omitempty"`   // why the cashing transaction has no receipt
+	ChainID          int64           `json:"chainID,omitempty"` // of the chain the payment settles on
+}
+
+// LedgerFilter selects the entries of a peer within a time range. The zero
+// values select every peer and leave the range open.
+type LedgerFilter struct {
+	Peer boson.Address
+	From time.Time
+	To   time.Time
+}
+
+func (f LedgerFilter) match(e *LedgerEntry) bool {
+	if !f.Peer.IsZero() && !f.Peer.Equal(e.Peer) {
+		return false
+	}
+	if !f.From.IsZero() && e.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !e.Time.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// LedgerTotal sums the entries of a period.
+type LedgerTotal struct {
+	Period           string   `json:"period"`
+	Credit           *big.Int `json:"credit"`           // received in cheques
+	Debit            *big.Int `json:"debit"`            // sent in cheques
+	Cashed           *big.Int `json:"cashed"`           // cashed by successful transactions
+	AccountingCredit *big.Int `json:"accountingCredit"` // accounted for the traffic the peers served us
+	AccountingDebit  *big.Int `json:"accountingDebit"`  // accounted for the traffic we served the peers
+}
+
+// Ledger is the append-only record of the cheques issued and received and the
+// transactions cashing them, along with the daily accounting of the traffic
+// with every peer. Closing it stores the traffic accounted since the last
+// flush.
+type Ledger interface {
+	// Record appends the entry, timed now if it has no time.
+	Record(entry *LedgerEntry) error
+	// Account adds the amount of the accounting entry to the entry of the
+	// same type and peer of the day, timed now if it has no time. The sum is
+	// kept in memory and stored periodically.
+	Account(entry *LedgerEntry) error
+	// Entries returns the entries selected by the filter, oldest first.
+	Entries(filter LedgerFilter) ([]*LedgerEntry, error)
+	// Totals sums the entries selected by the filter per period, oldest first.
+	Totals(filter LedgerFilter, period LedgerPeriod) ([]*LedgerTotal, error)
+	io.Closer
+}
+
+type ledger struct {
+	store     storage.StateStorer
+	seq       uint32
+	accountMu sync.Mutex
+	accounted map[string]*LedgerEntry // accounted since the last flush, by key
+	flushMu   sync.Mutex
+	quit      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewLedger creates a ledger kept in the store.
+func NewLedger(store storage.StateStorer) Ledger {
+	l := &ledger{
+		store:     store,
+		accounted: make(map[string]*LedgerEntry),
+		quit:      make(chan struct{}),
+	}
+	l.wg.Add(1)
+	go l.flushLoop()
+	return l
+}
+
+// ledgerTime is the part of the keys ordering the entries by time.
+func ledgerTime(t time.Time) string {
+	return fmt.Sprintf("%s%020d", ledgerPrefix, t.UnixNano())
+}
+
+// ledgerKey orders the entries by time, the sequence telling apart the ones
+// recorded at the same time.
+func ledgerKey(t time.Time, seq uint32) string {
+	return fmt.Sprintf("%s_%010d", ledgerTime(t), seq)
+}
+
+func (l *ledger) Record(entry *LedgerEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	entry.Time = entry.Time.UTC()
+	if entry.Amount == nil {
+		entry.Amount = big.NewInt(0)
+	}
+	return l.store.Put(ledgerKey(entry.Time, atomic.AddUint32(&l.seq, 1)), entry)
+}
+
+// accountKey is the key of the accounting entry of the peer, sorting with the
+// entries at the start of its day.
+func accountKey(day time.Time, entryType LedgerEntryType, peer boson.Address) string {
+	return fmt.Sprintf("%s_%s_%s", ledgerTime(day), entryType, peer)
+}
+
+func (l *ledger) Account(entry *LedgerEntry) error {
+	if entry.Type != LedgerAccountingCredit && entry.Type != LedgerAccountingDebit {
+		return fmt.Errorf("not an accounting entry: %s", entry.Type)
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	t := entry.Time.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	key := accountKey(day, entry.Type, entry.Peer)
+
+	l.accountMu.Lock()
+	defer l.accountMu.Unlock()
+
+	total, ok := l.accounted[key]
+	if !ok {
+		total = &LedgerEntry{
+			Time:         day,
+			Type:         entry.Type,
+			Peer:         entry.Peer,
+			ChainAddress: entry.ChainAddress,
+			Amount:       big.NewInt(0),
+			ChainID:      entry.ChainID,
+		}
+		l.accounted[key] = total
+	}
+	total.Amount.Add(total.Amount, entry.Amount)
+	return nil
+}
+
+func (l *ledger) flushLoop() {
+	defer l.wg.Done()
+	ticker := time.NewTicker(ledgerFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.quit:
+			return
+		case <-ticker.C:
+			// the sums failing to be stored are kept for the next flush
+			_ = l.flush()
+		}
+	}
+}
+
+// flush adds the traffic accounted in memory to the stored accounting entries.
+func (l *ledger) flush() error {
+	l.flushMu.Lock()
+	defer l.flushMu.Unlock()
+
+	l.accountMu.Lock()
+	accounted := l.accounted
+	l.accounted = make(map[string]*LedgerEntry)
+	l.accountMu.Unlock()
+
+	var firstErr error
+	for key, entry := range accounted {
+		if err := l.storeAccount(key, entry); err != nil {
+			l.restore(key, entry)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// storeAccount adds the accounted entry to the stored one of the same key.
+func (l *ledger) storeAccount(key string, entry *LedgerEntry) error {
+	total := new(LedgerEntry)
+	err := l.store.Get(key, total)
+	if errors.Is(err, storage.ErrNotFound) {
+		return l.store.Put(key, entry)
+	}
+	if err != nil {
+		return err
+	}
+	total.Amount.Add(total.Amount, entry.Amount)
+	return l.store.Put(key, total)
+}
+
+// restore returns the accounted entry failing to be stored to the ones kept
+// in memory.
+func (l *ledger) restore(key string, entry *LedgerEntry) {
+	l.accountMu.Lock()
+	defer l.accountMu.Unlock()
+	if total, ok := l.accounted[key]; ok {
+		total.Amount.Add(total.Amount, entry.Amount)
+		return
+	}
+	l.accounted[key] = entry
+}
+
+func (l *ledger) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.quit)
+	})
+	l.wg.Wait()
+	return l.flush()
+}
+
+func (l *ledger) Entries(filter LedgerFilter) ([]*LedgerEntry, error) {
+	if err := l.flush(); err != nil {
+		return nil, err
+	}
+	db := l.store.DB()
+	if db == nil {
+		return l.scan(filter)
+	}
+
+	// the keys order the entries by time, so only the ones of the range are read
+	start, end := []byte(ledgerPrefix), []byte(nil)
+	if !filter.From.IsZero() {
+		start = []byte(ledgerTime(filter.From))
+	}
+	if !filter.To.IsZero() {
+		end = []byte(ledgerTime(filter.To))
+	}
+	cursor := db.Search(driver.Query{Prefix: driver.Key{Data: []byte(ledgerPrefix)}, MatchPrefix: true})
+	defer cursor.Close()
+	var list []*LedgerEntry
+	for ok := cursor.Seek(driver.Key{Data: start}); ok; ok = cursor.Next() {
+		key := cursor.Key()
+		if !bytes.HasPrefix(key, []byte(ledgerPrefix)) || end != nil && bytes.Compare(key, end) >= 0 {
+			break
+		}
+		value, err := driver.ReadValue(cursor)
+		if err != nil {
+			return nil, fmt.Errorf("read ledger entry %s: %w", key, err)
+		}
+		entry := new(LedgerEntry)
+		if err := json.Unmarshal(value, entry); err != nil {
+			return nil, fmt.Errorf("invalid ledger entry %s: %w", key, err)
+		}
+		if filter.match(entry) {
+			list = append(list, entry)
+		}
+	}
+	if err := cursor.Error(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// scan selects the entries of a store which cannot be read in the order of
+// the keys.
+func (l *ledger) scan(filter LedgerFilter) ([]*LedgerEntry, error) {
+	type keyed struct {
+		key   string
+		entry *LedgerEntry
+	}
+	var entries []keyed
+	err := l.store.Iterate(ledgerPrefix, func(key, value []byte) (bool, error) {
+		entry := new(LedgerEntry)
+		if err := json.Unmarshal(value, entry); err != nil {
+			return true, fmt.Errorf("invalid ledger entry %s: %w", key, err)
+		}
+		if filter.match(entry) {
+			entries = append(entries, keyed{key: string(key), entry: entry})
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+	list := make([]*LedgerEntry, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, e.entry)
+	}
+	return list, nil
+}
+
+func (l *ledger) Totals(filter LedgerFilter, period LedgerPeriod) ([]*LedgerTotal, error) {
+	entries, err := l.Entries(filter)
+	if err != nil {
+		return nil, err
+	}
+	return SumLedger(entries, period)
+}
+
+// SumLedger sums the entries, listed by time, per period.
+func SumLedger(entries []*LedgerEntry, period LedgerPeriod) ([]*LedgerTotal, error) {
+	var layout string
+	switch period {
+	case LedgerDay:
+		layout = "2006-01-02"
+	case LedgerMonth:
+		layout = "2006-01"
+	default:
+		return nil, ErrInvalidPeriod
+	}
+	var totals []*LedgerTotal
+	for _, e := range entries {
+		p := e.Time.UTC().Format(layout)
+		if len(totals) == 0 || totals[len(totals)-1].Period != p {
+			totals = append(totals, &LedgerTotal{
+				Period:           p,
+				Credit:           big.NewInt(0),
+				Debit:            big.NewInt(0),
+				Cashed:           big.NewInt(0),
+				AccountingCredit: big.NewInt(0),
+				AccountingDebit:  big.NewInt(0),
+			})
+		}
+		total := totals[len(totals)-1]
+		switch e.Type {
+		case LedgerChequeReceived:
+			total.Credit.Add(total.Credit, e.Amount)
+		case LedgerChequeSent:
+			total.Debit.Add(total.Debit, e.Amount)
+		case LedgerCashout:
+			if e.Status == 1 {
+				total.Cashed.Add(total.Cashed, e.Amount)
+			}
+		case LedgerAccountingCredit:
+			total.AccountingCredit.Add(total.AccountingCredit, e.Amount)
+		case LedgerAccountingDebit:
+			total.AccountingDebit.Add(total.AccountingDebit, e.Amount)
+		}
+	}
+	return totals, nil
+}
+
+// WriteLedgerCSV writes the entries as CSV with a header row.
+func WriteLedgerCSV(w io.Writer, entries []*LedgerEntry) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"time", "type", "peer", "chainAddress", "amount", "cumulativePayout", "txHash", "status", "error", "chainID"})
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		var txHash, status string
+		if e.TxHash != nil {
+			txHash = e.TxHash.String()
+			status = strconv.FormatUint(e.Status, 10)
+		}
+		var cumulativePayout string
+		if e.CumulativePayout != nil {
+			cumulativePayout = e.CumulativePayout.String()
+		}
+		err := cw.Write([]string{
+			e.Time.UTC().Format(time.RFC3339Nano),
+			string(e.Type),
+			e.Peer.String(),
+			e.ChainAddress.String(),
+			e.Amount.String(),
+			cumulativePayout,
+			txHash,
+			status,
+			e.Error,
+			strconv.FormatInt(e.ChainID, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
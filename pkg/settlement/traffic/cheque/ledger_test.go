@@ -0,0 +1,261 @@
+package cheque_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/boson/test"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	chequePkg "github.com/FavorLabs/favorX/pkg/settlement/traffic/cheque"
+	"github.com/FavorLabs/favorX/pkg/statestore/leveldb"
+	"github.com/FavorLabs/favorX/pkg/statestore/mock"
+	"github.com/FavorLabs/favorX/pkg/storage"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ledgerStores are the stores the ledger is tested with, one which iterates
+// in the order of the keys and one which does not.
+var ledgerStores = []struct {
+	name string
+	new  func(t *testing.T) storage.StateStorer
+}{
+	{"mock", func(t *testing.T) storage.StateStorer {
+		return mock.NewStateStore()
+	}},
+	{"leveldb", func(t *testing.T) storage.StateStorer {
+		store, err := leveldb.NewInMemoryStateStore(logging.New(io.Discard, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = store.Close()
+		})
+		return store
+	}},
+}
+
+func TestLedger(t *testing.T) {
+	for _, s := range ledgerStores {
+		t.Run(s.name, func(t *testing.T) {
+			testLedger(t, s.new(t))
+		})
+	}
+}
+
+func testLedger(t *testing.T, store storage.StateStorer) {
+	ledger := chequePkg.NewLedger(store)
+	defer ledger.Close()
+	peer1, peer2 := test.RandomAddress(), test.RandomAddress()
+	day1 := time.Date(2022, 3, 31, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	txHash := common.HexToHash("0x01")
+
+	entries := []*chequePkg.LedgerEntry{
+		{Time: day1, Type: chequePkg.LedgerChequeReceived, Peer: peer1, Amount: big.NewInt(10), CumulativePayout: big.NewInt(10)},
+		{Time: day1, Type: chequePkg.LedgerChequeSent, Peer: peer2, Amount: big.NewInt(4), CumulativePayout: big.NewInt(4)},
+		{Time: day1.Add(time.Hour), Type: chequePkg.LedgerChequeReceived, Peer: peer1, Amount: big.NewInt(5), CumulativePayout: big.NewInt(15)},
+		{Time: day2, Type: chequePkg.LedgerCashout, Peer: peer1, Amount: big.NewInt(15), CumulativePayout: big.NewInt(15), TxHash: &txHash, Status: 1},
+		{Time: day2, Type: chequePkg.LedgerChequeSent, Peer: peer2, Amount: big.NewInt(6), CumulativePayout: big.NewInt(10)},
+	}
+	// recorded out of order, listed by time
+	for _, i := range []int{3, 0, 1, 4, 2} {
+		if err := ledger.Record(entries[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := ledger.Entries(chequePkg.LedgerFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(entries) {
+		t.Fatalf("got %d entries, want %d", len(got), len(entries))
+	}
+	for i, e := range got {
+		if !e.Time.Equal(entries[i].Time) || e.Type != entries[i].Type || e.Amount.Cmp(entries[i].Amount) != 0 {
+			t.Fatalf("got entry %d %+v, want %+v", i, e, entries[i])
+		}
+	}
+
+	got, err = ledger.Entries(chequePkg.LedgerFilter{Peer: peer1, From: day1.Add(time.Minute), To: day2.Add(time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Amount.Int64() != 5 || got[1].Type != chequePkg.LedgerCashout || *got[1].TxHash != txHash {
+		t.Fatalf("got filtered entries %+v", got)
+	}
+
+	totals, err := ledger.Totals(chequePkg.LedgerFilter{}, chequePkg.LedgerDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		period                string
+		credit, debit, cashed int64
+	}{
+		{"2022-03-31", 15, 4, 0},
+		{"2022-04-01", 0, 6, 15},
+	}
+	if len(totals) != len(want) {
+		t.Fatalf("got %d daily totals, want %d", len(totals), len(want))
+	}
+	for i, w := range want {
+		total := totals[i]
+		if total.Period != w.period || total.Credit.Int64() != w.credit || total.Debit.Int64() != w.debit || total.Cashed.Int64() != w.cashed {
+			t.Fatalf("got total %s %d/%d/%d, want %+v", total.Period, total.Credit, total.Debit, total.Cashed, w)
+		}
+	}
+
+	totals, err = ledger.Totals(chequePkg.LedgerFilter{}, chequePkg.LedgerMonth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(totals) != 2 || totals[0].Period != "2022-03" || totals[1].Period != "2022-04" {
+		t.Fatalf("got monthly totals %+v", totals)
+	}
+	if _, err := ledger.Totals(chequePkg.LedgerFilter{}, "week"); !errors.Is(err, chequePkg.ErrInvalidPeriod) {
+		t.Fatalf("got error %v, want %v", err, chequePkg.ErrInvalidPeriod)
+	}
+}
+
+func TestLedgerAccount(t *testing.T) {
+	for _, s := range ledgerStores {
+		t.Run(s.name, func(t *testing.T) {
+			testLedgerAccount(t, s.new(t))
+		})
+	}
+}
+
+func testLedgerAccount(t *testing.T, store storage.StateStorer) {
+	ledger := chequePkg.NewLedger(store)
+	defer ledger.Close()
+	peer := test.RandomAddress()
+	day1 := time.Date(2022, 3, 31, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+
+	for _, e := range []*chequePkg.LedgerEntry{
+		{Time: day1, Type: chequePkg.LedgerAccountingCredit, Peer: peer, Amount: big.NewInt(3)},
+		{Time: day1.Add(time.Hour), Type: chequePkg.LedgerAccountingCredit, Peer: peer, Amount: big.NewInt(4)},
+		{Time: day1, Type: chequePkg.LedgerAccountingDebit, Peer: peer, Amount: big.NewInt(2)},
+		{Time: day2, Type: chequePkg.LedgerAccountingDebit, Peer: peer, Amount: big.NewInt(5)},
+	} {
+		if err := ledger.Account(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ledger.Account(&chequePkg.LedgerEntry{Type: chequePkg.LedgerChequeSent, Amount: big.NewInt(1)}); err == nil {
+		t.Fatal("accounted a cheque")
+	}
+	if err := ledger.Record(&chequePkg.LedgerEntry{Time: day1, Type: chequePkg.LedgerChequeReceived, Peer: peer, Amount: big.NewInt(6)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the traffic of a day is summed in an entry timed at its start
+	entries, err := ledger.Entries(chequePkg.LedgerFilter{Peer: peer, To: day1.Add(14 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	for _, e := range entries[:2] {
+		if !e.Time.Equal(time.Date(2022, 3, 31, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("got accounting entry timed %s", e.Time)
+		}
+	}
+
+	totals, err := ledger.Totals(chequePkg.LedgerFilter{}, chequePkg.LedgerDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		period        string
+		credit, debit int64
+	}{
+		{"2022-03-31", 7, 2},
+		{"2022-04-01", 0, 5},
+	}
+	if len(totals) != len(want) {
+		t.Fatalf("got %d daily totals, want %d", len(totals), len(want))
+	}
+	for i, w := range want {
+		total := totals[i]
+		if total.Period != w.period || total.AccountingCredit.Int64() != w.credit || total.AccountingDebit.Int64() != w.debit {
+			t.Fatalf("got total %s %d/%d, want %+v", total.Period, total.AccountingCredit, total.AccountingDebit, w)
+		}
+	}
+	if totals[0].Credit.Int64() != 6 {
+		t.Fatalf("got cheque credit %d, want 6", totals[0].Credit)
+	}
+}
+
+func TestLedgerAccountClose(t *testing.T) {
+	store := mock.NewStateStore()
+	ledger := chequePkg.NewLedger(store)
+	peer := test.RandomAddress()
+	day := time.Date(2022, 3, 31, 10, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		if err := ledger.Account(&chequePkg.LedgerEntry{Time: day, Type: chequePkg.LedgerAccountingCredit, Peer: peer, Amount: big.NewInt(2)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the traffic is kept in memory until the ledger is flushed
+	var stored int
+	if err := store.Iterate("traffic_ledger_", func(_, _ []byte) (bool, error) {
+		stored++
+		return false, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if stored != 0 {
+		t.Fatalf("got %d stored entries, want none", stored)
+	}
+	if err := ledger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// and added to the stored entry of the day once closed
+	ledger = chequePkg.NewLedger(store)
+	defer ledger.Close()
+	if err := ledger.Account(&chequePkg.LedgerEntry{Time: day, Type: chequePkg.LedgerAccountingCredit, Peer: peer, Amount: big.NewInt(1)}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ledger.Entries(chequePkg.LedgerFilter{Peer: peer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Amount.Int64() != 7 {
+		t.Fatalf("got entries %+v, want one of 7", entries)
+	}
+}
+
+func TestWriteLedgerCSV(t *testing.T) {
+	txHash := common.HexToHash("0x01")
+	entries := []*chequePkg.LedgerEntry{
+		{Time: time.Unix(0, 0), Type: chequePkg.LedgerChequeSent, Peer: test.RandomAddress(), Amount: big.NewInt(4), CumulativePayout: big.NewInt(4)},
+		{Time: time.Unix(1, 0), Type: chequePkg.LedgerCashout, Peer: test.RandomAddress(), Amount: big.NewInt(0), TxHash: &txHash, Error: "dropped"},
+	}
+	var buf bytes.Buffer
+	if err := chequePkg.WriteLedgerCSV(&buf, entries); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[0][0] != "time" {
+		t.Fatalf("got records %v", records)
+	}
+	if records[1][1] != "chequeSent" || records[1][4] != "4" || records[1][6] != "" {
+		t.Fatalf("got cheque record %v", records[1])
+	}
+	if records[2][6] != txHash.String() || records[2][7] != "0" || records[2][8] != "dropped" {
+		t.Fatalf("got cashout record %v", records[2])
+	}
+}
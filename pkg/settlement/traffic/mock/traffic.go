@@ -22,6 +22,10 @@ type TraafficMock struct {
 
 	trafficCheques func() ([]*traffic.TrafficCheque, error)
 
+	ledger func(filter chequePkg.LedgerFilter) ([]*chequePkg.LedgerEntry, error)
+
+	ledgerTotals func(filter chequePkg.LedgerFilter, period chequePkg.LedgerPeriod) ([]*chequePkg.LedgerTotal, error)
+
 	address func() common.Address
 
 	trafficInfo func() (*traffic.TrafficInfo, error)
@@ -80,6 +84,18 @@ func WithTrafficCheques(f func() ([]*traffic.TrafficCheque, error)) Option {
 	})
 }
 
+func WithLedger(f func(filter chequePkg.LedgerFilter) ([]*chequePkg.LedgerEntry, error)) Option {
+	return optionFunc(func(s *TraafficMock) {
+		s.ledger = f
+	})
+}
+
+func WithLedgerTotals(f func(filter chequePkg.LedgerFilter, period chequePkg.LedgerPeriod) ([]*chequePkg.LedgerTotal, error)) Option {
+	return optionFunc(func(s *TraafficMock) {
+		s.ledgerTotals = f
+	})
+}
+
 func WithAddress(f func() common.Address) Option {
 	return optionFunc(func(s *TraafficMock) {
 		s.address = f
@@ -177,6 +193,14 @@ func (s *TraafficMock) TrafficCheques() ([]*traffic.TrafficCheque, error) {
 	return s.trafficCheques()
 }
 
+func (s *TraafficMock) Ledger(filter chequePkg.LedgerFilter) ([]*chequePkg.LedgerEntry, error) {
+	return s.ledger(filter)
+}
+
+func (s *TraafficMock) LedgerTotals(filter chequePkg.LedgerFilter, period chequePkg.LedgerPeriod) ([]*chequePkg.LedgerTotal, error) {
+	return s.ledgerTotals(filter, period)
+}
+
 func (s *TraafficMock) Address() common.Address {
 	return s.address()
 }
@@ -55,6 +55,7 @@ type TrafficCheque struct {
 	Total               *big.Int      `json:"total"`
 	Uncashed            *big.Int      `json:"unCashed"`
 	Status              CashStatus    `json:"status"`
+	ChainID             int64         `json:"chainID"`
 }
 
 type CashStatus = int
@@ -65,14 +66,41 @@ const (
 )
 
 type cashCheque struct {
-	txHash       common.Hash
-	peer         boson.Address
-	chainAddress common.Address
+	txHash           common.Hash
+	peer             boson.Address
+	chainAddress     common.Address
+	amount           *big.Int // uncashed when the cheque was cashed
+	cumulativePayout *big.Int // of the cheque cashed
 }
 
 type CashOutStatus struct {
 	Overlay boson.Address `json:"overlay"`
 	Status  bool          `json:"status"`
+	// Decision, Reason and TxHash are set on the decisions of the cashout
+	// scheduler.
+	Decision CashOutDecision `json:"decision,omitempty"`
+	Reason   string          `json:"reason,omitempty"`
+	TxHash   *common.Hash    `json:"txHash,omitempty"`
+}
+
+// CashOutDecision is what the cashout scheduler decided for the cheque of a
+// peer.
+type CashOutDecision string
+
+const (
+	CashOutCashing CashOutDecision = "cashing"
+	CashOutSkipped CashOutDecision = "skipped"
+	CashOutFailed  CashOutDecision = "failed"
+)
+
+// UncashedCheque is the last cheque received from a peer that is not fully
+// cashed yet.
+type UncashedCheque struct {
+	Peer         boson.Address
+	ChainAddress common.Address
+	Payout       *big.Int // cumulative payout of the cheque
+	Cashed       *big.Int // cumulative payout cashed on the chain
+	InProgress   bool     // a cashing transaction is pending
 }
 
 type TrafficInfo struct {
@@ -80,6 +108,9 @@ type TrafficInfo struct {
 	AvailableBalance *big.Int `json:"availableBalance"`
 	TotalSendTraffic *big.Int `json:"totalSendTraffic"`
 	ReceivedTraffic  *big.Int `json:"receivedTraffic"`
+	ChainID          int64    `json:"chainID,omitempty"`
+	// Chains holds the information of every chain when settling on several.
+	Chains []*TrafficInfo `json:"chains,omitempty"`
 }
 
 type ApiInterface interface {
@@ -91,6 +122,12 @@ type ApiInterface interface {
 
 	TrafficCheques() ([]*TrafficCheque, error)
 
+	// Ledger returns the cheques and cashing transactions selected by the filter.
+	Ledger(filter chequePkg.LedgerFilter) ([]*chequePkg.LedgerEntry, error)
+
+	// LedgerTotals sums the ledger entries selected by the filter per period.
+	LedgerTotals(filter chequePkg.LedgerFilter, period chequePkg.LedgerPeriod) ([]*chequePkg.LedgerTotal, error)
+
 	Address() common.Address
 
 	TrafficInfo() (*TrafficInfo, error)
@@ -111,6 +148,7 @@ type Service struct {
 	metrics             metrics
 	chequeStore         chequePkg.ChequeStore
 	cashout             chequePkg.CashoutService
+	ledger              chequePkg.Ledger
 	trafficChainService chain.Traffic
 	p2pService          p2p.Service
 	peersLock           sync.Mutex
@@ -126,7 +164,7 @@ type Service struct {
 }
 
 func New(logger logging.Logger, chainAddress common.Address, store storage.StateStorer, trafficChainService chain.Traffic,
-	chequeStore chequePkg.ChequeStore, cashout chequePkg.CashoutService, p2pService p2p.Service, addressBook Addressbook,
+	chequeStore chequePkg.ChequeStore, cashout chequePkg.CashoutService, ledger chequePkg.Ledger, p2pService p2p.Service, addressBook Addressbook,
 	chequeSigner chequePkg.ChequeSigner, protocol trafficprotocol.Interface, chainID int64, subPub subscribe.SubPub) *Service {
 
 	service := &Service{
@@ -137,6 +175,7 @@ func New(logger logging.Logger, chainAddress common.Address, store storage.State
 		metrics:             newMetrics(),
 		chequeStore:         chequeStore,
 		cashout:             cashout,
+		ledger:              ledger,
 		p2pService:          p2pService,
 		addressBook:         addressBook,
 		chequeSigner:        chequeSigner,
@@ -417,19 +456,60 @@ func (s *Service) CashCheque(ctx context.Context, peer boson.Address) (common.Ha
 		return common.Hash{}, chequePkg.ErrNoCheque
 	}
 	traffic := s.getTraffic(chainAddress)
+	traffic.Lock()
+	cumulativePayout := new(big.Int).Set(traffic.transferChequeTraffic)
+	amount := new(big.Int).Sub(traffic.transferChequeTraffic, traffic.transferChainTraffic)
+	traffic.Unlock()
 	c, err := s.cashout.CashCheque(ctx, peer, chainAddress, s.chainAddress)
 	if err != nil {
 		return common.Hash{}, err
 	}
 	traffic.updateStatus(Operation)
 	s.cashChequeChan <- cashCheque{
-		txHash:       c,
-		peer:         peer,
-		chainAddress: chainAddress,
+		txHash:           c,
+		peer:             peer,
+		chainAddress:     chainAddress,
+		amount:           amount,
+		cumulativePayout: cumulativePayout,
 	}
 	return c, err
 }
 
+// UncashedCheques returns the last received cheques of the known peers that
+// are not fully cashed.
+func (s *Service) UncashedCheques() ([]UncashedCheque, error) {
+	cheques, err := s.chequeStore.LastReceivedCheques()
+	if err != nil {
+		return nil, err
+	}
+	uncashed := make([]UncashedCheque, 0, len(cheques))
+	for chainAddress, cheque := range cheques {
+		peer, known := s.addressBook.BeneficiaryPeer(chainAddress)
+		if !known {
+			continue
+		}
+		cashed, err := s.chequeStore.GetChainTransferTraffic(chainAddress)
+		if err != nil {
+			return nil, err
+		}
+		if cheque.CumulativePayout.Cmp(cashed) <= 0 {
+			continue
+		}
+		traffic := s.getTraffic(chainAddress)
+		traffic.Lock()
+		status := traffic.status
+		traffic.Unlock()
+		uncashed = append(uncashed, UncashedCheque{
+			Peer:         peer,
+			ChainAddress: chainAddress,
+			Payout:       cheque.CumulativePayout,
+			Cashed:       cashed,
+			InProgress:   status == Operation,
+		})
+	}
+	return uncashed, nil
+}
+
 func (s *Service) Address() common.Address {
 	return s.chainAddress
 }
@@ -449,10 +529,24 @@ func (s *Service) TrafficInfo() (*TrafficInfo, error) {
 
 	respTraffic.Balance = s.trafficPeers.balance
 	respTraffic.AvailableBalance = new(big.Int).Add(respTraffic.Balance, new(big.Int).Sub(cashed, transfer))
+	respTraffic.ChainID = s.chainID
 
 	return respTraffic, nil
 }
 
+func (s *Service) Ledger(filter chequePkg.LedgerFilter) ([]*chequePkg.LedgerEntry, error) {
+	return s.ledger.Entries(filter)
+}
+
+func (s *Service) LedgerTotals(filter chequePkg.LedgerFilter, period chequePkg.LedgerPeriod) ([]*chequePkg.LedgerTotal, error) {
+	return s.ledger.Totals(filter, period)
+}
+
+// Close stores the traffic accounted in the ledger since its last flush.
+func (s *Service) Close() error {
+	return s.ledger.Close()
+}
+
 func (s *Service) TrafficCheques() ([]*TrafficCheque, error) {
 	s.trafficPeers.trafficLock.Lock()
 	defer s.trafficPeers.trafficLock.Unlock()
@@ -470,6 +564,7 @@ func (s *Service) TrafficCheques() ([]*TrafficCheque, error) {
 				Total:               new(big.Int).Sub(traffic.transferTraffic, traffic.retrieveTraffic),
 				Uncashed:            new(big.Int).Sub(traffic.transferChequeTraffic, traffic.transferChainTraffic),
 				Status:              traffic.status,
+				ChainID:             s.chainID,
 			}
 			if trafficCheque.OutstandingTraffic.Cmp(big.NewInt(0)) == 0 && trafficCheque.SentSettlements.Cmp(big.NewInt(0)) == 0 && trafficCheque.ReceivedSettlements.Cmp(big.NewInt(0)) == 0 {
 				continue
@@ -542,7 +637,52 @@ func (s *Service) issue(ctx context.Context, peer boson.Address, recipient, bene
 	if err := s.protocol.EmitCheque(ctx, peer, signedCheque); err != nil {
 		return err
 	}
-	return s.putSendCheque(ctx, &c, recipient, traffic)
+	if err := s.putSendCheque(ctx, &c, recipient, traffic); err != nil {
+		return err
+	}
+	s.record(&chequePkg.LedgerEntry{
+		Type:             chequePkg.LedgerChequeSent,
+		Peer:             peer,
+		ChainAddress:     recipient,
+		Amount:           new(big.Int).Set(balance),
+		CumulativePayout: new(big.Int).Set(c.CumulativePayout),
+	})
+	return nil
+}
+
+// RecordTraffic adds the costs of the traffic accounted with the peer to the
+// ledger.
+func (s *Service) RecordTraffic(peer boson.Address, credit, debit *big.Int) {
+	s.account(chequePkg.LedgerAccountingCredit, peer, credit)
+	s.account(chequePkg.LedgerAccountingDebit, peer, debit)
+}
+
+// account adds the amount to the accounting entry of the peer. The traffic
+// is already accounted, so failing to record it is only logged.
+func (s *Service) account(entryType chequePkg.LedgerEntryType, peer boson.Address, amount *big.Int) {
+	if amount == nil || amount.Sign() == 0 {
+		return
+	}
+	chainAddress, _ := s.addressBook.Beneficiary(peer)
+	err := s.ledger.Account(&chequePkg.LedgerEntry{
+		Type:         entryType,
+		Peer:         peer,
+		ChainAddress: chainAddress,
+		Amount:       amount,
+		ChainID:      s.chainID,
+	})
+	if err != nil {
+		s.logger.Errorf("traffic: record %s of peer %s: %v", entryType, peer, err)
+	}
+}
+
+// record appends the entry to the ledger. The payment it records already
+// happened, so failing to record it is only logged.
+func (s *Service) record(entry *chequePkg.LedgerEntry) {
+	entry.ChainID = s.chainID
+	if err := s.ledger.Record(entry); err != nil {
+		s.logger.Errorf("traffic: record %s of peer %s: %v", entry.Type, entry.Peer, err)
+	}
 }
 
 func (s *Service) putSendCheque(ctx context.Context, cheque *chequePkg.Cheque, recipient common.Address, traffic *Traffic) error {
@@ -743,11 +883,18 @@ func (s *Service) ReceiveCheque(ctx context.Context, peer boson.Address, cheque
 	traffic := s.getTraffic(chainAddress)
 	traffic.Lock()
 	defer traffic.Unlock()
-	_, err := s.chequeStore.ReceiveCheque(ctx, cheque)
+	amount, err := s.chequeStore.ReceiveCheque(ctx, cheque)
 	if err != nil {
 		return err
 	}
 	traffic.transferChequeTraffic = cheque.CumulativePayout
+	s.record(&chequePkg.LedgerEntry{
+		Type:             chequePkg.LedgerChequeReceived,
+		Peer:             peer,
+		ChainAddress:     chainAddress,
+		Amount:           amount,
+		CumulativePayout: new(big.Int).Set(cheque.CumulativePayout),
+	})
 	go s.PublishTrafficCheque(chainAddress)
 	return nil
 }
@@ -817,6 +964,20 @@ func (s *Service) cashChequeReceiptUpdate() {
 			status, err := tranReceipt(cashInfo.txHash)
 			traffic := s.getTraffic(cashInfo.chainAddress)
 			traffic.updateStatus(UnOperation)
+			txHash := cashInfo.txHash
+			entry := &chequePkg.LedgerEntry{
+				Type:             chequePkg.LedgerCashout,
+				Peer:             cashInfo.peer,
+				ChainAddress:     cashInfo.chainAddress,
+				Amount:           cashInfo.amount,
+				CumulativePayout: cashInfo.cumulativePayout,
+				TxHash:           &txHash,
+				Status:           status,
+			}
+			if err != nil {
+				entry.Error = err.Error()
+			}
+			s.record(entry)
 			if err != nil {
 				go s.PublishCashOut(CashOutStatus{Overlay: cashInfo.peer, Status: false})
 				continue
@@ -826,7 +987,7 @@ func (s *Service) cashChequeReceiptUpdate() {
 				if err != nil {
 					s.logger.Errorf("traffic:chainRetrieveTrafficUpdate - %v ", err.Error())
 				}
-				err = s.chequeStore.PutChainTransferTraffic(cashInfo.chainAddress, traffic.transferChequeTraffic)
+				err = s.chequeStore.PutChainTransferTraffic(cashInfo.chainAddress, cashInfo.cumulativePayout)
 				if err != nil {
 					s.logger.Errorf("traffic:chainTransferTrafficUpdate - %v ", err.Error())
 				}
@@ -198,6 +198,7 @@ func TestPayUnknownBeneficiary(t *testing.T) {
 		nil,
 		mockchequestore.NewChequeStore(),
 		cashOutMock,
+		chequePkg.NewLedger(store),
 		mockp2p.New(
 			mockp2p.WithDisconnectFunc(func(overlay boson.Address, reason string) error {
 				if !peer.Equal(overlay) {
@@ -270,6 +271,7 @@ func TestPay(t *testing.T) {
 		nil,
 		chequeStore,
 		&cashOut,
+		chequePkg.NewLedger(store),
 		mockp2p.New(),
 		addressBook,
 		&chequeSigner,
@@ -404,6 +406,7 @@ func TestCashOut(t *testing.T) {
 		nil,
 		chequeStore,
 		cashOut,
+		chequePkg.NewLedger(store),
 		mockp2p.New(),
 		addressBook,
 		chequeSigner,
@@ -482,6 +485,7 @@ func newTrafficTest(t *testing.T, store storage.StateStorer, logger logging.Logg
 		trafficChainService,
 		chequeStore,
 		cashout,
+		chequePkg.NewLedger(store),
 		p2pServer,
 		addressBook,
 		chequeSigner,
@@ -25,6 +25,7 @@ const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 type EmitCheque struct {
 	Address      []byte `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
 	SignedCheque []byte `protobuf:"bytes,2,opt,name=SignedCheque,proto3" json:"SignedCheque,omitempty"`
+	ChainID      int64  `protobuf:"varint,3,opt,name=ChainID,proto3" json:"ChainID,omitempty"`
 }
 
 func (m *EmitCheque) Reset()         { *m = EmitCheque{} }
@@ -74,23 +75,77 @@ func (m *EmitCheque) GetSignedCheque() []byte {
 	return nil
 }
 
+func (m *EmitCheque) GetChainID() int64 {
+	if m != nil {
+		return m.ChainID
+	}
+	return 0
+}
+
+type Chains struct {
+	ChainIDs []int64 `protobuf:"varint,1,rep,packed,name=ChainIDs,proto3" json:"ChainIDs,omitempty"`
+}
+
+func (m *Chains) Reset()         { *m = Chains{} }
+func (m *Chains) String() string { return proto.CompactTextString(m) }
+func (*Chains) ProtoMessage()    {}
+func (*Chains) Descriptor() ([]byte, []int) {
+	return fileDescriptor_50e185a42cb2d3c6, []int{1}
+}
+func (m *Chains) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Chains) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Chains.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Chains) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Chains.Merge(m, src)
+}
+func (m *Chains) XXX_Size() int {
+	return m.Size()
+}
+func (m *Chains) XXX_DiscardUnknown() {
+	xxx_messageInfo_Chains.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Chains proto.InternalMessageInfo
+
+func (m *Chains) GetChainIDs() []int64 {
+	if m != nil {
+		return m.ChainIDs
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*EmitCheque)(nil), "traffic.EmitCheque")
+	proto.RegisterType((*Chains)(nil), "traffic.Chains")
 }
 
 func init() { proto.RegisterFile("traffic.proto", fileDescriptor_50e185a42cb2d3c6) }
 
 var fileDescriptor_50e185a42cb2d3c6 = []byte{
-	// 131 bytes of a gzipped FileDescriptorProto
+	// 166 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x2d, 0x29, 0x4a, 0x4c,
-	0x4b, 0xcb, 0x4c, 0xd6, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x87, 0x72, 0x95, 0xbc, 0xb8,
+	0x4b, 0xcb, 0x4c, 0xd6, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x87, 0x72, 0x95, 0x52, 0xb8,
 	0xb8, 0x5c, 0x73, 0x33, 0x4b, 0x9c, 0x33, 0x52, 0x0b, 0x4b, 0x53, 0x85, 0x24, 0xb8, 0xd8, 0x13,
 	0x53, 0x52, 0x8a, 0x52, 0x8b, 0x8b, 0x25, 0x18, 0x15, 0x18, 0x35, 0x78, 0x82, 0x60, 0x5c, 0x21,
 	0x25, 0x2e, 0x9e, 0xe0, 0xcc, 0xf4, 0xbc, 0xd4, 0x14, 0x88, 0x4a, 0x09, 0x26, 0xb0, 0x34, 0x8a,
-	0x98, 0x93, 0xcc, 0x89, 0x47, 0x72, 0x8c, 0x17, 0x1e, 0xc9, 0x31, 0x3e, 0x78, 0x24, 0xc7, 0x38,
-	0xe1, 0xb1, 0x1c, 0xc3, 0x85, 0xc7, 0x72, 0x0c, 0x37, 0x1e, 0xcb, 0x31, 0x44, 0x31, 0x15, 0x24,
-	0x25, 0xb1, 0x81, 0x6d, 0x36, 0x06, 0x04, 0x00, 0x00, 0xff, 0xff, 0xa7, 0xd9, 0xc8, 0x95, 0x8a,
-	0x00, 0x00, 0x00,
+	0x18, 0x48, 0xb7, 0x73, 0x46, 0x62, 0x66, 0x9e, 0xa7, 0x8b, 0x04, 0xb3, 0x02, 0xa3, 0x06, 0x73,
+	0x10, 0x8c, 0xab, 0xa4, 0xc2, 0xc5, 0x06, 0x66, 0x16, 0x0b, 0x49, 0x71, 0x71, 0x40, 0x05, 0x41,
+	0x56, 0x30, 0x6b, 0x30, 0x07, 0xc1, 0xf9, 0x4e, 0x32, 0x27, 0x1e, 0xc9, 0x31, 0x5e, 0x78, 0x24,
+	0xc7, 0xf8, 0xe0, 0x91, 0x1c, 0xe3, 0x84, 0xc7, 0x72, 0x0c, 0x17, 0x1e, 0xcb, 0x31, 0xdc, 0x78,
+	0x2c, 0xc7, 0x10, 0xc5, 0x54, 0x90, 0x94, 0xc4, 0x06, 0x76, 0xb9, 0x31, 0x60, 0x00, 0xfe, 0x9c,
+	0xfe, 0x7e, 0xca, 0x00, 0x00, 0x00,
 }
 
 func (m *EmitCheque) Marshal() (dAtA []byte, err error) {
@@ -113,6 +168,11 @@ func (m *EmitCheque) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.ChainID != 0 {
+		i = encodeVarintTraffic(dAtA, i, uint64(m.ChainID))
+		i--
+		dAtA[i] = 0x18
+	}
 	if len(m.SignedCheque) > 0 {
 		i -= len(m.SignedCheque)
 		copy(dAtA[i:], m.SignedCheque)
@@ -130,6 +190,48 @@ func (m *EmitCheque) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *Chains) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Chains) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Chains) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.ChainIDs) > 0 {
+		dAtA2 := make([]byte, len(m.ChainIDs)*10)
+		var j1 int
+		for _, num1 := range m.ChainIDs {
+			num := uint64(num1)
+			for num >= 1<<7 {
+				dAtA2[j1] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j1++
+			}
+			dAtA2[j1] = uint8(num)
+			j1++
+		}
+		i -= j1
+		copy(dAtA[i:], dAtA2[:j1])
+		i = encodeVarintTraffic(dAtA, i, uint64(j1))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintTraffic(dAtA []byte, offset int, v uint64) int {
 	offset -= sovTraffic(v)
 	base := offset
@@ -155,6 +257,25 @@ func (m *EmitCheque) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovTraffic(uint64(l))
 	}
+	if m.ChainID != 0 {
+		n += 1 + sovTraffic(uint64(m.ChainID))
+	}
+	return n
+}
+
+func (m *Chains) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.ChainIDs) > 0 {
+		l = 0
+		for _, e := range m.ChainIDs {
+			l += sovTraffic(uint64(e))
+		}
+		n += 1 + sovTraffic(uint64(l)) + l
+	}
 	return n
 }
 
@@ -261,6 +382,151 @@ func (m *EmitCheque) Unmarshal(dAtA []byte) error {
 				m.SignedCheque = []byte{}
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChainID", wireType)
+			}
+			m.ChainID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTraffic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChainID |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTraffic(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTraffic
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Chains) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTraffic
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Chains: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Chains: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType == 0 {
+				var v int64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowTraffic
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= int64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.ChainIDs = append(m.ChainIDs, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowTraffic
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthTraffic
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthTraffic
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.ChainIDs) == 0 {
+					m.ChainIDs = make([]int64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v int64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowTraffic
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= int64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.ChainIDs = append(m.ChainIDs, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChainIDs", wireType)
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTraffic(dAtA[iNdEx:])
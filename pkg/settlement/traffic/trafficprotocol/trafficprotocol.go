@@ -3,7 +3,9 @@ package trafficprotocol
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/FavorLabs/favorX/pkg/boson"
@@ -20,8 +22,11 @@ const (
 	protocolVersion = "1.0.0"
 	streamName      = "traffic" // stream for cheques
 	initStreamName  = "init"    // stream for handshake
+	chainStreamName = "chain"   // stream for negotiating the settlement chain
 )
 
+var ErrUnknownChain = errors.New("unknown settlement chain")
+
 type Interface interface {
 	// EmitCheque sends a signed cheque to a peer.
 	EmitCheque(ctx context.Context, peer boson.Address, cheque *cheque.SignedCheque) error
@@ -41,15 +46,101 @@ type Service struct {
 	streamer p2p.Streamer
 	logging  logging.Logger
 	address  common.Address
-	traffic  Traffic
+	chains   []int64 // in the order of preference
+	traffic  map[int64]Traffic
+
+	peersMu sync.RWMutex
+	peers   map[string]int64 // chain negotiated with the peer
 }
 
 func New(streamer p2p.Streamer, logging logging.Logger, address common.Address) *Service {
-	return &Service{streamer: streamer, logging: logging, address: address}
+	return &Service{
+		streamer: streamer,
+		logging:  logging,
+		address:  address,
+		traffic:  make(map[int64]Traffic),
+		peers:    make(map[string]int64),
+	}
+}
+
+// AddTraffic settles on the chain with the traffic service. The chains are
+// preferred in the order they are added.
+func (s *Service) AddTraffic(chainID int64, traffic Traffic) {
+	s.chains = append(s.chains, chainID)
+	s.traffic[chainID] = traffic
 }
 
-func (s *Service) SetTraffic(traffic Traffic) {
-	s.traffic = traffic
+// Chain returns the protocol emitting the cheques signed for the chain.
+func (s *Service) Chain(chainID int64) Interface {
+	return &chainProtocol{service: s, chainID: chainID}
+}
+
+// PeerChain returns the chain negotiated with the peer, the preferred one if
+// none was.
+func (s *Service) PeerChain(peer boson.Address) int64 {
+	s.peersMu.RLock()
+	chainID, ok := s.peers[peer.ByteString()]
+	s.peersMu.RUnlock()
+	if ok {
+		return chainID
+	}
+	if len(s.chains) == 0 {
+		return 0
+	}
+	return s.chains[0]
+}
+
+func (s *Service) setPeerChain(peer boson.Address, chainID int64) {
+	s.peersMu.Lock()
+	defer s.peersMu.Unlock()
+	s.peers[peer.ByteString()] = chainID
+}
+
+func (s *Service) disconnect(p p2p.Peer) error {
+	s.peersMu.Lock()
+	defer s.peersMu.Unlock()
+	delete(s.peers, p.Address.ByteString())
+	return nil
+}
+
+// fallbackChain returns the chain to settle on with the peer not negotiating
+// chains: the one its cheques were signed for, or the only chain the node
+// settles on. The node refuses to settle with the peer otherwise.
+func (s *Service) fallbackChain(peer boson.Address) (int64, error) {
+	for _, chainID := range s.chains {
+		c, err := s.traffic[chainID].LastReceivedCheque(peer)
+		if err == nil && c != nil && c.Signature != nil {
+			return chainID, nil
+		}
+	}
+	if len(s.chains) == 1 {
+		return s.chains[0], nil
+	}
+	return 0, fmt.Errorf("no chain agreed with peer %s: %w", peer, ErrUnknownChain)
+}
+
+// chainTraffic returns the traffic service of the chain, or of the chain
+// settled on with the peer if the message names none, as the peers not
+// negotiating chains do.
+func (s *Service) chainTraffic(peer boson.Address, chainID int64) (Traffic, error) {
+	if chainID == 0 {
+		s.peersMu.RLock()
+		negotiated, ok := s.peers[peer.ByteString()]
+		s.peersMu.RUnlock()
+		if ok {
+			chainID = negotiated
+		} else {
+			var err error
+			if chainID, err = s.fallbackChain(peer); err != nil {
+				return nil, err
+			}
+		}
+	}
+	traffic, ok := s.traffic[chainID]
+	if !ok {
+		return nil, fmt.Errorf("chain %d: %w", chainID, ErrUnknownChain)
+	}
+	return traffic, nil
 }
 
 func (s *Service) Protocol() p2p.ProtocolSpec {
@@ -65,8 +156,14 @@ func (s *Service) Protocol() p2p.ProtocolSpec {
 				Name:    initStreamName,
 				Handler: s.initHandler,
 			},
+			{
+				Name:    chainStreamName,
+				Handler: s.chainHandler,
+			},
 		},
-		ConnectOut: s.init,
+		ConnectOut:    s.init,
+		DisconnectIn:  s.disconnect,
+		DisconnectOut: s.disconnect,
 	}
 }
 
@@ -84,17 +181,22 @@ func (s *Service) initHandler(ctx context.Context, p p2p.Peer, stream p2p.Stream
 		return fmt.Errorf("read request from peer %v: %w", p.Address, err)
 	}
 
+	traffic, err := s.chainTraffic(p.Address, req.ChainID)
+	if err != nil {
+		return err
+	}
+
 	var c cheque.SignedCheque
 	err = json.Unmarshal(req.SignedCheque, &c)
 	if err != nil {
 		return err
 	}
-	err = s.traffic.Handshake(p.Address, common.BytesToAddress(req.Address), c)
+	err = traffic.Handshake(p.Address, common.BytesToAddress(req.Address), c)
 	if err != nil {
 		s.logging.Error(err)
 	}
 
-	receiveCheque, err := s.traffic.LastReceivedCheque(p.Address)
+	receiveCheque, err := traffic.LastReceivedCheque(p.Address)
 	if receiveCheque == nil {
 		return err
 	}
@@ -106,6 +208,7 @@ func (s *Service) initHandler(ctx context.Context, p p2p.Peer, stream p2p.Stream
 	err = w.WriteMsgWithContext(ctx, &pb.EmitCheque{
 		Address:      s.address.Bytes(),
 		SignedCheque: signedCheque,
+		ChainID:      req.ChainID,
 	})
 	if err != nil {
 		return err
@@ -113,10 +216,80 @@ func (s *Service) initHandler(ctx context.Context, p p2p.Peer, stream p2p.Stream
 	return nil
 }
 
+// chainHandler settles with the peer on the first chain it offers that the
+// node settles on too, or on the preferred chain of the node if none.
+func (s *Service) chainHandler(ctx context.Context, p p2p.Peer, stream p2p.Stream) (err error) {
+	w, r := protobuf.NewWriterAndReader(stream)
+	defer func() {
+		if err != nil {
+			_ = stream.Reset()
+		} else {
+			go stream.FullClose()
+		}
+	}()
+	var req pb.Chains
+	if err := r.ReadMsgWithContext(ctx, &req); err != nil {
+		return fmt.Errorf("read chains from peer %v: %w", p.Address, err)
+	}
+	if len(s.chains) == 0 {
+		return ErrUnknownChain
+	}
+	chainID := s.chains[0]
+	for _, id := range req.ChainIDs {
+		if _, ok := s.traffic[id]; ok {
+			chainID = id
+			break
+		}
+	}
+	s.setPeerChain(p.Address, chainID)
+	return w.WriteMsgWithContext(ctx, &pb.Chains{ChainIDs: []int64{chainID}})
+}
+
+// negotiate offers the peer the chains of the node and returns the one the
+// peer chose.
+func (s *Service) negotiate(ctx context.Context, peer boson.Address) (chainID int64, err error) {
+	stream, err := s.streamer.NewStream(ctx, peer, nil, protocolName, protocolVersion, chainStreamName)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = stream.Reset()
+		} else {
+			go stream.FullClose()
+		}
+	}()
+	w, r := protobuf.NewWriterAndReader(stream)
+	if err := w.WriteMsgWithContext(ctx, &pb.Chains{ChainIDs: s.chains}); err != nil {
+		return 0, err
+	}
+	var resp pb.Chains
+	if err := r.ReadMsgWithContext(ctx, &resp); err != nil {
+		return 0, fmt.Errorf("read chain from peer %v: %w", peer, err)
+	}
+	if len(resp.ChainIDs) == 0 {
+		return 0, ErrUnknownChain
+	}
+	if _, ok := s.traffic[resp.ChainIDs[0]]; !ok {
+		return 0, fmt.Errorf("chain %d: %w", resp.ChainIDs[0], ErrUnknownChain)
+	}
+	return resp.ChainIDs[0], nil
+}
+
 func (s *Service) init(ctx context.Context, p p2p.Peer) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	chainID, err := s.negotiate(ctx, p.Address)
+	if err != nil {
+		s.logging.Debugf("traffic: negotiate chain with peer %s: %v", p.Address, err)
+		if chainID, err = s.fallbackChain(p.Address); err != nil {
+			return err
+		}
+	}
+	s.setPeerChain(p.Address, chainID)
+	traffic := s.traffic[chainID]
+
 	stream, err := s.streamer.NewStream(ctx, p.Address, nil, protocolName, protocolVersion, initStreamName)
 	if err != nil {
 		return err
@@ -129,7 +302,7 @@ func (s *Service) init(ctx context.Context, p p2p.Peer) error {
 		}
 	}()
 
-	receiveCheque, err := s.traffic.LastReceivedCheque(p.Address)
+	receiveCheque, err := traffic.LastReceivedCheque(p.Address)
 	if receiveCheque == nil {
 		return err
 	}
@@ -142,6 +315,7 @@ func (s *Service) init(ctx context.Context, p p2p.Peer) error {
 	err = w.WriteMsgWithContext(ctx, &pb.EmitCheque{
 		Address:      s.address.Bytes(),
 		SignedCheque: signedCheque,
+		ChainID:      chainID,
 	})
 	if err != nil {
 		return err
@@ -158,7 +332,7 @@ func (s *Service) init(ctx context.Context, p p2p.Peer) error {
 		return err
 	}
 
-	return s.traffic.Handshake(p.Address, common.BytesToAddress(req.Address), c)
+	return traffic.Handshake(p.Address, common.BytesToAddress(req.Address), c)
 }
 
 func (s *Service) handler(ctx context.Context, p p2p.Peer, stream p2p.Stream) (err error) {
@@ -175,16 +349,31 @@ func (s *Service) handler(ctx context.Context, p p2p.Peer, stream p2p.Stream) (e
 		return fmt.Errorf("read request from peer %v: %w", p.Address, err)
 	}
 
+	traffic, err := s.chainTraffic(p.Address, req.ChainID)
+	if err != nil {
+		return err
+	}
+
 	var signedCheque *cheque.SignedCheque
 	err = json.Unmarshal(req.SignedCheque, &signedCheque)
 	if err != nil {
 		return err
 	}
 
-	return s.traffic.ReceiveCheque(ctx, p.Address, signedCheque)
+	return traffic.ReceiveCheque(ctx, p.Address, signedCheque)
+}
+
+// chainProtocol emits the cheques signed for one chain.
+type chainProtocol struct {
+	service *Service
+	chainID int64
+}
+
+func (c *chainProtocol) EmitCheque(ctx context.Context, peer boson.Address, cheque *cheque.SignedCheque) error {
+	return c.service.emitCheque(ctx, peer, cheque, c.chainID)
 }
 
-func (s *Service) EmitCheque(ctx context.Context, peer boson.Address, cheque *cheque.SignedCheque) error {
+func (s *Service) emitCheque(ctx context.Context, peer boson.Address, cheque *cheque.SignedCheque, chainID int64) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -210,5 +399,6 @@ func (s *Service) EmitCheque(ctx context.Context, peer boson.Address, cheque *ch
 	w := protobuf.NewWriter(stream)
 	return w.WriteMsgWithContext(ctx, &pb.EmitCheque{
 		SignedCheque: signedCheque,
+		ChainID:      chainID,
 	})
 }
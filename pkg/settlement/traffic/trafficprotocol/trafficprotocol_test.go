@@ -0,0 +1,198 @@
+package trafficprotocol_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/boson"
+	"github.com/FavorLabs/favorX/pkg/boson/test"
+	"github.com/FavorLabs/favorX/pkg/logging"
+	"github.com/FavorLabs/favorX/pkg/p2p"
+	"github.com/FavorLabs/favorX/pkg/p2p/streamtest"
+	"github.com/FavorLabs/favorX/pkg/settlement/traffic/cheque"
+	"github.com/FavorLabs/favorX/pkg/settlement/traffic/trafficprotocol"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type trafficMock struct {
+	received chan *cheque.SignedCheque
+	last     *cheque.SignedCheque
+}
+
+func newTrafficMock() *trafficMock {
+	return &trafficMock{received: make(chan *cheque.SignedCheque, 1)}
+}
+
+func (m *trafficMock) ReceiveCheque(_ context.Context, _ boson.Address, cheque *cheque.SignedCheque) error {
+	m.received <- cheque
+	return nil
+}
+
+func (m *trafficMock) Handshake(boson.Address, common.Address, cheque.SignedCheque) error {
+	return nil
+}
+
+func (m *trafficMock) LastReceivedCheque(boson.Address) (*cheque.SignedCheque, error) {
+	return m.last, nil
+}
+
+func (m *trafficMock) UpdatePeerBalance(boson.Address) error {
+	return nil
+}
+
+func TestNegotiateChain(t *testing.T) {
+	logger := logging.New(io.Discard, 0)
+	client, server := test.RandomAddress(), test.RandomAddress()
+
+	serverProtocol := trafficprotocol.New(nil, logger, common.Address{})
+	serverTraffic := map[int64]*trafficMock{2: newTrafficMock(), 3: newTrafficMock()}
+	serverProtocol.AddTraffic(3, serverTraffic[3])
+	serverProtocol.AddTraffic(2, serverTraffic[2])
+
+	recorder := streamtest.New(
+		streamtest.WithProtocols(serverProtocol.Protocol()),
+		streamtest.WithBaseAddr(client),
+	)
+	clientProtocol := trafficprotocol.New(recorder, logger, common.Address{})
+	clientProtocol.AddTraffic(1, newTrafficMock())
+	clientProtocol.AddTraffic(2, newTrafficMock())
+
+	if err := clientProtocol.Protocol().ConnectOut(context.Background(), p2p.Peer{Address: server}); err != nil {
+		t.Fatal(err)
+	}
+	// the server settles on the first chain offered it settles on too
+	if got := clientProtocol.PeerChain(server); got != 2 {
+		t.Fatalf("got chain %d negotiated by the client, want 2", got)
+	}
+	if got := serverProtocol.PeerChain(client); got != 2 {
+		t.Fatalf("got chain %d negotiated by the server, want 2", got)
+	}
+
+	signed := &cheque.SignedCheque{Cheque: cheque.Cheque{CumulativePayout: big.NewInt(10)}}
+	if err := clientProtocol.Chain(2).EmitCheque(context.Background(), server, signed); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-serverTraffic[2].received:
+		if got.CumulativePayout.Cmp(signed.CumulativePayout) != 0 {
+			t.Fatalf("got cheque %+v, want %+v", got, signed)
+		}
+	case <-serverTraffic[3].received:
+		t.Fatal("cheque received on the preferred chain of the server")
+	case <-time.After(5 * time.Second):
+		t.Fatal("cheque not received")
+	}
+}
+
+func TestNegotiateNoCommonChain(t *testing.T) {
+	logger := logging.New(io.Discard, 0)
+	client, server := test.RandomAddress(), test.RandomAddress()
+
+	serverProtocol := trafficprotocol.New(nil, logger, common.Address{})
+	serverProtocol.AddTraffic(3, newTrafficMock())
+	recorder := streamtest.New(
+		streamtest.WithProtocols(serverProtocol.Protocol()),
+		streamtest.WithBaseAddr(client),
+	)
+	clientProtocol := trafficprotocol.New(recorder, logger, common.Address{})
+	clientProtocol.AddTraffic(1, newTrafficMock())
+
+	if err := clientProtocol.Protocol().ConnectOut(context.Background(), p2p.Peer{Address: server}); err != nil {
+		t.Fatal(err)
+	}
+	// both settle on their preferred chain, as the nodes did before
+	// negotiating chains
+	if got := clientProtocol.PeerChain(server); got != 1 {
+		t.Fatalf("got chain %d negotiated by the client, want 1", got)
+	}
+	if got := serverProtocol.PeerChain(client); got != 3 {
+		t.Fatalf("got chain %d negotiated by the server, want 3", got)
+	}
+}
+
+// legacyProtocol is the protocol of the peers not negotiating chains.
+func legacyProtocol(s *trafficprotocol.Service) p2p.ProtocolSpec {
+	spec := s.Protocol()
+	var streams []p2p.StreamSpec
+	for _, ss := range spec.StreamSpecs {
+		if ss.Name != "chain" {
+			streams = append(streams, ss)
+		}
+	}
+	spec.StreamSpecs = streams
+	return spec
+}
+
+func TestNegotiateLegacyPeer(t *testing.T) {
+	logger := logging.New(io.Discard, 0)
+	client, server := test.RandomAddress(), test.RandomAddress()
+
+	serverProtocol := trafficprotocol.New(nil, logger, common.Address{})
+	serverTraffic := newTrafficMock()
+	serverTraffic.last = &cheque.SignedCheque{Signature: []byte{1}}
+	serverProtocol.AddTraffic(2, serverTraffic)
+	recorder := streamtest.New(
+		streamtest.WithProtocols(legacyProtocol(serverProtocol)),
+		streamtest.WithBaseAddr(client),
+	)
+
+	t.Run("cheques", func(t *testing.T) {
+		clientProtocol := trafficprotocol.New(recorder, logger, common.Address{})
+		signed := newTrafficMock()
+		signed.last = &cheque.SignedCheque{Signature: []byte{1}}
+		clientProtocol.AddTraffic(1, newTrafficMock())
+		clientProtocol.AddTraffic(2, signed)
+
+		if err := clientProtocol.Protocol().ConnectOut(context.Background(), p2p.Peer{Address: server}); err != nil {
+			t.Fatal(err)
+		}
+		// the peer settles on the chain its cheques were signed for
+		if got := clientProtocol.PeerChain(server); got != 2 {
+			t.Fatalf("got chain %d, want the chain of the cheques 2", got)
+		}
+	})
+
+	t.Run("no cheques", func(t *testing.T) {
+		clientProtocol := trafficprotocol.New(recorder, logger, common.Address{})
+		clientProtocol.AddTraffic(1, newTrafficMock())
+		clientProtocol.AddTraffic(2, newTrafficMock())
+
+		err := clientProtocol.Protocol().ConnectOut(context.Background(), p2p.Peer{Address: server})
+		if !errors.Is(err, trafficprotocol.ErrUnknownChain) {
+			t.Fatalf("got error %v, want %v", err, trafficprotocol.ErrUnknownChain)
+		}
+	})
+}
+
+func TestDisconnectForgetsChain(t *testing.T) {
+	logger := logging.New(io.Discard, 0)
+	client, server := test.RandomAddress(), test.RandomAddress()
+
+	serverProtocol := trafficprotocol.New(nil, logger, common.Address{})
+	serverProtocol.AddTraffic(2, newTrafficMock())
+	recorder := streamtest.New(
+		streamtest.WithProtocols(serverProtocol.Protocol()),
+		streamtest.WithBaseAddr(client),
+	)
+	clientProtocol := trafficprotocol.New(recorder, logger, common.Address{})
+	clientProtocol.AddTraffic(1, newTrafficMock())
+	clientProtocol.AddTraffic(2, newTrafficMock())
+
+	spec := clientProtocol.Protocol()
+	if err := spec.ConnectOut(context.Background(), p2p.Peer{Address: server}); err != nil {
+		t.Fatal(err)
+	}
+	if got := clientProtocol.PeerChain(server); got != 2 {
+		t.Fatalf("got chain %d, want 2", got)
+	}
+	if err := spec.DisconnectOut(p2p.Peer{Address: server}); err != nil {
+		t.Fatal(err)
+	}
+	if got := clientProtocol.PeerChain(server); got != 1 {
+		t.Fatalf("got chain %d of the disconnected peer, want the preferred 1", got)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build bbolt
+// +build bbolt
+
+package shed
+
+import "github.com/FavorLabs/favorX/pkg/shed/bbolt"
+
+const BBOLT = "bbolt"
+
+func init() {
+	Register(BBOLT, bbolt.Driver{})
+}
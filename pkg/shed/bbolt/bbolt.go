@@ -0,0 +1,267 @@
+package bbolt
+
+import (
+	"bytes"
+	"errors"
+	"os"
+
+	"github.com/FavorLabs/favorX/pkg/shed/driver"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucket holds all the keys. The key spaces are told apart by their first
+// byte, the same way as in the leveldb driver.
+var bucket = []byte("shed")
+
+type BoltDB struct {
+	db     *bolt.DB
+	path   string
+	remove string // temporary directory removed on close
+}
+
+func (b *BoltDB) Put(key driver.Key, value driver.Value) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put(key.Data, value.Data)
+	})
+}
+
+func (b *BoltDB) Get(key driver.Key) (value []byte, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		value, err = get(tx, key)
+		return err
+	})
+	return value, err
+}
+
+func (b *BoltDB) Has(key driver.Key) (yes bool, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		yes = tx.Bucket(bucket).Get(key.Data) != nil
+		return nil
+	})
+	return yes, err
+}
+
+func (b *BoltDB) Delete(key driver.Key) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete(key.Data)
+	})
+}
+
+// get returns a copy of the value, as the values returned by bolt are
+// only valid while the transaction is open.
+func get(tx *bolt.Tx, key driver.Key) ([]byte, error) {
+	v := tx.Bucket(bucket).Get(key.Data)
+	if v == nil {
+		return nil, driver.ErrNotFound
+	}
+	return clone(v), nil
+}
+
+// clone copies b, keeping empty values distinct from missing ones.
+func clone(b []byte) []byte {
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
+
+func (b *BoltDB) Search(query driver.Query) driver.Cursor {
+	c := &Cursor{
+		db: b.db,
+		q:  query,
+	}
+	c.move(func(bc *bolt.Cursor) ([]byte, []byte) {
+		return bc.Seek(query.Prefix.Data)
+	})
+	return c
+}
+
+// GetSnapshot returns a snapshot backed by a read transaction. Snapshots
+// must be closed shortly, since an open read transaction blocks writes that
+// need to grow the memory map of the database.
+func (b *BoltDB) GetSnapshot() (driver.Snapshot, error) {
+	tx, err := b.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{tx: tx}, nil
+}
+
+type Snapshot struct {
+	tx *bolt.Tx
+}
+
+func (s *Snapshot) Get(key driver.Key) ([]byte, error) {
+	return get(s.tx, key)
+}
+
+func (s *Snapshot) Has(key driver.Key) (bool, error) {
+	return s.tx.Bucket(bucket).Get(key.Data) != nil, nil
+}
+
+func (s *Snapshot) Close() error {
+	return s.tx.Rollback()
+}
+
+// Cursor iterates over the keys without holding a transaction between the
+// moves, so that the keys can be written while iterating. Every move opens
+// a read transaction and positions itself relative to the current key.
+type Cursor struct {
+	db    *bolt.DB
+	q     driver.Query
+	key   []byte
+	value []byte
+	valid bool
+	err   error
+}
+
+// move positions the cursor on the key returned by fn.
+func (c *Cursor) move(fn func(bc *bolt.Cursor) ([]byte, []byte)) bool {
+	err := c.db.View(func(tx *bolt.Tx) error {
+		k, v := fn(tx.Bucket(bucket).Cursor())
+		if k == nil || c.q.MatchPrefix && !bytes.HasPrefix(k, c.q.Prefix.Data) {
+			c.key, c.value, c.valid = nil, nil, false
+			return nil
+		}
+		c.key = append(c.key[:0], k...)
+		c.value = clone(v)
+		c.valid = true
+		return nil
+	})
+	if err != nil {
+		c.err = err
+		c.key, c.value, c.valid = nil, nil, false
+	}
+	return c.valid
+}
+
+func (c *Cursor) Next() bool {
+	if !c.valid {
+		return false
+	}
+	return c.move(func(bc *bolt.Cursor) ([]byte, []byte) {
+		k, v := bc.Seek(c.key)
+		if bytes.Equal(k, c.key) {
+			return bc.Next()
+		}
+		return k, v
+	})
+}
+
+func (c *Cursor) Prev() bool {
+	if !c.valid {
+		return false
+	}
+	return c.move(func(bc *bolt.Cursor) ([]byte, []byte) {
+		if k, _ := bc.Seek(c.key); k == nil {
+			return bc.Last()
+		}
+		return bc.Prev()
+	})
+}
+
+func (c *Cursor) Last() bool {
+	return c.move(func(bc *bolt.Cursor) ([]byte, []byte) {
+		if c.q.MatchPrefix {
+			if next := bytesIncrement(c.q.Prefix.Data); next != nil {
+				if k, _ := bc.Seek(next); k != nil {
+					return bc.Prev()
+				}
+			}
+		}
+		return bc.Last()
+	})
+}
+
+func (c *Cursor) Seek(key driver.Key) bool {
+	return c.move(func(bc *bolt.Cursor) ([]byte, []byte) {
+		return bc.Seek(key.Data)
+	})
+}
+
+func (c *Cursor) Key() []byte {
+	return c.key
+}
+
+func (c *Cursor) Value() []byte {
+	return c.value
+}
+
+func (c *Cursor) Valid() bool {
+	return c.valid
+}
+
+func (c *Cursor) Error() error {
+	return c.err
+}
+
+func (c *Cursor) Close() error {
+	c.key, c.value, c.valid = nil, nil, false
+	return nil
+}
+
+// bytesIncrement returns the smallest key greater than every key with
+// the prefix, or nil if there is none.
+func bytesIncrement(prefix []byte) []byte {
+	b := append([]byte(nil), prefix...)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] != 0xff {
+			b[i]++
+			return b[:i+1]
+		}
+	}
+	return nil
+}
+
+type batchOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+// Batch collects the operations and writes them in a single transaction.
+type Batch struct {
+	db  *bolt.DB
+	ops []batchOp
+}
+
+func (b *Batch) Put(key driver.Key, value driver.Value) error {
+	b.ops = append(b.ops, batchOp{key: clone(key.Data), value: clone(value.Data)})
+	return nil
+}
+
+func (b *Batch) Delete(key driver.Key) error {
+	b.ops = append(b.ops, batchOp{key: clone(key.Data), delete: true})
+	return nil
+}
+
+func (b *Batch) Commit() error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bk := tx.Bucket(bucket)
+		for _, op := range b.ops {
+			var err error
+			if op.delete {
+				err = bk.Delete(op.key)
+			} else {
+				err = bk.Put(op.key, op.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *BoltDB) NewBatch() driver.Batching {
+	return &Batch{db: b.db}
+}
+
+func (b *BoltDB) Close() error {
+	err := b.db.Close()
+	if b.remove != "" {
+		if rerr := os.RemoveAll(b.remove); err == nil && !errors.Is(rerr, os.ErrNotExist) {
+			err = rerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,104 @@
+// Package bbolt implements a shed driver on top of bbolt, a pure Go
+// B+tree database, so that it can be built without cgo.
+package bbolt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/shed/driver"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Driver is exported to make the driver directly accessible.
+// In general the driver is used via the shed/driver package.
+type Driver struct{}
+
+const dataFile = "data.db"
+
+var (
+	defaultTimeout         = time.Second
+	defaultNoGrowSync      = false
+	defaultNoFreelistSync  = true
+	defaultFreelistType    = bolt.FreelistMapType
+	defaultInitialMmapSize = 0
+	defaultNoSync          = false
+)
+
+// Open opens the database in the directory at path. An empty path opens
+// the database in a temporary directory which is removed when it is closed.
+func (d Driver) Open(path, options string) (driver.DB, error) {
+	var c Configuration
+
+	exported := c.Options(
+		c.SetTimeout(defaultTimeout),
+		c.SetNoGrowSync(defaultNoGrowSync),
+		c.SetNoFreelistSync(defaultNoFreelistSync),
+		c.SetFreelistType(defaultFreelistType),
+		c.SetInitialMmapSize(defaultInitialMmapSize),
+		c.SetNoSync(defaultNoSync),
+	)
+
+	if options != "" {
+		// the options are decoded over the defaults, so the defaults can be
+		// overridden with zero values too
+		var override map[string]json.RawMessage
+		err := json.Unmarshal([]byte(options), &override)
+		if err != nil {
+			return nil, err
+		}
+		cv := reflect.ValueOf(&c).Elem()
+		for name, value := range override {
+			if _, ok := exported[name]; !ok {
+				continue
+			}
+			if err := json.Unmarshal(value, cv.FieldByName(name).Addr().Interface()); err != nil {
+				return nil, fmt.Errorf("option %s: %w", name, err)
+			}
+		}
+	}
+
+	opts := bolt.Options(c)
+
+	temporary := path == ""
+	if temporary {
+		dir, err := os.MkdirTemp("", "shed-bbolt")
+		if err != nil {
+			return nil, err
+		}
+		path = dir
+	} else if err := os.MkdirAll(path, 0o755); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(filepath.Join(path, dataFile), 0o600, &opts)
+	if err == nil {
+		err = db.Update(func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(bucket)
+			return err
+		})
+		if err != nil {
+			_ = db.Close()
+		}
+	}
+	if err != nil {
+		if temporary {
+			_ = os.RemoveAll(path)
+		}
+		return nil, err
+	}
+
+	bdb := &BoltDB{
+		db:   db,
+		path: path,
+	}
+	if temporary {
+		bdb.remove = path
+	}
+
+	return bdb, nil
+}
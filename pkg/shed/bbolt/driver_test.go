@@ -0,0 +1,31 @@
+package bbolt
+
+import "testing"
+
+func TestOpenOptions(t *testing.T) {
+	db, err := Driver{}.Open("", `{"NoFreelistSync":false,"ReadOnly":true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	bdb := db.(*BoltDB).db
+	if bdb.NoFreelistSync {
+		t.Fatal("the freelist sync is not enabled by the options")
+	}
+	if bdb.IsReadOnly() {
+		t.Fatal("the database is opened read only by an option that is not exported")
+	}
+	if bdb.FreelistType != defaultFreelistType {
+		t.Fatalf("got freelist type %q, want the default %q", bdb.FreelistType, defaultFreelistType)
+	}
+
+	db, err = Driver{}.Open("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if !db.(*BoltDB).db.NoFreelistSync {
+		t.Fatal("the freelist sync is not disabled by default")
+	}
+}
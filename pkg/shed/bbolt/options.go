@@ -0,0 +1,91 @@
+package bbolt
+
+import (
+	"time"
+
+	"github.com/FavorLabs/favorX/pkg/shed/driver"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	optionTimeout         = "Timeout"
+	optionNoGrowSync      = "NoGrowSync"
+	optionNoFreelistSync  = "NoFreelistSync"
+	optionFreelistType    = "FreelistType"
+	optionInitialMmapSize = "InitialMmapSize"
+	optionNoSync          = "NoSync"
+)
+
+type Configuration bolt.Options
+
+func (c *Configuration) Options(opts ...driver.Option) map[string]struct{} {
+	exported := make(map[string]struct{})
+
+	for _, o := range opts {
+		switch o.Identity() {
+		case optionTimeout:
+			c.Timeout = o.Value().(time.Duration)
+		case optionNoGrowSync:
+			c.NoGrowSync = o.Value().(bool)
+		case optionNoFreelistSync:
+			c.NoFreelistSync = o.Value().(bool)
+		case optionFreelistType:
+			c.FreelistType = o.Value().(bolt.FreelistType)
+		case optionInitialMmapSize:
+			c.InitialMmapSize = o.Value().(int)
+		case optionNoSync:
+			c.NoSync = o.Value().(bool)
+		}
+
+		if o.Exported() {
+			exported[o.Identity()] = struct{}{}
+		}
+	}
+
+	return exported
+}
+
+// SetTimeout defines how long to wait for the file lock of a database
+// opened by another process, zero waits indefinitely.
+func (c *Configuration) SetTimeout(d time.Duration) driver.Option {
+	o := driver.NewOption(optionTimeout, time.Duration(0), true)
+	o.Set(d)
+	return o
+}
+
+// SetNoGrowSync defines whether the file is not synced when it grows.
+func (c *Configuration) SetNoGrowSync(b bool) driver.Option {
+	o := driver.NewOption(optionNoGrowSync, false, true)
+	o.Set(b)
+	return o
+}
+
+// SetNoFreelistSync defines whether the freelist is not written to disk,
+// it is rebuilt when the database is opened instead.
+func (c *Configuration) SetNoFreelistSync(b bool) driver.Option {
+	o := driver.NewOption(optionNoFreelistSync, false, true)
+	o.Set(b)
+	return o
+}
+
+// SetFreelistType defines the backend of the freelist, array or hashmap.
+func (c *Configuration) SetFreelistType(t bolt.FreelistType) driver.Option {
+	o := driver.NewOption(optionFreelistType, bolt.FreelistType(""), true)
+	o.Set(t)
+	return o
+}
+
+// SetInitialMmapSize defines the initial size of the memory map, which
+// avoids remapping while the database grows below it.
+func (c *Configuration) SetInitialMmapSize(n int) driver.Option {
+	o := driver.NewOption(optionInitialMmapSize, int(0), true)
+	o.Set(n)
+	return o
+}
+
+// SetNoSync defines whether the file is not synced after each commit.
+func (c *Configuration) SetNoSync(b bool) driver.Option {
+	o := driver.NewOption(optionNoSync, false, true)
+	o.Set(b)
+	return o
+}
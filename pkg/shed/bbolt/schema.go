@@ -0,0 +1,150 @@
+package bbolt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/FavorLabs/favorX/pkg/shed/driver"
+)
+
+var (
+	// Key value for storing the schema.
+	keySchema = []byte{0}
+	// Key prefix for all field type.
+	// Keys will be constructed by appending name values to this prefix.
+	keyPrefixFields byte = 1
+	// Key prefix from which indexing keys start.
+	// Every index has its own key prefix and this value defines the first one.
+	keyPrefixIndexStart byte = 2 // Q: or maybe a higher number like 7, to have more space for potential specific perfixes
+)
+
+func (b *BoltDB) DefaultFieldKey() []byte {
+	return []byte{keyPrefixFields}
+}
+
+func (b *BoltDB) DefaultIndexKey() []byte {
+	return []byte{keyPrefixIndexStart}
+}
+
+func (b *BoltDB) InitSchema() error {
+	_, err := b.getSchema()
+	if err != nil {
+		if errors.Is(err, driver.ErrNotFound) {
+			// Save schema with initialized default fields.
+			err = b.putSchema(driver.SchemaSpec{
+				Fields:  make([]driver.FieldSpec, 0),
+				Indexes: make([]driver.IndexSpec, 0),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (b *BoltDB) CreateField(spec driver.FieldSpec) ([]byte, error) {
+	if spec.Name == "" {
+		return nil, errors.New("field name cannot be blank")
+	}
+	if spec.Type == "" {
+		return nil, errors.New("field type cannot be blank")
+	}
+	s, err := b.getSchema()
+	if err != nil {
+		return nil, fmt.Errorf("get schema: %w", err)
+	}
+	var found bool
+	for _, f := range s.Fields {
+		if f.Name == spec.Name {
+			if f.Type != spec.Type {
+				return nil, fmt.Errorf("field %q of type %q stored as %q in db", spec.Name, spec.Type, f.Type)
+			}
+			found = true
+			break
+		}
+	}
+	if !found {
+		s.Fields = append(s.Fields, spec)
+		err := b.putSchema(s)
+		if err != nil {
+			return nil, fmt.Errorf("put schema: %w", err)
+		}
+	}
+	return append([]byte{keyPrefixFields}, []byte(spec.Name)...), nil
+}
+
+func (b *BoltDB) CreateIndex(spec driver.IndexSpec) ([]byte, error) {
+	s, err := b.getSchema()
+	if err != nil {
+		return nil, fmt.Errorf("get schema: %w", err)
+	}
+	nextID := keyPrefixIndexStart
+	for _, f := range s.Indexes {
+		if f.Prefix[0] >= nextID {
+			nextID = f.Prefix[0] + 1
+		}
+		if f.Name == spec.Name {
+			return f.Prefix, nil
+		}
+	}
+	id := nextID
+	spec.Prefix = []byte{id}
+	s.Indexes = append(s.Indexes, spec)
+	return spec.Prefix, b.putSchema(s)
+}
+
+func (b *BoltDB) RenameIndex(oldName, newName string) (bool, error) {
+	if oldName == "" {
+		return false, errors.New("index name cannot be blank")
+	}
+	if newName == "" {
+		return false, errors.New("new index name cannot be blank")
+	}
+	if newName == oldName {
+		return false, nil
+	}
+	s, err := b.getSchema()
+	if err != nil {
+		return false, fmt.Errorf("get schema: %w", err)
+	}
+	for i, f := range s.Indexes {
+		if f.Name == oldName {
+			s.Indexes[i].Name = newName
+			return true, b.putSchema(s)
+		}
+		if f.Name == newName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (b *BoltDB) GetSchemaSpec() (driver.SchemaSpec, error) {
+	return b.getSchema()
+}
+
+// getSchema retrieves the complete schema from
+// the database.
+func (b *BoltDB) getSchema() (s driver.SchemaSpec, err error) {
+	data, err := b.Get(driver.Key{Data: keySchema})
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(data, &s)
+	return s, err
+}
+
+// putSchema stores the complete schema to
+// the database.
+func (b *BoltDB) putSchema(s driver.SchemaSpec) (err error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return b.Put(driver.Key{Data: keySchema}, driver.Value{Data: data})
+}
@@ -30,6 +30,7 @@ import (
 	"sync"
 
 	"github.com/FavorLabs/favorX/pkg/shed/driver"
+	"github.com/FavorLabs/favorX/pkg/shed/encrypted"
 )
 
 var (
@@ -37,6 +38,10 @@ var (
 	drivers   = make(map[string]driver.Driver)
 )
 
+// ErrNotEncrypted is returned when the keys of a database without at-rest
+// encryption are rotated.
+var ErrNotEncrypted = errors.New("database is not encrypted")
+
 type ErrDriverNotRegister struct {
 	Name string
 }
@@ -84,12 +89,25 @@ type DB struct {
 
 type Options struct {
 	Driver string
+	// Password enables the at-rest encryption of the database values
+	// with data keys sealed by it, see the encrypted package.
+	Password string
 }
 
 // NewDB constructs a new DB and validates the schema
 // if it exists in database on the given path.
 // metricsPrefix is used for metrics collection for the given DB.
 func NewDB(path string, o *Options) (db *DB, err error) {
+	bi, err := Open(path, o)
+	if err != nil {
+		return nil, err
+	}
+	return NewDBWrap(bi)
+}
+
+// Open opens the database on the given path with the driver of the options.
+// The database is wrapped with the encryption layer if a password is set.
+func Open(path string, o *Options) (driver.BatchDB, error) {
 	var (
 		drv    string
 		config string
@@ -128,7 +146,16 @@ func NewDB(path string, o *Options) (db *DB, err error) {
 		return nil, fmt.Errorf("current backend %s not support batching", drv)
 	}
 
-	return NewDBWrap(bi)
+	if o != nil && o.Password != "" {
+		ei, err := encrypted.Open(bi, path, o.Password)
+		if err != nil {
+			_ = bi.Close()
+			return nil, fmt.Errorf("encrypted: %w", err)
+		}
+		return ei, nil
+	}
+
+	return bi, nil
 }
 
 // NewDBWrap returns new DB which uses the given database as its underlying storage.
@@ -154,6 +181,16 @@ func NewDBWrap(i driver.BatchDB) (db *DB, err error) {
 	return db, nil
 }
 
+// RotateKeys rotates the data keys of an encrypted database and re-encrypts
+// its values while it stays in use.
+func (db *DB) RotateKeys() error {
+	edb, ok := db.backend.(*encrypted.DB)
+	if !ok {
+		return ErrNotEncrypted
+	}
+	return edb.Rotate()
+}
+
 // Put wraps database Put method to increment metrics counter.
 func (db *DB) Put(prefix int, key, value []byte) (err error) {
 	err = db.backend.Put(driver.Key{Prefix: prefix, Data: key}, driver.Value{Data: value})
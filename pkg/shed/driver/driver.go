@@ -66,3 +66,18 @@ type Cursor interface {
 	Error() error
 	io.Closer
 }
+
+// ValueReader is implemented by the cursors whose values can fail to be read
+// on their own, like the values an encryption layer cannot decrypt.
+type ValueReader interface {
+	ReadValue() ([]byte, error)
+}
+
+// ReadValue returns the value at the position of the cursor, or the error
+// reading it.
+func ReadValue(c Cursor) ([]byte, error) {
+	if r, ok := c.(ValueReader); ok {
+		return r.ReadValue()
+	}
+	return c.Value(), nil
+}
@@ -0,0 +1,489 @@
+// Package encrypted provides a shed driver layer which keeps the values of the
+// wrapped database encrypted at rest.
+//
+// Values are sealed with AES-256-GCM under a data key of a Keyring, bound to
+// the key they are stored under. Keys are left as they are, since drivers rely
+// on their order for prefix searches and the indexes key chunks by address.
+// The schema spec is kept by the drivers themselves and stays readable too.
+//
+// Data keys can be rotated while the database is in use: a new key seals all
+// writes from the moment it is activated, and the values sealed with the
+// previous keys are re-encrypted in batches before those keys are retired.
+// Retired keys stay in memory until the cursors and snapshots that were open
+// during the rotation are closed.
+package encrypted
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/FavorLabs/favorX/pkg/shed/driver"
+)
+
+const (
+	envelopeVersion byte = 1
+	headerSize           = 1 + 4 // version and data key identifier
+
+	reencryptBatchSize = 1000
+)
+
+var (
+	// ErrUnencryptedData is returned when encryption is enabled for a database
+	// that already holds data but has no keyring.
+	ErrUnencryptedData = errors.New("database holds data but has no keyring")
+	// ErrMalformedValue is returned when a stored value cannot be decrypted.
+	ErrMalformedValue = errors.New("malformed encrypted value")
+)
+
+var _ driver.BatchDB = (*DB)(nil)
+
+// DB wraps a driver.BatchDB encrypting the values written to it and decrypting
+// the values read from it.
+type DB struct {
+	driver.BatchDB
+	keyring *Keyring
+	mu      sync.RWMutex // writes hold the read lock, key changes and re-encryption hold the write lock
+
+	readersMu sync.Mutex
+	readers   int  // open reads, cursors and snapshots
+	retiring  bool // retired keys are forgotten once there are no readers
+}
+
+// KeyringPath returns the path of the keyring of the database at path.
+func KeyringPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	return path + ".keyring"
+}
+
+// Open wraps the database at path with the encryption layer. The keyring next
+// to the database is opened with the password, or created if the database is
+// still empty. An empty path keeps the keyring in memory.
+func Open(db driver.BatchDB, path, password string) (*DB, error) {
+	var (
+		k   *Keyring
+		err error
+	)
+	kp := KeyringPath(path)
+	if kp != "" {
+		k, err = LoadKeyring(kp, password)
+	}
+	if kp == "" || errors.Is(err, os.ErrNotExist) {
+		empty, err := isEmpty(db)
+		if err != nil {
+			return nil, err
+		}
+		if !empty {
+			return nil, ErrUnencryptedData
+		}
+		k, err = NewKeyring(kp, password)
+		if err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &DB{
+		BatchDB: db,
+		keyring: k,
+	}, nil
+}
+
+// isEmpty reports whether the database holds neither state entries nor fields.
+func isEmpty(db driver.BatchDB) (bool, error) {
+	fk := db.DefaultFieldKey()
+	for _, q := range []driver.Query{
+		{Prefix: driver.Key{}},
+		{Prefix: driver.Key{Prefix: len(fk), Data: fk}, MatchPrefix: true},
+	} {
+		c := db.Search(q)
+		valid := c.Valid()
+		err := c.Error()
+		_ = c.Close()
+		if err != nil {
+			return false, err
+		}
+		if valid {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Keyring returns the keyring of the database.
+func (db *DB) Keyring() *Keyring {
+	return db.keyring
+}
+
+func (db *DB) Get(key driver.Key) ([]byte, error) {
+	db.acquire()
+	defer db.release()
+
+	v, err := db.BatchDB.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return db.open(key.Data, v)
+}
+
+func (db *DB) Put(key driver.Key, value driver.Value) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	v, err := db.seal(key.Data, value.Data)
+	if err != nil {
+		return err
+	}
+	return db.BatchDB.Put(key, driver.Value{Data: v})
+}
+
+func (db *DB) Delete(key driver.Key) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.BatchDB.Delete(key)
+}
+
+func (db *DB) Search(query driver.Query) driver.Cursor {
+	db.acquire()
+	return &cursor{Cursor: db.BatchDB.Search(query), db: db}
+}
+
+func (db *DB) GetSnapshot() (driver.Snapshot, error) {
+	db.acquire()
+	s, err := db.BatchDB.GetSnapshot()
+	if err != nil {
+		db.release()
+		return nil, err
+	}
+	return &snapshot{Snapshot: s, db: db}, nil
+}
+
+// acquire registers a reader which may open values sealed with retired keys.
+func (db *DB) acquire() {
+	db.readersMu.Lock()
+	db.readers++
+	db.readersMu.Unlock()
+}
+
+// release unregisters a reader and forgets the retired keys if it was the
+// last one.
+func (db *DB) release() {
+	db.readersMu.Lock()
+	defer db.readersMu.Unlock()
+
+	db.readers--
+	if db.readers == 0 && db.retiring {
+		db.keyring.Forget()
+		db.retiring = false
+	}
+}
+
+// retire retires every data key but the active one, keeping them in memory
+// while there are readers.
+func (db *DB) retire() error {
+	if err := db.keyring.Retire(); err != nil {
+		return err
+	}
+	db.readersMu.Lock()
+	defer db.readersMu.Unlock()
+
+	if db.readers == 0 {
+		db.keyring.Forget()
+		return nil
+	}
+	db.retiring = true
+	return nil
+}
+
+func (db *DB) NewBatch() driver.Batching {
+	return &batch{db: db}
+}
+
+// ChangePassword seals the keyring with the new password. The data keys and
+// the stored values stay unchanged.
+func (db *DB) ChangePassword(password string) error {
+	return db.keyring.ChangePassword(password)
+}
+
+// Rotate activates a new data key, re-encrypts every value with it and
+// retires the previous keys. The database can be used meanwhile.
+func (db *DB) Rotate() error {
+	db.mu.Lock()
+	err := db.keyring.Rotate()
+	db.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return db.Reencrypt()
+}
+
+// Reencrypt seals every value that is not sealed with the active data key
+// with it and retires the other keys. It completes an interrupted rotation.
+func (db *DB) Reencrypt() error {
+	queries := []driver.Query{{Prefix: driver.Key{}}}
+	fk := db.DefaultFieldKey()
+	queries = append(queries, driver.Query{Prefix: driver.Key{Prefix: len(fk), Data: fk}, MatchPrefix: true})
+	spec, err := db.GetSchemaSpec()
+	if err != nil && !errors.Is(err, driver.ErrNotFound) {
+		return fmt.Errorf("get schema: %w", err)
+	}
+	il := len(db.DefaultIndexKey())
+	for _, i := range spec.Indexes {
+		queries = append(queries, driver.Query{Prefix: driver.Key{Prefix: il, Data: i.Prefix}, MatchPrefix: true})
+	}
+
+	for _, q := range queries {
+		if err := db.reencrypt(q); err != nil {
+			return err
+		}
+	}
+	return db.retire()
+}
+
+// reencrypt re-seals the values matched by the query that are not sealed with
+// the active data key. Values are collected from a cursor and rewritten in
+// batches, each under the write lock after checking that the value has not
+// been changed since it was read.
+func (db *DB) reencrypt(q driver.Query) error {
+	active := db.keyring.Active()
+	pending := make([][]byte, 0, reencryptBatchSize)
+
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		db.mu.Lock()
+		defer db.mu.Unlock()
+
+		b := db.BatchDB.NewBatch()
+		for _, k := range pending {
+			key := driver.Key{Prefix: q.Prefix.Prefix, Data: k}
+			v, err := db.BatchDB.Get(key)
+			if err != nil {
+				if errors.Is(err, driver.ErrNotFound) {
+					continue
+				}
+				return err
+			}
+			if id, ok := envelopeKey(v); !ok || id == active {
+				continue
+			}
+			plain, err := db.open(k, v)
+			if err != nil {
+				return err
+			}
+			sealed, err := db.seal(k, plain)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(key, driver.Value{Data: sealed}); err != nil {
+				return err
+			}
+		}
+		pending = pending[:0]
+		return b.Commit()
+	}
+
+	c := db.BatchDB.Search(q)
+	defer c.Close()
+	for ; c.Valid(); c.Next() {
+		k := c.Key()
+		// values that are not sealed are kept by the driver itself, like the schema
+		if id, ok := envelopeKey(c.Value()); !ok || id == active {
+			continue
+		}
+		pending = append(pending, append([]byte(nil), k...))
+		if len(pending) == reencryptBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := c.Error(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// seal encrypts the value with the active data key. The result holds the
+// envelope version, the key identifier, the nonce and the sealed value.
+func (db *DB) seal(key, value []byte) ([]byte, error) {
+	id, aead := db.keyring.activeKey()
+	ns := aead.NonceSize()
+	out := make([]byte, headerSize+ns, headerSize+ns+len(value)+aead.Overhead())
+	out[0] = envelopeVersion
+	binary.BigEndian.PutUint32(out[1:headerSize], id)
+	nonce := out[headerSize:]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("read random data: %w", err)
+	}
+	return aead.Seal(out, nonce, value, key), nil
+}
+
+// open decrypts a value sealed under the key.
+func (db *DB) open(key, value []byte) ([]byte, error) {
+	id, ok := envelopeKey(value)
+	if !ok {
+		return nil, ErrMalformedValue
+	}
+	aead, ok := db.keyring.key(id)
+	if !ok {
+		return nil, fmt.Errorf("key %d: %w", id, ErrUnknownKey)
+	}
+	ns := aead.NonceSize()
+	if len(value) < headerSize+ns+aead.Overhead() {
+		return nil, ErrMalformedValue
+	}
+	v, err := aead.Open(nil, value[headerSize:headerSize+ns], value[headerSize+ns:], key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedValue, err)
+	}
+	return v, nil
+}
+
+// envelopeKey returns the identifier of the data key the value is sealed with.
+func envelopeKey(value []byte) (uint32, bool) {
+	if len(value) < headerSize || value[0] != envelopeVersion {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(value[1:headerSize]), true
+}
+
+type cursor struct {
+	driver.Cursor
+	db     *DB
+	err    error
+	closed bool
+}
+
+// ReadValue decrypts the value at the position of the cursor.
+func (c *cursor) ReadValue() ([]byte, error) {
+	v, err := c.db.open(c.Cursor.Key(), c.Cursor.Value())
+	if err != nil {
+		c.err = err
+		return nil, err
+	}
+	return v, nil
+}
+
+// Value returns nil if the value cannot be decrypted, and the cursor is no
+// longer valid so the loops over it stop with the error.
+func (c *cursor) Value() []byte {
+	v, _ := c.ReadValue()
+	return v
+}
+
+func (c *cursor) Valid() bool {
+	return c.err == nil && c.Cursor.Valid()
+}
+
+func (c *cursor) Next() bool {
+	return c.err == nil && c.Cursor.Next()
+}
+
+func (c *cursor) Prev() bool {
+	return c.err == nil && c.Cursor.Prev()
+}
+
+func (c *cursor) Last() bool {
+	return c.err == nil && c.Cursor.Last()
+}
+
+func (c *cursor) Seek(key driver.Key) bool {
+	return c.err == nil && c.Cursor.Seek(key)
+}
+
+func (c *cursor) Error() error {
+	if c.err != nil {
+		return c.err
+	}
+	return c.Cursor.Error()
+}
+
+func (c *cursor) Close() error {
+	if !c.closed {
+		c.closed = true
+		defer c.db.release()
+	}
+	return c.Cursor.Close()
+}
+
+type snapshot struct {
+	driver.Snapshot
+	db     *DB
+	closed bool
+}
+
+func (s *snapshot) Close() error {
+	if !s.closed {
+		s.closed = true
+		defer s.db.release()
+	}
+	return s.Snapshot.Close()
+}
+
+func (s *snapshot) Get(key driver.Key) ([]byte, error) {
+	v, err := s.Snapshot.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.db.open(key.Data, v)
+}
+
+type batchOp struct {
+	key    driver.Key
+	value  []byte
+	delete bool
+}
+
+// batch collects the operations and seals the values on commit, so that
+// a rotation never misses values sealed with a retired key.
+type batch struct {
+	db  *DB
+	ops []batchOp
+}
+
+func (b *batch) Put(key driver.Key, value driver.Value) error {
+	b.ops = append(b.ops, batchOp{key: copyKey(key), value: append([]byte(nil), value.Data...)})
+	return nil
+}
+
+func (b *batch) Delete(key driver.Key) error {
+	b.ops = append(b.ops, batchOp{key: copyKey(key), delete: true})
+	return nil
+}
+
+// copyKey copies the key data, which callers may reuse before the commit.
+func copyKey(key driver.Key) driver.Key {
+	return driver.Key{Prefix: key.Prefix, Data: append([]byte(nil), key.Data...)}
+}
+
+func (b *batch) Commit() error {
+	b.db.mu.RLock()
+	defer b.db.mu.RUnlock()
+
+	nb := b.db.BatchDB.NewBatch()
+	for _, op := range b.ops {
+		if op.delete {
+			if err := nb.Delete(op.key); err != nil {
+				return err
+			}
+			continue
+		}
+		v, err := b.db.seal(op.key.Data, op.value)
+		if err != nil {
+			return err
+		}
+		if err := nb.Put(op.key, driver.Value{Data: v}); err != nil {
+			return err
+		}
+	}
+	return nb.Commit()
+}
@@ -0,0 +1,286 @@
+package encrypted_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/FavorLabs/favorX/pkg/shed/driver"
+	"github.com/FavorLabs/favorX/pkg/shed/encrypted"
+	"github.com/FavorLabs/favorX/pkg/shed/leveldb"
+)
+
+func newTestDriver(t *testing.T, path string) driver.BatchDB {
+	t.Helper()
+
+	db, err := leveldb.Driver{}.Open(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db.(driver.BatchDB)
+}
+
+func stateKey(i int) driver.Key {
+	return driver.Key{Data: []byte(fmt.Sprintf("key-%04d", i))}
+}
+
+func TestPutGet(t *testing.T) {
+	raw := newTestDriver(t, "")
+	defer raw.Close()
+
+	db, err := encrypted.Open(raw, "", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := stateKey(0)
+	value := []byte("some value")
+	if err := db.Put(key, driver.Value{Data: value}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := db.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, value) {
+		t.Fatalf("got value %q, want %q", got, value)
+	}
+
+	stored, err := raw.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stored, value) {
+		t.Fatal("value is stored in plaintext")
+	}
+
+	b := db.NewBatch()
+	if err := b.Put(stateKey(1), driver.Value{Data: value}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Get(key); !errors.Is(err, driver.ErrNotFound) {
+		t.Fatalf("got error %v, want %v", err, driver.ErrNotFound)
+	}
+
+	c := db.Search(driver.Query{Prefix: driver.Key{}})
+	defer c.Close()
+	if !c.Valid() {
+		t.Fatal("no value found")
+	}
+	if !bytes.Equal(c.Value(), value) {
+		t.Fatalf("got value %q, want %q", c.Value(), value)
+	}
+	if err := c.Error(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	raw := newTestDriver(t, "")
+	defer raw.Close()
+
+	db, err := encrypted.Open(raw, "", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const count = 2500
+	for i := 0; i < count; i++ {
+		if err := db.Put(stateKey(i), driver.Value{Data: []byte(fmt.Sprint(i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := count; i < 2*count; i++ {
+			if err := db.Put(stateKey(i), driver.Value{Data: []byte(fmt.Sprint(i))}); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	if err := db.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	if l := db.Keyring().Len(); l != 1 {
+		t.Fatalf("got %d keys, want 1", l)
+	}
+	for i := 0; i < 2*count; i++ {
+		v, err := db.Get(stateKey(i))
+		if err != nil {
+			t.Fatalf("key %d: %v", i, err)
+		}
+		if string(v) != fmt.Sprint(i) {
+			t.Fatalf("key %d: got value %q", i, v)
+		}
+	}
+}
+
+func TestRotateOpenReaders(t *testing.T) {
+	raw := newTestDriver(t, "")
+	defer raw.Close()
+
+	db, err := encrypted.Open(raw, "", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const count = 10
+	for i := 0; i < count; i++ {
+		if err := db.Put(stateKey(i), driver.Value{Data: []byte(fmt.Sprint(i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stale, err := raw.Get(stateKey(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := db.GetSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := db.Search(driver.Query{Prefix: driver.Key{}})
+
+	if err := db.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// readers opened before the rotation see the values sealed with the retired key
+	for i := 0; c.Valid(); c.Next() {
+		if v := c.Value(); string(v) != fmt.Sprint(i) {
+			t.Fatalf("cursor key %d: got value %q, error %v", i, v, c.Error())
+		}
+		i++
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	v, err := snapshot.Get(stateKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(v) != "1" {
+		t.Fatalf("snapshot: got value %q", v)
+	}
+	if err := snapshot.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the retired key is forgotten once the readers are closed
+	if err := raw.Put(stateKey(0), driver.Value{Data: stale}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Get(stateKey(0)); !errors.Is(err, encrypted.ErrUnknownKey) {
+		t.Fatalf("got error %v, want %v", err, encrypted.ErrUnknownKey)
+	}
+}
+
+func TestKeyring(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db")
+
+	raw := newTestDriver(t, path)
+	db, err := encrypted.Open(raw, path, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put(stateKey(0), driver.Value{Data: []byte("value")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.ChangePassword("new password"); err != nil {
+		t.Fatal(err)
+	}
+	if err := raw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	raw = newTestDriver(t, path)
+	defer raw.Close()
+
+	if _, err := encrypted.Open(raw, path, "password"); !errors.Is(err, encrypted.ErrInvalidPassword) {
+		t.Fatalf("got error %v, want %v", err, encrypted.ErrInvalidPassword)
+	}
+	db, err = encrypted.Open(raw, path, "new password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := db.Get(stateKey(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(v) != "value" {
+		t.Fatalf("got value %q, want %q", v, "value")
+	}
+}
+
+func TestUnencryptedData(t *testing.T) {
+	raw := newTestDriver(t, "")
+	defer raw.Close()
+
+	if err := raw.Put(stateKey(0), driver.Value{Data: []byte("value")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := encrypted.Open(raw, "", "password"); !errors.Is(err, encrypted.ErrUnencryptedData) {
+		t.Fatalf("got error %v, want %v", err, encrypted.ErrUnencryptedData)
+	}
+}
+
+func TestCursorTamperedValue(t *testing.T) {
+	raw := newTestDriver(t, "")
+	defer raw.Close()
+
+	db, err := encrypted.Open(raw, "", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := db.Put(stateKey(i), driver.Value{Data: []byte(fmt.Sprint(i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sealed, err := raw.Get(stateKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed[len(sealed)-1]++
+	if err := raw.Put(stateKey(1), driver.Value{Data: sealed}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the cursor stops at the value it cannot decrypt and returns the error
+	c := db.Search(driver.Query{Prefix: driver.Key{}})
+	defer c.Close()
+	var values []string
+	for ok := c.Valid(); ok; ok = c.Next() {
+		v, err := driver.ReadValue(c)
+		if err != nil {
+			break
+		}
+		values = append(values, string(v))
+	}
+	if len(values) != 1 || values[0] != "0" {
+		t.Fatalf("got values %q, want only the first one", values)
+	}
+	if c.Valid() || c.Next() {
+		t.Fatal("cursor valid after a value failed to decrypt")
+	}
+	if err := c.Error(); !errors.Is(err, encrypted.ErrMalformedValue) {
+		t.Fatalf("got error %v, want %v", err, encrypted.ErrMalformedValue)
+	}
+}
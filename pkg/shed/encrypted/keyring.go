@@ -0,0 +1,307 @@
+package encrypted
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	keyringVersion = 1
+
+	scryptN     = 1 << 15
+	scryptR     = 8
+	scryptP     = 1
+	scryptDKLen = 32
+
+	dataKeyLength = 32
+)
+
+var (
+	// ErrInvalidPassword is returned when the keyring cannot be opened with the given password.
+	ErrInvalidPassword = errors.New("invalid password")
+	// ErrUnknownKey is returned when a value is sealed with a key the keyring does not hold.
+	ErrUnknownKey = errors.New("unknown data key")
+)
+
+// keyringFile is the on-disk representation of a keyring. The data keys are
+// sealed with a key derived from the password, so changing the password only
+// rewrites this file.
+type keyringFile struct {
+	Version int         `json:"version"`
+	KDF     kdfParams   `json:"kdf"`
+	Active  uint32      `json:"active"`
+	Keys    []sealedKey `json:"keys"`
+}
+
+type kdfParams struct {
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt []byte `json:"salt"`
+}
+
+type sealedKey struct {
+	ID    uint32 `json:"id"`
+	Nonce []byte `json:"nonce"`
+	Key   []byte `json:"key"`
+}
+
+// Keyring holds the data keys of an encrypted database. One of the keys is
+// active and seals new values, the others are kept until every value sealed
+// with them has been re-encrypted. Retired keys are no longer stored but stay
+// in memory until Forget is called, so that readers opened before the
+// re-encryption can still open the values they see.
+type Keyring struct {
+	mu       sync.RWMutex
+	path     string
+	password string
+	active   uint32
+	keys     map[uint32]cipher.AEAD
+	raw      map[uint32][]byte
+	retired  map[uint32]cipher.AEAD
+}
+
+// NewKeyring creates a keyring with a single random data key and stores it
+// at path. An empty path keeps the keyring in memory only.
+func NewKeyring(path, password string) (*Keyring, error) {
+	k := &Keyring{
+		path:     path,
+		password: password,
+		keys:     make(map[uint32]cipher.AEAD),
+		raw:      make(map[uint32][]byte),
+		retired:  make(map[uint32]cipher.AEAD),
+	}
+	if err := k.add(1); err != nil {
+		return nil, err
+	}
+	if err := k.save(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// LoadKeyring opens the keyring stored at path with the password. It returns
+// an error wrapping os.ErrNotExist if there is no keyring at path.
+func LoadKeyring(path, password string) (*Keyring, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f keyringFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("decode keyring: %w", err)
+	}
+	if f.Version != keyringVersion {
+		return nil, fmt.Errorf("unsupported keyring version: %d", f.Version)
+	}
+	kek, err := keyEncryptionKey(password, f.KDF)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &Keyring{
+		path:     path,
+		password: password,
+		active:   f.Active,
+		keys:     make(map[uint32]cipher.AEAD),
+		raw:      make(map[uint32][]byte),
+		retired:  make(map[uint32]cipher.AEAD),
+	}
+	for _, sk := range f.Keys {
+		key, err := kek.Open(nil, sk.Nonce, sk.Key, keyID(sk.ID))
+		if err != nil {
+			return nil, ErrInvalidPassword
+		}
+		if err := k.set(sk.ID, key); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := k.keys[k.active]; !ok {
+		return nil, fmt.Errorf("active key %d: %w", k.active, ErrUnknownKey)
+	}
+	return k, nil
+}
+
+// ChangePassword seals the data keys with a key derived from the new password.
+func (k *Keyring) ChangePassword(password string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	old := k.password
+	k.password = password
+	if err := k.save(); err != nil {
+		k.password = old
+		return err
+	}
+	return nil
+}
+
+// Rotate adds a new data key and makes it the active one.
+func (k *Keyring) Rotate() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	var next uint32
+	for id := range k.keys {
+		if id > next {
+			next = id
+		}
+	}
+	if err := k.add(next + 1); err != nil {
+		return err
+	}
+	return k.save()
+}
+
+// Retire drops every data key but the active one from the stored keyring. It
+// must only be called once no value is sealed with the retired keys any more.
+// The retired keys can still open values until Forget is called.
+func (k *Keyring) Retire() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for id, a := range k.keys {
+		if id != k.active {
+			k.retired[id] = a
+			delete(k.keys, id)
+			delete(k.raw, id)
+		}
+	}
+	return k.save()
+}
+
+// Forget drops the retired data keys from memory.
+func (k *Keyring) Forget() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.retired = make(map[uint32]cipher.AEAD)
+}
+
+// Len returns the number of data keys in the keyring.
+func (k *Keyring) Len() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.keys)
+}
+
+// Active returns the identifier of the active data key.
+func (k *Keyring) Active() uint32 {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.active
+}
+
+func (k *Keyring) activeKey() (uint32, cipher.AEAD) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.active, k.keys[k.active]
+}
+
+func (k *Keyring) key(id uint32) (cipher.AEAD, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	a, ok := k.keys[id]
+	if !ok {
+		a, ok = k.retired[id]
+	}
+	return a, ok
+}
+
+// add generates a data key with the given identifier and activates it.
+func (k *Keyring) add(id uint32) error {
+	key := make([]byte, dataKeyLength)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return fmt.Errorf("read random data: %w", err)
+	}
+	if err := k.set(id, key); err != nil {
+		return err
+	}
+	k.active = id
+	return nil
+}
+
+func (k *Keyring) set(id uint32, key []byte) error {
+	a, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	k.keys[id] = a
+	k.raw[id] = key
+	return nil
+}
+
+// save writes the keyring sealed with the current password. The file is
+// replaced atomically so that a crash never leaves a partial keyring behind.
+func (k *Keyring) save() error {
+	if k.path == "" {
+		return nil
+	}
+	params := kdfParams{N: scryptN, R: scryptR, P: scryptP, Salt: make([]byte, 32)}
+	if _, err := io.ReadFull(rand.Reader, params.Salt); err != nil {
+		return fmt.Errorf("read random data: %w", err)
+	}
+	kek, err := keyEncryptionKey(k.password, params)
+	if err != nil {
+		return err
+	}
+
+	f := keyringFile{
+		Version: keyringVersion,
+		KDF:     params,
+		Active:  k.active,
+	}
+	for id, key := range k.raw {
+		nonce := make([]byte, kek.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return fmt.Errorf("read random data: %w", err)
+		}
+		f.Keys = append(f.Keys, sealedKey{
+			ID:    id,
+			Nonce: nonce,
+			Key:   kek.Seal(nil, nonce, key, keyID(id)),
+		})
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	tmp := k.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, k.path)
+}
+
+func keyEncryptionKey(password string, p kdfParams) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(password), p.Salt, p.N, p.R, p.P, scryptDKLen)
+	if err != nil {
+		return nil, err
+	}
+	return newAEAD(key)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func keyID(id uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, id)
+	return b
+}
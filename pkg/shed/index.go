@@ -464,8 +464,12 @@ func (f Index) itemFromIterator(it driver.Cursor, totalPrefix []byte) (i Item, e
 	if err != nil {
 		return i, fmt.Errorf("decode key: %w", err)
 	}
+	value, err := driver.ReadValue(it)
+	if err != nil {
+		return i, fmt.Errorf("read value: %w", err)
+	}
 	// create a copy of value byte slice not to share database underlaying slice array
-	valueItem, err := f.decodeValueFunc(keyItem, append([]byte(nil), it.Value()...))
+	valueItem, err := f.decodeValueFunc(keyItem, append([]byte(nil), value...))
 	if err != nil {
 		return i, fmt.Errorf("decode value: %w", err)
 	}
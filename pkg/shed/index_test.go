@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/FavorLabs/favorX/pkg/shed/driver"
+	"github.com/FavorLabs/favorX/pkg/shed/encrypted"
 )
 
 // Index functions for the index that is used in tests in this file.
@@ -1544,3 +1545,55 @@ func TestIndex_HasMulti(t *testing.T) {
 		t.Errorf("got %v, want %v", got, want)
 	}
 }
+
+// TestIndex_undecryptableValue validates that the values an encrypted
+// database cannot decrypt fail the reads and iterations of the Index instead
+// of reaching the decoders.
+func TestIndex_undecryptableValue(t *testing.T) {
+	var path string
+	if TestDriver == "wiredtiger" {
+		path = t.TempDir()
+	}
+	db, err := NewDB(path, &Options{Password: "password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	index, err := db.NewIndex("retrieval", retrievalIndexFuncs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, address := range []string{"hash-1", "hash-2"} {
+		if err := index.Put(Item{Address: []byte(address), Data: []byte("DATA")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// tamper with the sealed value of the first item
+	raw := db.backend.(*encrypted.DB).BatchDB
+	key := driver.Key{Prefix: indexKeyPrefixLength, Data: append(append([]byte(nil), index.prefix...), "hash-1"...)}
+	sealed, err := raw.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed[len(sealed)-1]++
+	if err := raw.Put(key, driver.Value{Data: sealed}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := index.First(nil); !errors.Is(err, encrypted.ErrMalformedValue) {
+		t.Fatalf("got error %v getting the first item, want %v", err, encrypted.ErrMalformedValue)
+	}
+	var count int
+	err = index.Iterate(func(item Item) (stop bool, err error) {
+		count++
+		return false, nil
+	}, nil)
+	if !errors.Is(err, encrypted.ErrMalformedValue) {
+		t.Fatalf("got error %v iterating, want %v", err, encrypted.ErrMalformedValue)
+	}
+	if count != 0 {
+		t.Fatalf("got %d items iterated, want none", count)
+	}
+}
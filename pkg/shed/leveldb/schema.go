@@ -64,6 +64,7 @@ func (l *LevelDB) CreateField(spec driver.FieldSpec) ([]byte, error) {
 			if f.Type != spec.Type {
 				return nil, fmt.Errorf("field %q of type %q stored as %q in db", spec.Name, spec.Type, f.Type)
 			}
+			found = true
 			break
 		}
 	}
@@ -0,0 +1,390 @@
+package shed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/FavorLabs/favorX/pkg/shed/driver"
+)
+
+// DefaultMigrateBatchSize is the number of entries written to the target
+// database in a single batch by Migrate.
+const DefaultMigrateBatchSize = 1000
+
+// ErrTargetNotEmpty is returned by Migrate if the target database holds data.
+var ErrTargetNotEmpty = errors.New("target database is not empty")
+
+// MigrateResult holds the number of migrated fields, indexes and entries.
+// The entries include the state entries.
+type MigrateResult struct {
+	Fields  int
+	Indexes int
+	State   uint64
+	Entries uint64
+}
+
+// space is a part of the key space, a field, an index or the state entries,
+// together with its key prefix in the source and in the target database.
+type space struct {
+	name     string
+	src, dst driver.Key
+	count    uint64
+	checksum [sha256.Size]byte
+}
+
+// Migrate copies the schema, the fields, the indexes and the state entries of
+// the src database to the empty dst database, which may be opened with a
+// different driver. As the drivers assign their own key prefixes, every key is
+// moved from the prefix of its field or index in src to the one in dst. State
+// entries, the ones the statestore keeps under keys without a prefix, are
+// copied as they are. The entries are written in batches of batchSize and the
+// dst database is verified against the counts and checksums of the copied
+// entries.
+func Migrate(src, dst driver.BatchDB, batchSize int) (r MigrateResult, err error) {
+	if batchSize <= 0 {
+		batchSize = DefaultMigrateBatchSize
+	}
+	if err := src.InitSchema(); err != nil {
+		return r, fmt.Errorf("init source schema: %w", err)
+	}
+	if err := dst.InitSchema(); err != nil {
+		return r, fmt.Errorf("init target schema: %w", err)
+	}
+	spec, err := src.GetSchemaSpec()
+	if err != nil {
+		return r, fmt.Errorf("get source schema: %w", err)
+	}
+	if err := checkEmpty(dst); err != nil {
+		return r, err
+	}
+	srcSpaces, err := dbSpaces(src)
+	if err != nil {
+		return r, fmt.Errorf("get source schema: %w", err)
+	}
+
+	fields := &space{
+		name: "fields",
+		src:  driver.Key{Prefix: len(src.DefaultFieldKey()), Data: src.DefaultFieldKey()},
+		dst:  driver.Key{Prefix: len(dst.DefaultFieldKey()), Data: dst.DefaultFieldKey()},
+	}
+	// field keys, the vector ones too, start with the key of their field
+	fieldKeys := make(map[string][]byte, len(spec.Fields))
+	for _, f := range spec.Fields {
+		sk, err := src.CreateField(f)
+		if err != nil {
+			return r, fmt.Errorf("source field %s: %w", f.Name, err)
+		}
+		dk, err := dst.CreateField(f)
+		if err != nil {
+			return r, fmt.Errorf("target field %s: %w", f.Name, err)
+		}
+		fieldKeys[string(sk)] = dk
+		r.Fields++
+	}
+	fieldKey := func(k []byte) []byte {
+		var match []byte
+		for sk := range fieldKeys {
+			if bytes.HasPrefix(k, []byte(sk)) && len(sk) > len(match) {
+				match = []byte(sk)
+			}
+		}
+		if match == nil {
+			return append(append([]byte(nil), fields.dst.Data...), k[len(fields.src.Data):]...)
+		}
+		return append(append([]byte(nil), fieldKeys[string(match)]...), k[len(match):]...)
+	}
+
+	spaces := []*space{fields}
+	for _, i := range spec.Indexes {
+		p, err := dst.CreateIndex(driver.IndexSpec{Name: i.Name})
+		if err != nil {
+			return r, fmt.Errorf("target index %s: %w", i.Name, err)
+		}
+		spaces = append(spaces, &space{
+			name: i.Name,
+			src:  driver.Key{Prefix: len(src.DefaultIndexKey()), Data: i.Prefix},
+			dst:  driver.Key{Prefix: len(dst.DefaultIndexKey()), Data: p},
+		})
+		r.Indexes++
+	}
+
+	state := &space{name: "state"}
+	n, err := state.copyState(src, dst, srcSpaces, batchSize)
+	if err != nil {
+		return r, fmt.Errorf("copy state: %w", err)
+	}
+	r.State = n
+	r.Entries += n
+
+	for _, s := range spaces {
+		mapKey := func(k []byte) []byte {
+			return append(append([]byte(nil), s.dst.Data...), k[len(s.src.Data):]...)
+		}
+		if s == fields {
+			mapKey = fieldKey
+		}
+		n, err := s.copy(src, dst, batchSize, mapKey)
+		if err != nil {
+			return r, fmt.Errorf("copy %s: %w", s.name, err)
+		}
+		r.Entries += n
+	}
+
+	for _, s := range spaces {
+		if err := s.verify(dst); err != nil {
+			return r, fmt.Errorf("verify %s: %w", s.name, err)
+		}
+	}
+	if err := state.verifyState(dst); err != nil {
+		return r, fmt.Errorf("verify state: %w", err)
+	}
+	return r, nil
+}
+
+// dbSpaces returns the key prefixes of the fields and the indexes of the database.
+func dbSpaces(db driver.BatchDB) ([]driver.Key, error) {
+	spec, err := db.GetSchemaSpec()
+	if err != nil {
+		return nil, err
+	}
+	spaces := []driver.Key{{Prefix: len(db.DefaultFieldKey()), Data: db.DefaultFieldKey()}}
+	for _, i := range spec.Indexes {
+		spaces = append(spaces, driver.Key{Prefix: len(db.DefaultIndexKey()), Data: i.Prefix})
+	}
+	return spaces, nil
+}
+
+// checkEmpty returns ErrTargetNotEmpty if the database holds fields, index
+// or state entries.
+func checkEmpty(db driver.BatchDB) error {
+	prefixes, err := dbSpaces(db)
+	if err != nil {
+		return fmt.Errorf("get target schema: %w", err)
+	}
+	var found bool
+	stop := func(_, _ []byte) error {
+		found = true
+		return errStopIteration
+	}
+	for _, p := range prefixes {
+		if err := iterate(db, p, stop); err != nil {
+			return err
+		}
+		if found {
+			return ErrTargetNotEmpty
+		}
+	}
+	if err := iterateState(db, prefixes, stop); err != nil {
+		return err
+	}
+	if found {
+		return ErrTargetNotEmpty
+	}
+	return nil
+}
+
+// copy writes the entries of the space from src to dst under the keys
+// returned by mapKey and records their count and checksum.
+func (s *space) copy(src, dst driver.BatchDB, batchSize int, mapKey func([]byte) []byte) (uint64, error) {
+	b := dst.NewBatch()
+	pending := 0
+	err := iterate(src, s.src, func(k, v []byte) error {
+		dk := mapKey(k)
+		if err := b.Put(driver.Key{Prefix: s.dst.Prefix, Data: dk}, driver.Value{Data: v}); err != nil {
+			return err
+		}
+		s.add(dk, v)
+		pending++
+		if pending == batchSize {
+			if err := b.Commit(); err != nil {
+				return err
+			}
+			b = dst.NewBatch()
+			pending = 0
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if pending > 0 {
+		if err := b.Commit(); err != nil {
+			return 0, err
+		}
+	}
+	return s.count, nil
+}
+
+// copyState writes the state entries from src to dst and records their count
+// and checksum. The keys are kept as they are.
+func (s *space) copyState(src, dst driver.BatchDB, srcSpaces []driver.Key, batchSize int) (uint64, error) {
+	b := dst.NewBatch()
+	pending := 0
+	err := iterateState(src, srcSpaces, func(k, v []byte) error {
+		if err := b.Put(driver.Key{Data: append([]byte(nil), k...)}, driver.Value{Data: v}); err != nil {
+			return err
+		}
+		s.add(k, v)
+		pending++
+		if pending == batchSize {
+			if err := b.Commit(); err != nil {
+				return err
+			}
+			b = dst.NewBatch()
+			pending = 0
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if pending > 0 {
+		if err := b.Commit(); err != nil {
+			return 0, err
+		}
+	}
+	return s.count, nil
+}
+
+// verify compares the entries of the space in dst with the copied ones.
+func (s *space) verify(dst driver.BatchDB) error {
+	got := space{}
+	err := iterate(dst, s.dst, func(k, v []byte) error {
+		got.add(k, v)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.compare(&got)
+}
+
+// verifyState compares the state entries in dst with the copied ones.
+func (s *space) verifyState(dst driver.BatchDB) error {
+	spaces, err := dbSpaces(dst)
+	if err != nil {
+		return err
+	}
+	got := space{}
+	err = iterateState(dst, spaces, func(k, v []byte) error {
+		got.add(k, v)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.compare(&got)
+}
+
+// compare checks the count and the checksum of the entries found in the
+// target database against the copied ones.
+func (s *space) compare(got *space) error {
+	if got.count != s.count {
+		return fmt.Errorf("got %d entries, want %d", got.count, s.count)
+	}
+	if got.checksum != s.checksum {
+		return errors.New("checksum mismatch")
+	}
+	return nil
+}
+
+// add counts the entry and combines its hash into the checksum. The hashes
+// are combined so that the checksum does not depend on the order of keys.
+func (s *space) add(k, v []byte) {
+	h := sha256.New()
+	var l [8]byte
+	binary.BigEndian.PutUint64(l[:], uint64(len(k)))
+	h.Write(l[:])
+	h.Write(k)
+	h.Write(v)
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	for i := range s.checksum {
+		s.checksum[i] ^= sum[i]
+	}
+	s.count++
+}
+
+var errStopIteration = errors.New("stop iteration")
+
+// iterate calls fn for every entry with a key that starts with the prefix.
+// Cursors of some drivers are positioned near the prefix and do not stop at
+// its end, so the keys are checked here.
+func iterate(db driver.BatchDB, prefix driver.Key, fn func(k, v []byte) error) error {
+	c := db.Search(driver.Query{Prefix: prefix, MatchPrefix: true})
+	defer c.Close()
+	for ok := c.Valid(); ok; ok = c.Next() {
+		k := c.Key()
+		if !bytes.HasPrefix(k, prefix.Data) {
+			if bytes.Compare(k, prefix.Data) < 0 {
+				continue
+			}
+			break
+		}
+		v, err := driver.ReadValue(c)
+		if err != nil {
+			return err
+		}
+		if err := fn(k, v); err != nil {
+			if errors.Is(err, errStopIteration) {
+				return nil
+			}
+			return err
+		}
+	}
+	return c.Error()
+}
+
+// iterateState calls fn for every state entry. Some drivers keep the state
+// entries in the key space of the fields and indexes, so the keys the fields
+// and indexes hold are skipped, as well as the keys starting with a zero byte
+// these drivers keep their schema under. The keys are checked before the
+// value is read, as the schema is not sealed by the encryption layer.
+func iterateState(db driver.BatchDB, spaces []driver.Key, fn func(k, v []byte) error) error {
+	c := db.Search(driver.Query{Prefix: driver.Key{}})
+	defer c.Close()
+	for ok := c.Valid(); ok; ok = c.Next() {
+		k := c.Key()
+		if len(k) == 0 || k[0] == 0 {
+			continue
+		}
+		skip, err := inSpace(db, spaces, k)
+		if err != nil {
+			return err
+		}
+		if skip {
+			continue
+		}
+		v, err := driver.ReadValue(c)
+		if err != nil {
+			return err
+		}
+		if err := fn(k, v); err != nil {
+			if errors.Is(err, errStopIteration) {
+				return nil
+			}
+			return err
+		}
+	}
+	return c.Error()
+}
+
+// inSpace reports whether the key is held by one of the fields or indexes.
+func inSpace(db driver.BatchDB, spaces []driver.Key, k []byte) (bool, error) {
+	for _, p := range spaces {
+		if !bytes.HasPrefix(k, p.Data) {
+			continue
+		}
+		has, err := db.Has(driver.Key{Prefix: p.Prefix, Data: k})
+		if err != nil {
+			return false, err
+		}
+		if has {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,159 @@
+package shed
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/FavorLabs/favorX/pkg/shed/bbolt"
+	"github.com/FavorLabs/favorX/pkg/shed/driver"
+	"github.com/FavorLabs/favorX/pkg/shed/leveldb"
+)
+
+// TestMigrate validates that fields, indexes and state entries are copied to a
+// database of another driver, with index prefixes that differ from the source
+// ones.
+func TestMigrate(t *testing.T) {
+	src, err := leveldb.Driver{}.Open("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcDB, err := NewDBWrap(src.(driver.BatchDB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srcDB.Close()
+
+	name, err := srcDB.NewStringField("name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := name.Put("migrated"); err != nil {
+		t.Fatal(err)
+	}
+	// the key of this field is a prefix of the vector one
+	vec, err := srcDB.NewUint64Vector("name-vector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(0); i < 10; i++ {
+		if err := vec.Put(i, i*i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, err := srcDB.NewIndex("first", retrievalIndexFuncs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := srcDB.NewIndex("second", retrievalIndexFuncs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const count = 2500
+	for i := 0; i < count; i++ {
+		item := Item{Address: []byte(fmt.Sprintf("address-%04d", i)), Data: []byte(fmt.Sprint(i)), StoreTimestamp: int64(i)}
+		if err := first.Put(item); err != nil {
+			t.Fatal(err)
+		}
+		if i%2 == 0 {
+			if err := second.Put(item); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	const stateCount = 3
+	for i := 0; i < stateCount; i++ {
+		if err := src.(driver.BatchDB).Put(driver.Key{Data: []byte(fmt.Sprintf("state-%d", i))}, driver.Value{Data: []byte(fmt.Sprint(i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dst, err := bbolt.Driver{}.Open(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dstDB, err := NewDBWrap(dst.(driver.BatchDB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dstDB.Close()
+	// shift the prefixes of the migrated indexes
+	if _, err := dstDB.NewIndex("second", retrievalIndexFuncs); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Migrate(src.(driver.BatchDB), dst.(driver.BatchDB), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := MigrateResult{Fields: 2, Indexes: 2, State: stateCount, Entries: 1 + 10 + count + count/2 + stateCount}
+	if r != want {
+		t.Fatalf("got result %+v, want %+v", r, want)
+	}
+
+	if _, err := Migrate(src.(driver.BatchDB), dst.(driver.BatchDB), 100); !errors.Is(err, ErrTargetNotEmpty) {
+		t.Fatalf("got error %v, want %v", err, ErrTargetNotEmpty)
+	}
+
+	for i := 0; i < stateCount; i++ {
+		v, err := dst.(driver.BatchDB).Get(driver.Key{Data: []byte(fmt.Sprintf("state-%d", i))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(v) != fmt.Sprint(i) {
+			t.Errorf("got state value %q at %d", v, i)
+		}
+	}
+
+	name, err = dstDB.NewStringField("name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := name.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "migrated" {
+		t.Errorf("got field value %q, want %q", got, "migrated")
+	}
+	vec, err = dstDB.NewUint64Vector("name-vector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(0); i < 10; i++ {
+		v, err := vec.Get(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != i*i {
+			t.Errorf("got vector value %d at %d, want %d", v, i, i*i)
+		}
+	}
+	for _, tc := range []struct {
+		name  string
+		count int
+	}{
+		{"first", count},
+		{"second", count / 2},
+	} {
+		index, err := dstDB.NewIndex(tc.name, retrievalIndexFuncs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := index.Count()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c != tc.count {
+			t.Errorf("index %s: got %d items, want %d", tc.name, c, tc.count)
+		}
+		item, err := index.Get(Item{Address: []byte("address-0042")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(item.Data) != "42" || item.StoreTimestamp != 42 {
+			t.Errorf("index %s: got item %+v", tc.name, item)
+		}
+	}
+}
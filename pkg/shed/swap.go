@@ -0,0 +1,79 @@
+package shed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Rename moves a database, or a file that belongs to it, as part of a swap.
+type Rename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Swap renames the paths in order. The renames are recorded in the journal
+// before the first one is done, so that CompleteSwap finishes them if the
+// process stops in between. A destination path may only exist if an earlier
+// rename moves it away.
+func Swap(journal string, renames []Rename) error {
+	moved := make(map[string]bool)
+	for _, r := range renames {
+		if _, err := os.Stat(r.To); err == nil && !moved[r.To] {
+			return fmt.Errorf("swap: %s already exists", r.To)
+		}
+		moved[r.From] = true
+	}
+	b, err := json.Marshal(renames)
+	if err != nil {
+		return err
+	}
+	tmp := journal + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, journal); err != nil {
+		return err
+	}
+	return CompleteSwap(journal)
+}
+
+// CompleteSwap does the renames recorded in the journal that are not done yet
+// and removes the journal. A rename is done if its destination exists: the
+// destinations either did not exist when the swap started or were moved away
+// by an earlier rename. It does nothing if there is no journal.
+func CompleteSwap(journal string) error {
+	b, err := os.ReadFile(journal)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var renames []Rename
+	if err := json.Unmarshal(b, &renames); err != nil {
+		return fmt.Errorf("decode swap journal: %w", err)
+	}
+	for _, r := range renames {
+		if _, err := os.Stat(r.To); err == nil {
+			continue
+		}
+		if err := os.Rename(r.From, r.To); err != nil {
+			return fmt.Errorf("swap: %w", err)
+		}
+	}
+	return os.Remove(journal)
+}
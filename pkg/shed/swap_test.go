@@ -0,0 +1,82 @@
+package shed_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/FavorLabs/favorX/pkg/shed"
+)
+
+func TestSwap(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+	for _, name := range []string{"db", "db.migrate"} {
+		if err := os.WriteFile(path(name), []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	renames := []shed.Rename{
+		{From: path("db"), To: path("db.backup")},
+		{From: path("db.migrate"), To: path("db")},
+	}
+	journal := path("journal")
+
+	if err := shed.Swap(journal, renames); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, path("db"), "db.migrate")
+	checkFile(t, path("db.backup"), "db")
+	if _, err := os.Stat(journal); !os.IsNotExist(err) {
+		t.Fatalf("journal not removed: %v", err)
+	}
+
+	if err := shed.Swap(journal, renames); err == nil {
+		t.Fatal("swapped to existing paths")
+	}
+}
+
+// TestCompleteSwap validates that an interrupted swap is finished from its
+// journal without redoing the renames that are done.
+func TestCompleteSwap(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+	// the first rename is done, the second one is not
+	for _, name := range []string{"db.backup", "db.migrate"} {
+		if err := os.WriteFile(path(name), []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	journal := path("journal")
+	if err := os.WriteFile(journal, []byte(`[{"from":"`+path("db")+`","to":"`+path("db.backup")+`"},{"from":"`+path("db.migrate")+`","to":"`+path("db")+`"}]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := shed.CompleteSwap(journal); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, path("db"), "db.migrate")
+	checkFile(t, path("db.backup"), "db.backup")
+	if _, err := os.Stat(journal); !os.IsNotExist(err) {
+		t.Fatalf("journal not removed: %v", err)
+	}
+
+	if err := shed.CompleteSwap(journal); err != nil {
+		t.Fatalf("complete without a journal: %v", err)
+	}
+}
+
+func checkFile(t *testing.T, path, want string) {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != want {
+		t.Fatalf("%s: got %q, want %q", path, b, want)
+	}
+}
@@ -1,5 +1,5 @@
-//go:build !leveldb
-// +build !leveldb
+//go:build !leveldb && !bbolt
+// +build !leveldb,!bbolt
 
 package shed
 
@@ -376,6 +376,16 @@ func (c *cursor) value() ([]byte, error) {
 	return C.GoBytes(item.data, C.int(item.size)), nil
 }
 
+// next moves the cursor to the next record, or to the first one after a reset.
+func (c *cursor) next() error {
+	result := int(C.wiredtiger_cursor_next(c.impl))
+	if checkError(result) {
+		return NewError(result, c.s)
+	}
+
+	return nil
+}
+
 func (c *cursor) reset() error {
 	result := int(C.wiredtiger_cursor_reset(c.impl))
 	if checkError(result) {
@@ -1,7 +1,9 @@
 package wiredtiger
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
 
 	"github.com/FavorLabs/favorX/pkg/shed/driver"
 )
@@ -98,9 +100,63 @@ func (db *DB) InitSchema() error {
 	schema.Fields = make([]driver.FieldSpec, 0)
 	schema.Indexes = make([]driver.IndexSpec, 0)
 
+	err = loadSchema(s)
+	if err != nil {
+		_ = s.close()
+		return err
+	}
+
 	return s.close()
 }
 
+// loadSchema reads the fields and indexes created by previous runs from
+// the metadata table, so that the schema spec is complete before they are
+// created again.
+func loadSchema(s *session) error {
+	c, err := s.openCursor(dataSource{dataType: tableSource, sourceName: schemaMetadataTableName}, nil)
+	if err != nil {
+		return err
+	}
+	defer s.closeCursor(c)
+
+	for {
+		err := c.next()
+		if IsNotFound(err) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		key, err := c.key()
+		if err != nil {
+			return err
+		}
+		value, err := c.value()
+		if err != nil {
+			return err
+		}
+		switch {
+		case bytes.HasPrefix(key, []byte(fieldMetadataKeyPrefix)):
+			schema.Fields = append(schema.Fields, driver.FieldSpec{
+				Name: string(key[len(fieldMetadataKeyPrefix):]),
+				Type: string(value),
+			})
+		case bytes.HasPrefix(key, []byte(indexMetadataKeyPrefix)):
+			schema.Indexes = append(schema.Indexes, driver.IndexSpec{
+				Name:   string(key[len(indexMetadataKeyPrefix):]),
+				Prefix: value,
+			})
+		}
+	}
+
+	// the prefix of a new index follows the one of the last index
+	sort.Slice(schema.Indexes, func(i, j int) bool {
+		return bytes.Compare(schema.Indexes[i].Prefix, schema.Indexes[j].Prefix) < 0
+	})
+
+	return nil
+}
+
 func (db *DB) DefaultFieldKey() []byte {
 	return []byte{fieldKeyPrefix}
 }
@@ -116,6 +172,7 @@ func (db *DB) CreateField(spec driver.FieldSpec) ([]byte, error) {
 			if f.Type != spec.Type {
 				return nil, fmt.Errorf("field %q of type %q stored as %q in db", spec.Name, spec.Type, f.Type)
 			}
+			found = true
 			break
 		}
 	}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/FavorLabs/favorX/pkg/logging"
 	"github.com/FavorLabs/favorX/pkg/shed/driver"
+	"github.com/FavorLabs/favorX/pkg/shed/encrypted"
 	"github.com/FavorLabs/favorX/pkg/shed/leveldb"
 	"github.com/FavorLabs/favorX/pkg/storage"
 	ldberr "github.com/syndtr/goleveldb/leveldb/errors"
@@ -69,6 +70,47 @@ func NewStateStore(path string, l logging.Logger) (storage.StateStorer, error) {
 	return s, nil
 }
 
+// NewEncryptedStateStore creates a new persistent state storage which keeps
+// its values encrypted at rest with data keys sealed by the password.
+func NewEncryptedStateStore(path, password string, l logging.Logger) (storage.StateStorer, error) {
+	db, err := leveldbDriver.Open(path, "{\"NoSync\":true}")
+	if err != nil {
+		return nil, err
+	}
+
+	edb, err := encrypted.Open(db.(driver.BatchDB), path, password)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	s := &store{
+		db:     edb,
+		logger: l,
+	}
+
+	if err := migrate(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// NewStateStoreWithDB creates a new persistent state storage on a database
+// opened with another shed driver, which may be encrypted too.
+func NewStateStoreWithDB(db driver.BatchDB, l logging.Logger) (storage.StateStorer, error) {
+	s := &store{
+		db:     db,
+		logger: l,
+	}
+
+	if err := migrate(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
 func migrate(s *store) error {
 	sn, err := s.getSchemaName()
 	if err != nil {
@@ -49,18 +49,22 @@ const (
 	flagTimeout       = 5 * time.Minute  // how long before blocking a flagged peer
 	blockDuration     = time.Hour        // how long to blacklist an unresponsive peer for
 	blockWorkerWakeup = time.Second * 15 // wake-up interval for the blocker worker
+
+	DefaultBinMaxPeers = 20 // every k bucket max connes unless configured
 )
 
 var (
-	nnLowWatermark              = 3  // the number of peers in consecutive deepest bins that constitute as nearest neighbours
-	quickSaturationPeers        = 4  // cale depth
-	saturationPeers             = 8  // active connected neighbor max
-	overSaturationPeers         = 20 // every k bucket max connes
+	nnLowWatermark              = 3                  // the number of peers in consecutive deepest bins that constitute as nearest neighbours
+	quickSaturationPeers        = 4                  // cale depth
+	saturationPeers             = 8                  // active connected neighbor max
+	overSaturationPeers         = DefaultBinMaxPeers // every k bucket max connes
 	bootNodeOverSaturationPeers = 20
 	shortRetry                  = 30 * time.Second
 	timeToRetry                 = 2 * shortRetry
 	broadcastBinSize            = 4
 	peerPingPollTime            = blockWorkerWakeup // how often to ping a peer
+
+	saturationMu sync.RWMutex // guards the saturation peers once a kademlia is running
 )
 
 var (
@@ -147,24 +151,20 @@ func New(
 	o Options,
 ) (*Kad, error) {
 	if o.BinMaxPeers > 0 {
-		if o.BinMaxPeers < 5 {
-			o.BinMaxPeers = 5
-		}
-		if o.BinMaxPeers%5 == 0 {
-			overSaturationPeers = o.BinMaxPeers
-		} else {
-			overSaturationPeers = o.BinMaxPeers - o.BinMaxPeers%5 + 5
-		}
-		saturationPeers = overSaturationPeers / 5 * 2
-		quickSaturationPeers = overSaturationPeers / 5
+		saturationMu.Lock()
+		setBinMaxPeers(o.BinMaxPeers)
+		saturationMu.Unlock()
 	}
-	os := overSaturationPeers
-	if o.NodeMode.IsBootNode() && os < bootNodeOverSaturationPeers {
-		os = bootNodeOverSaturationPeers
+	oversaturation := func() int {
+		_, _, os := saturation()
+		if o.NodeMode.IsBootNode() && os < bootNodeOverSaturationPeers {
+			os = bootNodeOverSaturationPeers
+		}
+		return os
 	}
 
 	if o.SaturationFunc == nil {
-		o.SaturationFunc = binSaturated(os, isStaticPeer(o.StaticNodes))
+		o.SaturationFunc = binSaturated(oversaturation, isStaticPeer(o.StaticNodes))
 	}
 	if o.BitSuffixLength == 0 {
 		o.BitSuffixLength = defaultBitSuffixLength
@@ -351,7 +351,8 @@ func (k *Kad) connectNeighbours(wg *sync.WaitGroup, peerConnChan chan<- *peerCon
 
 		// We want 'sent' equal to 'saturationPeers'
 		// in order to skip to the next bin and speed up the topology build.
-		return false, sent == saturationPeers, nil
+		_, saturated, _ := saturation()
+		return false, sent == saturated, nil
 	})
 }
 
@@ -662,7 +663,8 @@ func (k *Kad) pruneOversaturatedBins(depth uint8) {
 			return
 		}
 
-		over := overSaturationPeers + quickSaturationPeers // overSaturation +20%
+		quick, _, oversaturated := saturation()
+		over := oversaturated + quick // overSaturation +20%
 
 		binPeersCount := k.connectedPeers.BinSize(uint8(i))
 		if binPeersCount <= over {
@@ -851,7 +853,7 @@ func (k *Kad) connectBootNodes(ctx context.Context) {
 // binSaturated indicates whether a certain bin is saturated or not.
 // when a bin is not saturated it means we would like to proactively
 // initiate connections to other peers in the bin.
-func binSaturated(oversaturationAmount int, staticNode staticPeerFunc) binSaturationFunc {
+func binSaturated(oversaturation func() int, staticNode staticPeerFunc) binSaturationFunc {
 	return func(bin uint8, peers, connected *pslice.PSlice, filter peerFilterFunc) (bool, bool) {
 		potentialDepth := recalcDepth(peers, boson.MaxPO, filter)
 
@@ -875,7 +877,8 @@ func binSaturated(oversaturationAmount int, staticNode staticPeerFunc) binSatura
 			return false, false, nil
 		})
 
-		return size >= saturationPeers, size >= oversaturationAmount
+		_, saturated, _ := saturation()
+		return size >= saturated, size >= oversaturation()
 	}
 }
 
@@ -893,6 +896,7 @@ func recalcDepth(peers *pslice.PSlice, radius uint8, filter peerFilterFunc) uint
 
 	shallowestUnsaturated := uint8(0)
 	binCount := 0
+	quick, _, _ := saturation()
 	_ = peers.EachBinRev(func(addr boson.Address, bin uint8) (bool, bool, error) {
 		if filter(addr) {
 			return false, false, nil
@@ -901,7 +905,7 @@ func recalcDepth(peers *pslice.PSlice, radius uint8, filter peerFilterFunc) uint
 			binCount++
 			return false, false, nil
 		}
-		if bin > shallowestUnsaturated && binCount < quickSaturationPeers {
+		if bin > shallowestUnsaturated && binCount < quick {
 			// this means we have less than quickSaturationPeers in the previous bin
 			// therefore we can return assuming that bin is the unsaturated one.
 			return true, false, nil
@@ -1541,7 +1545,42 @@ func (k *Kad) IsBalanced(bin uint8) bool {
 }
 
 func (k *Kad) IsSaturated(bin uint8) bool {
-	return k.connectedPeers.BinSize(bin) >= saturationPeers
+	_, saturated, _ := saturation()
+	return k.connectedPeers.BinSize(bin) >= saturated
+}
+
+// SetBinMaxPeers changes the maximum number of peers connected in a bin. A
+// non-positive n restores the default.
+func (k *Kad) SetBinMaxPeers(n int) {
+	if n <= 0 {
+		n = DefaultBinMaxPeers
+	}
+	saturationMu.Lock()
+	setBinMaxPeers(n)
+	saturationMu.Unlock()
+	k.notifyManageLoop()
+}
+
+// setBinMaxPeers derives the saturation peers from the maximum number of
+// peers in a bin. The caller must hold saturationMu.
+func setBinMaxPeers(n int) {
+	if n < 5 {
+		n = 5
+	}
+	if n%5 == 0 {
+		overSaturationPeers = n
+	} else {
+		overSaturationPeers = n - n%5 + 5
+	}
+	saturationPeers = overSaturationPeers / 5 * 2
+	quickSaturationPeers = overSaturationPeers / 5
+}
+
+// saturation returns the quick, the regular and the over saturation peers.
+func saturation() (quick, saturated, over int) {
+	saturationMu.RLock()
+	defer saturationMu.RUnlock()
+	return quickSaturationPeers, saturationPeers, overSaturationPeers
 }
 
 func (k *Kad) SetRadius(r uint8) {
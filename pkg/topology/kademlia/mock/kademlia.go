@@ -54,10 +54,7 @@ func (m *Mock) GetPeersWithLatencyEWMA(list []boson.Address) (now []boson.Addres
 	panic("implement me")
 }
 
-func (m *Mock) RefreshProtectPeer(peer []boson.Address) {
-	// TODO implement me
-	panic("implement me")
-}
+func (m *Mock) RefreshProtectPeer(peer []boson.Address) {}
 
 func NewMockKademlia(o ...Option) *Mock {
 	m := &Mock{}